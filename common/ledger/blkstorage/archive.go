@@ -0,0 +1,252 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+var archivedBlockfilesInfoKey = []byte("archivedBlockfilesInfo")
+
+// BlockfileArchiver moves the complete block files of a ledger to an external storage, such as an object store,
+// and fetches them back when an archived block is requested. An implementation for a specific object store
+// (S3, GCS, Azure blob etc.) maps a (ledgerID, fileNum) pair to an object name of its choice. The only built-in
+// implementation is `DirArchiver`; the peer uses an object store only via a filesystem mount of the store
+type BlockfileArchiver interface {
+	// Archive copies the block file present at the given path into the archive. The block file is removed
+	// from the local disk only after this function returns without an error
+	Archive(ledgerID string, fileNum int, filePath string) error
+	// Retrieve writes the contents of an archived block file to the given writer
+	Retrieve(ledgerID string, fileNum int, w io.Writer) error
+}
+
+// DirArchiver is a `BlockfileArchiver` that keeps the archived block files in a directory. The directory
+// is typically a mount of a cheaper, larger storage than the one used for the peer file system
+type DirArchiver struct {
+	dir string
+}
+
+// NewDirArchiver constructs a `DirArchiver` that archives the block files under the given directory
+func NewDirArchiver(dir string) *DirArchiver {
+	return &DirArchiver{dir: dir}
+}
+
+// Archive implements the function in the interface `BlockfileArchiver`
+func (a *DirArchiver) Archive(ledgerID string, fileNum int, filePath string) error {
+	ledgerDir := filepath.Join(a.dir, ledgerID)
+	if _, err := fileutil.CreateDirIfMissing(ledgerDir); err != nil {
+		return errors.WithMessagef(err, "error while creating the archive dir [%s]", ledgerDir)
+	}
+	src, err := os.Open(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "error while opening block file [%s]", filePath)
+	}
+	defer src.Close()
+
+	archivedFilePath := deriveBlockfilePath(ledgerDir, fileNum)
+	tempFilePath := archivedFilePath + ".tmp"
+	dst, err := os.Create(tempFilePath)
+	if err != nil {
+		return errors.Wrapf(err, "error while creating file [%s]", tempFilePath)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return errors.Wrapf(err, "error while copying block file [%s] to [%s]", filePath, tempFilePath)
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return errors.Wrapf(err, "error while syncing file [%s]", tempFilePath)
+	}
+	if err := dst.Close(); err != nil {
+		return errors.Wrapf(err, "error while closing file [%s]", tempFilePath)
+	}
+	if err := os.Rename(tempFilePath, archivedFilePath); err != nil {
+		return errors.Wrapf(err, "error while renaming file [%s] to [%s]", tempFilePath, archivedFilePath)
+	}
+	return fileutil.SyncDir(ledgerDir)
+}
+
+// Retrieve implements the function in the interface `BlockfileArchiver`
+func (a *DirArchiver) Retrieve(ledgerID string, fileNum int, w io.Writer) error {
+	archivedFilePath := deriveBlockfilePath(filepath.Join(a.dir, ledgerID), fileNum)
+	f, err := os.Open(archivedFilePath)
+	if err != nil {
+		return errors.Wrapf(err, "error while opening archived block file [%s]", archivedFilePath)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return errors.Wrapf(err, "error while reading archived block file [%s]", archivedFilePath)
+	}
+	return nil
+}
+
+// archivedBlockfilesInfo records the highest block file number that is moved to the archive. All the block
+// files up to this number are expected to be present in the archive
+type archivedBlockfilesInfo struct {
+	lastArchivedFileNum int
+}
+
+func (i *archivedBlockfilesInfo) marshal() ([]byte, error) {
+	buffer := proto.NewBuffer([]byte{})
+	if err := buffer.EncodeVarint(uint64(i.lastArchivedFileNum)); err != nil {
+		return nil, errors.Wrapf(err, "error encoding the lastArchivedFileNum [%d]", i.lastArchivedFileNum)
+	}
+	return buffer.Bytes(), nil
+}
+
+func (i *archivedBlockfilesInfo) unmarshal(b []byte) error {
+	val, err := proto.NewBuffer(b).DecodeVarint()
+	if err != nil {
+		return err
+	}
+	i.lastArchivedFileNum = int(val)
+	return nil
+}
+
+func (mgr *blockfileMgr) loadArchivedBlockfilesInfo() (*archivedBlockfilesInfo, error) {
+	b, err := mgr.db.Get(archivedBlockfilesInfoKey)
+	if b == nil || err != nil {
+		return nil, err
+	}
+	i := &archivedBlockfilesInfo{}
+	if err := i.unmarshal(b); err != nil {
+		return nil, errors.Wrap(err, "error while unmarshalling archived block files info")
+	}
+	return i, nil
+}
+
+func (mgr *blockfileMgr) getArchivedBlockfilesInfo() *archivedBlockfilesInfo {
+	i, _ := mgr.archivedBlockfilesInfo.Load().(*archivedBlockfilesInfo)
+	return i
+}
+
+func (mgr *blockfileMgr) isArchived(fileNum int) bool {
+	i := mgr.getArchivedBlockfilesInfo()
+	return i != nil && fileNum <= i.lastArchivedFileNum
+}
+
+// archiveBlockfiles moves the block files that contain only the blocks below `beforeBlock` to the archive.
+// The block file that is currently being appended to is never archived. The block files that are restored
+// from the archive by an earlier retrieval are removed from the local disk again
+func (mgr *blockfileMgr) archiveBlockfiles(beforeBlock uint64) error {
+	if mgr.conf.archiver == nil {
+		return errors.New("no block file archiver is configured for the block store")
+	}
+	bcInfo := mgr.getBlockchainInfo()
+	if bcInfo.Height == 0 || beforeBlock > bcInfo.Height-1 {
+		return errors.Errorf(
+			"cannot archive blocks before block [%d]. The block store height = [%d], the last block cannot be archived",
+			beforeBlock, bcInfo.Height,
+		)
+	}
+	if beforeBlock < mgr.firstAvailableBlockNumber() {
+		beforeBlock = mgr.firstAvailableBlockNumber()
+	}
+	loc, err := mgr.index.getBlockLocByBlockNum(beforeBlock)
+	if err != nil {
+		return errors.WithMessagef(err, "error while retrieving the location of block [%d]", beforeBlock)
+	}
+
+	mgr.archiveLock.Lock()
+	defer mgr.archiveLock.Unlock()
+
+	firstFileNum := 0
+	if i := mgr.getArchivedBlockfilesInfo(); i != nil {
+		firstFileNum = i.lastArchivedFileNum + 1
+	}
+	if i := mgr.getPrunedBlocksInfo(); i != nil && i.fileSuffixNum > firstFileNum {
+		firstFileNum = i.fileSuffixNum
+	}
+	lastFileNum := loc.fileSuffixNum - 1
+	for fileNum := firstFileNum; fileNum <= lastFileNum; fileNum++ {
+		filePath := deriveBlockfilePath(mgr.rootDir, fileNum)
+		if err := mgr.conf.archiver.Archive(mgr.ledgerID, fileNum, filePath); err != nil {
+			return errors.WithMessagef(err, "error while archiving block file [%s]", filePath)
+		}
+		logger.Debugf("Archived block file [%s]", filePath)
+	}
+
+	if lastFileNum >= firstFileNum {
+		i := &archivedBlockfilesInfo{lastArchivedFileNum: lastFileNum}
+		b, err := i.marshal()
+		if err != nil {
+			return err
+		}
+		if err := mgr.db.Put(archivedBlockfilesInfoKey, b, true); err != nil {
+			return errors.WithMessage(err, "error while saving archived block files info")
+		}
+		mgr.archivedBlockfilesInfo.Store(i)
+	}
+
+	// the local files are deleted after the archived block files info is saved. In the event of a crash in
+	// between, the files are deleted in the next invocation of archive. A file restored from the archive may
+	// still be open for reading, which is fine because the reader keeps the deleted file accessible
+	i := mgr.getArchivedBlockfilesInfo()
+	if i == nil {
+		return nil
+	}
+	for fileNum := 0; fileNum <= i.lastArchivedFileNum; fileNum++ {
+		filePath := deriveBlockfilePath(mgr.rootDir, fileNum)
+		err := os.Remove(filePath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error while deleting block file [%s]", filePath)
+		}
+		logger.Debugf("Deleted archived block file [%s] from local disk", filePath)
+	}
+	logger.Infof("Archived block files up to block file number [%d]", i.lastArchivedFileNum)
+	return nil
+}
+
+// restoreIfArchived copies an archived block file back to its location on the local disk, if the file
+// is not already present there
+func (mgr *blockfileMgr) restoreIfArchived(fileNum int) error {
+	if !mgr.isArchived(fileNum) {
+		return nil
+	}
+	filePath := deriveBlockfilePath(mgr.rootDir, fileNum)
+	if _, err := os.Stat(filePath); err == nil {
+		return nil
+	}
+
+	mgr.archiveLock.Lock()
+	defer mgr.archiveLock.Unlock()
+	if _, err := os.Stat(filePath); err == nil {
+		return nil
+	}
+	if mgr.conf.archiver == nil {
+		return errors.Errorf("block file number [%d] is archived but no block file archiver is configured", fileNum)
+	}
+	tempFilePath := filePath + ".restore" + strconv.Itoa(os.Getpid())
+	f, err := os.Create(tempFilePath)
+	if err != nil {
+		return errors.Wrapf(err, "error while creating file [%s]", tempFilePath)
+	}
+	if err := mgr.conf.archiver.Retrieve(mgr.ledgerID, fileNum, f); err != nil {
+		f.Close()
+		os.Remove(tempFilePath)
+		return errors.WithMessagef(err, "error while retrieving archived block file number [%d]", fileNum)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tempFilePath)
+		return errors.Wrapf(err, "error while closing file [%s]", tempFilePath)
+	}
+	if err := os.Rename(tempFilePath, filePath); err != nil {
+		return errors.Wrapf(err, "error while renaming file [%s] to [%s]", tempFilePath, filePath)
+	}
+	logger.Debugf("Restored block file [%s] from the archive", filePath)
+	return nil
+}
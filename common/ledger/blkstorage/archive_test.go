@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveBlocks(t *testing.T) {
+	path := t.TempDir()
+	archiveDir := t.TempDir()
+	newConf := func() *Conf {
+		conf := NewConf(path, 0)
+		conf.SetBlockfileArchiver(NewDirArchiver(archiveDir))
+		return conf
+	}
+	blocks := testutil.ConstructTestBlocks(t, 30)
+	env := newTestEnv(t, newConf())
+	defer env.Cleanup()
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	for i, b := range blocks {
+		require.NoError(t, store.AddBlock(b))
+		if i != 0 && i%10 == 0 {
+			// block ranges in files [(0, 10):file0, (11,20):file1, (21,29):file2]
+			store.fileMgr.moveToNextFile()
+		}
+	}
+
+	assertArchivedFileExists := func(fileNum int, expectedExists bool) {
+		_, err := os.Stat(deriveBlockfilePath(filepath.Join(archiveDir, "testLedger"), fileNum))
+		if expectedExists {
+			require.NoError(t, err)
+			return
+		}
+		require.True(t, os.IsNotExist(err))
+	}
+
+	t.Run("cannot-archive-beyond-last-block", func(t *testing.T) {
+		require.EqualError(t, store.ArchiveBlocks(30),
+			"cannot archive blocks before block [30]. The block store height = [30], the last block cannot be archived",
+		)
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		require.NoError(t, store.ArchiveBlocks(15))
+		assertBlockFileExists(t, store, 0, false)
+		assertBlockFileExists(t, store, 1, true)
+		assertArchivedFileExists(0, true)
+		assertArchivedFileExists(1, false)
+
+		// the archived blocks are fetched back transparently
+		assertBlocksPruned(t, store, blocks, 0)
+		assertBlockFileExists(t, store, 0, true)
+
+		// the next archive removes the restored file from the local disk again
+		require.NoError(t, store.ArchiveBlocks(15))
+		assertBlockFileExists(t, store, 0, false)
+	})
+
+	t.Run("iterator-crosses-archived-files", func(t *testing.T) {
+		require.NoError(t, store.ArchiveBlocks(25))
+		assertBlockFileExists(t, store, 0, false)
+		assertBlockFileExists(t, store, 1, false)
+
+		itr, err := store.RetrieveBlocks(5)
+		require.NoError(t, err)
+		defer itr.Close()
+		for i := 5; i < 30; i++ {
+			b, err := itr.Next()
+			require.NoError(t, err)
+			require.Equal(t, blocks[i], b)
+		}
+	})
+
+	t.Run("archive-survives-restart", func(t *testing.T) {
+		require.NoError(t, store.ArchiveBlocks(25))
+		env.provider.Close()
+		env = newTestEnv(t, newConf())
+		store, err = env.provider.Open("testLedger")
+		require.NoError(t, err)
+		assertBlocksPruned(t, store, blocks, 0)
+
+		bcInfo, err := store.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, uint64(30), bcInfo.Height)
+	})
+
+	t.Run("archive-after-prune", func(t *testing.T) {
+		require.NoError(t, store.PruneBlocks(25))
+		require.NoError(t, store.ArchiveBlocks(25))
+		assertBlocksPruned(t, store, blocks, 25)
+	})
+}
+
+func TestArchiveBlocksWithoutArchiver(t *testing.T) {
+	env := newTestEnv(t, NewConf(t.TempDir(), 0))
+	defer env.Cleanup()
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	for _, b := range testutil.ConstructTestBlocks(t, 5) {
+		require.NoError(t, store.AddBlock(b))
+	}
+	require.EqualError(t, store.ArchiveBlocks(3), "no block file archiver is configured for the block store")
+}
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+// CopyBlockfiles copies the block files of the block store, up to the given checkpoint, under the given block
+// storage dir, in the same layout that the `BlockStoreProvider` uses. The checkpoint is expected to be obtained
+// earlier via the function `GetCheckpointInfo` and the blocks added after the checkpoint are left out of the copy,
+// so the blocks can keep being added while the files are copied. The block index is not copied and is rebuilt from
+// the copied block files when the copied block store is opened. Hence, the block store should contain all the blocks
+// starting from the genesis block; i.e., the block store should neither be bootstrapped from a snapshot nor be pruned.
+// The archived block files are fetched back from the archive for being copied
+func (store *BlockStore) CopyBlockfiles(blockStorageDir string, checkpoint *CheckpointInfo) error {
+	mgr := store.fileMgr
+	if mgr.bootstrappingSnapshotInfo != nil {
+		return errors.Errorf("cannot copy the block files of ledger [%s] as it is bootstrapped from a snapshot", store.id)
+	}
+	if n := mgr.firstAvailableBlockNumber(); n > 0 {
+		return errors.Errorf("cannot copy the block files of ledger [%s] as the blocks below block [%d] are pruned", store.id, n)
+	}
+
+	targetDir := filepath.Join(blockStorageDir, ChainsDir, store.id)
+	isEmpty, err := fileutil.CreateDirIfMissing(targetDir)
+	if err != nil {
+		return errors.WithMessagef(err, "error while creating dir [%s]", targetDir)
+	}
+	if !isEmpty {
+		return errors.Errorf("dir [%s] is not empty", targetDir)
+	}
+	if err := recordCompression(targetDir, mgr.compression); err != nil {
+		return err
+	}
+	if checkpoint.NoBlockFiles {
+		return nil
+	}
+	for fileNum := 0; fileNum <= checkpoint.LatestFileNumber; fileNum++ {
+		size := int64(-1)
+		if fileNum == checkpoint.LatestFileNumber {
+			size = int64(checkpoint.LatestFileSize)
+		}
+		if err := mgr.copyBlockfile(fileNum, deriveBlockfilePath(targetDir, fileNum), size); err != nil {
+			return err
+		}
+	}
+	return fileutil.SyncDir(targetDir)
+}
+
+// copyBlockfile copies the block file to the given path. A negative size copies the complete file; otherwise,
+// only the first `size` bytes are copied
+func (mgr *blockfileMgr) copyBlockfile(fileNum int, targetPath string, size int64) error {
+	if err := mgr.restoreIfArchived(fileNum); err != nil {
+		return err
+	}
+	// the file is opened under the archive lock so that a concurrent archive does not remove a restored
+	// file before it is opened. Once opened, the file stays readable even if it is removed later
+	filePath := deriveBlockfilePath(mgr.rootDir, fileNum)
+	mgr.archiveLock.Lock()
+	src, err := os.Open(filePath)
+	mgr.archiveLock.Unlock()
+	if err != nil {
+		return errors.Wrapf(err, "error while opening block file [%s]", filePath)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(targetPath)
+	if err != nil {
+		return errors.Wrapf(err, "error while creating file [%s]", targetPath)
+	}
+	if size < 0 {
+		_, err = io.Copy(dst, src)
+	} else {
+		_, err = io.CopyN(dst, src, size)
+	}
+	if err != nil {
+		dst.Close()
+		return errors.Wrapf(err, "error while copying block file [%s] to [%s]", filePath, targetPath)
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return errors.Wrapf(err, "error while syncing file [%s]", targetPath)
+	}
+	return errors.Wrapf(dst.Close(), "error while closing file [%s]", targetPath)
+}
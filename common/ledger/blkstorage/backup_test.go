@@ -0,0 +1,63 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyBlockfiles(t *testing.T) {
+	env := newTestEnv(t, NewConf(t.TempDir(), 0))
+	defer env.Cleanup()
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+
+	blocks := testutil.ConstructTestBlocks(t, 30)
+	for i, b := range blocks[:20] {
+		require.NoError(t, store.AddBlock(b))
+		if i != 0 && i%10 == 0 {
+			store.fileMgr.moveToNextFile()
+		}
+	}
+	checkpoint := store.GetCheckpointInfo()
+	// the blocks added after the checkpoint are not copied
+	for _, b := range blocks[20:] {
+		require.NoError(t, store.AddBlock(b))
+	}
+
+	targetDir := t.TempDir()
+	require.NoError(t, store.CopyBlockfiles(targetDir, checkpoint))
+	require.EqualError(t, store.CopyBlockfiles(targetDir, checkpoint),
+		"dir ["+NewConf(targetDir, 0).getLedgerBlockDir("testLedger")+"] is not empty",
+	)
+
+	copyEnv := newTestEnv(t, NewConf(targetDir, 0))
+	defer copyEnv.Cleanup()
+	copiedStore, err := copyEnv.provider.Open("testLedger")
+	require.NoError(t, err)
+	bcInfo, err := copiedStore.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), bcInfo.Height)
+	for i := 0; i < 20; i++ {
+		b, err := copiedStore.RetrieveBlockByNumber(uint64(i))
+		require.NoError(t, err)
+		require.Equal(t, blocks[i], b)
+	}
+
+	// the copied block store accepts the next blocks
+	require.NoError(t, copiedStore.AddBlock(blocks[20]))
+
+	t.Run("pruned-block-store", func(t *testing.T) {
+		require.NoError(t, store.PruneBlocks(15))
+		require.EqualError(t, store.CopyBlockfiles(t.TempDir(), store.GetCheckpointInfo()),
+			"cannot copy the block files of ledger [testLedger] as the blocks below block [15] are pruned",
+		)
+	})
+}
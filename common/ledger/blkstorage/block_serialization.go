@@ -21,8 +21,9 @@ type serializedBlockInfo struct {
 
 // The order of the transactions must be maintained for history
 type txindexInfo struct {
-	txID string
-	loc  *locPointer
+	txID       string
+	loc        *locPointer
+	txEnvelope []byte
 }
 
 func serializeBlock(block *common.Block) ([]byte, *serializedBlockInfo, error) {
@@ -43,6 +44,66 @@ func serializeBlock(block *common.Block) ([]byte, *serializedBlockInfo, error) {
 	return buf.Bytes(), info, nil
 }
 
+// BlockCodec encodes the blocks for storing in the block files and decodes them back. When no codec is configured,
+// the blocks are stored in the protobuf encoding. As the transactions cannot be located within the bytes produced
+// by a codec, retrieving a transaction decodes the entire block that contains it. The offline rollback and reset of
+// the block store support only the default encoding
+type BlockCodec interface {
+	Marshal(block *common.Block) ([]byte, error)
+	Unmarshal(b []byte) (*common.Block, error)
+}
+
+// encodeBlock returns the bytes to be stored in the block files for the block, using the codec if not nil,
+// and the info for indexing the block. The info is always computed from the block and not from the encoded bytes
+func encodeBlock(block *common.Block, codec BlockCodec) ([]byte, *serializedBlockInfo, error) {
+	blockBytes, info, err := serializeBlock(block)
+	if err != nil || codec == nil {
+		return blockBytes, info, err
+	}
+	if blockBytes, err = codec.Marshal(block); err != nil {
+		return nil, nil, errors.WithMessage(err, "error encoding block with the block codec")
+	}
+	resetTxLocs(info)
+	return blockBytes, info, nil
+}
+
+// decodeBlock decodes the bytes read from the block files, using the codec if not nil
+func decodeBlock(blockBytes []byte, codec BlockCodec) (*common.Block, error) {
+	if codec == nil {
+		return deserializeBlock(blockBytes)
+	}
+	block, err := codec.Unmarshal(blockBytes)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error decoding block with the block codec")
+	}
+	return block, nil
+}
+
+// extractBlockInfo returns the info for indexing the block from the bytes read from the block files
+func extractBlockInfo(blockBytes []byte, codec BlockCodec) (*serializedBlockInfo, error) {
+	if codec == nil {
+		return extractSerializedBlockInfo(blockBytes)
+	}
+	block, err := decodeBlock(blockBytes, codec)
+	if err != nil {
+		return nil, err
+	}
+	_, info, err := serializeBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	resetTxLocs(info)
+	return info, nil
+}
+
+// resetTxLocs points the location of each transaction to the start of the block, as the location of
+// a transaction within the bytes encoded by a codec is not known
+func resetTxLocs(info *serializedBlockInfo) {
+	for _, txOffset := range info.txOffsets {
+		txOffset.loc = &locPointer{}
+	}
+}
+
 func deserializeBlock(serializedBlockBytes []byte) (*common.Block, error) {
 	block := &common.Block{}
 	var err error
@@ -108,7 +169,7 @@ func addDataBytesAndConstructTxIndexInfo(blockData *common.BlockData, buf *proto
 		if err := buf.EncodeRawBytes(txEnvelopeBytes); err != nil {
 			return nil, errors.Wrap(err, "error encoding the transaction envelope")
 		}
-		idxInfo := &txindexInfo{txID: txid, loc: &locPointer{offset, len(buf.Bytes()) - offset}}
+		idxInfo := &txindexInfo{txID: txid, loc: &locPointer{offset, len(buf.Bytes()) - offset}, txEnvelope: txEnvelopeBytes}
 		txOffsets = append(txOffsets, idxInfo)
 	}
 	return txOffsets, nil
@@ -169,7 +230,7 @@ func extractData(buf *buffer) (*common.BlockData, []*txindexInfo, error) {
 				err)
 		}
 		data.Data = append(data.Data, txEnvBytes)
-		idxInfo := &txindexInfo{txID: txid, loc: &locPointer{txOffset, buf.GetBytesConsumed() - txOffset}}
+		idxInfo := &txindexInfo{txID: txid, loc: &locPointer{txOffset, buf.GetBytesConsumed() - txOffset}, txEnvelope: txEnvBytes}
 		txOffsets = append(txOffsets, idxInfo)
 	}
 	return data, txOffsets, nil
@@ -38,6 +38,9 @@ type blockStream struct {
 	currentFileNum    int
 	endFileNum        int
 	currentFileStream *blockfileStream
+	// restoreFile, when set, is invoked before opening the next block file for making sure that
+	// the file is present on the local disk
+	restoreFile func(fileNum int) error
 }
 
 // blockPlacementInfo captures the information related
@@ -151,7 +154,7 @@ func newBlockStream(rootDir string, startFileNum int, startOffset int64, endFile
 	if err != nil {
 		return nil, err
 	}
-	return &blockStream{rootDir, startFileNum, endFileNum, startFileStream}, nil
+	return &blockStream{rootDir, startFileNum, endFileNum, startFileStream, nil}, nil
 }
 
 func (s *blockStream) moveToNextBlockfileStream() error {
@@ -160,6 +163,11 @@ func (s *blockStream) moveToNextBlockfileStream() error {
 		return err
 	}
 	s.currentFileNum++
+	if s.restoreFile != nil {
+		if err = s.restoreFile(s.currentFileNum); err != nil {
+			return err
+		}
+	}
 	if s.currentFileStream, err = newBlockfileStream(s.rootDir, s.currentFileNum, 0); err != nil {
 		return err
 	}
@@ -23,7 +23,7 @@ import (
 // constructBlockfilesInfo scans the last blockfile (if any) and construct the blockfilesInfo
 // if the last file contains no block or only a partially written block (potentially because of a crash while writing block to the file),
 // this scans the second last file (if any)
-func constructBlockfilesInfo(rootDir string) (*blockfilesInfo, error) {
+func constructBlockfilesInfo(rootDir string, codec BlockCodec) (*blockfilesInfo, error) {
 	logger.Debugf("constructing BlockfilesInfo")
 	var lastFileNum int
 	var numBlocksInFile int
@@ -68,7 +68,7 @@ func constructBlockfilesInfo(rootDir string) (*blockfilesInfo, error) {
 	}
 
 	if lastBlockBytes != nil {
-		if lastBlock, err = deserializeBlock(lastBlockBytes); err != nil {
+		if lastBlock, err = decodeBlock(lastBlockBytes, codec); err != nil {
 			logger.Errorf("Error deserializing last block: %s. Block bytes length: %d", err, len(lastBlockBytes))
 			return nil, err
 		}
@@ -89,7 +89,7 @@ func constructBlockfilesInfo(rootDir string) (*blockfilesInfo, error) {
 // This function assumes that the caller invokes this function with a block number that has been committed
 // For any uncommitted block, this function returns the last file present
 func binarySearchFileNumForBlock(rootDir string, blockNum uint64) (int, error) {
-	blkfilesInfo, err := constructBlockfilesInfo(rootDir)
+	blkfilesInfo, err := constructBlockfilesInfo(rootDir, nil)
 	if err != nil {
 		return -1, err
 	}
@@ -99,7 +99,7 @@ func binarySearchFileNumForBlock(rootDir string, blockNum uint64) (int, error) {
 
 	for endFile != beginFile {
 		searchFile := beginFile + (endFile-beginFile)/2 + 1
-		n, err := retrieveFirstBlockNumFromFile(rootDir, searchFile)
+		n, err := retrieveFirstBlockNumFromFile(rootDir, searchFile, nil)
 		if err != nil {
 			return -1, err
 		}
@@ -115,7 +115,7 @@ func binarySearchFileNumForBlock(rootDir string, blockNum uint64) (int, error) {
 	return beginFile, nil
 }
 
-func retrieveFirstBlockNumFromFile(rootDir string, fileNum int) (uint64, error) {
+func retrieveFirstBlockNumFromFile(rootDir string, fileNum int, codec BlockCodec) (uint64, error) {
 	s, err := newBlockfileStream(rootDir, fileNum, 0)
 	if err != nil {
 		return 0, err
@@ -125,7 +125,7 @@ func retrieveFirstBlockNumFromFile(rootDir string, fileNum int) (uint64, error)
 	if err != nil {
 		return 0, err
 	}
-	blockInfo, err := extractSerializedBlockInfo(bb)
+	blockInfo, err := extractBlockInfo(bb, codec)
 	if err != nil {
 		return 0, err
 	}
@@ -218,3 +218,26 @@ func GetLedgersBootstrappedFromSnapshot(blockStorageDir string) ([]string, error
 
 	return ledgersFromSnapshot, nil
 }
+
+// GetLedgersWithPrunedBlocks returns the IDs of the ledgers whose blocks have been pruned from the block store.
+// As in the case of a ledger bootstrapped from a snapshot, the databases of such a ledger cannot be rebuilt from
+// the blocks
+func GetLedgersWithPrunedBlocks(blockStorageDir string) ([]string, error) {
+	chainsDir := filepath.Join(blockStorageDir, ChainsDir)
+	ledgerIDs, err := fileutil.ListSubdirs(chainsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	prunedLedgers := []string{}
+	for _, ledgerID := range ledgerIDs {
+		i, err := loadPrunedBlocksInfo(filepath.Join(chainsDir, ledgerID))
+		if err != nil {
+			return nil, err
+		}
+		if i != nil {
+			prunedLedgers = append(prunedLedgers, ledgerID)
+		}
+	}
+	return prunedLedgers, nil
+}
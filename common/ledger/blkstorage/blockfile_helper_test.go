@@ -27,7 +27,7 @@ func TestConstructBlockfilesInfo(t *testing.T) {
 	defer env.Cleanup()
 
 	// constructBlockfilesInfo on an empty block folder should return blockfileInfo with noBlockFiles: true
-	blkfilesInfo, err := constructBlockfilesInfo(blkStoreDir)
+	blkfilesInfo, err := constructBlockfilesInfo(blkStoreDir, nil)
 	require.NoError(t, err)
 	require.Equal(t,
 		&blockfilesInfo{
@@ -178,7 +178,7 @@ func TestGetLedgersBootstrappedFromSnapshot(t *testing.T) {
 }
 
 func checkBlockfilesInfoFromFS(t *testing.T, blkStoreDir string, expected *blockfilesInfo) {
-	blkfilesInfo, err := constructBlockfilesInfo(blkStoreDir)
+	blkfilesInfo, err := constructBlockfilesInfo(blkStoreDir, nil)
 	require.NoError(t, err)
 	require.Equal(t, expected, blkfilesInfo)
 }
@@ -40,7 +40,15 @@ type blockfileMgr struct {
 	bootstrappingSnapshotInfo *BootstrappingSnapshotInfo
 	blkfilesInfoCond          *sync.Cond
 	currentFileWriter         *blockfileWriter
+	numUnsyncedBlocks         int
+	writers                   *writerLRU
 	bcInfo                    atomic.Value
+	prunedBlocksInfo          atomic.Value
+	ledgerID                  string
+	codec                     BlockCodec
+	compression               Compression
+	archiveLock               sync.Mutex
+	archivedBlockfilesInfo    atomic.Value
 }
 
 /*
@@ -96,7 +104,18 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *IndexConfig, indexStore
 	if err != nil {
 		panic(fmt.Sprintf("Error creating block storage root dir [%s]: %s", rootDir, err))
 	}
-	mgr := &blockfileMgr{rootDir: rootDir, conf: conf, db: indexStore}
+	compression, err := ledgerCompression(rootDir, conf.compression)
+	if err != nil {
+		return nil, err
+	}
+	mgr := &blockfileMgr{
+		rootDir:     rootDir,
+		conf:        conf,
+		db:          indexStore,
+		ledgerID:    id,
+		codec:       conf.blockCodecFor(id, compression),
+		compression: compression,
+	}
 
 	blockfilesInfo, err := mgr.loadBlkfilesInfo()
 	if err != nil {
@@ -104,7 +123,7 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *IndexConfig, indexStore
 	}
 	if blockfilesInfo == nil {
 		logger.Info(`Getting block information from block storage`)
-		if blockfilesInfo, err = constructBlockfilesInfo(rootDir); err != nil {
+		if blockfilesInfo, err = constructBlockfilesInfo(rootDir, mgr.codec); err != nil {
 			panic(fmt.Sprintf("Could not build blockfilesInfo info from block files: %s", err))
 		}
 		logger.Debugf("Info constructed by scanning the blocks dir = %s", spew.Sdump(blockfilesInfo))
@@ -135,6 +154,20 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *IndexConfig, indexStore
 		return nil, err
 	}
 	mgr.bootstrappingSnapshotInfo = bsi
+	pbi, err := loadPrunedBlocksInfo(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if pbi != nil {
+		mgr.prunedBlocksInfo.Store(pbi)
+	}
+	abi, err := mgr.loadArchivedBlockfilesInfo()
+	if err != nil {
+		return nil, err
+	}
+	if abi != nil {
+		mgr.archivedBlockfilesInfo.Store(abi)
+	}
 	mgr.currentFileWriter = currentFileWriter
 	mgr.blkfilesInfoCond = sync.NewCond(&sync.Mutex{})
 
@@ -252,9 +285,52 @@ func deriveBlockfilePath(rootDir string, suffixNum int) string {
 }
 
 func (mgr *blockfileMgr) close() {
+	if mgr.writers != nil {
+		mgr.writers.remove(mgr)
+	}
+	// the writer is nil if it was closed by an eviction
+	if mgr.currentFileWriter != nil {
+		mgr.closeWriter()
+	}
+}
+
+// closeWriter closes the writer of the current block file, after syncing the blocks appended since the last sync.
+// Other than on close, this is invoked when the writer is evicted (see writerLRU)
+func (mgr *blockfileMgr) closeWriter() {
+	if mgr.numUnsyncedBlocks > 0 {
+		if err := mgr.syncBlockfile(); err != nil {
+			logger.Errorf("Error while syncing the block file on close: %s", err)
+		}
+	}
 	mgr.currentFileWriter.close()
 }
 
+// syncBlockfile flushes the current block file to the disk and then saves the blockfilesInfo so that
+// the saved blockfilesInfo never refers to the data that is not yet on the disk. This is used only
+// when the block file is not synced after every block (see SyncMode)
+func (mgr *blockfileMgr) syncBlockfile() error {
+	if err := mgr.currentFileWriter.sync(); err != nil {
+		return err
+	}
+	if err := mgr.saveBlkfilesInfo(mgr.blockfilesInfo, true); err != nil {
+		return errors.WithMessage(err, "error saving blockfiles file info to db")
+	}
+	mgr.numUnsyncedBlocks = 0
+	return nil
+}
+
+// isSyncPoint returns true if the block file is to be synced after appending the next block
+func (mgr *blockfileMgr) isSyncPoint() bool {
+	switch mgr.conf.syncMode {
+	case SyncPerN:
+		return mgr.numUnsyncedBlocks+1 >= mgr.conf.syncEveryN
+	case SyncOnClose:
+		return false
+	default:
+		return true
+	}
+}
+
 func (mgr *blockfileMgr) moveToNextFile() {
 	blkfilesInfo := &blockfilesInfo{
 		latestFileNumber:   mgr.blockfilesInfo.latestFileNumber + 1,
@@ -267,6 +343,12 @@ func (mgr *blockfileMgr) moveToNextFile() {
 	if err != nil {
 		panic(fmt.Sprintf("Could not open writer to next file: %s", err))
 	}
+	if mgr.numUnsyncedBlocks > 0 {
+		if err := mgr.currentFileWriter.sync(); err != nil {
+			panic(fmt.Sprintf("Could not sync current file before moving to next file: %s", err))
+		}
+		mgr.numUnsyncedBlocks = 0
+	}
 	mgr.currentFileWriter.close()
 	err = mgr.saveBlkfilesInfo(blkfilesInfo, true)
 	if err != nil {
@@ -295,10 +377,16 @@ func (mgr *blockfileMgr) addBlock(block *common.Block) error {
 			bcInfo.CurrentBlockHash, block.Header.PreviousHash,
 		)
 	}
-	blockBytes, info, err := serializeBlock(block)
+	blockBytes, info, err := encodeBlock(block, mgr.codec)
 	if err != nil {
 		return errors.WithMessage(err, "error serializing block")
 	}
+	if mgr.writers != nil {
+		if err := mgr.writers.acquire(mgr); err != nil {
+			return err
+		}
+		defer mgr.writers.release(mgr)
+	}
 	blockHash := protoutil.BlockHeaderHash(block.Header)
 	// Get the location / offset where each transaction starts in the block and where the block ends
 	txOffsets := info.txOffsets
@@ -314,11 +402,12 @@ func (mgr *blockfileMgr) addBlock(block *common.Block) error {
 		mgr.moveToNextFile()
 		currentOffset = 0
 	}
+	syncPoint := mgr.isSyncPoint()
 	// append blockBytesEncodedLen to the file
 	err = mgr.currentFileWriter.append(blockBytesEncodedLen, false)
 	if err == nil {
 		// append the actual block bytes to the file
-		err = mgr.currentFileWriter.append(blockBytes, true)
+		err = mgr.currentFileWriter.append(blockBytes, syncPoint)
 	}
 	if err != nil {
 		truncateErr := mgr.currentFileWriter.truncateFile(mgr.blockfilesInfo.latestFileSize)
@@ -336,21 +425,31 @@ func (mgr *blockfileMgr) addBlock(block *common.Block) error {
 		noBlockFiles:       false,
 		lastPersistedBlock: block.Header.Number,
 	}
-	// save the blockfilesInfo in the database
-	if err = mgr.saveBlkfilesInfo(newBlkfilesInfo, false); err != nil {
-		truncateErr := mgr.currentFileWriter.truncateFile(currentBlkfilesInfo.latestFileSize)
-		if truncateErr != nil {
-			panic(fmt.Sprintf("Error in truncating current file to known size after an error in saving blockfiles info: %s", err))
+	// save the blockfilesInfo in the database. If the block file is not synced for this block, the
+	// saved blockfilesInfo is left behind at the last synced block so that, after a crash, the recovery
+	// scans the blocks beyond it and truncates the block file at the last complete block
+	if syncPoint {
+		if err = mgr.saveBlkfilesInfo(newBlkfilesInfo, false); err != nil {
+			truncateErr := mgr.currentFileWriter.truncateFile(currentBlkfilesInfo.latestFileSize)
+			if truncateErr != nil {
+				panic(fmt.Sprintf("Error in truncating current file to known size after an error in saving blockfiles info: %s", err))
+			}
+			return errors.WithMessage(err, "error saving blockfiles file info to db")
 		}
-		return errors.WithMessage(err, "error saving blockfiles file info to db")
+		mgr.numUnsyncedBlocks = 0
+	} else {
+		mgr.numUnsyncedBlocks++
 	}
 
 	// Index block file location pointer updated with file suffex and offset for the new block
 	blockFLP := &fileLocPointer{fileSuffixNum: newBlkfilesInfo.latestFileNumber}
 	blockFLP.offset = currentOffset
-	// shift the txoffset because we prepend length of bytes before block bytes
-	for _, txOffset := range txOffsets {
-		txOffset.loc.offset += len(blockBytesEncodedLen)
+	// shift the txoffset because we prepend length of bytes before block bytes. With a block codec,
+	// the transactions are located by the start of the block and are not shifted
+	if mgr.codec == nil {
+		for _, txOffset := range txOffsets {
+			txOffset.loc.offset += len(blockBytesEncodedLen)
+		}
 	}
 	// save the index in the database
 	if err = mgr.index.indexBlock(&blockIdxInfo{
@@ -386,6 +485,28 @@ func (mgr *blockfileMgr) syncIndex() error {
 		)
 	}
 
+	prunedInfo := mgr.getPrunedBlocksInfo()
+	if prunedInfo != nil && nextIndexableBlock <= prunedInfo.firstAvailableBlock {
+		// the block index of a pruned block store cannot be rebuilt, as the transaction IDs of the
+		// pruned blocks, which are needed for the detection of the duplicate transactions, are lost
+		return errors.Errorf(
+			"cannot sync index with block files. blockstore is pruned and first available block=[%d], next block to index=[%d]",
+			prunedInfo.firstAvailableBlock, nextIndexableBlock,
+		)
+	}
+
+	nextPersistableBlock := mgr.firstPossibleBlockNumberInBlockFiles()
+	if !mgr.blockfilesInfo.noBlockFiles {
+		nextPersistableBlock = mgr.blockfilesInfo.lastPersistedBlock + 1
+	}
+	if nextIndexableBlock > nextPersistableBlock {
+		// The index is synced for every block whereas the block files may be synced less frequently (see SyncMode).
+		// Hence, the index can be ahead of the block files if the unsynced blocks were lost in a crash
+		logger.Warnf("Last block indexed [%d] is not present in the block files, removing the index entries starting from block [%d]",
+			lastBlockIndexed, nextPersistableBlock)
+		return mgr.index.rollbackTo(nextPersistableBlock, mgr.blockfilesInfo)
+	}
+
 	if mgr.blockfilesInfo.noBlockFiles {
 		logger.Debug("No block files present. This happens when there has not been any blocks added to the ledger yet")
 		return nil
@@ -401,8 +522,11 @@ func (mgr *blockfileMgr) syncIndex() error {
 	skipFirstBlock := false
 	endFileNum := mgr.blockfilesInfo.latestFileNumber
 
-	firstAvailableBlkNum, err := retrieveFirstBlockNumFromFile(mgr.rootDir, 0)
-	if err != nil {
+	var firstAvailableBlkNum uint64
+	if prunedInfo != nil {
+		// the block files before the one that contains the first available block are deleted
+		firstAvailableBlkNum = prunedInfo.firstAvailableBlock
+	} else if firstAvailableBlkNum, err = retrieveFirstBlockNumFromFile(mgr.rootDir, 0, mgr.codec); err != nil {
 		return err
 	}
 
@@ -448,16 +572,18 @@ func (mgr *blockfileMgr) syncIndex() error {
 		if blockBytes == nil {
 			break
 		}
-		info, err := extractSerializedBlockInfo(blockBytes)
+		info, err := extractBlockInfo(blockBytes, mgr.codec)
 		if err != nil {
 			return err
 		}
 
 		// The blockStartOffset will get applied to the txOffsets prior to indexing within indexBlock(),
 		// therefore just shift by the difference between blockBytesOffset and blockStartOffset
-		numBytesToShift := int(blockPlacementInfo.blockBytesOffset - blockPlacementInfo.blockStartOffset)
-		for _, offset := range info.txOffsets {
-			offset.loc.offset += numBytesToShift
+		if mgr.codec == nil {
+			numBytesToShift := int(blockPlacementInfo.blockBytesOffset - blockPlacementInfo.blockStartOffset)
+			for _, offset := range info.txOffsets {
+				offset.loc.offset += numBytesToShift
+			}
 		}
 
 		// Update the blockIndexInfo with what was actually stored in file system
@@ -494,6 +620,14 @@ func (mgr *blockfileMgr) updateBlockfilesInfo(blkfilesInfo *blockfilesInfo) {
 	mgr.blkfilesInfoCond.Broadcast()
 }
 
+// getBlockfilesInfo returns the blockfilesInfo that was most recently persisted.
+// The returned value should not be modified by the caller.
+func (mgr *blockfileMgr) getBlockfilesInfo() *blockfilesInfo {
+	mgr.blkfilesInfoCond.L.Lock()
+	defer mgr.blkfilesInfoCond.L.Unlock()
+	return mgr.blockfilesInfo
+}
+
 func (mgr *blockfileMgr) updateBlockchainInfo(latestBlockHash []byte, latestBlock *common.Block) {
 	currentBCInfo := mgr.getBlockchainInfo()
 	newBCInfo := &common.BlockchainInfo{
@@ -512,6 +646,9 @@ func (mgr *blockfileMgr) retrieveBlockByHash(blockHash []byte) (*common.Block, e
 	if err != nil {
 		return nil, err
 	}
+	if err := mgr.checkLocNotPruned(loc); err != nil {
+		return nil, err
+	}
 	return mgr.fetchBlock(loc)
 }
 
@@ -522,6 +659,9 @@ func (mgr *blockfileMgr) retrieveBlockByNumber(blockNum uint64) (*common.Block,
 	if blockNum == math.MaxUint64 {
 		blockNum = mgr.getBlockchainInfo().Height - 1
 	}
+	if err := mgr.checkBlockNotPruned(blockNum); err != nil {
+		return nil, err
+	}
 	if blockNum < mgr.firstPossibleBlockNumberInBlockFiles() {
 		return nil, errors.Errorf(
 			"cannot serve block [%d]. The ledger is bootstrapped from a snapshot. First available block = [%d]",
@@ -546,6 +686,9 @@ func (mgr *blockfileMgr) retrieveBlockByTxID(txID string) (*common.Block, error)
 	if err != nil {
 		return nil, err
 	}
+	if err := mgr.checkLocNotPruned(loc); err != nil {
+		return nil, err
+	}
 	return mgr.fetchBlock(loc)
 }
 
@@ -562,6 +705,9 @@ func (mgr *blockfileMgr) retrieveTxValidationCodeByTxID(txID string) (peer.TxVal
 
 func (mgr *blockfileMgr) retrieveBlockHeaderByNumber(blockNum uint64) (*common.BlockHeader, error) {
 	logger.Debugf("retrieveBlockHeaderByNumber() - blockNum = [%d]", blockNum)
+	if err := mgr.checkBlockNotPruned(blockNum); err != nil {
+		return nil, err
+	}
 	if blockNum < mgr.firstPossibleBlockNumberInBlockFiles() {
 		return nil, errors.Errorf(
 			"cannot serve block [%d]. The ledger is bootstrapped from a snapshot. First available block = [%d]",
@@ -576,7 +722,7 @@ func (mgr *blockfileMgr) retrieveBlockHeaderByNumber(blockNum uint64) (*common.B
 	if err != nil {
 		return nil, err
 	}
-	info, err := extractSerializedBlockInfo(blockBytes)
+	info, err := extractBlockInfo(blockBytes, mgr.codec)
 	if err != nil {
 		return nil, err
 	}
@@ -584,6 +730,9 @@ func (mgr *blockfileMgr) retrieveBlockHeaderByNumber(blockNum uint64) (*common.B
 }
 
 func (mgr *blockfileMgr) retrieveBlocks(startNum uint64) (*blocksItr, error) {
+	if err := mgr.checkBlockNotPruned(startNum); err != nil {
+		return nil, err
+	}
 	if startNum < mgr.firstPossibleBlockNumberInBlockFiles() {
 		return nil, errors.Errorf(
 			"cannot serve block [%d]. The ledger is bootstrapped from a snapshot. First available block = [%d]",
@@ -608,11 +757,85 @@ func (mgr *blockfileMgr) retrieveTransactionByID(txID string) (*common.Envelope,
 	if err != nil {
 		return nil, err
 	}
+	if err := mgr.checkLocNotPruned(loc); err != nil {
+		return nil, err
+	}
+	if mgr.codec != nil {
+		return mgr.fetchTransactionEnvelopeFromBlock(loc, func(txNum int, txEnvelopeBytes []byte) (bool, error) {
+			id, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+			return id == txID, err
+		})
+	}
 	return mgr.fetchTransactionEnvelope(loc)
 }
 
+// retrieveTransactionsByIDs returns the transactions and their validation codes for the given txIDs, in the same
+// order. The locations of all the transactions are looked up in a single pass over the index and, with a block codec,
+// a block that contains more than one of the transactions is decoded only once
+func (mgr *blockfileMgr) retrieveTransactionsByIDs(txIDs []string) ([]*common.Envelope, []peer.TxValidationCode, error) {
+	logger.Debugf("retrieveTransactionsByIDs() - number of txIds = [%d]", len(txIDs))
+	vals, err := mgr.index.getTxIDVals(txIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envs := make([]*common.Envelope, len(txIDs))
+	validationCodes := make([]peer.TxValidationCode, len(txIDs))
+	blocks := map[fileLocPointer]*common.Block{}
+	for i, txID := range txIDs {
+		val := vals[txID]
+		if val == nil {
+			return nil, nil, errors.Errorf(
+				"details for the TXID [%s] not available. Ledger bootstrapped from a snapshot. First available block = [%d]",
+				txID, mgr.firstPossibleBlockNumberInBlockFiles())
+		}
+		loc := &fileLocPointer{}
+		if err := loc.unmarshal(val.TxLocation); err != nil {
+			return nil, nil, err
+		}
+		if err := mgr.checkLocNotPruned(loc); err != nil {
+			return nil, nil, err
+		}
+		validationCodes[i] = peer.TxValidationCode(val.TxValidationCode)
+		if mgr.codec == nil {
+			if envs[i], err = mgr.fetchTransactionEnvelope(loc); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		blockLoc := fileLocPointer{fileSuffixNum: loc.fileSuffixNum, locPointer: locPointer{offset: loc.offset}}
+		block, ok := blocks[blockLoc]
+		if !ok {
+			if block, err = mgr.fetchBlock(loc); err != nil {
+				return nil, nil, err
+			}
+			blocks[blockLoc] = block
+		}
+		for _, txEnvelopeBytes := range block.Data.Data {
+			id, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+			if err != nil {
+				return nil, nil, err
+			}
+			if id == txID {
+				if envs[i], err = protoutil.GetEnvelopeFromBlock(txEnvelopeBytes); err != nil {
+					return nil, nil, err
+				}
+				break
+			}
+		}
+		if envs[i] == nil {
+			return nil, nil, errors.Errorf("transaction [%s] not found in block [%d]", txID, block.Header.Number)
+		}
+	}
+	return envs, validationCodes, nil
+}
+
 func (mgr *blockfileMgr) retrieveTransactionByBlockNumTranNum(blockNum uint64, tranNum uint64) (*common.Envelope, error) {
 	logger.Debugf("retrieveTransactionByBlockNumTranNum() - blockNum = [%d], tranNum = [%d]", blockNum, tranNum)
+	if err := mgr.checkBlockNotPruned(blockNum); err != nil {
+		return nil, err
+	}
 	if blockNum < mgr.firstPossibleBlockNumberInBlockFiles() {
 		return nil, errors.Errorf(
 			"cannot serve block [%d]. The ledger is bootstrapped from a snapshot. First available block = [%d]",
@@ -623,6 +846,11 @@ func (mgr *blockfileMgr) retrieveTransactionByBlockNumTranNum(blockNum uint64, t
 	if err != nil {
 		return nil, err
 	}
+	if mgr.codec != nil {
+		return mgr.fetchTransactionEnvelopeFromBlock(loc, func(txNum int, _ []byte) (bool, error) {
+			return uint64(txNum) == tranNum, nil
+		})
+	}
 	return mgr.fetchTransactionEnvelope(loc)
 }
 
@@ -631,13 +859,36 @@ func (mgr *blockfileMgr) fetchBlock(lp *fileLocPointer) (*common.Block, error) {
 	if err != nil {
 		return nil, err
 	}
-	block, err := deserializeBlock(blockBytes)
+	block, err := decodeBlock(blockBytes, mgr.codec)
 	if err != nil {
 		return nil, err
 	}
 	return block, nil
 }
 
+// fetchTransactionEnvelopeFromBlock decodes the block at the given location and returns the first transaction for
+// which the match function returns true. This is used with a block codec, where the location of a transaction
+// refers to the start of the block that contains it
+func (mgr *blockfileMgr) fetchTransactionEnvelopeFromBlock(
+	lp *fileLocPointer,
+	match func(txNum int, txEnvelopeBytes []byte) (bool, error),
+) (*common.Envelope, error) {
+	block, err := mgr.fetchBlock(lp)
+	if err != nil {
+		return nil, err
+	}
+	for txNum, txEnvelopeBytes := range block.Data.Data {
+		matched, err := match(txNum, txEnvelopeBytes)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			return protoutil.GetEnvelopeFromBlock(txEnvelopeBytes)
+		}
+	}
+	return nil, errors.Errorf("transaction not found in block [%d]", block.Header.Number)
+}
+
 func (mgr *blockfileMgr) fetchTransactionEnvelope(lp *fileLocPointer) (*common.Envelope, error) {
 	logger.Debugf("Entering fetchTransactionEnvelope() %v\n", lp)
 	var err error
@@ -650,6 +901,9 @@ func (mgr *blockfileMgr) fetchTransactionEnvelope(lp *fileLocPointer) (*common.E
 }
 
 func (mgr *blockfileMgr) fetchBlockBytes(lp *fileLocPointer) ([]byte, error) {
+	if err := mgr.restoreIfArchived(lp.fileSuffixNum); err != nil {
+		return nil, err
+	}
 	stream, err := newBlockfileStream(mgr.rootDir, lp.fileSuffixNum, int64(lp.offset))
 	if err != nil {
 		return nil, err
@@ -663,6 +917,9 @@ func (mgr *blockfileMgr) fetchBlockBytes(lp *fileLocPointer) ([]byte, error) {
 }
 
 func (mgr *blockfileMgr) fetchRawBytes(lp *fileLocPointer) ([]byte, error) {
+	if err := mgr.restoreIfArchived(lp.fileSuffixNum); err != nil {
+		return nil, err
+	}
 	filePath := deriveBlockfilePath(mgr.rootDir, lp.fileSuffixNum)
 	reader, err := newBlockfileReader(filePath)
 	if err != nil {
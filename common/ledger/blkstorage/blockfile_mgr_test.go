@@ -234,6 +234,61 @@ func TestBlockfileMgrGetTxById(t *testing.T) {
 	}
 }
 
+func TestBlockfileMgrGetTxsByIDs(t *testing.T) {
+	blocks := testutil.ConstructTestBlocks(t, 3)
+	var txIDs []string
+	var expectedEnvs []*common.Envelope
+	for _, blk := range blocks {
+		for _, txEnvelopeBytes := range blk.Data.Data {
+			txID, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+			require.NoError(t, err)
+			txEnvelope, err := protoutil.GetEnvelopeFromBlock(txEnvelopeBytes)
+			require.NoError(t, err)
+			// the transactions are requested in the reverse order of their commit
+			txIDs = append([]string{txID}, txIDs...)
+			expectedEnvs = append([]*common.Envelope{txEnvelope}, expectedEnvs...)
+		}
+	}
+	// a txID may be requested more than once
+	txIDs = append(txIDs, txIDs[0])
+	expectedEnvs = append(expectedEnvs, expectedEnvs[0])
+
+	verify := func(t *testing.T, conf *Conf) {
+		env := newTestEnv(t, conf)
+		defer env.Cleanup()
+		blkfileMgrWrapper := newTestBlockfileWrapper(env, "testLedger")
+		defer blkfileMgrWrapper.close()
+		blkfileMgrWrapper.addBlocks(blocks)
+		mgr := blkfileMgrWrapper.blockfileMgr
+
+		envs, validationCodes, err := mgr.retrieveTransactionsByIDs(txIDs)
+		require.NoError(t, err)
+		require.Len(t, envs, len(txIDs))
+		for i := range txIDs {
+			require.True(t, proto.Equal(expectedEnvs[i], envs[i]))
+			require.Equal(t, peer.TxValidationCode_VALID, validationCodes[i])
+		}
+
+		envs, validationCodes, err = mgr.retrieveTransactionsByIDs(nil)
+		require.NoError(t, err)
+		require.Empty(t, envs)
+		require.Empty(t, validationCodes)
+
+		_, _, err = mgr.retrieveTransactionsByIDs([]string{txIDs[0], "non-existent-txid"})
+		require.EqualError(t, err, "no such transaction ID [non-existent-txid] in index")
+	}
+
+	t.Run("without-codec", func(t *testing.T) {
+		verify(t, NewConf(t.TempDir(), 0))
+	})
+
+	t.Run("with-codec", func(t *testing.T) {
+		conf := NewConf(t.TempDir(), 0)
+		conf.SetBlockCodec(&gzipBlockCodec{})
+		verify(t, conf)
+	})
+}
+
 // TestBlockfileMgrGetTxByIdDuplicateTxid tests that a transaction with an existing txid
 // (within same block or a different block) should not over-write the index by-txid (FAB-8557)
 func TestBlockfileMgrGetTxByIdDuplicateTxid(t *testing.T) {
@@ -391,6 +446,64 @@ func TestBlockfileMgrRestart(t *testing.T) {
 	require.Equal(t, expectedHeight, blkfileMgrWrapper.blockfileMgr.getBlockchainInfo().Height)
 }
 
+func TestBlockfileMgrSyncPerNCrashRecovery(t *testing.T) {
+	env := newTestEnv(t, NewConfWithSyncMode(t.TempDir(), 0, SyncPerN, 3))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blockfileMgr := blkfileMgrWrapper.blockfileMgr
+	blocks := testutil.ConstructTestBlocks(t, 10)
+
+	// the block file is synced after the blocks 2 and 5, the blocks 6 and 7 remain unsynced
+	blkfileMgrWrapper.addBlocks(blocks[:8])
+	require.Equal(t, uint64(8), blockfileMgr.getBlockchainInfo().Height)
+	require.Equal(t, 2, blockfileMgr.numUnsyncedBlocks)
+	savedBlkfilesInfo, err := blockfileMgr.loadBlkfilesInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), savedBlkfilesInfo.lastPersistedBlock)
+	filePath := blockfileMgr.currentFileWriter.filePath
+	require.Greater(t, testutilGetFileSize(t, filePath), savedBlkfilesInfo.latestFileSize)
+
+	// simulate a crash before the next sync that loses the unsynced blocks except for a few bytes of the block 6
+	require.NoError(t, os.Truncate(filePath, int64(savedBlkfilesInfo.latestFileSize+10)))
+	require.NoError(t, blockfileMgr.currentFileWriter.close())
+
+	// simulate a start after the crash
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	blockfileMgr = blkfileMgrWrapper.blockfileMgr
+	require.Equal(t, savedBlkfilesInfo, blockfileMgr.blockfilesInfo)
+	require.Equal(t, savedBlkfilesInfo.latestFileSize, testutilGetFileSize(t, filePath))
+	require.Equal(t, uint64(6), blockfileMgr.getBlockchainInfo().Height)
+	lastBlockIndexed, err := blockfileMgr.index.getLastBlockIndexed()
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), lastBlockIndexed)
+	_, err = blockfileMgr.retrieveBlockByNumber(6)
+	require.EqualError(t, err, "no such block number [6] in index")
+	_, err = blockfileMgr.retrieveBlockByHash(protoutil.BlockHeaderHash(blocks[6].Header))
+	require.EqualError(t, err, fmt.Sprintf("no such block hash [%x] in index", protoutil.BlockHeaderHash(blocks[6].Header)))
+	txID, err := protoutil.GetOrComputeTxIDFromEnvelope(blocks[7].Data.Data[0])
+	require.NoError(t, err)
+	exists, err := blockfileMgr.txIDExists(txID)
+	require.NoError(t, err)
+	require.False(t, exists)
+	_, err = blockfileMgr.retrieveTransactionByBlockNumTranNum(7, 0)
+	require.EqualError(t, err, "no such blockNumber, transactionNumber <7, 0> in index")
+
+	// the lost blocks can be added again and a graceful close syncs the remaining blocks
+	blkfileMgrWrapper.addBlocks(blocks[6:])
+	require.Equal(t, 1, blockfileMgr.numUnsyncedBlocks)
+	blkfileMgrWrapper.close()
+
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	savedBlkfilesInfo, err = blkfileMgrWrapper.blockfileMgr.loadBlkfilesInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), savedBlkfilesInfo.lastPersistedBlock)
+	require.Equal(t, uint64(10), blkfileMgrWrapper.blockfileMgr.getBlockchainInfo().Height)
+	blkfileMgrWrapper.testGetBlockByNumber(blocks)
+	testBlockfileMgrBlockIterator(t, blkfileMgrWrapper.blockfileMgr, 0, len(blocks)-1, blocks)
+}
+
 func TestBlockfileMgrFileRolling(t *testing.T) {
 	blocks := testutil.ConstructTestBlocks(t, 200)
 	size := 0
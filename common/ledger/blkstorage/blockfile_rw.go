@@ -46,6 +46,10 @@ func (w *blockfileWriter) append(b []byte, sync bool) error {
 	return nil
 }
 
+func (w *blockfileWriter) sync() error {
+	return errors.Wrapf(w.file.Sync(), "error syncing the file [%s]", w.filePath)
+}
+
 func (w *blockfileWriter) open() error {
 	file, err := os.OpenFile(w.filePath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0o660)
 	if err != nil {
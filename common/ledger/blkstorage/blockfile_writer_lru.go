@@ -0,0 +1,111 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// writerLRU bounds the number of the block stores, opened via a provider, that keep the writer of their current
+// block file open. When the limit is reached, the writer of the least recently used block store is closed, after
+// syncing the blocks appended since the last sync, if any. A closed writer is reopened on the next block append.
+// A block store is pinned while a block is being appended and a pinned block store is never evicted, which may
+// take the number of the open writers temporarily beyond the limit
+type writerLRU struct {
+	maxOpen int
+
+	mutex    sync.Mutex
+	lru      *list.List // of *writerLRUEntry, the most recently used at the front
+	elements map[*blockfileMgr]*list.Element
+}
+
+type writerLRUEntry struct {
+	mgr    *blockfileMgr
+	pinned bool
+}
+
+func newWriterLRU(maxOpen int) *writerLRU {
+	return &writerLRU{
+		maxOpen:  maxOpen,
+		lru:      list.New(),
+		elements: map[*blockfileMgr]*list.Element{},
+	}
+}
+
+// add starts tracking a block store whose writer has just been opened
+func (w *writerLRU) add(mgr *blockfileMgr) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.evictIfNeeded()
+	w.elements[mgr] = w.lru.PushFront(&writerLRUEntry{mgr: mgr})
+}
+
+// acquire pins the block store and reopens its writer, if the writer was closed by an eviction
+func (w *writerLRU) acquire(mgr *blockfileMgr) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if e, ok := w.elements[mgr]; ok {
+		e.Value.(*writerLRUEntry).pinned = true
+		w.lru.MoveToFront(e)
+		return nil
+	}
+
+	w.evictIfNeeded()
+	writer, err := newBlockfileWriter(deriveBlockfilePath(mgr.rootDir, mgr.blockfilesInfo.latestFileNumber))
+	if err != nil {
+		return errors.WithMessage(err, "error while reopening the writer of the current block file")
+	}
+	logger.Debugf("Reopened the writer of the current block file [%s]", writer.filePath)
+	mgr.currentFileWriter = writer
+	w.elements[mgr] = w.lru.PushFront(&writerLRUEntry{mgr: mgr, pinned: true})
+	return nil
+}
+
+// release unpins the block store
+func (w *writerLRU) release(mgr *blockfileMgr) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if e, ok := w.elements[mgr]; ok {
+		e.Value.(*writerLRUEntry).pinned = false
+	}
+}
+
+// remove stops tracking the block store, which is being closed
+func (w *writerLRU) remove(mgr *blockfileMgr) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if e, ok := w.elements[mgr]; ok {
+		w.lru.Remove(e)
+		delete(w.elements, mgr)
+	}
+}
+
+// evictIfNeeded closes the writers of the least recently used unpinned block stores so as to make room for one more
+// open writer. The caller is expected to hold the mutex
+func (w *writerLRU) evictIfNeeded() {
+	for e := w.lru.Back(); e != nil && w.lru.Len() >= w.maxOpen; {
+		prev := e.Prev()
+		entry := e.Value.(*writerLRUEntry)
+		if !entry.pinned {
+			entry.mgr.closeWriter()
+			entry.mgr.currentFileWriter = nil
+			w.lru.Remove(e)
+			delete(w.elements, entry.mgr)
+		}
+		e = prev
+	}
+}
+
+// numOpen returns the number of the block stores that have their writer open
+func (w *writerLRU) numOpen() int {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.lru.Len()
+}
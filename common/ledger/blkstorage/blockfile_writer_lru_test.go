@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterLRU(t *testing.T) {
+	conf := NewConfWithSyncMode(t.TempDir(), 0, SyncOnClose, 0)
+	conf.SetMaxOpenBlockStores(2)
+	env := newTestEnv(t, conf)
+	defer env.Cleanup()
+	writers := env.provider.writers
+	blocks := testutil.ConstructTestBlocks(t, 6)
+
+	w1 := newTestBlockfileWrapper(env, "ledger1")
+	defer w1.close()
+	w2 := newTestBlockfileWrapper(env, "ledger2")
+	defer w2.close()
+	w1.addBlocks(blocks[:2])
+	w2.addBlocks(blocks[:2])
+	require.Equal(t, 2, writers.numOpen())
+
+	// opening the third block store evicts the least recently used one (ledger1)
+	w3 := newTestBlockfileWrapper(env, "ledger3")
+	defer w3.close()
+	require.Equal(t, 2, writers.numOpen())
+	require.Nil(t, w1.blockfileMgr.currentFileWriter)
+	require.NotNil(t, w2.blockfileMgr.currentFileWriter)
+	require.NotNil(t, w3.blockfileMgr.currentFileWriter)
+
+	// the unsynced blocks are synced on eviction and the reads are served with the writer closed
+	require.Equal(t, 0, w1.blockfileMgr.numUnsyncedBlocks)
+	savedBlkfilesInfo, err := w1.blockfileMgr.loadBlkfilesInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), savedBlkfilesInfo.lastPersistedBlock)
+	w1.testGetBlockByNumber(blocks[:2])
+
+	// appending to ledger1 reopens its writer and evicts ledger2
+	w1.addBlocks(blocks[2:4])
+	require.Equal(t, 2, writers.numOpen())
+	require.NotNil(t, w1.blockfileMgr.currentFileWriter)
+	require.Nil(t, w2.blockfileMgr.currentFileWriter)
+	w1.testGetBlockByNumber(blocks[:4])
+	w1.testGetBlockByHash(blocks[:4])
+
+	w3.addBlocks(blocks[:1])
+	w2.addBlocks(blocks[2:6])
+	require.Equal(t, 2, writers.numOpen())
+	require.Nil(t, w1.blockfileMgr.currentFileWriter)
+	w2.testGetBlockByNumber(blocks[:6])
+	w3.testGetBlockByNumber(blocks[:1])
+
+	// the block stores reopened after close have all the blocks
+	w1.close()
+	w2.close()
+	require.Equal(t, 1, writers.numOpen())
+	w1 = newTestBlockfileWrapper(env, "ledger1")
+	defer w1.close()
+	w1.testGetBlockByNumber(blocks[:4])
+	w2 = newTestBlockfileWrapper(env, "ledger2")
+	defer w2.close()
+	w2.testGetBlockByNumber(blocks[:6])
+	require.Equal(t, 2, writers.numOpen())
+
+	t.Run("pinned-store-is-not-evicted", func(t *testing.T) {
+		w := newWriterLRU(1)
+		mgr1 := &blockfileMgr{currentFileWriter: &blockfileWriter{}}
+		w.add(mgr1)
+		require.NoError(t, w.acquire(mgr1))
+
+		mgr2 := &blockfileMgr{currentFileWriter: &blockfileWriter{}}
+		w.add(mgr2)
+		require.Equal(t, 2, w.numOpen())
+		require.NotNil(t, mgr1.currentFileWriter)
+		w.release(mgr1)
+	})
+}
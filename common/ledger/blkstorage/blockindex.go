@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"unicode/utf8"
 
 	"github.com/golang/protobuf/proto"
@@ -27,6 +28,8 @@ const (
 	blockHashIdxKeyPrefix       = 'h'
 	txIDIdxKeyPrefix            = 't'
 	blockNumTranNumIdxKeyPrefix = 'a'
+	chaincodeNameIdxKeyPrefix   = 'c'
+	endorserMSPIDIdxKeyPrefix   = 'e'
 	indexSavePointKeyStr        = "indexCheckpointKey"
 
 	snapshotFileFormat       = byte(1)
@@ -79,6 +82,88 @@ func (index *blockIndex) getLastBlockIndexed() (uint64, error) {
 	return decodeBlockNum(blockNumBytes), nil
 }
 
+// rollbackTo removes the index entries of the blocks starting from the block nextIndexableBlock and moves the
+// index save point back accordingly. This is used when the index is ahead of the block files, i.e., the blocks
+// have been lost from the block files in a crash. The block hash entries carry no block number and hence, the
+// ones that point beyond the end of the block files (as per the blkfilesInfo) are removed. As this scans the
+// entire txid and block hash indexes, this is expected to be used only during the recovery from a crash
+func (index *blockIndex) rollbackTo(nextIndexableBlock uint64, blkfilesInfo *blockfilesInfo) error {
+	batch := index.db.NewUpdateBatch()
+	deleteKeys := func(startKey, endKey []byte, isStale func(key, val []byte) (bool, error)) error {
+		itr, err := index.db.GetIterator(startKey, endKey)
+		if err != nil {
+			return err
+		}
+		defer itr.Release()
+		for itr.Next() {
+			stale, err := isStale(itr.Key(), itr.Value())
+			if err != nil {
+				return err
+			}
+			if stale {
+				batch.Delete(itr.Key())
+			}
+		}
+		return errors.Wrap(itr.Error(), "error while scanning the block index")
+	}
+	allStale := func(key, val []byte) (bool, error) {
+		return true, nil
+	}
+
+	if err := deleteKeys(
+		constructBlockNumKey(nextIndexableBlock),
+		[]byte{blockNumIdxKeyPrefix + 1},
+		allStale,
+	); err != nil {
+		return err
+	}
+	if err := deleteKeys(
+		append([]byte{blockNumTranNumIdxKeyPrefix}, util.EncodeOrderPreservingVarUint64(nextIndexableBlock)...),
+		[]byte{blockNumTranNumIdxKeyPrefix + 1},
+		allStale,
+	); err != nil {
+		return err
+	}
+	if err := deleteKeys(
+		[]byte{txIDIdxKeyPrefix},
+		[]byte{txIDIdxKeyPrefix + 1},
+		func(key, val []byte) (bool, error) {
+			txID, err := retrieveTxID(key)
+			if err != nil {
+				return false, err
+			}
+			blkNum, err := retrieveBlockNum(key, len(constructTxIDRangeScan(txID).startKey))
+			if err != nil {
+				return false, errors.WithMessage(err, "error while decoding block number from txID index key")
+			}
+			return blkNum >= nextIndexableBlock, nil
+		},
+	); err != nil {
+		return err
+	}
+	if err := deleteKeys(
+		[]byte{blockHashIdxKeyPrefix},
+		[]byte{blockHashIdxKeyPrefix + 1},
+		func(key, val []byte) (bool, error) {
+			flp := &fileLocPointer{}
+			if err := flp.unmarshal(val); err != nil {
+				return false, err
+			}
+			return flp.fileSuffixNum > blkfilesInfo.latestFileNumber ||
+				(flp.fileSuffixNum == blkfilesInfo.latestFileNumber && flp.offset >= blkfilesInfo.latestFileSize), nil
+		},
+	); err != nil {
+		return err
+	}
+
+	if nextIndexableBlock == 0 {
+		batch.Delete(indexSavePointKey)
+	} else {
+		batch.Put(indexSavePointKey, encodeBlockNum(nextIndexableBlock-1))
+	}
+	return index.db.WriteBatch(batch, true)
+}
+
 func (index *blockIndex) indexBlock(blockIdxInfo *blockIdxInfo) error {
 	// do not index anything
 	if len(index.indexItemsMap) == 0 {
@@ -146,6 +231,30 @@ func (index *blockIndex) indexBlock(blockIdxInfo *blockIdxInfo) error {
 		}
 	}
 
+	// Index5 and Index6 - Used to find the transactions by the invoked chaincode and by the endorsing organizations
+	if index.isAttributeIndexed(IndexableAttrChaincodeName) || index.isAttributeIndexed(IndexableAttrEndorserMSPID) {
+		for i, txoffset := range txOffsets {
+			attrs, err := extractTxAttrs(txoffset.txEnvelope)
+			if err != nil {
+				logger.Warningf("Not adding tx [%d] of block [%d] to the chaincode name and endorser indexes. Ignoring this error as this is caused by a malformed transaction. Error:%s",
+					i, blkNum, err)
+				continue
+			}
+			if attrs == nil {
+				continue
+			}
+			indexVal := encodeTxAttrIndexVal(txoffset.txID, txsfltr.Flag(i))
+			if index.isAttributeIndexed(IndexableAttrChaincodeName) && attrs.chaincodeName != "" {
+				batch.Put(constructTxAttrKey(chaincodeNameIdxKeyPrefix, attrs.chaincodeName, blkNum, uint64(i)), indexVal)
+			}
+			if index.isAttributeIndexed(IndexableAttrEndorserMSPID) {
+				for _, mspID := range attrs.endorserMSPIDs {
+					batch.Put(constructTxAttrKey(endorserMSPIDIdxKeyPrefix, mspID, blkNum, uint64(i)), indexVal)
+				}
+			}
+		}
+	}
+
 	batch.Put(indexSavePointKey, encodeBlockNum(blockIdxInfo.blockNum))
 	// Setting snyc to true as a precaution, false may be an ok optimization after further testing.
 	if err := index.db.WriteBatch(batch, true); err != nil {
@@ -278,6 +387,55 @@ func (index *blockIndex) getTxIDVal(txID string) (*TxIDIndexValue, uint64, error
 	return val, blockNum, nil
 }
 
+// getTxIDVals returns the index values for the given txIDs in a single pass over the txID index, by seeking to the
+// txIDs in the order of their keys. A nil value is returned for a txID whose details are not available because the
+// ledger is bootstrapped from a snapshot
+func (index *blockIndex) getTxIDVals(txIDs []string) (map[string]*TxIDIndexValue, error) {
+	if !index.isAttributeIndexed(IndexableAttrTxID) {
+		return nil, errors.New("transaction IDs not maintained in index")
+	}
+	sortedTxIDs := append([]string{}, txIDs...)
+	sort.Slice(sortedTxIDs, func(i, j int) bool {
+		// the order of the keys in the txID index is the shortlex order of the txIDs
+		if len(sortedTxIDs[i]) != len(sortedTxIDs[j]) {
+			return len(sortedTxIDs[i]) < len(sortedTxIDs[j])
+		}
+		return sortedTxIDs[i] < sortedTxIDs[j]
+	})
+
+	itr, err := index.db.GetIterator([]byte{txIDIdxKeyPrefix}, []byte{txIDIdxKeyPrefix + 1})
+	if err != nil {
+		return nil, errors.WithMessage(err, "error while trying to retrieve transaction info by TXIDs")
+	}
+	defer itr.Release()
+
+	vals := make(map[string]*TxIDIndexValue, len(txIDs))
+	for _, txID := range sortedTxIDs {
+		if _, ok := vals[txID]; ok {
+			continue
+		}
+		rangeScan := constructTxIDRangeScan(txID)
+		present := itr.Seek(rangeScan.startKey) && bytes.Compare(itr.Key(), rangeScan.stopKey) < 0
+		if err := itr.Error(); err != nil {
+			return nil, errors.Wrapf(err, "error while trying to retrieve transaction info by TXID [%s]", txID)
+		}
+		if !present {
+			return nil, errors.Errorf("no such transaction ID [%s] in index", txID)
+		}
+		valBytes := itr.Value()
+		if len(valBytes) == 0 {
+			vals[txID] = nil
+			continue
+		}
+		val := &TxIDIndexValue{}
+		if err := proto.Unmarshal(valBytes, val); err != nil {
+			return nil, errors.Wrapf(err, "unexpected error while unmarshalling bytes [%#v] into TxIDIndexValProto", valBytes)
+		}
+		vals[txID] = val
+	}
+	return vals, nil
+}
+
 func (index *blockIndex) getTXLocByBlockNumTranNum(blockNum uint64, tranNum uint64) (*fileLocPointer, error) {
 	if !index.isAttributeIndexed(IndexableAttrBlockNumTranNum) {
 		return nil, errors.New("<blockNumber, transactionNumber> tuple not maintained in index")
@@ -46,9 +46,13 @@ func (itr *blocksItr) initStream() error {
 	if lp, err = itr.mgr.index.getBlockLocByBlockNum(itr.blockNumToRetrieve); err != nil {
 		return err
 	}
+	if err = itr.mgr.restoreIfArchived(lp.fileSuffixNum); err != nil {
+		return err
+	}
 	if itr.stream, err = newBlockStream(itr.mgr.rootDir, lp.fileSuffixNum, int64(lp.offset), -1); err != nil {
 		return err
 	}
+	itr.stream.restoreFile = itr.mgr.restoreIfArchived
 	return nil
 }
 
@@ -79,7 +83,7 @@ func (itr *blocksItr) Next() (ledger.QueryResult, error) {
 		return nil, err
 	}
 	itr.blockNumToRetrieve++
-	return deserializeBlock(nextBlockBytes)
+	return decodeBlock(nextBlockBytes, itr.mgr.codec)
 }
 
 // Close releases any resources held by the iterator
@@ -22,6 +22,8 @@ type BlockStore struct {
 	conf    *Conf
 	fileMgr *blockfileMgr
 	stats   *ledgerStats
+
+	writeSaturation writeSaturation
 }
 
 // newBlockStore constructs a `BlockStore`
@@ -37,7 +39,12 @@ func newBlockStore(id string, conf *Conf, indexConfig *IndexConfig,
 	info := fileMgr.getBlockchainInfo()
 	ledgerStats.updateBlockchainHeight(info.Height)
 
-	return &BlockStore{id, conf, fileMgr, ledgerStats}, nil
+	return &BlockStore{
+		id:      id,
+		conf:    conf,
+		fileMgr: fileMgr,
+		stats:   ledgerStats,
+	}, nil
 }
 
 // AddBlock adds a new block
@@ -46,6 +53,7 @@ func (store *BlockStore) AddBlock(block *common.Block) error {
 	startBlockCommit := time.Now()
 	result := store.fileMgr.addBlock(block)
 	elapsedBlockCommit := time.Since(startBlockCommit)
+	store.writeSaturation.record(startBlockCommit)
 
 	store.updateBlockStats(block.Header.Number, elapsedBlockCommit)
 
@@ -72,6 +80,33 @@ func (store *BlockStore) RetrieveBlockByNumber(blockNum uint64) (*common.Block,
 	return store.fileMgr.retrieveBlockByNumber(blockNum)
 }
 
+// FirstAvailableBlockNumber returns the lowest block number that can be retrieved from the block store.
+// The blocks below this are either pruned or not present because the block store was bootstrapped from a snapshot
+func (store *BlockStore) FirstAvailableBlockNumber() uint64 {
+	return store.fileMgr.firstAvailableBlockNumber()
+}
+
+// PruneBlocks discards the blocks below `beforeBlock` from the block store. After pruning, an attempt to
+// retrieve a pruned block, or a transaction contained in a pruned block, returns an `ErrBlockPruned` error.
+// The last block in the block store cannot be pruned
+func (store *BlockStore) PruneBlocks(beforeBlock uint64) error {
+	return store.fileMgr.pruneBlocks(beforeBlock)
+}
+
+// PruneBlockfiles prunes the blocks below `beforeBlock`, as PruneBlocks does, only if the pruning makes at least
+// one block file deletable, so that the blocks do not become unavailable before any disk space can be reclaimed.
+// This is meant for the periodic pruning of the old blocks as per a retention policy
+func (store *BlockStore) PruneBlockfiles(beforeBlock uint64) error {
+	return store.fileMgr.pruneBlockfiles(beforeBlock)
+}
+
+// ArchiveBlocks moves the block files that contain only the blocks below `beforeBlock` to the archive configured
+// via `Conf.SetBlockfileArchiver`. The archived blocks remain retrievable; a retrieval that targets an archived
+// block file fetches the file back from the archive and keeps it on the local disk until the next archive call
+func (store *BlockStore) ArchiveBlocks(beforeBlock uint64) error {
+	return store.fileMgr.archiveBlockfiles(beforeBlock)
+}
+
 // TxIDExists returns true if a transaction with the txID is ever committed
 func (store *BlockStore) TxIDExists(txID string) (bool, error) {
 	return store.fileMgr.txIDExists(txID)
@@ -82,6 +117,12 @@ func (store *BlockStore) RetrieveTxByID(txID string) (*common.Envelope, error) {
 	return store.fileMgr.retrieveTransactionByID(txID)
 }
 
+// RetrieveTxsByIDs returns the transactions and their validation codes for the given txIDs, in the same order.
+// An error is returned if any of the transactions is not available
+func (store *BlockStore) RetrieveTxsByIDs(txIDs []string) ([]*common.Envelope, []peer.TxValidationCode, error) {
+	return store.fileMgr.retrieveTransactionsByIDs(txIDs)
+}
+
 // RetrieveTxByBlockNumTranNum returns a transaction for the given <blockNum, tranNum>
 func (store *BlockStore) RetrieveTxByBlockNumTranNum(blockNum uint64, tranNum uint64) (*common.Envelope, error) {
 	return store.fileMgr.retrieveTransactionByBlockNumTranNum(blockNum, tranNum)
@@ -105,6 +146,57 @@ func (store *BlockStore) ExportTxIds(dir string, newHashFunc snapshot.NewHashFun
 	return store.fileMgr.index.exportUniqueTxIDs(dir, newHashFunc)
 }
 
+// CheckpointInfo captures the position in the block files up to which the blocks have been persisted
+type CheckpointInfo struct {
+	// LatestFileNumber is the suffix number of the block file that is currently being appended to
+	LatestFileNumber int
+	// LatestFileSize is the byte offset in the latest block file up to which the blocks have been persisted
+	LatestFileSize int
+	// LastPersistedBlock is the number of the last block persisted in the block files
+	LastPersistedBlock uint64
+	// NoBlockFiles is true if no block has been persisted in the block files yet, which is the case
+	// for a block store bootstrapped from a snapshot before committing any block
+	NoBlockFiles bool
+}
+
+// RetrieveTxIDsInRange returns an iterator over the transactions in the blocks from startBlockNum (inclusive) to
+// endBlockNum (exclusive), in the order of their commit. The blocks below the first available block are skipped
+func (store *BlockStore) RetrieveTxIDsInRange(startBlockNum, endBlockNum uint64) (*TxIDsItr, error) {
+	return store.fileMgr.retrieveTxIDsInRange(startBlockNum, endBlockNum)
+}
+
+// RetrieveTxIDsByChaincodeName returns an iterator over the endorser transactions that invoke the given chaincode,
+// in the order of their commit. This requires the attribute `IndexableAttrChaincodeName` to be indexed
+func (store *BlockStore) RetrieveTxIDsByChaincodeName(chaincodeName string) (*TxIDsByAttrItr, error) {
+	return store.fileMgr.retrieveTxIDsByAttr(IndexableAttrChaincodeName, chaincodeName)
+}
+
+// RetrieveTxIDsByEndorserMSPID returns an iterator over the endorser transactions that carry an endorsement from
+// the given MSP, in the order of their commit. This requires the attribute `IndexableAttrEndorserMSPID` to be indexed
+func (store *BlockStore) RetrieveTxIDsByEndorserMSPID(mspID string) (*TxIDsByAttrItr, error) {
+	return store.fileMgr.retrieveTxIDsByAttr(IndexableAttrEndorserMSPID, mspID)
+}
+
+// WriteSaturation returns an estimate, in the range 0 to 1, of how saturated the block append path is, based on the
+// fraction of the recent time spent in appending the blocks. A value close to 1 indicates that the block store is
+// not keeping up with the rate at which the blocks are being added. This is cheap to call and does not contend with
+// the block store operations
+func (store *BlockStore) WriteSaturation() float64 {
+	return store.writeSaturation.value(time.Now())
+}
+
+// GetCheckpointInfo returns a point-in-time copy of the checkpoint information maintained by the block store.
+// This is read from the block store bookkeeping and does not require scanning the block files.
+func (store *BlockStore) GetCheckpointInfo() *CheckpointInfo {
+	i := store.fileMgr.getBlockfilesInfo()
+	return &CheckpointInfo{
+		LatestFileNumber:   i.latestFileNumber,
+		LatestFileSize:     i.latestFileSize,
+		LastPersistedBlock: i.lastPersistedBlock,
+		NoBlockFiles:       i.noBlockFiles,
+	}
+}
+
 // Shutdown shuts down the block store
 func (store *BlockStore) Shutdown() {
 	logger.Debugf("closing fs blockStore:%s", store.id)
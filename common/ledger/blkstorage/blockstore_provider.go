@@ -8,6 +8,7 @@ package blkstorage
 
 import (
 	"os"
+	"time"
 
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/dataformat"
@@ -28,6 +29,10 @@ const (
 	IndexableAttrBlockHash       = IndexableAttr("BlockHash")
 	IndexableAttrTxID            = IndexableAttr("TxID")
 	IndexableAttrBlockNumTranNum = IndexableAttr("BlockNumTranNum")
+	// IndexableAttrChaincodeName indexes the endorser transactions by the name of the invoked chaincode
+	IndexableAttrChaincodeName = IndexableAttr("ChaincodeName")
+	// IndexableAttrEndorserMSPID indexes the endorser transactions by the MSP IDs of their endorsers
+	IndexableAttrEndorserMSPID = IndexableAttr("EndorserMSPID")
 )
 
 // IndexConfig - a configuration that includes a list of attributes that should be indexed
@@ -58,13 +63,17 @@ type BlockStoreProvider struct {
 	indexConfig     *IndexConfig
 	leveldbProvider *leveldbhelper.Provider
 	stats           *stats
+	writers         *writerLRU
 }
 
 // NewProvider constructs a filesystem based block store provider
 func NewProvider(conf *Conf, indexConfig *IndexConfig, metricsProvider metrics.Provider) (*BlockStoreProvider, error) {
 	dbConf := &leveldbhelper.Conf{
-		DBPath:         conf.getIndexDir(),
-		ExpectedFormat: dataFormatVersion(indexConfig),
+		DBPath:            conf.getIndexDir(),
+		ExpectedFormat:    dataFormatVersion(indexConfig),
+		OpenRetries:       conf.indexDBOpenRetry.Retries,
+		OpenRetryInterval: conf.indexDBOpenRetry.Interval,
+		Tuning:            conf.indexDBTuning,
 	}
 
 	p, err := leveldbhelper.NewProvider(dbConf)
@@ -85,7 +94,11 @@ func NewProvider(conf *Conf, indexConfig *IndexConfig, metricsProvider metrics.P
 	}
 
 	stats := newStats(metricsProvider)
-	return &BlockStoreProvider{conf, indexConfig, p, stats}, nil
+	var writers *writerLRU
+	if conf.maxOpenWriters > 0 {
+		writers = newWriterLRU(conf.maxOpenWriters)
+	}
+	return &BlockStoreProvider{conf, indexConfig, p, stats, writers}, nil
 }
 
 // Open opens a block store for given ledgerid.
@@ -93,7 +106,15 @@ func NewProvider(conf *Conf, indexConfig *IndexConfig, metricsProvider metrics.P
 // This method should be invoked only once for a particular ledgerid
 func (p *BlockStoreProvider) Open(ledgerid string) (*BlockStore, error) {
 	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
-	return newBlockStore(ledgerid, p.conf, p.indexConfig, indexStoreHandle, p.stats)
+	store, err := newBlockStore(ledgerid, p.conf, p.indexConfig, indexStoreHandle, p.stats)
+	if err != nil {
+		return nil, err
+	}
+	if p.writers != nil {
+		store.fileMgr.writers = p.writers
+		p.writers.add(store.fileMgr)
+	}
+	return store, nil
 }
 
 // ImportFromSnapshot initializes blockstore from a previously generated snapshot
@@ -145,6 +166,49 @@ func (p *BlockStoreProvider) List() ([]string, error) {
 	return fileutil.ListSubdirs(p.conf.getChainsDir())
 }
 
+// StoreInfo captures the summary information of a block store that can be read without opening the block store
+type StoreInfo struct {
+	// Height is the number of the blocks in the block store, including the blocks covered by the bootstrapping snapshot
+	Height uint64
+	// LastAppendTime is the last modification time of the block file that is being appended to. This is zero
+	// if no block is appended to the block files yet
+	LastAppendTime time.Time
+}
+
+// GetStoreInfo returns the summary information of the block store for the given ledger without opening the block
+// store. If the block store is open, the information reflects the blocks synced to the disk. For a non-existent
+// block store, a nil value is returned
+func (p *BlockStoreProvider) GetStoreInfo(ledgerid string) (*StoreInfo, error) {
+	exists, err := p.Exists(ledgerid)
+	if err != nil || !exists {
+		return nil, err
+	}
+	rootDir := p.conf.getLedgerBlockDir(ledgerid)
+	mgr := &blockfileMgr{rootDir: rootDir, conf: p.conf, db: p.leveldbProvider.GetDBHandle(ledgerid)}
+	blkfilesInfo, err := mgr.loadBlkfilesInfo()
+	if err != nil {
+		return nil, err
+	}
+	info := &StoreInfo{}
+	if blkfilesInfo != nil && !blkfilesInfo.noBlockFiles {
+		info.Height = blkfilesInfo.lastPersistedBlock + 1
+		fi, err := os.Stat(deriveBlockfilePath(rootDir, blkfilesInfo.latestFileNumber))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while reading the block file info for ledger [%s]", ledgerid)
+		}
+		info.LastAppendTime = fi.ModTime()
+		return info, nil
+	}
+	bsi, err := loadBootstrappingSnapshotInfo(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if bsi != nil {
+		info.Height = bsi.LastBlockNum + 1
+	}
+	return info, nil
+}
+
 // Close closes the BlockStoreProvider
 func (p *BlockStoreProvider) Close() {
 	p.leveldbProvider.Close()
@@ -9,6 +9,7 @@ package blkstorage
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/peer"
@@ -245,6 +246,30 @@ func TestDrop(t *testing.T) {
 	require.EqualError(t, provider.Drop("ledger2"), "internal leveldb error while obtaining db iterator: leveldb: closed")
 }
 
+func TestGetStoreInfo(t *testing.T) {
+	env := newTestEnv(t, NewConf(t.TempDir(), 0))
+	defer env.Cleanup()
+	provider := env.provider
+
+	info, err := provider.GetStoreInfo("non-existent-ledger")
+	require.NoError(t, err)
+	require.Nil(t, info)
+
+	store, err := provider.Open("ledger1")
+	require.NoError(t, err)
+	defer store.Shutdown()
+	info, err = provider.GetStoreInfo("ledger1")
+	require.NoError(t, err)
+	require.Equal(t, &StoreInfo{}, info)
+
+	beforeAppend := time.Now().Add(-time.Second)
+	addBlocksToStore(t, store, 5)
+	info, err = provider.GetStoreInfo("ledger1")
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), info.Height)
+	require.True(t, info.LastAppendTime.After(beforeAppend))
+}
+
 func constructLedgerid(id int) string {
 	return fmt.Sprintf("ledger_%d", id)
 }
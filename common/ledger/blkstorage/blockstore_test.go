@@ -7,10 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package blkstorage
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protoutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -81,3 +88,134 @@ func TestTxIDIndexErrorPropagations(t *testing.T) {
 		require.Contains(t, err.Error(), expectedErrMsg)
 	}
 }
+
+func TestGetCheckpointInfo(t *testing.T) {
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	env := newTestEnv(t, NewConf(t.TempDir(), len(blocks[1].Data.Data[0])*3))
+	defer env.Cleanup()
+
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	defer store.Shutdown()
+
+	require.Equal(t, &CheckpointInfo{NoBlockFiles: true}, store.GetCheckpointInfo())
+
+	for _, b := range blocks {
+		require.NoError(t, store.AddBlock(b))
+		require.Equal(t, b.Header.Number, store.GetCheckpointInfo().LastPersistedBlock)
+	}
+
+	checkpointInfo := store.GetCheckpointInfo()
+	require.False(t, checkpointInfo.NoBlockFiles)
+	require.Greater(t, checkpointInfo.LatestFileNumber, 0)
+	fileInfo, err := os.Stat(deriveBlockfilePath(store.fileMgr.rootDir, checkpointInfo.LatestFileNumber))
+	require.NoError(t, err)
+	require.Equal(t, int(fileInfo.Size()), checkpointInfo.LatestFileSize)
+}
+
+func TestBlockCodec(t *testing.T) {
+	blockStorageDir := t.TempDir()
+	conf := NewConf(blockStorageDir, 0)
+	conf.SetBlockCodec(&gzipBlockCodec{})
+	blocks := testutil.ConstructTestBlocks(t, 10)
+
+	verifyBlocks := func(store *BlockStore) {
+		for _, block := range blocks {
+			b, err := store.RetrieveBlockByNumber(block.Header.Number)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(block, b))
+
+			b, err = store.RetrieveBlockByHash(protoutil.BlockHeaderHash(block.Header))
+			require.NoError(t, err)
+			require.True(t, proto.Equal(block, b))
+
+			for txNum, txEnvelopeBytes := range block.Data.Data {
+				expectedTxEnvelope, err := protoutil.GetEnvelopeFromBlock(txEnvelopeBytes)
+				require.NoError(t, err)
+				txID, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+				require.NoError(t, err)
+
+				txEnvelope, err := store.RetrieveTxByID(txID)
+				require.NoError(t, err)
+				require.True(t, proto.Equal(expectedTxEnvelope, txEnvelope))
+
+				txEnvelope, err = store.RetrieveTxByBlockNumTranNum(block.Header.Number, uint64(txNum))
+				require.NoError(t, err)
+				require.True(t, proto.Equal(expectedTxEnvelope, txEnvelope))
+
+				b, err := store.RetrieveBlockByTxID(txID)
+				require.NoError(t, err)
+				require.True(t, proto.Equal(block, b))
+			}
+		}
+
+		itr, err := store.RetrieveBlocks(0)
+		require.NoError(t, err)
+		defer itr.Close()
+		for _, block := range blocks {
+			b, err := itr.Next()
+			require.NoError(t, err)
+			require.True(t, proto.Equal(block, b.(*common.Block)))
+		}
+	}
+
+	env := newTestEnv(t, conf)
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	for _, block := range blocks {
+		require.NoError(t, store.AddBlock(block))
+	}
+	verifyBlocks(store)
+
+	// the blocks are stored in the encoding produced by the codec
+	blockBytes, err := store.fileMgr.fetchBlockBytes(&fileLocPointer{})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x1f, 0x8b}, blockBytes[:2])
+	env.Cleanup()
+
+	// the index is rebuilt from the decoded blocks
+	require.NoError(t, DeleteBlockStoreIndex(blockStorageDir))
+	env = newTestEnv(t, conf)
+	defer env.Cleanup()
+	store, err = env.provider.Open("testLedger")
+	require.NoError(t, err)
+	bcInfo, err := store.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), bcInfo.Height)
+	verifyBlocks(store)
+}
+
+type gzipBlockCodec struct{}
+
+func (c *gzipBlockCodec) Marshal(block *common.Block) ([]byte, error) {
+	b, err := proto.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *gzipBlockCodec) Unmarshal(b []byte) (*common.Block, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	protoBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	block := &common.Block{}
+	if err := proto.Unmarshal(protoBytes, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
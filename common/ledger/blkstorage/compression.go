@@ -0,0 +1,148 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/snappy"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	blockCompressionFile     = "blockCompression.info"
+	blockCompressionTempFile = "blockCompressionTemp.info"
+)
+
+// Compression determines how the blocks are compressed in the block files
+type Compression int
+
+const (
+	// CompressionNone stores the blocks uncompressed
+	CompressionNone Compression = iota
+	// CompressionSnappy compresses each block with snappy
+	CompressionSnappy
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionSnappy:
+		return "snappy"
+	default:
+		return fmt.Sprintf("Compression(%d)", int(c))
+	}
+}
+
+func parseCompression(s string) (Compression, error) {
+	switch s {
+	case CompressionNone.String():
+		return CompressionNone, nil
+	case CompressionSnappy.String():
+		return CompressionSnappy, nil
+	default:
+		return 0, errors.Errorf("unknown block compression [%s]", s)
+	}
+}
+
+// compressingCodec is a BlockCodec that compresses the bytes produced by the underlying codec, or the bytes of the
+// default encoding when the underlying codec is nil
+type compressingCodec struct {
+	codec BlockCodec
+}
+
+func (c *compressingCodec) Marshal(block *common.Block) ([]byte, error) {
+	blockBytes, _, err := encodeBlock(block, c.codec)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, blockBytes), nil
+}
+
+func (c *compressingCodec) Unmarshal(b []byte) (*common.Block, error) {
+	blockBytes, err := snappy.Decode(nil, b)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while decompressing a block")
+	}
+	return decodeBlock(blockBytes, c.codec)
+}
+
+// ledgerCompression returns the compression of the blocks in the given ledger dir. The compression of a ledger is
+// fixed by the configured compression when the ledger is created and is recorded in the ledger dir, so that a change
+// in the configuration applies only to the ledgers created afterwards. A ledger dir that contains blocks but no record,
+// such as of a ledger created before the compression was introduced, is treated as uncompressed
+func ledgerCompression(rootDir string, configured Compression) (Compression, error) {
+	b, err := ioutil.ReadFile(filepath.Join(rootDir, blockCompressionFile))
+	if err == nil {
+		compression, err := parseCompression(string(b))
+		if err != nil {
+			return 0, errors.WithMessagef(err, "invalid block compression recorded in dir [%s]", rootDir)
+		}
+		if compression != configured {
+			logger.Infof("Blocks in dir [%s] remain compressed with [%s] instead of the configured [%s]", rootDir, compression, configured)
+		}
+		return compression, nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, errors.Wrapf(err, "error reading the block compression in dir [%s]", rootDir)
+	}
+
+	hasBlocks, err := containsBlocks(rootDir)
+	if err != nil {
+		return 0, err
+	}
+	if hasBlocks {
+		if configured != CompressionNone {
+			logger.Infof("Blocks in dir [%s] remain uncompressed instead of the configured [%s]", rootDir, configured)
+		}
+		return CompressionNone, nil
+	}
+	if err := recordCompression(rootDir, configured); err != nil {
+		return 0, err
+	}
+	return configured, nil
+}
+
+// recordCompression records the compression of the blocks in the given ledger dir. Nothing is recorded for
+// the uncompressed blocks
+func recordCompression(rootDir string, compression Compression) error {
+	if compression == CompressionNone {
+		return nil
+	}
+	if err := fileutil.CreateAndSyncFileAtomically(
+		rootDir,
+		blockCompressionTempFile,
+		blockCompressionFile,
+		[]byte(compression.String()),
+		0o644,
+	); err != nil {
+		return errors.WithMessagef(err, "error recording the block compression in dir [%s]", rootDir)
+	}
+	return fileutil.SyncDir(rootDir)
+}
+
+// containsBlocks returns true if any block file in the given ledger dir is not empty
+func containsBlocks(rootDir string) (bool, error) {
+	lastFileNum, err := retrieveLastFileSuffix(rootDir)
+	if err != nil {
+		return false, err
+	}
+	switch {
+	case lastFileNum < 0:
+		return false, nil
+	case lastFileNum > 0:
+		return true, nil
+	default:
+		return getFileInfoOrPanic(rootDir, 0).Size() > 0, nil
+	}
+}
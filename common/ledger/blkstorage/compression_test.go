@@ -0,0 +1,202 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockCompression(t *testing.T) {
+	blockStorageDir := t.TempDir()
+	conf := NewConf(blockStorageDir, 0)
+	conf.SetCompression(CompressionSnappy)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+
+	verifyBlocks := func(store *BlockStore) {
+		for _, block := range blocks {
+			b, err := store.RetrieveBlockByNumber(block.Header.Number)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(block, b))
+
+			for txNum, txEnvelopeBytes := range block.Data.Data {
+				expectedTxEnvelope, err := protoutil.GetEnvelopeFromBlock(txEnvelopeBytes)
+				require.NoError(t, err)
+				txID, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+				require.NoError(t, err)
+
+				txEnvelope, err := store.RetrieveTxByID(txID)
+				require.NoError(t, err)
+				require.True(t, proto.Equal(expectedTxEnvelope, txEnvelope))
+
+				txEnvelope, err = store.RetrieveTxByBlockNumTranNum(block.Header.Number, uint64(txNum))
+				require.NoError(t, err)
+				require.True(t, proto.Equal(expectedTxEnvelope, txEnvelope))
+			}
+		}
+	}
+
+	env := newTestEnv(t, conf)
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	for _, block := range blocks {
+		require.NoError(t, store.AddBlock(block))
+	}
+	verifyBlocks(store)
+	require.Equal(t, CompressionSnappy, store.fileMgr.compression)
+
+	// the blocks are stored compressed
+	blockBytes, err := store.fileMgr.fetchBlockBytes(&fileLocPointer{})
+	require.NoError(t, err)
+	serializedBlockBytes, _, err := serializeBlock(blocks[0])
+	require.NoError(t, err)
+	require.Less(t, len(blockBytes), len(serializedBlockBytes))
+	_, err = (&compressingCodec{}).Unmarshal(serializedBlockBytes)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error while decompressing a block")
+	env.Cleanup()
+
+	// the index is rebuilt from the decompressed blocks
+	require.NoError(t, DeleteBlockStoreIndex(blockStorageDir))
+	env = newTestEnv(t, conf)
+	store, err = env.provider.Open("testLedger")
+	require.NoError(t, err)
+	bcInfo, err := store.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), bcInfo.Height)
+	verifyBlocks(store)
+
+	// the copy of the block files retains the compression
+	targetDir := t.TempDir()
+	require.NoError(t, store.CopyBlockfiles(targetDir, store.GetCheckpointInfo()))
+	env.Cleanup()
+	copyEnv := newTestEnv(t, NewConf(targetDir, 0))
+	defer copyEnv.Cleanup()
+	store, err = copyEnv.provider.Open("testLedger")
+	require.NoError(t, err)
+	require.Equal(t, CompressionSnappy, store.fileMgr.compression)
+	verifyBlocks(store)
+}
+
+func TestBlockCompressionWithEncryption(t *testing.T) {
+	conf := NewConf(t.TempDir(), 0)
+	conf.SetCompression(CompressionSnappy)
+	conf.SetEncryptor(&aesGCMEncryptor{})
+	blocks := testutil.ConstructTestBlocks(t, 5)
+
+	env := newTestEnv(t, conf)
+	defer env.Cleanup()
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	for _, block := range blocks {
+		require.NoError(t, store.AddBlock(block))
+	}
+	for _, block := range blocks {
+		b, err := store.RetrieveBlockByNumber(block.Header.Number)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(block, b))
+	}
+
+	// the blocks are compressed before being encrypted
+	blockBytes, err := store.fileMgr.fetchBlockBytes(&fileLocPointer{})
+	require.NoError(t, err)
+	compressedBytes, err := (&aesGCMEncryptor{}).Decrypt("testLedger", blockBytes)
+	require.NoError(t, err)
+	block, err := (&compressingCodec{}).Unmarshal(compressedBytes)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(blocks[0], block))
+}
+
+func TestLedgerCompression(t *testing.T) {
+	blocks := testutil.ConstructTestBlocks(t, 5)
+
+	openAndAddBlocks := func(t *testing.T, blockStorageDir string, compression Compression, blocks []*common.Block) (*testEnv, *BlockStore) {
+		conf := NewConf(blockStorageDir, 0)
+		conf.SetCompression(compression)
+		env := newTestEnv(t, conf)
+		store, err := env.provider.Open("testLedger")
+		require.NoError(t, err)
+		for _, block := range blocks {
+			require.NoError(t, store.AddBlock(block))
+		}
+		return env, store
+	}
+
+	t.Run("existing uncompressed ledger", func(t *testing.T) {
+		blockStorageDir := t.TempDir()
+		env, store := openAndAddBlocks(t, blockStorageDir, CompressionNone, blocks[:3])
+		require.Equal(t, CompressionNone, store.fileMgr.compression)
+		env.Cleanup()
+
+		env, store = openAndAddBlocks(t, blockStorageDir, CompressionSnappy, blocks[3:])
+		defer env.Cleanup()
+		require.Equal(t, CompressionNone, store.fileMgr.compression)
+		require.NoFileExists(t, filepath.Join(store.fileMgr.rootDir, blockCompressionFile))
+		for _, block := range blocks {
+			b, err := store.RetrieveBlockByNumber(block.Header.Number)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(block, b))
+		}
+	})
+
+	t.Run("existing compressed ledger", func(t *testing.T) {
+		blockStorageDir := t.TempDir()
+		env, store := openAndAddBlocks(t, blockStorageDir, CompressionSnappy, blocks[:3])
+		env.Cleanup()
+
+		env, store = openAndAddBlocks(t, blockStorageDir, CompressionNone, blocks[3:])
+		defer env.Cleanup()
+		require.Equal(t, CompressionSnappy, store.fileMgr.compression)
+		for _, block := range blocks {
+			b, err := store.RetrieveBlockByNumber(block.Header.Number)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(block, b))
+		}
+	})
+
+	t.Run("empty ledger", func(t *testing.T) {
+		blockStorageDir := t.TempDir()
+		env, store := openAndAddBlocks(t, blockStorageDir, CompressionNone, nil)
+		env.Cleanup()
+
+		env, store = openAndAddBlocks(t, blockStorageDir, CompressionSnappy, blocks)
+		defer env.Cleanup()
+		require.Equal(t, CompressionSnappy, store.fileMgr.compression)
+		b, err := ioutil.ReadFile(filepath.Join(store.fileMgr.rootDir, blockCompressionFile))
+		require.NoError(t, err)
+		require.Equal(t, "snappy", string(b))
+	})
+
+	t.Run("invalid record", func(t *testing.T) {
+		rootDir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(rootDir, blockCompressionFile), []byte("zstd"), 0o644))
+		_, err := ledgerCompression(rootDir, CompressionSnappy)
+		require.EqualError(t, err, "invalid block compression recorded in dir ["+rootDir+"]: unknown block compression [zstd]")
+	})
+}
+
+func TestCompressingCodecWithBlockCodec(t *testing.T) {
+	block := testutil.ConstructTestBlocks(t, 1)[0]
+	codec := &compressingCodec{codec: &gzipBlockCodec{}}
+	b, err := codec.Marshal(block)
+	require.NoError(t, err)
+	gzippedBytes, err := (&gzipBlockCodec{}).Marshal(block)
+	require.NoError(t, err)
+	require.False(t, bytes.Equal(gzippedBytes, b))
+
+	decoded, err := codec.Unmarshal(b)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(block, decoded))
+}
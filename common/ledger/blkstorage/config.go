@@ -6,7 +6,11 @@ SPDX-License-Identifier: Apache-2.0
 
 package blkstorage
 
-import "path/filepath"
+import (
+	"path/filepath"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+)
 
 const (
 	// ChainsDir is the name of the directory containing the channel ledgers.
@@ -16,19 +20,114 @@ const (
 	defaultMaxBlockfileSize = 64 * 1024 * 1024 // bytes
 )
 
+// SyncMode determines when the appended blocks are flushed from the block files to the disk
+type SyncMode int
+
+const (
+	// SyncPerBlock flushes the block file to the disk after every block
+	SyncPerBlock SyncMode = iota
+	// SyncPerN flushes the block file to the disk after every N blocks
+	SyncPerN
+	// SyncOnClose flushes the block file to the disk only when the block file is full or the block store is closed
+	SyncOnClose
+)
+
 // Conf encapsulates all the configurations for `BlockStore`
 type Conf struct {
 	blockStorageDir  string
 	maxBlockfileSize int
+	syncMode         SyncMode
+	syncEveryN       int
+	codec            BlockCodec
+	encryptor        Encryptor
+	maxOpenWriters   int
+	archiver         BlockfileArchiver
+	indexDBTuning    *leveldbhelper.Tuning
+	indexDBOpenRetry leveldbhelper.OpenRetry
+	compression      Compression
 }
 
 // NewConf constructs new `Conf`.
 // blockStorageDir is the top level folder under which `BlockStore` manages its data
 func NewConf(blockStorageDir string, maxBlockfileSize int) *Conf {
+	return NewConfWithSyncMode(blockStorageDir, maxBlockfileSize, SyncPerBlock, 1)
+}
+
+// NewConfWithSyncMode constructs new `Conf` with the given sync mode. syncEveryN is used only
+// with the `SyncPerN` mode. In the modes other than `SyncPerBlock`, the blocks appended since
+// the last sync may be lost on a crash and are detected and truncated when the block store is reopened
+func NewConfWithSyncMode(blockStorageDir string, maxBlockfileSize int, syncMode SyncMode, syncEveryN int) *Conf {
 	if maxBlockfileSize <= 0 {
 		maxBlockfileSize = defaultMaxBlockfileSize
 	}
-	return &Conf{blockStorageDir, maxBlockfileSize}
+	if syncMode == SyncPerN && syncEveryN <= 1 {
+		syncMode = SyncPerBlock
+	}
+	return &Conf{blockStorageDir, maxBlockfileSize, syncMode, syncEveryN, nil, nil, 0, nil, nil, leveldbhelper.OpenRetry{}, CompressionNone}
+}
+
+// SetBlockCodec sets the codec for encoding the blocks in the block files. A nil codec stores the blocks
+// in the protobuf encoding. The codec is expected to remain the same for the lifetime of a block store
+func (conf *Conf) SetBlockCodec(codec BlockCodec) {
+	conf.codec = codec
+}
+
+// SetEncryptor sets the encryptor for encrypting the blocks in the block files, on top of the encoding of the blocks
+// by the block codec, if any. The ledger ID is passed to the encryptor, so that the blocks of each ledger can be
+// encrypted with a separate key. The encryptor is expected to remain the same for the lifetime of a block store
+// and, as in the case of a block codec, the offline rollback and reset of the block store are not supported
+func (conf *Conf) SetEncryptor(encryptor Encryptor) {
+	conf.encryptor = encryptor
+}
+
+// SetCompression sets the compression of the blocks in the block files, on top of the encoding of the blocks by the
+// block codec, if any, and beneath the encryption, if an encryptor is set. The compression applies only to the ledgers
+// created afterwards, as each ledger retains the compression it was created with. As in the case of a block codec,
+// the offline rollback and reset of the block store are not supported with a compression
+func (conf *Conf) SetCompression(compression Compression) {
+	conf.compression = compression
+}
+
+// blockCodecFor returns the codec for the blocks of the given ledger, which compresses the encoded blocks with the
+// given compression and encrypts them if an encryptor is set. A nil codec is returned for the default encoding
+func (conf *Conf) blockCodecFor(ledgerID string, compression Compression) BlockCodec {
+	codec := conf.codec
+	if compression == CompressionSnappy {
+		codec = &compressingCodec{codec: codec}
+	}
+	if conf.encryptor == nil {
+		return codec
+	}
+	return &encryptingCodec{
+		ledgerID:  ledgerID,
+		encryptor: conf.encryptor,
+		codec:     codec,
+	}
+}
+
+// SetMaxOpenBlockStores limits the number of the block stores, opened via a provider, that keep their current
+// block file open for appending the blocks. The least recently used block stores beyond the limit release their
+// file and reopen it on the next block append. A value less than or equal to zero means no limit
+func (conf *Conf) SetMaxOpenBlockStores(maxOpen int) {
+	conf.maxOpenWriters = maxOpen
+}
+
+// SetBlockfileArchiver sets the archiver that is used by `BlockStore.ArchiveBlocks` for moving the old block files
+// to an external storage and for fetching them back when an archived block is retrieved
+func (conf *Conf) SetBlockfileArchiver(archiver BlockfileArchiver) {
+	conf.archiver = archiver
+}
+
+// SetIndexDBTuning sets the goleveldb options for the leveldb that holds the block indexes of all the ledgers.
+// A nil value leaves the goleveldb defaults in place
+func (conf *Conf) SetIndexDBTuning(tuning *leveldbhelper.Tuning) {
+	conf.indexDBTuning = tuning
+}
+
+// SetIndexDBOpenRetry sets the retries for opening the leveldb that holds the block indexes of all the ledgers,
+// when the first attempt fails, e.g., as the lock of the db is not yet released after an unclean shutdown
+func (conf *Conf) SetIndexDBOpenRetry(openRetry leveldbhelper.OpenRetry) {
+	conf.indexDBOpenRetry = openRetry
 }
 
 func (conf *Conf) getIndexDir() string {
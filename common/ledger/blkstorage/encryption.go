@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/pkg/errors"
+)
+
+// Encryptor encrypts and decrypts the blocks of a ledger stored in the block files
+type Encryptor interface {
+	Encrypt(ledgerID string, plaintext []byte) ([]byte, error)
+	Decrypt(ledgerID string, ciphertext []byte) ([]byte, error)
+}
+
+// encryptingCodec is a BlockCodec that encrypts the bytes produced by the underlying codec, or the bytes of the
+// default encoding when the underlying codec is nil
+type encryptingCodec struct {
+	ledgerID  string
+	encryptor Encryptor
+	codec     BlockCodec
+}
+
+func (c *encryptingCodec) Marshal(block *common.Block) ([]byte, error) {
+	blockBytes, _, err := encodeBlock(block, c.codec)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := c.encryptor.Encrypt(c.ledgerID, blockBytes)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while encrypting block [%d] of ledger [%s]", block.Header.Number, c.ledgerID)
+	}
+	return ciphertext, nil
+}
+
+func (c *encryptingCodec) Unmarshal(b []byte) (*common.Block, error) {
+	blockBytes, err := c.encryptor.Decrypt(c.ledgerID, b)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while decrypting a block of ledger [%s]", c.ledgerID)
+	}
+	return decodeBlock(blockBytes, c.codec)
+}
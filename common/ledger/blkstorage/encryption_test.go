@@ -0,0 +1,115 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockEncryption(t *testing.T) {
+	blockStorageDir := t.TempDir()
+	conf := NewConf(blockStorageDir, 0)
+	conf.SetEncryptor(&aesGCMEncryptor{})
+	blocks := testutil.ConstructTestBlocks(t, 10)
+
+	verifyBlocks := func(store *BlockStore) {
+		for _, block := range blocks {
+			b, err := store.RetrieveBlockByNumber(block.Header.Number)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(block, b))
+
+			for txNum, txEnvelopeBytes := range block.Data.Data {
+				expectedTxEnvelope, err := protoutil.GetEnvelopeFromBlock(txEnvelopeBytes)
+				require.NoError(t, err)
+				txID, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+				require.NoError(t, err)
+
+				txEnvelope, err := store.RetrieveTxByID(txID)
+				require.NoError(t, err)
+				require.True(t, proto.Equal(expectedTxEnvelope, txEnvelope))
+
+				txEnvelope, err = store.RetrieveTxByBlockNumTranNum(block.Header.Number, uint64(txNum))
+				require.NoError(t, err)
+				require.True(t, proto.Equal(expectedTxEnvelope, txEnvelope))
+			}
+		}
+	}
+
+	env := newTestEnv(t, conf)
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	for _, block := range blocks {
+		require.NoError(t, store.AddBlock(block))
+	}
+	verifyBlocks(store)
+
+	// the transactions are not present in the clear in the block files
+	blockBytes, err := store.fileMgr.fetchBlockBytes(&fileLocPointer{})
+	require.NoError(t, err)
+	require.False(t, bytes.Contains(blockBytes, blocks[0].Data.Data[0]))
+	// the blocks of a ledger cannot be decrypted with the key of another ledger
+	_, err = (&encryptingCodec{ledgerID: "anotherLedger", encryptor: &aesGCMEncryptor{}}).Unmarshal(blockBytes)
+	require.EqualError(t, err, "error while decrypting a block of ledger [anotherLedger]: cipher: message authentication failed")
+	env.Cleanup()
+
+	// the index is rebuilt from the decrypted blocks
+	require.NoError(t, DeleteBlockStoreIndex(blockStorageDir))
+	env = newTestEnv(t, conf)
+	defer env.Cleanup()
+	store, err = env.provider.Open("testLedger")
+	require.NoError(t, err)
+	bcInfo, err := store.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), bcInfo.Height)
+	verifyBlocks(store)
+}
+
+// aesGCMEncryptor encrypts with a key derived from the ledger ID, so that each ledger has a separate key
+type aesGCMEncryptor struct{}
+
+func (e *aesGCMEncryptor) aead(ledgerID string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(ledgerID))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (e *aesGCMEncryptor) Encrypt(ledgerID string, plaintext []byte) ([]byte, error) {
+	aead, err := e.aead(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (e *aesGCMEncryptor) Decrypt(ledgerID string, ciphertext []byte) ([]byte, error) {
+	aead, err := e.aead(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil)
+}
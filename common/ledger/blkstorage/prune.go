@@ -0,0 +1,215 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	prunedBlocksInfoFile     = "prunedBlocks.info"
+	prunedBlocksInfoTempFile = "prunedBlocksTemp.info"
+)
+
+// ErrBlockPruned is returned when a block, or a transaction contained in a block, is requested
+// and the block has been pruned from the block store
+type ErrBlockPruned struct {
+	FirstAvailableBlock uint64
+}
+
+func (e *ErrBlockPruned) Error() string {
+	return fmt.Sprintf("the requested block is pruned from the block store. First available block = [%d]", e.FirstAvailableBlock)
+}
+
+// prunedBlocksInfo records the first block that is available after the block store is pruned
+// and the location of this block in the block files. All the blocks that lie before this location
+// in the block files are considered pruned. The info is kept in a file alongside the block files,
+// rather than in the block index, so that it survives the drop of the block index
+type prunedBlocksInfo struct {
+	firstAvailableBlock uint64
+	fileSuffixNum       int
+	offset              int
+}
+
+func (i *prunedBlocksInfo) marshal() ([]byte, error) {
+	buffer := proto.NewBuffer([]byte{})
+	if err := buffer.EncodeVarint(i.firstAvailableBlock); err != nil {
+		return nil, errors.Wrapf(err, "error encoding the firstAvailableBlock [%d]", i.firstAvailableBlock)
+	}
+	if err := buffer.EncodeVarint(uint64(i.fileSuffixNum)); err != nil {
+		return nil, errors.Wrapf(err, "error encoding the fileSuffixNum [%d]", i.fileSuffixNum)
+	}
+	if err := buffer.EncodeVarint(uint64(i.offset)); err != nil {
+		return nil, errors.Wrapf(err, "error encoding the offset [%d]", i.offset)
+	}
+	return buffer.Bytes(), nil
+}
+
+func (i *prunedBlocksInfo) unmarshal(b []byte) error {
+	buffer := proto.NewBuffer(b)
+	val, err := buffer.DecodeVarint()
+	if err != nil {
+		return err
+	}
+	i.firstAvailableBlock = val
+
+	if val, err = buffer.DecodeVarint(); err != nil {
+		return err
+	}
+	i.fileSuffixNum = int(val)
+
+	if val, err = buffer.DecodeVarint(); err != nil {
+		return err
+	}
+	i.offset = int(val)
+	return nil
+}
+
+// includes returns true if the given location lies before the first available block
+func (i *prunedBlocksInfo) includes(lp *fileLocPointer) bool {
+	if lp.fileSuffixNum != i.fileSuffixNum {
+		return lp.fileSuffixNum < i.fileSuffixNum
+	}
+	return lp.offset < i.offset
+}
+
+func loadPrunedBlocksInfo(rootDir string) (*prunedBlocksInfo, error) {
+	b, err := ioutil.ReadFile(filepath.Join(rootDir, prunedBlocksInfoFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error while reading prunedBlocksInfo file")
+	}
+	i := &prunedBlocksInfo{}
+	if err := i.unmarshal(b); err != nil {
+		return nil, errors.Wrap(err, "error while unmarshalling pruned blocks info")
+	}
+	return i, nil
+}
+
+func savePrunedBlocksInfo(rootDir string, i *prunedBlocksInfo) error {
+	b, err := i.marshal()
+	if err != nil {
+		return err
+	}
+	// a temp file may be left behind by a crash during a previous save
+	if err := os.Remove(filepath.Join(rootDir, prunedBlocksInfoTempFile)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "error while removing prunedBlocksInfo temp file")
+	}
+	if err := fileutil.CreateAndSyncFileAtomically(rootDir, prunedBlocksInfoTempFile, prunedBlocksInfoFile, b, 0o644); err != nil {
+		return err
+	}
+	return fileutil.SyncDir(rootDir)
+}
+
+func (mgr *blockfileMgr) getPrunedBlocksInfo() *prunedBlocksInfo {
+	i, _ := mgr.prunedBlocksInfo.Load().(*prunedBlocksInfo)
+	return i
+}
+
+// pruneBlocks discards the blocks below `beforeBlock`. The block files that contain only the blocks
+// below `beforeBlock` are deleted. The remaining pruned blocks, if any, share the block file with the
+// block `beforeBlock` and are not retrievable anymore, though they still occupy the disk space
+func (mgr *blockfileMgr) pruneBlocks(beforeBlock uint64) error {
+	bcInfo := mgr.getBlockchainInfo()
+	if bcInfo.Height == 0 || beforeBlock > bcInfo.Height-1 {
+		return errors.Errorf(
+			"cannot prune blocks before block [%d]. The block store height = [%d], the last block cannot be pruned",
+			beforeBlock, bcInfo.Height,
+		)
+	}
+
+	if beforeBlock <= mgr.firstAvailableBlockNumber() {
+		logger.Debugf("No blocks to prune before block [%d]. First available block = [%d]", beforeBlock, mgr.firstAvailableBlockNumber())
+		return nil
+	}
+
+	loc, err := mgr.index.getBlockLocByBlockNum(beforeBlock)
+	if err != nil {
+		return errors.WithMessagef(err, "error while retrieving the location of block [%d]", beforeBlock)
+	}
+	i := &prunedBlocksInfo{
+		firstAvailableBlock: beforeBlock,
+		fileSuffixNum:       loc.fileSuffixNum,
+		offset:              loc.offset,
+	}
+	if err := savePrunedBlocksInfo(mgr.rootDir, i); err != nil {
+		return errors.WithMessage(err, "error while saving pruned blocks info")
+	}
+	mgr.prunedBlocksInfo.Store(i)
+
+	// the files are deleted after the pruned blocks info is saved. In the event of a crash in between,
+	// the files are deleted in the next invocation of prune
+	for fileNum := loc.fileSuffixNum - 1; fileNum >= 0; fileNum-- {
+		filePath := deriveBlockfilePath(mgr.rootDir, fileNum)
+		err := os.Remove(filePath)
+		if os.IsNotExist(err) {
+			// the files before this one were deleted by a previous prune
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error while deleting block file [%s]", filePath)
+		}
+		logger.Debugf("Deleted block file [%s]", filePath)
+	}
+	logger.Infof("Pruned blocks before block [%d]", beforeBlock)
+	return nil
+}
+
+// pruneBlockfiles prunes the blocks below `beforeBlock`, as pruneBlocks does, only if the pruning makes at least
+// one block file deletable. Otherwise, the blocks are left retrievable until a later invocation can delete a file
+func (mgr *blockfileMgr) pruneBlockfiles(beforeBlock uint64) error {
+	if beforeBlock <= mgr.firstAvailableBlockNumber() {
+		return nil
+	}
+	loc, err := mgr.index.getBlockLocByBlockNum(beforeBlock)
+	if err != nil {
+		return errors.WithMessagef(err, "error while retrieving the location of block [%d]", beforeBlock)
+	}
+	firstFileNum := 0
+	if i := mgr.getPrunedBlocksInfo(); i != nil {
+		firstFileNum = i.fileSuffixNum
+	}
+	if loc.fileSuffixNum <= firstFileNum {
+		return nil
+	}
+	return mgr.pruneBlocks(beforeBlock)
+}
+
+// firstAvailableBlockNumber returns the lowest block number that can be served by the block store,
+// taking into account both the pruning and the bootstrapping from a snapshot
+func (mgr *blockfileMgr) firstAvailableBlockNumber() uint64 {
+	firstAvailableBlock := mgr.firstPossibleBlockNumberInBlockFiles()
+	if i := mgr.getPrunedBlocksInfo(); i != nil && i.firstAvailableBlock > firstAvailableBlock {
+		firstAvailableBlock = i.firstAvailableBlock
+	}
+	return firstAvailableBlock
+}
+
+func (mgr *blockfileMgr) checkBlockNotPruned(blockNum uint64) error {
+	i := mgr.getPrunedBlocksInfo()
+	if i != nil && blockNum < i.firstAvailableBlock {
+		return &ErrBlockPruned{FirstAvailableBlock: i.firstAvailableBlock}
+	}
+	return nil
+}
+
+func (mgr *blockfileMgr) checkLocNotPruned(lp *fileLocPointer) error {
+	i := mgr.getPrunedBlocksInfo()
+	if i != nil && i.includes(lp) {
+		return &ErrBlockPruned{FirstAvailableBlock: i.firstAvailableBlock}
+	}
+	return nil
+}
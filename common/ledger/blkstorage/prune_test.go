@@ -0,0 +1,179 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneBlocks(t *testing.T) {
+	path := t.TempDir()
+	blocks := testutil.ConstructTestBlocks(t, 30)
+	env := newTestEnv(t, NewConf(path, 0))
+	defer env.Cleanup()
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	for i, b := range blocks {
+		require.NoError(t, store.AddBlock(b))
+		if i != 0 && i%10 == 0 {
+			// block ranges in files [(0, 10):file0, (11,20):file1, (21,29):file2]
+			store.fileMgr.moveToNextFile()
+		}
+	}
+
+	t.Run("cannot-prune-beyond-last-block", func(t *testing.T) {
+		require.EqualError(t, store.PruneBlocks(30),
+			"cannot prune blocks before block [30]. The block store height = [30], the last block cannot be pruned",
+		)
+		require.NoError(t, store.PruneBlocks(0))
+		assertBlocksPruned(t, store, blocks, 0)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		require.NoError(t, store.PruneBlocks(15))
+		assertBlocksPruned(t, store, blocks, 15)
+		assertBlockFileExists(t, store, 0, false)
+		assertBlockFileExists(t, store, 1, true)
+	})
+
+	t.Run("prune-below-first-available-block-is-noop", func(t *testing.T) {
+		require.NoError(t, store.PruneBlocks(10))
+		assertBlocksPruned(t, store, blocks, 15)
+	})
+
+	t.Run("pruning-survives-restart", func(t *testing.T) {
+		env.provider.Close()
+		env = newTestEnv(t, NewConf(path, 0))
+		store, err = env.provider.Open("testLedger")
+		require.NoError(t, err)
+		assertBlocksPruned(t, store, blocks, 15)
+
+		require.NoError(t, store.PruneBlocks(29))
+		assertBlocksPruned(t, store, blocks, 29)
+		assertBlockFileExists(t, store, 1, false)
+		assertBlockFileExists(t, store, 2, true)
+
+		bcInfo, err := store.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, uint64(30), bcInfo.Height)
+	})
+
+	t.Run("pruned-ledger-is-listed", func(t *testing.T) {
+		ledgerIDs, err := GetLedgersWithPrunedBlocks(path)
+		require.NoError(t, err)
+		require.Equal(t, []string{"testLedger"}, ledgerIDs)
+	})
+
+	t.Run("index-of-pruned-blockstore-cannot-be-rebuilt", func(t *testing.T) {
+		env.provider.Close()
+		require.NoError(t, DeleteBlockStoreIndex(path))
+		env = newTestEnv(t, NewConf(path, 0))
+		_, err := env.provider.Open("testLedger")
+		require.EqualError(t, err,
+			"cannot sync index with block files. blockstore is pruned and first available block=[29], next block to index=[0]",
+		)
+	})
+}
+
+func assertBlocksPruned(t *testing.T, store *BlockStore, blocks []*common.Block, firstAvailableBlock uint64) {
+	expectedErr := &ErrBlockPruned{FirstAvailableBlock: firstAvailableBlock}
+	for _, block := range blocks {
+		blockNum := block.Header.Number
+		blockHash := protoutil.BlockHeaderHash(block.Header)
+		txID, err := protoutil.GetOrComputeTxIDFromEnvelope(block.Data.Data[0])
+		require.NoError(t, err)
+
+		if blockNum < firstAvailableBlock {
+			_, err := store.RetrieveBlockByNumber(blockNum)
+			require.Equal(t, expectedErr, err)
+			_, err = store.RetrieveBlockByHash(blockHash)
+			require.Equal(t, expectedErr, err)
+			_, err = store.RetrieveBlockByTxID(txID)
+			require.Equal(t, expectedErr, err)
+			_, err = store.RetrieveTxByID(txID)
+			require.Equal(t, expectedErr, err)
+			_, err = store.RetrieveTxByBlockNumTranNum(blockNum, 0)
+			require.Equal(t, expectedErr, err)
+			_, err = store.RetrieveBlocks(blockNum)
+			require.Equal(t, expectedErr, err)
+			// the transaction IDs of the pruned blocks are still maintained in the index
+			exists, err := store.TxIDExists(txID)
+			require.NoError(t, err)
+			require.True(t, exists)
+			continue
+		}
+
+		b, err := store.RetrieveBlockByNumber(blockNum)
+		require.NoError(t, err)
+		require.Equal(t, block, b)
+		b, err = store.RetrieveBlockByHash(blockHash)
+		require.NoError(t, err)
+		require.Equal(t, block, b)
+		b, err = store.RetrieveBlockByTxID(txID)
+		require.NoError(t, err)
+		require.Equal(t, block, b)
+		txEnv, err := store.RetrieveTxByID(txID)
+		require.NoError(t, err)
+		require.Equal(t, block.Data.Data[0], protoutil.MarshalOrPanic(txEnv))
+	}
+
+	itr, err := store.RetrieveBlocks(firstAvailableBlock)
+	require.NoError(t, err)
+	defer itr.Close()
+	b, err := itr.Next()
+	require.NoError(t, err)
+	require.Equal(t, blocks[firstAvailableBlock], b)
+}
+
+func assertBlockFileExists(t *testing.T, store *BlockStore, fileNum int, expectedExists bool) {
+	_, err := os.Stat(deriveBlockfilePath(store.fileMgr.rootDir, fileNum))
+	if expectedExists {
+		require.NoError(t, err)
+		return
+	}
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestPruneBlockfiles(t *testing.T) {
+	env := newTestEnv(t, NewConf(t.TempDir(), 0))
+	defer env.Cleanup()
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	blocks := testutil.ConstructTestBlocks(t, 30)
+	for i, b := range blocks {
+		require.NoError(t, store.AddBlock(b))
+		if i != 0 && i%10 == 0 {
+			// block ranges in files [(0, 10):file0, (11,20):file1, (21,29):file2]
+			store.fileMgr.moveToNextFile()
+		}
+	}
+
+	// the block [5] lies in the file0, no block file can be deleted
+	require.NoError(t, store.PruneBlockfiles(5))
+	assertBlocksPruned(t, store, blocks, 0)
+
+	// the block [13] lies in the file1, the file0 is deleted
+	require.NoError(t, store.PruneBlockfiles(13))
+	assertBlocksPruned(t, store, blocks, 13)
+	assertBlockFileExists(t, store, 0, false)
+
+	// the block [18] lies in the same file as the first available block
+	require.NoError(t, store.PruneBlockfiles(18))
+	assertBlocksPruned(t, store, blocks, 13)
+	assertBlockFileExists(t, store, 1, true)
+
+	require.NoError(t, store.PruneBlockfiles(22))
+	assertBlocksPruned(t, store, blocks, 22)
+	assertBlockFileExists(t, store, 1, false)
+	assertBlockFileExists(t, store, 2, true)
+}
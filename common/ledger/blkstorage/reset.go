@@ -142,7 +142,7 @@ const (
 // the existing file (if present). This helps in achieving fail-safe behviour of reset utility
 func recordHeightIfGreaterThanPreviousRecording(ledgerDir string) error {
 	logger.Infof("Preparing to record current height for ledger at [%s]", ledgerDir)
-	blkfilesInfo, err := constructBlockfilesInfo(ledgerDir)
+	blkfilesInfo, err := constructBlockfilesInfo(ledgerDir, nil)
 	if err != nil {
 		return err
 	}
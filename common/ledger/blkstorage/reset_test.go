@@ -191,7 +191,7 @@ func TestRecordHeight(t *testing.T) {
 	fileInfo, err := os.Stat(lastFile)
 	require.NoError(t, err)
 	require.NoError(t, os.Truncate(lastFile, fileInfo.Size()/2))
-	blkfilesInfo, err := constructBlockfilesInfo(ledgerDir)
+	blkfilesInfo, err := constructBlockfilesInfo(ledgerDir, nil)
 	require.NoError(t, err)
 	require.True(t, blkfilesInfo.lastPersistedBlock < 59)
 	require.NoError(t, recordHeightIfGreaterThanPreviousRecording(ledgerDir))
@@ -165,6 +165,19 @@ func addIndexEntriesToBeDeleted(batch *leveldbhelper.UpdateBatch, blockInfo *ser
 			batch.Delete(constructTxIDKey(txOffset.txID, blockInfo.blockHeader.Number, uint64(i)))
 		}
 	}
+
+	// the chaincode name and endorser indexes are optional and the offline rollback is not aware of whether
+	// they are maintained, so their entries are always deleted
+	for i, txOffset := range blockInfo.txOffsets {
+		attrs, err := extractTxAttrs(txOffset.txEnvelope)
+		if err != nil || attrs == nil {
+			continue
+		}
+		batch.Delete(constructTxAttrKey(chaincodeNameIdxKeyPrefix, attrs.chaincodeName, blockInfo.blockHeader.Number, uint64(i)))
+		for _, mspID := range attrs.endorserMSPIDs {
+			batch.Delete(constructTxAttrKey(endorserMSPIDIdxKeyPrefix, mspID, blockInfo.blockHeader.Number, uint64(i)))
+		}
+	}
 	return nil
 }
 
@@ -259,7 +272,7 @@ func validateLedgerID(ledgerDir, ledgerID string) error {
 
 func validateTargetBlkNum(ledgerDir string, targetBlockNum uint64) error {
 	logger.Debugf("Validating the given block number [%d] against the ledger block height", targetBlockNum)
-	blkfilesInfo, err := constructBlockfilesInfo(ledgerDir)
+	blkfilesInfo, err := constructBlockfilesInfo(ledgerDir, nil)
 	if err != nil {
 		return err
 	}
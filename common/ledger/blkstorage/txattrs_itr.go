@@ -0,0 +1,193 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// txAttrs captures the attributes of an endorser transaction that are maintained in the chaincode name and
+// endorser indexes
+type txAttrs struct {
+	chaincodeName  string
+	endorserMSPIDs []string
+}
+
+// extractTxAttrs returns the name of the chaincode invoked by the endorser transaction in the envelope and the
+// distinct MSP IDs of its endorsers. A nil value is returned for the other types of transactions
+func extractTxAttrs(txEnvelopeBytes []byte) (*txAttrs, error) {
+	env, err := protoutil.UnmarshalEnvelope(txEnvelopeBytes)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := protoutil.UnmarshalPayload(env.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, errors.New("transaction payload header is nil")
+	}
+	chdr, err := protoutil.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, nil
+	}
+	hdrExt, err := protoutil.UnmarshalChaincodeHeaderExtension(chdr.Extension)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := protoutil.UnmarshalTransaction(payload.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := &txAttrs{chaincodeName: hdrExt.GetChaincodeId().GetName()}
+	seen := map[string]bool{}
+	for _, action := range tx.Actions {
+		ccActionPayload, err := protoutil.UnmarshalChaincodeActionPayload(action.Payload)
+		if err != nil {
+			return nil, err
+		}
+		for _, endorsement := range ccActionPayload.GetAction().GetEndorsements() {
+			endorser, err := protoutil.UnmarshalSerializedIdentity(endorsement.Endorser)
+			if err != nil {
+				return nil, err
+			}
+			if endorser.Mspid == "" || seen[endorser.Mspid] {
+				continue
+			}
+			seen[endorser.Mspid] = true
+			attrs.endorserMSPIDs = append(attrs.endorserMSPIDs, endorser.Mspid)
+		}
+	}
+	return attrs, nil
+}
+
+// constructTxAttrKey constructs the key of the format `prefix:len(value):value:BlkNum:TxNum`
+func constructTxAttrKey(prefix byte, value string, blkNum, txNum uint64) []byte {
+	k := constructTxAttrKeyPrefix(prefix, value)
+	k = append(k, util.EncodeOrderPreservingVarUint64(blkNum)...)
+	return append(k, util.EncodeOrderPreservingVarUint64(txNum)...)
+}
+
+func constructTxAttrKeyPrefix(prefix byte, value string) []byte {
+	k := append(
+		[]byte{prefix},
+		util.EncodeOrderPreservingVarUint64(uint64(len(value)))...,
+	)
+	return append(k, value...)
+}
+
+func decodeTxAttrKey(key []byte) (uint64, uint64, error) {
+	valueLen, n, err := util.DecodeOrderPreservingVarUint64(key[1:])
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "invalid transaction attribute key {%x}", key)
+	}
+	remainingBytes := key[1+n:]
+	if len(remainingBytes) <= int(valueLen) {
+		return 0, 0, errors.Errorf("invalid transaction attribute key {%x}, fewer bytes present", key)
+	}
+	remainingBytes = remainingBytes[valueLen:]
+	blkNum, n, err := util.DecodeOrderPreservingVarUint64(remainingBytes)
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "invalid transaction attribute key {%x}", key)
+	}
+	txNum, _, err := util.DecodeOrderPreservingVarUint64(remainingBytes[n:])
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "invalid transaction attribute key {%x}", key)
+	}
+	return blkNum, txNum, nil
+}
+
+func encodeTxAttrIndexVal(txID string, validationCode peer.TxValidationCode) []byte {
+	buffer := proto.NewBuffer(nil)
+	// the errors are not checked as encoding a varint or bytes into a buffer does not fail
+	buffer.EncodeVarint(uint64(validationCode))
+	buffer.EncodeRawBytes([]byte(txID))
+	return buffer.Bytes()
+}
+
+func decodeTxAttrIndexVal(b []byte) (string, peer.TxValidationCode, error) {
+	buffer := proto.NewBuffer(b)
+	validationCode, err := buffer.DecodeVarint()
+	if err != nil {
+		return "", 0, errors.Wrap(err, "error decoding the validation code")
+	}
+	txID, err := buffer.DecodeRawBytes(false)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "error decoding the transaction ID")
+	}
+	return string(txID), peer.TxValidationCode(validationCode), nil
+}
+
+// TxIDsByAttrItr iterates over the transactions that match a value of an attribute in the chaincode name or
+// the endorser index, in the order of their commit
+type TxIDsByAttrItr struct {
+	dbItr *leveldbhelper.Iterator
+}
+
+// retrieveTxIDsByAttr returns an iterator over the transactions that match the given value of the attribute.
+// The transactions in the blocks below the first available block are skipped
+func (mgr *blockfileMgr) retrieveTxIDsByAttr(attr IndexableAttr, value string) (*TxIDsByAttrItr, error) {
+	var prefix byte
+	switch attr {
+	case IndexableAttrChaincodeName:
+		prefix = chaincodeNameIdxKeyPrefix
+	case IndexableAttrEndorserMSPID:
+		prefix = endorserMSPIDIdxKeyPrefix
+	default:
+		return nil, errors.Errorf("transactions cannot be retrieved by the attribute [%s]", attr)
+	}
+	if !mgr.index.isAttributeIndexed(attr) {
+		return nil, errors.Errorf("transactions cannot be retrieved by the attribute [%s] as it is not maintained in index", attr)
+	}
+	dbItr, err := mgr.index.db.GetIterator(
+		constructTxAttrKey(prefix, value, mgr.firstAvailableBlockNumber(), 0),
+		append(constructTxAttrKeyPrefix(prefix, value), 0xff),
+	)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while creating iterator over the index of the attribute [%s]", attr)
+	}
+	return &TxIDsByAttrItr{dbItr: dbItr}, nil
+}
+
+// Next returns the next transaction or nil when the iterator is exhausted
+func (itr *TxIDsByAttrItr) Next() (*TxIDInfo, error) {
+	if !itr.dbItr.Next() {
+		if err := itr.dbItr.Error(); err != nil {
+			return nil, errors.Wrap(err, "error while iterating over the index of a transaction attribute")
+		}
+		return nil, nil
+	}
+	blockNum, txNum, err := decodeTxAttrKey(itr.dbItr.Key())
+	if err != nil {
+		return nil, err
+	}
+	txID, validationCode, err := decodeTxAttrIndexVal(itr.dbItr.Value())
+	if err != nil {
+		return nil, err
+	}
+	return &TxIDInfo{
+		TxID:           txID,
+		BlockNum:       blockNum,
+		TxNum:          txNum,
+		ValidationCode: validationCode,
+	}, nil
+}
+
+// Close releases the resources held by the iterator
+func (itr *TxIDsByAttrItr) Close() {
+	itr.dbItr.Release()
+}
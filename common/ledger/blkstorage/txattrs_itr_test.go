@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+	"github.com/hyperledger/fabric/internal/pkg/txflags"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrieveTxIDsByAttr(t *testing.T) {
+	_, gb := testutil.NewBlockGenerator(t, "testLedger", true)
+	blocks := []*common.Block{gb}
+	for i := 1; i < 5; i++ {
+		block := testutil.ConstructBlockFromBlockDetails(t,
+			&testutil.BlockDetails{
+				BlockNum:     uint64(i),
+				PreviousHash: protoutil.BlockHeaderHash(blocks[i-1].Header),
+				Txs: []*testutil.TxDetails{
+					{TxID: fmt.Sprintf("tx-%d-0", i), ChaincodeName: "cc1", Type: common.HeaderType_ENDORSER_TRANSACTION},
+					{TxID: fmt.Sprintf("tx-%d-1", i), ChaincodeName: "cc2", Type: common.HeaderType_ENDORSER_TRANSACTION},
+				},
+			},
+			true,
+		)
+		txValidationFlags := txflags.NewWithValues(2, peer.TxValidationCode_VALID)
+		txValidationFlags.SetFlag(1, peer.TxValidationCode_MVCC_READ_CONFLICT)
+		block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txValidationFlags
+		blocks = append(blocks, block)
+	}
+
+	expectedTxIDs := func(txNum uint64, validationCode peer.TxValidationCode) []*TxIDInfo {
+		var txIDInfos []*TxIDInfo
+		for blockNum := uint64(1); blockNum < 5; blockNum++ {
+			txIDInfos = append(txIDInfos, &TxIDInfo{
+				TxID:           fmt.Sprintf("tx-%d-%d", blockNum, txNum),
+				BlockNum:       blockNum,
+				TxNum:          txNum,
+				ValidationCode: validationCode,
+			})
+		}
+		return txIDInfos
+	}
+
+	collect := func(itr *TxIDsByAttrItr, err error) []*TxIDInfo {
+		require.NoError(t, err)
+		defer itr.Close()
+		var txIDInfos []*TxIDInfo
+		for {
+			txIDInfo, err := itr.Next()
+			require.NoError(t, err)
+			if txIDInfo == nil {
+				return txIDInfos
+			}
+			txIDInfos = append(txIDInfos, txIDInfo)
+		}
+	}
+
+	extendedAttrsToIndex := append([]IndexableAttr{IndexableAttrChaincodeName, IndexableAttrEndorserMSPID}, attrsToIndex...)
+
+	verify := func(t *testing.T, conf *Conf) {
+		env := newTestEnvSelectiveIndexing(t, conf, extendedAttrsToIndex, &disabled.Provider{})
+		store, err := env.provider.Open("testLedger")
+		require.NoError(t, err)
+		for _, block := range blocks {
+			require.NoError(t, store.AddBlock(block))
+		}
+
+		verifyStore := func(store *BlockStore) {
+			require.Equal(t, expectedTxIDs(0, peer.TxValidationCode_VALID), collect(store.RetrieveTxIDsByChaincodeName("cc1")))
+			require.Equal(t, expectedTxIDs(1, peer.TxValidationCode_MVCC_READ_CONFLICT), collect(store.RetrieveTxIDsByChaincodeName("cc2")))
+			require.Empty(t, collect(store.RetrieveTxIDsByChaincodeName("cc")))
+			require.Empty(t, collect(store.RetrieveTxIDsByChaincodeName("cc10")))
+			require.Len(t, collect(store.RetrieveTxIDsByEndorserMSPID("SampleOrg")), 8)
+			require.Empty(t, collect(store.RetrieveTxIDsByEndorserMSPID("AnotherOrg")))
+		}
+		verifyStore(store)
+		env.Cleanup()
+
+		// the chaincode name and endorser indexes are rebuilt along with the other indexes
+		require.NoError(t, DeleteBlockStoreIndex(conf.blockStorageDir))
+		env = newTestEnvSelectiveIndexing(t, conf, extendedAttrsToIndex, &disabled.Provider{})
+		defer env.Cleanup()
+		store, err = env.provider.Open("testLedger")
+		require.NoError(t, err)
+		verifyStore(store)
+	}
+
+	t.Run("without-codec", func(t *testing.T) {
+		verify(t, NewConf(t.TempDir(), 0))
+	})
+
+	t.Run("with-codec", func(t *testing.T) {
+		conf := NewConf(t.TempDir(), 0)
+		conf.SetBlockCodec(&gzipBlockCodec{})
+		verify(t, conf)
+	})
+
+	t.Run("not-indexed", func(t *testing.T) {
+		env := newTestEnv(t, NewConf(t.TempDir(), 0))
+		defer env.Cleanup()
+		store, err := env.provider.Open("testLedger")
+		require.NoError(t, err)
+		_, err = store.RetrieveTxIDsByChaincodeName("cc1")
+		require.EqualError(t, err, "transactions cannot be retrieved by the attribute [ChaincodeName] as it is not maintained in index")
+		_, err = store.RetrieveTxIDsByEndorserMSPID("SampleOrg")
+		require.EqualError(t, err, "transactions cannot be retrieved by the attribute [EndorserMSPID] as it is not maintained in index")
+		_, err = store.fileMgr.retrieveTxIDsByAttr(IndexableAttrTxID, "tx-1-0")
+		require.EqualError(t, err, "transactions cannot be retrieved by the attribute [TxID]")
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		conf := NewConf(t.TempDir(), 0)
+		env := newTestEnvSelectiveIndexing(t, conf, extendedAttrsToIndex, &disabled.Provider{})
+		store, err := env.provider.Open("testLedger")
+		require.NoError(t, err)
+		for _, block := range blocks {
+			require.NoError(t, store.AddBlock(block))
+		}
+		env.Cleanup()
+
+		require.NoError(t, Rollback(conf.blockStorageDir, "testLedger", 2, &IndexConfig{AttrsToIndex: attrsToIndex}))
+		env = newTestEnvSelectiveIndexing(t, conf, extendedAttrsToIndex, &disabled.Provider{})
+		defer env.Cleanup()
+		store, err = env.provider.Open("testLedger")
+		require.NoError(t, err)
+		require.Equal(t, expectedTxIDs(0, peer.TxValidationCode_VALID)[:2], collect(store.RetrieveTxIDsByChaincodeName("cc1")))
+		require.Len(t, collect(store.RetrieveTxIDsByEndorserMSPID("SampleOrg")), 4)
+	})
+}
+
+func TestExtractTxAttrs(t *testing.T) {
+	env, _, err := testutil.ConstructTransaction(t, []byte("results"), "txid", true)
+	require.NoError(t, err)
+	attrs, err := extractTxAttrs(protoutil.MarshalOrPanic(env))
+	require.NoError(t, err)
+	require.Equal(t, &txAttrs{chaincodeName: "foo", endorserMSPIDs: []string{"SampleOrg"}}, attrs)
+
+	env, _, err = testutil.ConstructTransactionWithHeaderType(t, []byte("results"), "txid", true, common.HeaderType_CONFIG)
+	require.NoError(t, err)
+	attrs, err = extractTxAttrs(protoutil.MarshalOrPanic(env))
+	require.NoError(t, err)
+	require.Nil(t, attrs)
+
+	_, err = extractTxAttrs([]byte("garbage"))
+	require.Error(t, err)
+}
+
+func TestTxAttrKeyAndValue(t *testing.T) {
+	key := constructTxAttrKey(chaincodeNameIdxKeyPrefix, "mycc", 10, 3)
+	blockNum, txNum, err := decodeTxAttrKey(key)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), blockNum)
+	require.Equal(t, uint64(3), txNum)
+
+	_, _, err = decodeTxAttrKey(constructTxAttrKeyPrefix(chaincodeNameIdxKeyPrefix, "mycc"))
+	require.EqualError(t, err, fmt.Sprintf("invalid transaction attribute key {%x}, fewer bytes present", constructTxAttrKeyPrefix(chaincodeNameIdxKeyPrefix, "mycc")))
+
+	txID, validationCode, err := decodeTxAttrIndexVal(encodeTxAttrIndexVal("txid", peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE))
+	require.NoError(t, err)
+	require.Equal(t, "txid", txID)
+	require.Equal(t, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE, validationCode)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// TxIDInfo identifies a transaction in the block store along with its validation code
+type TxIDInfo struct {
+	TxID           string
+	BlockNum       uint64
+	TxNum          uint64
+	ValidationCode peer.TxValidationCode
+}
+
+// TxIDsItr iterates over the transactions in a range of blocks, in the order of their commit
+type TxIDsItr struct {
+	mgr   *blockfileMgr
+	dbItr *leveldbhelper.Iterator
+
+	// with a block codec, the transaction envelopes cannot be read individually from the block files and hence,
+	// the transaction IDs are extracted from the decoded block, which is retained for its remaining transactions
+	decodedBlockNum   uint64
+	decodedBlockTxIDs []string
+}
+
+// retrieveTxIDsInRange returns an iterator over the transactions in the blocks from startBlockNum (inclusive) to
+// endBlockNum (exclusive). The range is walked via the <blockNum, tranNum> index and the transaction IDs are read
+// from the transaction envelopes, so that the block bodies are not decoded, unless the block store uses a codec
+func (mgr *blockfileMgr) retrieveTxIDsInRange(startBlockNum, endBlockNum uint64) (*TxIDsItr, error) {
+	if !mgr.index.isAttributeIndexed(IndexableAttrBlockNumTranNum) || !mgr.index.isAttributeIndexed(IndexableAttrTxID) {
+		return nil, errors.New("transaction IDs cannot be iterated as <blockNumber, transactionNumber> tuple or transaction IDs are not maintained in index")
+	}
+	if firstAvailableBlockNum := mgr.firstAvailableBlockNumber(); startBlockNum < firstAvailableBlockNum {
+		startBlockNum = firstAvailableBlockNum
+	}
+	if endBlockNum < startBlockNum {
+		endBlockNum = startBlockNum
+	}
+	dbItr, err := mgr.index.db.GetIterator(
+		constructBlockNumTranNumKey(startBlockNum, 0),
+		constructBlockNumTranNumKey(endBlockNum, 0),
+	)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error while creating iterator over <blockNumber, transactionNumber> index")
+	}
+	return &TxIDsItr{mgr: mgr, dbItr: dbItr}, nil
+}
+
+// Next returns the next transaction or nil when the iterator is exhausted
+func (itr *TxIDsItr) Next() (*TxIDInfo, error) {
+	if !itr.dbItr.Next() {
+		if err := itr.dbItr.Error(); err != nil {
+			return nil, errors.Wrap(err, "error while iterating over <blockNumber, transactionNumber> index")
+		}
+		return nil, nil
+	}
+	blockNum, txNum, err := decodeBlockNumTranNumKey(itr.dbItr.Key())
+	if err != nil {
+		return nil, err
+	}
+	txLoc := &fileLocPointer{}
+	if err := txLoc.unmarshal(itr.dbItr.Value()); err != nil {
+		return nil, err
+	}
+	txID, err := itr.txID(blockNum, txNum, txLoc)
+	if err != nil {
+		return nil, err
+	}
+
+	indexValBytes, err := itr.mgr.index.db.Get(constructTxIDKey(txID, blockNum, txNum))
+	if err != nil {
+		return nil, err
+	}
+	if indexValBytes == nil {
+		return nil, errors.Errorf("no entry in txID index for transaction [%s] at <%d, %d>", txID, blockNum, txNum)
+	}
+	indexVal := &TxIDIndexValue{}
+	if err := proto.Unmarshal(indexValBytes, indexVal); err != nil {
+		return nil, errors.Wrapf(err, "unexpected error while unmarshalling bytes [%#v] into TxIDIndexValProto", indexValBytes)
+	}
+	return &TxIDInfo{
+		TxID:           txID,
+		BlockNum:       blockNum,
+		TxNum:          txNum,
+		ValidationCode: peer.TxValidationCode(indexVal.TxValidationCode),
+	}, nil
+}
+
+func (itr *TxIDsItr) txID(blockNum, txNum uint64, txLoc *fileLocPointer) (string, error) {
+	if itr.mgr.codec == nil {
+		txEnvelopeBytes, err := itr.mgr.fetchRawBytes(txLoc)
+		if err != nil {
+			return "", err
+		}
+		_, n := proto.DecodeVarint(txEnvelopeBytes)
+		return protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes[n:])
+	}
+
+	if itr.decodedBlockTxIDs == nil || itr.decodedBlockNum != blockNum {
+		block, err := itr.mgr.fetchBlock(txLoc)
+		if err != nil {
+			return "", err
+		}
+		txIDs := make([]string, len(block.Data.Data))
+		for i, txEnvelopeBytes := range block.Data.Data {
+			if txIDs[i], err = protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes); err != nil {
+				return "", err
+			}
+		}
+		itr.decodedBlockNum = blockNum
+		itr.decodedBlockTxIDs = txIDs
+	}
+	if txNum >= uint64(len(itr.decodedBlockTxIDs)) {
+		return "", errors.Errorf("transaction number [%d] is out of range for block [%d]", txNum, blockNum)
+	}
+	return itr.decodedBlockTxIDs[txNum], nil
+}
+
+// Close releases the resources held by the iterator
+func (itr *TxIDsItr) Close() {
+	itr.dbItr.Release()
+}
+
+func decodeBlockNumTranNumKey(key []byte) (uint64, uint64, error) {
+	blockNum, n, err := util.DecodeOrderPreservingVarUint64(key[1:])
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "invalid <blockNumber, transactionNumber> key {%x}", key)
+	}
+	txNum, _, err := util.DecodeOrderPreservingVarUint64(key[1+n:])
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "invalid <blockNumber, transactionNumber> key {%x}", key)
+	}
+	return blockNum, txNum, nil
+}
@@ -0,0 +1,97 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+	"github.com/hyperledger/fabric/internal/pkg/txflags"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrieveTxIDsInRange(t *testing.T) {
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	for _, block := range blocks[1:] {
+		txValidationFlags := txflags.NewWithValues(len(block.Data.Data), peer.TxValidationCode_VALID)
+		txValidationFlags.SetFlag(0, peer.TxValidationCode_MVCC_READ_CONFLICT)
+		block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txValidationFlags
+	}
+
+	expectedTxIDs := func(startBlockNum, endBlockNum uint64) []*TxIDInfo {
+		var txIDInfos []*TxIDInfo
+		for _, block := range blocks[startBlockNum:endBlockNum] {
+			txValidationFlags := txflags.ValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+			for txNum, txEnvelopeBytes := range block.Data.Data {
+				txID, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+				require.NoError(t, err)
+				txIDInfos = append(txIDInfos, &TxIDInfo{
+					TxID:           txID,
+					BlockNum:       block.Header.Number,
+					TxNum:          uint64(txNum),
+					ValidationCode: txValidationFlags.Flag(txNum),
+				})
+			}
+		}
+		return txIDInfos
+	}
+
+	retrieveTxIDs := func(store *BlockStore, startBlockNum, endBlockNum uint64) []*TxIDInfo {
+		itr, err := store.RetrieveTxIDsInRange(startBlockNum, endBlockNum)
+		require.NoError(t, err)
+		defer itr.Close()
+		var txIDInfos []*TxIDInfo
+		for {
+			txIDInfo, err := itr.Next()
+			require.NoError(t, err)
+			if txIDInfo == nil {
+				return txIDInfos
+			}
+			txIDInfos = append(txIDInfos, txIDInfo)
+		}
+	}
+
+	verify := func(t *testing.T, conf *Conf) {
+		env := newTestEnv(t, conf)
+		defer env.Cleanup()
+		store, err := env.provider.Open("testLedger")
+		require.NoError(t, err)
+		for _, block := range blocks {
+			require.NoError(t, store.AddBlock(block))
+		}
+
+		require.Equal(t, expectedTxIDs(0, 10), retrieveTxIDs(store, 0, 10))
+		require.Equal(t, expectedTxIDs(3, 7), retrieveTxIDs(store, 3, 7))
+		require.Equal(t, expectedTxIDs(9, 10), retrieveTxIDs(store, 9, 20))
+		require.Empty(t, retrieveTxIDs(store, 7, 3))
+		require.Empty(t, retrieveTxIDs(store, 5, 5))
+		require.Empty(t, retrieveTxIDs(store, 10, 20))
+	}
+
+	t.Run("without-codec", func(t *testing.T) {
+		verify(t, NewConf(t.TempDir(), 0))
+	})
+
+	t.Run("with-codec", func(t *testing.T) {
+		conf := NewConf(t.TempDir(), 0)
+		conf.SetBlockCodec(&gzipBlockCodec{})
+		verify(t, conf)
+	})
+
+	t.Run("txid-not-indexed", func(t *testing.T) {
+		env := newTestEnvSelectiveIndexing(t, NewConf(t.TempDir(), 0), []IndexableAttr{IndexableAttrBlockNumTranNum}, &disabled.Provider{})
+		defer env.Cleanup()
+		store, err := env.provider.Open("testLedger")
+		require.NoError(t, err)
+		_, err = store.RetrieveTxIDsInRange(0, 10)
+		require.EqualError(t, err, "transaction IDs cannot be iterated as <blockNumber, transactionNumber> tuple or transaction IDs are not maintained in index")
+	})
+}
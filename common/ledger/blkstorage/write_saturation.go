@@ -0,0 +1,67 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"sync"
+	"time"
+)
+
+// writeSaturationWindow is the number of the most recent block appends over which the saturation is estimated
+const writeSaturationWindow = 10
+
+// writeSaturation estimates how saturated the write path of a block store is. As the blocks are appended one at a
+// time, there is no queue of pending writes to measure. Instead, the saturation is estimated as the fraction of the
+// time, since the start of the oldest of the recent appends, that is spent in appending the blocks, which includes
+// writing and syncing the block file and updating the index. When the appends take up nearly all of the time, the
+// block store is the bottleneck for the commits. As the time passes without an append, the estimate decays to zero.
+// The estimate uses a lock of its own, so that reading it does not contend with the block store operations
+type writeSaturation struct {
+	mutex   sync.Mutex
+	appends [writeSaturationWindow]appendSpan
+	next    int
+	count   int
+}
+
+type appendSpan struct {
+	start, end time.Time
+}
+
+// record records an append that started at the given time and has just finished
+func (w *writeSaturation) record(start time.Time) {
+	end := time.Now()
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.appends[w.next] = appendSpan{start, end}
+	w.next = (w.next + 1) % writeSaturationWindow
+	if w.count < writeSaturationWindow {
+		w.count++
+	}
+}
+
+// value returns the saturation estimate, in the range 0 to 1, as of the given time
+func (w *writeSaturation) value(now time.Time) float64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.count == 0 {
+		return 0
+	}
+	oldest := w.appends[(w.next-w.count+writeSaturationWindow)%writeSaturationWindow]
+	elapsed := now.Sub(oldest.start)
+	if elapsed <= 0 {
+		return 0
+	}
+	var busy time.Duration
+	for i := 0; i < w.count; i++ {
+		busy += w.appends[i].end.Sub(w.appends[i].start)
+	}
+	saturation := float64(busy) / float64(elapsed)
+	if saturation > 1 {
+		return 1
+	}
+	return saturation
+}
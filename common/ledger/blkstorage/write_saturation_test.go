@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package blkstorage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSaturation(t *testing.T) {
+	w := &writeSaturation{}
+	require.Equal(t, float64(0), w.value(time.Now()))
+
+	// a slow writer under load spends nearly all the time in appending the blocks
+	slowAppend := func() {
+		start := time.Now()
+		time.Sleep(20 * time.Millisecond)
+		w.record(start)
+	}
+	for i := 0; i < writeSaturationWindow; i++ {
+		slowAppend()
+	}
+	require.Greater(t, w.value(time.Now()), 0.8)
+
+	// the estimate decays when the appends stop
+	require.Eventually(t, func() bool {
+		return w.value(time.Now()) < 0.2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// an append that is quick relative to the time between the appends keeps the estimate low
+	for i := 0; i < writeSaturationWindow; i++ {
+		start := time.Now()
+		w.record(start)
+		time.Sleep(2 * time.Millisecond)
+	}
+	require.Less(t, w.value(time.Now()), 0.5)
+}
+
+func TestBlockStoreWriteSaturation(t *testing.T) {
+	env := newTestEnv(t, NewConf(t.TempDir(), 0))
+	defer env.Cleanup()
+
+	store, err := env.provider.Open("testLedger")
+	require.NoError(t, err)
+	defer store.Shutdown()
+	require.Equal(t, float64(0), store.WriteSaturation())
+
+	for _, b := range testutil.ConstructTestBlocks(t, 5) {
+		require.NoError(t, store.AddBlock(b))
+	}
+	saturation := store.WriteSaturation()
+	require.Greater(t, saturation, float64(0))
+	require.LessOrEqual(t, saturation, float64(1))
+}
@@ -49,7 +49,7 @@ type BlockGenerator struct {
 	blockNum     uint64
 	previousHash []byte
 	signTxs      bool
-	t            *testing.T
+	t            testing.TB
 }
 
 type TxDetails struct {
@@ -73,7 +73,7 @@ type signingIdentity interface {
 }
 
 // NewBlockGenerator instantiates new BlockGenerator for testing
-func NewBlockGenerator(t *testing.T, ledgerID string, signTxs bool) (*BlockGenerator, *common.Block) {
+func NewBlockGenerator(t testing.TB, ledgerID string, signTxs bool) (*BlockGenerator, *common.Block) {
 	gb, err := test.MakeGenesisBlock(ledgerID)
 	require.NoError(t, err)
 	gb.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txflags.NewWithValues(len(gb.Data.Data), pb.TxValidationCode_VALID)
@@ -123,7 +123,7 @@ func (bg *BlockGenerator) NextTestBlocks(numBlocks int) []*common.Block {
 
 // ConstructTransaction constructs a transaction for testing
 func ConstructTransaction(
-	t *testing.T,
+	t testing.TB,
 	simulationResults []byte,
 	txid string,
 	sign bool,
@@ -139,7 +139,7 @@ func ConstructTransaction(
 
 // ConstructTransaction constructs a transaction for testing with header type
 func ConstructTransactionWithHeaderType(
-	t *testing.T,
+	t testing.TB,
 	simulationResults []byte,
 	txid string,
 	sign bool,
@@ -191,7 +191,7 @@ func ConstructTransactionFromTxDetails(txDetails *TxDetails, sign bool) (*common
 	return txEnv, txID, err
 }
 
-func ConstructBlockFromBlockDetails(t *testing.T, blockDetails *BlockDetails, sign bool) *common.Block {
+func ConstructBlockFromBlockDetails(t testing.TB, blockDetails *BlockDetails, sign bool) *common.Block {
 	var envs []*common.Envelope
 	for _, txDetails := range blockDetails.Txs {
 		env, _, err := ConstructTransactionFromTxDetails(txDetails, sign)
@@ -204,7 +204,7 @@ func ConstructBlockFromBlockDetails(t *testing.T, blockDetails *BlockDetails, si
 }
 
 func ConstructBlockWithTxid(
-	t *testing.T,
+	t testing.TB,
 	blockNum uint64,
 	previousHash []byte,
 	simulationResults [][]byte,
@@ -223,7 +223,7 @@ func ConstructBlockWithTxid(
 }
 
 func ConstructBlockWithTxidHeaderType(
-	t *testing.T,
+	t testing.TB,
 	blockNum uint64,
 	previousHash []byte,
 	simulationResults [][]byte,
@@ -250,7 +250,7 @@ func ConstructBlockWithTxidHeaderType(
 
 // ConstructBlock constructs a single block
 func ConstructBlock(
-	t *testing.T,
+	t testing.TB,
 	blockNum uint64,
 	previousHash []byte,
 	simulationResults [][]byte,
@@ -268,7 +268,7 @@ func ConstructBlock(
 }
 
 // ConstructTestBlock constructs a single block with random contents
-func ConstructTestBlock(t *testing.T, blockNum uint64, numTx int, txSize int) *common.Block {
+func ConstructTestBlock(t testing.TB, blockNum uint64, numTx int, txSize int) *common.Block {
 	simulationResults := [][]byte{}
 	for i := 0; i < numTx; i++ {
 		simulationResults = append(simulationResults, ConstructRandomBytes(t, txSize))
@@ -279,7 +279,7 @@ func ConstructTestBlock(t *testing.T, blockNum uint64, numTx int, txSize int) *c
 // ConstructTestBlocks returns a series of blocks starting with blockNum=0.
 // The first block in the returned array is a config tx block that represents a genesis block
 // Except the genesis block, the size of each of the block would be the same.
-func ConstructTestBlocks(t *testing.T, numBlocks int) []*common.Block {
+func ConstructTestBlocks(t testing.TB, numBlocks int) []*common.Block {
 	bg, gb := NewBlockGenerator(t, "testchannelid", false)
 	blocks := []*common.Block{}
 	if numBlocks != 0 {
@@ -457,7 +457,7 @@ func ConstructSignedTxEnv(
 	return env, txid, nil
 }
 
-func SetTxID(t *testing.T, block *common.Block, txNum int, txID string) {
+func SetTxID(t testing.TB, block *common.Block, txNum int, txID string) {
 	envelopeBytes := block.Data.Data[txNum]
 	envelope, err := protoutil.UnmarshalEnvelope(envelopeBytes)
 	if err != nil {
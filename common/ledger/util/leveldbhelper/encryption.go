@@ -0,0 +1,87 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package leveldbhelper
+
+import (
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Encryptor encrypts and decrypts the values stored in the named dbs. The name of the db is passed as the ledger ID,
+// so that an implementation can use a separate key for each ledger
+type Encryptor interface {
+	Encrypt(ledgerID string, plaintext []byte) ([]byte, error)
+	Decrypt(ledgerID string, ciphertext []byte) ([]byte, error)
+}
+
+func encryptValue(encryptor Encryptor, dbName string, value []byte) ([]byte, error) {
+	if encryptor == nil {
+		return value, nil
+	}
+	// the capacity of the value is limited to its length, as the value may be a slice of the data of a batch and
+	// an encryptor that appends to the plaintext, such as for padding, would otherwise overwrite the batch data
+	ciphertext, err := encryptor.Encrypt(dbName, value[:len(value):len(value)])
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while encrypting value for db [%s]", dbName)
+	}
+	return ciphertext, nil
+}
+
+// decryptValue decrypts a value read from the db. A nil value, which represents an absent key, is returned as is
+// and an empty plaintext is returned as a non-nil empty value, so that the absence of a key can be told apart
+func decryptValue(encryptor Encryptor, dbName string, value []byte) ([]byte, error) {
+	if encryptor == nil || value == nil {
+		return value, nil
+	}
+	plaintext, err := encryptor.Decrypt(dbName, value)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while decrypting value for db [%s]", dbName)
+	}
+	if plaintext == nil {
+		plaintext = []byte{}
+	}
+	return plaintext, nil
+}
+
+// encryptBatch returns a copy of the leveldb batch with the values of the puts encrypted
+func encryptBatch(encryptor Encryptor, dbName string, batch *leveldb.Batch) (*leveldb.Batch, error) {
+	r := &batchEncryptor{
+		encryptor: encryptor,
+		dbName:    dbName,
+		batch:     &leveldb.Batch{},
+	}
+	if err := batch.Replay(r); err != nil {
+		return nil, err
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.batch, nil
+}
+
+type batchEncryptor struct {
+	encryptor Encryptor
+	dbName    string
+	batch     *leveldb.Batch
+	err       error
+}
+
+func (r *batchEncryptor) Put(key, value []byte) {
+	if r.err != nil {
+		return
+	}
+	ciphertext, err := encryptValue(r.encryptor, r.dbName, value)
+	if err != nil {
+		r.err = err
+		return
+	}
+	r.batch.Put(key, ciphertext)
+}
+
+func (r *batchEncryptor) Delete(key []byte) {
+	r.batch.Delete(key)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package leveldbhelper
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// testEncryptor prefixes the plaintext with the ledger ID and reverses the bytes, so that the stored
+// values differ from the plaintext and the decryption fails for a ciphertext of another ledger
+type testEncryptor struct{}
+
+func (e *testEncryptor) Encrypt(ledgerID string, plaintext []byte) ([]byte, error) {
+	return reverse(append([]byte(ledgerID+":"), plaintext...)), nil
+}
+
+func (e *testEncryptor) Decrypt(ledgerID string, ciphertext []byte) ([]byte, error) {
+	b := reverse(ciphertext)
+	prefix := []byte(ledgerID + ":")
+	if !bytes.HasPrefix(b, prefix) {
+		return nil, errors.Errorf("value is not encrypted for ledger [%s]", ledgerID)
+	}
+	return b[len(prefix):], nil
+}
+
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
+
+func TestEncryptedDBHandle(t *testing.T) {
+	dbPath := t.TempDir()
+	p, err := NewProvider(&Conf{DBPath: dbPath, ExpectedFormat: "2.0", Encryptor: &testEncryptor{}})
+	require.NoError(t, err)
+	defer p.Close()
+
+	db1 := p.GetDBHandle("db1")
+	require.NoError(t, db1.Put([]byte("key1"), []byte("value1"), true))
+	batch := db1.NewUpdateBatch()
+	batch.Put([]byte("key2"), []byte("value2"))
+	batch.Put([]byte("key3"), []byte{})
+	batch.Put([]byte("key4"), []byte("value4"))
+	batch.Delete([]byte("key4"))
+	require.NoError(t, db1.WriteBatch(batch, true))
+
+	// the values are stored encrypted
+	rawValue, err := p.db.Get(constructLevelKey("db1", []byte("key1")))
+	require.NoError(t, err)
+	require.Equal(t, reverse([]byte("db1:value1")), rawValue)
+	format, err := p.GetDataFormat()
+	require.NoError(t, err)
+	require.Equal(t, "2.0", format)
+
+	val, err := db1.Get([]byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+	val, err = db1.Get([]byte("key3"))
+	require.NoError(t, err)
+	require.NotNil(t, val)
+	require.Len(t, val, 0)
+	val, err = db1.Get([]byte("key4"))
+	require.NoError(t, err)
+	require.Nil(t, val)
+
+	vals, err := db1.GetMultiple([][]byte{[]byte("key1"), []byte("key2"), []byte("key4")})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("value1"), []byte("value2"), nil}, vals)
+
+	itr, err := db1.GetIterator(nil, nil)
+	require.NoError(t, err)
+	checkItrResults(t, itr, []string{"key1", "key2", "key3"}, []string{"value1", "value2", ""})
+	itr.Release()
+
+	// the snapshot reads decrypt the values and the export copies the encrypted values
+	snapshot, err := db1.GetSnapshot()
+	require.NoError(t, err)
+	val, err = snapshot.Get([]byte("key2"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), val)
+	exportPath := t.TempDir()
+	require.NoError(t, snapshot.ExportTo(exportPath))
+	snapshot.Release()
+	exported, err := NewProvider(&Conf{DBPath: exportPath, ExpectedFormat: "2.0", Encryptor: &testEncryptor{}})
+	require.NoError(t, err)
+	val, err = exported.GetDBHandle("db1").Get([]byte("key1"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+	exported.Close()
+
+	t.Run("decryption-failure", func(t *testing.T) {
+		require.NoError(t, p.db.Put(constructLevelKey("db2", []byte("key1")), []byte("not-encrypted"), true))
+		db2 := p.GetDBHandle("db2")
+		_, err := db2.Get([]byte("key1"))
+		require.EqualError(t, err, "error while decrypting value for db [db2]: value is not encrypted for ledger [db2]")
+
+		itr, err := db2.GetIterator(nil, nil)
+		require.NoError(t, err)
+		defer itr.Release()
+		require.True(t, itr.Next())
+		require.Nil(t, itr.Value())
+		require.EqualError(t, itr.Error(), "error while decrypting value for db [db2]: value is not encrypted for ledger [db2]")
+	})
+}
+
+// appendingEncryptor appends a padding to the plaintext in place, as the PKCS7 padding of the SW BCCSP does
+type appendingEncryptor struct{}
+
+var padding = bytes.Repeat([]byte{0xff}, 16)
+
+func (e *appendingEncryptor) Encrypt(ledgerID string, plaintext []byte) ([]byte, error) {
+	return append(plaintext, padding...), nil
+}
+
+func (e *appendingEncryptor) Decrypt(ledgerID string, ciphertext []byte) ([]byte, error) {
+	return ciphertext[:len(ciphertext)-len(padding)], nil
+}
+
+func TestEncryptedDBHandleBatchWithAppendingEncryptor(t *testing.T) {
+	p, err := NewProvider(&Conf{DBPath: t.TempDir(), Encryptor: &appendingEncryptor{}})
+	require.NoError(t, err)
+	defer p.Close()
+
+	db := p.GetDBHandle("db")
+	batch := db.NewUpdateBatch()
+	batch.Put([]byte("key1"), []byte("value1"))
+	batch.Put([]byte("key2"), []byte("value2"))
+	require.NoError(t, db.WriteBatch(batch, true))
+
+	itr, err := db.GetIterator(nil, nil)
+	require.NoError(t, err)
+	defer itr.Release()
+	checkItrResults(t, itr, []string{"key1", "key2"}, []string{"value1", "value2"})
+}
@@ -10,11 +10,13 @@ import (
 	"fmt"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/internal/fileutil"
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
 	goleveldbutil "github.com/syndtr/goleveldb/leveldb/util"
@@ -29,6 +31,9 @@ const (
 	opened
 )
 
+// openFunc opens the leveldb at the given path. This is a variable so that tests can inject open failures
+var openFunc = leveldb.OpenFile
+
 // DB - a wrapper on an actual store
 type DB struct {
 	conf    *Conf
@@ -65,6 +70,7 @@ func (dbInst *DB) Open() {
 		return
 	}
 	dbOpts := &opt.Options{}
+	dbInst.conf.Tuning.apply(dbOpts)
 	dbPath := dbInst.conf.DBPath
 	var err error
 	var dirEmpty bool
@@ -72,12 +78,50 @@ func (dbInst *DB) Open() {
 		panic(fmt.Sprintf("Error creating dir if missing: %s", err))
 	}
 	dbOpts.ErrorIfMissing = !dirEmpty
-	if dbInst.db, err = leveldb.OpenFile(dbPath, dbOpts); err != nil {
+	if dbInst.db, err = openWithRetry(dbInst.conf, dbOpts); err != nil {
 		panic(fmt.Sprintf("Error opening leveldb: %s", err))
 	}
 	dbInst.dbState = opened
 }
 
+func (t *Tuning) apply(dbOpts *opt.Options) {
+	if t == nil {
+		return
+	}
+	if t.BloomFilterBitsPerKey > 0 {
+		dbOpts.Filter = filter.NewBloomFilter(t.BloomFilterBitsPerKey)
+	}
+	if t.BlockCacheSize > 0 {
+		dbOpts.BlockCacheCapacity = t.BlockCacheSize
+	}
+	if t.WriteBufferSize > 0 {
+		dbOpts.WriteBuffer = t.WriteBufferSize
+	}
+	if t.DisableCompression {
+		dbOpts.Compression = opt.NoCompression
+	}
+}
+
+// openWithRetry attempts to open the leveldb and, if configured, retries for `conf.OpenRetries` additional
+// times. The wait between the retries starts at `conf.OpenRetryInterval` and doubles after every attempt.
+// The error from the last attempt is returned if all the attempts fail
+func openWithRetry(conf *Conf, dbOpts *opt.Options) (*leveldb.DB, error) {
+	interval := conf.OpenRetryInterval
+	for attempt := 0; ; attempt++ {
+		db, err := openFunc(conf.DBPath, dbOpts)
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= conf.OpenRetries {
+			return nil, err
+		}
+		logger.Warningf("Error opening leveldb at path [%s] (attempt %d of %d), retrying in %s: %s",
+			conf.DBPath, attempt+1, conf.OpenRetries+1, interval, err)
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
 // IsEmpty returns whether or not a database is empty
 func (dbInst *DB) IsEmpty() (bool, error) {
 	dbInst.mutex.RLock()
@@ -118,6 +162,33 @@ func (dbInst *DB) Get(key []byte) ([]byte, error) {
 	return value, nil
 }
 
+// GetMultiple returns the values for the given keys, in the order of the keys, from a single snapshot of
+// the db. A nil value is returned for a key that is not present in the db
+func (dbInst *DB) GetMultiple(keys [][]byte) ([][]byte, error) {
+	dbInst.mutex.RLock()
+	defer dbInst.mutex.RUnlock()
+	snapshot, err := dbInst.db.GetSnapshot()
+	if err != nil {
+		logger.Errorf("Error acquiring leveldb snapshot: %s", err)
+		return nil, errors.Wrap(err, "error acquiring leveldb snapshot")
+	}
+	defer snapshot.Release()
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, err := snapshot.Get(key, dbInst.readOpts)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			logger.Errorf("Error retrieving leveldb key [%#v]: %s", key, err)
+			return nil, errors.Wrapf(err, "error retrieving leveldb key [%#v]", key)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
 // Put saves the key/value
 func (dbInst *DB) Put(key []byte, value []byte, sync bool) error {
 	dbInst.mutex.RLock()
@@ -159,6 +230,29 @@ func (dbInst *DB) GetIterator(startKey []byte, endKey []byte) iterator.Iterator
 	return dbInst.db.NewIterator(&goleveldbutil.Range{Start: startKey, Limit: endKey}, dbInst.readOpts)
 }
 
+// CompactRange compacts the underlying storage for the key range [start, limit). A nil start
+// represents the first key in the db and a nil limit represents a key after the last key in the db
+func (dbInst *DB) CompactRange(start, limit []byte) error {
+	dbInst.mutex.RLock()
+	defer dbInst.mutex.RUnlock()
+	if err := dbInst.db.CompactRange(goleveldbutil.Range{Start: start, Limit: limit}); err != nil {
+		return errors.Wrapf(err, "error while compacting leveldb at path [%s]", dbInst.conf.DBPath)
+	}
+	return nil
+}
+
+// SizeOf returns the approximate size of the file system space used by the keys in the range [start, limit).
+// The data that is not yet flushed from the memtable to the files is not accounted for
+func (dbInst *DB) SizeOf(start, limit []byte) (int64, error) {
+	dbInst.mutex.RLock()
+	defer dbInst.mutex.RUnlock()
+	sizes, err := dbInst.db.SizeOf([]goleveldbutil.Range{{Start: start, Limit: limit}})
+	if err != nil {
+		return 0, errors.Wrapf(err, "error while computing approximate size of leveldb at path [%s]", dbInst.conf.DBPath)
+	}
+	return sizes.Sum(), nil
+}
+
 // WriteBatch writes a batch
 func (dbInst *DB) WriteBatch(batch *leveldb.Batch, sync bool) error {
 	dbInst.mutex.RLock()
@@ -173,6 +267,17 @@ func (dbInst *DB) WriteBatch(batch *leveldb.Batch, sync bool) error {
 	return nil
 }
 
+// GetSnapshot returns a point-in-time read-only view of the db. The snapshot should be released after the use
+func (dbInst *DB) GetSnapshot() (*leveldb.Snapshot, error) {
+	dbInst.mutex.RLock()
+	defer dbInst.mutex.RUnlock()
+	snapshot, err := dbInst.db.GetSnapshot()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while obtaining snapshot of leveldb at path [%s]", dbInst.conf.DBPath)
+	}
+	return snapshot, nil
+}
+
 // FileLock encapsulate the DB that holds the file lock.
 // As the FileLock to be used by a single process/goroutine,
 // there is no need for the semaphore to synchronize the
@@ -11,9 +11,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 func TestLevelDBHelperWriteWithoutOpen(t *testing.T) {
@@ -213,3 +216,89 @@ func TestCreateDBInNonEmptyDir(t *testing.T) {
 	}()
 	db.Open()
 }
+
+func TestOpenWithRetries(t *testing.T) {
+	origOpenFunc := openFunc
+	defer func() { openFunc = origOpenFunc }()
+
+	failingOpenFunc := func(numFailures int) func(string, *opt.Options) (*leveldb.DB, error) {
+		numAttempts := 0
+		return func(path string, o *opt.Options) (*leveldb.DB, error) {
+			numAttempts++
+			if numAttempts <= numFailures {
+				return nil, errors.Errorf("open failure %d", numAttempts)
+			}
+			return origOpenFunc(path, o)
+		}
+	}
+
+	t.Run("no-retries", func(t *testing.T) {
+		env := newTestDBEnv(t, testDBPath)
+		defer env.cleanup()
+		openFunc = failingOpenFunc(1)
+		require.PanicsWithValue(t, "Error opening leveldb: open failure 1", env.db.Open)
+	})
+
+	t.Run("succeeds-on-retry", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(testDBPath))
+		defer os.RemoveAll(testDBPath)
+		openFunc = failingOpenFunc(1)
+		db := CreateDB(&Conf{DBPath: testDBPath, OpenRetries: 2, OpenRetryInterval: time.Millisecond})
+		defer db.Close()
+		db.Open()
+		require.NoError(t, db.Put([]byte("key"), []byte("value"), true))
+		val, err := db.Get([]byte("key"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), val)
+	})
+
+	t.Run("retries-exhausted", func(t *testing.T) {
+		require.NoError(t, os.RemoveAll(testDBPath))
+		defer os.RemoveAll(testDBPath)
+		openFunc = failingOpenFunc(3)
+		db := CreateDB(&Conf{DBPath: testDBPath, OpenRetries: 2, OpenRetryInterval: time.Millisecond})
+		require.PanicsWithValue(t, "Error opening leveldb: open failure 3", db.Open)
+	})
+}
+
+func TestTuning(t *testing.T) {
+	origOpenFunc := openFunc
+	defer func() { openFunc = origOpenFunc }()
+
+	var capturedOpts *opt.Options
+	openFunc = func(path string, o *opt.Options) (*leveldb.DB, error) {
+		capturedOpts = o
+		return origOpenFunc(path, o)
+	}
+
+	require.NoError(t, os.RemoveAll(testDBPath))
+	defer os.RemoveAll(testDBPath)
+	tuning := &Tuning{
+		BloomFilterBitsPerKey: 10,
+		BlockCacheSize:        16 * opt.MiB,
+		WriteBufferSize:       8 * opt.MiB,
+		DisableCompression:    true,
+	}
+	db := CreateDB(&Conf{DBPath: testDBPath, Tuning: tuning})
+	db.Open()
+	require.Equal(t, "leveldb.BuiltinBloomFilter", capturedOpts.GetFilter().Name())
+	require.Equal(t, 16*opt.MiB, capturedOpts.GetBlockCacheCapacity())
+	require.Equal(t, 8*opt.MiB, capturedOpts.GetWriteBuffer())
+	require.Equal(t, opt.NoCompression, capturedOpts.GetCompression())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, db.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)), true))
+	}
+	db.Close()
+
+	// the data remains readable when the db is reopened with the default options
+	db = CreateDB(&Conf{DBPath: testDBPath})
+	db.Open()
+	defer db.Close()
+	require.Nil(t, capturedOpts.GetFilter())
+	require.Equal(t, opt.SnappyCompression, capturedOpts.GetCompression())
+	for i := 0; i < 100; i++ {
+		val, err := db.Get([]byte(fmt.Sprintf("key%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value%d", i)), val)
+	}
+}
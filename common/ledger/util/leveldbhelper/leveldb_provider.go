@@ -10,11 +10,13 @@ import (
 	"bytes"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/hyperledger/fabric/common/ledger/dataformat"
 	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	goleveldbutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const (
@@ -26,6 +28,10 @@ const (
 	maxBatchSize = 1000000
 )
 
+// keyCountSampleSize is the maximum number of keys that are iterated over for estimating the number of keys in a range.
+// This is a variable so that tests can use a smaller sample
+var keyCountSampleSize = uint64(10000)
+
 var (
 	dbNameKeySep     = []byte{0x00}
 	lastKeyIndicator = byte(0x01)
@@ -42,14 +48,51 @@ type closeFunc func()
 // either the db is empty (i.e., opening for the first time) or the value
 // of the formatVersionKey is equal to `ExpectedFormat`. Otherwise, an error is returned.
 // A nil value for ExpectedFormat indicates that the format is never set and hence there is no such record.
+//
+// `OpenRetries` is the number of additional attempts made to open the db if the first attempt fails,
+// with a wait of `OpenRetryInterval` before the first retry that doubles after every subsequent retry.
+// A zero value for OpenRetries causes the open to fail immediately on the first error.
+//
+// `Encryptor`, if set, encrypts the values that are stored via the db handles obtained from the `Provider`,
+// with the name of the db passed as the ledger ID. The keys are stored unencrypted. The encryptor is
+// expected to remain the same for the lifetime of the db.
+//
+// `Tuning`, if set, overrides the goleveldb defaults that affect the performance of the db.
 type Conf struct {
-	DBPath         string
-	ExpectedFormat string
+	DBPath            string
+	ExpectedFormat    string
+	OpenRetries       int
+	OpenRetryInterval time.Duration
+	Encryptor         Encryptor
+	Tuning            *Tuning
+}
+
+// OpenRetry carries the values for the fields `OpenRetries` and `OpenRetryInterval` of the `Conf` to the components
+// that construct the `Conf` for their dbs. The zero value causes the open to fail immediately on the first error
+type OpenRetry struct {
+	Retries  int
+	Interval time.Duration
+}
+
+// Tuning contains the goleveldb options that affect the performance of a db. A zero value for a field leaves the
+// goleveldb default in place. All the options can be changed for an existing db, as they affect only the data that
+// is written after the change and the data written before the change remains readable
+type Tuning struct {
+	// BloomFilterBitsPerKey is the number of bits per key of the bloom filter that is kept for each table, which
+	// saves the disk reads for the keys that do not exist in a table. By default, no bloom filter is used
+	BloomFilterBitsPerKey int
+	// BlockCacheSize is the capacity, in bytes, of the cache of the uncompressed table blocks
+	BlockCacheSize int
+	// WriteBufferSize is the size, in bytes, of the memtable, which is sorted and written to a table when full
+	WriteBufferSize int
+	// DisableCompression disables the snappy compression of the table blocks
+	DisableCompression bool
 }
 
 // Provider enables to use a single leveldb as multiple logical leveldbs
 type Provider struct {
-	db *DB
+	db        *DB
+	encryptor Encryptor
 
 	mux       sync.Mutex
 	dbHandles map[string]*DBHandle
@@ -61,9 +104,10 @@ type DataFormatInfo struct {
 	IsDBEmpty     bool   // set to true if the db does not contain any data
 }
 
-// RetrieveDataFormatInfo retrieves the DataFormatInfo for the db at the supplied `dbPath`
-func RetrieveDataFormatInfo(dbPath string) (*DataFormatInfo, error) {
-	db := CreateDB(&Conf{DBPath: dbPath})
+// RetrieveDataFormatInfo retrieves the DataFormatInfo for the db at the supplied `dbPath`, retrying the open
+// of the db as per the supplied `openRetry`
+func RetrieveDataFormatInfo(dbPath string, openRetry OpenRetry) (*DataFormatInfo, error) {
+	db := CreateDB(&Conf{DBPath: dbPath, OpenRetries: openRetry.Retries, OpenRetryInterval: openRetry.Interval})
 	db.Open()
 	defer db.Close()
 
@@ -96,6 +140,7 @@ func NewProvider(conf *Conf) (*Provider, error) {
 	}
 	return &Provider{
 		db:        db,
+		encryptor: conf.Encryptor,
 		dbHandles: make(map[string]*DBHandle),
 	}, nil
 }
@@ -169,7 +214,11 @@ func (p *Provider) GetDBHandle(dbName string) *DBHandle {
 			defer p.mux.Unlock()
 			delete(p.dbHandles, dbName)
 		}
-		dbHandle = &DBHandle{dbName, p.db, closeFunc}
+		dbHandle = &DBHandle{dbName: dbName, db: p.db, closeFunc: closeFunc}
+		// the internal db holds the data format, which is checked before the db is opened with an encryptor
+		if dbName != internalDBName {
+			dbHandle.encryptor = p.encryptor
+		}
 		p.dbHandles[dbName] = dbHandle
 	}
 	return dbHandle
@@ -192,15 +241,43 @@ type DBHandle struct {
 	dbName    string
 	db        *DB
 	closeFunc closeFunc
+	encryptor Encryptor
 }
 
 // Get returns the value for the given key
 func (h *DBHandle) Get(key []byte) ([]byte, error) {
-	return h.db.Get(constructLevelKey(h.dbName, key))
+	value, err := h.db.Get(constructLevelKey(h.dbName, key))
+	if err != nil {
+		return nil, err
+	}
+	return decryptValue(h.encryptor, h.dbName, value)
+}
+
+// GetMultiple returns the values for the given keys, in the order of the keys, from a single snapshot of the db.
+// A nil value is returned for a key that is not present
+func (h *DBHandle) GetMultiple(keys [][]byte) ([][]byte, error) {
+	levelKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		levelKeys[i] = constructLevelKey(h.dbName, key)
+	}
+	values, err := h.db.GetMultiple(levelKeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if values[i], err = decryptValue(h.encryptor, h.dbName, value); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
 }
 
 // Put saves the key/value
 func (h *DBHandle) Put(key []byte, value []byte, sync bool) error {
+	value, err := encryptValue(h.encryptor, h.dbName, value)
+	if err != nil {
+		return err
+	}
 	return h.db.Put(constructLevelKey(h.dbName, key), value, sync)
 }
 
@@ -277,7 +354,14 @@ func (h *DBHandle) WriteBatch(batch *UpdateBatch, sync bool) error {
 	if batch == nil || batch.leveldbBatch.Len() == 0 {
 		return nil
 	}
-	if err := h.db.WriteBatch(batch.leveldbBatch, sync); err != nil {
+	leveldbBatch := batch.leveldbBatch
+	if h.encryptor != nil {
+		var err error
+		if leveldbBatch, err = encryptBatch(h.encryptor, h.dbName, leveldbBatch); err != nil {
+			return err
+		}
+	}
+	if err := h.db.WriteBatch(leveldbBatch, sync); err != nil {
 		return err
 	}
 	return nil
@@ -299,7 +383,79 @@ func (h *DBHandle) GetIterator(startKey []byte, endKey []byte) (*Iterator, error
 		itr.Release()
 		return nil, errors.Wrapf(err, "internal leveldb error while obtaining db iterator")
 	}
-	return &Iterator{h.dbName, itr}, nil
+	return &Iterator{dbName: h.dbName, Iterator: itr, encryptor: h.encryptor}, nil
+}
+
+// Compact compacts the underlying storage for all the keys that belong to the dbName. This removes
+// the deleted and overwritten entries from the physical storage and does not alter the contents of the db
+func (h *DBHandle) Compact() error {
+	sKey := constructLevelKey(h.dbName, nil)
+	eKey := constructLevelKey(h.dbName, nil)
+	eKey[len(eKey)-1] = lastKeyIndicator
+	return h.db.CompactRange(sKey, eKey)
+}
+
+// ApproximateSize returns the approximate size of the file system space used by all the keys that belong to
+// the dbName. The data that is not yet flushed from the memtable to the files is not accounted for
+func (h *DBHandle) ApproximateSize() (int64, error) {
+	sKey := constructLevelKey(h.dbName, nil)
+	eKey := constructLevelKey(h.dbName, nil)
+	eKey[len(eKey)-1] = lastKeyIndicator
+	return h.db.SizeOf(sKey, eKey)
+}
+
+// ApproximateKeyCount returns an estimate of the number of keys that are present in the db between the
+// startKey (inclusive) and the endKey (exclusive). A nil startKey and a nil endKey carry the same meaning as in
+// the function `GetIterator`. The keys are counted by iterating over the range for at most `keyCountSampleSize`
+// keys. If the range contains more keys, the count is extrapolated from the ratio of the file system space used
+// by the complete range to the space used by the sampled keys. If the sampled keys are not yet flushed to the
+// files, the estimate cannot be computed and the remaining keys are counted instead
+func (h *DBHandle) ApproximateKeyCount(startKey []byte, endKey []byte) (uint64, error) {
+	itr, err := h.GetIterator(startKey, endKey)
+	if err != nil {
+		return 0, err
+	}
+	defer itr.Release()
+
+	count := uint64(0)
+	for itr.Next() {
+		count++
+		if count == keyCountSampleSize {
+			break
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return 0, errors.Wrap(err, "internal leveldb error while retrieving data from db iterator")
+	}
+	if count < keyCountSampleSize {
+		return count, nil
+	}
+
+	sKey := constructLevelKey(h.dbName, startKey)
+	eKey := constructLevelKey(h.dbName, endKey)
+	if endKey == nil {
+		eKey[len(eKey)-1] = lastKeyIndicator
+	}
+	// the limit of the sampled range is the key next to the last sampled key
+	sampleLimit := append(append([]byte{}, itr.Iterator.Key()...), 0x00)
+	sampleSize, err := h.db.SizeOf(sKey, sampleLimit)
+	if err != nil {
+		return 0, err
+	}
+	if sampleSize == 0 {
+		for itr.Next() {
+			count++
+		}
+		if err := itr.Error(); err != nil {
+			return 0, errors.Wrap(err, "internal leveldb error while retrieving data from db iterator")
+		}
+		return count, nil
+	}
+	totalSize, err := h.db.SizeOf(sKey, eKey)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(float64(count) * float64(totalSize) / float64(sampleSize)), nil
 }
 
 // Close closes the DBHandle after its db data have been deleted
@@ -309,6 +465,108 @@ func (h *DBHandle) Close() {
 	}
 }
 
+// GetSnapshot returns a point-in-time read-only view of the keys that belong to the dbName. The writes that are made
+// to the db after this call are not visible via the returned snapshot. The snapshot should be released after the use
+func (h *DBHandle) GetSnapshot() (*Snapshot, error) {
+	snapshot, err := h.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{dbName: h.dbName, snapshot: snapshot, encryptor: h.encryptor}, nil
+}
+
+// Snapshot is a point-in-time read-only view of a named db
+type Snapshot struct {
+	dbName    string
+	snapshot  *leveldb.Snapshot
+	encryptor Encryptor
+}
+
+// Get returns the value for the given key as of the time of the snapshot
+func (s *Snapshot) Get(key []byte) ([]byte, error) {
+	value, err := s.snapshot.Get(constructLevelKey(s.dbName, key), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving leveldb key [%#v] from snapshot", key)
+	}
+	return decryptValue(s.encryptor, s.dbName, value)
+}
+
+// GetIterator gets an handle to iterator over the snapshot. The iterator should be released after the use.
+// The semantics of startKey and endKey are the same as in the function `DBHandle.GetIterator`
+func (s *Snapshot) GetIterator(startKey []byte, endKey []byte) (*Iterator, error) {
+	itr, err := s.getRawIterator(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+	itr.encryptor = s.encryptor
+	return itr, nil
+}
+
+// getRawIterator returns an iterator over the snapshot that returns the values as stored in the db
+func (s *Snapshot) getRawIterator(startKey []byte, endKey []byte) (*Iterator, error) {
+	sKey := constructLevelKey(s.dbName, startKey)
+	eKey := constructLevelKey(s.dbName, endKey)
+	if endKey == nil {
+		eKey[len(eKey)-1] = lastKeyIndicator
+	}
+	itr := s.snapshot.NewIterator(&goleveldbutil.Range{Start: sKey, Limit: eKey}, nil)
+	if err := itr.Error(); err != nil {
+		itr.Release()
+		return nil, errors.Wrapf(err, "internal leveldb error while obtaining snapshot iterator")
+	}
+	return &Iterator{dbName: s.dbName, Iterator: itr}, nil
+}
+
+// ExportTo copies the keys present in the snapshot to a db with the same name in the leveldb at the given path.
+// The leveldb is created, if not already present, with the data format of the leveldb from which the snapshot is taken.
+// The values are copied as stored and hence, the values of an encrypted db are copied encrypted
+func (s *Snapshot) ExportTo(dbPath string) error {
+	format, err := s.snapshot.Get(constructLevelKey(internalDBName, formatVersionKey), nil)
+	if err != nil && err != leveldb.ErrNotFound {
+		return errors.Wrap(err, "error retrieving the data format from snapshot")
+	}
+	p, err := NewProvider(&Conf{DBPath: dbPath, ExpectedFormat: string(format)})
+	if err != nil {
+		return err
+	}
+	defer p.Close()
+
+	itr, err := s.getRawIterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer itr.Release()
+
+	h := p.GetDBHandle(s.dbName)
+	batch := h.NewUpdateBatch()
+	for itr.Next() {
+		value := itr.Value()
+		if value == nil {
+			value = []byte{}
+		}
+		batch.Put(itr.Key(), value)
+		if batch.Size() < maxBatchSize {
+			continue
+		}
+		if err := h.WriteBatch(batch, false); err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+	if err := itr.Error(); err != nil {
+		return errors.Wrap(err, "internal leveldb error while iterating snapshot")
+	}
+	return h.WriteBatch(batch, true)
+}
+
+// Release releases the snapshot
+func (s *Snapshot) Release() {
+	s.snapshot.Release()
+}
+
 // UpdateBatch encloses the details of multiple `updates`
 type UpdateBatch struct {
 	leveldbBatch *leveldb.Batch
@@ -353,6 +611,8 @@ func (b *UpdateBatch) Reset() {
 type Iterator struct {
 	dbName string
 	iterator.Iterator
+	encryptor Encryptor
+	err       error
 }
 
 // Key wraps actual leveldb iterator method
@@ -360,6 +620,29 @@ func (itr *Iterator) Key() []byte {
 	return retrieveAppKey(itr.Iterator.Key())
 }
 
+// Value wraps actual leveldb iterator method. For an encrypted db, the decrypted value is returned and a failure
+// in decrypting the value is reported via the function `Error`
+func (itr *Iterator) Value() []byte {
+	value := itr.Iterator.Value()
+	if itr.encryptor == nil {
+		return value
+	}
+	plaintext, err := decryptValue(itr.encryptor, itr.dbName, value)
+	if err != nil {
+		itr.err = err
+		return nil
+	}
+	return plaintext
+}
+
+// Error wraps actual leveldb iterator method
+func (itr *Iterator) Error() error {
+	if itr.err != nil {
+		return itr.err
+	}
+	return itr.Iterator.Error()
+}
+
 // Seek moves the iterator to the first key/value pair
 // whose key is greater than or equal to the given key.
 // It returns whether such pair exist.
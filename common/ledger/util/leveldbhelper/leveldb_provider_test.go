@@ -199,6 +199,37 @@ func TestBatchedUpdates(t *testing.T) {
 	}
 }
 
+func TestGetMultiple(t *testing.T) {
+	env := newTestProviderEnv(t, testDBPath)
+	defer env.cleanup()
+	p := env.provider
+
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+	batch := db1.NewUpdateBatch()
+	batch.Put([]byte("key1"), []byte("value1"))
+	batch.Put([]byte("key3"), []byte("value3"))
+	require.NoError(t, db1.WriteBatch(batch, true))
+	require.NoError(t, db2.Put([]byte("key2"), []byte("value2"), true))
+
+	values, err := db1.GetMultiple([][]byte{
+		[]byte("key3"), []byte("key2"), []byte("key1"), []byte("key4"), []byte("key3"),
+	})
+	require.NoError(t, err)
+	require.Equal(t,
+		[][]byte{[]byte("value3"), nil, []byte("value1"), nil, []byte("value3")},
+		values,
+	)
+
+	values, err = db1.GetMultiple(nil)
+	require.NoError(t, err)
+	require.Empty(t, values)
+
+	env.provider.Close()
+	_, err = db1.GetMultiple([][]byte{[]byte("key1")})
+	require.EqualError(t, err, "error acquiring leveldb snapshot: leveldb: closed")
+}
+
 func TestDrop(t *testing.T) {
 	env := newTestProviderEnv(t, testDBPath)
 	defer env.cleanup()
@@ -291,6 +322,183 @@ func TestDrop(t *testing.T) {
 	require.EqualError(t, db2.deleteAll(), "internal leveldb error while obtaining db iterator: leveldb: closed")
 }
 
+func TestCompact(t *testing.T) {
+	env := newTestProviderEnv(t, testDBPath)
+	defer env.cleanup()
+	p := env.provider
+
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+	for i := 0; i < 20; i++ {
+		require.NoError(t, db1.Put([]byte(createTestKey(i)), []byte(createTestValue("db1", i)), false))
+		require.NoError(t, db2.Put([]byte(createTestKey(i)), []byte(createTestValue("db2", i)), false))
+	}
+	for i := 0; i < 20; i += 2 {
+		require.NoError(t, db1.Delete([]byte(createTestKey(i)), false))
+	}
+
+	require.NoError(t, db1.Compact())
+	for i := 0; i < 20; i++ {
+		val, err := db1.Get([]byte(createTestKey(i)))
+		require.NoError(t, err)
+		if i%2 == 0 {
+			require.Nil(t, val)
+		} else {
+			require.Equal(t, createTestValue("db1", i), string(val))
+		}
+		val, err = db2.Get([]byte(createTestKey(i)))
+		require.NoError(t, err)
+		require.Equal(t, createTestValue("db2", i), string(val))
+	}
+
+	env.provider.Close()
+	require.EqualError(t, db1.Compact(), "error while compacting leveldb at path ["+testDBPath+"]: leveldb: closed")
+}
+
+func TestApproximateSize(t *testing.T) {
+	env := newTestProviderEnv(t, testDBPath)
+	defer env.cleanup()
+	p := env.provider
+
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+	size, err := db1.ApproximateSize()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), size)
+
+	batch := db1.NewUpdateBatch()
+	for i := 0; i < 1000; i++ {
+		batch.Put([]byte(createTestLongKey(i)), []byte(createTestValue("db1", i)))
+	}
+	require.NoError(t, db1.WriteBatch(batch, true))
+	require.NoError(t, db1.Compact())
+	sizeAfterWrites, err := db1.ApproximateSize()
+	require.NoError(t, err)
+	require.Greater(t, sizeAfterWrites, int64(0))
+	// the size of db2 is not influenced by the keys in db1
+	size, err = db2.ApproximateSize()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), size)
+
+	batch = db1.NewUpdateBatch()
+	for i := 0; i < 1000; i += 2 {
+		batch.Delete([]byte(createTestLongKey(i)))
+	}
+	require.NoError(t, db1.WriteBatch(batch, true))
+	require.NoError(t, db1.Compact())
+	size, err = db1.ApproximateSize()
+	require.NoError(t, err)
+	require.Less(t, size, sizeAfterWrites)
+
+	env.provider.Close()
+	_, err = db1.ApproximateSize()
+	require.EqualError(t, err, "error while computing approximate size of leveldb at path ["+testDBPath+"]: leveldb: closed")
+}
+
+func TestSnapshot(t *testing.T) {
+	p, err := NewProvider(&Conf{DBPath: t.TempDir(), ExpectedFormat: "2.0"})
+	require.NoError(t, err)
+	defer p.Close()
+
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+	for i := 0; i < 10; i++ {
+		require.NoError(t, db1.Put([]byte(createTestKey(i)), []byte(createTestValue("db1", i)), false))
+		require.NoError(t, db2.Put([]byte(createTestKey(i)), []byte(createTestValue("db2", i)), false))
+	}
+
+	snapshot, err := db1.GetSnapshot()
+	require.NoError(t, err)
+	defer snapshot.Release()
+
+	// the updates after the snapshot is taken are not visible via the snapshot
+	require.NoError(t, db1.Put([]byte(createTestKey(10)), []byte(createTestValue("db1", 10)), false))
+	require.NoError(t, db1.Delete([]byte(createTestKey(0)), false))
+
+	val, err := snapshot.Get([]byte(createTestKey(0)))
+	require.NoError(t, err)
+	require.Equal(t, createTestValue("db1", 0), string(val))
+	val, err = snapshot.Get([]byte(createTestKey(10)))
+	require.NoError(t, err)
+	require.Nil(t, val)
+
+	itr, err := snapshot.GetIterator(nil, nil)
+	require.NoError(t, err)
+	checkItrResults(t, itr, createTestKeys(0, 9), createTestValues("db1", 0, 9))
+	itr.Release()
+
+	t.Run("export", func(t *testing.T) {
+		exportPath := t.TempDir()
+		require.NoError(t, snapshot.ExportTo(exportPath))
+
+		exported, err := NewProvider(&Conf{DBPath: exportPath, ExpectedFormat: "2.0"})
+		require.NoError(t, err)
+		defer exported.Close()
+		itr, err := exported.GetDBHandle("db1").GetIterator(nil, nil)
+		require.NoError(t, err)
+		defer itr.Release()
+		checkItrResults(t, itr, createTestKeys(0, 9), createTestValues("db1", 0, 9))
+		empty, err := exported.GetDBHandle("db2").IsEmpty()
+		require.NoError(t, err)
+		require.True(t, empty)
+	})
+}
+
+func TestApproximateKeyCount(t *testing.T) {
+	env := newTestProviderEnv(t, testDBPath)
+	defer env.cleanup()
+	p := env.provider
+
+	defer func(s uint64) { keyCountSampleSize = s }(keyCountSampleSize)
+	keyCountSampleSize = 500
+
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+
+	count, err := db1.ApproximateKeyCount(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), count)
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, db1.Put([]byte(createTestKey(i)), []byte(createTestValue("db1", i)), false))
+	}
+	t.Run("keys-fewer-than-sample-size", func(t *testing.T) {
+		count, err := db1.ApproximateKeyCount(nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), count)
+		count, err = db1.ApproximateKeyCount([]byte(createTestKey(10)), []byte(createTestKey(20)))
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), count)
+	})
+
+	batch := db2.NewUpdateBatch()
+	for i := 0; i < 5000; i++ {
+		batch.Put([]byte(createTestLongKey(i)), []byte(createTestValue("db2", i)))
+	}
+	require.NoError(t, db2.WriteBatch(batch, true))
+
+	t.Run("keys-not-flushed-are-counted", func(t *testing.T) {
+		count, err := db2.ApproximateKeyCount(nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(5000), count)
+	})
+
+	t.Run("keys-flushed-are-estimated", func(t *testing.T) {
+		require.NoError(t, db2.Compact())
+		count, err := db2.ApproximateKeyCount(nil, nil)
+		require.NoError(t, err)
+		require.InEpsilon(t, 5000, count, 0.2)
+		// the estimate for db1 is not influenced by the keys in db2
+		count, err = db1.ApproximateKeyCount(nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, uint64(50), count)
+	})
+
+	env.provider.Close()
+	_, err = db1.ApproximateKeyCount(nil, nil)
+	require.EqualError(t, err, "internal leveldb error while obtaining db iterator: leveldb: closed")
+}
+
 func TestFormatCheck(t *testing.T) {
 	testCases := []struct {
 		dataFormat     string
@@ -459,7 +667,7 @@ func TestRetrieveDataFormatInfo(t *testing.T) {
 		defer cleanup()
 
 		provider.Close()
-		info, err := RetrieveDataFormatInfo(testDBPath)
+		info, err := RetrieveDataFormatInfo(testDBPath, OpenRetry{})
 		require.NoError(t, err)
 		require.Equal(t,
 			&DataFormatInfo{
@@ -478,7 +686,7 @@ func TestRetrieveDataFormatInfo(t *testing.T) {
 		require.NoError(t, db.Put([]byte("k"), []byte("v"), true))
 		provider.Close()
 
-		info, err := RetrieveDataFormatInfo(testDBPath)
+		info, err := RetrieveDataFormatInfo(testDBPath, OpenRetry{})
 		require.NoError(t, err)
 		require.Equal(t,
 			&DataFormatInfo{
@@ -497,7 +705,7 @@ func TestRetrieveDataFormatInfo(t *testing.T) {
 		db := provider.GetDBHandle("dummy")
 		require.NoError(t, db.Put([]byte("k"), []byte("v"), true))
 		env.provider.Close()
-		info, err := RetrieveDataFormatInfo(testDBPath)
+		info, err := RetrieveDataFormatInfo(testDBPath, OpenRetry{})
 		require.NoError(t, err)
 		require.Equal(t,
 			&DataFormatInfo{
@@ -86,6 +86,7 @@ func newDefaultACLProvider(policyChecker policy.PolicyChecker) defaultACLProvide
 	d.cResourcePolicyMap[resources.Qscc_GetBlockByNumber] = CHANNELREADERS
 	d.cResourcePolicyMap[resources.Qscc_GetBlockByHash] = CHANNELREADERS
 	d.cResourcePolicyMap[resources.Qscc_GetTransactionByID] = CHANNELREADERS
+	d.cResourcePolicyMap[resources.Qscc_GetTransactionsByIDs] = CHANNELREADERS
 	d.cResourcePolicyMap[resources.Qscc_GetBlockByTxID] = CHANNELREADERS
 
 	//--------------- CSCC resources -----------
@@ -93,6 +94,8 @@ func newDefaultACLProvider(policyChecker policy.PolicyChecker) defaultACLProvide
 	d.pResourcePolicyMap[resources.Cscc_JoinChain] = policy.Admins
 	d.pResourcePolicyMap[resources.Cscc_JoinChainBySnapshot] = policy.Admins
 	d.pResourcePolicyMap[resources.Cscc_JoinBySnapshotStatus] = policy.Admins
+	d.pResourcePolicyMap[resources.Cscc_DeactivateChannel] = policy.Admins
+	d.pResourcePolicyMap[resources.Cscc_ReactivateChannel] = policy.Admins
 	d.pResourcePolicyMap[resources.Cscc_GetChannels] = policy.Members
 
 	// c resources
@@ -40,16 +40,19 @@ const (
 	Lscc_GetCollectionsConfig      = "lscc/GetCollectionsConfig"
 
 	// Qscc resources
-	Qscc_GetChainInfo       = "qscc/GetChainInfo"
-	Qscc_GetBlockByNumber   = "qscc/GetBlockByNumber"
-	Qscc_GetBlockByHash     = "qscc/GetBlockByHash"
-	Qscc_GetTransactionByID = "qscc/GetTransactionByID"
-	Qscc_GetBlockByTxID     = "qscc/GetBlockByTxID"
+	Qscc_GetChainInfo         = "qscc/GetChainInfo"
+	Qscc_GetBlockByNumber     = "qscc/GetBlockByNumber"
+	Qscc_GetBlockByHash       = "qscc/GetBlockByHash"
+	Qscc_GetTransactionByID   = "qscc/GetTransactionByID"
+	Qscc_GetTransactionsByIDs = "qscc/GetTransactionsByIDs"
+	Qscc_GetBlockByTxID       = "qscc/GetBlockByTxID"
 
 	// Cscc resources
 	Cscc_JoinChain            = "cscc/JoinChain"
 	Cscc_JoinChainBySnapshot  = "cscc/JoinChainBySnapshot"
 	Cscc_JoinBySnapshotStatus = "cscc/JoinBySnapshotStatus"
+	Cscc_DeactivateChannel    = "cscc/DeactivateChannel"
+	Cscc_ReactivateChannel    = "cscc/ReactivateChannel"
 	Cscc_GetConfigBlock       = "cscc/GetConfigBlock"
 	Cscc_GetChannelConfig     = "cscc/GetChannelConfig"
 	Cscc_GetChannels          = "cscc/GetChannels"
@@ -1319,6 +1319,9 @@ func startTxSimulation(peerInstance *peer.Peer, channelID string, txid string) (
 		return nil, nil, err
 	}
 	historyQueryExecutor, err := lgr.NewHistoryQueryExecutor()
+	if _, ok := err.(*ledger.HistoryDBNotEnabledError); ok {
+		historyQueryExecutor, err = nil, nil
+	}
 	if err != nil {
 		return nil, nil, err
 	}
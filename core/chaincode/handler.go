@@ -1181,6 +1181,9 @@ func (h *Handler) HandleInvokeChaincode(msg *pb.ChaincodeMessage, txContext *Tra
 		defer sim.Done()
 
 		hqe, err := lgr.NewHistoryQueryExecutor()
+		if _, ok := err.(*ledger.HistoryDBNotEnabledError); ok {
+			hqe, err = nil, nil
+		}
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -20,6 +20,7 @@ import (
 	"github.com/hyperledger/fabric/core/chaincode/mock"
 	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/common/sysccprovider"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/scc"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -2426,6 +2427,21 @@ var _ = Describe("Handler", func() {
 					Expect(err).To(MatchError("razzies"))
 				})
 			})
+
+			Context("when the history database is not enabled for the target channel", func() {
+				BeforeEach(func() {
+					fakePeerLedger.NewHistoryQueryExecutorReturns(nil, &ledger.HistoryDBNotEnabledError{LedgerID: "target-channel-id"})
+				})
+
+				It("provides a nil history query executor in the context used for execution", func() {
+					_, err := handler.HandleInvokeChaincode(incomingMessage, txContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeInvoker.InvokeCallCount()).To(Equal(1))
+					txParams, _, _ := fakeInvoker.InvokeArgsForCall(0)
+					Expect(txParams.HistoryQueryExecutor).To(BeNil())
+				})
+			})
 		})
 
 		Context("when the target is a system chaincode", func() {
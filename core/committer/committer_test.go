@@ -69,6 +69,11 @@ func (m *mockLedger) GetTransactionByID(txID string) (*peer.ProcessedTransaction
 	return args.Get(0).(*peer.ProcessedTransaction), args.Error(1)
 }
 
+func (m *mockLedger) GetTransactionsByIDs(txIDs []string) ([]*peer.ProcessedTransaction, error) {
+	args := m.Called(txIDs)
+	return args.Get(0).([]*peer.ProcessedTransaction), args.Error(1)
+}
+
 func (m *mockLedger) GetBlockByHash(blockHash []byte) (*common.Block, error) {
 	args := m.Called(blockHash)
 	return args.Get(0).(*common.Block), args.Error(1)
@@ -3,8 +3,10 @@
 package mocks
 
 import coreledger "github.com/hyperledger/fabric/core/ledger"
+import kvrwset "github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 import ledger "github.com/hyperledger/fabric/common/ledger"
 import mock "github.com/stretchr/testify/mock"
+import stateproof "github.com/hyperledger/fabric/core/ledger/stateproof"
 
 // QueryExecutor is an autogenerated mock type for the QueryExecutor type
 type QueryExecutor struct {
@@ -85,6 +87,29 @@ func (_m *QueryExecutor) ExecuteQueryWithPagination(namespace string, query stri
 	return r0, r1
 }
 
+// GetNamespaceFullScanIterator provides a mock function with given fields: namespace
+func (_m *QueryExecutor) GetNamespaceFullScanIterator(namespace string) (ledger.ResultsIterator, error) {
+	ret := _m.Called(namespace)
+
+	var r0 ledger.ResultsIterator
+	if rf, ok := ret.Get(0).(func(string) ledger.ResultsIterator); ok {
+		r0 = rf(namespace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(ledger.ResultsIterator)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(namespace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPrivateData provides a mock function with given fields: namespace, collection, key
 func (_m *QueryExecutor) GetPrivateData(namespace string, collection string, key string) ([]byte, error) {
 	ret := _m.Called(namespace, collection, key)
@@ -131,6 +156,29 @@ func (_m *QueryExecutor) GetPrivateDataHash(namespace string, collection string,
 	return r0, r1
 }
 
+// GetPrivateDataHashByRange provides a mock function with given fields: namespace, collection, startKeyHash, endKeyHash
+func (_m *QueryExecutor) GetPrivateDataHashByRange(namespace string, collection string, startKeyHash []byte, endKeyHash []byte) (ledger.ResultsIterator, error) {
+	ret := _m.Called(namespace, collection, startKeyHash, endKeyHash)
+
+	var r0 ledger.ResultsIterator
+	if rf, ok := ret.Get(0).(func(string, string, []byte, []byte) ledger.ResultsIterator); ok {
+		r0 = rf(namespace, collection, startKeyHash, endKeyHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(ledger.ResultsIterator)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, []byte, []byte) error); ok {
+		r1 = rf(namespace, collection, startKeyHash, endKeyHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPrivateDataMetadata provides a mock function with given fields: namespace, collection, key
 func (_m *QueryExecutor) GetPrivateDataMetadata(namespace string, collection string, key string) (map[string][]byte, error) {
 	ret := _m.Called(namespace, collection, key)
@@ -269,6 +317,52 @@ func (_m *QueryExecutor) GetStateMetadata(namespace string, key string) (map[str
 	return r0, r1
 }
 
+// GetStateVersion provides a mock function with given fields: namespace, key
+func (_m *QueryExecutor) GetStateVersion(namespace string, key string) (*kvrwset.Version, error) {
+	ret := _m.Called(namespace, key)
+
+	var r0 *kvrwset.Version
+	if rf, ok := ret.Get(0).(func(string, string) *kvrwset.Version); ok {
+		r0 = rf(namespace, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*kvrwset.Version)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(namespace, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStateProof provides a mock function with given fields: namespace, key
+func (_m *QueryExecutor) GetStateProof(namespace string, key string) (*stateproof.Proof, error) {
+	ret := _m.Called(namespace, key)
+
+	var r0 *stateproof.Proof
+	if rf, ok := ret.Get(0).(func(string, string) *stateproof.Proof); ok {
+		r0 = rf(namespace, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*stateproof.Proof)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(namespace, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetStateMultipleKeys provides a mock function with given fields: namespace, keys
 func (_m *QueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	ret := _m.Called(namespace, keys)
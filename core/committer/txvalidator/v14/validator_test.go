@@ -40,6 +40,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
 	"github.com/hyperledger/fabric/core/ledger/ledgermgmt/ledgermgmttest"
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
 	mocktxvalidator "github.com/hyperledger/fabric/core/mocks/txvalidator"
 	mocks2 "github.com/hyperledger/fabric/discovery/support/mocks"
 	"github.com/hyperledger/fabric/internal/pkg/txflags"
@@ -1436,6 +1437,12 @@ func (m *mockLedger) GetTransactionByID(txID string) (*peer.ProcessedTransaction
 	return args.Get(0).(*peer.ProcessedTransaction), args.Error(1)
 }
 
+// GetTransactionsByIDs returns transactions by ids
+func (m *mockLedger) GetTransactionsByIDs(txIDs []string) ([]*peer.ProcessedTransaction, error) {
+	args := m.Called(txIDs)
+	return args.Get(0).([]*peer.ProcessedTransaction), args.Error(1)
+}
+
 // GetBlockByHash returns block using its hash value
 func (m *mockLedger) GetBlockByHash(blockHash []byte) (*common.Block, error) {
 	args := m.Called(blockHash)
@@ -1472,6 +1479,143 @@ func (m *mockLedger) NewHistoryQueryExecutor() (ledger.HistoryQueryExecutor, err
 	return args.Get(0).(ledger.HistoryQueryExecutor), nil
 }
 
+// RollbackHistoryDB rolls back history db
+func (m *mockLedger) RollbackHistoryDB(toBlock uint64) error {
+	return nil
+}
+
+// BlockStoreCheckpointInfo returns the block store checkpoint
+func (m *mockLedger) BlockStoreCheckpointInfo() (*ledger.BlockStoreCheckpoint, error) {
+	return nil, nil
+}
+
+// PruneBlocks prunes the blocks below the given block number
+func (m *mockLedger) PruneBlocks(beforeBlock uint64) error {
+	return nil
+}
+
+// ArchiveBlocks archives the blocks below the given block number
+func (m *mockLedger) ArchiveBlocks(beforeBlock uint64) error {
+	return nil
+}
+
+// Backup writes a copy of the ledger under the given dir
+func (m *mockLedger) Backup(targetDir string) error {
+	return nil
+}
+
+// CollectionConfigAt returns the collection config at the given block number
+func (m *mockLedger) CollectionConfigAt(namespace string, blockNum uint64) (*peer.CollectionConfigPackage, error) {
+	return nil, nil
+}
+
+// UpdatePvtDataConfig updates the private data config
+func (m *mockLedger) UpdatePvtDataConfig(cfg *ledger.PrivateDataConfig) error {
+	return nil
+}
+
+// Freeze freezes the ledger
+func (m *mockLedger) Freeze() error {
+	return nil
+}
+
+// Unfreeze unfreezes the ledger
+func (m *mockLedger) Unfreeze() error {
+	return nil
+}
+
+func (m *mockLedger) NewTxSimulatorAtHeight(txid string, height uint64) (ledger.TxSimulator, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) GetLastBlock() (*common.Block, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) AppendBlockRaw(block *common.Block) error {
+	return nil
+}
+
+func (m *mockLedger) MissingPvtDataInfo(maxBlock uint64) (ledger.MissingPvtDataInfo, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) GetPvtdataReconciliationStatus() (*ledger.PvtdataReconciliationStatus, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) CompactPvtdataStore() (int64, error) {
+	return 0, nil
+}
+
+func (m *mockLedger) PreviewCommit(blockAndPvtdata *ledger.BlockAndPvtData) (*ledger.StateDelta, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) GetBlockchainInfoExtended() (*ledger.ExtendedBlockchainInfo, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) HistoryRebuildStatus() (*ledger.HistoryRebuildStatus, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) RegisterCommitListener(fn func(committedBlock *common.Block)) func() {
+	return func() {}
+}
+
+func (m *mockLedger) RegisterPvtdataPurgeListener(fn func(event *ledger.PvtdataPurgeEvent)) func() {
+	return func() {}
+}
+
+func (m *mockLedger) GetPvtdataScheduledForPurge(numBlocks uint64) ([]*ledger.ScheduledPvtdataPurge, error) {
+	return nil, nil
+}
+
+func (m *mockLedger) ComputeStateHash(height uint64) ([]byte, error) {
+	args := m.Called(height)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *mockLedger) CommitBackpressure() float64 {
+	return 0
+}
+
+func (m *mockLedger) ChannelID() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockLedger) TxIDsInRange(startBlockNum, endBlockNum uint64) (ledger2.ResultsIterator, error) {
+	args := m.Called(startBlockNum, endBlockNum)
+	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
+}
+
+func (m *mockLedger) TxIDsByChaincodeName(chaincodeName string) (ledger2.ResultsIterator, error) {
+	args := m.Called(chaincodeName)
+	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
+}
+
+func (m *mockLedger) TxIDsByEndorserMSPID(mspID string) (ledger2.ResultsIterator, error) {
+	args := m.Called(mspID)
+	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
+}
+
+func (m *mockLedger) GetMostRecentConfigBlock() (*common.Block, error) {
+	args := m.Called()
+	return args.Get(0).(*common.Block), args.Error(1)
+}
+
+func (m *mockLedger) HistoryDBSavepoint() (*ledger.HistoryDBSavepoint, error) {
+	args := m.Called()
+	return args.Get(0).(*ledger.HistoryDBSavepoint), args.Error(1)
+}
+
+func (m *mockLedger) CatchUpHistoryDB() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
 // GetPvtDataAndBlockByNum retrieves pvt data and block
 func (m *mockLedger) GetPvtDataAndBlockByNum(blockNum uint64, filter ledger.PvtNsCollFilter) (*ledger.BlockAndPvtData, error) {
 	args := m.Called()
@@ -1613,11 +1757,21 @@ func (exec *mockQueryExecutor) GetPrivateDataRangeScanIterator(namespace, collec
 	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
 }
 
+func (exec *mockQueryExecutor) GetPrivateDataHashByRange(namespace, collection string, startKeyHash, endKeyHash []byte) (ledger2.ResultsIterator, error) {
+	args := exec.Called(namespace, collection, startKeyHash, endKeyHash)
+	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
+}
+
 func (exec *mockQueryExecutor) ExecuteQueryOnPrivateData(namespace, collection, query string) (ledger2.ResultsIterator, error) {
 	args := exec.Called(namespace, collection, query)
 	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
 }
 
+func (exec *mockQueryExecutor) GetNamespaceFullScanIterator(namespace string) (ledger2.ResultsIterator, error) {
+	args := exec.Called(namespace)
+	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
+}
+
 func (exec *mockQueryExecutor) Done() {
 }
 
@@ -1625,6 +1779,14 @@ func (exec *mockQueryExecutor) GetStateMetadata(namespace, key string) (map[stri
 	return nil, nil
 }
 
+func (exec *mockQueryExecutor) GetStateVersion(namespace, key string) (*kvrwset.Version, error) {
+	return nil, nil
+}
+
+func (exec *mockQueryExecutor) GetStateProof(namespace, key string) (*stateproof.Proof, error) {
+	return nil, nil
+}
+
 func (exec *mockQueryExecutor) GetPrivateDataMetadata(namespace, collection, key string) (map[string][]byte, error) {
 	return nil, nil
 }
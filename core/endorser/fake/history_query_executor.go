@@ -5,6 +5,7 @@ import (
 	"sync"
 
 	"github.com/hyperledger/fabric/common/ledger"
+	ledgera "github.com/hyperledger/fabric/core/ledger"
 )
 
 type HistoryQueryExecutor struct {
@@ -22,6 +23,38 @@ type HistoryQueryExecutor struct {
 		result1 ledger.ResultsIterator
 		result2 error
 	}
+	GetHistoryForKeyRangeStub        func(string, string, uint64, uint64) (ledger.ResultsIterator, error)
+	getHistoryForKeyRangeMutex       sync.RWMutex
+	getHistoryForKeyRangeArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 uint64
+		arg4 uint64
+	}
+	getHistoryForKeyRangeReturns struct {
+		result1 ledger.ResultsIterator
+		result2 error
+	}
+	getHistoryForKeyRangeReturnsOnCall map[int]struct {
+		result1 ledger.ResultsIterator
+		result2 error
+	}
+	GetHistoryForKeyWithPaginationStub        func(string, string, string, int32) (ledgera.QueryResultsIterator, error)
+	getHistoryForKeyWithPaginationMutex       sync.RWMutex
+	getHistoryForKeyWithPaginationArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 int32
+	}
+	getHistoryForKeyWithPaginationReturns struct {
+		result1 ledgera.QueryResultsIterator
+		result2 error
+	}
+	getHistoryForKeyWithPaginationReturnsOnCall map[int]struct {
+		result1 ledgera.QueryResultsIterator
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -90,11 +123,147 @@ func (fake *HistoryQueryExecutor) GetHistoryForKeyReturnsOnCall(i int, result1 l
 	}{result1, result2}
 }
 
+func (fake *HistoryQueryExecutor) GetHistoryForKeyRange(arg1 string, arg2 string, arg3 uint64, arg4 uint64) (ledger.ResultsIterator, error) {
+	fake.getHistoryForKeyRangeMutex.Lock()
+	ret, specificReturn := fake.getHistoryForKeyRangeReturnsOnCall[len(fake.getHistoryForKeyRangeArgsForCall)]
+	fake.getHistoryForKeyRangeArgsForCall = append(fake.getHistoryForKeyRangeArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 uint64
+		arg4 uint64
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("GetHistoryForKeyRange", []interface{}{arg1, arg2, arg3, arg4})
+	fake.getHistoryForKeyRangeMutex.Unlock()
+	if fake.GetHistoryForKeyRangeStub != nil {
+		return fake.GetHistoryForKeyRangeStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getHistoryForKeyRangeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyRangeCallCount() int {
+	fake.getHistoryForKeyRangeMutex.RLock()
+	defer fake.getHistoryForKeyRangeMutex.RUnlock()
+	return len(fake.getHistoryForKeyRangeArgsForCall)
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyRangeCalls(stub func(string, string, uint64, uint64) (ledger.ResultsIterator, error)) {
+	fake.getHistoryForKeyRangeMutex.Lock()
+	defer fake.getHistoryForKeyRangeMutex.Unlock()
+	fake.GetHistoryForKeyRangeStub = stub
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyRangeArgsForCall(i int) (string, string, uint64, uint64) {
+	fake.getHistoryForKeyRangeMutex.RLock()
+	defer fake.getHistoryForKeyRangeMutex.RUnlock()
+	argsForCall := fake.getHistoryForKeyRangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyRangeReturns(result1 ledger.ResultsIterator, result2 error) {
+	fake.getHistoryForKeyRangeMutex.Lock()
+	defer fake.getHistoryForKeyRangeMutex.Unlock()
+	fake.GetHistoryForKeyRangeStub = nil
+	fake.getHistoryForKeyRangeReturns = struct {
+		result1 ledger.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyRangeReturnsOnCall(i int, result1 ledger.ResultsIterator, result2 error) {
+	fake.getHistoryForKeyRangeMutex.Lock()
+	defer fake.getHistoryForKeyRangeMutex.Unlock()
+	fake.GetHistoryForKeyRangeStub = nil
+	if fake.getHistoryForKeyRangeReturnsOnCall == nil {
+		fake.getHistoryForKeyRangeReturnsOnCall = make(map[int]struct {
+			result1 ledger.ResultsIterator
+			result2 error
+		})
+	}
+	fake.getHistoryForKeyRangeReturnsOnCall[i] = struct {
+		result1 ledger.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyWithPagination(arg1 string, arg2 string, arg3 string, arg4 int32) (ledgera.QueryResultsIterator, error) {
+	fake.getHistoryForKeyWithPaginationMutex.Lock()
+	ret, specificReturn := fake.getHistoryForKeyWithPaginationReturnsOnCall[len(fake.getHistoryForKeyWithPaginationArgsForCall)]
+	fake.getHistoryForKeyWithPaginationArgsForCall = append(fake.getHistoryForKeyWithPaginationArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 int32
+	}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("GetHistoryForKeyWithPagination", []interface{}{arg1, arg2, arg3, arg4})
+	fake.getHistoryForKeyWithPaginationMutex.Unlock()
+	if fake.GetHistoryForKeyWithPaginationStub != nil {
+		return fake.GetHistoryForKeyWithPaginationStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getHistoryForKeyWithPaginationReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyWithPaginationCallCount() int {
+	fake.getHistoryForKeyWithPaginationMutex.RLock()
+	defer fake.getHistoryForKeyWithPaginationMutex.RUnlock()
+	return len(fake.getHistoryForKeyWithPaginationArgsForCall)
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyWithPaginationCalls(stub func(string, string, string, int32) (ledgera.QueryResultsIterator, error)) {
+	fake.getHistoryForKeyWithPaginationMutex.Lock()
+	defer fake.getHistoryForKeyWithPaginationMutex.Unlock()
+	fake.GetHistoryForKeyWithPaginationStub = stub
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyWithPaginationArgsForCall(i int) (string, string, string, int32) {
+	fake.getHistoryForKeyWithPaginationMutex.RLock()
+	defer fake.getHistoryForKeyWithPaginationMutex.RUnlock()
+	argsForCall := fake.getHistoryForKeyWithPaginationArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyWithPaginationReturns(result1 ledgera.QueryResultsIterator, result2 error) {
+	fake.getHistoryForKeyWithPaginationMutex.Lock()
+	defer fake.getHistoryForKeyWithPaginationMutex.Unlock()
+	fake.GetHistoryForKeyWithPaginationStub = nil
+	fake.getHistoryForKeyWithPaginationReturns = struct {
+		result1 ledgera.QueryResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *HistoryQueryExecutor) GetHistoryForKeyWithPaginationReturnsOnCall(i int, result1 ledgera.QueryResultsIterator, result2 error) {
+	fake.getHistoryForKeyWithPaginationMutex.Lock()
+	defer fake.getHistoryForKeyWithPaginationMutex.Unlock()
+	fake.GetHistoryForKeyWithPaginationStub = nil
+	if fake.getHistoryForKeyWithPaginationReturnsOnCall == nil {
+		fake.getHistoryForKeyWithPaginationReturnsOnCall = make(map[int]struct {
+			result1 ledgera.QueryResultsIterator
+			result2 error
+		})
+	}
+	fake.getHistoryForKeyWithPaginationReturnsOnCall[i] = struct {
+		result1 ledgera.QueryResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *HistoryQueryExecutor) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.getHistoryForKeyMutex.RLock()
 	defer fake.getHistoryForKeyMutex.RUnlock()
+	fake.getHistoryForKeyRangeMutex.RLock()
+	defer fake.getHistoryForKeyRangeMutex.RUnlock()
+	fake.getHistoryForKeyWithPaginationMutex.RLock()
+	defer fake.getHistoryForKeyWithPaginationMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
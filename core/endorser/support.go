@@ -67,13 +67,18 @@ func (s *SupportImpl) GetTxSimulator(ledgername string, txid string) (ledger.TxS
 }
 
 // GetHistoryQueryExecutor gives handle to a history query executor for the
-// specified ledger
+// specified ledger. It returns a nil history query executor if the history
+// database is not enabled for the ledger.
 func (s *SupportImpl) GetHistoryQueryExecutor(ledgername string) (ledger.HistoryQueryExecutor, error) {
 	lgr := s.Peer.GetLedger(ledgername)
 	if lgr == nil {
 		return nil, errors.Errorf("Channel does not exist: %s", ledgername)
 	}
-	return lgr.NewHistoryQueryExecutor()
+	hqe, err := lgr.NewHistoryQueryExecutor()
+	if _, ok := err.(*ledger.HistoryDBNotEnabledError); ok {
+		return nil, nil
+	}
+	return hqe, err
 }
 
 // GetTransactionByID retrieves a transaction by id
@@ -8,6 +8,7 @@ package confighistorytest
 
 import (
 	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/confighistory"
 	"github.com/hyperledger/fabric/core/ledger/mock"
@@ -20,7 +21,7 @@ type Mgr struct {
 
 func NewMgr(dbPath string) (*Mgr, error) {
 	mockCCInfoProvider := &mock.DeployedChaincodeInfoProvider{}
-	configHistory, err := confighistory.NewMgr(dbPath, mockCCInfoProvider)
+	configHistory, err := confighistory.NewMgr(dbPath, mockCCInfoProvider, leveldbhelper.OpenRetry{})
 	if err != nil {
 		return nil, err
 	}
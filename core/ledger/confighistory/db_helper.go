@@ -43,9 +43,13 @@ type batch struct {
 	*leveldbhelper.UpdateBatch
 }
 
-func newDBProvider(dbPath string) (*dbProvider, error) {
+func newDBProvider(dbPath string, openRetry leveldbhelper.OpenRetry) (*dbProvider, error) {
 	logger.Debugf("Opening db for config history: db path = %s", dbPath)
-	p, err := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})
+	p, err := leveldbhelper.NewProvider(&leveldbhelper.Conf{
+		DBPath:            dbPath,
+		OpenRetries:       openRetry.Retries,
+		OpenRetryInterval: openRetry.Interval,
+	})
 	if err != nil {
 		return nil, err
 	}
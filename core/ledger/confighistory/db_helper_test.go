@@ -37,7 +37,7 @@ func TestCompareEncodedHeight(t *testing.T) {
 func TestQueries(t *testing.T) {
 	testDBPath := "/tmp/fabric/core/ledger/confighistory"
 	deleteTestPath(t, testDBPath)
-	provider, err := newDBProvider(testDBPath)
+	provider, err := newDBProvider(testDBPath, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 	defer deleteTestPath(t, testDBPath)
 
@@ -81,7 +81,7 @@ func TestQueries(t *testing.T) {
 
 func TestGetNamespaceIterator(t *testing.T) {
 	testDBPath := "/tmp/fabric/core/ledger/confighistory"
-	provider, err := newDBProvider(testDBPath)
+	provider, err := newDBProvider(testDBPath, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 	defer deleteTestPath(t, testDBPath)
 
@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/snapshot"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/internal/fileutil"
 	"github.com/pkg/errors"
@@ -39,9 +40,10 @@ type Mgr struct {
 	dbProvider     *dbProvider
 }
 
-// NewMgr constructs an instance that implements interface `Mgr`
-func NewMgr(dbPath string, ccInfoProvider ledger.DeployedChaincodeInfoProvider) (*Mgr, error) {
-	p, err := newDBProvider(dbPath)
+// NewMgr constructs an instance that implements interface `Mgr`. The open of the db is retried as per the
+// supplied openRetry
+func NewMgr(dbPath string, ccInfoProvider ledger.DeployedChaincodeInfoProvider, openRetry leveldbhelper.OpenRetry) (*Mgr, error) {
+	p, err := newDBProvider(dbPath, openRetry)
 	if err != nil {
 		return nil, err
 	}
@@ -190,12 +192,23 @@ func (m *Mgr) Drop(ledgerid string) error {
 	return m.dbProvider.Drop(ledgerid)
 }
 
+// Exists returns true if the config history db holds any data for the given ledger
+func (m *Mgr) Exists(ledgerid string) (bool, error) {
+	empty, err := m.dbProvider.GetDBHandle(ledgerid).IsEmpty()
+	return !empty, err
+}
+
 // Retriever helps consumer retrieve collection config history
 type Retriever struct {
 	ledgerID string
 	dbHandle *db
 }
 
+// GetDBSnapshot returns a point-in-time view of the config history of the ledger. The snapshot should be released after the use
+func (r *Retriever) GetDBSnapshot() (*leveldbhelper.Snapshot, error) {
+	return r.dbHandle.GetSnapshot()
+}
+
 // MostRecentCollectionConfigBelow implements function from the interface ledger.ConfigHistoryRetriever
 func (r *Retriever) MostRecentCollectionConfigBelow(blockNum uint64, chaincodeName string) (*ledger.CollectionConfigInfo, error) {
 	compositeKV, err := r.dbHandle.mostRecentEntryBelow(blockNum, collectionConfigNamespace, constructCollectionConfigKey(chaincodeName))
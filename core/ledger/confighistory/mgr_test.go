@@ -20,6 +20,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/snapshot"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/mock"
 	"github.com/stretchr/testify/require"
@@ -37,7 +38,7 @@ func TestMain(m *testing.M) {
 func TestWithNoCollectionConfig(t *testing.T) {
 	dbPath := t.TempDir()
 	mockCCInfoProvider := &mock.DeployedChaincodeInfoProvider{}
-	mgr, err := NewMgr(dbPath, mockCCInfoProvider)
+	mgr, err := NewMgr(dbPath, mockCCInfoProvider, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 	testutilEquipMockCCInfoProviderToReturnDesiredCollConfig(mockCCInfoProvider, "chaincode1", nil)
 	err = mgr.HandleStateUpdates(&ledger.StateUpdateTrigger{
@@ -55,7 +56,7 @@ func TestWithNoCollectionConfig(t *testing.T) {
 func TestWithEmptyCollectionConfig(t *testing.T) {
 	dbPath := t.TempDir()
 	mockCCInfoProvider := &mock.DeployedChaincodeInfoProvider{}
-	mgr, err := NewMgr(dbPath, mockCCInfoProvider)
+	mgr, err := NewMgr(dbPath, mockCCInfoProvider, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 	testutilEquipMockCCInfoProviderToReturnDesiredCollConfig(
 		mockCCInfoProvider,
@@ -77,7 +78,7 @@ func TestWithEmptyCollectionConfig(t *testing.T) {
 func TestMgrQueries(t *testing.T) {
 	dbPath := t.TempDir()
 	mockCCInfoProvider := &mock.DeployedChaincodeInfoProvider{}
-	mgr, err := NewMgr(dbPath, mockCCInfoProvider)
+	mgr, err := NewMgr(dbPath, mockCCInfoProvider, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 	chaincodeName := "chaincode1"
 	configCommittingBlockNums := []uint64{5, 10, 15, 100}
@@ -123,7 +124,7 @@ func TestMgrQueries(t *testing.T) {
 func TestDrop(t *testing.T) {
 	dbPath := t.TempDir()
 	mockCCInfoProvider := &mock.DeployedChaincodeInfoProvider{}
-	mgr, err := NewMgr(dbPath, mockCCInfoProvider)
+	mgr, err := NewMgr(dbPath, mockCCInfoProvider, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 	chaincodeName := "chaincode1"
 	configCommittingBlockNums := []uint64{5, 10, 15, 100}
@@ -183,7 +184,7 @@ func TestWithImplicitColls(t *testing.T) {
 		},
 		nil,
 	)
-	p, err := newDBProvider(dbPath)
+	p, err := newDBProvider(dbPath, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 
 	mgr := &Mgr{
@@ -238,7 +239,7 @@ type testEnvForSnapshot struct {
 
 func newTestEnvForSnapshot(t *testing.T) *testEnvForSnapshot {
 	dbPath := t.TempDir()
-	mgr, err := NewMgr(dbPath, &mock.DeployedChaincodeInfoProvider{})
+	mgr, err := NewMgr(dbPath, &mock.DeployedChaincodeInfoProvider{}, leveldbhelper.OpenRetry{})
 	if err != nil {
 		t.Fatalf("Failed to create new config history manager: %s", err)
 	}
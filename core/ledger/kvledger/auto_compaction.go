@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// autoCompactionMaxCommitBackpressure is the commit backpressure at or above which a scheduled compaction is
+// skipped, so that the compaction does not compete with the commits for the disk bandwidth
+const autoCompactionMaxCommitBackpressure = 0.5
+
+// autoCompaction compacts the physical storage of some of the databases of a ledger in the background at a
+// configured interval. It is used for the state database and the history database, with the interval configured
+// via StateDBConfig.AutoCompactInterval, and for the pvtdata store, with the interval configured via
+// PrivateDataConfig.CompactionInterval
+type autoCompaction struct {
+	l         *kvLedger
+	interval  time.Duration
+	databases string
+	compactFn func() error
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+func newAutoCompaction(l *kvLedger, interval time.Duration, databases string, compactFn func() error) *autoCompaction {
+	return &autoCompaction{
+		l:         l,
+		interval:  interval,
+		databases: databases,
+		compactFn: compactFn,
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+}
+
+func (c *autoCompaction) run() {
+	defer close(c.doneCh)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.compact()
+		}
+	}
+}
+
+// compact compacts the databases unless the ledger is under heavy commit load. The block commits are not
+// held off, as the leveldb compaction proceeds alongside the writes
+func (c *autoCompaction) compact() {
+	l := c.l
+	if backpressure := l.CommitBackpressure(); backpressure >= autoCompactionMaxCommitBackpressure {
+		logger.Debugf("[%s] Skipping the scheduled compaction of the %s as the commit backpressure [%.2f] is high", l.ledgerID, c.databases, backpressure)
+		return
+	}
+
+	logger.Debugf("[%s] Starting the scheduled compaction of the %s", l.ledgerID, c.databases)
+	startTime := time.Now()
+	if err := c.compactFn(); err != nil {
+		logger.Errorf("[%s] Failed the scheduled compaction of the %s: %s", l.ledgerID, c.databases, err)
+		return
+	}
+	logger.Infof("[%s] Completed the scheduled compaction of the %s in %dms", l.ledgerID, c.databases, time.Since(startTime).Milliseconds())
+}
+
+// stop stops the scheduled compactions and waits for the background goroutine to exit,
+// which includes waiting for an in-progress compaction to complete
+func (c *autoCompaction) stop() {
+	close(c.stopCh)
+	<-c.doneCh
+}
+
+// compactStateAndHistoryDBs compacts the state database and, if enabled, the history database of the ledger
+func (l *kvLedger) compactStateAndHistoryDBs() error {
+	if err := l.txmgr.CompactStateDB(); err != nil {
+		return errors.WithMessage(err, "failed to compact the state database")
+	}
+	if l.historyDB != nil {
+		if err := l.historyDB.Compact(); err != nil {
+			return errors.WithMessage(err, "failed to compact the history database")
+		}
+	}
+	l.stats.updateAutoCompactions()
+	return nil
+}
+
+// compactPvtdataStore is the compaction function of the scheduled compactions of the pvtdata store
+func (l *kvLedger) compactPvtdataStore() error {
+	_, err := l.CompactPvtdataStore()
+	return err
+}
@@ -0,0 +1,189 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoCompaction(t *testing.T) {
+	conf := testConfig(t)
+	conf.StateDBConfig.AutoCompactInterval = 10 * time.Millisecond
+	testMetricProvider := testutilConstructMetricProvider()
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	provider, err := NewProvider(
+		&ledger.Initializer{
+			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+			MetricsProvider:               testMetricProvider.fakeProvider,
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	kvlgr := lgr.(*kvLedger)
+	require.NotNil(t, kvlgr.autoCompaction)
+
+	commitBlock := func(txid string, deleteKeys bool) {
+		simulator, err := lgr.NewTxSimulator(txid)
+		require.NoError(t, err)
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("key%d", i)
+			if deleteKeys {
+				require.NoError(t, simulator.DeleteState("ns", key))
+			} else {
+				require.NoError(t, simulator.SetState("ns", key, []byte("value")))
+			}
+		}
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: bg.NextBlock([][]byte{pubSimBytes})}, &ledger.CommitOptions{}))
+	}
+	commitBlock("txid-1", false)
+	commitBlock("txid-2", true)
+
+	fakeCounter := testMetricProvider.fakeAutoCompactionsCounter
+	require.Eventually(t, func() bool {
+		return fakeCounter.AddCallCount() > 0
+	}, time.Minute, 10*time.Millisecond)
+	require.Equal(t, []string{"channel", "testLedger"}, fakeCounter.WithArgsForCall(0))
+	require.Equal(t, float64(1), fakeCounter.AddArgsForCall(0))
+
+	// the background goroutine exits on closing the ledger and no compaction runs thereafter
+	lgr.Close()
+	select {
+	case <-kvlgr.autoCompaction.doneCh:
+	default:
+		t.Fatal("the auto compaction goroutine is expected to have exited on close")
+	}
+	count := fakeCounter.AddCallCount()
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, count, fakeCounter.AddCallCount())
+}
+
+func TestAutoCompactionDisabled(t *testing.T) {
+	provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	require.Nil(t, lgr.(*kvLedger).autoCompaction)
+	require.Nil(t, lgr.(*kvLedger).pvtdataAutoCompaction)
+}
+
+func TestPvtdataAutoCompaction(t *testing.T) {
+	conf := testConfig(t)
+	conf.PrivateDataConfig.CompactionInterval = 10 * time.Millisecond
+	testMetricProvider := testutilConstructMetricProvider()
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	provider, err := NewProvider(
+		&ledger.Initializer{
+			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+			MetricsProvider:               testMetricProvider.fakeProvider,
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	kvlgr := lgr.(*kvLedger)
+	require.NotNil(t, kvlgr.pvtdataAutoCompaction)
+	require.Nil(t, kvlgr.autoCompaction)
+
+	fakeCounter := testMetricProvider.fakePvtdataCompactionsCounter
+	require.Eventually(t, func() bool {
+		return fakeCounter.AddCallCount() > 0
+	}, time.Minute, 10*time.Millisecond)
+	require.Equal(t, []string{"channel", "testLedger"}, fakeCounter.WithArgsForCall(0))
+	require.Equal(t, float64(1), fakeCounter.AddArgsForCall(0))
+	require.Equal(t, []string{"channel", "testLedger"}, testMetricProvider.fakePvtdataCompactionReclaimedSizeCounter.WithArgsForCall(0))
+	// the compaction of the state database and the history database is not scheduled
+	require.Equal(t, 0, testMetricProvider.fakeAutoCompactionsCounter.AddCallCount())
+
+	lgr.Close()
+	select {
+	case <-kvlgr.pvtdataAutoCompaction.doneCh:
+	default:
+		t.Fatal("the pvtdata auto compaction goroutine is expected to have exited on close")
+	}
+	count := fakeCounter.AddCallCount()
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, count, fakeCounter.AddCallCount())
+}
+
+func TestCompactPvtdataStore(t *testing.T) {
+	conf := testConfig(t)
+	testMetricProvider := testutilConstructMetricProvider()
+	ccInfoProvider := &mock.DeployedChaincodeInfoProvider{}
+	ccInfoProvider.CollectionInfoReturns(&peer.StaticCollectionConfig{BlockToLive: 0}, nil)
+	ccInfoProvider.AllCollectionsConfigPkgReturns(
+		testutilCollConfigPkg([]*peer.StaticCollectionConfig{{Name: "coll"}}),
+		nil,
+	)
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	provider, err := NewProvider(
+		&ledger.Initializer{
+			DeployedChaincodeInfoProvider: ccInfoProvider,
+			MetricsProvider:               testMetricProvider.fakeProvider,
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, map[string]string{"pvtkey1": "pvtvalue1"})
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	reclaimedBytes, err := lgr.CompactPvtdataStore()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, reclaimedBytes, int64(0))
+	fakeCounter := testMetricProvider.fakePvtdataCompactionsCounter
+	require.Equal(t, 1, fakeCounter.AddCallCount())
+	require.Equal(t, []string{"channel", "testLedger"}, fakeCounter.WithArgsForCall(0))
+	fakeReclaimedSizeCounter := testMetricProvider.fakePvtdataCompactionReclaimedSizeCounter
+	require.Equal(t, 1, fakeReclaimedSizeCounter.AddCallCount())
+	require.Equal(t, float64(reclaimedBytes), fakeReclaimedSizeCounter.AddArgsForCall(0))
+
+	// the compaction does not alter the private data
+	pvtdata, err := lgr.GetPvtDataByNum(1, nil)
+	require.NoError(t, err)
+	require.Len(t, pvtdata, 1)
+	require.True(t, pvtdata[0].Has("ns", "coll"))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+// Backup writes a copy of the ledger under the given dir, in the layout of the ledger root dir
+// (`ledger.Config.RootFSPath`), while the ledger keeps committing blocks. The copy contains the block files,
+// the pvtdata store, the config history, and the bookkeeping data of the ledger, all as of the same block height,
+// and an entry for the ledger in the ledger ID store. Multiple ledgers can be backed up under the same dir.
+//
+// For restoring, the dir is used as the ledger root dir of a peer. As in the case of a backup taken by copying the
+// ledger root dir of a stopped peer, the state DB, the history DB, and the block index are not copied and are rebuilt
+// from the blocks when the restored ledger is opened for the first time. Hence, a ledger that is bootstrapped from a
+// snapshot or whose blocks are pruned cannot be backed up. If the backup fails, the partially copied data of the ledger
+// should be removed from the dir before the backup is attempted again
+func (l *kvLedger) Backup(targetDir string) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	if l.bootSnapshotMetadata != nil {
+		return errors.Errorf("cannot back up ledger [%s] as it is bootstrapped from a snapshot", l.ledgerID)
+	}
+	if _, err := fileutil.CreateDirIfMissing(targetDir); err != nil {
+		return errors.WithMessagef(err, "error while creating dir [%s]", targetDir)
+	}
+
+	// holding the read lock ensures that no block is being committed and hence all the stores
+	// are at the same height. The lock is held only for capturing the point-in-time views of the stores
+	l.blockAPIsRWLock.RLock()
+	checkpoint := l.blockStore.GetCheckpointInfo()
+	snapshots, err := l.getDBSnapshots(targetDir)
+	l.blockAPIsRWLock.RUnlock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, s := range snapshots {
+			s.snapshot.Release()
+		}
+	}()
+
+	if err := l.blockStore.CopyBlockfiles(BlockStorePath(targetDir), checkpoint); err != nil {
+		return errors.WithMessagef(err, "error while copying the block files of ledger [%s]", l.ledgerID)
+	}
+	for _, s := range snapshots {
+		if err := s.snapshot.ExportTo(s.dbPath); err != nil {
+			return errors.WithMessagef(err, "error while copying the data of ledger [%s] to [%s]", l.ledgerID, s.dbPath)
+		}
+	}
+
+	// the ledger ID is added at the end so that a partial backup is never opened as a ledger
+	idStore, err := openIDStore(LedgerProviderPath(targetDir), leveldbhelper.OpenRetry{})
+	if err != nil {
+		return err
+	}
+	defer idStore.close()
+	if err := idStore.createLedgerID(l.ledgerID, &msgs.LedgerMetadata{Status: msgs.Status_ACTIVE}); err != nil {
+		return err
+	}
+	logger.Infof("[%s] Backed up the ledger at block [%d] to dir [%s]", l.ledgerID, checkpoint.LastPersistedBlock, targetDir)
+	return nil
+}
+
+type dbSnapshot struct {
+	dbPath   string
+	snapshot *leveldbhelper.Snapshot
+}
+
+// getDBSnapshots returns the point-in-time views of the leveldb backed stores of the ledger that are copied in a
+// backup, along with the path of the corresponding store under the backup dir
+func (l *kvLedger) getDBSnapshots(targetDir string) (snapshots []*dbSnapshot, e error) {
+	defer func() {
+		if e != nil {
+			for _, s := range snapshots {
+				s.snapshot.Release()
+			}
+		}
+	}()
+
+	pvtdataSnapshot, err := l.pvtdataStore.GetDBSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	snapshots = append(snapshots, &dbSnapshot{PvtDataStorePath(targetDir), pvtdataSnapshot})
+
+	configHistorySnapshot, err := l.configHistoryRetriever.GetDBSnapshot()
+	if err != nil {
+		return snapshots, err
+	}
+	snapshots = append(snapshots, &dbSnapshot{ConfigHistoryDBPath(targetDir), configHistorySnapshot})
+
+	bookkeepingSnapshots, err := l.bookkeepingProvider.GetDBSnapshots(l.ledgerID)
+	if err != nil {
+		return snapshots, err
+	}
+	for _, s := range bookkeepingSnapshots {
+		snapshots = append(snapshots, &dbSnapshot{BookkeeperDBPath(targetDir), s})
+	}
+	return snapshots, nil
+}
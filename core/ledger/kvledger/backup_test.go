@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackup(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	commitBlock := func(value string) *ledger.BlockAndPvtData {
+		sim, err := lgr.NewTxSimulator(util.GenerateUUID())
+		require.NoError(t, err)
+		require.NoError(t, sim.SetState("ns1", "key1", []byte(value)))
+		sim.Done()
+		simRes, err := sim.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		blockAndPvtdata := &ledger.BlockAndPvtData{Block: bg.NextBlock([][]byte{pubSimBytes})}
+		require.NoError(t, lgr.CommitLegacy(blockAndPvtdata, &ledger.CommitOptions{}))
+		return blockAndPvtdata
+	}
+	block1 := commitBlock("value1")
+	block2 := commitBlock("value2")
+
+	// the ledger keeps committing after the backup and the blocks committed after the backup are not present in the backup
+	backupConf := testConfig(t)
+	require.NoError(t, lgr.Backup(backupConf.RootFSPath))
+	commitBlock("value3")
+	err = lgr.Backup(backupConf.RootFSPath)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error while copying the block files of ledger [testLedger]")
+
+	backupProvider := testutilNewProvider(backupConf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer backupProvider.Close()
+	restoredLgr, err := backupProvider.Open("testLedger")
+	require.NoError(t, err)
+	defer restoredLgr.Close()
+
+	bcInfo, err := restoredLgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), bcInfo.Height)
+	for i, expectedBlock := range []*ledger.BlockAndPvtData{{Block: gb}, block1, block2} {
+		b, err := restoredLgr.GetBlockByNumber(uint64(i))
+		require.NoError(t, err)
+		require.True(t, proto.Equal(expectedBlock.Block, b), "proto messages are not equal")
+	}
+
+	// the state DB is rebuilt from the blocks
+	qe, err := restoredLgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+	val, err := qe.GetState("ns1", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2"), val)
+}
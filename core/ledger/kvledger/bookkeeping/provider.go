@@ -24,14 +24,20 @@ const (
 	SnapshotRequest
 )
 
+var allCategories = []Category{PvtdataExpiry, MetadataPresenceIndicator, SnapshotRequest}
+
 // Provider provides db handle to different bookkeepers
 type Provider struct {
 	dbProvider *leveldbhelper.Provider
 }
 
-// NewProvider instantiates a new provider
-func NewProvider(dbPath string) (*Provider, error) {
-	dbProvider, err := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})
+// NewProvider instantiates a new provider, retrying the open of the db as per the supplied openRetry
+func NewProvider(dbPath string, openRetry leveldbhelper.OpenRetry) (*Provider, error) {
+	dbProvider, err := leveldbhelper.NewProvider(&leveldbhelper.Conf{
+		DBPath:            dbPath,
+		OpenRetries:       openRetry.Retries,
+		OpenRetryInterval: openRetry.Interval,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +49,23 @@ func (p *Provider) GetDBHandle(ledgerID string, cat Category) *leveldbhelper.DBH
 	return p.dbProvider.GetDBHandle(dbName(ledgerID, cat))
 }
 
+// GetDBSnapshots returns a point-in-time view of the bookkeeping data of the ledger for each of the categories.
+// The snapshots should be released after the use
+func (p *Provider) GetDBSnapshots(ledgerID string) ([]*leveldbhelper.Snapshot, error) {
+	var snapshots []*leveldbhelper.Snapshot
+	for _, cat := range allCategories {
+		snapshot, err := p.GetDBHandle(ledgerID, cat).GetSnapshot()
+		if err != nil {
+			for _, s := range snapshots {
+				s.Release()
+			}
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
 // Close implements the function in the interface 'BookKeeperProvider'
 func (p *Provider) Close() {
 	p.dbProvider.Close()
@@ -50,7 +73,7 @@ func (p *Provider) Close() {
 
 // Drop drops channel-specific data from the config history db
 func (p *Provider) Drop(ledgerID string) error {
-	for _, cat := range []Category{PvtdataExpiry, MetadataPresenceIndicator, SnapshotRequest} {
+	for _, cat := range allCategories {
 		if err := p.dbProvider.Drop(dbName(ledgerID, cat)); err != nil {
 			return err
 		}
@@ -58,6 +81,20 @@ func (p *Provider) Drop(ledgerID string) error {
 	return nil
 }
 
+// Exists returns true if the bookkeeping db holds any data for the given ledger
+func (p *Provider) Exists(ledgerID string) (bool, error) {
+	for _, cat := range allCategories {
+		empty, err := p.dbProvider.GetDBHandle(dbName(ledgerID, cat)).IsEmpty()
+		if err != nil {
+			return false, err
+		}
+		if !empty {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func dbName(ledgerID string, cat Category) string {
 	return fmt.Sprintf(ledgerID+"/%d", cat)
 }
@@ -0,0 +1,155 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/validation"
+)
+
+// commitListenerBufferSize is the number of the events of the committed blocks buffered for a listener
+// that is yet to process the previous events. The events beyond these are dropped for the listener
+const commitListenerBufferSize = 100
+
+// commitListeners maintains the listeners registered via RegisterCommitListener or RegisterPvtdataPurgeListener.
+// An event, which is the committed block or the pvtdata purge event, is passed to a listener as an interface{}
+type commitListeners struct {
+	mutex     sync.Mutex
+	nextID    uint64
+	listeners map[uint64]*commitListener
+}
+
+type commitListener struct {
+	fn       func(interface{})
+	events   chan interface{}
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// RegisterCommitListener implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) RegisterCommitListener(fn func(committedBlock *common.Block)) (cancel func()) {
+	return l.commitListeners.register(func(event interface{}) { fn(event.(*common.Block)) })
+}
+
+// RegisterPvtdataPurgeListener implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) RegisterPvtdataPurgeListener(fn func(event *ledger.PvtdataPurgeEvent)) (cancel func()) {
+	return l.pvtdataPurgeListeners.register(func(event interface{}) { fn(event.(*ledger.PvtdataPurgeEvent)) })
+}
+
+// newPvtdataPurgeEvent returns the event for the pvtdata purges initiated by the transactions of a block, ordered by
+// the transaction number, namespace, collection, and key hash, or nil if the block does not purge any pvtdata.
+// The 'purgedPvtKeys' supplies the raw keys that are deleted from the state of this peer by the purges
+func newPvtdataPurgeEvent(
+	blockNum uint64,
+	appInitiatedPurgeUpdates []*validation.AppInitiatedPurgeUpdate,
+	purgedPvtKeys map[privacyenabledstate.HashedCompositeKey]string,
+) *ledger.PvtdataPurgeEvent {
+	if len(appInitiatedPurgeUpdates) == 0 {
+		return nil
+	}
+	event := &ledger.PvtdataPurgeEvent{BlockNumber: blockNum}
+	for _, u := range appInitiatedPurgeUpdates {
+		event.Purges = append(event.Purges, &ledger.PvtdataPurge{
+			TxNum:      u.Version.TxNum,
+			Namespace:  u.CompositeKey.Namespace,
+			Collection: u.CompositeKey.CollectionName,
+			KeyHash:    []byte(u.CompositeKey.KeyHash),
+			Key:        purgedPvtKeys[*u.CompositeKey],
+		})
+	}
+	sort.Slice(event.Purges, func(i, j int) bool {
+		pi, pj := event.Purges[i], event.Purges[j]
+		if pi.TxNum != pj.TxNum {
+			return pi.TxNum < pj.TxNum
+		}
+		if pi.Namespace != pj.Namespace {
+			return pi.Namespace < pj.Namespace
+		}
+		if pi.Collection != pj.Collection {
+			return pi.Collection < pj.Collection
+		}
+		return bytes.Compare(pi.KeyHash, pj.KeyHash) < 0
+	})
+	return event
+}
+
+func (c *commitListeners) register(fn func(interface{})) func() {
+	listener := &commitListener{
+		fn:     fn,
+		events: make(chan interface{}, commitListenerBufferSize),
+		stop:   make(chan struct{}),
+	}
+	go listener.run()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.listeners == nil {
+		c.listeners = map[uint64]*commitListener{}
+	}
+	id := c.nextID
+	c.nextID++
+	c.listeners[id] = listener
+
+	return func() {
+		c.mutex.Lock()
+		delete(c.listeners, id)
+		c.mutex.Unlock()
+		listener.cancel()
+	}
+}
+
+// notify hands over the event of the committed block to each of the listeners without waiting for the listeners to process it
+func (c *commitListeners) notify(blockNum uint64, event interface{}) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, listener := range c.listeners {
+		select {
+		case listener.events <- event:
+		default:
+			logger.Warnf("Dropping the notification of block [%d] for a commit listener that has [%d] blocks pending",
+				blockNum, commitListenerBufferSize)
+		}
+	}
+}
+
+func (c *commitListeners) cancelAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for id, listener := range c.listeners {
+		listener.cancel()
+		delete(c.listeners, id)
+	}
+}
+
+func (l *commitListener) run() {
+	for {
+		select {
+		case <-l.stop:
+			return
+		case event := <-l.events:
+			// the stop is checked again as select picks randomly among the ready cases
+			select {
+			case <-l.stop:
+				return
+			default:
+			}
+			l.fn(event)
+		}
+	}
+}
+
+func (l *commitListener) cancel() {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitListeners(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	received1 := make(chan *common.Block, 10)
+	cancel1 := lgr.RegisterCommitListener(func(b *common.Block) { received1 <- b })
+	received2 := make(chan *common.Block, 10)
+	cancel2 := lgr.RegisterCommitListener(func(b *common.Block) { received2 <- b })
+	defer cancel2()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", map[string]string{"key1": "value2"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+
+	for _, received := range []chan *common.Block{received1, received2} {
+		require.Equal(t, uint64(1), receiveBlock(t, received).Header.Number)
+		require.Equal(t, uint64(2), receiveBlock(t, received).Header.Number)
+	}
+
+	// a cancelled listener is not invoked for the blocks committed afterwards
+	cancel1()
+	blk3 := prepareNextBlockForTest(t, lgr, bg, "txid-3", map[string]string{"key1": "value3"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk3, &ledger.CommitOptions{}))
+	require.Equal(t, uint64(3), receiveBlock(t, received2).Header.Number)
+	require.Never(t, func() bool { return len(received1) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	t.Run("slow-listener", func(t *testing.T) {
+		listeners := &commitListeners{}
+		unblock := make(chan struct{})
+		received := make(chan *common.Block, commitListenerBufferSize+10)
+		cancel := listeners.register(func(event interface{}) {
+			<-unblock
+			received <- event.(*common.Block)
+		})
+		defer cancel()
+
+		// the notifications beyond the buffer are dropped without blocking the notifier
+		for i := uint64(0); i < commitListenerBufferSize+10; i++ {
+			listeners.notify(i, &common.Block{Header: &common.BlockHeader{Number: i}})
+		}
+		close(unblock)
+		// the listener receives the buffered blocks and, possibly, the one it picked before the buffer filled up
+		require.Eventually(t, func() bool { return len(received) >= commitListenerBufferSize }, 5*time.Second, 10*time.Millisecond)
+		require.Never(t, func() bool { return len(received) > commitListenerBufferSize+1 }, 100*time.Millisecond, 10*time.Millisecond)
+		lastBlockNum := receiveBlock(t, received).Header.Number
+		for len(received) > 0 {
+			b := receiveBlock(t, received)
+			require.Equal(t, lastBlockNum+1, b.Header.Number)
+			lastBlockNum = b.Header.Number
+		}
+	})
+}
+
+func receiveBlock(t *testing.T, ch <-chan *common.Block) *common.Block {
+	select {
+	case b := <-ch:
+		return b
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the commit listener")
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// maxConfigBlockScan is the maximum number of blocks scanned backward for a config block when the last block
+// of the ledger does not carry the index of the last config block in its metadata
+const maxConfigBlockScan = 1000
+
+// GetMostRecentConfigBlock implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) GetMostRecentConfigBlock() (*common.Block, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if bcInfo.Height == 0 {
+		return nil, errors.Errorf("ledger [%s] is empty", l.ledgerID)
+	}
+	lastBlockNum := bcInfo.Height - 1
+	lastBlock, err := l.blockStore.RetrieveBlockByNumber(lastBlockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	configBlockNum, ok, err := lastConfigIndexFromMetadata(lastBlock)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while retrieving the last config index from block [%d] of ledger [%s]", lastBlockNum, l.ledgerID)
+	}
+	if ok && configBlockNum <= lastBlockNum {
+		configBlock, err := l.blockStore.RetrieveBlockByNumber(configBlockNum)
+		if err != nil {
+			return nil, err
+		}
+		if protoutil.IsConfigBlock(configBlock) {
+			return configBlock, nil
+		}
+		logger.Warnf("[%s] Block [%d] referred as the last config block in the metadata of block [%d] is not a config block, scanning backward for the config block",
+			l.ledgerID, configBlockNum, lastBlockNum)
+	}
+
+	for n := uint64(0); n < maxConfigBlockScan && n <= lastBlockNum; n++ {
+		block := lastBlock
+		if n > 0 {
+			if block, err = l.blockStore.RetrieveBlockByNumber(lastBlockNum - n); err != nil {
+				return nil, err
+			}
+		}
+		if protoutil.IsConfigBlock(block) {
+			return block, nil
+		}
+	}
+	return nil, errors.Errorf("no config block found in the last %d blocks of ledger [%s]", maxConfigBlockScan, l.ledgerID)
+}
+
+// lastConfigIndexFromMetadata returns the index of the last config block recorded in the metadata of the block
+// and false if the metadata does not record the index. As the encoding of the legacy LastConfig metadata with
+// index 0 is indistinguishable from an absent one, the index 0 is reported as not recorded in that case
+func lastConfigIndexFromMetadata(block *common.Block) (uint64, bool, error) {
+	metadata := block.GetMetadata().GetMetadata()
+	metadataValue := func(index common.BlockMetadataIndex) ([]byte, error) {
+		if len(metadata) <= int(index) || len(metadata[index]) == 0 {
+			return nil, nil
+		}
+		md, err := protoutil.GetMetadataFromBlock(block, index)
+		if err != nil {
+			return nil, err
+		}
+		return md.Value, nil
+	}
+
+	sigValue, err := metadataValue(common.BlockMetadataIndex_SIGNATURES)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(sigValue) > 0 {
+		obm := &common.OrdererBlockMetadata{}
+		if err := proto.Unmarshal(sigValue, obm); err != nil {
+			return 0, false, errors.Wrap(err, "error unmarshalling orderer block metadata")
+		}
+		if obm.LastConfig != nil {
+			return obm.LastConfig.Index, true, nil
+		}
+	}
+
+	lastConfigValue, err := metadataValue(common.BlockMetadataIndex_LAST_CONFIG)
+	if err != nil || len(lastConfigValue) == 0 {
+		return 0, false, err
+	}
+	lc := &common.LastConfig{}
+	if err := proto.Unmarshal(lastConfigValue, lc); err != nil {
+		return 0, false, errors.Wrap(err, "error unmarshalling last config metadata")
+	}
+	return lc.Index, true, nil
+}
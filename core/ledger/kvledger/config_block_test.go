@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMostRecentConfigBlock(t *testing.T) {
+	provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	verifyMostRecentConfigBlock := func(expectedBlockNum uint64) {
+		configBlock, err := lgr.GetMostRecentConfigBlock()
+		require.NoError(t, err)
+		expectedBlock, err := lgr.GetBlockByNumber(expectedBlockNum)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(expectedBlock, configBlock))
+	}
+
+	// genesis only ledger
+	verifyMostRecentConfigBlock(0)
+
+	// the normal blocks do not carry the last config index and the genesis block is found by a backward scan
+	for _, txid := range []string{"txid-1", "txid-2"} {
+		blk := prepareNextBlockForTest(t, lgr, bg, txid, map[string]string{"key1": txid}, nil)
+		require.NoError(t, lgr.CommitLegacy(blk, &ledger.CommitOptions{}))
+	}
+	verifyMostRecentConfigBlock(0)
+
+	lastBlockHash := func() []byte {
+		bcInfo, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+		return bcInfo.CurrentBlockHash
+	}
+	commitNormalBlock := func(blockNum uint64, lastConfigMetadata *cb.LastConfig) {
+		block := testutil.ConstructBlock(t, blockNum, lastBlockHash(), [][]byte{{}}, false)
+		if lastConfigMetadata != nil {
+			block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = protoutil.MarshalOrPanic(&cb.Metadata{
+				Value: protoutil.MarshalOrPanic(&cb.OrdererBlockMetadata{LastConfig: lastConfigMetadata}),
+			})
+		}
+		require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: block}, &ledger.CommitOptions{}))
+	}
+
+	// a second config block is returned via the last config index in its own metadata
+	configEnv := getEnvelopeFromConfig("testLedger", getConfigFromBlock(gb))
+	configBlock := newBlock([]*cb.Envelope{configEnv}, 3, 3, lastBlockHash())
+	require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: configBlock}, &ledger.CommitOptions{}))
+	verifyMostRecentConfigBlock(3)
+
+	// via the last config index in the metadata of a subsequent normal block
+	commitNormalBlock(4, &cb.LastConfig{Index: 3})
+	verifyMostRecentConfigBlock(3)
+
+	// via a backward scan when the last block does not carry the last config index
+	commitNormalBlock(5, nil)
+	verifyMostRecentConfigBlock(3)
+
+	// via a backward scan when the last config index does not refer to a config block
+	commitNormalBlock(6, &cb.LastConfig{Index: 4})
+	verifyMostRecentConfigBlock(3)
+}
+
+func TestLastConfigIndexFromMetadata(t *testing.T) {
+	block := testutil.NewBlock(nil, 5, []byte("previous-hash"))
+	_, ok, err := lastConfigIndexFromMetadata(block)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	block.Metadata.Metadata[cb.BlockMetadataIndex_LAST_CONFIG] = protoutil.MarshalOrPanic(&cb.Metadata{
+		Value: protoutil.MarshalOrPanic(&cb.LastConfig{Index: 2}),
+	})
+	index, ok, err := lastConfigIndexFromMetadata(block)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(2), index)
+
+	block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = protoutil.MarshalOrPanic(&cb.Metadata{
+		Value: protoutil.MarshalOrPanic(&cb.OrdererBlockMetadata{LastConfig: &cb.LastConfig{Index: 0}}),
+	})
+	index, ok, err = lastConfigIndexFromMetadata(block)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint64(0), index)
+
+	block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = []byte("invalid_bytes")
+	_, _, err = lastConfigIndexFromMetadata(block)
+	require.ErrorContains(t, err, "error unmarshalling metadata at index [SIGNATURES]")
+}
@@ -0,0 +1,124 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/pkg/errors"
+)
+
+// ConsistencyMismatchType identifies the kind of inconsistency between the ledger ID store and the ledger data
+type ConsistencyMismatchType int
+
+const (
+	// MismatchBlockStoreMissing indicates an ACTIVE ledger for which the block store does not exist
+	MismatchBlockStoreMissing ConsistencyMismatchType = iota
+	// MismatchStateDBMissing indicates an ACTIVE ledger for which the state database does not exist
+	MismatchStateDBMissing
+	// MismatchOrphanBlockStore indicates a block store for which the ledger ID store does not contain metadata
+	MismatchOrphanBlockStore
+)
+
+func (t ConsistencyMismatchType) String() string {
+	switch t {
+	case MismatchBlockStoreMissing:
+		return "metadata active but block store missing"
+	case MismatchStateDBMissing:
+		return "metadata active but statedb missing"
+	case MismatchOrphanBlockStore:
+		return "block store exists without metadata"
+	default:
+		return fmt.Sprintf("ConsistencyMismatchType(%d)", int(t))
+	}
+}
+
+// ConsistencyMismatch describes an inconsistency found for a ledger
+type ConsistencyMismatch struct {
+	LedgerID string
+	Type     ConsistencyMismatchType
+}
+
+// ConsistencyReport lists the inconsistencies found by CheckConsistency, ordered by the ledger ID.
+// StateDBChecked is false if the state database does not support checking the existence of the
+// data of a ledger without creating it, in which case the state database is not checked
+type ConsistencyReport struct {
+	Mismatches     []ConsistencyMismatch
+	StateDBChecked bool
+}
+
+// CheckConsistency cross-checks the ledger ID store against the block stores and the state databases.
+// For each ACTIVE ledger, the block store and the state database are expected to exist and for each
+// block store, the ledger ID store is expected to contain the metadata. This is a diagnostic that
+// does not modify any data
+func (p *Provider) CheckConsistency() (*ConsistencyReport, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	ledgerIDs, err := p.idStore.getLedgerIDs(
+		map[msgs.Status]struct{}{
+			msgs.Status_ACTIVE:             {},
+			msgs.Status_INACTIVE:           {},
+			msgs.Status_UNDER_CONSTRUCTION: {},
+			msgs.Status_UNDER_DELETION:     {},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	activeLedgerIDs, err := p.idStore.getActiveLedgerIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	stateDBChecker, stateDBCheckable := p.dbProvider.VersionedDBProvider.(statedb.ExistenceChecker)
+	report := &ConsistencyReport{
+		StateDBChecked: stateDBCheckable,
+	}
+	for _, ledgerID := range activeLedgerIDs {
+		exists, err := p.blkStoreProvider.Exists(ledgerID)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error while checking the existence of the block store for ledger [%s]", ledgerID)
+		}
+		if !exists {
+			report.Mismatches = append(report.Mismatches, ConsistencyMismatch{ledgerID, MismatchBlockStoreMissing})
+		}
+		if !stateDBCheckable {
+			continue
+		}
+		if exists, err = stateDBChecker.Exists(ledgerID); err != nil {
+			return nil, errors.WithMessagef(err, "error while checking the existence of the state database for ledger [%s]", ledgerID)
+		}
+		if !exists {
+			report.Mismatches = append(report.Mismatches, ConsistencyMismatch{ledgerID, MismatchStateDBMissing})
+		}
+	}
+
+	blockStoreIDs, err := p.blkStoreProvider.List()
+	if err != nil {
+		return nil, errors.WithMessage(err, "error while listing the block stores")
+	}
+	ledgerIDsWithMetadata := map[string]struct{}{}
+	for _, ledgerID := range ledgerIDs {
+		ledgerIDsWithMetadata[ledgerID] = struct{}{}
+	}
+	for _, ledgerID := range blockStoreIDs {
+		if _, ok := ledgerIDsWithMetadata[ledgerID]; !ok {
+			report.Mismatches = append(report.Mismatches, ConsistencyMismatch{ledgerID, MismatchOrphanBlockStore})
+		}
+	}
+
+	sort.SliceStable(report.Mismatches, func(i, j int) bool {
+		return report.Mismatches[i].LedgerID < report.Mismatches[j].LedgerID
+	})
+	return report, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	for i := 0; i < 3; i++ {
+		genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(i))
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+		lgr.Close()
+	}
+
+	report, err := provider.CheckConsistency()
+	require.NoError(t, err)
+	require.Equal(t, &ConsistencyReport{StateDBChecked: true}, report)
+
+	chainsDir := filepath.Join(BlockStorePath(conf.RootFSPath), blkstorage.ChainsDir)
+	// block store of an active ledger is removed
+	require.NoError(t, os.RemoveAll(filepath.Join(chainsDir, constructTestLedgerID(0))))
+	// statedb of an active ledger is removed
+	require.NoError(t, provider.dbProvider.Drop(constructTestLedgerID(2)))
+	// block store without metadata
+	require.NoError(t, os.MkdirAll(filepath.Join(chainsDir, "orphan-ledger"), 0o755))
+
+	expectedReport := &ConsistencyReport{
+		Mismatches: []ConsistencyMismatch{
+			{LedgerID: constructTestLedgerID(0), Type: MismatchBlockStoreMissing},
+			{LedgerID: constructTestLedgerID(2), Type: MismatchStateDBMissing},
+			{LedgerID: "orphan-ledger", Type: MismatchOrphanBlockStore},
+		},
+		StateDBChecked: true,
+	}
+	report, err = provider.CheckConsistency()
+	require.NoError(t, err)
+	require.Equal(t, expectedReport, report)
+	require.Equal(t, "metadata active but block store missing", report.Mismatches[0].Type.String())
+	require.Equal(t, "metadata active but statedb missing", report.Mismatches[1].Type.String())
+	require.Equal(t, "block store exists without metadata", report.Mismatches[2].Type.String())
+
+	// the check does not repair or remove anything
+	exists, err := provider.Exists(constructTestLedgerID(0))
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = provider.Exists("orphan-ledger")
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.DirExists(t, filepath.Join(chainsDir, "orphan-ledger"))
+	report, err = provider.CheckConsistency()
+	require.NoError(t, err)
+	require.Equal(t, expectedReport, report)
+
+	provider.Close()
+	_, err = provider.CheckConsistency()
+	require.Equal(t, &ProviderClosedError{}, err)
+}
@@ -86,6 +86,57 @@ func TestNewProviderDeletesPartialLedgers(t *testing.T) {
 	verifyPartialLedgers(t, provider, targetStatus)
 }
 
+func TestPendingRecoveryActionsAndApplyRecovery(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	targetStatus := []msgs.Status{
+		msgs.Status_ACTIVE,
+		msgs.Status_UNDER_CONSTRUCTION,
+		msgs.Status_INACTIVE,
+		msgs.Status_UNDER_DELETION,
+	}
+	constructPartialLedgers(t, provider, targetStatus)
+
+	actions, err := provider.PendingRecoveryActions()
+	require.NoError(t, err)
+	require.Equal(t,
+		[]RecoveryAction{
+			{
+				LedgerID: constructTestLedgerID(1),
+				Status:   msgs.Status_UNDER_CONSTRUCTION,
+				Type:     RecoveryActionDeleteUnderConstruction,
+			},
+			{
+				LedgerID: constructTestLedgerID(3),
+				Status:   msgs.Status_UNDER_DELETION,
+				Type:     RecoveryActionFinalizeDeletion,
+			},
+		},
+		actions,
+	)
+	// preview does not delete anything
+	for i, status := range targetStatus {
+		verifyLedgerIDExists(t, provider, constructTestLedgerID(i), status)
+	}
+
+	require.NoError(t, provider.ApplyRecovery())
+	verifyLedgerIDExists(t, provider, constructTestLedgerID(0), msgs.Status_ACTIVE)
+	verifyLedgerIDExists(t, provider, constructTestLedgerID(2), msgs.Status_INACTIVE)
+	verifyLedgerDoesNotExist(t, provider, constructTestLedgerID(1))
+	verifyLedgerDoesNotExist(t, provider, constructTestLedgerID(3))
+
+	actions, err = provider.PendingRecoveryActions()
+	require.NoError(t, err)
+	require.Empty(t, actions)
+
+	provider.Close()
+	_, err = provider.PendingRecoveryActions()
+	require.Equal(t, &ProviderClosedError{}, err)
+	require.Equal(t, &ProviderClosedError{}, provider.ApplyRecovery())
+}
+
 // Construct a series of test ledgers, each with a target status.
 func constructPartialLedgers(t *testing.T, provider *Provider, targetStatus []msgs.Status) {
 	for i := 0; i < len(targetStatus); i++ {
@@ -21,6 +21,9 @@ func dropDBs(rootFSPath string) error {
 	if err := dropStateLevelDB(rootFSPath); err != nil {
 		return err
 	}
+	if err := dropStatePebbleDB(rootFSPath); err != nil {
+		return err
+	}
 	if err := dropConfigHistoryDB(rootFSPath); err != nil {
 		return err
 	}
@@ -36,6 +39,12 @@ func dropStateLevelDB(rootFSPath string) error {
 	return fileutil.RemoveContents(stateLeveldbPath)
 }
 
+func dropStatePebbleDB(rootFSPath string) error {
+	statePebbleDBPath := StatePebbleDBPath(rootFSPath)
+	logger.Infof("Dropping all contents in StatePebbleDB at location [%s] ...if present", statePebbleDBPath)
+	return fileutil.RemoveContents(statePebbleDBPath)
+}
+
 func dropConfigHistoryDB(rootFSPath string) error {
 	configHistoryDBPath := ConfigHistoryDBPath(rootFSPath)
 	logger.Infof("Dropping all contents in ConfigHistoryDB at location [%s] ...if present", configHistoryDBPath)
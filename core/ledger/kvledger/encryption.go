@@ -0,0 +1,67 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/pkg/errors"
+)
+
+// NewBCCSPDataEncryptor returns a ledger.DataEncryptor that encrypts the data of a ledger with an AES key derived for
+// the ledger from the given master key via the BCCSP, so that the key never leaves the BCCSP (e.g., an HSM or a KMS
+// backed BCCSP). The data is encrypted with AES in CBC mode with PKCS7 padding and a random IV
+func NewBCCSPDataEncryptor(csp bccsp.BCCSP, masterKey bccsp.Key) ledger.DataEncryptor {
+	return &bccspDataEncryptor{
+		csp:       csp,
+		masterKey: masterKey,
+		keys:      map[string]bccsp.Key{},
+	}
+}
+
+type bccspDataEncryptor struct {
+	csp       bccsp.BCCSP
+	masterKey bccsp.Key
+
+	mutex sync.Mutex
+	keys  map[string]bccsp.Key
+}
+
+func (e *bccspDataEncryptor) Encrypt(ledgerID string, plaintext []byte) ([]byte, error) {
+	key, err := e.ledgerKey(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	return e.csp.Encrypt(key, plaintext, &bccsp.AESCBCPKCS7ModeOpts{})
+}
+
+func (e *bccspDataEncryptor) Decrypt(ledgerID string, ciphertext []byte) ([]byte, error) {
+	key, err := e.ledgerKey(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	return e.csp.Decrypt(key, ciphertext, &bccsp.AESCBCPKCS7ModeOpts{})
+}
+
+func (e *bccspDataEncryptor) ledgerKey(ledgerID string) (bccsp.Key, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if key, ok := e.keys[ledgerID]; ok {
+		return key, nil
+	}
+	key, err := e.csp.KeyDeriv(
+		e.masterKey,
+		&bccsp.HMACTruncated256AESDeriveKeyOpts{Temporary: true, Arg: []byte(ledgerID)},
+	)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while deriving the encryption key for ledger [%s]", ledgerID)
+	}
+	e.keys[ledgerID] = key
+	return key, nil
+}
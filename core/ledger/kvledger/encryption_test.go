@@ -0,0 +1,127 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/bccsp"
+	"github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataEncryption(t *testing.T) {
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	masterKey, err := cryptoProvider.KeyGen(&bccsp.AES256KeyGenOpts{Temporary: true})
+	require.NoError(t, err)
+
+	conf := testConfig(t)
+	conf.DataEncryptor = NewBCCSPDataEncryptor(cryptoProvider, masterKey)
+	ccInfoProvider := &mock.DeployedChaincodeInfoProvider{}
+	ccInfoProvider.CollectionInfoReturns(&peer.StaticCollectionConfig{BlockToLive: 0}, nil)
+	ccInfoProvider.AllCollectionsConfigPkgReturns(
+		testutilCollConfigPkg([]*peer.StaticCollectionConfig{{Name: "coll"}}),
+		nil,
+	)
+	provider := testutilNewProvider(conf, t, ccInfoProvider)
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "public-value1"}, map[string]string{"key1": "private-value1"})
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	lgr.Close()
+	provider.Close()
+
+	// the values are not present in the clear in the block files and the state and private data dbs
+	for _, dir := range []string{BlockStorePath(conf.RootFSPath), StateDBPath(conf.RootFSPath), PvtDataStorePath(conf.RootFSPath)} {
+		require.NoError(t, filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.False(t, bytes.Contains(content, []byte("public-value1")), "value found in the clear in file [%s]", path)
+			require.False(t, bytes.Contains(content, []byte("private-value1")), "value found in the clear in file [%s]", path)
+			return nil
+		}))
+	}
+
+	provider = testutilNewProvider(conf, t, ccInfoProvider)
+	defer provider.Close()
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	checkBCSummaryForTest(t, lgr, &bcSummary{
+		stateDBKVs:    map[string]string{"key1": "public-value1"},
+		stateDBPvtKVs: map[string]string{"key1": "private-value1"},
+	})
+	block, err := lgr.GetBlockByNumber(1)
+	require.NoError(t, err)
+	require.Equal(t, blk1.Block.Data, block.Data)
+	pvtdata, err := lgr.GetPvtDataByNum(1, nil)
+	require.NoError(t, err)
+	require.Len(t, pvtdata, 1)
+	require.Equal(t, blk1.PvtData[0].WriteSet.NsPvtRwset[0].CollectionPvtRwset[0].Rwset,
+		pvtdata[0].WriteSet.NsPvtRwset[0].CollectionPvtRwset[0].Rwset)
+}
+
+func TestDataEncryptionUnsupportedStateDB(t *testing.T) {
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	masterKey, err := cryptoProvider.KeyGen(&bccsp.AES256KeyGenOpts{Temporary: true})
+	require.NoError(t, err)
+
+	conf := testConfig(t)
+	conf.DataEncryptor = NewBCCSPDataEncryptor(cryptoProvider, masterKey)
+	conf.StateDBConfig.StateDatabase = ledger.CouchDB
+	_, err = NewProvider(
+		&ledger.Initializer{
+			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+			MetricsProvider:               &disabled.Provider{},
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.EqualError(t, err, "encryption of the ledger data is not supported with the state database [CouchDB]")
+}
+
+func TestBCCSPDataEncryptor(t *testing.T) {
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	masterKey, err := cryptoProvider.KeyGen(&bccsp.AES256KeyGenOpts{Temporary: true})
+	require.NoError(t, err)
+	encryptor := NewBCCSPDataEncryptor(cryptoProvider, masterKey)
+
+	ciphertext, err := encryptor.Encrypt("ledger1", []byte("value1"))
+	require.NoError(t, err)
+	require.NotContains(t, string(ciphertext), "value1")
+	plaintext, err := encryptor.Decrypt("ledger1", ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), plaintext)
+
+	// the key of another ledger does not decrypt the value
+	plaintext, err = encryptor.Decrypt("ledger2", ciphertext)
+	if err == nil {
+		require.NotEqual(t, []byte("value1"), plaintext)
+	}
+
+	_, err = NewBCCSPDataEncryptor(cryptoProvider, nil).Encrypt("ledger1", []byte("value1"))
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "error while deriving the encryption key for ledger [ledger1]"))
+}
@@ -0,0 +1,98 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
+	"github.com/pkg/errors"
+)
+
+// ForkLedger creates a new ledger with the ID newID as a copy of the ledger sourceID at its current height
+// and returns the new ledger opened. The blocks of the source ledger, along with their private data, are
+// recommitted to the new ledger, which builds the block store, the state DB, and the history DB of the new
+// ledger independent of the ones of the source ledger. This is intended for the testing and the analysis
+// scenarios that need to experiment on a ledger without touching the original ledger.
+// The source ledger is expected to be closed while it is being forked. As in the case of a ledger creation,
+// the new ledger remains in the UNDER_CONSTRUCTION status till it is completely populated, so that it is
+// deleted on a failure or, in the event of a crash, at the next peer start
+func (p *Provider) ForkLedger(sourceID, newID string) (ledger.PeerLedger, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	if err := p.validateLedgerID(newID); err != nil {
+		return nil, err
+	}
+	sourceMetadata, err := p.idStore.getLedgerMetadata(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if sourceMetadata == nil {
+		return nil, errors.Errorf("cannot fork ledger [%s], ledger does not exist", sourceID)
+	}
+	if sourceMetadata.Status != msgs.Status_ACTIVE {
+		return nil, errors.Errorf("cannot fork ledger [%s], ledger status is [%s]", sourceID, sourceMetadata.Status)
+	}
+	if sourceMetadata.BootSnapshotMetadata != nil {
+		return nil, errors.Errorf("cannot fork ledger [%s], ledger is created from a snapshot", sourceID)
+	}
+
+	if err := p.beginCreation(newID); err != nil {
+		return nil, err
+	}
+	defer p.endCreation(newID)
+	if err := p.idStore.createLedgerID(
+		newID,
+		&msgs.LedgerMetadata{
+			Status: msgs.Status_UNDER_CONSTRUCTION,
+		},
+	); err != nil {
+		return nil, err
+	}
+
+	source, err := p.open(sourceID, nil, false, false)
+	if err != nil {
+		return nil, p.deleteUnderConstructionLedger(nil, newID, err)
+	}
+	defer source.Close()
+
+	info, err := source.GetBlockchainInfo()
+	if err != nil {
+		return nil, p.deleteUnderConstructionLedger(nil, newID, err)
+	}
+	genesisBlock, err := source.GetBlockByNumber(0)
+	if err != nil {
+		return nil, p.deleteUnderConstructionLedger(nil, newID, err)
+	}
+
+	fork, err := p.open(newID, nil, false, false)
+	if err != nil {
+		return nil, p.deleteUnderConstructionLedger(fork, newID, err)
+	}
+
+	logger.Infof("Forking ledger [%s] at height [%d] into ledger [%s]", sourceID, info.Height, newID)
+	for blockNum := uint64(0); blockNum < info.Height; blockNum++ {
+		blockAndPvtdata, err := source.GetPvtDataAndBlockByNum(blockNum, nil)
+		if err != nil {
+			return nil, p.deleteUnderConstructionLedger(fork, newID, err)
+		}
+		if err := fork.CommitLegacy(blockAndPvtdata, &ledger.CommitOptions{}); err != nil {
+			return nil, p.deleteUnderConstructionLedger(
+				fork, newID, errors.WithMessagef(err, "error while committing block [%d] to the fork", blockNum),
+			)
+		}
+	}
+
+	if err := p.idStore.updateLedgerStatus(newID, msgs.Status_ACTIVE); err != nil {
+		return nil, p.deleteUnderConstructionLedger(fork, newID, err)
+	}
+	p.notifyLedgerCreated(newID, genesisBlock)
+	logger.Infof("Forked ledger [%s] at height [%d] into ledger [%s]", sourceID, info.Height, newID)
+	return fork, nil
+}
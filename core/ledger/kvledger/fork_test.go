@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForkLedger(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+	sourceBCInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	lgr.Close()
+
+	fork, err := provider.ForkLedger("testLedger", "forkedLedger")
+	require.NoError(t, err)
+	defer fork.Close()
+
+	status, exists, err := provider.LedgerStatus("forkedLedger")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, msgs.Status_ACTIVE, status)
+	checkBCSummaryForTest(t, fork,
+		&bcSummary{
+			bcInfo:             sourceBCInfo,
+			stateDBSavePoint:   uint64(3),
+			stateDBKVs:         map[string]string{"key1": "value1.3"},
+			historyDBSavePoint: uint64(3),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.3", "value1.2", "value1.1"},
+		},
+	)
+
+	// new state written into the fork does not affect the source ledger
+	blkAndPvtdata := prepareNextBlockForTest(t, fork, bg, "SimulateForBlk4",
+		map[string]string{"key1": "value1.4"}, nil)
+	require.NoError(t, fork.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	checkBCSummaryForTest(t, fork,
+		&bcSummary{
+			stateDBSavePoint: uint64(4),
+			stateDBKVs:       map[string]string{"key1": "value1.4"},
+		},
+	)
+
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			bcInfo:             sourceBCInfo,
+			stateDBSavePoint:   uint64(3),
+			stateDBKVs:         map[string]string{"key1": "value1.3"},
+			historyDBSavePoint: uint64(3),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.3", "value1.2", "value1.1"},
+		},
+	)
+
+	t.Run("new-ledger-exists", func(t *testing.T) {
+		_, err := provider.ForkLedger("testLedger", "forkedLedger")
+		require.EqualError(t, err, "ledger [forkedLedger] already exists with state [ACTIVE]")
+	})
+
+	t.Run("source-ledger-does-not-exist", func(t *testing.T) {
+		_, err := provider.ForkLedger("non-existent-ledger", "anotherFork")
+		require.EqualError(t, err, "cannot fork ledger [non-existent-ledger], ledger does not exist")
+		exists, err := provider.Exists("anotherFork")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+}
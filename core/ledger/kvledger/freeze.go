@@ -0,0 +1,72 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/pkg/errors"
+)
+
+// Freeze implements the corresponding method from interface ledger.PeerLedger.
+// It returns after the in-flight commit, if any, finishes
+func (l *kvLedger) Freeze() error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	l.freezeOpsLock.Lock()
+	defer l.freezeOpsLock.Unlock()
+
+	l.frozenLock.Lock()
+	if l.frozen {
+		l.frozenLock.Unlock()
+		return errors.Errorf("ledger [%s] is already frozen", l.ledgerID)
+	}
+	// the flag is set before acquiring the freezeLock so that, in the reject mode, the new
+	// operations are rejected while we wait for the in-flight operations to finish
+	l.frozen = true
+	l.frozenLock.Unlock()
+
+	l.freezeLock.Lock()
+	logger.Infow("Ledger frozen", "channelID", l.ledgerID)
+	return nil
+}
+
+// Unfreeze implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) Unfreeze() error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	l.freezeOpsLock.Lock()
+	defer l.freezeOpsLock.Unlock()
+
+	l.frozenLock.Lock()
+	defer l.frozenLock.Unlock()
+	if !l.frozen {
+		return errors.Errorf("ledger [%s] is not frozen", l.ledgerID)
+	}
+	l.frozen = false
+	l.freezeLock.Unlock()
+	logger.Infow("Ledger unfrozen", "channelID", l.ledgerID)
+	return nil
+}
+
+// acquireFreezeRLock acquires the freezeLock in read mode. If the ledger is frozen, this blocks until the
+// ledger is unfrozen or, if the ledger is configured to reject the writes when frozen, returns a LedgerFrozenError
+func (l *kvLedger) acquireFreezeRLock() error {
+	if !l.config.RejectWritesWhenFrozen {
+		l.freezeLock.RLock()
+		return nil
+	}
+
+	l.frozenLock.Lock()
+	defer l.frozenLock.Unlock()
+	if l.frozen {
+		return &ledger.LedgerFrozenError{LedgerID: l.ledgerID}
+	}
+	l.freezeLock.RLock()
+	return nil
+}
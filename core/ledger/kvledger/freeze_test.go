@@ -0,0 +1,102 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeRejectsWrites(t *testing.T) {
+	conf := testConfig(t)
+	conf.RejectWritesWhenFrozen = true
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", map[string]string{"key1": "value2"}, nil)
+
+	require.EqualError(t, lgr.Unfreeze(), "ledger [testLedger] is not frozen")
+	require.NoError(t, lgr.Freeze())
+	require.EqualError(t, lgr.Freeze(), "ledger [testLedger] is already frozen")
+
+	err = lgr.CommitLegacy(blk2, &ledger.CommitOptions{})
+	require.Equal(t, &ledger.LedgerFrozenError{LedgerID: "testLedger"}, err)
+	_, err = lgr.NewTxSimulator("txid-3")
+	require.EqualError(t, err, "ledger [testLedger] is frozen")
+	_, err = lgr.CommitPvtDataOfOldBlocks(nil, nil)
+	require.Equal(t, &ledger.LedgerFrozenError{LedgerID: "testLedger"}, err)
+
+	// the read queries continue to work
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), bcInfo.Height)
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	val, err := qe.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+	qe.Done()
+
+	require.NoError(t, lgr.Unfreeze())
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+	checkStateDBForTest(t, lgr, map[string]string{"key1": "value2"}, nil)
+	sim, err := lgr.NewTxSimulator("txid-3")
+	require.NoError(t, err)
+	sim.Done()
+}
+
+func TestFreezeBlocksWrites(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.Freeze())
+
+	commitDone := make(chan error, 1)
+	go func() {
+		commitDone <- lgr.CommitLegacy(blk1, &ledger.CommitOptions{})
+	}()
+	require.Never(t, func() bool { return len(commitDone) > 0 }, 200*time.Millisecond, 10*time.Millisecond)
+
+	// the read queries continue to work while the commit is blocked
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), bcInfo.Height)
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	qe.Done()
+
+	require.NoError(t, lgr.Unfreeze())
+	select {
+	case err := <-commitDone:
+		require.NoError(t, err)
+	case <-time.After(time.Minute):
+		t.Fatal("commit did not resume after unfreezing the ledger")
+	}
+	bcInfo, err = lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), bcInfo.Height)
+	checkStateDBForTest(t, lgr, map[string]string{"key1": "value1"}, nil)
+}
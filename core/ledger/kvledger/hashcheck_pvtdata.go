@@ -26,6 +26,9 @@ import (
 // to remove the key-values that were not present in the snapshot (most likely because, they
 //
 //	were over-written in a later version)
+//
+// The reconciled data elements that belong to a block that is pruned from the blockstore are skipped,
+// as there is no means to verify them
 func constructValidAndInvalidPvtData(
 	reconciledPvtdata []*ledger.ReconciledPvtdata,
 	blockStore *blkstorage.BlockStore,
@@ -40,15 +43,21 @@ func constructValidAndInvalidPvtData(
 	// On a match, add the pvtData to the validPvtData list
 	validPvtData := make(map[uint64][]*ledger.TxPvtData)
 	var invalidPvtData []*ledger.PvtdataHashMismatch
+	firstAvailableBlock := blockStore.FirstAvailableBlockNumber()
 
 	for _, pvtdata := range reconciledPvtdata {
 		var validData []*ledger.TxPvtData
 		var invalidData []*ledger.PvtdataHashMismatch
 		var err error
 
-		if pvtdata.BlockNum <= lastBlockInBootSnapshot {
+		switch {
+		case pvtdata.BlockNum <= lastBlockInBootSnapshot:
 			validData, invalidData, err = verifyHashesViaBootKVHashes(pvtdata, pvtdataStore)
-		} else {
+		case pvtdata.BlockNum < firstAvailableBlock:
+			logger.Debugf("Skipping the pvtData of block [%d] as the block is pruned. First available block = [%d]",
+				pvtdata.BlockNum, firstAvailableBlock)
+			continue
+		default:
 			validData, invalidData, err = verifyHashesFromBlockStore(pvtdata, blockStore)
 		}
 		if err != nil {
@@ -91,7 +91,7 @@ func TestConstructValidInvalidBlocksPvtData(t *testing.T) {
 	require.NoError(t, kvledger.commit(blockAndPvtData1, &ledger.CommitOptions{}))
 
 	// generate snapshot at block-2
-	require.NoError(t, kvledger.generateSnapshot())
+	require.NoError(t, kvledger.generateSnapshot(""))
 	freshConf := testConfig(t)
 
 	freshProvider := testutilNewProviderWithCollectionConfig(
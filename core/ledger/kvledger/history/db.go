@@ -7,7 +7,11 @@ SPDX-License-Identifier: Apache-2.0
 package history
 
 import (
+	"bytes"
+
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/dataformat"
@@ -22,25 +26,41 @@ import (
 
 var logger = flogging.MustGetLogger("history")
 
+// maxRollbackBatchSize limits the memory used by a single batch of deletes during a rollback
+const maxRollbackBatchSize = 4 * 1024 * 1024
+
 // DBProvider provides handle to HistoryDB for a given channel
 type DBProvider struct {
 	leveldbProvider *leveldbhelper.Provider
+	sampleEveryN    uint64
+	storeValues     bool
 }
 
-// NewDBProvider instantiates DBProvider
-func NewDBProvider(path string) (*DBProvider, error) {
+// NewDBProvider instantiates DBProvider. When sampleEveryN is greater than 1, only every Nth
+// modification of a key is recorded along with the latest one; see DB.Commit for details.
+// When storeValues is set, the modification of a key is stored in its history entry so that
+// the history queries do not read the transactions from the block store. The open of the db is
+// retried as per the supplied openRetry
+func NewDBProvider(path string, sampleEveryN uint64, storeValues bool, openRetry leveldbhelper.OpenRetry) (*DBProvider, error) {
 	logger.Debugf("constructing HistoryDBProvider dbPath=%s", path)
 	levelDBProvider, err := leveldbhelper.NewProvider(
 		&leveldbhelper.Conf{
-			DBPath:         path,
-			ExpectedFormat: dataformat.CurrentFormat,
+			DBPath:            path,
+			ExpectedFormat:    dataformat.CurrentFormat,
+			OpenRetries:       openRetry.Retries,
+			OpenRetryInterval: openRetry.Interval,
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
+	if sampleEveryN == 0 {
+		sampleEveryN = 1
+	}
 	return &DBProvider{
 		leveldbProvider: levelDBProvider,
+		sampleEveryN:    sampleEveryN,
+		storeValues:     storeValues,
 	}, nil
 }
 
@@ -54,8 +74,10 @@ func (p *DBProvider) MarkStartingSavepoint(name string, savepoint *version.Heigh
 // GetDBHandle gets the handle to a named database
 func (p *DBProvider) GetDBHandle(name string) *DB {
 	return &DB{
-		levelDB: p.leveldbProvider.GetDBHandle(name),
-		name:    name,
+		levelDB:      p.leveldbProvider.GetDBHandle(name),
+		name:         name,
+		sampleEveryN: p.sampleEveryN,
+		storeValues:  p.storeValues,
 	}
 }
 
@@ -69,19 +91,53 @@ func (p *DBProvider) Drop(channelName string) error {
 	return p.leveldbProvider.Drop(channelName)
 }
 
+// Exists returns true if the history db holds any data for the given channel
+func (p *DBProvider) Exists(channelName string) (bool, error) {
+	empty, err := p.leveldbProvider.GetDBHandle(channelName).IsEmpty()
+	return !empty, err
+}
+
 // DB maintains and provides access to history data for a particular channel
 type DB struct {
-	levelDB *leveldbhelper.DBHandle
-	name    string
+	levelDB      *leveldbhelper.DBHandle
+	name         string
+	sampleEveryN uint64
+	storeValues  bool
 }
 
 // Commit implements method in HistoryDB interface
+// When the history is sampled (sampleEveryN > 1), the valid modifications of a key are numbered, starting
+// with 1, in the order of their commit since the sampling was enabled. The modifications numbered 1, N+1,
+// 2N+1, and so on are retained. In addition, the latest modification is always recorded and it is removed
+// when superseded, unless it is a retained one. As the numbering depends only on the committed blocks,
+// the same subset of the history is retained by all the peers that use the same N since the same block
 func (d *DB) Commit(block *common.Block) error {
+	return d.commit(block, nil)
+}
+
+// RecommitNamespaces adds the history entries of the given namespaces for the block, without moving the savepoint.
+// This is used for rebuilding the history of the namespaces, after dropping it via DropNamespaces, from the blocks
+// up to the savepoint
+func (d *DB) RecommitNamespaces(block *common.Block, namespaces []string) error {
+	if d.sampleEveryN > 1 {
+		return errors.Errorf("cannot recommit namespaces to history database for channel [%s] as the history is sampled", d.name)
+	}
+	filter := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		filter[ns] = struct{}{}
+	}
+	return d.commit(block, filter)
+}
+
+// commit adds the history entries for the block. When the namespaces filter is not nil, only the entries of the
+// namespaces in the filter are added and the savepoint is left unchanged
+func (d *DB) commit(block *common.Block, namespaces map[string]struct{}) error {
 	blockNo := block.Header.Number
 	// Set the starting tranNo to 0
 	var tranNo uint64
 
 	dbBatch := d.levelDB.NewUpdateBatch()
+	sampler := &historySampler{db: d, batch: dbBatch, infos: map[string]*samplingInfo{}}
 
 	logger.Debugf("Channel [%s]: Updating history database for blockNo [%v] with [%d] transactions",
 		d.name, blockNo, len(block.Data.Data))
@@ -128,11 +184,32 @@ func (d *DB) Commit(block *common.Block) error {
 			// add a history record for each write
 			for _, nsRWSet := range txRWSet.NsRwSets {
 				ns := nsRWSet.NameSpace
+				if _, ok := namespaces[ns]; namespaces != nil && !ok {
+					continue
+				}
 
 				for _, kvWrite := range nsRWSet.KvRwSet.Writes {
 					dataKey := constructDataKey(ns, kvWrite.Key, blockNo, tranNo)
-					// No value is required, write an empty byte array (emptyValue) since Put() of nil is not allowed
-					dbBatch.Put(dataKey, emptyValue)
+					if d.storeValues {
+						keyModBytes, err := proto.Marshal(&queryresult.KeyModification{
+							TxId:      chdr.TxId,
+							Value:     kvWrite.Value,
+							Timestamp: chdr.Timestamp,
+							IsDelete:  rwsetutil.IsKVWriteDelete(kvWrite),
+						})
+						if err != nil {
+							return errors.Wrap(err, "error while marshalling the key modification")
+						}
+						dbBatch.Put(dataKey, keyModBytes)
+					} else {
+						// No value is required, write an empty byte array (emptyValue) since Put() of nil is not allowed
+						dbBatch.Put(dataKey, emptyValue)
+					}
+					if d.sampleEveryN > 1 {
+						if err := sampler.recordModification(ns, kvWrite.Key, blockNo, tranNo); err != nil {
+							return err
+						}
+					}
 				}
 			}
 
@@ -143,8 +220,10 @@ func (d *DB) Commit(block *common.Block) error {
 	}
 
 	// add savepoint for recovery purpose
-	height := version.NewHeight(blockNo, tranNo)
-	dbBatch.Put(savePointKey, height.ToBytes())
+	if namespaces == nil {
+		height := version.NewHeight(blockNo, tranNo)
+		dbBatch.Put(savePointKey, height.ToBytes())
+	}
 
 	// write the block's history records and savepoint to LevelDB
 	// Setting snyc to true as a precaution, false may be an ok optimization after further testing.
@@ -174,6 +253,184 @@ func (d *DB) GetLastSavepoint() (*version.Height, error) {
 	return height, nil
 }
 
+// Rollback removes the history entries for the blocks above the given block number and resets the
+// savepoint to the given block number. The entries for the removed blocks are re-populated when the
+// history db is caught up with the block store, either on the next ledger open or explicitly.
+// The savepoint is lowered before removing the entries so that a crash in between leaves the db in a
+// recoverable state, as recommitting a block overwrites the same keys.
+func (d *DB) Rollback(toBlock uint64) error {
+	savepoint, err := d.GetLastSavepoint()
+	if err != nil {
+		return err
+	}
+	if savepoint == nil {
+		return errors.Errorf("history database for channel [%s] does not have a savepoint", d.name)
+	}
+	if toBlock > savepoint.BlockNum {
+		return errors.Errorf(
+			"cannot rollback history database for channel [%s] to block [%d] as it is above the savepoint [%d]",
+			d.name, toBlock, savepoint.BlockNum,
+		)
+	}
+	sampled, err := d.containsSamplingInfo()
+	if err != nil {
+		return err
+	}
+	if sampled {
+		// the removed entries cannot be accounted for in the modification counts
+		return errors.Errorf("cannot rollback history database for channel [%s] as the history is sampled", d.name)
+	}
+	if err := d.levelDB.Put(savePointKey, version.NewHeight(toBlock, 0).ToBytes(), true); err != nil {
+		return errors.WithMessagef(err, "error while resetting the savepoint for channel [%s]", d.name)
+	}
+
+	itr, err := d.levelDB.GetIterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer itr.Release()
+
+	dbBatch := d.levelDB.NewUpdateBatch()
+	numDeleted := 0
+	for itr.Next() {
+		key := itr.Key()
+		if bytes.Equal(key, savePointKey) {
+			continue
+		}
+		blockNum, _, err := decodeBlockNumTranNum(key)
+		if err != nil {
+			return err
+		}
+		if blockNum <= toBlock {
+			continue
+		}
+		dbBatch.Delete(key)
+		numDeleted++
+		if dbBatch.Size() >= maxRollbackBatchSize {
+			if err := d.levelDB.WriteBatch(dbBatch, true); err != nil {
+				return err
+			}
+			dbBatch.Reset()
+		}
+	}
+	if err := itr.Error(); err != nil {
+		return errors.Wrap(err, "internal leveldb error while iterating over history database")
+	}
+	if err := d.levelDB.WriteBatch(dbBatch, true); err != nil {
+		return err
+	}
+	logger.Infof("Channel [%s]: Rolled back history database to block [%d], removed [%d] entries", d.name, toBlock, numDeleted)
+	return nil
+}
+
+// DropNamespaces removes the history entries of the given namespaces, leaving the savepoint unchanged.
+// The history of the namespaces can then be rebuilt via RecommitNamespaces
+func (d *DB) DropNamespaces(namespaces []string) error {
+	sampled, err := d.containsSamplingInfo()
+	if err != nil {
+		return err
+	}
+	if sampled || d.sampleEveryN > 1 {
+		// the retained modifications depend on the blocks committed since the sampling was enabled
+		return errors.Errorf("cannot drop namespaces from history database for channel [%s] as the history is sampled", d.name)
+	}
+
+	dbBatch := d.levelDB.NewUpdateBatch()
+	numDeleted := 0
+	for _, ns := range namespaces {
+		startKey := append([]byte(ns), compositeKeySep...)
+		endKey := append([]byte(ns), compositeKeySep[0]+1)
+		itr, err := d.levelDB.GetIterator(startKey, endKey)
+		if err != nil {
+			return err
+		}
+		for itr.Next() {
+			dbBatch.Delete(itr.Key())
+			numDeleted++
+			if dbBatch.Size() >= maxRollbackBatchSize {
+				if err := d.levelDB.WriteBatch(dbBatch, true); err != nil {
+					itr.Release()
+					return err
+				}
+				dbBatch.Reset()
+			}
+		}
+		err = itr.Error()
+		itr.Release()
+		if err != nil {
+			return errors.Wrap(err, "internal leveldb error while iterating over history database")
+		}
+	}
+	if err := d.levelDB.WriteBatch(dbBatch, true); err != nil {
+		return err
+	}
+	logger.Infof("Channel [%s]: Dropped namespaces %s from history database, removed [%d] entries", d.name, namespaces, numDeleted)
+	return nil
+}
+
+// ApproximateKeyCount returns an estimate of the number of history entries in the db. The savepoint and the
+// sampling info are not counted
+func (d *DB) ApproximateKeyCount() (uint64, error) {
+	countBeforeSavepoint, err := d.levelDB.ApproximateKeyCount(append(samplingKeyPrefix, 0xff), savePointKey)
+	if err != nil {
+		return 0, err
+	}
+	countAfterSavepoint, err := d.levelDB.ApproximateKeyCount(append(savePointKey, 0x00), nil)
+	if err != nil {
+		return 0, err
+	}
+	return countBeforeSavepoint + countAfterSavepoint, nil
+}
+
+func (d *DB) containsSamplingInfo() (bool, error) {
+	itr, err := d.levelDB.GetIterator(samplingKeyPrefix, append(samplingKeyPrefix, 0xff))
+	if err != nil {
+		return false, err
+	}
+	defer itr.Release()
+	found := itr.Next()
+	if err := itr.Error(); err != nil {
+		return false, errors.Wrap(err, "internal leveldb error while iterating over history database")
+	}
+	return found, nil
+}
+
+// historySampler removes the superseded history entries, within a block, that are not retained as per the sampling
+type historySampler struct {
+	db    *DB
+	batch *leveldbhelper.UpdateBatch
+	// infos caches the sampling info updated in the batch, as a key may be modified more than once in a block
+	infos map[string]*samplingInfo
+}
+
+func (s *historySampler) recordModification(ns, key string, blockNum, tranNum uint64) error {
+	samplingKey := constructSamplingKey(ns, key)
+	info, ok := s.infos[string(samplingKey)]
+	if !ok {
+		infoBytes, err := s.db.levelDB.Get(samplingKey)
+		if err != nil {
+			return err
+		}
+		info = &samplingInfo{}
+		if infoBytes != nil {
+			if info, err = samplingInfoFromBytes(infoBytes); err != nil {
+				return err
+			}
+		}
+	}
+	if info.numModifications > 0 && (info.numModifications-1)%s.db.sampleEveryN != 0 {
+		s.batch.Delete(constructDataKey(ns, key, info.blockNum, info.tranNum))
+	}
+	info = &samplingInfo{
+		numModifications: info.numModifications + 1,
+		blockNum:         blockNum,
+		tranNum:          tranNum,
+	}
+	s.infos[string(samplingKey)] = info
+	s.batch.Put(samplingKey, info.toBytes())
+	return nil
+}
+
 // ShouldRecover implements method in interface kvledger.Recoverer
 func (d *DB) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
 	savepoint, err := d.GetLastSavepoint()
@@ -186,6 +443,11 @@ func (d *DB) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
 	return savepoint.BlockNum != lastAvailableBlock, savepoint.BlockNum + 1, nil
 }
 
+// Compact compacts the physical storage of the history data of the channel
+func (d *DB) Compact() error {
+	return d.levelDB.Compact()
+}
+
 // Name returns the name of the database that manages historical states.
 func (d *DB) Name() string {
 	return "history"
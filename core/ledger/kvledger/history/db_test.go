@@ -9,6 +9,7 @@ package history
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"testing"
@@ -22,6 +23,7 @@ import (
 	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	util2 "github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
@@ -432,6 +434,387 @@ func TestHistoryWithBlockNumber256(t *testing.T) {
 	testutilVerifyResults(t, qhistory, "ns1", "key", expectedHistoryResults)
 }
 
+func TestHistoryForKeyRange(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testBlockStorageEnv.provider
+	store, err := provider.Open("ledger1")
+	require.NoError(t, err)
+	defer store.Shutdown()
+
+	simulateUpdate := func(value string) []byte {
+		simulator, err := env.txmgr.NewTxSimulator(util2.GenerateUUID())
+		require.NoError(t, err)
+		require.NoError(t, simulator.SetState("ns1", "key1", []byte(value)))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimResBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		return pubSimResBytes
+	}
+	commit := func(block *common.Block) {
+		require.NoError(t, store.AddBlock(block))
+		require.NoError(t, env.testHistoryDB.Commit(block))
+	}
+
+	bg, gb := testutil.NewBlockGenerator(t, "ledger1", false)
+	commit(gb)
+	// block 3 has two modifications of the key
+	commit(bg.NextBlock([][]byte{simulateUpdate("value1")}))
+	commit(bg.NextBlock([][]byte{simulateUpdate("value2")}))
+	commit(bg.NextBlock([][]byte{simulateUpdate("value3"), simulateUpdate("value4")}))
+	commit(bg.NextBlock([][]byte{simulateUpdate("value5")}))
+
+	qe, err := env.testHistoryDB.NewQueryExecutor(store)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		startBlock, endBlock uint64
+		expectedVals         []string
+	}{
+		{0, math.MaxUint64, []string{"value5", "value4", "value3", "value2", "value1"}},
+		{2, 3, []string{"value4", "value3", "value2"}},
+		{3, 3, []string{"value4", "value3"}},
+		{4, 10, []string{"value5"}},
+		{0, 0, []string{}},
+		{5, math.MaxUint64, []string{}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("blocks-%d-to-%d", tc.startBlock, tc.endBlock), func(t *testing.T) {
+			itr, err := qe.GetHistoryForKeyRange("ns1", "key1", tc.startBlock, tc.endBlock)
+			require.NoError(t, err)
+			defer itr.Close()
+			retrievedVals := []string{}
+			for {
+				kmod, err := itr.Next()
+				require.NoError(t, err)
+				if kmod == nil {
+					break
+				}
+				retrievedVals = append(retrievedVals, string(kmod.(*queryresult.KeyModification).Value))
+			}
+			require.Equal(t, tc.expectedVals, retrievedVals)
+		})
+	}
+
+	_, err = qe.GetHistoryForKeyRange("ns1", "key1", 3, 2)
+	require.EqualError(t, err, "startBlock [3] is greater than endBlock [2]")
+}
+
+func TestHistoryForKeyWithPagination(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testBlockStorageEnv.provider
+	store, err := provider.Open("ledger1")
+	require.NoError(t, err)
+	defer store.Shutdown()
+
+	simulateUpdate := func(value string) []byte {
+		simulator, err := env.txmgr.NewTxSimulator(util2.GenerateUUID())
+		require.NoError(t, err)
+		require.NoError(t, simulator.SetState("ns1", "key1", []byte(value)))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimResBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		return pubSimResBytes
+	}
+	commit := func(block *common.Block) {
+		require.NoError(t, store.AddBlock(block))
+		require.NoError(t, env.testHistoryDB.Commit(block))
+	}
+
+	bg, gb := testutil.NewBlockGenerator(t, "ledger1", false)
+	commit(gb)
+	commit(bg.NextBlock([][]byte{simulateUpdate("value1")}))
+	commit(bg.NextBlock([][]byte{simulateUpdate("value2"), simulateUpdate("value3")}))
+	commit(bg.NextBlock([][]byte{simulateUpdate("value4")}))
+	commit(bg.NextBlock([][]byte{simulateUpdate("value5")}))
+
+	qe, err := env.testHistoryDB.NewQueryExecutor(store)
+	require.NoError(t, err)
+
+	retrievePage := func(bookmark string, pageSize int32) ([]string, string) {
+		itr, err := qe.GetHistoryForKeyWithPagination("ns1", "key1", bookmark, pageSize)
+		require.NoError(t, err)
+		vals := []string{}
+		for {
+			kmod, err := itr.Next()
+			require.NoError(t, err)
+			if kmod == nil {
+				break
+			}
+			vals = append(vals, string(kmod.(*queryresult.KeyModification).Value))
+		}
+		return vals, itr.GetBookmarkAndClose()
+	}
+
+	vals, bookmark := retrievePage("", 2)
+	require.Equal(t, []string{"value5", "value4"}, vals)
+	require.Equal(t, "2:1", bookmark)
+	vals, bookmark = retrievePage(bookmark, 2)
+	require.Equal(t, []string{"value3", "value2"}, vals)
+	require.Equal(t, "1:0", bookmark)
+	vals, bookmark = retrievePage(bookmark, 2)
+	require.Equal(t, []string{"value1"}, vals)
+	require.Equal(t, "", bookmark)
+
+	vals, bookmark = retrievePage("", 0)
+	require.Equal(t, []string{"value5", "value4", "value3", "value2", "value1"}, vals)
+	require.Equal(t, "", bookmark)
+
+	vals, bookmark = retrievePage("2:0", 0)
+	require.Equal(t, []string{"value2", "value1"}, vals)
+	require.Equal(t, "", bookmark)
+
+	for _, bookmark := range []string{"2", "2:a", "-1:0", "1:2:3"} {
+		_, err = qe.GetHistoryForKeyWithPagination("ns1", "key1", bookmark, 2)
+		require.EqualError(t, err, fmt.Sprintf("invalid bookmark [%s] for the history of a key", bookmark))
+	}
+	_, err = qe.GetHistoryForKeyWithPagination("ns1", "key1", "", -1)
+	require.EqualError(t, err, "invalid page size [-1]")
+}
+
+func TestRollback(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testBlockStorageEnv.provider
+	store, err := provider.Open("ledger1")
+	require.NoError(t, err)
+	defer store.Shutdown()
+
+	bg, gb := testutil.NewBlockGenerator(t, "ledger1", false)
+	require.NoError(t, store.AddBlock(gb))
+	require.NoError(t, env.testHistoryDB.Commit(gb))
+	for i := 1; i <= 3; i++ {
+		simulator, err := env.txmgr.NewTxSimulator(util2.GenerateUUID())
+		require.NoError(t, err)
+		require.NoError(t, simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value%d", i))))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimResBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		block := bg.NextBlock([][]byte{pubSimResBytes})
+		require.NoError(t, store.AddBlock(block))
+		require.NoError(t, env.testHistoryDB.Commit(block))
+	}
+
+	err = env.testHistoryDB.Rollback(4)
+	require.EqualError(t, err, "cannot rollback history database for channel [TestHistoryDB] to block [4] as it is above the savepoint [3]")
+
+	require.NoError(t, env.testHistoryDB.Rollback(1))
+	savepoint, err := env.testHistoryDB.GetLastSavepoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), savepoint.BlockNum)
+
+	qe, err := env.testHistoryDB.NewQueryExecutor(store)
+	require.NoError(t, err)
+	testutilVerifyResults(t, qe, "ns1", "key1", []string{"value1"})
+
+	shouldRecover, nextBlock, err := env.testHistoryDB.ShouldRecover(3)
+	require.NoError(t, err)
+	require.True(t, shouldRecover)
+	require.Equal(t, uint64(2), nextBlock)
+}
+
+func TestDropAndRecommitNamespaces(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testBlockStorageEnv.provider
+	store, err := provider.Open("ledger1")
+	require.NoError(t, err)
+	defer store.Shutdown()
+
+	bg, gb := testutil.NewBlockGenerator(t, "ledger1", false)
+	require.NoError(t, store.AddBlock(gb))
+	require.NoError(t, env.testHistoryDB.Commit(gb))
+	blocks := []*common.Block{gb}
+	for i := 1; i <= 3; i++ {
+		simulator, err := env.txmgr.NewTxSimulator(util2.GenerateUUID())
+		require.NoError(t, err)
+		require.NoError(t, simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value%d", i))))
+		require.NoError(t, simulator.SetState("ns2", "key1", []byte(fmt.Sprintf("value%d", i))))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimResBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		block := bg.NextBlock([][]byte{pubSimResBytes})
+		require.NoError(t, store.AddBlock(block))
+		require.NoError(t, env.testHistoryDB.Commit(block))
+		blocks = append(blocks, block)
+	}
+
+	qe, err := env.testHistoryDB.NewQueryExecutor(store)
+	require.NoError(t, err)
+	require.NoError(t, env.testHistoryDB.DropNamespaces([]string{"ns1"}))
+	testutilVerifyResults(t, qe, "ns1", "key1", []string{})
+	testutilVerifyResults(t, qe, "ns2", "key1", []string{"value3", "value2", "value1"})
+
+	for _, block := range blocks {
+		require.NoError(t, env.testHistoryDB.RecommitNamespaces(block, []string{"ns1"}))
+	}
+	testutilVerifyResults(t, qe, "ns1", "key1", []string{"value3", "value2", "value1"})
+	testutilVerifyResults(t, qe, "ns2", "key1", []string{"value3", "value2", "value1"})
+	savepoint, err := env.testHistoryDB.GetLastSavepoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), savepoint.BlockNum)
+
+	// a namespace that is a prefix of another namespace
+	require.NoError(t, env.testHistoryDB.DropNamespaces([]string{"ns"}))
+	testutilVerifyResults(t, qe, "ns1", "key1", []string{"value3", "value2", "value1"})
+}
+
+func TestHistorySampling(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testBlockStorageEnv.provider
+	store, err := provider.Open("ledger1")
+	require.NoError(t, err)
+	defer store.Shutdown()
+
+	sampledDBProvider, err := NewDBProvider(t.TempDir(), 2, false, leveldbhelper.OpenRetry{})
+	require.NoError(t, err)
+	defer sampledDBProvider.Close()
+	sampledDB := sampledDBProvider.GetDBHandle("ledger1")
+
+	simulateUpdate := func(value string) []byte {
+		simulator, err := env.txmgr.NewTxSimulator(util2.GenerateUUID())
+		require.NoError(t, err)
+		require.NoError(t, simulator.SetState("ns1", "key1", []byte(value)))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimResBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		return pubSimResBytes
+	}
+	commit := func(block *common.Block) {
+		require.NoError(t, store.AddBlock(block))
+		require.NoError(t, sampledDB.Commit(block))
+	}
+
+	bg, gb := testutil.NewBlockGenerator(t, "ledger1", false)
+	commit(gb)
+	for i := 1; i <= 4; i++ {
+		commit(bg.NextBlock([][]byte{simulateUpdate(fmt.Sprintf("value%d", i))}))
+	}
+	qe, err := sampledDB.NewQueryExecutor(store)
+	require.NoError(t, err)
+	// the modifications 1 and 3 are retained and the latest modification 4 is recorded
+	testutilVerifyResults(t, qe, "ns1", "key1", []string{"value4", "value3", "value1"})
+
+	// two modifications in the same block, the superseded modification 4 is removed
+	commit(bg.NextBlock([][]byte{simulateUpdate("value5"), simulateUpdate("value6")}))
+	testutilVerifyResults(t, qe, "ns1", "key1", []string{"value6", "value5", "value3", "value1"})
+
+	err = sampledDB.Rollback(1)
+	require.EqualError(t, err, "cannot rollback history database for channel [ledger1] as the history is sampled")
+	err = sampledDB.DropNamespaces([]string{"ns1"})
+	require.EqualError(t, err, "cannot drop namespaces from history database for channel [ledger1] as the history is sampled")
+	err = sampledDB.RecommitNamespaces(gb, []string{"ns1"})
+	require.EqualError(t, err, "cannot recommit namespaces to history database for channel [ledger1] as the history is sampled")
+}
+
+func TestHistoryStoreValues(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testBlockStorageEnv.provider
+	store, err := provider.Open("ledger1")
+	require.NoError(t, err)
+	defer store.Shutdown()
+
+	simulate := func(update func(simulator ledger.TxSimulator) error) []byte {
+		simulator, err := env.txmgr.NewTxSimulator(util2.GenerateUUID())
+		require.NoError(t, err)
+		require.NoError(t, update(simulator))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimResBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		return pubSimResBytes
+	}
+	setValue := func(value string) []byte {
+		return simulate(func(simulator ledger.TxSimulator) error {
+			return simulator.SetState("ns1", "key1", []byte(value))
+		})
+	}
+
+	dbPath := t.TempDir()
+	dbProvider, err := NewDBProvider(dbPath, 1, false, leveldbhelper.OpenRetry{})
+	require.NoError(t, err)
+	db := dbProvider.GetDBHandle("ledger1")
+	bg, gb := testutil.NewBlockGenerator(t, "ledger1", false)
+	require.NoError(t, store.AddBlock(gb))
+	require.NoError(t, db.Commit(gb))
+	block1 := bg.NextBlock([][]byte{setValue("value1")})
+	require.NoError(t, store.AddBlock(block1))
+	require.NoError(t, db.Commit(block1))
+	dbProvider.Close()
+
+	// the values are stored for the blocks committed after the option is turned on
+	dbProvider, err = NewDBProvider(dbPath, 1, true, leveldbhelper.OpenRetry{})
+	require.NoError(t, err)
+	defer dbProvider.Close()
+	db = dbProvider.GetDBHandle("ledger1")
+	block2 := bg.NextBlock([][]byte{setValue("value2")})
+	block3 := bg.NextBlock([][]byte{simulate(func(simulator ledger.TxSimulator) error {
+		return simulator.DeleteState("ns1", "key1")
+	})})
+	for _, block := range []*common.Block{block2, block3} {
+		require.NoError(t, store.AddBlock(block))
+		require.NoError(t, db.Commit(block))
+	}
+
+	entry, err := db.levelDB.Get(constructDataKey("ns1", "key1", 1, 0))
+	require.NoError(t, err)
+	require.Empty(t, entry)
+	entry, err = db.levelDB.Get(constructDataKey("ns1", "key1", 2, 0))
+	require.NoError(t, err)
+	require.NotEmpty(t, entry)
+
+	qe, err := db.NewQueryExecutor(store)
+	require.NoError(t, err)
+	itr, err := qe.GetHistoryForKey("ns1", "key1")
+	require.NoError(t, err)
+	defer itr.Close()
+	var keyMods []*queryresult.KeyModification
+	for {
+		kmod, err := itr.Next()
+		require.NoError(t, err)
+		if kmod == nil {
+			break
+		}
+		keyMods = append(keyMods, kmod.(*queryresult.KeyModification))
+	}
+	require.Len(t, keyMods, 3)
+	require.True(t, keyMods[0].IsDelete)
+	require.Nil(t, keyMods[0].Value)
+	require.Equal(t, []byte("value2"), keyMods[1].Value)
+	require.False(t, keyMods[1].IsDelete)
+	require.Equal(t, []byte("value1"), keyMods[2].Value)
+
+	// the stored modifications are the same as the ones read from the block store
+	for i, blockNum := range []uint64{3, 2, 1} {
+		tranEnvelope, err := store.RetrieveTxByBlockNumTranNum(blockNum, 0)
+		require.NoError(t, err)
+		expected, err := getKeyModificationFromTran(tranEnvelope, "ns1", "key1")
+		require.NoError(t, err)
+		require.True(t, proto.Equal(expected.(*queryresult.KeyModification), keyMods[i]))
+	}
+
+	// the stored modifications are served without the block store
+	qe, err = db.NewQueryExecutor(nil)
+	require.NoError(t, err)
+	keyMod, err := qe.(*QueryExecutor).GetKeyModificationBelowHeight("ns1", "key1", 3)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(keyMods[1], keyMod))
+}
+
 func TestName(t *testing.T) {
 	env := newTestHistoryEnv(t)
 	defer env.cleanup()
@@ -24,6 +24,9 @@ var (
 	compositeKeySep = []byte{0x00} // used as a separator between different components of dataKey
 	savePointKey    = []byte{'s'}  // a single key in db for persisting savepoint
 	emptyValue      = []byte{}     // used to store as value for keys where only key needs to be stored (e.g., dataKeys)
+	// samplingKeyPrefix is the prefix for the keys that track the modifications of a key when the history is sampled.
+	// A dataKey begins with a non-empty namespace and hence, never begins with this prefix
+	samplingKeyPrefix = []byte{0x00, 's'}
 )
 
 // constructDataKey builds the key of the format namespace~len(key)~key~blocknum~trannum
@@ -57,6 +60,26 @@ func constructRangeScan(ns string, key string) *rangeScan {
 	}
 }
 
+// decodeBlockNumTranNum decodes the block number and the transaction number from a dataKey
+// without requiring the namespace and key for which the dataKey was constructed
+func decodeBlockNumTranNum(dataKey dataKey) (uint64, uint64, error) {
+	nsEnd := bytes.Index(dataKey, compositeKeySep)
+	if nsEnd < 0 {
+		return 0, 0, errors.Errorf("invalid dataKey [%x]: namespace separator not found", []byte(dataKey))
+	}
+	remaining := dataKey[nsEnd+1:]
+	keyLen, lenBytesConsumed, err := util.DecodeOrderPreservingVarUint64(remaining)
+	if err != nil {
+		return 0, 0, err
+	}
+	keyEnd := uint64(lenBytesConsumed) + keyLen
+	if keyEnd+1 > uint64(len(remaining)) {
+		return 0, 0, errors.Errorf("invalid dataKey [%x]: key length exceeds the dataKey length", []byte(dataKey))
+	}
+	r := &rangeScan{startKey: dataKey[:uint64(nsEnd+1)+keyEnd+1]}
+	return r.decodeBlockNumTranNum(dataKey)
+}
+
 func (r *rangeScan) decodeBlockNumTranNum(dataKey dataKey) (uint64, uint64, error) {
 	blockNumTranNumBytes := bytes.TrimPrefix(dataKey, r.startKey)
 	blockNum, blockBytesConsumed, err := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes)
@@ -76,3 +99,44 @@ func (r *rangeScan) decodeBlockNumTranNum(dataKey dataKey) (uint64, uint64, erro
 	}
 	return blockNum, tranNum, nil
 }
+
+// constructSamplingKey builds the key of the format 0x00~s~namespace~len(key)~key that tracks
+// the number of modifications and the latest entry of a key when the history is sampled
+func constructSamplingKey(ns string, key string) []byte {
+	k := append([]byte{}, samplingKeyPrefix...)
+	k = append(k, []byte(ns)...)
+	k = append(k, compositeKeySep...)
+	k = append(k, util.EncodeOrderPreservingVarUint64(uint64(len(key)))...)
+	k = append(k, []byte(key)...)
+	return k
+}
+
+// samplingInfo records the number of modifications of a key since the sampling was enabled
+// and the height of the latest modification
+type samplingInfo struct {
+	numModifications uint64
+	blockNum         uint64
+	tranNum          uint64
+}
+
+func (s *samplingInfo) toBytes() []byte {
+	b := util.EncodeOrderPreservingVarUint64(s.numModifications)
+	b = append(b, util.EncodeOrderPreservingVarUint64(s.blockNum)...)
+	return append(b, util.EncodeOrderPreservingVarUint64(s.tranNum)...)
+}
+
+func samplingInfoFromBytes(b []byte) (*samplingInfo, error) {
+	s := &samplingInfo{}
+	var n int
+	var err error
+	for _, field := range []*uint64{&s.numModifications, &s.blockNum, &s.tranNum} {
+		if *field, n, err = util.DecodeOrderPreservingVarUint64(b); err != nil {
+			return nil, errors.WithMessage(err, "error while decoding the sampling info")
+		}
+		b = b[n:]
+	}
+	if len(b) != 0 {
+		return nil, errors.Errorf("unexpected %d trailing bytes in the sampling info", len(b))
+	}
+	return s, nil
+}
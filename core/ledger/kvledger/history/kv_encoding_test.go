@@ -54,3 +54,28 @@ func TestSplitCompositeKey(t *testing.T) {
 	require.Equal(t, blkNum, uint64(20))
 	require.Equal(t, txNum, uint64(200))
 }
+
+func TestDecodeBlockNumTranNum(t *testing.T) {
+	for _, key := range []string{"key1", "key1\x00", "\x00key\x00\x001", ""} {
+		dataKey := constructDataKey("ns1", key, 20, 200)
+		blkNum, txNum, err := decodeBlockNumTranNum(dataKey)
+		require.NoError(t, err)
+		require.Equal(t, uint64(20), blkNum)
+		require.Equal(t, uint64(200), txNum)
+	}
+
+	_, _, err := decodeBlockNumTranNum(dataKey("ns1"))
+	require.EqualError(t, err, "invalid dataKey [6e7331]: namespace separator not found")
+}
+
+func TestSamplingInfoEncoding(t *testing.T) {
+	info := &samplingInfo{numModifications: 5, blockNum: 20, tranNum: 200}
+	decoded, err := samplingInfoFromBytes(info.toBytes())
+	require.NoError(t, err)
+	require.Equal(t, info, decoded)
+
+	_, err = samplingInfoFromBytes(append(info.toBytes(), 0x01))
+	require.EqualError(t, err, "unexpected 1 trailing bytes in the sampling info")
+
+	require.True(t, bytes.HasPrefix(constructSamplingKey("ns1", "key1"), samplingKeyPrefix))
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/common/metrics/disabled"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
@@ -63,7 +64,7 @@ func newTestHistoryEnv(t *testing.T) *levelDBLockBasedHistoryEnv {
 	txMgr, err := txmgr.NewLockBasedTxMgr(txmgrInitializer)
 
 	require.NoError(t, err)
-	testHistoryDBProvider, err := NewDBProvider(testHistoryDBPath)
+	testHistoryDBProvider, err := NewDBProvider(testHistoryDBPath, 1, false, leveldbhelper.OpenRetry{})
 	require.NoError(t, err)
 	testHistoryDB := testHistoryDBProvider.GetDBHandle("TestHistoryDB")
 
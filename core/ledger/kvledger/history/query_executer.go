@@ -7,11 +7,18 @@ SPDX-License-Identifier: Apache-2.0
 package history
 
 import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	protoutil "github.com/hyperledger/fabric/protoutil"
 	"github.com/pkg/errors"
@@ -38,7 +45,132 @@ func (q *QueryExecutor) GetHistoryForKey(namespace string, key string) (commonle
 	if dbItr.Last() {
 		dbItr.Next()
 	}
-	return &historyScanner{rangeScan, namespace, key, dbItr, q.blockStore}, nil
+	return &historyScanner{
+		rangeScan:  rangeScan,
+		namespace:  namespace,
+		key:        key,
+		dbItr:      dbItr,
+		blockStore: q.blockStore,
+	}, nil
+}
+
+// GetHistoryForKeyRange implements method in interface `ledger.HistoryQueryExecutor`
+func (q *QueryExecutor) GetHistoryForKeyRange(namespace string, key string, startBlock, endBlock uint64) (commonledger.ResultsIterator, error) {
+	if startBlock > endBlock {
+		return nil, errors.Errorf("startBlock [%d] is greater than endBlock [%d]", startBlock, endBlock)
+	}
+	rangeScan := constructRangeScan(namespace, key)
+	startKey := constructDataKey(namespace, key, startBlock, 0)
+	endKey := rangeScan.endKey
+	if endBlock < math.MaxUint64 {
+		endKey = constructDataKey(namespace, key, endBlock+1, 0)
+	}
+	dbItr, err := q.levelDB.GetIterator(startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// move the cursor to the end of the entries so that the entries are iterated in the order of newest to oldest
+	if dbItr.Last() {
+		dbItr.Next()
+	}
+	return &historyScanner{
+		rangeScan:  rangeScan,
+		namespace:  namespace,
+		key:        key,
+		dbItr:      dbItr,
+		blockStore: q.blockStore,
+	}, nil
+}
+
+// GetHistoryForKeyWithPagination implements method in interface `ledger.HistoryQueryExecutor`. The bookmark
+// is the block number and the transaction number of the next modification of the key, in the form blockNum:tranNum
+func (q *QueryExecutor) GetHistoryForKeyWithPagination(namespace, key, bookmark string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	if pageSize < 0 {
+		return nil, errors.Errorf("invalid page size [%d]", pageSize)
+	}
+	rangeScan := constructRangeScan(namespace, key)
+	endKey := rangeScan.endKey
+	if bookmark != "" {
+		blockNum, tranNum, err := decodeBookmark(bookmark)
+		if err != nil {
+			return nil, err
+		}
+		// the modification at the bookmark is the first result, as the entries are iterated from newest to oldest
+		endKey = append(constructDataKey(namespace, key, blockNum, tranNum), 0x00)
+	}
+	dbItr, err := q.levelDB.GetIterator(rangeScan.startKey, endKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if dbItr.Last() {
+		dbItr.Next()
+	}
+	return &historyScanner{
+		rangeScan:  rangeScan,
+		namespace:  namespace,
+		key:        key,
+		dbItr:      dbItr,
+		blockStore: q.blockStore,
+		pageSize:   pageSize,
+	}, nil
+}
+
+// GetKeyModificationBelowHeight returns the modification of the key by the last transaction, in the blocks below
+// the given height, that wrote the key. A nil value is returned if the key was not written in the blocks below
+// the given height
+func (q *QueryExecutor) GetKeyModificationBelowHeight(namespace, key string, height uint64) (*queryresult.KeyModification, error) {
+	rangeScan := constructRangeScan(namespace, key)
+	dbItr, err := q.levelDB.GetIterator(rangeScan.startKey, constructDataKey(namespace, key, height, 0))
+	if err != nil {
+		return nil, err
+	}
+	defer dbItr.Release()
+	if !dbItr.Last() {
+		return nil, dbItr.Error()
+	}
+	blockNum, tranNum, err := rangeScan.decodeBlockNumTranNum(dbItr.Key())
+	if err != nil {
+		return nil, err
+	}
+	return retrieveKeyModification(q.blockStore, dbItr.Value(), namespace, key, blockNum, tranNum)
+}
+
+// retrieveKeyModification returns the modification of the key by the transaction at the given block number and
+// transaction number. The modification is decoded from the value of the history entry if the value was stored
+// in the entry, otherwise it is read from the transaction in the block store
+func retrieveKeyModification(
+	blockStore *blkstorage.BlockStore,
+	entryValue []byte,
+	namespace, key string,
+	blockNum, tranNum uint64,
+) (*queryresult.KeyModification, error) {
+	if len(entryValue) > 0 {
+		keyMod := &queryresult.KeyModification{}
+		if err := proto.Unmarshal(entryValue, keyMod); err != nil {
+			return nil, errors.Wrapf(err, "error while unmarshalling the history entry for namespace %s and key %s with blockNum %d and tranNum %d", namespace, key, blockNum, tranNum)
+		}
+		return keyMod, nil
+	}
+
+	// Get the transaction from block storage that is associated with this history record
+	tranEnvelope, err := blockStore.RetrieveTxByBlockNumTranNum(blockNum, tranNum)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the txid, key write value, timestamp, and delete indicator associated with this transaction
+	queryResult, err := getKeyModificationFromTran(tranEnvelope, namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	if queryResult == nil {
+		// should not happen, but make sure there is inconsistency between historydb and statedb
+		logger.Errorf("No namespace or key is found for namespace %s and key %s with decoded blockNum %d and tranNum %d", namespace, key, blockNum, tranNum)
+		return nil, errors.Errorf("no namespace or key is found for namespace %s and key %s with decoded blockNum %d and tranNum %d", namespace, key, blockNum, tranNum)
+	}
+	return queryResult.(*queryresult.KeyModification), nil
 }
 
 // historyScanner implements ResultsIterator for iterating through history results
@@ -48,12 +180,18 @@ type historyScanner struct {
 	key        string
 	dbItr      iterator.Iterator
 	blockStore *blkstorage.BlockStore
+	// pageSize, when greater than zero, limits the number of results returned by the scanner
+	pageSize    int32
+	numReturned int32
 }
 
 // Next iterates to the next key, in the order of newest to oldest, from history scanner.
-// It decodes blockNumTranNumBytes to get blockNum and tranNum,
-// loads the block:tran from block storage, finds the key and returns the result.
+// It decodes blockNumTranNumBytes to get blockNum and tranNum, and returns the modification
+// stored in the history entry or, if not stored, loads the block:tran from block storage and finds the key.
 func (scanner *historyScanner) Next() (commonledger.QueryResult, error) {
+	if scanner.pageSize > 0 && scanner.numReturned >= scanner.pageSize {
+		return nil, nil
+	}
 	// call Prev because history query result is returned from newest to oldest
 	if !scanner.dbItr.Prev() {
 		return nil, nil
@@ -67,24 +205,13 @@ func (scanner *historyScanner) Next() (commonledger.QueryResult, error) {
 	logger.Debugf("Found history record for namespace:%s key:%s at blockNumTranNum %v:%v\n",
 		scanner.namespace, scanner.key, blockNum, tranNum)
 
-	// Get the transaction from block storage that is associated with this history record
-	tranEnvelope, err := scanner.blockStore.RetrieveTxByBlockNumTranNum(blockNum, tranNum)
-	if err != nil {
-		return nil, err
-	}
-
-	// Get the txid, key write value, timestamp, and delete indicator associated with this transaction
-	queryResult, err := getKeyModificationFromTran(tranEnvelope, scanner.namespace, scanner.key)
+	queryResult, err := retrieveKeyModification(scanner.blockStore, scanner.dbItr.Value(), scanner.namespace, scanner.key, blockNum, tranNum)
 	if err != nil {
 		return nil, err
 	}
-	if queryResult == nil {
-		// should not happen, but make sure there is inconsistency between historydb and statedb
-		logger.Errorf("No namespace or key is found for namespace %s and key %s with decoded blockNum %d and tranNum %d", scanner.namespace, scanner.key, blockNum, tranNum)
-		return nil, errors.Errorf("no namespace or key is found for namespace %s and key %s with decoded blockNum %d and tranNum %d", scanner.namespace, scanner.key, blockNum, tranNum)
-	}
 	logger.Debugf("Found historic key value for namespace:%s key:%s from transaction %s",
-		scanner.namespace, scanner.key, queryResult.(*queryresult.KeyModification).TxId)
+		scanner.namespace, scanner.key, queryResult.TxId)
+	scanner.numReturned++
 	return queryResult, nil
 }
 
@@ -92,6 +219,36 @@ func (scanner *historyScanner) Close() {
 	scanner.dbItr.Release()
 }
 
+// GetBookmarkAndClose returns the bookmark for the next modification of the key, if any, and closes the scanner
+func (scanner *historyScanner) GetBookmarkAndClose() string {
+	defer scanner.Close()
+	if !scanner.dbItr.Prev() {
+		return ""
+	}
+	blockNum, tranNum, err := scanner.rangeScan.decodeBlockNumTranNum(scanner.dbItr.Key())
+	if err != nil {
+		logger.Errorf("Error while decoding the history entry for the bookmark: %s", err)
+		return ""
+	}
+	return encodeBookmark(blockNum, tranNum)
+}
+
+func encodeBookmark(blockNum, tranNum uint64) string {
+	return fmt.Sprintf("%d:%d", blockNum, tranNum)
+}
+
+func decodeBookmark(bookmark string) (uint64, uint64, error) {
+	parts := strings.Split(bookmark, ":")
+	if len(parts) == 2 {
+		blockNum, err1 := strconv.ParseUint(parts[0], 10, 64)
+		tranNum, err2 := strconv.ParseUint(parts[1], 10, 64)
+		if err1 == nil && err2 == nil {
+			return blockNum, tranNum, nil
+		}
+	}
+	return 0, 0, errors.Errorf("invalid bookmark [%s] for the history of a key", bookmark)
+}
+
 // getTxIDandKeyWriteValueFromTran inspects a transaction for writes to a given key
 func getKeyModificationFromTran(tranEnvelope *common.Envelope, namespace string, key string) (commonledger.QueryResult, error) {
 	logger.Debugf("Entering getKeyModificationFromTran %s:%s", namespace, key)
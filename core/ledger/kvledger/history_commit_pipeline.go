@@ -0,0 +1,119 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/history"
+	"github.com/pkg/errors"
+)
+
+// historyCommitPipeline makes the history commit asynchronous, i.e., it commits the blocks to the history database
+// in the background, in the order in which the blocks are submitted, so that the commit of a block to the history
+// database overlaps with the processing of the subsequent blocks. Only the history commit is taken off the commit
+// path; the block store append, the validation, and the state commit of a block remain in sequence, as the validation
+// of a block depends on the state committed for the previous block and the state database is not permitted to go
+// ahead of the block store. The history database lags behind the block store only until the pending commits finish
+// or, after a crash or a failed commit, until it is caught up with the block store on the next ledger open
+type historyCommitPipeline struct {
+	ledgerID  string
+	historyDB *history.DB
+	blocks    chan *common.Block
+	doneCh    chan struct{}
+
+	// submitted and processed count the blocks submitted to and processed by the pipeline. After a failed commit,
+	// err is set and the subsequent blocks are processed without being committed to the history database
+	mutex     sync.Mutex
+	cond      *sync.Cond
+	submitted uint64
+	processed uint64
+	err       error
+}
+
+func newHistoryCommitPipeline(ledgerID string, historyDB *history.DB, depth int) *historyCommitPipeline {
+	p := &historyCommitPipeline{
+		ledgerID:  ledgerID,
+		historyDB: historyDB,
+		blocks:    make(chan *common.Block, depth),
+		doneCh:    make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.mutex)
+	return p
+}
+
+func (p *historyCommitPipeline) run() {
+	defer close(p.doneCh)
+	for block := range p.blocks {
+		if p.getErr() == nil {
+			logger.Debugf("[%s] Committing block [%d] transactions to history database", p.ledgerID, block.Header.Number)
+			if err := p.historyDB.Commit(block); err != nil {
+				logger.Errorf("[%s] Commit of block [%d] to history database failed, further commits are rejected until the ledger is reopened: %s",
+					p.ledgerID, block.Header.Number, err)
+				p.setErr(errors.WithMessagef(err, "commit of block [%d] to the history database of ledger [%s] failed", block.Header.Number, p.ledgerID))
+			}
+		}
+		p.mutex.Lock()
+		p.processed++
+		p.cond.Broadcast()
+		p.mutex.Unlock()
+	}
+}
+
+func (p *historyCommitPipeline) getErr() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.err
+}
+
+func (p *historyCommitPipeline) setErr(err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.err = err
+}
+
+// submit submits a block for the commit to the history database. It blocks if the commits of the number of blocks
+// equal to the depth of the pipeline are pending. The caller is expected to submit the blocks in order, after the
+// blocks are added to the block store
+func (p *historyCommitPipeline) submit(block *common.Block) {
+	p.mutex.Lock()
+	p.submitted++
+	p.mutex.Unlock()
+	p.blocks <- block
+}
+
+// waitForPending waits for the commits of the blocks submitted so far to finish and returns the error of a failed
+// commit, if any. The blocks that are submitted while waiting are not waited for, so that a caller is not held off
+// indefinitely under a constant commit load
+func (p *historyCommitPipeline) waitForPending() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	submitted := p.submitted
+	for p.processed < submitted {
+		p.cond.Wait()
+	}
+	return p.err
+}
+
+// stop waits for the pending commits to finish and stops the background goroutine
+func (p *historyCommitPipeline) stop() {
+	close(p.blocks)
+	<-p.doneCh
+}
+
+// waitForPendingHistoryCommits waits for the commits of the blocks submitted to the history commit pipeline, if any,
+// and for the writes left running in the background on a commit timeout, so that the history database reflects all
+// the blocks committed to the ledger before the invocation. An error is returned if a commit in the history commit
+// pipeline failed, as the history database then lags behind the block store until the ledger is reopened
+func (l *kvLedger) waitForPendingHistoryCommits() error {
+	l.pendingDBCommits.Wait()
+	if l.historyCommitPipeline != nil {
+		return l.historyCommitPipeline.waitForPending()
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryCommitPipeline(t *testing.T) {
+	conf := testConfig(t)
+	conf.HistoryDBConfig.CommitPipelineDepth = 2
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	pipeline := lgr.(*kvLedger).historyCommitPipeline
+	require.NotNil(t, pipeline)
+
+	for i := 1; i <= 5; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+	// the history rebuild status waits for the pending commits
+	status, err := lgr.HistoryRebuildStatus()
+	require.NoError(t, err)
+	require.Equal(t, &ledger.HistoryRebuildStatus{HistoryHeight: 6, BlockStoreHeight: 6}, status)
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			historyDBSavePoint: uint64(5),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.5", "value1.4", "value1.3", "value1.2", "value1.1"},
+		},
+	)
+
+	// the pending commits are finished on close and the history db is up to date on the next open
+	blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, "SimulateForBlk6",
+		map[string]string{"key1": "value1.6"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	lgr.Close()
+	<-pipeline.doneCh
+	provider.Close()
+
+	conf.HistoryDBConfig.CommitPipelineDepth = 0
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	require.Nil(t, lgr.(*kvLedger).historyCommitPipeline)
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			historyDBSavePoint: uint64(6),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.6", "value1.5", "value1.4", "value1.3", "value1.2", "value1.1"},
+		},
+	)
+}
+
+func TestHistoryCommitPipelineFailure(t *testing.T) {
+	conf := testConfig(t)
+	conf.HistoryDBConfig.CommitPipelineDepth = 2
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "SimulateForBlk1", map[string]string{"key1": "value1.1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	// the block is added to the ledger but its commit to the history database fails in the background
+	_, err = lgr.NewHistoryQueryExecutor()
+	require.NoError(t, err)
+	provider.historydbProvider.Close()
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "SimulateForBlk2", map[string]string{"key1": "value1.2"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+	_, err = lgr.NewHistoryQueryExecutor()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "commit of block [2] to the history database of ledger [testLedger] failed")
+
+	blk3 := prepareNextBlockForTest(t, lgr, bg, "SimulateForBlk3", map[string]string{"key1": "value1.3"}, nil)
+	err = lgr.CommitLegacy(blk3, &ledger.CommitOptions{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "commit of block [2] to the history database of ledger [testLedger] failed")
+	lgr.Close()
+	provider.Close()
+
+	// the history database is caught up with the block store on the next open
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			historyDBSavePoint: uint64(2),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.2", "value1.1"},
+		},
+	)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/pkg/errors"
+)
+
+// historyRebuildBatchSize is the maximum number of blocks committed to the history DB
+// in one go during a background rebuild. The block commits are held off only between the batches
+const historyRebuildBatchSize = 100
+
+var errHistoryRebuildStopped = errors.New("history rebuild stopped as the ledger is closed")
+
+// historyRebuild catches up the history DB with the block store in the background for a ledger that is
+// opened with the option `DeferHistoryRebuild`. Until the rebuild completes, the history DB does not receive
+// the new blocks via the regular commit path and the history queries are not served
+type historyRebuild struct {
+	l      *kvLedger
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	mutex      sync.Mutex
+	inProgress bool
+	err        error
+}
+
+func newHistoryRebuild(l *kvLedger) *historyRebuild {
+	return &historyRebuild{
+		l:          l,
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
+		inProgress: true,
+	}
+}
+
+func (r *historyRebuild) run() {
+	defer close(r.doneCh)
+	err := r.rebuild()
+	switch {
+	case err == errHistoryRebuildStopped:
+		logger.Infof("Stopped the rebuild of the history database for ledger [%s]", r.l.ledgerID)
+	case err != nil:
+		logger.Errorf("Failed to rebuild the history database for ledger [%s]: %s", r.l.ledgerID, err)
+	default:
+		logger.Infof("Completed the rebuild of the history database for ledger [%s]", r.l.ledgerID)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.inProgress = false
+	r.err = err
+}
+
+func (r *historyRebuild) rebuild() error {
+	return r.l.catchUpHistoryDB(r.stopCh)
+}
+
+// catchUpHistoryDB commits to the history DB the blocks from its savepoint to the tip of the block store, in batches,
+// and then resumes the history DB commits via the regular commit path. This resumes from the savepoint of the history
+// DB and hence, an interrupted catch-up continues where it left off and a repeated catch-up is a no-op. It returns
+// errHistoryRebuildStopped if the stopCh is closed before the catch-up completes
+func (l *kvLedger) catchUpHistoryDB(stopCh <-chan struct{}) error {
+	for {
+		select {
+		case <-stopCh:
+			return errHistoryRebuildStopped
+		default:
+		}
+
+		caughtUp, err := l.commitNextHistoryBatch()
+		if err != nil {
+			return err
+		}
+		if !caughtUp {
+			continue
+		}
+		completed, err := l.completeHistoryCatchUp()
+		if err != nil || completed {
+			return err
+		}
+	}
+}
+
+// commitNextHistoryBatch commits the next batch of blocks to the history DB. The read lock lets the block queries
+// proceed while holding off the block commits for the duration of the batch
+func (l *kvLedger) commitNextHistoryBatch() (bool, error) {
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	return l.commitBlocksToHistoryDB(historyRebuildBatchSize)
+}
+
+// completeHistoryCatchUp resumes the history DB commits if the history DB has caught up with the block store.
+// The write lock ensures that no block gets committed between the check and the resumption
+func (l *kvLedger) completeHistoryCatchUp() (bool, error) {
+	l.blockAPIsRWLock.Lock()
+	defer l.blockAPIsRWLock.Unlock()
+	caughtUp, err := l.commitBlocksToHistoryDB(historyRebuildBatchSize)
+	if err != nil || !caughtUp {
+		return false, err
+	}
+	l.historyDBCommitsPaused = false
+	return true, nil
+}
+
+// commitBlocksToHistoryDB commits to the history DB at most maxBlocks of the blocks that the history DB lags behind
+// the block store and returns true if the history DB has caught up. The caller is expected to hold blockAPIsRWLock
+func (l *kvLedger) commitBlocksToHistoryDB(maxBlocks uint64) (bool, error) {
+	// the blocks pending in the history commit pipeline are not committed again
+	if err := l.waitForPendingHistoryCommits(); err != nil {
+		return false, err
+	}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return false, err
+	}
+	savepoint, err := l.historyDB.GetLastSavepoint()
+	if err != nil {
+		return false, err
+	}
+	nextBlock := uint64(0)
+	if savepoint != nil {
+		nextBlock = savepoint.BlockNum + 1
+	}
+
+	for n := uint64(0); n < maxBlocks && nextBlock < info.Height; n++ {
+		// the block store is used directly as GetPvtDataAndBlockByNum acquires blockAPIsRWLock
+		block, err := l.blockStore.RetrieveBlockByNumber(nextBlock)
+		if err != nil {
+			return false, err
+		}
+		if err := l.historyDB.CommitLostBlock(&ledger.BlockAndPvtData{Block: block}); err != nil {
+			return false, err
+		}
+		nextBlock++
+	}
+	return nextBlock >= info.Height, nil
+}
+
+// checkCompleted returns an error if the rebuild is in progress or has failed
+func (r *historyRebuild) checkCompleted() error {
+	inProgress, err := r.state()
+	if inProgress {
+		return &ledger.HistoryDBRebuildInProgressError{LedgerID: r.l.ledgerID}
+	}
+	if err != nil {
+		return errors.WithMessagef(err, "history rebuild failed for ledger [%s]", r.l.ledgerID)
+	}
+	return nil
+}
+
+func (r *historyRebuild) state() (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.inProgress, r.err
+}
+
+// stop stops the rebuild and waits for the background goroutine to exit
+func (r *historyRebuild) stop() {
+	close(r.stopCh)
+	<-r.doneCh
+}
+
+// CatchUpHistoryDB implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) CatchUpHistoryDB() error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	if l.historyDB == nil {
+		return &ledger.HistoryDBNotEnabledError{LedgerID: l.ledgerID}
+	}
+	if l.historyRebuild != nil {
+		if inProgress, _ := l.historyRebuild.state(); inProgress {
+			return &ledger.HistoryDBRebuildInProgressError{LedgerID: l.ledgerID}
+		}
+	}
+	// the concurrent catch-ups are serialized as each of them commits the batches under the read lock
+	l.historyCatchUpLock.Lock()
+	defer l.historyCatchUpLock.Unlock()
+	return l.catchUpHistoryDB(nil)
+}
+
+// HistoryDBSavepoint implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) HistoryDBSavepoint() (*ledger.HistoryDBSavepoint, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if l.historyDB == nil {
+		return nil, &ledger.HistoryDBNotEnabledError{LedgerID: l.ledgerID}
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	if err := l.waitForPendingHistoryCommits(); err != nil {
+		return nil, err
+	}
+	savepoint, err := l.historyDB.GetLastSavepoint()
+	if err != nil || savepoint == nil {
+		return nil, err
+	}
+	return &ledger.HistoryDBSavepoint{BlockNum: savepoint.BlockNum, TxNum: savepoint.TxNum}, nil
+}
+
+// HistoryRebuildStatus implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) HistoryRebuildStatus() (*ledger.HistoryRebuildStatus, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if l.historyDB == nil {
+		return nil, &ledger.HistoryDBNotEnabledError{LedgerID: l.ledgerID}
+	}
+
+	status := &ledger.HistoryRebuildStatus{}
+	if l.historyRebuild != nil {
+		status.InProgress, status.Err = l.historyRebuild.state()
+	}
+
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	if err := l.waitForPendingHistoryCommits(); err != nil {
+		return nil, err
+	}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	savepoint, err := l.historyDB.GetLastSavepoint()
+	if err != nil {
+		return nil, err
+	}
+	status.BlockStoreHeight = info.Height
+	if savepoint != nil {
+		status.HistoryHeight = savepoint.BlockNum + 1
+	}
+	return status, nil
+}
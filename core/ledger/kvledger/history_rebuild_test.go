@@ -0,0 +1,165 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeferredHistoryRebuild(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+	status, err := lgr.HistoryRebuildStatus()
+	require.NoError(t, err)
+	require.Equal(t, &ledger.HistoryRebuildStatus{HistoryHeight: 4, BlockStoreHeight: 4}, status)
+
+	// roll back the history db so that it lags behind the block store on the next open
+	require.NoError(t, lgr.RollbackHistoryDB(1))
+	lgr.Close()
+	provider.Close()
+
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	lgr, err = provider.OpenWithOptions("testLedger", &ledger.OpenOptions{DeferHistoryRebuild: true})
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	require.Eventually(t,
+		func() bool {
+			status, err := lgr.HistoryRebuildStatus()
+			require.NoError(t, err)
+			return !status.InProgress
+		},
+		10*time.Second, 10*time.Millisecond,
+	)
+	status, err = lgr.HistoryRebuildStatus()
+	require.NoError(t, err)
+	require.Equal(t, &ledger.HistoryRebuildStatus{HistoryHeight: 4, BlockStoreHeight: 4}, status)
+
+	// the history db receives the commits once the rebuild is completed
+	blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, "SimulateForBlk4",
+		map[string]string{"key1": "value1.4"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			historyDBSavePoint: uint64(4),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.4", "value1.3", "value1.2", "value1.1"},
+		},
+	)
+
+	t.Run("rebuild-in-progress", func(t *testing.T) {
+		kvl := &kvLedger{
+			ledgerID:  "testLedger",
+			historyDB: lgr.(*kvLedger).historyDB,
+		}
+		kvl.historyRebuild = newHistoryRebuild(kvl)
+		_, err := kvl.NewHistoryQueryExecutor()
+		require.EqualError(t, err, "history rebuild in progress for ledger [testLedger]")
+		require.IsType(t, &ledger.HistoryDBRebuildInProgressError{}, err)
+	})
+
+	t.Run("rebuild-failed", func(t *testing.T) {
+		kvl := &kvLedger{
+			ledgerID:  "testLedger",
+			historyDB: lgr.(*kvLedger).historyDB,
+		}
+		kvl.historyRebuild = newHistoryRebuild(kvl)
+		kvl.historyRebuild.inProgress = false
+		kvl.historyRebuild.err = errors.New("error-retrieving-block")
+		_, err := kvl.NewHistoryQueryExecutor()
+		require.EqualError(t, err, "history rebuild failed for ledger [testLedger]: error-retrieving-block")
+	})
+
+	t.Run("history-disabled", func(t *testing.T) {
+		kvl := &kvLedger{ledgerID: "testLedger"}
+		_, err := kvl.HistoryRebuildStatus()
+		require.EqualError(t, err, "history database is not enabled for ledger [testLedger]")
+	})
+}
+
+func TestCatchUpHistoryDB(t *testing.T) {
+	provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	commitBlock := func(blockNum int) {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", blockNum),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", blockNum)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+	verifySavepoint := func(expectedBlockNum uint64) {
+		savepoint, err := lgr.HistoryDBSavepoint()
+		require.NoError(t, err)
+		require.NotNil(t, savepoint)
+		require.Equal(t, expectedBlockNum, savepoint.BlockNum)
+	}
+
+	for i := 1; i <= 3; i++ {
+		commitBlock(i)
+	}
+	verifySavepoint(3)
+
+	// the history db lags behind the block store after the rollback and misses the subsequent commits
+	require.NoError(t, lgr.RollbackHistoryDB(1))
+	commitBlock(4)
+	verifySavepoint(1)
+	checkHistoryDBForTest(t, lgr, "key1", []string{"value1.1"})
+
+	require.NoError(t, lgr.CatchUpHistoryDB())
+	verifySavepoint(4)
+	checkHistoryDBForTest(t, lgr, "key1", []string{"value1.4", "value1.3", "value1.2", "value1.1"})
+
+	// a repeated catch-up is a no-op and the history db receives the commits after the catch-up
+	require.NoError(t, lgr.CatchUpHistoryDB())
+	verifySavepoint(4)
+	commitBlock(5)
+	verifySavepoint(5)
+	checkHistoryDBForTest(t, lgr, "key1", []string{"value1.5", "value1.4", "value1.3", "value1.2", "value1.1"})
+
+	t.Run("rebuild-in-progress", func(t *testing.T) {
+		kvl := &kvLedger{
+			ledgerID:  "testLedger",
+			historyDB: lgr.(*kvLedger).historyDB,
+		}
+		kvl.historyRebuild = newHistoryRebuild(kvl)
+		err := kvl.CatchUpHistoryDB()
+		require.EqualError(t, err, "history rebuild in progress for ledger [testLedger]")
+		require.IsType(t, &ledger.HistoryDBRebuildInProgressError{}, err)
+	})
+
+	t.Run("history-disabled", func(t *testing.T) {
+		kvl := &kvLedger{ledgerID: "testLedger"}
+		require.EqualError(t, kvl.CatchUpHistoryDB(), "history database is not enabled for ledger [testLedger]")
+		_, err := kvl.HistoryDBSavepoint()
+		require.EqualError(t, err, "history database is not enabled for ledger [testLedger]")
+	})
+}
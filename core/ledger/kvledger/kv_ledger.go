@@ -7,8 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package kvledger
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -42,6 +44,7 @@ var logger = flogging.MustGetLogger("kvledger")
 var (
 	rwsetHashOpts    = &bccsp.SHA256Opts{}
 	snapshotHashOpts = &bccsp.SHA256Opts{}
+	commitHashOpts   = &bccsp.SHA256Opts{}
 )
 
 // kvLedger provides an implementation of `ledger.PeerLedger`.
@@ -54,9 +57,11 @@ type kvLedger struct {
 	txmgr                  *txmgr.LockBasedTxMgr
 	historyDB              *history.DB
 	configHistoryRetriever *collectionConfigHistoryRetriever
+	bookkeepingProvider    *bookkeeping.Provider
 	snapshotMgr            *snapshotMgr
 	blockAPIsRWLock        *sync.RWMutex
 	stats                  *ledgerStats
+	snapshotThrottle       *snapshotThrottle
 	commitHash             []byte
 	hashProvider           ledger.HashProvider
 	config                 *ledger.Config
@@ -68,6 +73,70 @@ type kvLedger struct {
 
 	commitNotifierLock sync.Mutex
 	commitNotifier     *commitNotifier
+
+	commitListeners       commitListeners
+	pvtdataPurgeListeners commitListeners
+
+	// lastPvtdataReconciledTime is the time at which the private data of old blocks was last committed via the
+	// function CommitPvtDataOfOldBlocks and is guarded by lastPvtdataReconciledLock
+	lastPvtdataReconciledLock sync.Mutex
+	lastPvtdataReconciledTime time.Time
+
+	// channelID caches the channel ID extracted from the genesis block and is guarded by channelIDLock
+	channelIDLock sync.Mutex
+	channelID     string
+
+	// readAuthorizer, if set, is consulted for the reads made via the query executors returned by NewQueryExecutor
+	readAuthorizer func(namespace, key string) error
+
+	// historyDBCommitsPaused is set when the history DB is rolled back while the ledger is open.
+	// The history DB then stays at its savepoint until it is caught up with the block store.
+	// It is guarded by blockAPIsRWLock.
+	historyDBCommitsPaused bool
+	// deferHistoryRebuild is set when the ledger is opened with the option `DeferHistoryRebuild` and
+	// historyRebuild tracks the background rebuild of the history DB, if one is needed
+	deferHistoryRebuild bool
+	historyRebuild      *historyRebuild
+	// rebuildCheckpointer checkpoints the progress of replaying the blocks while the ledger is being opened
+	rebuildCheckpointer *rebuildCheckpointer
+	// rawImportMarker marks the ledger once a block is appended via AppendBlockRaw
+	rawImportMarker *rawImportMarker
+	// historyCatchUpLock serializes the invocations of the function CatchUpHistoryDB
+	historyCatchUpLock sync.Mutex
+	// autoCompaction, if set, compacts the state database and the history database at a configured interval
+	autoCompaction *autoCompaction
+	// pvtdataAutoCompaction, if set, compacts the pvtdata store at a configured interval
+	pvtdataAutoCompaction *autoCompaction
+	// snapshotScheduler, if set, requests a snapshot at a configured interval
+	snapshotScheduler *snapshotScheduler
+	// historyCommitPipeline, if set, commits the blocks to the history database in the background
+	historyCommitPipeline *historyCommitPipeline
+
+	// commitErr, when set, is returned for the subsequent commits. It is set to a CommitTimeoutError while the
+	// writes to the state database and the history database of a timed out commit are pending in the background
+	// and is cleared once the writes complete. If the pending writes fail, it is set to the error of the writes
+	// and the ledger stays failed until it is reopened. It is guarded by commitErrLock
+	commitErr     error
+	commitErrLock sync.Mutex
+	// pendingDBCommits tracks the writes to the state database and the history database that are left running
+	// in the background on a commit timeout and delayedCommits tracks the completion of such commits, including
+	// the notifications to the listeners
+	pendingDBCommits sync.WaitGroup
+	delayedCommits   sync.WaitGroup
+
+	// freezeLock is held in read mode by the operations that are not permitted on a frozen ledger
+	// and is held in write mode while the ledger is frozen. freezeOpsLock serializes the freeze and
+	// unfreeze operations and frozenLock guards the flag frozen
+	freezeLock    sync.RWMutex
+	freezeOpsLock sync.Mutex
+	frozenLock    sync.Mutex
+	frozen        bool
+
+	closeOnce sync.Once
+	// closed is set to 1 by Close. The ledger is also treated as closed once providerClosed returns true,
+	// as the databases of the ledger are shared across the ledgers and closed along with the provider
+	closed         uint32
+	providerClosed func() bool
 }
 
 type lgrInitializer struct {
@@ -84,9 +153,16 @@ type lgrInitializer struct {
 	ccInfoProvider           ledger.DeployedChaincodeInfoProvider
 	ccLifecycleEventProvider ledger.ChaincodeLifecycleEventProvider
 	stats                    *ledgerStats
+	snapshotThrottle         *snapshotThrottle
 	customTxProcessors       map[common.HeaderType]ledger.CustomTxProcessor
+	readAuthorizer           func(namespace, key string) error
 	hashProvider             ledger.HashProvider
 	config                   *ledger.Config
+	deferHistoryRebuild      bool
+	namespacesToRebuild      []string
+	rebuildCheckpointer      *rebuildCheckpointer
+	rawImportMarker          *rawImportMarker
+	providerClosed           func() bool
 }
 
 func newKVLedger(initializer *lgrInitializer) (*kvLedger, error) {
@@ -98,9 +174,17 @@ func newKVLedger(initializer *lgrInitializer) (*kvLedger, error) {
 		blockStore:           initializer.blockStore,
 		pvtdataStore:         initializer.pvtdataStore,
 		historyDB:            initializer.historyDB,
+		bookkeepingProvider:  initializer.bookkeeperProvider,
 		hashProvider:         initializer.hashProvider,
 		config:               initializer.config,
+		providerClosed:       initializer.providerClosed,
+		stats:                initializer.stats,
+		snapshotThrottle:     initializer.snapshotThrottle,
 		blockAPIsRWLock:      &sync.RWMutex{},
+		deferHistoryRebuild:  initializer.deferHistoryRebuild,
+		readAuthorizer:       initializer.readAuthorizer,
+		rebuildCheckpointer:  initializer.rebuildCheckpointer,
+		rawImportMarker:      initializer.rawImportMarker,
 	}
 
 	btlPolicy := pvtdatapolicy.ConstructBTLPolicy(&collectionInfoRetriever{ledgerID, l, initializer.ccInfoProvider})
@@ -121,10 +205,12 @@ func newKVLedger(initializer *lgrInitializer) (*kvLedger, error) {
 		DB:                  initializer.stateDB,
 		StateListeners:      initializer.stateListeners,
 		BtlPolicy:           btlPolicy,
+		PubStateBTL:         pubStateBTL(initializer.config),
 		BookkeepingProvider: initializer.bookkeeperProvider,
 		CCInfoProvider:      initializer.ccInfoProvider,
 		CustomTxProcessors:  initializer.customTxProcessors,
 		HashFunc:            rwsetHashFunc,
+		CommitHashWorkers:   commitHashWorkers(initializer.config),
 	}
 	if err := l.initTxMgr(txmgrInitializer); err != nil {
 		return nil, err
@@ -161,6 +247,10 @@ func newKVLedger(initializer *lgrInitializer) (*kvLedger, error) {
 		}
 	}
 
+	if err := l.rebuildNamespaces(initializer.namespacesToRebuild); err != nil {
+		return nil, err
+	}
+
 	// Recover both state DB and history DB if they are out of sync with block storage
 	if err := l.recoverDBs(); err != nil {
 		return nil, err
@@ -175,10 +265,43 @@ func newKVLedger(initializer *lgrInitializer) (*kvLedger, error) {
 		return nil, err
 	}
 
-	l.stats = initializer.stats
+	// the reconciliation metrics are otherwise reported only after the reconciler commits the private data
+	if _, err := l.GetPvtdataReconciliationStatus(); err != nil {
+		logger.Warnf("[%s] Failed to report the status of the private data reconciliation: %s", ledgerID, err)
+	}
+
+	if l.historyRebuild != nil {
+		go l.historyRebuild.run()
+	}
+	if l.config.StateDBConfig != nil && l.config.StateDBConfig.AutoCompactInterval > 0 {
+		l.autoCompaction = newAutoCompaction(l, l.config.StateDBConfig.AutoCompactInterval,
+			"state database and history database", l.compactStateAndHistoryDBs)
+		go l.autoCompaction.run()
+	}
+	if l.config.PrivateDataConfig != nil && l.config.PrivateDataConfig.CompactionInterval > 0 {
+		l.pvtdataAutoCompaction = newAutoCompaction(l, l.config.PrivateDataConfig.CompactionInterval,
+			"pvtdata store", l.compactPvtdataStore)
+		go l.pvtdataAutoCompaction.run()
+	}
+	if l.config.SnapshotsConfig != nil && l.config.SnapshotsConfig.AutoGenerateInterval > 0 {
+		l.snapshotScheduler = newSnapshotScheduler(l, l.config.SnapshotsConfig.AutoGenerateInterval)
+		go l.snapshotScheduler.run()
+	}
+	if l.historyDB != nil && l.config.HistoryDBConfig != nil && l.config.HistoryDBConfig.CommitPipelineDepth > 0 {
+		l.historyCommitPipeline = newHistoryCommitPipeline(ledgerID, l.historyDB, l.config.HistoryDBConfig.CommitPipelineDepth)
+		go l.historyCommitPipeline.run()
+	}
 	return l, nil
 }
 
+// pubStateBTL returns the block-to-live of the public state keys per namespace, as configured in the ledger config
+func pubStateBTL(config *ledger.Config) map[string]uint64 {
+	if config == nil || config.StateDBConfig == nil {
+		return nil
+	}
+	return config.StateDBConfig.BlockToLive
+}
+
 func (l *kvLedger) registerStateDBIndexCreatorForChaincodeLifecycleEvents(
 	stateDBIndexCreator cceventmgmt.ChaincodeLifecycleEventListener,
 	deployedChaincodesInfoExtractor ledger.DeployedChaincodeInfoProvider,
@@ -391,6 +514,15 @@ func (l *kvLedger) syncStateAndHistoryDBWithBlockstore() error {
 				)
 			}
 		}
+		if firstAvailableBlock := l.blockStore.FirstAvailableBlockNumber(); nextRequiredBlock < firstAvailableBlock {
+			return errors.Errorf(
+				"recovery for DB [%s] not possible. The blocks of ledger [%s] are pruned. First available block = [%d], DB needs block [%d] onward",
+				recoverable.Name(),
+				l.ledgerID,
+				firstAvailableBlock,
+				nextRequiredBlock,
+			)
+		}
 
 		if nextRequiredBlock > lastBlockInBlockStore+1 {
 			dbName := recoverable.Name()
@@ -399,6 +531,12 @@ func (l *kvLedger) syncStateAndHistoryDBWithBlockstore() error {
 				"The %s database can safely be dropped and will be rebuilt up to block store height upon the next peer start",
 				dbName, nextRequiredBlock, lastBlockInBlockStore+1, dbName, dbName)
 		}
+		if recoverFlag && l.deferHistoryRebuild && recoverable.Name() == l.historyDB.Name() {
+			logger.Infof("Deferring the rebuild of the history database for ledger [%s] from block [%d] onward", l.ledgerID, nextRequiredBlock)
+			l.historyDBCommitsPaused = true
+			l.historyRebuild = newHistoryRebuild(l)
+			continue
+		}
 		if recoverFlag {
 			recoverers = append(recoverers, &recoverer{nextRequiredBlock, recoverable})
 		}
@@ -472,6 +610,8 @@ func (l *kvLedger) filterYetToCommitBlocks(blocksPvtData map[uint64][]*ledger.Tx
 // state DB or history DB or both
 func (l *kvLedger) recommitLostBlocks(firstBlockNum uint64, lastBlockNum uint64, recoverables ...recoverable) error {
 	logger.Infof("Recommitting lost blocks - firstBlockNum=%d, lastBlockNum=%d, recoverables=%#v", firstBlockNum, lastBlockNum, recoverables)
+	l.stats.updateRebuildInProgress(true, lastBlockNum+1)
+	defer l.stats.updateRebuildInProgress(false, lastBlockNum+1)
 	var err error
 	var blockAndPvtdata *ledger.BlockAndPvtData
 	for blockNumber := firstBlockNum; blockNumber <= lastBlockNum; blockNumber++ {
@@ -483,6 +623,12 @@ func (l *kvLedger) recommitLostBlocks(firstBlockNum uint64, lastBlockNum uint64,
 				return err
 			}
 		}
+		l.stats.updateRebuildReplayedHeight(blockNumber + 1)
+		if isRebuildCheckpoint(blockNumber+1, lastBlockNum+1) {
+			if err := l.rebuildCheckpointer.checkpointFullRebuild(blockNumber + 1); err != nil {
+				return err
+			}
+		}
 	}
 	logger.Infof("Recommitted lost blocks - firstBlockNum=%d, lastBlockNum=%d, recoverables=%#v", firstBlockNum, lastBlockNum, recoverables)
 	return nil
@@ -490,6 +636,9 @@ func (l *kvLedger) recommitLostBlocks(firstBlockNum uint64, lastBlockNum uint64,
 
 // TxIDExists returns true if the specified txID is already present in one of the already committed blocks
 func (l *kvLedger) TxIDExists(txID string) (bool, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return false, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	return l.blockStore.TxIDExists(txID)
@@ -497,6 +646,9 @@ func (l *kvLedger) TxIDExists(txID string) (bool, error) {
 
 // GetTransactionByID retrieves a transaction by id
 func (l *kvLedger) GetTransactionByID(txID string) (*peer.ProcessedTransaction, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	tranEnv, err := l.blockStore.RetrieveTxByID(txID)
@@ -511,27 +663,229 @@ func (l *kvLedger) GetTransactionByID(txID string) (*peer.ProcessedTransaction,
 	return processedTran, nil
 }
 
+// GetTransactionsByIDs retrieves the transactions for the given ids
+func (l *kvLedger) GetTransactionsByIDs(txIDs []string) ([]*peer.ProcessedTransaction, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	tranEnvs, txVResults, err := l.blockStore.RetrieveTxsByIDs(txIDs)
+	if err != nil {
+		return nil, err
+	}
+	processedTrans := make([]*peer.ProcessedTransaction, len(tranEnvs))
+	for i, tranEnv := range tranEnvs {
+		processedTrans[i] = &peer.ProcessedTransaction{TransactionEnvelope: tranEnv, ValidationCode: int32(txVResults[i])}
+	}
+	return processedTrans, nil
+}
+
 // GetBlockchainInfo returns basic info about blockchain
 func (l *kvLedger) GetBlockchainInfo() (*common.BlockchainInfo, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	bcInfo, err := l.blockStore.GetBlockchainInfo()
 	return bcInfo, err
 }
 
+// CommitBackpressure implements the corresponding method in interface ledger.PeerLedger
+// The lock blockAPIsRWLock is not acquired, as this is expected to be invoked while a commit is in progress
+func (l *kvLedger) CommitBackpressure() float64 {
+	return l.blockStore.WriteSaturation()
+}
+
+// BlockStoreCheckpointInfo returns a point-in-time copy of the block store checkpoint
+func (l *kvLedger) BlockStoreCheckpointInfo() (*ledger.BlockStoreCheckpoint, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	i := l.blockStore.GetCheckpointInfo()
+	return &ledger.BlockStoreCheckpoint{
+		FileSuffixNum:   i.LatestFileNumber,
+		Offset:          i.LatestFileSize,
+		LastBlockNumber: i.LastPersistedBlock,
+		NoBlockFiles:    i.NoBlockFiles,
+	}, nil
+}
+
+// GetBlockchainInfoExtended returns the basic info about the blockchain along with the range of the available blocks
+func (l *kvLedger) GetBlockchainInfoExtended() (*ledger.ExtendedBlockchainInfo, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	info := &ledger.ExtendedBlockchainInfo{
+		Height:                 bcInfo.Height,
+		CurrentBlockHash:       bcInfo.CurrentBlockHash,
+		PreviousBlockHash:      bcInfo.PreviousBlockHash,
+		EarliestAvailableBlock: l.blockStore.FirstAvailableBlockNumber(),
+	}
+	if bcInfo.BootstrappingSnapshotInfo != nil {
+		info.BootstrappingSnapshotHeight = bcInfo.BootstrappingSnapshotInfo.LastBlockInSnapshot + 1
+	}
+	return info, nil
+}
+
+// PruneBlocks discards the blocks below `beforeBlock` from the block store
+func (l *kvLedger) PruneBlocks(beforeBlock uint64) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	l.blockAPIsRWLock.Lock()
+	defer l.blockAPIsRWLock.Unlock()
+	return l.blockStore.PruneBlocks(beforeBlock)
+}
+
+// applyRetentionPolicy prunes the blocks that lie outside the retention configured via
+// BlockStorageConfig.RetainBlocks, given the last committed block. The pruning never goes past the last config
+// block, nor past the lowest block that the state DB, the history DB, or the pvtdata store still needs, as these
+// may lag behind the block store, e.g., when the history DB commits are paused or pipelined. The blocks are pruned
+// only when at least one block file can be deleted. The caller is expected to hold the write lock on blockAPIsRWLock
+func (l *kvLedger) applyRetentionPolicy(lastBlock *common.Block) error {
+	if l.config.BlockStorageConfig == nil || l.config.BlockStorageConfig.RetainBlocks == 0 {
+		return nil
+	}
+	retainBlocks := l.config.BlockStorageConfig.RetainBlocks
+	if lastBlock.Header.Number < retainBlocks {
+		return nil
+	}
+	beforeBlock := lastBlock.Header.Number + 1 - retainBlocks
+	lastConfigBlock, err := protoutil.GetLastConfigIndexFromBlock(lastBlock)
+	if err != nil {
+		return errors.WithMessagef(err, "error while reading the last config block number from block [%d]", lastBlock.Header.Number)
+	}
+	if lastConfigBlock < beforeBlock {
+		beforeBlock = lastConfigBlock
+	}
+	if beforeBlock <= l.blockStore.FirstAvailableBlockNumber() {
+		return nil
+	}
+	nextBlockNeeded, err := l.lowestNextBlockOfDBs()
+	if err != nil {
+		return err
+	}
+	if nextBlockNeeded < beforeBlock {
+		beforeBlock = nextBlockNeeded
+	}
+	return l.blockStore.PruneBlockfiles(beforeBlock)
+}
+
+// lowestNextBlockOfDBs returns the lowest among the next blocks expected by the state DB, the history DB, and
+// the pvtdata store
+func (l *kvLedger) lowestNextBlockOfDBs() (uint64, error) {
+	savepoint, err := l.txmgr.GetLastSavepoint()
+	if err != nil {
+		return 0, err
+	}
+	nextBlock := uint64(0)
+	if savepoint != nil {
+		nextBlock = savepoint.BlockNum + 1
+	}
+	if l.historyDB != nil {
+		savepoint, err := l.historyDB.GetLastSavepoint()
+		if err != nil {
+			return 0, err
+		}
+		historyDBNextBlock := uint64(0)
+		if savepoint != nil {
+			historyDBNextBlock = savepoint.BlockNum + 1
+		}
+		if historyDBNextBlock < nextBlock {
+			nextBlock = historyDBNextBlock
+		}
+	}
+	pvtdataStoreHeight, err := l.pvtdataStore.LastCommittedBlockHeight()
+	if err != nil {
+		return 0, err
+	}
+	if pvtdataStoreHeight < nextBlock {
+		nextBlock = pvtdataStoreHeight
+	}
+	return nextBlock, nil
+}
+
+// ArchiveBlocks moves the block files that contain only the blocks below `beforeBlock` to the block file archive
+func (l *kvLedger) ArchiveBlocks(beforeBlock uint64) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	return l.blockStore.ArchiveBlocks(beforeBlock)
+}
+
 // GetBlockByNumber returns block at a given height
 // blockNumber of  math.MaxUint64 will return last block
 func (l *kvLedger) GetBlockByNumber(blockNumber uint64) (*common.Block, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	block, err := l.blockStore.RetrieveBlockByNumber(blockNumber)
 	return block, err
 }
 
+// ChannelID implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) ChannelID() (string, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return "", err
+	}
+	l.channelIDLock.Lock()
+	defer l.channelIDLock.Unlock()
+	if l.channelID != "" {
+		return l.channelID, nil
+	}
+	if l.bootSnapshotMetadata != nil {
+		return "", errors.Errorf("ledger [%s] was bootstrapped from a snapshot and does not contain the genesis block", l.ledgerID)
+	}
+	genesisBlock, err := l.GetBlockByNumber(0)
+	if err != nil {
+		return "", errors.WithMessagef(err, "error while retrieving the genesis block of ledger [%s]", l.ledgerID)
+	}
+	channelID, err := protoutil.GetChannelIDFromBlock(genesisBlock)
+	if err != nil {
+		return "", errors.WithMessagef(err, "error while extracting the channel ID from the genesis block of ledger [%s]", l.ledgerID)
+	}
+	l.channelID = channelID
+	return channelID, nil
+}
+
+// GetLastBlock implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) GetLastBlock() (*common.Block, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if bcInfo.Height == 0 {
+		return nil, &ledger.EmptyLedgerError{LedgerID: l.ledgerID}
+	}
+	return l.blockStore.RetrieveBlockByNumber(bcInfo.Height - 1)
+}
+
 // GetBlocksIterator returns an iterator that starts from `startBlockNumber`(inclusive).
 // The iterator is a blocking iterator i.e., it blocks till the next block gets available in the ledger
 // ResultsIterator contains type BlockHolder
 func (l *kvLedger) GetBlocksIterator(startBlockNumber uint64) (commonledger.ResultsIterator, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	blkItr, err := l.blockStore.RetrieveBlocks(startBlockNumber)
 	if err != nil {
 		return nil, err
@@ -541,6 +895,9 @@ func (l *kvLedger) GetBlocksIterator(startBlockNumber uint64) (commonledger.Resu
 
 // GetBlockByHash returns a block given it's hash
 func (l *kvLedger) GetBlockByHash(blockHash []byte) (*common.Block, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	block, err := l.blockStore.RetrieveBlockByHash(blockHash)
@@ -549,6 +906,9 @@ func (l *kvLedger) GetBlockByHash(blockHash []byte) (*common.Block, error) {
 
 // GetBlockByTxID returns a block which contains a transaction
 func (l *kvLedger) GetBlockByTxID(txID string) (*common.Block, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	block, err := l.blockStore.RetrieveBlockByTxID(txID)
@@ -557,6 +917,9 @@ func (l *kvLedger) GetBlockByTxID(txID string) (*common.Block, error) {
 
 // GetTxValidationCodeByTxID returns transaction validation code and block number in which the transaction was committed
 func (l *kvLedger) GetTxValidationCodeByTxID(txID string) (peer.TxValidationCode, uint64, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return 0, 0, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	txValidationCode, blkNum, err := l.blockStore.RetrieveTxValidationCodeByTxID(txID)
@@ -565,6 +928,13 @@ func (l *kvLedger) GetTxValidationCodeByTxID(txID string) (peer.TxValidationCode
 
 // NewTxSimulator returns new `ledger.TxSimulator`
 func (l *kvLedger) NewTxSimulator(txid string) (ledger.TxSimulator, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if err := l.acquireFreezeRLock(); err != nil {
+		return nil, err
+	}
+	defer l.freezeLock.RUnlock()
 	return l.txmgr.NewTxSimulator(txid)
 }
 
@@ -572,6 +942,12 @@ func (l *kvLedger) NewTxSimulator(txid string) (ledger.TxSimulator, error) {
 // A client can obtain more than one 'QueryExecutor's for parallel execution.
 // Any synchronization should be performed at the implementation level if required
 func (l *kvLedger) NewQueryExecutor() (ledger.QueryExecutor, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if l.readAuthorizer != nil {
+		return l.txmgr.NewQueryExecutorWithReadAuthorizer(util.GenerateUUID(), l.readAuthorizer)
+	}
 	return l.txmgr.NewQueryExecutor(util.GenerateUUID())
 }
 
@@ -580,10 +956,51 @@ func (l *kvLedger) NewQueryExecutor() (ledger.QueryExecutor, error) {
 // Any synchronization should be performed at the implementation level if required
 // Pass the ledger blockstore so that historical values can be looked up from the chain
 func (l *kvLedger) NewHistoryQueryExecutor() (ledger.HistoryQueryExecutor, error) {
-	if l.historyDB != nil {
-		return l.historyDB.NewQueryExecutor(l.blockStore)
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if l.historyDB == nil {
+		return nil, &ledger.HistoryDBNotEnabledError{LedgerID: l.ledgerID}
+	}
+	if l.historyRebuild != nil {
+		if err := l.historyRebuild.checkCompleted(); err != nil {
+			return nil, err
+		}
 	}
-	return nil, nil
+	if err := l.waitForPendingHistoryCommits(); err != nil {
+		return nil, err
+	}
+	return l.historyDB.NewQueryExecutor(l.blockStore)
+}
+
+// RollbackHistoryDB removes the history entries for the blocks above the given block number and resets
+// the savepoint of the history DB to the given block number, without touching the state DB or the block store.
+// Until the history DB is caught up with the block store, the commit of new blocks skips the history DB.
+// The catch-up happens via the function CatchUpHistoryDB or during the recovery on the next ledger open.
+func (l *kvLedger) RollbackHistoryDB(toBlock uint64) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	if l.historyDB == nil {
+		return &ledger.HistoryDBNotEnabledError{LedgerID: l.ledgerID}
+	}
+	if l.bootSnapshotMetadata != nil && toBlock < l.bootSnapshotMetadata.LastBlockNumber {
+		return errors.Errorf(
+			"cannot rollback history database of ledger [%s] to block [%d] as the ledger is created from a snapshot at block [%d]",
+			l.ledgerID, toBlock, l.bootSnapshotMetadata.LastBlockNumber,
+		)
+	}
+
+	l.blockAPIsRWLock.Lock()
+	defer l.blockAPIsRWLock.Unlock()
+	if err := l.waitForPendingHistoryCommits(); err != nil {
+		return err
+	}
+	if err := l.historyDB.Rollback(toBlock); err != nil {
+		return err
+	}
+	l.historyDBCommitsPaused = true
+	return nil
 }
 
 // CommitLegacy commits the block and the corresponding pvt data in an atomic operation.
@@ -592,6 +1009,14 @@ func (l *kvLedger) NewHistoryQueryExecutor() (ledger.HistoryQueryExecutor, error
 // After the block is committed, it sends a commitDone event.
 // Refer to processEvents function to understand how the channels and events work together to handle synchronization.
 func (l *kvLedger) CommitLegacy(pvtdataAndBlock *ledger.BlockAndPvtData, commitOpts *ledger.CommitOptions) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	if err := l.acquireFreezeRLock(); err != nil {
+		return err
+	}
+	defer l.freezeLock.RUnlock()
+
 	blockNumber := pvtdataAndBlock.Block.Header.Number
 	l.snapshotMgr.events <- &event{commitStart, blockNumber}
 	<-l.snapshotMgr.commitProceed
@@ -604,12 +1029,79 @@ func (l *kvLedger) CommitLegacy(pvtdataAndBlock *ledger.BlockAndPvtData, commitO
 	return nil
 }
 
+// PreviewCommit implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) PreviewCommit(blockAndPvtdata *ledger.BlockAndPvtData) (*ledger.StateDelta, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if err := l.verifyNextBlock(blockAndPvtdata.Block); err != nil {
+		return nil, err
+	}
+	return l.txmgr.PreviewStateDelta(blockAndPvtdata)
+}
+
+// AppendBlockRaw implements the corresponding method from interface ledger.PeerLedger
+// Like CommitLegacy, it synchronizes with the snapshot generation via commitStart and commitDone events
+func (l *kvLedger) AppendBlockRaw(block *common.Block) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	if err := l.acquireFreezeRLock(); err != nil {
+		return err
+	}
+	defer l.freezeLock.RUnlock()
+
+	// verify upfront so that a block out of sequence is rejected before it is announced to the snapshot manager
+	if err := l.verifyNextBlock(block); err != nil {
+		return err
+	}
+
+	blockNumber := block.Header.Number
+	l.snapshotMgr.events <- &event{commitStart, blockNumber}
+	<-l.snapshotMgr.commitProceed
+
+	if err := l.appendBlockRaw(block); err != nil {
+		return err
+	}
+
+	l.snapshotMgr.events <- &event{commitDone, blockNumber}
+	return nil
+}
+
+func (l *kvLedger) appendBlockRaw(block *common.Block) error {
+	if err := l.getCommitErr(); err != nil {
+		return err
+	}
+	l.blockAPIsRWLock.Lock()
+	defer l.blockAPIsRWLock.Unlock()
+	if err := l.rawImportMarker.mark(); err != nil {
+		return err
+	}
+	if err := l.commitToPvtAndBlockStore(&ledger.BlockAndPvtData{Block: block}, nil); err != nil {
+		return err
+	}
+	logger.Infof("[%s] Appended block [%d] with %d transaction(s) without updating the state database", l.ledgerID, block.Header.Number, len(block.Data.Data))
+	return nil
+}
+
 // commit commits the block and the corresponding pvt data in an atomic operation.
 func (l *kvLedger) commit(pvtdataAndBlock *ledger.BlockAndPvtData, commitOpts *ledger.CommitOptions) error {
 	var err error
 	block := pvtdataAndBlock.Block
 	blockNo := pvtdataAndBlock.Block.Header.Number
 
+	if err := l.getCommitErr(); err != nil {
+		return err
+	}
+	if l.historyCommitPipeline != nil {
+		if err := l.historyCommitPipeline.getErr(); err != nil {
+			return err
+		}
+	}
+	if err := l.checkNotRawImport(fmt.Sprintf("commit of block [%d]", blockNo)); err != nil {
+		return err
+	}
+
 	startBlockProcessing := time.Now()
 	if commitOpts.FetchPvtDataFromLedger {
 		// when we reach here, it means that the pvtdata store has the
@@ -631,8 +1123,17 @@ func (l *kvLedger) commit(pvtdataAndBlock *ledger.BlockAndPvtData, commitOpts *l
 		pvtdataAndBlock.PvtData = convertTxPvtDataArrayToMap(txPvtData)
 	}
 
+	doMVCCValidation := true
+	if commitOpts.SkipValidation {
+		if err := l.verifyNextBlock(block); err != nil {
+			return err
+		}
+		logger.Debugf("[%s] Skipping validation for block [%d], trusting the supplied transaction validation codes", l.ledgerID, blockNo)
+		doMVCCValidation = false
+	}
+
 	logger.Debugf("[%s] Validating state for block [%d]", l.ledgerID, blockNo)
-	appInitiatedPurgeUpdates, txstatsInfo, updateBatchBytes, err := l.txmgr.ValidateAndPrepare(pvtdataAndBlock, true)
+	appInitiatedPurgeUpdates, txstatsInfo, updateBatchBytes, err := l.txmgr.ValidateAndPrepare(pvtdataAndBlock, doMVCCValidation)
 	if err != nil {
 		return err
 	}
@@ -643,8 +1144,13 @@ func (l *kvLedger) commit(pvtdataAndBlock *ledger.BlockAndPvtData, commitOpts *l
 	// we need to ensure that only after a genesis block, commitHash is computed
 	// and added to the block. In other words, only after joining a new channel
 	// or peer reset, the commitHash would be added to the block
+	var commitHashResult <-chan *commitHashResult
 	if block.Header.Number == 1 || len(l.commitHash) != 0 {
-		l.addBlockCommitHash(pvtdataAndBlock.Block, updateBatchBytes)
+		if l.txmgr.UsesCommitHashWorkers() {
+			commitHashResult = l.computeBlockCommitHashAsync(pvtdataAndBlock.Block)
+		} else {
+			l.addBlockCommitHash(pvtdataAndBlock.Block, updateBatchBytes)
+		}
 	}
 
 	logger.Debugf("[%s] Committing pvtdata and block [%d] to storage", l.ledgerID, blockNo)
@@ -662,14 +1168,17 @@ func (l *kvLedger) commit(pvtdataAndBlock *ledger.BlockAndPvtData, commitOpts *l
 			},
 		)
 	}
-	if err = l.commitToPvtAndBlockStore(pvtdataAndBlock, purgeMarkers); err != nil {
+	blkStoreCheckpointBeforeCommit := l.blockStore.GetCheckpointInfo()
+	if err = l.commitToPvtAndBlockStoreAfterCommitHash(pvtdataAndBlock, purgeMarkers, commitHashResult); err != nil {
 		return err
 	}
 	elapsedBlockstorageAndPvtdataCommit := time.Since(startBlockstorageAndPvtdataCommit)
+	blockSize := serializedBlockSize(blkStoreCheckpointBeforeCommit, l.blockStore.GetCheckpointInfo())
 
 	startCommitState := time.Now()
 
 	pvtKeysToDelete := map[privacyenabledstate.PvtdataCompositeKey]*version.Height{}
+	purgedPvtKeys := map[privacyenabledstate.HashedCompositeKey]string{}
 	for _, u := range appInitiatedPurgeUpdates {
 		if !u.DeletePrivateKeyFromState {
 			continue
@@ -688,27 +1197,101 @@ func (l *kvLedger) commit(pvtdataAndBlock *ledger.BlockAndPvtData, commitOpts *l
 			CollectionName: u.CompositeKey.CollectionName,
 			Key:            pvtKey,
 		}] = u.Version
+		purgedPvtKeys[*u.CompositeKey] = pvtKey
 	}
 	l.txmgr.UpdateBatchWithAppInitiatedPvtKeysToPurge(pvtKeysToDelete)
-	logger.Debugf("[%s] Committing block [%d] transactions to state database", l.ledgerID, blockNo)
-	if err = l.txmgr.Commit(); err != nil {
-		panic(errors.WithMessage(err, "error during commit to txmgr"))
-	}
-	elapsedCommitState := time.Since(startCommitState)
+	pvtdataPurgeEvent := newPvtdataPurgeEvent(blockNo, appInitiatedPurgeUpdates, purgedPvtKeys)
+
+	// The history database is written in parallel with the state database, as the two are independent
+	// of each other. Both the commits are joined before proceeding and a failure in either of them causes
+	// a panic, so that the lagging database is caught up with the block store on the next ledger open.
+	// The elapsed duration of the history commit is not logged, as it overlaps with the state commit.
+	// With the history commit pipeline, the block is only submitted to the pipeline and is not joined; a failure
+	// of the history commit in the pipeline is returned by the subsequent commits and history queries instead.
+	var stateDBCommitErr, historyDBCommitErr error
+	var elapsedCommitState time.Duration
+	dbCommitsDone := make(chan struct{})
+	go func() {
+		defer close(dbCommitsDone)
+		historyDBCommitDone := &sync.WaitGroup{}
+		switch {
+		case l.historyDB == nil || l.historyDBCommitsPaused:
+		case l.historyCommitPipeline != nil:
+			l.historyCommitPipeline.submit(block)
+		default:
+			historyDBCommitDone.Add(1)
+			go func() {
+				defer historyDBCommitDone.Done()
+				logger.Debugf("[%s] Committing block [%d] transactions to history database", l.ledgerID, blockNo)
+				historyDBCommitErr = l.historyDB.Commit(block)
+			}()
+		}
 
-	// History database could be written in parallel with state and/or async as a future optimization,
-	// although it has not been a bottleneck...no need to clutter the log with elapsed duration.
-	if l.historyDB != nil {
-		logger.Debugf("[%s] Committing block [%d] transactions to history database", l.ledgerID, blockNo)
-		if err := l.historyDB.Commit(block); err != nil {
-			panic(errors.WithMessage(err, "Error during commit to history db"))
+		logger.Debugf("[%s] Committing block [%d] transactions to state database", l.ledgerID, blockNo)
+		stateDBCommitErr = l.txmgr.Commit()
+		elapsedCommitState = time.Since(startCommitState)
+		historyDBCommitDone.Wait()
+	}()
+	dbCommitErr := func() error {
+		if stateDBCommitErr != nil {
+			return errors.WithMessage(stateDBCommitErr, "error during commit to txmgr")
+		}
+		if historyDBCommitErr != nil {
+			return errors.WithMessage(historyDBCommitErr, "Error during commit to history db")
 		}
+		return nil
+	}
+	postCommit := func() {
+		l.completeCommit(block, txstatsInfo, pvtdataPurgeEvent, startBlockProcessing, blockSize,
+			elapsedBlockProcessing, elapsedBlockstorageAndPvtdataCommit, elapsedCommitState)
+	}
+	if err := l.waitForDBCommits(blockNo, dbCommitsDone); err != nil {
+		l.pendingDBCommits.Add(1)
+		l.delayedCommits.Add(1)
+		go func() {
+			defer l.delayedCommits.Done()
+			<-dbCommitsDone
+			l.pendingDBCommits.Done()
+			if err := dbCommitErr(); err != nil {
+				logger.Errorf("[%s] Delayed commit of block [%d] to the state and history databases failed, further commits are rejected until the ledger is reopened: %s",
+					l.ledgerID, blockNo, err)
+				l.setCommitErr(errors.WithMessagef(err, "delayed commit of block [%d] to the databases of ledger [%s] failed", blockNo, l.ledgerID))
+				return
+			}
+			logger.Infof("[%s] Delayed commit of block [%d] to the state and history databases completed", l.ledgerID, blockNo)
+			l.blockAPIsRWLock.Lock()
+			l.applyRetentionPolicyOrWarn(block)
+			l.blockAPIsRWLock.Unlock()
+			postCommit()
+			l.snapshotMgr.events <- &event{commitDone, blockNo}
+			l.setCommitErr(nil)
+		}()
+		return err
+	}
+	if err := dbCommitErr(); err != nil {
+		panic(err)
 	}
+	l.applyRetentionPolicyOrWarn(block)
+	postCommit()
+	return nil
+}
 
+// completeCommit logs the commit of the block, updates the stats, and notifies the listeners, once the block is
+// committed to the block store, the pvtdata store, and the databases
+func (l *kvLedger) completeCommit(
+	block *common.Block,
+	txstatsInfo []*validation.TxStatInfo,
+	pvtdataPurgeEvent *ledger.PvtdataPurgeEvent,
+	startBlockProcessing time.Time,
+	blockSize int,
+	elapsedBlockProcessing, elapsedBlockstorageAndPvtdataCommit, elapsedCommitState time.Duration,
+) {
+	blockNo := block.Header.Number
+	elapsedCommit := time.Since(startBlockProcessing)
 	logger.Infof("[%s] Committed block [%d] with %d transaction(s) in %dms (state_validation=%dms block_and_pvtdata_commit=%dms state_commit=%dms)"+
 		" commitHash=[%x]",
 		l.ledgerID, block.Header.Number, len(block.Data.Data),
-		time.Since(startBlockProcessing)/time.Millisecond,
+		elapsedCommit/time.Millisecond,
 		elapsedBlockProcessing/time.Millisecond,
 		elapsedBlockstorageAndPvtdataCommit/time.Millisecond,
 		elapsedCommitState/time.Millisecond,
@@ -721,14 +1304,103 @@ func (l *kvLedger) commit(pvtdataAndBlock *ledger.BlockAndPvtData, commitOpts *l
 		elapsedCommitState,
 		txstatsInfo,
 	)
+	l.stats.updateCommitDuration(elapsedCommit)
+	l.stats.updateBlockSize(blockSize)
 
 	l.sendCommitNotification(blockNo, txstatsInfo)
+	l.commitListeners.notify(blockNo, block)
+	if pvtdataPurgeEvent != nil {
+		l.pvtdataPurgeListeners.notify(blockNo, pvtdataPurgeEvent)
+	}
+}
+
+// applyRetentionPolicyOrWarn applies the retention policy after the commit of the given block. The block is
+// committed at this point and a failure in pruning the old blocks is not fatal, as the pruning is attempted
+// again with the next block. The caller is expected to hold the write lock on blockAPIsRWLock
+func (l *kvLedger) applyRetentionPolicyOrWarn(block *common.Block) {
+	if err := l.applyRetentionPolicy(block); err != nil {
+		logger.Warningf("[%s] Error while pruning the blocks as per the retention policy: %s", l.ledgerID, err)
+	}
+}
+
+func (l *kvLedger) getCommitErr() error {
+	l.commitErrLock.Lock()
+	defer l.commitErrLock.Unlock()
+	return l.commitErr
+}
+
+func (l *kvLedger) setCommitErr(err error) {
+	l.commitErrLock.Lock()
+	defer l.commitErrLock.Unlock()
+	l.commitErr = err
+}
+
+// waitForDBCommits waits for the commits to the state database and the history database to finish, for up to the
+// duration configured via StateDBConfig.WriteTimeout. On a timeout, the commits are left running in the background, as
+// a write to leveldb cannot be aborted midway, and the ledger rejects further commits until the writes complete. At that
+// point, the block is persisted in the block store and the pvtdata store but its application to the databases is
+// pending. The caller completes the commit in the background once the writes finish or, if the writes fail, marks the
+// ledger failed. As each of the databases writes its updates along with its save point in a single batch, the save
+// points stay behind the block store until the stalled writes complete and, in any case, the databases are caught up
+// with the block store on the next ledger open. The caller is expected to hold the write lock on blockAPIsRWLock
+func (l *kvLedger) waitForDBCommits(blockNum uint64, done <-chan struct{}) error {
+	if l.config.StateDBConfig == nil || l.config.StateDBConfig.WriteTimeout <= 0 {
+		<-done
+		return nil
+	}
+	timeout := l.config.StateDBConfig.WriteTimeout
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		logger.Errorf("[%s] Commit of block [%d] to the state and history databases did not complete within %s, further commits are rejected until the writes complete",
+			l.ledgerID, blockNum, timeout)
+		commitTimeoutErr := &ledger.CommitTimeoutError{
+			LedgerID: l.ledgerID,
+			BlockNum: blockNum,
+			Timeout:  timeout,
+		}
+		l.setCommitErr(commitTimeoutErr)
+		return commitTimeoutErr
+	}
+}
+
+// verifyNextBlock verifies that the given block is the next block in the chain, both in terms of the
+// block number and the previous block hash. When the validation of a block is skipped, this check is
+// performed upfront so that a block out of sequence does not get processed by the state database
+func (l *kvLedger) verifyNextBlock(block *common.Block) error {
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if block.Header.Number != bcInfo.Height {
+		return errors.Errorf("block number should have been %d but was %d", bcInfo.Height, block.Header.Number)
+	}
+	if !bytes.Equal(block.Header.PreviousHash, bcInfo.CurrentBlockHash) {
+		return errors.Errorf(
+			"unexpected Previous block hash. Expected PreviousHash = [%x], PreviousHash referred in the latest block= [%x]",
+			bcInfo.CurrentBlockHash, block.Header.PreviousHash,
+		)
+	}
 	return nil
 }
 
 func (l *kvLedger) commitToPvtAndBlockStore(
 	blockAndPvtdata *ledger.BlockAndPvtData,
 	appInitiatedPurgeMarkers []*pvtdatastorage.PurgeMarker,
+) error {
+	return l.commitToPvtAndBlockStoreAfterCommitHash(blockAndPvtdata, appInitiatedPurgeMarkers, nil)
+}
+
+// commitToPvtAndBlockStoreAfterCommitHash commits the block and the pvt data as commitToPvtAndBlockStore does.
+// If the commit hash of the block is being computed in the background, the block is added to the block store
+// after the commit hash is added to the block
+func (l *kvLedger) commitToPvtAndBlockStoreAfterCommitHash(
+	blockAndPvtdata *ledger.BlockAndPvtData,
+	appInitiatedPurgeMarkers []*pvtdatastorage.PurgeMarker,
+	commitHashResult <-chan *commitHashResult,
 ) error {
 	pvtdataStoreHt, err := l.pvtdataStore.LastCommittedBlockHeight()
 	if err != nil {
@@ -755,6 +1427,11 @@ func (l *kvLedger) commitToPvtAndBlockStore(
 		logger.Debugf("Skipping writing pvtData to pvt block store as it ahead of the block store")
 	}
 
+	if commitHashResult != nil {
+		if err := l.publishBlockCommitHash(blockAndPvtdata.Block, commitHashResult); err != nil {
+			return err
+		}
+	}
 	if err := l.blockStore.AddBlock(blockAndPvtdata.Block); err != nil {
 		return err
 	}
@@ -770,6 +1447,16 @@ func (l *kvLedger) commitToPvtAndBlockStore(
 	return nil
 }
 
+// serializedBlockSize returns the number of bytes appended to the block files for a block, given the
+// checkpoints of the block store before and after adding the block. The block store moves to a new
+// file if the block does not fit in the current file, in which case the new file contains only this block
+func serializedBlockSize(before, after *blkstorage.CheckpointInfo) int {
+	if after.LatestFileNumber != before.LatestFileNumber {
+		return after.LatestFileSize
+	}
+	return after.LatestFileSize - before.LatestFileSize
+}
+
 func convertTxPvtDataArrayToMap(txPvtData []*ledger.TxPvtData) ledger.TxPvtDataMap {
 	txPvtDataMap := make(ledger.TxPvtDataMap)
 	for _, pvtData := range txPvtData {
@@ -793,6 +1480,9 @@ func (l *kvLedger) updateBlockStats(
 // GetMissingPvtDataInfoForMostRecentBlocks returns the missing private data information for the
 // most recent `maxBlock` blocks which miss at least a private data of a eligible collection.
 func (l *kvLedger) GetMissingPvtDataInfoForMostRecentBlocks(maxBlock int) (ledger.MissingPvtDataInfo, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	// the missing pvtData info in the pvtdataStore could belong to a block which is yet
 	// to be processed and committed to the blockStore and stateDB (such a scenario is possible
 	// after a peer rollback). In such cases, we cannot return missing pvtData info. Otherwise,
@@ -804,7 +1494,24 @@ func (l *kvLedger) GetMissingPvtDataInfoForMostRecentBlocks(maxBlock int) (ledge
 	// lastCommittedBlock can change due to a new block commit. As a result, we may not
 	// be able to fetch the missing data info of truly the most recent blocks. This
 	// decision was made to ensure that the regular block commit rate is not affected.
-	return l.pvtdataStore.GetMissingPvtDataInfoForMostRecentBlocks(maxBlock)
+	missingPvtDataInfo, err := l.pvtdataStore.GetMissingPvtDataInfoForMostRecentBlocks(maxBlock)
+	if err != nil {
+		return nil, err
+	}
+	// the missing pvtData of the pruned blocks cannot be verified against the blocks and hence, is not
+	// reconciled. The blocks that are covered by the bootstrapping snapshot are retained, as their
+	// pvtData is verified via the boot KV hashes
+	lastBlockInBootstrapSnapshot := uint64(0)
+	if l.bootSnapshotMetadata != nil {
+		lastBlockInBootstrapSnapshot = l.bootSnapshotMetadata.LastBlockNumber
+	}
+	firstAvailableBlock := l.blockStore.FirstAvailableBlockNumber()
+	for blkNum := range missingPvtDataInfo {
+		if blkNum > lastBlockInBootstrapSnapshot && blkNum < firstAvailableBlock {
+			delete(missingPvtDataInfo, blkNum)
+		}
+	}
+	return missingPvtDataInfo, nil
 }
 
 func (l *kvLedger) addBlockCommitHash(block *common.Block, updateBatchBytes []byte) {
@@ -820,9 +1527,64 @@ func (l *kvLedger) addBlockCommitHash(block *common.Block, updateBatchBytes []by
 	block.Metadata.Metadata[common.BlockMetadataIndex_COMMIT_HASH] = protoutil.MarshalOrPanic(&common.Metadata{Value: l.commitHash})
 }
 
+type commitHashResult struct {
+	commitHash []byte
+	err        error
+}
+
+// computeBlockCommitHashAsync computes, in the background, the commit hash of the block prepared by the last
+// validation, with the same input as addBlockCommitHash, where the bytes of the update batch are written to the hash
+// by the commit hash workers of the transaction manager. The computation overlaps only with the commit of the same
+// block to the private data store; it is joined via publishBlockCommitHash before the block is added to the block
+// store, and so before the next block is validated, as the commit hash of the next block chains this one
+func (l *kvLedger) computeBlockCommitHashAsync(block *common.Block) <-chan *commitHashResult {
+	txValidationCode := block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	writeUpdateBatchBytes := l.txmgr.UpdateBatchBytesWriter()
+	previousCommitHash := l.commitHash
+	result := make(chan *commitHashResult, 1)
+	go func() {
+		h, err := l.hashProvider.GetHash(commitHashOpts)
+		if err != nil {
+			result <- &commitHashResult{err: err}
+			return
+		}
+		h.Write(proto.EncodeVarint(uint64(len(txValidationCode))))
+		h.Write(txValidationCode)
+		if err := writeUpdateBatchBytes(h); err != nil {
+			result <- &commitHashResult{err: err}
+			return
+		}
+		h.Write(previousCommitHash)
+		result <- &commitHashResult{commitHash: h.Sum(nil)}
+	}()
+	return result
+}
+
+// publishBlockCommitHash waits for the commit hash computed via computeBlockCommitHashAsync and adds it to the block.
+// This is invoked in the commit of the same block, so the computation never runs concurrently with another block
+func (l *kvLedger) publishBlockCommitHash(block *common.Block, result <-chan *commitHashResult) error {
+	r := <-result
+	if r.err != nil {
+		return errors.WithMessagef(r.err, "error while computing the commit hash of block [%d]", block.Header.Number)
+	}
+	l.commitHash = r.commitHash
+	block.Metadata.Metadata[common.BlockMetadataIndex_COMMIT_HASH] = protoutil.MarshalOrPanic(&common.Metadata{Value: l.commitHash})
+	return nil
+}
+
+func commitHashWorkers(config *ledger.Config) int {
+	if config == nil || config.StateDBConfig == nil {
+		return 0
+	}
+	return config.StateDBConfig.CommitHashWorkers
+}
+
 // GetPvtDataAndBlockByNum returns the block and the corresponding pvt data.
 // The pvt data is filtered by the list of 'collections' supplied
 func (l *kvLedger) GetPvtDataAndBlockByNum(blockNum uint64, filter ledger.PvtNsCollFilter) (*ledger.BlockAndPvtData, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 
@@ -844,6 +1606,9 @@ func (l *kvLedger) GetPvtDataAndBlockByNum(blockNum uint64, filter ledger.PvtNsC
 // GetPvtDataByNum returns only the pvt data  corresponding to the given block number
 // The pvt data is filtered by the list of 'collections' supplied
 func (l *kvLedger) GetPvtDataByNum(blockNum uint64, filter ledger.PvtNsCollFilter) ([]*ledger.TxPvtData, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.blockAPIsRWLock.RLock()
 	defer l.blockAPIsRWLock.RUnlock()
 	var pvtdata []*ledger.TxPvtData
@@ -864,6 +1629,9 @@ func (l *kvLedger) GetPvtDataByNum(blockNum uint64, filter ledger.PvtNsCollFilte
 //
 //	transaction with pvtData.
 func (l *kvLedger) DoesPvtDataInfoExist(blockNum uint64) (bool, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return false, err
+	}
 	pvtStoreHt, err := l.pvtdataStore.LastCommittedBlockHeight()
 	if err != nil {
 		return false, err
@@ -872,10 +1640,47 @@ func (l *kvLedger) DoesPvtDataInfoExist(blockNum uint64) (bool, error) {
 }
 
 func (l *kvLedger) GetConfigHistoryRetriever() (ledger.ConfigHistoryRetriever, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	return l.configHistoryRetriever, nil
 }
 
+// CollectionConfigAt returns the collection config package of the given namespace as it was in effect at the given
+// block number, i.e., the config committed by the most recent block at or below `blockNum`
+func (l *kvLedger) CollectionConfigAt(namespace string, blockNum uint64) (*peer.CollectionConfigPackage, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	bcInfo, err := l.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if blockNum >= bcInfo.Height {
+		return nil, errors.Errorf("requested block number [%d] is beyond the last committed block [%d]", blockNum, bcInfo.Height-1)
+	}
+	configInfo, err := l.configHistoryRetriever.MostRecentCollectionConfigBelow(blockNum+1, namespace)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while retrieving collection config for namespace [%s] at block [%d]", namespace, blockNum)
+	}
+	if configInfo == nil {
+		return nil, errors.Errorf("no collection config defined for namespace [%s] at block [%d]", namespace, blockNum)
+	}
+	return configInfo.CollectionConfig, nil
+}
+
 func (l *kvLedger) CommitPvtDataOfOldBlocks(reconciledPvtdata []*ledger.ReconciledPvtdata, unreconciled ledger.MissingPvtDataInfo) ([]*ledger.PvtdataHashMismatch, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if err := l.acquireFreezeRLock(); err != nil {
+		return nil, err
+	}
+	defer l.freezeLock.RUnlock()
+	if err := l.checkNotRawImport("commit of the pvtdata of old blocks"); err != nil {
+		return nil, err
+	}
+
 	logger.Debugf("[%s:] Comparing pvtData of [%d] old blocks against the hashes in transaction's rwset to find valid and invalid data",
 		l.ledgerID, len(reconciledPvtdata))
 
@@ -903,6 +1708,14 @@ func (l *kvLedger) CommitPvtDataOfOldBlocks(reconciledPvtdata []*ledger.Reconcil
 		return nil, err
 	}
 
+	if len(hashVerifiedPvtData) > 0 {
+		l.lastPvtdataReconciledLock.Lock()
+		l.lastPvtdataReconciledTime = time.Now()
+		l.lastPvtdataReconciledLock.Unlock()
+	}
+	if _, err := l.GetPvtdataReconciliationStatus(); err != nil {
+		logger.Warnf("[%s] Failed to report the status of the private data reconciliation: %s", l.ledgerID, err)
+	}
 	return hashMismatches, nil
 }
 
@@ -932,9 +1745,112 @@ func (l *kvLedger) applyValidTxPvtDataOfOldBlocks(hashVerifiedPvtData map[uint64
 }
 
 func (l *kvLedger) GetMissingPvtDataTracker() (ledger.MissingPvtDataTracker, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	return l, nil
 }
 
+// MissingPvtDataInfo implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) MissingPvtDataInfo(maxBlock uint64) (ledger.MissingPvtDataInfo, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	// as in GetMissingPvtDataInfoForMostRecentBlocks, the missing pvtData info of the blocks
+	// that are yet to be committed to the blockStore is not returned
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if bcInfo.Height == 0 {
+		return ledger.MissingPvtDataInfo{}, nil
+	}
+	if maxBlock > bcInfo.Height-1 {
+		maxBlock = bcInfo.Height - 1
+	}
+	return l.pvtdataStore.GetMissingPvtDataInfo(maxBlock)
+}
+
+// GetPvtdataReconciliationStatus implements the corresponding method from interface ledger.PeerLedger.
+// The reconciliation metrics of the ledger are updated with the returned status
+func (l *kvLedger) GetPvtdataReconciliationStatus() (*ledger.PvtdataReconciliationStatus, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.lastPvtdataReconciledLock.Lock()
+	status := &ledger.PvtdataReconciliationStatus{
+		LastReconciledTime: l.lastPvtdataReconciledTime,
+	}
+	l.lastPvtdataReconciledLock.Unlock()
+
+	// as in MissingPvtDataInfo, the missing pvtData of the blocks that are yet
+	// to be committed to the blockStore is not counted
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if bcInfo.Height > 0 {
+		status.MissingPvtdataCount, status.OldestMissingBlock, err = l.pvtdataStore.GetMissingPvtDataSummary(bcInfo.Height - 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	l.stats.updatePvtdataReconciliationStatus(status)
+	return status, nil
+}
+
+// CompactPvtdataStore implements the corresponding method from interface ledger.PeerLedger.
+// The compaction metrics of the ledger are updated with the reclaimed bytes
+func (l *kvLedger) CompactPvtdataStore() (int64, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return 0, err
+	}
+	startTime := time.Now()
+	reclaimedBytes, err := l.pvtdataStore.Compact()
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed to compact the pvtdata store")
+	}
+	l.stats.updatePvtdataCompaction(reclaimedBytes)
+	logger.Infof("[%s] Compacted the pvtdata store in %dms, reclaimed approximately %d bytes",
+		l.ledgerID, time.Since(startTime).Milliseconds(), reclaimedBytes)
+	return reclaimedBytes, nil
+}
+
+// GetPvtdataScheduledForPurge implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) GetPvtdataScheduledForPurge(numBlocks uint64) ([]*ledger.ScheduledPvtdataPurge, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if numBlocks == 0 {
+		return nil, nil
+	}
+	// the next block is determined from the savepoint of the state, as the expiry is applied to the state
+	savepoint, err := l.txmgr.GetLastSavepoint()
+	if err != nil {
+		return nil, err
+	}
+	startBlk := uint64(0)
+	if savepoint != nil {
+		startBlk = savepoint.BlockNum + 1
+	}
+	endBlk := startBlk + numBlocks - 1
+	if endBlk < startBlk {
+		endBlk = math.MaxUint64
+	}
+	return l.txmgr.ScheduledPvtdataPurges(startBlk, endBlk)
+}
+
+// UpdatePvtDataConfig implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) UpdatePvtDataConfig(cfg *ledger.PrivateDataConfig) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
+	if cfg == nil {
+		return errors.New("private data config must not be nil")
+	}
+	return l.pvtdataStore.UpdateConfig(cfg)
+}
+
 type commitNotifier struct {
 	dataChannel chan *ledger.CommitNotification
 	doneChannel <-chan struct{}
@@ -947,6 +1863,9 @@ type commitNotifier struct {
 // CommitNotifications channel to close. There is expected to be only one consumer at a time. The function returns error
 // if already a CommitNotification channel is active.
 func (l *kvLedger) CommitNotificationsChannel(done <-chan struct{}) (<-chan *ledger.CommitNotification, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	l.commitNotifierLock.Lock()
 	defer l.commitNotifierLock.Unlock()
 
@@ -1005,11 +1924,42 @@ func (l *kvLedger) sendCommitNotification(blockNum uint64, txStatsInfo []*valida
 // Close closes `KVLedger`.
 // Currently this function is only used by test code. The caller should make sure no in-progress commit
 // or snapshot generation before calling this function. Otherwise, the ledger may have unknown behavior
-// and cause panic.
+// and cause panic. Closing an already closed ledger is a no-op.
 func (l *kvLedger) Close() {
-	l.blockStore.Shutdown()
-	l.txmgr.Shutdown()
-	l.snapshotMgr.shutdown()
+	l.closeOnce.Do(func() {
+		l.delayedCommits.Wait()
+		if l.historyRebuild != nil {
+			l.historyRebuild.stop()
+		}
+		if l.autoCompaction != nil {
+			l.autoCompaction.stop()
+		}
+		if l.pvtdataAutoCompaction != nil {
+			l.pvtdataAutoCompaction.stop()
+		}
+		if l.snapshotScheduler != nil {
+			l.snapshotScheduler.stop()
+		}
+		if l.historyCommitPipeline != nil {
+			l.historyCommitPipeline.stop()
+		}
+		l.commitListeners.cancelAll()
+		l.pvtdataPurgeListeners.cancelAll()
+		l.blockStore.Shutdown()
+		l.txmgr.Shutdown()
+		l.snapshotMgr.shutdown()
+		atomic.StoreUint32(&l.closed, 1)
+	})
+}
+
+// checkNotClosed returns a LedgerClosedError if the ledger or its provider is closed. This is a best effort check
+// that is made at the start of an operation, so that the operations invoked after the close fail with a consistent
+// error instead of an error from the closed databases
+func (l *kvLedger) checkNotClosed() error {
+	if atomic.LoadUint32(&l.closed) == 1 || (l.providerClosed != nil && l.providerClosed()) {
+		return &ledger.LedgerClosedError{LedgerID: l.ledgerID}
+	}
+	return nil
 }
 
 type blocksItr struct {
@@ -1033,12 +1983,13 @@ func (itr *blocksItr) Close() {
 
 type collectionInfoRetriever struct {
 	ledgerID     string
-	ledger       ledger.PeerLedger
+	ledger       *kvLedger
 	infoProvider ledger.DeployedChaincodeInfoProvider
 }
 
 func (r *collectionInfoRetriever) CollectionInfo(chaincodeName, collectionName string) (*peer.StaticCollectionConfig, error) {
-	qe, err := r.ledger.NewQueryExecutor()
+	// the query executor is obtained from txmgr directly, so that the ledger internal reads are not subject to the readAuthorizer
+	qe, err := r.ledger.txmgr.NewQueryExecutor(util.GenerateUUID())
 	if err != nil {
 		return nil, err
 	}
@@ -1061,7 +2012,7 @@ func (r *collectionConfigHistoryRetriever) MostRecentCollectionConfigBelow(
 	if err != nil {
 		return nil, errors.WithMessage(err, "error while retrieving explicit collections")
 	}
-	qe, err := r.ledger.NewQueryExecutor()
+	qe, err := r.ledger.txmgr.NewQueryExecutor(util.GenerateUUID())
 	if err != nil {
 		return nil, err
 	}
@@ -1117,11 +2068,16 @@ func filterPvtDataOfInvalidTx(
 	lastBlockInBootstrapSnapshot uint64,
 ) (map[uint64][]*ledger.TxPvtData, error) {
 	committedPvtData := make(map[uint64][]*ledger.TxPvtData)
+	firstAvailableBlock := blockStore.FirstAvailableBlockNumber()
 	for blkNum, txsPvtData := range hashVerifiedPvtData {
 		if blkNum <= lastBlockInBootstrapSnapshot {
 			committedPvtData[blkNum] = txsPvtData
 			continue
 		}
+		if blkNum < firstAvailableBlock {
+			// the block is pruned after the pvtData was verified and the validity of the transactions is not known
+			continue
+		}
 		// TODO: Instead of retrieving the whole block, we need to retrieve only
 		// the TxValidationFlags from the block metadata. For that, we would need
 		// to add a new index for the block metadata - FAB-15808
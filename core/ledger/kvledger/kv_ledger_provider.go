@@ -9,16 +9,22 @@ package kvledger
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/configtx"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/dataformat"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/confighistory"
+	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
@@ -39,9 +45,24 @@ var (
 	metadataKeyPrefix = []byte{'s'}
 	// metadataKeyStop is the end key when querying idStore db by metadata key
 	metadataKeyStop = []byte{'s' + 1}
+	// creationTimeKeyPrefix is the prefix for the key that holds the creation time of a ledger in idStore db
+	creationTimeKeyPrefix = []byte{'c'}
+	// namespaceRebuildKeyPrefix is the prefix for the keys that hold the namespaces of a ledger to be rebuilt
+	// in idStore db
+	namespaceRebuildKeyPrefix = []byte{'n'}
+	// rebuildProgressKeyPrefix is the prefix for the key that holds the progress of the rebuild of the databases of
+	// a ledger in idStore db
+	rebuildProgressKeyPrefix = []byte{'p'}
+	// rebuildProgressKeyStop is the end key when querying idStore db by rebuild progress key
+	rebuildProgressKeyStop = []byte{'p' + 1}
+	// rawImportKeyPrefix is the prefix for the key that marks a ledger to which the blocks are appended via
+	// AppendBlockRaw in idStore db
+	rawImportKeyPrefix = []byte{'a'}
 
 	// formatKey
 	formatKey = []byte("f")
+	// snapshotsRootDirKey is the key for the snapshots root dir that was configured when the provider was last opened
+	snapshotsRootDirKey = []byte("r")
 
 	attrsToIndex = []blkstorage.IndexableAttr{
 		blkstorage.IndexableAttrBlockHash,
@@ -66,14 +87,74 @@ type Provider struct {
 	initializer          *ledger.Initializer
 	collElgNotifier      *collElgNotifier
 	stats                *stats
+	snapshotThrottle     *snapshotThrottle
 	fileLock             *leveldbhelper.FileLock
+
+	// closeLock is held in read mode by the exported operations for their duration
+	// and in write mode by Close and ApplyRecovery, so that the provider is not closed
+	// or recovered underneath an operation. The closed flag is set to 1 under the closeLock and is read atomically,
+	// so that the ledgers can check it without taking the closeLock
+	closeLock sync.RWMutex
+	closed    uint32
+
+	// recoveryCompleted is closed once the partial ledgers left behind by a crash are deleted
+	recoveryCompleted chan struct{}
+
+	// creationsInFlight holds the IDs of the ledgers being created by the in-progress operations, so that a ledger
+	// left UNDER_CONSTRUCTION by a failed creation can be told apart from a ledger that is being created
+	creationsLock     sync.Mutex
+	creationsInFlight map[string]struct{}
+}
+
+// ProviderClosedError is returned whenever an operation is invoked on a Provider after it has been closed
+type ProviderClosedError struct{}
+
+func (e *ProviderClosedError) Error() string {
+	return "ledger provider is closed"
+}
+
+// ErrProviderRecovering is returned when a ledger is opened before the provider finishes deleting
+// the partial ledgers left behind by a crash
+type ErrProviderRecovering struct{}
+
+func (e *ErrProviderRecovering) Error() string {
+	return "ledger provider recovery is in progress"
+}
+
+// CorruptLedgerRef identifies a ledger whose metadata in the ledger ID store could not be unmarshalled
+type CorruptLedgerRef struct {
+	LedgerID string
+	Err      error
+}
+
+// LedgerInfo captures the metadata of a ledger as returned by `Provider.ListWithMetadata`
+type LedgerInfo struct {
+	LedgerID string
+	Status   msgs.Status
+	// Height is the height of the block store, as of the last block synced to the disk
+	Height uint64
+	// LastCommitTime is the time of the last block append to the block store. This is zero if no block is
+	// committed yet, which is the case for a ledger created from a snapshot
+	LastCommitTime time.Time
+	// CreationTime is zero for the ledgers created by a peer that did not record the creation time
+	CreationTime time.Time
+}
+
+// DBKeyCounts holds the estimated number of keys in the state database and the history database of a ledger
+type DBKeyCounts struct {
+	StateDB   uint64
+	HistoryDB uint64
 }
 
 // NewProvider instantiates a new Provider.
+// The partial ledgers left behind by a crash are deleted before this function returns and hence the
+// channel returned by RecoveryCompleted on the returned Provider is always closed.
 // This is not thread-safe and assumed to be synchronized by the caller
 func NewProvider(initializer *ledger.Initializer) (pr *Provider, e error) {
 	p := &Provider{
-		initializer: initializer,
+		initializer:       initializer,
+		recoveryCompleted: make(chan struct{}),
+		creationsInFlight: map[string]struct{}{},
 	}
 
 	defer func() {
@@ -89,6 +170,16 @@ func NewProvider(initializer *ledger.Initializer) (pr *Provider, e error) {
 		}
 	}()
 
+	if initializer.HashProvider == nil {
+		return nil, errors.New("a hash provider is required for creating the ledger provider")
+	}
+	if initializer.Config.DataEncryptor != nil {
+		if stateDBConfig := initializer.Config.StateDBConfig; stateDBConfig != nil &&
+			stateDBConfig.StateDatabase != "" && stateDBConfig.StateDatabase != ledger.GoLevelDB {
+			return nil, errors.Errorf("encryption of the ledger data is not supported with the state database [%s]", stateDBConfig.StateDatabase)
+		}
+	}
+
 	fileLockPath := fileLockPath(initializer.Config.RootFSPath)
 	fileLock := leveldbhelper.NewFileLock(fileLockPath)
 	if err := fileLock.Lock(); err != nil {
@@ -119,9 +210,11 @@ func NewProvider(initializer *ledger.Initializer) (pr *Provider, e error) {
 		return nil, err
 	}
 	p.initLedgerStatistics()
+	p.initSnapshotThrottle()
 	if err := p.deletePartialLedgers(); err != nil {
 		return nil, err
 	}
+	close(p.recoveryCompleted)
 	if err := p.initSnapshotDir(); err != nil {
 		return nil, err
 	}
@@ -129,7 +222,7 @@ func NewProvider(initializer *ledger.Initializer) (pr *Provider, e error) {
 }
 
 func (p *Provider) initLedgerIDInventory() error {
-	idStore, err := openIDStore(LedgerProviderPath(p.initializer.Config.RootFSPath))
+	idStore, err := openIDStore(LedgerProviderPath(p.initializer.Config.RootFSPath), levelDBOpenRetryFor(p.initializer.Config))
 	if err != nil {
 		return err
 	}
@@ -138,12 +231,48 @@ func (p *Provider) initLedgerIDInventory() error {
 }
 
 func (p *Provider) initBlockStoreProvider() error {
-	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
+	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: blockStorageAttrsToIndex(p.initializer.Config.BlockStorageConfig)}
+	syncMode, syncEveryN, err := blockStorageSyncMode(p.initializer.Config.BlockStorageConfig)
+	if err != nil {
+		return err
+	}
+	blockfileSize := maxBlockFileSize
+	if blockStorageConfig := p.initializer.Config.BlockStorageConfig; blockStorageConfig != nil && blockStorageConfig.MaxBlockfileSizeMBs > 0 {
+		blockfileSize = blockStorageConfig.MaxBlockfileSizeMBs * 1024 * 1024
+	}
+	blkStoreConf := blkstorage.NewConfWithSyncMode(
+		BlockStorePath(p.initializer.Config.RootFSPath),
+		blockfileSize,
+		syncMode,
+		syncEveryN,
+	)
+	if blockStorageConfig := p.initializer.Config.BlockStorageConfig; blockStorageConfig != nil {
+		compression, err := blockStorageCompression(blockStorageConfig.Compression)
+		if err != nil {
+			return err
+		}
+		blkStoreConf.SetCompression(compression)
+		if blockStorageConfig.Codec != nil {
+			blkStoreConf.SetBlockCodec(blockStorageConfig.Codec)
+		}
+		if blockStorageConfig.Archiver != nil {
+			blkStoreConf.SetBlockfileArchiver(blockStorageConfig.Archiver)
+		}
+		indexDBTuning, err := levelDBTuningFor(blockStorageConfig.IndexLevelDB)
+		if err != nil {
+			return errors.WithMessage(err, "invalid block storage configuration")
+		}
+		blkStoreConf.SetIndexDBTuning(indexDBTuning)
+	}
+	if p.initializer.Config.DataEncryptor != nil {
+		blkStoreConf.SetEncryptor(p.initializer.Config.DataEncryptor)
+	}
+	blkStoreConf.SetIndexDBOpenRetry(levelDBOpenRetryFor(p.initializer.Config))
+	if p.initializer.Config.MaxOpenLedgers > 0 {
+		blkStoreConf.SetMaxOpenBlockStores(p.initializer.Config.MaxOpenLedgers)
+	}
 	blkStoreProvider, err := blkstorage.NewProvider(
-		blkstorage.NewConf(
-			BlockStorePath(p.initializer.Config.RootFSPath),
-			maxBlockFileSize,
-		),
+		blkStoreConf,
 		indexConfig,
 		p.initializer.MetricsProvider,
 	)
@@ -154,16 +283,94 @@ func (p *Provider) initBlockStoreProvider() error {
 	return nil
 }
 
+// blockStorageAttrsToIndex returns the default attributes to index in the block store, along with the optional
+// attributes enabled in the configuration
+func blockStorageAttrsToIndex(config *ledger.BlockStorageConfig) []blkstorage.IndexableAttr {
+	attrs := append([]blkstorage.IndexableAttr{}, attrsToIndex...)
+	if config == nil {
+		return attrs
+	}
+	if config.IndexChaincodeName {
+		attrs = append(attrs, blkstorage.IndexableAttrChaincodeName)
+	}
+	if config.IndexEndorserMSPIDs {
+		attrs = append(attrs, blkstorage.IndexableAttrEndorserMSPID)
+	}
+	return attrs
+}
+
+func blockStorageSyncMode(config *ledger.BlockStorageConfig) (blkstorage.SyncMode, int, error) {
+	if config == nil {
+		return blkstorage.SyncPerBlock, 1, nil
+	}
+	switch config.SyncMode {
+	case "", ledger.BlockStorageSyncPerBlock:
+		return blkstorage.SyncPerBlock, 1, nil
+	case ledger.BlockStorageSyncPerN:
+		if config.SyncEveryN <= 0 {
+			return 0, 0, errors.Errorf("invalid block storage configuration: syncEveryN [%d] must be positive for the sync mode [%s]",
+				config.SyncEveryN, config.SyncMode)
+		}
+		return blkstorage.SyncPerN, config.SyncEveryN, nil
+	case ledger.BlockStorageSyncOnClose:
+		return blkstorage.SyncOnClose, 0, nil
+	default:
+		return 0, 0, errors.Errorf("invalid block storage configuration: unsupported sync mode [%s]", config.SyncMode)
+	}
+}
+
+func blockStorageCompression(compression string) (blkstorage.Compression, error) {
+	switch compression {
+	case "", ledger.BlockStorageCompressionNone:
+		return blkstorage.CompressionNone, nil
+	case ledger.BlockStorageCompressionSnappy:
+		return blkstorage.CompressionSnappy, nil
+	default:
+		return 0, errors.Errorf("invalid block storage configuration: unsupported compression [%s]", compression)
+	}
+}
+
+// levelDBTuningFor translates the goleveldb configuration into the options for the leveldbhelper. A nil value is
+// returned for a nil configuration
+func levelDBTuningFor(config *ledger.LevelDBConfig) (*leveldbhelper.Tuning, error) {
+	if config == nil {
+		return nil, nil
+	}
+	tuning := &leveldbhelper.Tuning{
+		BloomFilterBitsPerKey: config.BloomFilterBitsPerKey,
+		BlockCacheSize:        config.BlockCacheSizeMBs * 1024 * 1024,
+		WriteBufferSize:       config.WriteBufferSizeMBs * 1024 * 1024,
+	}
+	switch config.Compression {
+	case "", ledger.LevelDBCompressionSnappy:
+	case ledger.LevelDBCompressionNone:
+		tuning.DisableCompression = true
+	default:
+		return nil, errors.Errorf("unsupported leveldb compression [%s]", config.Compression)
+	}
+	return tuning, nil
+}
+
+// levelDBOpenRetryFor returns the retry of the open of the goleveldb databases as per the ledger configuration
+func levelDBOpenRetryFor(config *ledger.Config) leveldbhelper.OpenRetry {
+	return leveldbhelper.OpenRetry{
+		Retries:  config.LevelDBOpenRetries,
+		Interval: config.LevelDBOpenRetryInterval,
+	}
+}
+
 func (p *Provider) initPvtDataStoreProvider() error {
 	privateDataConfig := &pvtdatastorage.PrivateDataConfig{
 		PrivateDataConfig: p.initializer.Config.PrivateDataConfig,
 		StorePath:         PvtDataStorePath(p.initializer.Config.RootFSPath),
+		Encryptor:         p.initializer.Config.DataEncryptor,
+		OpenRetry:         levelDBOpenRetryFor(p.initializer.Config),
 	}
 	ledgerIDs, err := p.idStore.getActiveAndInactiveLedgerIDs()
 	if err != nil {
 		return err
 	}
-	if err := pvtdatastorage.CheckAndConstructHashedIndex(privateDataConfig.StorePath, ledgerIDs); err != nil {
+	if err := pvtdatastorage.CheckAndConstructHashedIndex(privateDataConfig.StorePath, ledgerIDs, privateDataConfig.OpenRetry); err != nil {
 		return err
 	}
 	pvtdataStoreProvider, err := pvtdatastorage.NewProvider(privateDataConfig)
@@ -181,6 +388,9 @@ func (p *Provider) initHistoryDBProvider() error {
 	// Initialize the history database (index for history of values by key)
 	historydbProvider, err := history.NewDBProvider(
 		HistoryDBPath(p.initializer.Config.RootFSPath),
+		p.initializer.Config.HistoryDBConfig.SampleEveryN,
+		p.initializer.Config.HistoryDBConfig.StoreValues,
+		levelDBOpenRetryFor(p.initializer.Config),
 	)
 	if err != nil {
 		return err
@@ -194,6 +404,7 @@ func (p *Provider) initConfigHistoryManager() error {
 	configHistoryMgr, err := confighistory.NewMgr(
 		ConfigHistoryDBPath(p.initializer.Config.RootFSPath),
 		p.initializer.DeployedChaincodeInfoProvider,
+		levelDBOpenRetryFor(p.initializer.Config),
 	)
 	if err != nil {
 		return err
@@ -219,16 +430,29 @@ func (p *Provider) initStateListeners() {
 }
 
 func (p *Provider) initStateDBProvider() error {
+	var levelDBTuning *leveldbhelper.Tuning
+	if stateDBConfig := p.initializer.Config.StateDBConfig; stateDBConfig != nil {
+		tuning, err := levelDBTuningFor(stateDBConfig.LevelDB)
+		if err != nil {
+			return errors.WithMessage(err, "invalid state database configuration")
+		}
+		levelDBTuning = tuning
+	}
 	var err error
 	p.bookkeepingProvider, err = bookkeeping.NewProvider(
 		BookkeeperDBPath(p.initializer.Config.RootFSPath),
+		levelDBOpenRetryFor(p.initializer.Config),
 	)
 	if err != nil {
 		return err
 	}
 	stateDBConfig := &privacyenabledstate.StateDBConfig{
-		StateDBConfig: p.initializer.Config.StateDBConfig,
-		LevelDBPath:   StateDBPath(p.initializer.Config.RootFSPath),
+		StateDBConfig:    p.initializer.Config.StateDBConfig,
+		LevelDBPath:      StateDBPath(p.initializer.Config.RootFSPath),
+		PebbleDBPath:     StatePebbleDBPath(p.initializer.Config.RootFSPath),
+		Encryptor:        p.initializer.Config.DataEncryptor,
+		LevelDBTuning:    levelDBTuning,
+		LevelDBOpenRetry: levelDBOpenRetryFor(p.initializer.Config),
 	}
 	sysNamespaces := p.initializer.DeployedChaincodeInfoProvider.Namespaces()
 	p.dbProvider, err = privacyenabledstate.NewDBProvider(
@@ -245,6 +469,14 @@ func (p *Provider) initLedgerStatistics() {
 	p.stats = newStats(p.initializer.MetricsProvider)
 }
 
+func (p *Provider) initSnapshotThrottle() {
+	snapshotsConfig := p.initializer.Config.SnapshotsConfig
+	p.snapshotThrottle = newSnapshotThrottle(
+		snapshotsConfig.MaxConcurrentGenerations,
+		int64(snapshotsConfig.MaxWriteRateMBs)*1024*1024,
+	)
+}
+
 func (p *Provider) initSnapshotDir() error {
 	snapshotsRootDir := p.initializer.Config.SnapshotsConfig.RootDir
 	if !filepath.IsAbs(snapshotsRootDir) {
@@ -254,6 +486,19 @@ func (p *Provider) initSnapshotDir() error {
 	inProgressSnapshotsPath := SnapshotsTempDirPath(snapshotsRootDir)
 	completedSnapshotsPath := CompletedSnapshotsPath(snapshotsRootDir)
 
+	prevSnapshotsRootDir, err := p.idStore.getSnapshotsRootDir()
+	if err != nil {
+		return err
+	}
+	if prevSnapshotsRootDir != "" && filepath.Clean(prevSnapshotsRootDir) != filepath.Clean(snapshotsRootDir) {
+		if !p.initializer.Config.SnapshotsConfig.MigrateSnapshots {
+			logger.Warnf("The snapshots root dir has changed from [%s] to [%s], the snapshots generated previously are not migrated to the new location",
+				prevSnapshotsRootDir, snapshotsRootDir)
+		} else if err := migrateSnapshots(prevSnapshotsRootDir, snapshotsRootDir); err != nil {
+			return err
+		}
+	}
+
 	if err := os.RemoveAll(inProgressSnapshotsPath); err != nil {
 		return errors.Wrapf(err, "error while deleting the dir: %s", inProgressSnapshotsPath)
 	}
@@ -263,7 +508,10 @@ func (p *Provider) initSnapshotDir() error {
 	if err := os.MkdirAll(completedSnapshotsPath, 0o755); err != nil {
 		return errors.Wrapf(err, "error while creating the dir: %s, ensure peer has write access to configured ledger.snapshots.rootDir directory", completedSnapshotsPath)
 	}
-	return fileutil.SyncDir(snapshotsRootDir)
+	if err := fileutil.SyncDir(snapshotsRootDir); err != nil {
+		return err
+	}
+	return p.idStore.setSnapshotsRootDir(snapshotsRootDir)
 }
 
 // CreateFromGenesisBlock implements the corresponding method from interface ledger.PeerLedgerProvider
@@ -274,6 +522,32 @@ func (p *Provider) CreateFromGenesisBlock(genesisBlock *common.Block) (ledger.Pe
 	if err != nil {
 		return nil, err
 	}
+	return p.CreateFromGenesisBlockWithID(ledgerID, genesisBlock)
+}
+
+// CreateFromGenesisBlockWithID is similar to CreateFromGenesisBlock, except that the ledger is stored under
+// the supplied ledger ID instead of the channel ID in the genesis block. This is intended for the testing and
+// the migration scenarios where the ledger ID on disk needs to differ from the channel name
+func (p *Provider) CreateFromGenesisBlockWithID(ledgerID string, genesisBlock *common.Block) (ledger.PeerLedger, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	if err := p.validateGenesisBlock(genesisBlock); err != nil {
+		return nil, err
+	}
+	err := p.validateLedgerID(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.beginCreation(ledgerID); err != nil {
+		return nil, err
+	}
+	defer p.endCreation(ledgerID)
+	if err := p.cleanupUnderConstructionLedger(ledgerID); err != nil {
+		return nil, err
+	}
 	if err = p.idStore.createLedgerID(
 		ledgerID,
 		&msgs.LedgerMetadata{
@@ -283,7 +557,7 @@ func (p *Provider) CreateFromGenesisBlock(genesisBlock *common.Block) (ledger.Pe
 		return nil, err
 	}
 
-	lgr, err := p.open(ledgerID, nil, false)
+	lgr, err := p.open(ledgerID, nil, false, false)
 	if err != nil {
 		return nil, p.deleteUnderConstructionLedger(lgr, ledgerID, err)
 	}
@@ -295,9 +569,152 @@ func (p *Provider) CreateFromGenesisBlock(genesisBlock *common.Block) (ledger.Pe
 	if err = p.idStore.updateLedgerStatus(ledgerID, msgs.Status_ACTIVE); err != nil {
 		return nil, p.deleteUnderConstructionLedger(lgr, ledgerID, err)
 	}
+	p.notifyLedgerCreated(ledgerID, genesisBlock)
 	return lgr, nil
 }
 
+// CreateFromGenesisBlocks creates a new ledger for each of the given genesis blocks and commits the genesis blocks.
+// The ledgers are created as a set, i.e., if a failure happens during the creation of any of the ledgers, all the
+// ledgers created in this call are deleted. The ledgers remain in the UNDER_CONSTRUCTION status till all of them
+// are created, so that, in the event of a crash, all the ledgers in the set are deleted at the next peer start
+func (p *Provider) CreateFromGenesisBlocks(genesisBlocks []*common.Block) ([]ledger.PeerLedger, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	ledgerIDs := make([]string, len(genesisBlocks))
+	for i, genesisBlock := range genesisBlocks {
+		ledgerID, err := protoutil.GetChannelIDFromBlock(genesisBlock)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error while extracting ledger ID from the genesis block at index [%d]", i)
+		}
+		if err := p.validateLedgerID(ledgerID); err != nil {
+			return nil, err
+		}
+		for j := 0; j < i; j++ {
+			if ledgerIDs[j] == ledgerID {
+				return nil, errors.Errorf("duplicate ledger ID [%s] in the genesis blocks at index [%d] and [%d]", ledgerID, j, i)
+			}
+		}
+		exists, err := p.idStore.ledgerIDExists(ledgerID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, errors.Errorf("ledger [%s] already exists", ledgerID)
+		}
+		ledgerIDs[i] = ledgerID
+	}
+	if err := p.beginCreation(ledgerIDs...); err != nil {
+		return nil, err
+	}
+	defer p.endCreation(ledgerIDs...)
+
+	var createdLedgerIDs []string
+	var lgrs []ledger.PeerLedger
+	for i, ledgerID := range ledgerIDs {
+		if err := p.idStore.createLedgerID(
+			ledgerID,
+			&msgs.LedgerMetadata{
+				Status: msgs.Status_UNDER_CONSTRUCTION,
+			},
+		); err != nil {
+			return nil, p.deleteUnderConstructionLedgers(lgrs, createdLedgerIDs, err)
+		}
+		createdLedgerIDs = append(createdLedgerIDs, ledgerID)
+
+		lgr, err := p.open(ledgerID, nil, false, false)
+		if err != nil {
+			return nil, p.deleteUnderConstructionLedgers(lgrs, createdLedgerIDs, err)
+		}
+		lgrs = append(lgrs, lgr)
+
+		if err := lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: genesisBlocks[i]}, &ledger.CommitOptions{}); err != nil {
+			return nil, p.deleteUnderConstructionLedgers(lgrs, createdLedgerIDs, err)
+		}
+	}
+
+	if err := p.idStore.updateLedgerStatuses(ledgerIDs, msgs.Status_ACTIVE); err != nil {
+		return nil, p.deleteUnderConstructionLedgers(lgrs, createdLedgerIDs, err)
+	}
+	for i, ledgerID := range ledgerIDs {
+		p.notifyLedgerCreated(ledgerID, genesisBlocks[i])
+	}
+	return lgrs, nil
+}
+
+// notifyLedgerCreated invokes the ledger creation listener, if any, for a ledger that is created from the given
+// genesis block. This is expected to be invoked only after the ledger is marked active. The ledger is durable by
+// then and hence an error returned by the listener is only logged
+func (p *Provider) notifyLedgerCreated(ledgerID string, genesisBlock *common.Block) {
+	listener := p.initializer.LedgerCreationListener
+	if listener == nil {
+		return
+	}
+	if err := listener.HandleLedgerCreated(ledgerID, protoutil.BlockHeaderHash(genesisBlock.Header)); err != nil {
+		logger.Errorw("Error from the ledger creation listener", "ledgerID", ledgerID, "error", err)
+	}
+}
+
+// deleteUnderConstructionLedgers closes the given ledgers and deletes all the ledgers with the given ledger IDs.
+// The ledgers are expected to be in the same order as the ledger IDs, with ledgers missing only at the end
+func (p *Provider) deleteUnderConstructionLedgers(lgrs []ledger.PeerLedger, ledgerIDs []string, creationErr error) error {
+	logger.Errorf("ledgers creation error = %+v", creationErr)
+	for _, lgr := range lgrs {
+		lgr.Close()
+	}
+	for _, ledgerID := range ledgerIDs {
+		if cleanupErr := p.runCleanup(ledgerID); cleanupErr != nil {
+			return errors.WithMessagef(cleanupErr, creationErr.Error())
+		}
+	}
+	return creationErr
+}
+
+// cleanupUnderConstructionLedger deletes the ledger if it has been left UNDER_CONSTRUCTION by an earlier creation
+// that failed without being able to clean up or was interrupted by a crash. Such a ledger was never made available
+// and hence, it is deleted so that the creation can be retried. The caller is expected to have invoked beginCreation
+// for the ledger, which ensures that the ledger is not being created by another in-progress operation
+func (p *Provider) cleanupUnderConstructionLedger(ledgerID string) error {
+	metadata, err := p.idStore.getLedgerMetadata(ledgerID)
+	if err != nil {
+		return err
+	}
+	if metadata == nil || metadata.Status != msgs.Status_UNDER_CONSTRUCTION {
+		return nil
+	}
+	logger.Infow("Deleting the ledger left under construction by an earlier creation, before retrying the creation", "ledgerID", ledgerID)
+	if err := p.runCleanup(ledgerID); err != nil {
+		return errors.WithMessagef(err, "error while deleting the ledger [%s] left under construction by an earlier creation", ledgerID)
+	}
+	return nil
+}
+
+// beginCreation records that the ledgers are being created and returns an error if the creation of any of
+// the ledgers is already in progress
+func (p *Provider) beginCreation(ledgerIDs ...string) error {
+	p.creationsLock.Lock()
+	defer p.creationsLock.Unlock()
+	for _, ledgerID := range ledgerIDs {
+		if _, ok := p.creationsInFlight[ledgerID]; ok {
+			return errors.Errorf("ledger [%s] is being created by another operation", ledgerID)
+		}
+	}
+	for _, ledgerID := range ledgerIDs {
+		p.creationsInFlight[ledgerID] = struct{}{}
+	}
+	return nil
+}
+
+func (p *Provider) endCreation(ledgerIDs ...string) {
+	p.creationsLock.Lock()
+	defer p.creationsLock.Unlock()
+	for _, ledgerID := range ledgerIDs {
+		delete(p.creationsInFlight, ledgerID)
+	}
+}
+
 func (p *Provider) deleteUnderConstructionLedger(ledger ledger.PeerLedger, ledgerID string, creationErr error) error {
 	if creationErr == nil {
 		return nil
@@ -317,7 +734,24 @@ func (p *Provider) deleteUnderConstructionLedger(ledger ledger.PeerLedger, ledge
 
 // Open implements the corresponding method from interface ledger.PeerLedgerProvider
 func (p *Provider) Open(ledgerID string) (ledger.PeerLedger, error) {
+	return p.OpenWithOptions(ledgerID, nil)
+}
+
+// OpenWithOptions opens an already created ledger, same as Open, with the given options applied.
+// A nil opts is equivalent to the default options
+func (p *Provider) OpenWithOptions(ledgerID string, opts *ledger.OpenOptions) (ledger.PeerLedger, error) {
 	logger.Debugf("Open() opening kvledger: %s", ledgerID)
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	select {
+	case <-p.recoveryCompleted:
+	default:
+		return nil, &ErrProviderRecovering{}
+	}
+
 	// Check the ID store to ensure that the chainId/ledgerId exists
 	ledgerMetadata, err := p.idStore.getLedgerMetadata(ledgerID)
 	if err != nil {
@@ -334,10 +768,11 @@ func (p *Provider) Open(ledgerID string) (ledger.PeerLedger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return p.open(ledgerID, bootSnapshotMetadata, false)
+	deferHistoryRebuild := opts != nil && opts.DeferHistoryRebuild
+	return p.open(ledgerID, bootSnapshotMetadata, false, deferHistoryRebuild)
 }
 
-func (p *Provider) open(ledgerID string, bootSnapshotMetadata *SnapshotMetadata, initializingFromSnapshot bool) (ledger.PeerLedger, error) {
+func (p *Provider) open(ledgerID string, bootSnapshotMetadata *SnapshotMetadata, initializingFromSnapshot, deferHistoryRebuild bool) (ledger.PeerLedger, error) {
 	// Get the block store for a chain/ledger
 	blockStore, err := p.blkStoreProvider.Open(ledgerID)
 	if err != nil {
@@ -361,6 +796,22 @@ func (p *Provider) open(ledgerID string, bootSnapshotMetadata *SnapshotMetadata,
 	var historyDB *history.DB
 	if p.historydbProvider != nil {
 		historyDB = p.historydbProvider.GetDBHandle(ledgerID)
+		if err := p.prepareHistoryDBForBootSnapshot(ledgerID, historyDB, bootSnapshotMetadata); err != nil {
+			return nil, err
+		}
+	}
+
+	namespacesToRebuild, err := p.idStore.getNamespacesToRebuild(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	_, fullRebuild, err := p.idStore.getRebuildProgress(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	rawImport, err := p.idStore.isRawImport(ledgerID)
+	if err != nil {
+		return nil, err
 	}
 
 	initializer := &lgrInitializer{
@@ -375,32 +826,263 @@ func (p *Provider) open(ledgerID string, bootSnapshotMetadata *SnapshotMetadata,
 		ccInfoProvider:           p.initializer.DeployedChaincodeInfoProvider,
 		ccLifecycleEventProvider: p.initializer.ChaincodeLifecycleEventProvider,
 		stats:                    p.stats.ledgerStats(ledgerID),
+		snapshotThrottle:         p.snapshotThrottle,
 		customTxProcessors:       p.initializer.CustomTxProcessors,
 		hashProvider:             p.initializer.HashProvider,
 		config:                   p.initializer.Config,
 		bootSnapshotMetadata:     bootSnapshotMetadata,
 		initializingFromSnapshot: initializingFromSnapshot,
+		deferHistoryRebuild:      deferHistoryRebuild,
+		readAuthorizer:           p.initializer.ReadAuthorizer,
+		namespacesToRebuild:      namespacesToRebuild,
+		rebuildCheckpointer: &rebuildCheckpointer{
+			idStore:     p.idStore,
+			ledgerID:    ledgerID,
+			fullRebuild: fullRebuild,
+		},
+		rawImportMarker: &rawImportMarker{
+			idStore:  p.idStore,
+			ledgerID: ledgerID,
+			marked:   rawImport,
+		},
+		providerClosed: p.isClosed,
 	}
 
 	l, err := newKVLedger(initializer)
 	if err != nil {
 		return nil, err
 	}
+	if len(namespacesToRebuild) > 0 {
+		if err := p.idStore.clearNamespacesToRebuild(ledgerID); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	if fullRebuild {
+		if err := p.idStore.clearRebuildProgress(ledgerID); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
 	return l, nil
 }
 
+// prepareHistoryDBForBootSnapshot marks the starting savepoint for the history db of a ledger that was
+// bootstrapped from a snapshot while the history db was disabled. Without the savepoint, the history db
+// would require the blocks that are not present in the block store for catching up with the block store.
+func (p *Provider) prepareHistoryDBForBootSnapshot(ledgerID string, historyDB *history.DB, bootSnapshotMetadata *SnapshotMetadata) error {
+	if bootSnapshotMetadata == nil {
+		return nil
+	}
+	savepoint, err := historyDB.GetLastSavepoint()
+	if err != nil || savepoint != nil {
+		return err
+	}
+	logger.Infow("History database is enabled for a ledger bootstrapped from a snapshot. History will be available from the snapshot height onward",
+		"ledgerID", ledgerID, "snapshotHeight", bootSnapshotMetadata.LastBlockNumber+1)
+	return p.historydbProvider.MarkStartingSavepoint(
+		ledgerID,
+		version.NewHeight(bootSnapshotMetadata.LastBlockNumber, math.MaxUint64),
+	)
+}
+
 // Exists implements the corresponding method from interface ledger.PeerLedgerProvider
 func (p *Provider) Exists(ledgerID string) (bool, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return false, err
+	}
+	defer p.closeLock.RUnlock()
 	return p.idStore.ledgerIDExists(ledgerID)
 }
 
+// ExistsBatch checks the existence of the given ledgers in a single pass over the idStore. The returned map
+// contains an entry for each of the given ledger IDs (the duplicates in the input appear once in the map)
+func (p *Provider) ExistsBatch(ledgerIDs []string) (map[string]bool, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+	return p.idStore.ledgerIDsExist(ledgerIDs)
+}
+
+// LedgerStatus returns the status of the ledger along with a flag that indicates whether the ledger exists.
+// Unlike calling Exists followed by a metadata read, the status and the existence are read in a single lookup.
+// For a non-existent ledger, the returned flag is false and no error is returned
+func (p *Provider) LedgerStatus(ledgerID string) (msgs.Status, bool, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return 0, false, err
+	}
+	defer p.closeLock.RUnlock()
+	metadata, err := p.idStore.getLedgerMetadata(ledgerID)
+	if err != nil || metadata == nil {
+		return 0, false, err
+	}
+	return metadata.Status, true, nil
+}
+
 // List implements the corresponding method from interface ledger.PeerLedgerProvider
 func (p *Provider) List() ([]string, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
 	return p.idStore.getActiveLedgerIDs()
 }
 
-// Close implements the corresponding method from interface ledger.PeerLedgerProvider
+// ListTolerant is similar to List, except that it does not fail on the ledgers whose metadata cannot be unmarshalled.
+// Such ledgers are skipped and reported in `skipped` so that the healthy ledgers remain accessible. An error is
+// returned only for failures at the level of the underlying store
+func (p *Provider) ListTolerant() (ids []string, skipped []CorruptLedgerRef, err error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, nil, err
+	}
+	defer p.closeLock.RUnlock()
+	return p.idStore.getActiveLedgerIDsTolerant()
+}
+
+// ListWithMetadata returns the metadata of all the ledgers present in the ledger ID store, irrespective of their
+// status. The height and the last commit time are read from the block store bookkeeping without opening the ledgers
+func (p *Provider) ListWithMetadata() ([]*LedgerInfo, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	var infos []*LedgerInfo
+	itr := p.idStore.db.GetIterator(metadataKeyPrefix, metadataKeyStop)
+	defer itr.Release()
+	for itr.Error() == nil && itr.Next() {
+		metadata := &msgs.LedgerMetadata{}
+		if err := proto.Unmarshal(itr.Value(), metadata); err != nil {
+			logger.Errorf("Error unmarshalling ledger metadata: %s", err)
+			return nil, errors.Wrapf(err, "error unmarshalling ledger metadata")
+		}
+		infos = append(infos, &LedgerInfo{
+			LedgerID: ledgerIDFromMetadataKey(itr.Key()),
+			Status:   metadata.Status,
+		})
+	}
+	if err := itr.Error(); err != nil {
+		logger.Errorf("Error getting ledger ids from idStore: %s", err)
+		return nil, errors.Wrapf(err, "error getting ledger ids from idStore")
+	}
+
+	for _, info := range infos {
+		creationTime, err := p.idStore.getCreationTime(info.LedgerID)
+		if err != nil {
+			return nil, err
+		}
+		info.CreationTime = creationTime
+		storeInfo, err := p.blkStoreProvider.GetStoreInfo(info.LedgerID)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "error while reading the block store info for ledger [%s]", info.LedgerID)
+		}
+		if storeInfo != nil {
+			info.Height = storeInfo.Height
+			info.LastCommitTime = storeInfo.LastAppendTime
+		}
+	}
+	return infos, nil
+}
+
+// ApproximateKeyCounts returns an estimate of the number of keys in the state database and the history database
+// of the given ledger. The counts are estimated from a sample of keys and the disk space used by the databases and
+// hence are not exact; they are intended for capacity planning. The savepoints and other bookkeeping keys are
+// not counted, so a ledger without any data yields zero counts. The estimate for the state database is available
+// only for the goleveldb state database and the estimate for the history database is zero if the history database
+// is disabled. This function can be invoked while the ledger is open
+func (p *Provider) ApproximateKeyCounts(ledgerID string) (*DBKeyCounts, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	exists, err := p.idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.Errorf("cannot estimate key counts for ledger [%s], ledger does not exist", ledgerID)
+	}
+
+	stateDB, err := p.dbProvider.GetDBHandle(ledgerID, nil)
+	if err != nil {
+		return nil, err
+	}
+	counts := &DBKeyCounts{}
+	if counts.StateDB, err = stateDB.ApproximateKeyCount(); err != nil {
+		return nil, errors.WithMessagef(err, "error while estimating key count in the state database for ledger [%s]", ledgerID)
+	}
+	if p.historydbProvider != nil {
+		if counts.HistoryDB, err = p.historydbProvider.GetDBHandle(ledgerID).ApproximateKeyCount(); err != nil {
+			return nil, errors.WithMessagef(err, "error while estimating key count in the history database for ledger [%s]", ledgerID)
+		}
+	}
+	return counts, nil
+}
+
+// validateLedgerID invokes the LedgerIDValidator supplied in the initializer, if any
+func (p *Provider) validateLedgerID(ledgerID string) error {
+	if p.initializer.LedgerIDValidator == nil {
+		return nil
+	}
+	if err := p.initializer.LedgerIDValidator(ledgerID); err != nil {
+		return errors.WithMessagef(err, "invalid ledger ID [%s]", ledgerID)
+	}
+	return nil
+}
+
+// validateGenesisBlock performs the checks on the genesis block as per the configured GenesisValidation
+func (p *Provider) validateGenesisBlock(genesisBlock *common.Block) error {
+	if p.initializer.GenesisValidation != ledger.GenesisValidationRelaxed {
+		// the channel ID is not used but extracting it ensures that the genesis block carries a valid channel header
+		_, err := protoutil.GetChannelIDFromBlock(genesisBlock)
+		return err
+	}
+	if genesisBlock == nil || genesisBlock.Header == nil || genesisBlock.Data == nil {
+		return errors.New("genesis block is missing the header or the data")
+	}
+	if genesisBlock.Header.Number != 0 {
+		return errors.Errorf("expected block number=0, received block number=%d", genesisBlock.Header.Number)
+	}
+	if !bytes.Equal(genesisBlock.Header.DataHash, protoutil.BlockDataHash(genesisBlock.Data)) {
+		return errors.New("data hash in the header of the genesis block does not match the block data")
+	}
+	return nil
+}
+
+// ValidateLedgerID is the default ledger ID validator that can be supplied as the
+// LedgerIDValidator in ledger.Initializer. It enforces the rules for the channel names
+func ValidateLedgerID(ledgerID string) error {
+	return configtx.ValidateChannelID(ledgerID)
+}
+
+// acquireCloseRLock acquires the closeLock in read mode if the provider is not closed. Otherwise,
+// it returns a ProviderClosedError without holding the lock
+func (p *Provider) acquireCloseRLock() error {
+	p.closeLock.RLock()
+	if p.isClosed() {
+		p.closeLock.RUnlock()
+		return &ProviderClosedError{}
+	}
+	return nil
+}
+
+// isClosed returns whether the provider is closed
+func (p *Provider) isClosed() bool {
+	return atomic.LoadUint32(&p.closed) == 1
+}
+
+// Close implements the corresponding method from interface ledger.PeerLedgerProvider.
+// Closing an already closed provider is a no-op
 func (p *Provider) Close() {
+	p.closeLock.Lock()
+	defer p.closeLock.Unlock()
+	if p.isClosed() {
+		return
+	}
+	atomic.StoreUint32(&p.closed, 1)
+
 	if p.idStore != nil {
 		p.idStore.close()
 	}
@@ -427,45 +1109,117 @@ func (p *Provider) Close() {
 	}
 }
 
+// RecoveryActionType identifies the action that the recovery performs on a partial ledger
+type RecoveryActionType int
+
+const (
+	// RecoveryActionDeleteUnderConstruction deletes a ledger whose creation did not complete
+	RecoveryActionDeleteUnderConstruction RecoveryActionType = iota
+	// RecoveryActionFinalizeDeletion completes the deletion of a ledger whose deletion did not complete
+	RecoveryActionFinalizeDeletion
+)
+
+func (t RecoveryActionType) String() string {
+	switch t {
+	case RecoveryActionDeleteUnderConstruction:
+		return "DeleteUnderConstruction"
+	case RecoveryActionFinalizeDeletion:
+		return "FinalizeDeletion"
+	default:
+		return fmt.Sprintf("RecoveryActionType(%d)", int(t))
+	}
+}
+
+// RecoveryAction describes an action that the recovery performs on a partial ledger, i.e., a ledger
+// that is left with a status of UNDER_CONSTRUCTION or UNDER_DELETION as a side effect of a crash
+type RecoveryAction struct {
+	LedgerID string
+	Status   msgs.Status
+	Type     RecoveryActionType
+}
+
+// PendingRecoveryActions returns the actions that the recovery would perform on the partial ledgers,
+// without performing them. The recovery runs when the provider is instantiated and can also be
+// triggered by invoking ApplyRecovery
+func (p *Provider) PendingRecoveryActions() ([]RecoveryAction, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+	return p.pendingRecoveryActions()
+}
+
+// RecoveryCompleted returns a channel that is closed once the provider finishes deleting the partial ledgers
+// left behind by a crash, which allows the callers that construct the provider asynchronously to wait for the recovery
+func (p *Provider) RecoveryCompleted() <-chan struct{} {
+	return p.recoveryCompleted
+}
+
+// ApplyRecovery deletes the partial ledgers. This holds the closeLock in write mode so that the ledgers
+// that are being created by an in-flight operation are not mistaken for the residue of a crash
+func (p *Provider) ApplyRecovery() error {
+	p.closeLock.Lock()
+	defer p.closeLock.Unlock()
+	if p.isClosed() {
+		return &ProviderClosedError{}
+	}
+	return p.deletePartialLedgers()
+}
+
 // deletePartialLedgers scans for and deletes any ledger with a status of UNDER_CONSTRUCTION or UNDER_DELETION.
 // UNDER_CONSTRUCTION ledgers represent residual structures created as a side effect of a crash during ledger creation.
 // UNDER_DELETION ledgers represent residual structures created as a side effect of a crash during a peer channel unjoin.
 func (p *Provider) deletePartialLedgers() error {
 	logger.Debug("Removing ledgers in state UNDER_CONSTRUCTION or UNDER_DELETION")
+	actions, err := p.pendingRecoveryActions()
+	if err != nil {
+		return err
+	}
+	for _, a := range actions {
+		logger.Infow(
+			"A partial ledger was identified at peer launch, indicating a peer stop/crash during creation or a failed channel unjoin.  The partial ledger wil be deleted.",
+			"ledgerID", a.LedgerID,
+			"Status", a.Status,
+		)
+		if err := p.runCleanup(a.LedgerID); err != nil {
+			logger.Errorw(
+				"Error while deleting a partially created ledger at start",
+				"ledgerID", a.LedgerID,
+				"Status", a.Status,
+				"error", err,
+			)
+			return errors.WithMessagef(err, "error while deleting a partially constructed ledger with status [%s] at start for ledger = [%s]", a.Status, a.LedgerID)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) pendingRecoveryActions() ([]RecoveryAction, error) {
 	itr := p.idStore.db.GetIterator(metadataKeyPrefix, metadataKeyStop)
 	defer itr.Release()
 	if err := itr.Error(); err != nil {
-		return errors.WithMessage(err, "error obtaining iterator for incomplete ledger scans")
+		return nil, errors.WithMessage(err, "error obtaining iterator for incomplete ledger scans")
 	}
+	var actions []RecoveryAction
 	for {
 		hasMore := itr.Next()
 		err := itr.Error()
 		if err != nil {
-			return errors.WithMessage(err, "error while iterating over ledger list while scanning for incomplete ledgers")
+			return nil, errors.WithMessage(err, "error while iterating over ledger list while scanning for incomplete ledgers")
 		}
 		if !hasMore {
-			return nil
+			return actions, nil
 		}
 		ledgerID := ledgerIDFromMetadataKey(itr.Key())
 		metadata := &msgs.LedgerMetadata{}
 		if err := proto.Unmarshal(itr.Value(), metadata); err != nil {
-			return errors.Wrapf(err, "error while unmarshalling metadata bytes for ledger [%s]", ledgerID)
+			return nil, errors.Wrapf(err, "error while unmarshalling metadata bytes for ledger [%s]", ledgerID)
 		}
-		if metadata.Status == msgs.Status_UNDER_CONSTRUCTION || metadata.Status == msgs.Status_UNDER_DELETION {
-			logger.Infow(
-				"A partial ledger was identified at peer launch, indicating a peer stop/crash during creation or a failed channel unjoin.  The partial ledger wil be deleted.",
-				"ledgerID", ledgerID,
-				"Status", metadata.Status,
-			)
-			if err := p.runCleanup(ledgerID); err != nil {
-				logger.Errorw(
-					"Error while deleting a partially created ledger at start",
-					"ledgerID", ledgerID,
-					"Status", metadata.Status,
-					"error", err,
-				)
-				return errors.WithMessagef(err, "error while deleting a partially constructed ledger with status [%s] at start for ledger = [%s]", metadata.Status, ledgerID)
-			}
+		switch metadata.Status {
+		case msgs.Status_UNDER_CONSTRUCTION:
+			actions = append(actions, RecoveryAction{LedgerID: ledgerID, Status: metadata.Status, Type: RecoveryActionDeleteUnderConstruction})
+		case msgs.Status_UNDER_DELETION:
+			actions = append(actions, RecoveryAction{LedgerID: ledgerID, Status: metadata.Status, Type: RecoveryActionFinalizeDeletion})
 		}
 	}
 }
@@ -509,8 +1263,12 @@ type idStore struct {
 	dbPath string
 }
 
-func openIDStore(path string) (s *idStore, e error) {
-	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: path})
+func openIDStore(path string, openRetry leveldbhelper.OpenRetry) (s *idStore, e error) {
+	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{
+		DBPath:            path,
+		OpenRetries:       openRetry.Retries,
+		OpenRetryInterval: openRetry.Interval,
+	})
 	db.Open()
 	defer func() {
 		if e != nil {
@@ -616,6 +1374,29 @@ func (s *idStore) upgradeFormat() error {
 	return s.db.WriteBatch(batch, true)
 }
 
+// setFormatIfEmpty writes the given format under the format key if the idStore does not contain a format.
+// This is a no-op if the idStore already contains the same format and an ErrFormatMismatch is returned
+// if the idStore contains a different format
+func (s *idStore) setFormatIfEmpty(format string) error {
+	existingFormat, err := s.db.Get(formatKey)
+	if err != nil {
+		return err
+	}
+	if existingFormat == nil {
+		logger.Infof("Setting the data format of ledger database %s to %s", s.dbPath, format)
+		return s.db.Put(formatKey, []byte(format), true)
+	}
+	if !bytes.Equal(existingFormat, []byte(format)) {
+		return &dataformat.ErrFormatMismatch{
+			ExpectedFormat: format,
+			Format:         string(existingFormat),
+			DBInfo:         fmt.Sprintf("leveldb for channel-IDs at [%s]", s.dbPath),
+		}
+	}
+	logger.Debugf("Ledger database %s already has data format %s, nothing to set", s.dbPath, format)
+	return nil
+}
+
 func (s *idStore) createLedgerID(ledgerID string, metadata *msgs.LedgerMetadata) error {
 	m, err := s.getLedgerMetadata(ledgerID)
 	if err != nil {
@@ -628,11 +1409,169 @@ func (s *idStore) createLedgerID(ledgerID string, metadata *msgs.LedgerMetadata)
 	if err != nil {
 		return err
 	}
-	return s.db.Put(metadataKey(ledgerID), metadataBytes, true)
+	batch := &leveldb.Batch{}
+	batch.Put(metadataKey(ledgerID), metadataBytes)
+	batch.Put(creationTimeKey(ledgerID), proto.EncodeVarint(uint64(time.Now().UnixNano())))
+	return s.db.WriteBatch(batch, true)
 }
 
 func (s *idStore) deleteLedgerID(ledgerID string) error {
-	return s.db.Delete(metadataKey(ledgerID), true)
+	batch := &leveldb.Batch{}
+	batch.Delete(metadataKey(ledgerID))
+	batch.Delete(creationTimeKey(ledgerID))
+	batch.Delete(rebuildProgressKey(ledgerID))
+	batch.Delete(rawImportKey(ledgerID))
+	if err := s.addNamespacesToRebuildDeletes(batch, ledgerID); err != nil {
+		return err
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+// addNamespacesToRebuild records the namespaces of the ledger to be rebuilt when the ledger is opened next
+func (s *idStore) addNamespacesToRebuild(ledgerID string, namespaces []string) error {
+	batch := &leveldb.Batch{}
+	for _, ns := range namespaces {
+		batch.Put(namespaceRebuildKey(ledgerID, ns), []byte{})
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+// getNamespacesToRebuild returns the namespaces of the ledger recorded via addNamespacesToRebuild
+func (s *idStore) getNamespacesToRebuild(ledgerID string) ([]string, error) {
+	startKey, endKey := namespaceRebuildKeyRange(ledgerID)
+	itr := s.db.GetIterator(startKey, endKey)
+	defer itr.Release()
+	var namespaces []string
+	for itr.Next() {
+		namespaces = append(namespaces, string(itr.Key()[len(startKey):]))
+	}
+	if err := itr.Error(); err != nil {
+		return nil, errors.Wrapf(err, "error while reading the namespaces to rebuild for ledger [%s]", ledgerID)
+	}
+	return namespaces, nil
+}
+
+// clearNamespacesToRebuild removes the namespaces of the ledger recorded via addNamespacesToRebuild
+func (s *idStore) clearNamespacesToRebuild(ledgerID string) error {
+	batch := &leveldb.Batch{}
+	if err := s.addNamespacesToRebuildDeletes(batch, ledgerID); err != nil {
+		return err
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+func (s *idStore) addNamespacesToRebuildDeletes(batch *leveldb.Batch, ledgerID string) error {
+	namespaces, err := s.getNamespacesToRebuild(ledgerID)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		batch.Delete(namespaceRebuildKey(ledgerID, ns))
+	}
+	return nil
+}
+
+// updateNamespacesRebuildProgress records the number of blocks replayed for the namespaces of the ledger recorded via
+// addNamespacesToRebuild
+func (s *idStore) updateNamespacesRebuildProgress(ledgerID string, replayedHeight uint64) error {
+	namespaces, err := s.getNamespacesToRebuild(ledgerID)
+	if err != nil {
+		return err
+	}
+	batch := &leveldb.Batch{}
+	for _, ns := range namespaces {
+		batch.Put(namespaceRebuildKey(ledgerID, ns), proto.EncodeVarint(replayedHeight))
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+// getNamespacesRebuildProgress returns the number of blocks replayed for the namespaces of the ledger recorded via
+// addNamespacesToRebuild. The returned bool is false if the rebuild of the namespaces has not started yet
+func (s *idStore) getNamespacesRebuildProgress(ledgerID string) (uint64, bool, error) {
+	namespaces, err := s.getNamespacesToRebuild(ledgerID)
+	if err != nil || len(namespaces) == 0 {
+		return 0, false, err
+	}
+	// the progress is recorded against all the namespaces together, hence, reading any of them suffices
+	return s.getProgress(namespaceRebuildKey(ledgerID, namespaces[0]), ledgerID)
+}
+
+// markRebuildInProgress records the rebuild of the databases of the given ledgers as started, with no block replayed
+func (s *idStore) markRebuildInProgress(ledgerIDs []string) error {
+	batch := &leveldb.Batch{}
+	for _, ledgerID := range ledgerIDs {
+		batch.Put(rebuildProgressKey(ledgerID), proto.EncodeVarint(0))
+	}
+	return s.db.WriteBatch(batch, true)
+}
+
+// updateRebuildProgress records the number of blocks replayed into the databases of the ledger being rebuilt
+func (s *idStore) updateRebuildProgress(ledgerID string, replayedHeight uint64) error {
+	return s.db.Put(rebuildProgressKey(ledgerID), proto.EncodeVarint(replayedHeight), true)
+}
+
+// getRebuildProgress returns the number of blocks replayed into the databases of the ledger being rebuilt. The
+// returned bool is false if the databases of the ledger are not being rebuilt
+func (s *idStore) getRebuildProgress(ledgerID string) (uint64, bool, error) {
+	return s.getProgress(rebuildProgressKey(ledgerID), ledgerID)
+}
+
+// getLedgersWithRebuildInProgress returns the IDs of the ledgers marked via markRebuildInProgress and not cleared yet
+func (s *idStore) getLedgersWithRebuildInProgress() ([]string, error) {
+	var ids []string
+	itr := s.db.GetIterator(rebuildProgressKeyPrefix, rebuildProgressKeyStop)
+	defer itr.Release()
+	for itr.Next() {
+		ids = append(ids, string(itr.Key()[len(rebuildProgressKeyPrefix):]))
+	}
+	if err := itr.Error(); err != nil {
+		return nil, errors.Wrap(err, "error while reading the ledgers with rebuild in progress")
+	}
+	return ids, nil
+}
+
+func (s *idStore) clearRebuildProgress(ledgerID string) error {
+	return s.db.Delete(rebuildProgressKey(ledgerID), true)
+}
+
+func (s *idStore) getProgress(key []byte, ledgerID string) (uint64, bool, error) {
+	val, err := s.db.Get(key)
+	if len(val) == 0 || err != nil {
+		return 0, false, err
+	}
+	replayedHeight, n := proto.DecodeVarint(val)
+	if n == 0 {
+		return 0, false, errors.Errorf("invalid rebuild progress recorded for ledger [%s]", ledgerID)
+	}
+	return replayedHeight, true, nil
+}
+
+// markRawImport records that the blocks are appended to the ledger via AppendBlockRaw
+func (s *idStore) markRawImport(ledgerID string) error {
+	return s.db.Put(rawImportKey(ledgerID), []byte{}, true)
+}
+
+// isRawImport returns true if the blocks are appended to the ledger via AppendBlockRaw
+func (s *idStore) isRawImport(ledgerID string) (bool, error) {
+	val, err := s.db.Get(rawImportKey(ledgerID))
+	if err != nil {
+		return false, err
+	}
+	return val != nil, nil
+}
+
+// getCreationTime returns the time at which the ledger ID was created. A zero time is returned if the
+// creation time is not recorded for the ledger
+func (s *idStore) getCreationTime(ledgerID string) (time.Time, error) {
+	val, err := s.db.Get(creationTimeKey(ledgerID))
+	if val == nil || err != nil {
+		return time.Time{}, err
+	}
+	nanos, n := proto.DecodeVarint(val)
+	if n == 0 {
+		return time.Time{}, errors.Errorf("invalid creation time recorded for ledger [%s]", ledgerID)
+	}
+	return time.Unix(0, int64(nanos)), nil
 }
 
 func (s *idStore) updateLedgerStatus(ledgerID string, newStatus msgs.Status) error {
@@ -659,6 +1598,30 @@ func (s *idStore) updateLedgerStatus(ledgerID string, newStatus msgs.Status) err
 	return s.db.Put(key, metadataBytes, true)
 }
 
+// updateLedgerStatuses updates the status of all the given ledgers atomically
+func (s *idStore) updateLedgerStatuses(ledgerIDs []string, newStatus msgs.Status) error {
+	batch := &leveldb.Batch{}
+	for _, ledgerID := range ledgerIDs {
+		metadata, err := s.getLedgerMetadata(ledgerID)
+		if err != nil {
+			return err
+		}
+		if metadata == nil {
+			logger.Errorf("LedgerID [%s] does not exist", ledgerID)
+			return errors.Errorf("cannot update ledger status, ledger [%s] does not exist", ledgerID)
+		}
+		metadata.Status = newStatus
+		metadataBytes, err := proto.Marshal(metadata)
+		if err != nil {
+			logger.Errorf("Error marshalling ledger metadata: %s", err)
+			return errors.Wrapf(err, "error marshalling ledger metadata")
+		}
+		batch.Put(metadataKey(ledgerID), metadataBytes)
+	}
+	logger.Infof("Updating status of ledgers %s to [%s]", ledgerIDs, newStatus)
+	return s.db.WriteBatch(batch, true)
+}
+
 func (s *idStore) getLedgerMetadata(ledgerID string) (*msgs.LedgerMetadata, error) {
 	val, err := s.db.Get(metadataKey(ledgerID))
 	if val == nil || err != nil {
@@ -681,6 +1644,28 @@ func (s *idStore) ledgerIDExists(ledgerID string) (bool, error) {
 	return val != nil, nil
 }
 
+// ledgerIDsExist checks the existence of all the given ledger IDs using a single iterator over the metadata keys,
+// so that all the IDs are checked against a consistent view of the idStore
+func (s *idStore) ledgerIDsExist(ledgerIDs []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(ledgerIDs))
+	for _, id := range ledgerIDs {
+		exists[id] = false
+	}
+	itr := s.db.GetIterator(metadataKeyPrefix, metadataKeyStop)
+	defer itr.Release()
+	for itr.Error() == nil && itr.Next() {
+		id := ledgerIDFromMetadataKey(itr.Key())
+		if _, ok := exists[id]; ok {
+			exists[id] = true
+		}
+	}
+	if err := itr.Error(); err != nil {
+		logger.Errorf("Error checking the existence of ledger ids in idStore: %s", err)
+		return nil, errors.Wrapf(err, "error checking the existence of ledger ids in idStore")
+	}
+	return exists, nil
+}
+
 func (s *idStore) getActiveAndInactiveLedgerIDs() ([]string, error) {
 	return s.getLedgerIDs(
 		map[msgs.Status]struct{}{
@@ -698,6 +1683,33 @@ func (s *idStore) getActiveLedgerIDs() ([]string, error) {
 	)
 }
 
+func (s *idStore) getActiveLedgerIDsTolerant() ([]string, []CorruptLedgerRef, error) {
+	var ids []string
+	var skipped []CorruptLedgerRef
+	itr := s.db.GetIterator(metadataKeyPrefix, metadataKeyStop)
+	defer itr.Release()
+	for itr.Error() == nil && itr.Next() {
+		id := ledgerIDFromMetadataKey(itr.Key())
+		metadata := &msgs.LedgerMetadata{}
+		if err := proto.Unmarshal(itr.Value(), metadata); err != nil {
+			logger.Errorw("Skipping ledger with corrupt metadata", "ledgerID", id, "error", err)
+			skipped = append(skipped, CorruptLedgerRef{
+				LedgerID: id,
+				Err:      errors.Wrapf(err, "error unmarshalling ledger metadata"),
+			})
+			continue
+		}
+		if metadata.Status == msgs.Status_ACTIVE {
+			ids = append(ids, id)
+		}
+	}
+	if err := itr.Error(); err != nil {
+		logger.Errorf("Error getting ledger ids from idStore: %s", err)
+		return nil, nil, errors.Wrapf(err, "error getting ledger ids from idStore")
+	}
+	return ids, skipped, nil
+}
+
 func (s *idStore) getLedgerIDs(filterIn map[msgs.Status]struct{}) ([]string, error) {
 	var ids []string
 	itr := s.db.GetIterator(metadataKeyPrefix, metadataKeyStop)
@@ -728,6 +1740,30 @@ func ledgerIDFromGenesisBlockKey(key []byte) string {
 	return string(key[len(genesisBlkKeyPrefix):])
 }
 
+func creationTimeKey(ledgerID string) []byte {
+	return append(creationTimeKeyPrefix, []byte(ledgerID)...)
+}
+
+func namespaceRebuildKey(ledgerID, namespace string) []byte {
+	startKey, _ := namespaceRebuildKeyRange(ledgerID)
+	return append(startKey, []byte(namespace)...)
+}
+
+func namespaceRebuildKeyRange(ledgerID string) ([]byte, []byte) {
+	k := append(namespaceRebuildKeyPrefix, []byte(ledgerID)...)
+	startKey := append(append([]byte{}, k...), 0x00)
+	endKey := append(append([]byte{}, k...), 0x01)
+	return startKey, endKey
+}
+
+func rebuildProgressKey(ledgerID string) []byte {
+	return append(rebuildProgressKeyPrefix, []byte(ledgerID)...)
+}
+
+func rawImportKey(ledgerID string) []byte {
+	return append(rawImportKeyPrefix, []byte(ledgerID)...)
+}
+
 func metadataKey(ledgerID string) []byte {
 	return append(metadataKeyPrefix, []byte(ledgerID)...)
 }
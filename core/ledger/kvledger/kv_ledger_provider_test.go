@@ -8,9 +8,11 @@ package kvledger
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -30,6 +32,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
 	"github.com/hyperledger/fabric/core/ledger/mock"
 	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -117,6 +120,138 @@ func testLedgerProvider(t *testing.T, enableHistoryDB bool) {
 	require.EqualError(t, err, "cannot open ledger [ledger_000010], ledger does not exist")
 }
 
+func TestProviderAndLedgerDoubleClose(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(0))
+	lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+
+	lgr.Close()
+	require.NotPanics(t, lgr.Close)
+	expectedErr := &ledger.LedgerClosedError{LedgerID: constructTestLedgerID(0)}
+	_, err = lgr.GetBlockchainInfo()
+	require.Equal(t, expectedErr, err)
+	_, err = lgr.NewQueryExecutor()
+	require.Equal(t, expectedErr, err)
+	require.Equal(t, expectedErr, lgr.SubmitSnapshotRequest(10))
+
+	provider.Close()
+	require.NotPanics(t, provider.Close)
+
+	_, err = provider.List()
+	require.Equal(t, &ProviderClosedError{}, err)
+	_, err = provider.Exists(constructTestLedgerID(0))
+	require.Equal(t, &ProviderClosedError{}, err)
+	_, err = provider.Open(constructTestLedgerID(0))
+	require.Equal(t, &ProviderClosedError{}, err)
+	genesisBlock, _ = configtxtest.MakeGenesisBlock(constructTestLedgerID(1))
+	_, err = provider.CreateFromGenesisBlock(genesisBlock)
+	require.EqualError(t, err, "ledger provider is closed")
+}
+
+func TestCreateFromGenesisBlocks(t *testing.T) {
+	constructGenesisBlocks := func(t *testing.T, numBlocks int) ([]*common.Block, []string) {
+		var genesisBlocks []*common.Block
+		var ledgerIDs []string
+		for i := 0; i < numBlocks; i++ {
+			ledgerID := constructTestLedgerID(i)
+			genesisBlock, err := configtxtest.MakeGenesisBlock(ledgerID)
+			require.NoError(t, err)
+			genesisBlocks = append(genesisBlocks, genesisBlock)
+			ledgerIDs = append(ledgerIDs, ledgerID)
+		}
+		return genesisBlocks, ledgerIDs
+	}
+
+	t.Run("success", func(t *testing.T) {
+		provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		genesisBlocks, ledgerIDs := constructGenesisBlocks(t, 5)
+
+		lgrs, err := provider.CreateFromGenesisBlocks(genesisBlocks)
+		require.NoError(t, err)
+		require.Len(t, lgrs, 5)
+		for i, lgr := range lgrs {
+			bcInfo, err := lgr.GetBlockchainInfo()
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), bcInfo.Height)
+			require.Equal(t, protoutil.BlockHeaderHash(genesisBlocks[i].Header), bcInfo.CurrentBlockHash)
+			verifyLedgerIDExists(t, provider, ledgerIDs[i], msgs.Status_ACTIVE)
+			lgr.Close()
+		}
+		activeLedgerIDs, err := provider.List()
+		require.NoError(t, err)
+		require.ElementsMatch(t, ledgerIDs, activeLedgerIDs)
+	})
+
+	t.Run("malformed-genesis-block", func(t *testing.T) {
+		provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		genesisBlocks, ledgerIDs := constructGenesisBlocks(t, 5)
+		genesisBlocks[2].Data.Data[0] = []byte("malformed-envelope")
+
+		_, err := provider.CreateFromGenesisBlocks(genesisBlocks)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "error while extracting ledger ID from the genesis block at index [2]")
+		for _, ledgerID := range ledgerIDs {
+			verifyLedgerDoesNotExist(t, provider, ledgerID)
+		}
+	})
+
+	t.Run("commit-failure-rolls-back-the-set", func(t *testing.T) {
+		conf := testConfig(t)
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		genesisBlocks, ledgerIDs := constructGenesisBlocks(t, 5)
+		genesisBlocks[2].Header.Number = 1
+
+		_, err := provider.CreateFromGenesisBlocks(genesisBlocks)
+		require.EqualError(t, err, "expected block number=0, received block number=1")
+		for _, ledgerID := range ledgerIDs {
+			verifyLedgerDoesNotExist(t, provider, ledgerID)
+		}
+
+		// the set can be created again after fixing the malformed block
+		genesisBlocks[2].Header.Number = 0
+		lgrs, err := provider.CreateFromGenesisBlocks(genesisBlocks)
+		require.NoError(t, err)
+		require.Len(t, lgrs, 5)
+		for i, lgr := range lgrs {
+			verifyLedgerIDExists(t, provider, ledgerIDs[i], msgs.Status_ACTIVE)
+			lgr.Close()
+		}
+	})
+
+	t.Run("duplicate-ledger-ids", func(t *testing.T) {
+		provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		genesisBlocks, ledgerIDs := constructGenesisBlocks(t, 3)
+		genesisBlocks = append(genesisBlocks, genesisBlocks[1])
+
+		_, err := provider.CreateFromGenesisBlocks(genesisBlocks)
+		require.EqualError(t, err, "duplicate ledger ID [ledger_000001] in the genesis blocks at index [1] and [3]")
+		for _, ledgerID := range ledgerIDs {
+			verifyLedgerDoesNotExist(t, provider, ledgerID)
+		}
+	})
+
+	t.Run("ledger-already-exists", func(t *testing.T) {
+		provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		genesisBlocks, ledgerIDs := constructGenesisBlocks(t, 3)
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlocks[2])
+		require.NoError(t, err)
+		lgr.Close()
+
+		_, err = provider.CreateFromGenesisBlocks(genesisBlocks)
+		require.EqualError(t, err, "ledger [ledger_000002] already exists")
+		verifyLedgerDoesNotExist(t, provider, ledgerIDs[0])
+		verifyLedgerDoesNotExist(t, provider, ledgerIDs[1])
+		verifyLedgerIDExists(t, provider, ledgerIDs[2], msgs.Status_ACTIVE)
+	})
+}
+
 func TestGetLedger(t *testing.T) {
 	conf := testConfig(t)
 	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
@@ -171,22 +306,676 @@ func TestLedgerMetataDataUnmarshalError(t *testing.T) {
 	require.ErrorContains(t, err, "error unmarshalling ledger metadata")
 }
 
+func TestExistsBatch(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	for i := 0; i < 3; i++ {
+		genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(i))
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+		lgr.Close()
+	}
+
+	exists, err := provider.ExistsBatch([]string{
+		constructTestLedgerID(0),
+		"missing-ledger-1",
+		constructTestLedgerID(2),
+		constructTestLedgerID(0),
+		"missing-ledger-2",
+		"missing-ledger-1",
+	})
+	require.NoError(t, err)
+	require.Equal(t,
+		map[string]bool{
+			constructTestLedgerID(0): true,
+			constructTestLedgerID(2): true,
+			"missing-ledger-1":       false,
+			"missing-ledger-2":       false,
+		},
+		exists,
+	)
+
+	exists, err = provider.ExistsBatch(nil)
+	require.NoError(t, err)
+	require.Empty(t, exists)
+
+	provider.idStore.db.Close()
+	_, err = provider.ExistsBatch([]string{constructTestLedgerID(0)})
+	require.EqualError(t, err, "error checking the existence of ledger ids in idStore: leveldb: closed")
+}
+
+func TestLedgerStatus(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	for i := 0; i < 2; i++ {
+		genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(i))
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+		lgr.Close()
+	}
+	require.NoError(t, provider.idStore.updateLedgerStatus(constructTestLedgerID(1), msgs.Status_INACTIVE))
+
+	status, exists, err := provider.LedgerStatus(constructTestLedgerID(0))
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, msgs.Status_ACTIVE, status)
+
+	status, exists, err = provider.LedgerStatus(constructTestLedgerID(1))
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, msgs.Status_INACTIVE, status)
+
+	status, exists, err = provider.LedgerStatus("non-existent-ledger")
+	require.NoError(t, err)
+	require.False(t, exists)
+	require.Equal(t, msgs.Status(0), status)
+
+	provider.Close()
+	_, _, err = provider.LedgerStatus(constructTestLedgerID(0))
+	require.EqualError(t, err, "ledger provider is closed")
+}
+
+func TestListWithMetadata(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	beforeCreation := time.Now().Add(-time.Second)
+	for i := 0; i < 2; i++ {
+		genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(i))
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+		lgr.Close()
+	}
+	require.NoError(t, provider.idStore.updateLedgerStatus(constructTestLedgerID(1), msgs.Status_INACTIVE))
+	// a ledger created by an older version of the peer does not have the creation time recorded
+	require.NoError(t, provider.idStore.db.Delete(creationTimeKey(constructTestLedgerID(1)), true))
+
+	infos, err := provider.ListWithMetadata()
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+
+	require.Equal(t, constructTestLedgerID(0), infos[0].LedgerID)
+	require.Equal(t, msgs.Status_ACTIVE, infos[0].Status)
+	require.Equal(t, uint64(1), infos[0].Height)
+	require.True(t, infos[0].CreationTime.After(beforeCreation))
+	require.True(t, infos[0].LastCommitTime.After(beforeCreation))
+
+	require.Equal(t, constructTestLedgerID(1), infos[1].LedgerID)
+	require.Equal(t, msgs.Status_INACTIVE, infos[1].Status)
+	require.Equal(t, uint64(1), infos[1].Height)
+	require.True(t, infos[1].CreationTime.IsZero())
+
+	provider.Close()
+	_, err = provider.ListWithMetadata()
+	require.EqualError(t, err, "ledger provider is closed")
+}
+
+func TestListTolerant(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	numLedgers := 4
+	for i := 0; i < numLedgers; i++ {
+		genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(i))
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+		lgr.Close()
+	}
+
+	// put invalid bytes for the metatdata key of one of the ledgers
+	corruptLedgerID := constructTestLedgerID(2)
+	require.NoError(t, provider.idStore.db.Put(metadataKey(corruptLedgerID), []byte("invalid"), true))
+
+	_, err := provider.List()
+	require.ErrorContains(t, err, "error unmarshalling ledger metadata")
+
+	ids, skipped, err := provider.ListTolerant()
+	require.NoError(t, err)
+	require.Equal(t,
+		[]string{constructTestLedgerID(0), constructTestLedgerID(1), constructTestLedgerID(3)},
+		ids,
+	)
+	require.Len(t, skipped, 1)
+	require.Equal(t, corruptLedgerID, skipped[0].LedgerID)
+	require.ErrorContains(t, skipped[0].Err, "error unmarshalling ledger metadata")
+
+	// close idStore to trigger db error
+	provider.idStore.close()
+	_, _, err = provider.ListTolerant()
+	require.EqualError(t, err, "error getting ledger ids from idStore: leveldb: closed")
+}
+
+func TestApproximateKeyCounts(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	counts, err := provider.ApproximateKeyCounts("testLedger")
+	require.NoError(t, err)
+	require.Equal(t, &DBKeyCounts{}, counts)
+
+	numBlocks, numKeysPerBlock := 5, 200
+	for i := 1; i <= numBlocks; i++ {
+		pubKVs := map[string]string{}
+		for j := 0; j < numKeysPerBlock; j++ {
+			pubKVs[fmt.Sprintf("key-%d-%d", i, j)] = fmt.Sprintf("value-%d-%d", i, j)
+		}
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i), pubKVs, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+
+	counts, err = provider.ApproximateKeyCounts("testLedger")
+	require.NoError(t, err)
+	expectedCount := numBlocks * numKeysPerBlock
+	require.InEpsilon(t, expectedCount, counts.StateDB, 0.1)
+	require.InEpsilon(t, expectedCount, counts.HistoryDB, 0.1)
+
+	_, err = provider.ApproximateKeyCounts("non-existing-ledger")
+	require.EqualError(t, err, "cannot estimate key counts for ledger [non-existing-ledger], ledger does not exist")
+
+	provider.historydbProvider.Close()
+	_, err = provider.ApproximateKeyCounts("testLedger")
+	require.EqualError(t, err,
+		"error while estimating key count in the history database for ledger [testLedger]: internal leveldb error while obtaining db iterator: leveldb: closed",
+	)
+}
+
+func TestLedgerCreationListener(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	listener := &mock.LedgerCreationListener{}
+	provider.initializer.LedgerCreationListener = listener
+
+	genesisBlock, err := configtxtest.MakeGenesisBlock("ledger-1")
+	require.NoError(t, err)
+	lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	lgr.Close()
+	require.Equal(t, 1, listener.HandleLedgerCreatedCallCount())
+	ledgerID, genesisBlockHash := listener.HandleLedgerCreatedArgsForCall(0)
+	require.Equal(t, "ledger-1", ledgerID)
+	require.Equal(t, protoutil.BlockHeaderHash(genesisBlock.Header), genesisBlockHash)
+
+	// a failed creation does not notify the listener
+	_, err = provider.CreateFromGenesisBlock(genesisBlock)
+	require.EqualError(t, err, "ledger [ledger-1] already exists with state [ACTIVE]")
+	require.Equal(t, 1, listener.HandleLedgerCreatedCallCount())
+
+	// an error from the listener does not roll back the ledger
+	listener.HandleLedgerCreatedReturns(errors.New("listener-error"))
+	genesisBlock2, err := configtxtest.MakeGenesisBlock("ledger-2")
+	require.NoError(t, err)
+	genesisBlock3, err := configtxtest.MakeGenesisBlock("ledger-3")
+	require.NoError(t, err)
+	lgrs, err := provider.CreateFromGenesisBlocks([]*common.Block{genesisBlock2, genesisBlock3})
+	require.NoError(t, err)
+	for _, l := range lgrs {
+		l.Close()
+	}
+	require.Equal(t, 3, listener.HandleLedgerCreatedCallCount())
+	ledgerID, genesisBlockHash = listener.HandleLedgerCreatedArgsForCall(1)
+	require.Equal(t, "ledger-2", ledgerID)
+	require.Equal(t, protoutil.BlockHeaderHash(genesisBlock2.Header), genesisBlockHash)
+	ledgerID, _ = listener.HandleLedgerCreatedArgsForCall(2)
+	require.Equal(t, "ledger-3", ledgerID)
+
+	ledgerIDs, err := provider.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"ledger-1", "ledger-2", "ledger-3"}, ledgerIDs)
+}
+
+func TestCreateFromGenesisBlockWithID(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	genesisBlock, err := configtxtest.MakeGenesisBlock("channel-1")
+	require.NoError(t, err)
+	lgr, err := provider.CreateFromGenesisBlockWithID("custom-id", genesisBlock)
+	require.NoError(t, err)
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), bcInfo.Height)
+	lgr.Close()
+
+	ledgerIDs, err := provider.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"custom-id"}, ledgerIDs)
+	exists, err := provider.Exists("channel-1")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = provider.CreateFromGenesisBlockWithID("custom-id", genesisBlock)
+	require.EqualError(t, err, "ledger [custom-id] already exists with state [ACTIVE]")
+
+	_, err = provider.CreateFromGenesisBlockWithID("custom-id-2", &common.Block{})
+	require.Error(t, err)
+
+	// a failure in committing the genesis block deletes the partially created ledger
+	invalidGenesisBlock, err := configtxtest.MakeGenesisBlock("channel-1")
+	require.NoError(t, err)
+	invalidGenesisBlock.Header.Number = 1
+	_, err = provider.CreateFromGenesisBlockWithID("custom-id-2", invalidGenesisBlock)
+	require.EqualError(t, err, "expected block number=0, received block number=1")
+	exists, err = provider.Exists("custom-id-2")
+	require.NoError(t, err)
+	require.False(t, exists)
+	blockStoreExists, err := provider.blkStoreProvider.Exists("custom-id-2")
+	require.NoError(t, err)
+	require.False(t, blockStoreExists)
+
+	lgr, err = provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	lgr.Close()
+	ledgerIDs, err = provider.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"channel-1", "custom-id"}, ledgerIDs)
+
+	lgr, err = provider.Open("custom-id")
+	require.NoError(t, err)
+	lgr.Close()
+}
+
+func TestRelaxedGenesisValidation(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	// a genesis block without any transaction
+	genesisBlock := testutil.NewBlock(nil, 0, nil)
+	_, err := provider.CreateFromGenesisBlockWithID("test-ledger", genesisBlock)
+	require.EqualError(t, err, "failed to retrieve channel id - block is empty")
+
+	provider.initializer.GenesisValidation = ledger.GenesisValidationRelaxed
+	lgr, err := provider.CreateFromGenesisBlockWithID("test-ledger", genesisBlock)
+	require.NoError(t, err)
+	lgr.Close()
+
+	lgr, err = provider.Open("test-ledger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	sim, err := lgr.NewTxSimulator("txid-1")
+	require.NoError(t, err)
+	require.NoError(t, sim.SetState("ns", "key1", []byte("value1")))
+	sim.Done()
+	simRes, err := sim.GetTxSimulationResults()
+	require.NoError(t, err)
+	pubSimBytes, err := simRes.GetPubSimulationBytes()
+	require.NoError(t, err)
+	block1 := testutil.ConstructBlock(t, 1, protoutil.BlockHeaderHash(genesisBlock.Header), [][]byte{pubSimBytes}, false)
+	require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: block1}, &ledger.CommitOptions{}))
+
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), bcInfo.Height)
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+	val, err := qe.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+
+	t.Run("block-number-is-enforced", func(t *testing.T) {
+		block := testutil.NewBlock(nil, 1, nil)
+		_, err := provider.CreateFromGenesisBlockWithID("test-ledger-2", block)
+		require.EqualError(t, err, "expected block number=0, received block number=1")
+	})
+
+	t.Run("data-hash-is-enforced", func(t *testing.T) {
+		block := testutil.NewBlock(nil, 0, nil)
+		block.Data.Data = append(block.Data.Data, []byte("tampered-data"))
+		_, err := provider.CreateFromGenesisBlockWithID("test-ledger-2", block)
+		require.EqualError(t, err, "data hash in the header of the genesis block does not match the block data")
+	})
+
+	exists, err := provider.Exists("test-ledger-2")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestLedgerIDValidator(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	createLedger := func(ledgerID string) error {
+		genesisBlock, err := configtxtest.MakeGenesisBlock(ledgerID)
+		require.NoError(t, err)
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+		if err != nil {
+			return err
+		}
+		lgr.Close()
+		return nil
+	}
+
+	t.Run("no-validator", func(t *testing.T) {
+		provider.initializer.LedgerIDValidator = nil
+		require.NoError(t, createLedger("Ledger_1"))
+	})
+
+	t.Run("default-validator", func(t *testing.T) {
+		provider.initializer.LedgerIDValidator = ValidateLedgerID
+		err := createLedger("ledger/2")
+		require.EqualError(t, err, "invalid ledger ID [ledger/2]: 'ledger/2' contains illegal characters")
+		verifyLedgerDoesNotExist(t, provider, "ledger/2")
+
+		genesisBlock, err := configtxtest.MakeGenesisBlock("ledger/2")
+		require.NoError(t, err)
+		_, err = provider.CreateFromGenesisBlocks([]*common.Block{genesisBlock})
+		require.EqualError(t, err, "invalid ledger ID [ledger/2]: 'ledger/2' contains illegal characters")
+		verifyLedgerDoesNotExist(t, provider, "ledger/2")
+
+		require.NoError(t, createLedger("ledger-2"))
+	})
+
+	t.Run("custom-validator-loosens-rules", func(t *testing.T) {
+		provider.initializer.LedgerIDValidator = func(ledgerID string) error {
+			if strings.ContainsAny(ledgerID, "/\\") {
+				return errors.New("path separators are not allowed")
+			}
+			return nil
+		}
+		require.NoError(t, createLedger("Ledger_3"))
+	})
+
+	t.Run("custom-validator-tightens-rules", func(t *testing.T) {
+		provider.initializer.LedgerIDValidator = func(ledgerID string) error {
+			if !strings.HasPrefix(ledgerID, "prod-") {
+				return errors.New("ledger ID must start with 'prod-'")
+			}
+			return ValidateLedgerID(ledgerID)
+		}
+		require.EqualError(t, createLedger("ledger-4"), "invalid ledger ID [ledger-4]: ledger ID must start with 'prod-'")
+		verifyLedgerDoesNotExist(t, provider, "ledger-4")
+		require.NoError(t, createLedger("prod-ledger-4"))
+	})
+}
+
+func TestNewProviderWithoutHashProvider(t *testing.T) {
+	conf := testConfig(t)
+	_, err := NewProvider(
+		&ledger.Initializer{
+			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+			MetricsProvider:               &disabled.Provider{},
+			Config:                        conf,
+		},
+	)
+	require.EqualError(t, err, "a hash provider is required for creating the ledger provider")
+
+	// the file lock is not held by the failed attempt
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	provider.Close()
+}
+
 func TestNewProviderIdStoreFormatError(t *testing.T) {
 	conf := testConfig(t)
 
 	require.NoError(t, testutil.Unzip("tests/testdata/v11/sample_ledgers/ledgersData.zip", conf.RootFSPath, false))
 
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+
 	// NewProvider fails because ledgerProvider (idStore) has old format
-	_, err := NewProvider(
+	_, err = NewProvider(
 		&ledger.Initializer{
 			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
 			MetricsProvider:               &disabled.Provider{},
 			Config:                        conf,
+			HashProvider:                  cryptoProvider,
 		},
 	)
 	require.EqualError(t, err, fmt.Sprintf("unexpected format. db info = [leveldb for channel-IDs at [%s]], data format = [], expected format = [2.0]", LedgerProviderPath(conf.RootFSPath)))
 }
 
+func TestMaxOpenLedgers(t *testing.T) {
+	if _, err := os.Stat("/proc/self/fd"); err != nil {
+		t.Skip("the open file descriptors cannot be listed on this platform")
+	}
+	conf := testConfig(t)
+	conf.MaxOpenLedgers = 2
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	chainsDir := filepath.Join(BlockStorePath(conf.RootFSPath), blkstorage.ChainsDir)
+
+	numOpenBlockfiles := func() int {
+		fds, err := ioutil.ReadDir("/proc/self/fd")
+		require.NoError(t, err)
+		count := 0
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name()))
+			if err == nil && strings.HasPrefix(target, chainsDir) {
+				count++
+			}
+		}
+		return count
+	}
+
+	lgrs := map[string]ledger.PeerLedger{}
+	bgs := map[string]*testutil.BlockGenerator{}
+	for i := 1; i <= 3; i++ {
+		ledgerID := fmt.Sprintf("ledger%d", i)
+		bg, gb := testutil.NewBlockGenerator(t, ledgerID, false)
+		lgr, err := provider.CreateFromGenesisBlock(gb)
+		require.NoError(t, err)
+		defer lgr.Close()
+		lgrs[ledgerID], bgs[ledgerID] = lgr, bg
+		require.LessOrEqual(t, numOpenBlockfiles(), 2)
+	}
+
+	expectedValues := map[string]string{}
+	for i, ledgerID := range []string{"ledger1", "ledger2", "ledger1", "ledger3", "ledger2", "ledger1"} {
+		lgr := lgrs[ledgerID]
+		value := fmt.Sprintf("value%d", i)
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bgs[ledgerID], fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": value}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		expectedValues[ledgerID] = value
+		require.LessOrEqual(t, numOpenBlockfiles(), 2)
+
+		// the reads succeed on all the ledgers, including the ones that have released their block file
+		for id, l := range lgrs {
+			bcInfo, err := l.GetBlockchainInfo()
+			require.NoError(t, err)
+			for blockNum := uint64(0); blockNum < bcInfo.Height; blockNum++ {
+				_, err := l.GetBlockByNumber(blockNum)
+				require.NoError(t, err)
+			}
+			if expectedValues[id] == "" {
+				continue
+			}
+			qe, err := l.NewQueryExecutor()
+			require.NoError(t, err)
+			val, err := qe.GetState("ns", "key1")
+			require.NoError(t, err)
+			require.Equal(t, []byte(expectedValues[id]), val)
+			qe.Done()
+		}
+		require.LessOrEqual(t, numOpenBlockfiles(), 2)
+	}
+}
+
+func TestNewProviderBlockStorageSyncMode(t *testing.T) {
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+
+	testcases := []struct {
+		config      *ledger.BlockStorageConfig
+		expectedErr string
+	}{
+		{config: &ledger.BlockStorageConfig{SyncMode: ledger.BlockStorageSyncPerN, SyncEveryN: 10}},
+		{config: &ledger.BlockStorageConfig{SyncMode: ledger.BlockStorageSyncOnClose}},
+		{
+			config:      &ledger.BlockStorageConfig{SyncMode: ledger.BlockStorageSyncPerN},
+			expectedErr: "invalid block storage configuration: syncEveryN [0] must be positive for the sync mode [PerN]",
+		},
+		{
+			config:      &ledger.BlockStorageConfig{SyncMode: "Never"},
+			expectedErr: "invalid block storage configuration: unsupported sync mode [Never]",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.config.SyncMode, func(t *testing.T) {
+			conf := testConfig(t)
+			conf.BlockStorageConfig = tc.config
+			provider, err := NewProvider(
+				&ledger.Initializer{
+					DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+					MetricsProvider:               &disabled.Provider{},
+					Config:                        conf,
+					HashProvider:                  cryptoProvider,
+				},
+			)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			provider.Close()
+		})
+	}
+}
+
+func TestNewProviderBlockStorageCompression(t *testing.T) {
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	conf := testConfig(t)
+	conf.BlockStorageConfig = &ledger.BlockStorageConfig{Compression: "zstd"}
+	_, err = NewProvider(
+		&ledger.Initializer{
+			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+			MetricsProvider:               &disabled.Provider{},
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.EqualError(t, err, "invalid block storage configuration: unsupported compression [zstd]")
+}
+
+func TestNewProviderLevelDBTuning(t *testing.T) {
+	levelDBConfig := &ledger.LevelDBConfig{
+		BloomFilterBitsPerKey: 10,
+		BlockCacheSizeMBs:     16,
+		WriteBufferSizeMBs:    8,
+		Compression:           ledger.LevelDBCompressionNone,
+	}
+	tuning, err := levelDBTuningFor(levelDBConfig)
+	require.NoError(t, err)
+	require.Equal(t,
+		&leveldbhelper.Tuning{
+			BloomFilterBitsPerKey: 10,
+			BlockCacheSize:        16 * 1024 * 1024,
+			WriteBufferSize:       8 * 1024 * 1024,
+			DisableCompression:    true,
+		},
+		tuning,
+	)
+
+	t.Run("tuned-ledger", func(t *testing.T) {
+		conf := testConfig(t)
+		conf.StateDBConfig.LevelDB = levelDBConfig
+		conf.BlockStorageConfig = &ledger.BlockStorageConfig{IndexLevelDB: levelDBConfig}
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+
+		bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+		lgr, err := provider.CreateFromGenesisBlock(gb)
+		require.NoError(t, err)
+		defer lgr.Close()
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, "SimulateForBlk1", map[string]string{"key1": "value1"}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		checkBCSummaryForTest(t, lgr,
+			&bcSummary{
+				stateDBKVs: map[string]string{"key1": "value1"},
+			},
+		)
+		blk, err := lgr.GetBlockByHash(protoutil.BlockHeaderHash(blkAndPvtdata.Block.Header))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), blk.Header.Number)
+	})
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	testcases := []struct {
+		name        string
+		modifyConf  func(conf *ledger.Config)
+		expectedErr string
+	}{
+		{
+			name: "invalid-state-compression",
+			modifyConf: func(conf *ledger.Config) {
+				conf.StateDBConfig.LevelDB = &ledger.LevelDBConfig{Compression: "zstd"}
+			},
+			expectedErr: "invalid state database configuration: unsupported leveldb compression [zstd]",
+		},
+		{
+			name: "invalid-block-index-compression",
+			modifyConf: func(conf *ledger.Config) {
+				conf.BlockStorageConfig = &ledger.BlockStorageConfig{IndexLevelDB: &ledger.LevelDBConfig{Compression: "zstd"}}
+			},
+			expectedErr: "invalid block storage configuration: unsupported leveldb compression [zstd]",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := testConfig(t)
+			tc.modifyConf(conf)
+			_, err := NewProvider(
+				&ledger.Initializer{
+					DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+					MetricsProvider:               &disabled.Provider{},
+					Config:                        conf,
+					HashProvider:                  cryptoProvider,
+				},
+			)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestNewProviderLevelDBOpenRetry(t *testing.T) {
+	conf := testConfig(t)
+	conf.LevelDBOpenRetries = 5
+	conf.LevelDBOpenRetryInterval = 50 * time.Millisecond
+	require.Equal(t,
+		leveldbhelper.OpenRetry{Retries: 5, Interval: 50 * time.Millisecond},
+		levelDBOpenRetryFor(conf),
+	)
+
+	// hold the lock of the idStore, as left behind by a process that is yet to exit
+	heldDB := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: LedgerProviderPath(conf.RootFSPath)})
+	heldDB.Open()
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		heldDB.Close()
+		close(released)
+	}()
+
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	<-released
+
+	_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	lgr.Close()
+}
+
 func TestUpgradeIDStoreFormatDBError(t *testing.T) {
 	conf := testConfig(t)
 	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
@@ -328,6 +1117,11 @@ func testDeletionOfUnderConstructionLedgersAtStart(t *testing.T, enableHistoryDB
 	provider.Close()
 	// construct a new provider to invoke recovery
 	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	select {
+	case <-provider.RecoveryCompleted():
+	default:
+		t.Fatal("recovery is expected to be completed when the provider is returned")
+	}
 	exists, err := provider.Exists(ledgerID)
 	require.NoError(t, err)
 	require.False(t, exists)
@@ -336,6 +1130,27 @@ func testDeletionOfUnderConstructionLedgersAtStart(t *testing.T, enableHistoryDB
 	require.Nil(t, m)
 }
 
+func TestOpenDuringRecovery(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	genesisBlock, err := configtxtest.MakeGenesisBlock("testLedger")
+	require.NoError(t, err)
+	lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	lgr.Close()
+
+	// mimic a provider whose recovery is in progress
+	provider.recoveryCompleted = make(chan struct{})
+	_, err = provider.Open("testLedger")
+	require.Equal(t, &ErrProviderRecovering{}, err)
+
+	close(provider.recoveryCompleted)
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	lgr.Close()
+}
+
 func TestLedgerCreationFailure(t *testing.T) {
 	conf := testConfig(t)
 	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
@@ -372,6 +1187,50 @@ func TestLedgerCreationFailureDuringLedgerDeletion(t *testing.T) {
 	verifyLedgerIDExists(t, provider, ledgerID, msgs.Status_UNDER_CONSTRUCTION)
 }
 
+func TestLedgerCreationRetryAfterFailure(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	ledgerID := "testLedger"
+	bg, genesisBlock := testutil.NewBlockGenerator(t, ledgerID, false)
+
+	// simulate a creation that committed the genesis block but crashed before marking the ledger active
+	require.NoError(t, provider.idStore.createLedgerID(ledgerID, &msgs.LedgerMetadata{Status: msgs.Status_UNDER_CONSTRUCTION}))
+	partialLgr, err := provider.open(ledgerID, nil, false, false)
+	require.NoError(t, err)
+	require.NoError(t, partialLgr.CommitLegacy(&ledger.BlockAndPvtData{Block: genesisBlock}, &ledger.CommitOptions{}))
+	partialLgr.Close()
+	verifyLedgerIDExists(t, provider, ledgerID, msgs.Status_UNDER_CONSTRUCTION)
+
+	// the creation is rejected while another creation of the same ledger is in progress
+	require.NoError(t, provider.beginCreation(ledgerID))
+	_, err = provider.CreateFromGenesisBlock(genesisBlock)
+	require.EqualError(t, err, "ledger [testLedger] is being created by another operation")
+	provider.endCreation(ledgerID)
+	verifyLedgerIDExists(t, provider, ledgerID, msgs.Status_UNDER_CONSTRUCTION)
+
+	lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	defer lgr.Close()
+	verifyLedgerIDExists(t, provider, ledgerID, msgs.Status_ACTIVE)
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), bcInfo.Height)
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+	val, err := qe.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+
+	// an active ledger is not recreated
+	_, err = provider.CreateFromGenesisBlock(genesisBlock)
+	require.EqualError(t, err, "ledger [testLedger] already exists with state [ACTIVE]")
+}
+
 func TestMultipleLedgerBasicRW(t *testing.T) {
 	conf := testConfig(t)
 	provider1 := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
@@ -564,6 +1423,95 @@ func TestLedgerBackup(t *testing.T) {
 	require.Equal(t, []byte("value1"), result2.(*queryresult.KeyModification).Value)
 }
 
+func TestReopenWithHistoryDBToggled(t *testing.T) {
+	commitBlock := func(lgr ledger.PeerLedger, bg *testutil.BlockGenerator, value string) {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, util.GenerateUUID(), map[string]string{"key1": value}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+
+	t.Run("enabled-to-disabled", func(t *testing.T) {
+		conf := testConfig(t)
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+		lgr, err := provider.CreateFromGenesisBlock(gb)
+		require.NoError(t, err)
+		commitBlock(lgr, bg, "value1")
+		lgr.Close()
+		provider.Close()
+
+		conf.HistoryDBConfig.Enabled = false
+		provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		lgr, err = provider.Open("testLedger")
+		require.NoError(t, err)
+		defer lgr.Close()
+
+		hqe, err := lgr.NewHistoryQueryExecutor()
+		require.Nil(t, hqe)
+		require.IsType(t, &ledger.HistoryDBNotEnabledError{}, err)
+		require.EqualError(t, err, "history database is not enabled for ledger [testLedger]")
+
+		commitBlock(lgr, bg, "value2")
+		checkBCSummaryForTest(t, lgr,
+			&bcSummary{
+				stateDBSavePoint: 2,
+				stateDBKVs:       map[string]string{"key1": "value2"},
+			},
+		)
+	})
+
+	t.Run("disabled-to-enabled", func(t *testing.T) {
+		conf := testConfig(t)
+		conf.HistoryDBConfig.Enabled = false
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+		lgr, err := provider.CreateFromGenesisBlock(gb)
+		require.NoError(t, err)
+		commitBlock(lgr, bg, "value1")
+		commitBlock(lgr, bg, "value2")
+		lgr.Close()
+		provider.Close()
+
+		// history db is rebuilt from the block store
+		conf.HistoryDBConfig.Enabled = true
+		provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		lgr, err = provider.Open("testLedger")
+		require.NoError(t, err)
+		checkBCSummaryForTest(t, lgr,
+			&bcSummary{
+				historyDBSavePoint: 2,
+				historyKey:         "key1",
+				historyVals:        []string{"value2", "value1"},
+			},
+		)
+		lgr.Close()
+		provider.Close()
+
+		// history db lagging behind the block store after a period with history disabled is caught up
+		conf.HistoryDBConfig.Enabled = false
+		provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		lgr, err = provider.Open("testLedger")
+		require.NoError(t, err)
+		commitBlock(lgr, bg, "value3")
+		lgr.Close()
+		provider.Close()
+
+		conf.HistoryDBConfig.Enabled = true
+		provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		lgr, err = provider.Open("testLedger")
+		require.NoError(t, err)
+		defer lgr.Close()
+		checkBCSummaryForTest(t, lgr,
+			&bcSummary{
+				historyDBSavePoint: 3,
+				historyKey:         "key1",
+				historyVals:        []string{"value3", "value2", "value1"},
+			},
+		)
+	})
+}
+
 func constructTestLedgerID(i int) string {
 	return fmt.Sprintf("ledger_%06d", i)
 }
@@ -581,7 +1529,7 @@ func constructTestLedger(t *testing.T, provider *Provider, sequenceID int) strin
 	return ledgerID
 }
 
-func testConfig(t *testing.T) (conf *ledger.Config) {
+func testConfig(t testing.TB) (conf *ledger.Config) {
 	path := t.TempDir()
 	conf = &ledger.Config{
 		RootFSPath:    path,
@@ -603,7 +1551,7 @@ func testConfig(t *testing.T) (conf *ledger.Config) {
 	return conf
 }
 
-func testutilNewProvider(conf *ledger.Config, t *testing.T, ccInfoProvider *mock.DeployedChaincodeInfoProvider) *Provider {
+func testutilNewProvider(conf *ledger.Config, t testing.TB, ccInfoProvider *mock.DeployedChaincodeInfoProvider) *Provider {
 	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
 	require.NoError(t, err)
 
@@ -7,8 +7,16 @@ SPDX-License-Identifier: Apache-2.0
 package kvledger
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/common"
@@ -16,11 +24,17 @@ import (
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/bccsp"
+	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/internal/version"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/validation"
 	"github.com/hyperledger/fabric/core/ledger/mock"
 	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
@@ -47,18 +61,54 @@ func TestMain(m *testing.M) {
 }
 
 func TestKVLedgerNilHistoryDBProvider(t *testing.T) {
-	kvl := &kvLedger{}
+	kvl := &kvLedger{ledgerID: "testLedger"}
 	qe, err := kvl.NewHistoryQueryExecutor()
 	require.Nil(
 		t,
 		qe,
 		"NewHistoryQueryExecutor should return nil when history db provider is nil",
 	)
-	require.NoError(
+	require.Equal(
 		t,
+		&ledger.HistoryDBNotEnabledError{LedgerID: "testLedger"},
 		err,
 		"NewHistoryQueryExecutor should return an error when history db provider is nil",
 	)
+	require.EqualError(t, err, "history database is not enabled for ledger [testLedger]")
+}
+
+func TestGetLastBlock(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	lastBlock, err := lgr.GetLastBlock()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(gb, lastBlock), "proto messages are not equal")
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", map[string]string{"key1": "value2"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+
+	lastBlock, err = lgr.GetLastBlock()
+	require.NoError(t, err)
+	block2, err := lgr.GetBlockByNumber(2)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(block2, lastBlock), "proto messages are not equal")
+
+	t.Run("empty-ledger", func(t *testing.T) {
+		emptyLgr, err := provider.open("empty-ledger", nil, false, false)
+		require.NoError(t, err)
+		defer emptyLgr.Close()
+		_, err = emptyLgr.GetLastBlock()
+		require.Equal(t, &ledger.EmptyLedgerError{LedgerID: "empty-ledger"}, err)
+	})
 }
 
 func TestKVLedgerBlockStorage(t *testing.T) {
@@ -227,6 +277,50 @@ func TestAddCommitHash(t *testing.T) {
 	require.Equal(t, len(commitHash), 0)
 }
 
+func TestAddCommitHashWithWorkers(t *testing.T) {
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	createLedger := func(commitHashWorkers int) ledger.PeerLedger {
+		conf := testConfig(t)
+		conf.StateDBConfig.CommitHashWorkers = commitHashWorkers
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		t.Cleanup(provider.Close)
+		lgr, err := provider.CreateFromGenesisBlock(proto.Clone(gb).(*common.Block))
+		require.NoError(t, err)
+		t.Cleanup(lgr.Close)
+		return lgr
+	}
+	lgr := createLedger(0)
+	lgrWithWorkers := createLedger(3)
+
+	for i := 0; i < 3; i++ {
+		var txs [][]byte
+		for j := 0; j < 4; j++ {
+			simulator, err := lgr.NewTxSimulator(util.GenerateUUID())
+			require.NoError(t, err)
+			for k := 0; k < 5; k++ {
+				ns := fmt.Sprintf("ns%d", (j+k)%3)
+				require.NoError(t, simulator.SetState(ns, fmt.Sprintf("key-%d-%d-%d", i, j, k), []byte("value")))
+			}
+			require.NoError(t, simulator.DeleteState("ns0", fmt.Sprintf("key-%d-0-0", i-1)))
+			simulator.Done()
+			simRes, err := simulator.GetTxSimulationResults()
+			require.NoError(t, err)
+			pubSimBytes, err := simRes.GetPubSimulationBytes()
+			require.NoError(t, err)
+			txs = append(txs, pubSimBytes)
+		}
+		block := bg.NextBlock(txs)
+		require.NoError(t, lgrWithWorkers.CommitLegacy(&ledger.BlockAndPvtData{Block: proto.Clone(block).(*common.Block)}, &ledger.CommitOptions{}))
+		require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: block}, &ledger.CommitOptions{}))
+
+		require.Len(t, lgrWithWorkers.(*kvLedger).commitHash, 32)
+		require.Equal(t, lgr.(*kvLedger).commitHash, lgrWithWorkers.(*kvLedger).commitHash)
+		commitHash, err := lgrWithWorkers.(*kvLedger).lastPersistedCommitHash()
+		require.NoError(t, err)
+		require.Equal(t, lgr.(*kvLedger).commitHash, commitHash)
+	}
+}
+
 func TestKVLedgerBlockStorageWithPvtdata(t *testing.T) {
 	t.Skip()
 	conf := testConfig(t)
@@ -511,6 +605,57 @@ func TestKVLedgerDBRecovery(t *testing.T) {
 	)
 }
 
+func TestStateDBCatchesUpWithBlockStoreOnOpen(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	ledgerID := "testLedger"
+	bg, gb := testutil.NewBlockGenerator(t, ledgerID, false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+
+	blkAndPvtdata1 := prepareNextBlockForTest(t, lgr, bg, "txid-1",
+		map[string]string{"key1": "value1.1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blkAndPvtdata1, &ledger.CommitOptions{}))
+	blkAndPvtdata2 := prepareNextBlockForTest(t, lgr, bg, "txid-2",
+		map[string]string{"key1": "value1.2", "key2": "value2.2"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blkAndPvtdata2, &ledger.CommitOptions{}))
+	lgr.Close()
+
+	// simulate the loss of the statedb flush for the last block by reverting
+	// the writes of block 2 and truncating the save point by one block
+	db, err := provider.dbProvider.GetDBHandle(ledgerID, nil)
+	require.NoError(t, err)
+	batch := privacyenabledstate.NewUpdateBatch()
+	batch.PubUpdates.Put("ns", "key1", []byte("value1.1"), version.NewHeight(1, 0))
+	batch.PubUpdates.Delete("ns", "key2", version.NewHeight(1, 0))
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(batch, version.NewHeight(1, 0)))
+	savepoint, err := db.GetLatestSavePoint()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(1, 0), savepoint)
+
+	lgr, err = provider.Open(ledgerID)
+	require.NoError(t, err)
+	savepoint, err = lgr.(*kvLedger).txmgr.GetLastSavepoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), savepoint.BlockNum)
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	for k, v := range map[string]string{"key1": "value1.2", "key2": "value2.2"} {
+		val, err := qe.GetState("ns", k)
+		require.NoError(t, err)
+		require.Equal(t, []byte(v), val)
+	}
+	qe.Done()
+	lgr.Close()
+
+	// a statedb that is ahead of the block store cannot be recovered
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(privacyenabledstate.NewUpdateBatch(), version.NewHeight(5, 0)))
+	_, err = provider.Open(ledgerID)
+	require.ErrorContains(t, err, "the state database [height=6] is ahead of the block store [height=3]")
+}
+
 func TestLedgerWithCouchDbEnabledWithBinaryAndJSONData(t *testing.T) {
 	conf := testConfig(t)
 	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
@@ -707,6 +852,48 @@ func TestPvtDataAPIs(t *testing.T) {
 	require.Equal(t, expectedMissingDataInfo, missingDataInfo)
 }
 
+func TestMissingPvtDataInfo(t *testing.T) {
+	conf := testConfig(t)
+	ccInfoProvider := &mock.DeployedChaincodeInfoProvider{}
+	ccInfoProvider.CollectionInfoReturns(&peer.StaticCollectionConfig{BlockToLive: 0}, nil)
+	ccInfoProvider.AllCollectionsConfigPkgReturns(
+		testutilCollConfigPkg([]*peer.StaticCollectionConfig{{Name: "coll"}}),
+		nil,
+	)
+	provider := testutilNewProvider(conf, t, ccInfoProvider)
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, map[string]string{"pvtkey1": "pvtvalue1"})
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	missingDataInfo, err := lgr.MissingPvtDataInfo(10)
+	require.NoError(t, err)
+	require.Empty(t, missingDataInfo)
+
+	// the private data of the eligible collection is missing and the private data of an ineligible
+	// collection, which is not reconciled, is missing too
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", map[string]string{"key1": "value2"}, map[string]string{"pvtkey1": "pvtvalue2"})
+	blk2.PvtData = nil
+	blk2.MissingPvtData = make(ledger.TxMissingPvtData)
+	blk2.MissingPvtData.Add(0, "ns", "coll", true)
+	blk2.MissingPvtData.Add(0, "ns", "coll-ineligible", false)
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+
+	expectedMissingDataInfo := make(ledger.MissingPvtDataInfo)
+	expectedMissingDataInfo.Add(2, 0, "ns", "coll")
+	missingDataInfo, err = lgr.MissingPvtDataInfo(10)
+	require.NoError(t, err)
+	require.Equal(t, expectedMissingDataInfo, missingDataInfo)
+
+	missingDataInfo, err = lgr.MissingPvtDataInfo(1)
+	require.NoError(t, err)
+	require.Empty(t, missingDataInfo)
+}
+
 func TestCrashAfterPvtdataStoreCommit(t *testing.T) {
 	conf := testConfig(t)
 	ccInfoProvider := &mock.DeployedChaincodeInfoProvider{}
@@ -758,7 +945,7 @@ func TestCrashAfterPvtdataStoreCommit(t *testing.T) {
 
 	// When starting the storage after a crash, we should be able to fetch the pvtData from pvtStore
 	testVerifyPvtData(t, lgr1, blockNumAtCrash, dataAtCrash.PvtData)
-	bcInfo, err = lgr.GetBlockchainInfo()
+	bcInfo, err = lgr1.GetBlockchainInfo()
 	require.NoError(t, err)
 	require.Equal(t, blockNumAtCrash, bcInfo.Height)
 
@@ -1112,148 +1299,863 @@ func TestCollectionConfigHistoryRetriever(t *testing.T) {
 	})
 }
 
-func TestCommitNotifications(t *testing.T) {
-	var lgr *kvLedger
-	var doneChannel chan struct{}
-	var dataChannel <-chan *ledger.CommitNotification
+func TestCollectionConfigAt(t *testing.T) {
+	conf := testConfig(t)
+	mockDeployedCCInfoProvider := &mock.DeployedChaincodeInfoProvider{}
+	provider := testutilNewProvider(conf, t, mockDeployedCCInfoProvider)
+	defer provider.Close()
 
-	setup := func() {
-		var err error
-		lgr = &kvLedger{}
-		doneChannel = make(chan struct{})
-		dataChannel, err = lgr.CommitNotificationsChannel(doneChannel)
-		require.NoError(t, err)
-	}
+	ledgerID := "testLedger"
+	bg, gb := testutil.NewBlockGenerator(t, ledgerID, false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
 
-	t.Run("only first txid is included in notification", func(t *testing.T) {
-		setup()
-		lgr.sendCommitNotification(1, []*validation.TxStatInfo{
-			{
-				TxIDFromChannelHeader: "txid_1",
-				ValidationCode:        peer.TxValidationCode_BAD_RWSET,
-				ChaincodeID:           &peer.ChaincodeID{Name: "cc1"},
-				ChaincodeEventData:    []byte("cc1_event"),
-				TxType:                common.HeaderType_ENDORSER_TRANSACTION,
-			},
+	collConfigPkgV1 := testutilCollConfigPkg(
+		[]*peer.StaticCollectionConfig{
 			{
-				TxIDFromChannelHeader: "txid_1",
-				ValidationCode:        peer.TxValidationCode_DUPLICATE_TXID,
+				Name: "coll-v1",
 			},
+		},
+	)
+	collConfigPkgV2 := testutilCollConfigPkg(
+		[]*peer.StaticCollectionConfig{
 			{
-				TxIDFromChannelHeader: "txid_2",
-				ValidationCode:        peer.TxValidationCode_VALID,
-				ChaincodeID:           &peer.ChaincodeID{Name: "cc2"},
-				ChaincodeEventData:    []byte("cc2_event"),
-				TxType:                common.HeaderType_ENDORSER_TRANSACTION,
+				Name: "coll-v2",
 			},
-		})
+		},
+	)
 
-		commitNotification := <-dataChannel
-		require.Equal(t,
-			&ledger.CommitNotification{
-				BlockNumber: 1,
-				TxsInfo: []*ledger.CommitNotificationTxInfo{
-					{
-						TxID:               "txid_1",
-						TxType:             common.HeaderType_ENDORSER_TRANSACTION,
-						ValidationCode:     peer.TxValidationCode_BAD_RWSET,
-						ChaincodeID:        &peer.ChaincodeID{Name: "cc1"},
-						ChaincodeEventData: []byte("cc1_event"),
-					},
-					{
-						TxID:               "txid_2",
-						TxType:             common.HeaderType_ENDORSER_TRANSACTION,
-						ValidationCode:     peer.TxValidationCode_VALID,
-						ChaincodeID:        &peer.ChaincodeID{Name: "cc2"},
-						ChaincodeEventData: []byte("cc2_event"),
-					},
-				},
-			},
-			commitNotification,
-		)
+	// deploy the collection config in block 1 and upgrade it in block 3
+	for blockNum := uint64(1); blockNum <= 4; blockNum++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", blockNum),
+			map[string]string{"key": fmt.Sprintf("value-%d", blockNum)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		switch blockNum {
+		case 1:
+			testutilPersistExplicitCollectionConfig(t, provider, mockDeployedCCInfoProvider, ledgerID, "ns", collConfigPkgV1, blockNum)
+		case 3:
+			testutilPersistExplicitCollectionConfig(t, provider, mockDeployedCCInfoProvider, ledgerID, "ns", collConfigPkgV2, blockNum)
+		}
+	}
+
+	t.Run("config-at-height", func(t *testing.T) {
+		expected := map[uint64]*peer.CollectionConfigPackage{
+			1: collConfigPkgV1,
+			2: collConfigPkgV1,
+			3: collConfigPkgV2,
+			4: collConfigPkgV2,
+		}
+		for blockNum, expectedPkg := range expected {
+			collConfigPkg, err := lgr.CollectionConfigAt("ns", blockNum)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(expectedPkg, collConfigPkg))
+		}
 	})
 
-	t.Run("empty txids are not included in notification", func(t *testing.T) {
-		setup()
-		lgr.sendCommitNotification(1, []*validation.TxStatInfo{
-			{
-				TxIDFromChannelHeader: "",
-				ValidationCode:        peer.TxValidationCode_BAD_RWSET,
-			},
-			{
-				TxIDFromChannelHeader: "",
-				ValidationCode:        peer.TxValidationCode_DUPLICATE_TXID,
-			},
-		})
+	t.Run("no-config-at-height", func(t *testing.T) {
+		_, err := lgr.CollectionConfigAt("ns", 0)
+		require.EqualError(t, err, "no collection config defined for namespace [ns] at block [0]")
 
-		commitNotification := <-dataChannel
-		require.Equal(t,
-			&ledger.CommitNotification{
-				BlockNumber: 1,
-				TxsInfo:     []*ledger.CommitNotificationTxInfo{},
-			},
-			commitNotification,
-		)
+		_, err = lgr.CollectionConfigAt("unknown-ns", 4)
+		require.EqualError(t, err, "no collection config defined for namespace [unknown-ns] at block [4]")
 	})
 
-	t.Run("second time calling CommitNotificationsChannel returns error", func(t *testing.T) {
-		setup()
-		_, err := lgr.CommitNotificationsChannel(make(chan struct{}))
-		require.EqualError(t, err, "only one commit notifications channel is allowed at a time")
+	t.Run("block-beyond-height", func(t *testing.T) {
+		_, err := lgr.CollectionConfigAt("ns", 5)
+		require.EqualError(t, err, "requested block number [5] is beyond the last committed block [4]")
 	})
+}
 
-	t.Run("closing done channel closes the data channel on next commit", func(t *testing.T) {
-		setup()
-		lgr.sendCommitNotification(1, []*validation.TxStatInfo{})
-		_, ok := <-dataChannel
-		require.True(t, ok)
+func TestUpdatePvtDataConfig(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
 
-		close(doneChannel)
-		lgr.sendCommitNotification(2, []*validation.TxStatInfo{})
+	_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
 
-		_, ok = <-dataChannel
-		require.False(t, ok)
+	pvtdataStore := lgr.(*kvLedger).pvtdataStore
+	require.NoError(t, lgr.UpdatePvtDataConfig(
+		&ledger.PrivateDataConfig{
+			MaxBatchSize:    10,
+			BatchesInterval: 20,
+			PurgeInterval:   30,
+		},
+	))
+	require.Equal(t,
+		&ledger.PrivateDataConfig{
+			MaxBatchSize:    10,
+			BatchesInterval: 20,
+			PurgeInterval:   30,
+		},
+		pvtdataStore.Config(),
+	)
+
+	t.Run("invalid-config", func(t *testing.T) {
+		require.EqualError(t, lgr.UpdatePvtDataConfig(nil), "private data config must not be nil")
+		require.EqualError(t,
+			lgr.UpdatePvtDataConfig(&ledger.PrivateDataConfig{MaxBatchSize: -1, BatchesInterval: 20, PurgeInterval: 30}),
+			"invalid max batch size [-1], it should be a non-negative integer",
+		)
+		require.EqualError(t,
+			lgr.UpdatePvtDataConfig(&ledger.PrivateDataConfig{MaxBatchSize: 10, BatchesInterval: -1, PurgeInterval: 30}),
+			"invalid batches interval [-1], it should be a non-negative integer",
+		)
+		require.EqualError(t,
+			lgr.UpdatePvtDataConfig(&ledger.PrivateDataConfig{MaxBatchSize: 10, BatchesInterval: 20, PurgeInterval: 0}),
+			"invalid purge interval [0], it should be a positive integer",
+		)
+		require.Equal(t, 30, pvtdataStore.Config().PurgeInterval)
 	})
 }
 
-func TestCommitNotificationsOnBlockCommit(t *testing.T) {
+func TestPruneBlocks(t *testing.T) {
 	conf := testConfig(t)
 	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
 	defer provider.Close()
 
-	_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
-	l, err := provider.CreateFromGenesisBlock(gb)
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
 	require.NoError(t, err)
-	defer l.Close()
+	defer lgr.Close()
 
-	lgr := l.(*kvLedger)
-	doneChannel := make(chan struct{})
-	dataChannel, err := lgr.CommitNotificationsChannel(doneChannel)
+	blocks := []*common.Block{gb}
+	for i := 1; i < 5; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{"key": fmt.Sprintf("value-%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		blocks = append(blocks, blkAndPvtdata.Block)
+	}
+
+	require.EqualError(t, lgr.PruneBlocks(5),
+		"cannot prune blocks before block [5]. The block store height = [5], the last block cannot be pruned",
+	)
+	require.NoError(t, lgr.PruneBlocks(2))
+
+	for _, blockNum := range []uint64{0, 1} {
+		_, err := lgr.GetBlockByNumber(blockNum)
+		require.Equal(t, &blkstorage.ErrBlockPruned{FirstAvailableBlock: 2}, err)
+		_, err = lgr.GetBlockByHash(protoutil.BlockHeaderHash(blocks[blockNum].Header))
+		require.Equal(t, &blkstorage.ErrBlockPruned{FirstAvailableBlock: 2}, err)
+	}
+	txID, err := protoutil.GetOrComputeTxIDFromEnvelope(blocks[1].Data.Data[0])
 	require.NoError(t, err)
+	_, err = lgr.GetTransactionByID(txID)
+	require.Equal(t, &blkstorage.ErrBlockPruned{FirstAvailableBlock: 2}, err)
 
-	s, err := lgr.NewTxSimulator("")
+	for blockNum := uint64(2); blockNum < 5; blockNum++ {
+		b, err := lgr.GetBlockByNumber(blockNum)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(blocks[blockNum], b))
+	}
+	txID, err = protoutil.GetOrComputeTxIDFromEnvelope(blocks[2].Data.Data[0])
 	require.NoError(t, err)
-	_, err = s.GetState("ns1", "key1")
+	_, err = lgr.GetTransactionByID(txID)
 	require.NoError(t, err)
-	require.NoError(t, s.SetState("ns1", "key1", []byte("val1")))
-	sr, err := s.GetTxSimulationResults()
+}
+
+func TestPrunedBlocksRecovery(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
 	require.NoError(t, err)
-	srBytes, err := sr.GetPubSimulationBytes()
+	for i := 1; i < 5; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{"key": fmt.Sprintf("value-%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+	require.NoError(t, lgr.PruneBlocks(3))
+	provider.Close()
+
+	// the state DB cannot be rebuilt from the blocks of a pruned ledger
+	require.NoError(t, os.RemoveAll(StateDBPath(conf.RootFSPath)))
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	_, err = provider.Open("testLedger")
+	require.EqualError(t, err,
+		"recovery for DB [state] not possible. The blocks of ledger [testLedger] are pruned. First available block = [3], DB needs block [0] onward",
+	)
+}
+
+func TestRetainBlocksBoundedByDBSavepoints(t *testing.T) {
+	conf := testConfig(t)
+	conf.BlockStorageConfig = &ledger.BlockStorageConfig{
+		RetainBlocks:        2,
+		MaxBlockfileSizeMBs: 1,
+	}
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
 	require.NoError(t, err)
-	s.Done()
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
 
-	block := testutil.ConstructBlockFromBlockDetails(
-		t, &testutil.BlockDetails{
-			BlockNum:     1,
-			PreviousHash: protoutil.BlockHeaderHash(gb.Header),
-			Txs: []*testutil.TxDetails{
-				{
-					Type:              common.HeaderType_ENDORSER_TRANSACTION,
-					TxID:              "txid_1",
-					ChaincodeName:     "foo",
-					ChaincodeVersion:  "v1",
-					SimulationResults: srBytes,
-					ChaincodeEvents:   []byte("foo-event"),
+	// the blocks of about 400 KB each spread over block files of 1 MB
+	commitBlocks := func(from, to int) {
+		for i := from; i <= to; i++ {
+			blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+				map[string]string{"key": strings.Repeat("v", 400*1024)}, nil)
+			blkAndPvtdata.Block.Metadata.Metadata[common.BlockMetadataIndex_SIGNATURES] = protoutil.MarshalOrPanic(
+				&common.Metadata{
+					Value: protoutil.MarshalOrPanic(&common.OrdererBlockMetadata{
+						LastConfig: &common.LastConfig{Index: uint64(i)},
+					}),
+				},
+			)
+			require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		}
+	}
+
+	commitBlocks(1, 4)
+	require.Equal(t, uint64(3), kvlgr.blockStore.FirstAvailableBlockNumber())
+
+	// the history DB needs the block 5 onward and holds back the pruning
+	require.NoError(t, lgr.RollbackHistoryDB(4))
+	commitBlocks(5, 8)
+	require.Equal(t, uint64(5), kvlgr.blockStore.FirstAvailableBlockNumber())
+
+	require.NoError(t, lgr.CatchUpHistoryDB())
+	commitBlocks(9, 9)
+	require.Equal(t, uint64(8), kvlgr.blockStore.FirstAvailableBlockNumber())
+}
+
+func TestPrunedBlocksPvtdataReconciliation(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
+	kvlgr.pvtdataStore.Init(btlPolicyForSampleData())
+	for _, sampleDatum := range sampleDataWithPvtdataForSelectiveTx(t, bg) {
+		require.NoError(t, kvlgr.commitToPvtAndBlockStore(sampleDatum, nil))
+	}
+
+	// block 6 misses the pvtdata of tx 4 and tx 5
+	missingDataInfo, err := kvlgr.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	require.NoError(t, err)
+	require.Contains(t, missingDataInfo, uint64(6))
+
+	require.NoError(t, lgr.PruneBlocks(7))
+	missingDataInfo, err = kvlgr.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	require.NoError(t, err)
+	require.Empty(t, missingDataInfo)
+
+	// the pvtdata of a pruned block cannot be verified against the block and is skipped
+	hashMismatches, err := lgr.CommitPvtDataOfOldBlocks(
+		[]*ledger.ReconciledPvtdata{
+			{
+				BlockNum:  6,
+				WriteSets: samplePvtData(t, []uint64{4}),
+			},
+		},
+		nil,
+	)
+	require.NoError(t, err)
+	require.Empty(t, hashMismatches)
+	pvtdata, err := lgr.GetPvtDataByNum(6, nil)
+	require.NoError(t, err)
+	require.Empty(t, pvtdata)
+}
+
+func TestArchiveBlocks(t *testing.T) {
+	conf := testConfig(t)
+	conf.BlockStorageConfig = &ledger.BlockStorageConfig{
+		Archiver: blkstorage.NewDirArchiver(filepath.Join(conf.RootFSPath, "archive")),
+	}
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blocks := []*common.Block{gb}
+	for i := 1; i < 5; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{"key": fmt.Sprintf("value-%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		blocks = append(blocks, blkAndPvtdata.Block)
+	}
+
+	require.EqualError(t, lgr.ArchiveBlocks(5),
+		"cannot archive blocks before block [5]. The block store height = [5], the last block cannot be archived",
+	)
+	// all the blocks are in the block file that is being appended to, which is not archived
+	require.NoError(t, lgr.ArchiveBlocks(4))
+	for _, block := range blocks {
+		b, err := lgr.GetBlockByNumber(block.Header.Number)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(block, b))
+	}
+}
+
+func TestGetBlockByTxID(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blocks := []*common.Block{gb}
+	for i := 1; i < 4; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{"key": fmt.Sprintf("value-%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		blocks = append(blocks, blkAndPvtdata.Block)
+	}
+
+	for _, block := range blocks {
+		txID, err := protoutil.GetOrComputeTxIDFromEnvelope(block.Data.Data[0])
+		require.NoError(t, err)
+		b, err := lgr.GetBlockByTxID(txID)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(block, b), "block [%d] retrieved by txID [%s] does not match", block.Header.Number, txID)
+	}
+
+	b, err := lgr.GetBlockByTxID("non-existing-txid")
+	require.EqualError(t, err, "no such transaction ID [non-existing-txid] in index")
+	require.Nil(t, b)
+}
+
+func TestGetTransactionsByIDs(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	var txIDs []string
+	for i := 1; i < 4; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{"key": fmt.Sprintf("value-%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		txID, err := protoutil.GetOrComputeTxIDFromEnvelope(blkAndPvtdata.Block.Data.Data[0])
+		require.NoError(t, err)
+		txIDs = append([]string{txID}, txIDs...)
+	}
+
+	processedTxs, err := lgr.GetTransactionsByIDs(txIDs)
+	require.NoError(t, err)
+	require.Len(t, processedTxs, len(txIDs))
+	for i, txID := range txIDs {
+		expectedProcessedTx, err := lgr.GetTransactionByID(txID)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(expectedProcessedTx, processedTxs[i]))
+	}
+
+	processedTxs, err = lgr.GetTransactionsByIDs([]string{txIDs[0], "non-existing-txid"})
+	require.EqualError(t, err, "no such transaction ID [non-existing-txid] in index")
+	require.Nil(t, processedTxs)
+}
+
+func TestCommitWithSkipValidation(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	// simulate two transactions that read and update the same key against the same state
+	simulateReadAndWrite := func(txid, value string) []byte {
+		sim, err := lgr.NewTxSimulator(txid)
+		require.NoError(t, err)
+		_, err = sim.GetState("ns", "key1")
+		require.NoError(t, err)
+		require.NoError(t, sim.SetState("ns", "key1", []byte(value)))
+		sim.Done()
+		simRes, err := sim.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		return pubSimBytes
+	}
+	pubSimBytes2 := simulateReadAndWrite("txid-2", "value2")
+	pubSimBytes3 := simulateReadAndWrite("txid-3", "value3")
+
+	blk2 := bg.NextBlock([][]byte{pubSimBytes2})
+	require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk2}, &ledger.CommitOptions{}))
+
+	t.Run("validation-codes-are-trusted", func(t *testing.T) {
+		// the transaction in block 3 would be marked as an MVCC conflict, if validated
+		blk3 := bg.NextBlock([][]byte{pubSimBytes3})
+		require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk3}, &ledger.CommitOptions{SkipValidation: true}))
+
+		bcInfo, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), bcInfo.Height)
+		committedBlk3, err := lgr.GetBlockByNumber(3)
+		require.NoError(t, err)
+		txFilter := txflags.ValidationFlags(committedBlk3.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		require.Equal(t, peer.TxValidationCode_VALID, txFilter.Flag(0))
+		checkStateDBForTest(t, lgr, map[string]string{"key1": "value3"}, nil)
+	})
+
+	t.Run("invalid-transactions-are-not-applied", func(t *testing.T) {
+		blk4 := prepareNextBlockForTest(t, lgr, bg, "txid-4", map[string]string{"key1": "value4"}, nil)
+		txflags.ValidationFlags(blk4.Block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]).
+			SetFlag(0, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE)
+		require.NoError(t, lgr.CommitLegacy(blk4, &ledger.CommitOptions{SkipValidation: true}))
+
+		bcInfo, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, uint64(5), bcInfo.Height)
+		checkStateDBForTest(t, lgr, map[string]string{"key1": "value3"}, nil)
+	})
+
+	t.Run("contiguity-is-verified", func(t *testing.T) {
+		bcInfo, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+
+		blk5 := prepareNextBlockForTest(t, lgr, bg, "txid-5", map[string]string{"key1": "value5"}, nil)
+		blk6 := prepareNextBlockForTest(t, lgr, bg, "txid-6", map[string]string{"key1": "value6"}, nil)
+		require.EqualError(t,
+			lgr.CommitLegacy(blk6, &ledger.CommitOptions{SkipValidation: true}),
+			"block number should have been 5 but was 6",
+		)
+
+		blk5.Block.Header.PreviousHash = []byte("wrong-previous-hash")
+		require.EqualError(t,
+			lgr.CommitLegacy(blk5, &ledger.CommitOptions{SkipValidation: true}),
+			fmt.Sprintf(
+				"unexpected Previous block hash. Expected PreviousHash = [%x], PreviousHash referred in the latest block= [%x]",
+				bcInfo.CurrentBlockHash, []byte("wrong-previous-hash"),
+			),
+		)
+
+		bcInfoAfterFailures, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, bcInfo, bcInfoAfterFailures)
+		checkStateDBForTest(t, lgr, map[string]string{"key1": "value3"}, nil)
+	})
+}
+
+func TestPreviewCommit(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1", "key2": "value2"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	simulate := func(txid string, f func(sim ledger.TxSimulator)) []byte {
+		sim, err := lgr.NewTxSimulator(txid)
+		require.NoError(t, err)
+		f(sim)
+		sim.Done()
+		simRes, err := sim.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		return pubSimBytes
+	}
+	// the first transaction updates key1, deletes key2, and creates key3. The second transaction reads
+	// key1 and creates key4, and is invalidated as key1 is updated by the first transaction
+	pubSimBytes1 := simulate("txid-2", func(sim ledger.TxSimulator) {
+		require.NoError(t, sim.SetState("ns", "key1", []byte("value1.2")))
+		require.NoError(t, sim.DeleteState("ns", "key2"))
+		require.NoError(t, sim.SetState("ns", "key3", []byte("value3")))
+	})
+	pubSimBytes2 := simulate("txid-3", func(sim ledger.TxSimulator) {
+		_, err := sim.GetState("ns", "key1")
+		require.NoError(t, err)
+		require.NoError(t, sim.SetState("ns", "key4", []byte("value4")))
+	})
+	blk2 := &ledger.BlockAndPvtData{Block: bg.NextBlock([][]byte{pubSimBytes1, pubSimBytes2})}
+	blk2Copy := proto.Clone(blk2.Block)
+
+	delta, err := lgr.PreviewCommit(blk2)
+	require.NoError(t, err)
+	require.Equal(t,
+		&ledger.StateDelta{
+			Namespaces: map[string]map[string]*ledger.KeyChange{
+				"ns": {
+					"key1": {OldValue: []byte("value1"), NewValue: []byte("value1.2")},
+					"key2": {OldValue: []byte("value2"), IsDelete: true},
+					"key3": {NewValue: []byte("value3")},
+				},
+			},
+		},
+		delta,
+	)
+	// neither the block nor the ledger is modified
+	require.True(t, proto.Equal(blk2Copy, blk2.Block), "proto messages are not equal")
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), bcInfo.Height)
+	checkStateDBForTest(t, lgr, map[string]string{"key1": "value1", "key2": "value2"}, nil)
+
+	// committing the block results in the previewed state
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	for key, change := range delta.Namespaces["ns"] {
+		val, err := qe.GetState("ns", key)
+		require.NoError(t, err)
+		require.Equal(t, change.NewValue, val)
+	}
+	val, err := qe.GetState("ns", "key4")
+	require.NoError(t, err)
+	require.Nil(t, val)
+	qe.Done()
+
+	t.Run("block-out-of-sequence", func(t *testing.T) {
+		_, err := lgr.PreviewCommit(blk2)
+		require.EqualError(t, err, "block number should have been 3 but was 2")
+	})
+}
+
+func TestAppendBlockRaw(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
+
+	stateDBSavepoint, err := kvlgr.txmgr.GetLastSavepoint()
+	require.NoError(t, err)
+	historyDBSavepoint, err := kvlgr.historyDB.GetLastSavepoint()
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		simulator, err := lgr.NewTxSimulator("")
+		require.NoError(t, err)
+		require.NoError(t, simulator.SetState("ns", "key1", []byte(fmt.Sprintf("value%d", i))))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		blk := bg.NextBlockWithTxid([][]byte{pubSimBytes}, []string{fmt.Sprintf("txid-%d", i)})
+		require.NoError(t, lgr.AppendBlockRaw(blk))
+	}
+
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), bcInfo.Height)
+	for i := uint64(1); i <= 3; i++ {
+		blk, err := lgr.GetBlockByNumber(i)
+		require.NoError(t, err)
+		require.Equal(t, i, blk.Header.Number)
+
+		txID := fmt.Sprintf("txid-%d", i)
+		tx, err := lgr.GetTransactionByID(txID)
+		require.NoError(t, err)
+		require.Equal(t, int32(peer.TxValidationCode_VALID), tx.ValidationCode)
+		blkByTxID, err := lgr.GetBlockByTxID(txID)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(blk, blkByTxID), "proto messages are not equal")
+	}
+
+	// state and history are not updated
+	actualStateDBSavepoint, err := kvlgr.txmgr.GetLastSavepoint()
+	require.NoError(t, err)
+	require.Equal(t, stateDBSavepoint, actualStateDBSavepoint)
+	actualHistoryDBSavepoint, err := kvlgr.historyDB.GetLastSavepoint()
+	require.NoError(t, err)
+	require.Equal(t, historyDBSavepoint, actualHistoryDBSavepoint)
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	val, err := qe.GetState("ns", "key1")
+	require.NoError(t, err)
+	qe.Done()
+	require.Nil(t, val)
+
+	t.Run("contiguity-is-verified", func(t *testing.T) {
+		blk4 := prepareNextBlockForTest(t, lgr, bg, "txid-4", map[string]string{"key1": "value4"}, nil)
+		blk5 := prepareNextBlockForTest(t, lgr, bg, "txid-5", map[string]string{"key1": "value5"}, nil)
+		require.EqualError(t, lgr.AppendBlockRaw(blk5.Block), "block number should have been 4 but was 5")
+
+		blk4.Block.Header.PreviousHash = []byte("wrong-previous-hash")
+		require.EqualError(t,
+			lgr.AppendBlockRaw(blk4.Block),
+			fmt.Sprintf(
+				"unexpected Previous block hash. Expected PreviousHash = [%x], PreviousHash referred in the latest block= [%x]",
+				bcInfo.CurrentBlockHash, []byte("wrong-previous-hash"),
+			),
+		)
+
+		bcInfoAfterFailures, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, bcInfo, bcInfoAfterFailures)
+	})
+
+	t.Run("commits-are-rejected", func(t *testing.T) {
+		blk := prepareNextBlockForTest(t, lgr, bg, "txid-6", map[string]string{"key1": "value6"}, nil)
+		expectedErr := fmt.Sprintf(
+			"commit of block [%d] is not permitted on ledger [testLedger] as its blocks are appended via AppendBlockRaw",
+			blk.Block.Header.Number,
+		)
+		require.EqualError(t, lgr.CommitLegacy(blk, &ledger.CommitOptions{}), expectedErr)
+		_, err := lgr.CommitPvtDataOfOldBlocks(nil, nil)
+		require.EqualError(t, err,
+			"commit of the pvtdata of old blocks is not permitted on ledger [testLedger] as its blocks are appended via AppendBlockRaw",
+		)
+
+		lgr.Close()
+		reopenedLgr, err := provider.Open("testLedger")
+		require.NoError(t, err)
+		defer reopenedLgr.Close()
+		require.EqualError(t, reopenedLgr.CommitLegacy(blk, &ledger.CommitOptions{}), expectedErr)
+	})
+}
+
+func TestCommitWithHistoryDB(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	numBlocks, numKeys := 5, 10
+	for i := 1; i <= numBlocks; i++ {
+		pubKVs := map[string]string{}
+		for j := 0; j < numKeys; j++ {
+			pubKVs[fmt.Sprintf("key%d", j)] = fmt.Sprintf("value%d-%d", j, i)
+		}
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i), pubKVs, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+
+	expectedKVs := map[string]string{}
+	for j := 0; j < numKeys; j++ {
+		key := fmt.Sprintf("key%d", j)
+		expectedKVs[key] = fmt.Sprintf("value%d-%d", j, numBlocks)
+		expectedHistory := []string{}
+		for i := numBlocks; i >= 1; i-- {
+			expectedHistory = append(expectedHistory, fmt.Sprintf("value%d-%d", j, i))
+		}
+		checkHistoryDBForTest(t, lgr, key, expectedHistory)
+	}
+	checkStateDBForTest(t, lgr, expectedKVs, nil)
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			stateDBSavePoint:   uint64(numBlocks),
+			historyDBSavePoint: uint64(numBlocks),
+		},
+	)
+}
+
+func BenchmarkCommitWithHistoryDB(b *testing.B) {
+	testCases := []struct {
+		historyDBEnabled    bool
+		commitPipelineDepth int
+	}{
+		{historyDBEnabled: false},
+		{historyDBEnabled: true},
+		// the history commit is asynchronous
+		{historyDBEnabled: true, commitPipelineDepth: 4},
+	}
+	for _, tc := range testCases {
+		b.Run(fmt.Sprintf("historyDBEnabled=%t/commitPipelineDepth=%d", tc.historyDBEnabled, tc.commitPipelineDepth), func(b *testing.B) {
+			conf := testConfig(b)
+			conf.HistoryDBConfig.Enabled = tc.historyDBEnabled
+			conf.HistoryDBConfig.CommitPipelineDepth = tc.commitPipelineDepth
+			provider := testutilNewProvider(conf, b, &mock.DeployedChaincodeInfoProvider{})
+			defer provider.Close()
+
+			bg, gb := testutil.NewBlockGenerator(b, "testLedger", false)
+			lgr, err := provider.CreateFromGenesisBlock(gb)
+			require.NoError(b, err)
+			defer lgr.Close()
+
+			// the transactions only write the keys and hence can be simulated upfront
+			blocks := make([]*ledger.BlockAndPvtData, b.N)
+			for i := 0; i < b.N; i++ {
+				pubKVs := map[string]string{}
+				for j := 0; j < 100; j++ {
+					pubKVs[fmt.Sprintf("key%d", j)] = fmt.Sprintf("value%d-%d", j, i)
+				}
+				blocks[i] = prepareNextBlockForTest(b, lgr, bg, fmt.Sprintf("txid-%d", i), pubKVs, nil)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := lgr.CommitLegacy(blocks[i], &ledger.CommitOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestCommitNotifications(t *testing.T) {
+	var lgr *kvLedger
+	var doneChannel chan struct{}
+	var dataChannel <-chan *ledger.CommitNotification
+
+	setup := func() {
+		var err error
+		lgr = &kvLedger{}
+		doneChannel = make(chan struct{})
+		dataChannel, err = lgr.CommitNotificationsChannel(doneChannel)
+		require.NoError(t, err)
+	}
+
+	t.Run("only first txid is included in notification", func(t *testing.T) {
+		setup()
+		lgr.sendCommitNotification(1, []*validation.TxStatInfo{
+			{
+				TxIDFromChannelHeader: "txid_1",
+				ValidationCode:        peer.TxValidationCode_BAD_RWSET,
+				ChaincodeID:           &peer.ChaincodeID{Name: "cc1"},
+				ChaincodeEventData:    []byte("cc1_event"),
+				TxType:                common.HeaderType_ENDORSER_TRANSACTION,
+			},
+			{
+				TxIDFromChannelHeader: "txid_1",
+				ValidationCode:        peer.TxValidationCode_DUPLICATE_TXID,
+			},
+			{
+				TxIDFromChannelHeader: "txid_2",
+				ValidationCode:        peer.TxValidationCode_VALID,
+				ChaincodeID:           &peer.ChaincodeID{Name: "cc2"},
+				ChaincodeEventData:    []byte("cc2_event"),
+				TxType:                common.HeaderType_ENDORSER_TRANSACTION,
+			},
+		})
+
+		commitNotification := <-dataChannel
+		require.Equal(t,
+			&ledger.CommitNotification{
+				BlockNumber: 1,
+				TxsInfo: []*ledger.CommitNotificationTxInfo{
+					{
+						TxID:               "txid_1",
+						TxType:             common.HeaderType_ENDORSER_TRANSACTION,
+						ValidationCode:     peer.TxValidationCode_BAD_RWSET,
+						ChaincodeID:        &peer.ChaincodeID{Name: "cc1"},
+						ChaincodeEventData: []byte("cc1_event"),
+					},
+					{
+						TxID:               "txid_2",
+						TxType:             common.HeaderType_ENDORSER_TRANSACTION,
+						ValidationCode:     peer.TxValidationCode_VALID,
+						ChaincodeID:        &peer.ChaincodeID{Name: "cc2"},
+						ChaincodeEventData: []byte("cc2_event"),
+					},
+				},
+			},
+			commitNotification,
+		)
+	})
+
+	t.Run("empty txids are not included in notification", func(t *testing.T) {
+		setup()
+		lgr.sendCommitNotification(1, []*validation.TxStatInfo{
+			{
+				TxIDFromChannelHeader: "",
+				ValidationCode:        peer.TxValidationCode_BAD_RWSET,
+			},
+			{
+				TxIDFromChannelHeader: "",
+				ValidationCode:        peer.TxValidationCode_DUPLICATE_TXID,
+			},
+		})
+
+		commitNotification := <-dataChannel
+		require.Equal(t,
+			&ledger.CommitNotification{
+				BlockNumber: 1,
+				TxsInfo:     []*ledger.CommitNotificationTxInfo{},
+			},
+			commitNotification,
+		)
+	})
+
+	t.Run("second time calling CommitNotificationsChannel returns error", func(t *testing.T) {
+		setup()
+		_, err := lgr.CommitNotificationsChannel(make(chan struct{}))
+		require.EqualError(t, err, "only one commit notifications channel is allowed at a time")
+	})
+
+	t.Run("closing done channel closes the data channel on next commit", func(t *testing.T) {
+		setup()
+		lgr.sendCommitNotification(1, []*validation.TxStatInfo{})
+		_, ok := <-dataChannel
+		require.True(t, ok)
+
+		close(doneChannel)
+		lgr.sendCommitNotification(2, []*validation.TxStatInfo{})
+
+		_, ok = <-dataChannel
+		require.False(t, ok)
+	})
+}
+
+func TestCommitNotificationsOnBlockCommit(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	l, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer l.Close()
+
+	lgr := l.(*kvLedger)
+	doneChannel := make(chan struct{})
+	dataChannel, err := lgr.CommitNotificationsChannel(doneChannel)
+	require.NoError(t, err)
+
+	s, err := lgr.NewTxSimulator("")
+	require.NoError(t, err)
+	_, err = s.GetState("ns1", "key1")
+	require.NoError(t, err)
+	require.NoError(t, s.SetState("ns1", "key1", []byte("val1")))
+	sr, err := s.GetTxSimulationResults()
+	require.NoError(t, err)
+	srBytes, err := sr.GetPubSimulationBytes()
+	require.NoError(t, err)
+	s.Done()
+
+	block := testutil.ConstructBlockFromBlockDetails(
+		t, &testutil.BlockDetails{
+			BlockNum:     1,
+			PreviousHash: protoutil.BlockHeaderHash(gb.Header),
+			Txs: []*testutil.TxDetails{
+				{
+					Type:              common.HeaderType_ENDORSER_TRANSACTION,
+					TxID:              "txid_1",
+					ChaincodeName:     "foo",
+					ChaincodeVersion:  "v1",
+					SimulationResults: srBytes,
+					ChaincodeEvents:   []byte("foo-event"),
 				},
 
 				{
@@ -1371,76 +2273,335 @@ func sampleDataWithPvtdataForSelectiveTx(t *testing.T, bg *testutil.BlockGenerat
 	txFilter.SetFlag(5, peer.TxValidationCode_INVALID_WRITESET)
 	blockAndpvtdata[5].Block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txFilter
 
-	return blockAndpvtdata
+	return blockAndpvtdata
+}
+
+func sampleDataWithPvtdataForAllTxs(t *testing.T, bg *testutil.BlockGenerator) []*ledger.BlockAndPvtData {
+	var blockAndpvtdata []*ledger.BlockAndPvtData
+	blocks := bg.NextTestBlocks(10)
+	for i := 0; i < 10; i++ {
+		blockAndpvtdata = append(blockAndpvtdata,
+			&ledger.BlockAndPvtData{
+				Block:   blocks[i],
+				PvtData: samplePvtData(t, []uint64{uint64(i), uint64(i + 1)}),
+			},
+		)
+	}
+	return blockAndpvtdata
+}
+
+func samplePvtData(t *testing.T, txNums []uint64) map[uint64]*ledger.TxPvtData {
+	txPvtWS := &rwsetutil.TxPvtRwSet{
+		NsPvtRwSet: []*rwsetutil.NsPvtRwSet{
+			{
+				NameSpace: "ns-1",
+				CollPvtRwSets: []*rwsetutil.CollPvtRwSet{
+					{
+						CollectionName: "coll-1",
+						KvRwSet: &kvrwset.KVRWSet{
+							Writes: []*kvrwset.KVWrite{
+								{
+									Key:   "testKey",
+									Value: []byte("testValue"),
+								},
+							},
+						},
+					},
+					{
+						CollectionName: "coll-2",
+						KvRwSet: &kvrwset.KVRWSet{
+							Writes: []*kvrwset.KVWrite{
+								{
+									Key:   "testKey",
+									Value: []byte("testValue"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	pvtWriteSet, err := txPvtWS.ToProtoMsg()
+	require.NoError(t, err)
+
+	var pvtData []*ledger.TxPvtData
+	for _, txNum := range txNums {
+		pvtData = append(pvtData, &ledger.TxPvtData{SeqInBlock: txNum, WriteSet: pvtWriteSet})
+	}
+	return constructPvtdataMap(pvtData)
+}
+
+func btlPolicyForSampleData() pvtdatapolicy.BTLPolicy {
+	return btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns-1", "coll-1"}: 0,
+			{"ns-1", "coll-2"}: 0,
+		},
+	)
+}
+
+func TestRollbackHistoryDB(t *testing.T) {
+	conf := testConfig(t)
+	nsCollBtlConfs := []*nsCollBtlConfig{
+		{
+			namespace: "ns",
+			btlConfig: map[string]uint64{"coll": 0},
+		},
+	}
+	provider := testutilNewProviderWithCollectionConfig(t, nsCollBtlConfs, conf)
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+
+	err = lgr.RollbackHistoryDB(4)
+	require.EqualError(t, err, "cannot rollback history database for channel [testLedger] to block [4] as it is above the savepoint [3]")
+
+	require.NoError(t, lgr.RollbackHistoryDB(1))
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			stateDBSavePoint:   uint64(3),
+			stateDBKVs:         map[string]string{"key1": "value1.3"},
+			historyDBSavePoint: uint64(1),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.1"},
+		},
+	)
+
+	// history db is not updated by the commits after the rollback
+	blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, "SimulateForBlk4",
+		map[string]string{"key1": "value1.4"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			stateDBSavePoint:   uint64(4),
+			stateDBKVs:         map[string]string{"key1": "value1.4"},
+			historyDBSavePoint: uint64(1),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.1"},
+		},
+	)
+
+	// history db is caught up with the block store on the next open
+	lgr.Close()
+	provider.Close()
+	provider = testutilNewProviderWithCollectionConfig(t, nsCollBtlConfs, conf)
+	defer provider.Close()
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			historyDBSavePoint: uint64(4),
+			historyKey:         "key1",
+			historyVals:        []string{"value1.4", "value1.3", "value1.2", "value1.1"},
+		},
+	)
+
+	t.Run("history-disabled", func(t *testing.T) {
+		kvl := &kvLedger{ledgerID: "testLedger"}
+		require.EqualError(t, kvl.RollbackHistoryDB(1), "history database is not enabled for ledger [testLedger]")
+	})
+}
+
+func TestBlockStoreCheckpointInfo(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+
+		checkpoint, err := lgr.BlockStoreCheckpointInfo()
+		require.NoError(t, err)
+		bcInfo, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, bcInfo.Height-1, checkpoint.LastBlockNumber)
+		require.Equal(t, 0, checkpoint.FileSuffixNum)
+		require.False(t, checkpoint.NoBlockFiles)
+
+		blockfileInfo, err := os.Stat(filepath.Join(BlockStorePath(conf.RootFSPath), "chains", "testLedger", "blockfile_000000"))
+		require.NoError(t, err)
+		require.Equal(t, int(blockfileInfo.Size()), checkpoint.Offset)
+	}
+}
+
+func TestGetBlockchainInfoExtended(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	verifyExtendedInfo := func(expectedEarliestAvailableBlock uint64) {
+		bcInfo, err := lgr.GetBlockchainInfo()
+		require.NoError(t, err)
+		extendedBCInfo, err := lgr.GetBlockchainInfoExtended()
+		require.NoError(t, err)
+		require.Equal(t,
+			&ledger.ExtendedBlockchainInfo{
+				Height:                 bcInfo.Height,
+				CurrentBlockHash:       bcInfo.CurrentBlockHash,
+				PreviousBlockHash:      bcInfo.PreviousBlockHash,
+				EarliestAvailableBlock: expectedEarliestAvailableBlock,
+			},
+			extendedBCInfo,
+		)
+	}
+
+	verifyExtendedInfo(0)
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		verifyExtendedInfo(0)
+	}
+
+	require.NoError(t, lgr.PruneBlocks(2))
+	verifyExtendedInfo(2)
+}
+
+func TestBlockStorageCodec(t *testing.T) {
+	conf := testConfig(t)
+	conf.BlockStorageConfig = &ledger.BlockStorageConfig{Codec: &gzipBlockCodec{}}
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blocks := []*common.Block{gb}
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{"key": fmt.Sprintf("value-%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		blocks = append(blocks, blkAndPvtdata.Block)
+	}
+
+	for _, block := range blocks {
+		b, err := lgr.GetBlockByNumber(block.Header.Number)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(block, b))
+	}
+	txID, err := protoutil.GetOrComputeTxIDFromEnvelope(blocks[2].Data.Data[0])
+	require.NoError(t, err)
+	processedTx, err := lgr.GetTransactionByID(txID)
+	require.NoError(t, err)
+	expectedTxEnvelope, err := protoutil.GetEnvelopeFromBlock(blocks[2].Data.Data[0])
+	require.NoError(t, err)
+	require.True(t, proto.Equal(expectedTxEnvelope, processedTx.TransactionEnvelope))
+
+	blockfile, err := ioutil.ReadFile(filepath.Join(BlockStorePath(conf.RootFSPath), "chains", "testLedger", "blockfile_000000"))
+	require.NoError(t, err)
+	_, n := proto.DecodeVarint(blockfile)
+	require.Equal(t, []byte{0x1f, 0x8b}, blockfile[n:n+2])
 }
 
-func sampleDataWithPvtdataForAllTxs(t *testing.T, bg *testutil.BlockGenerator) []*ledger.BlockAndPvtData {
-	var blockAndpvtdata []*ledger.BlockAndPvtData
-	blocks := bg.NextTestBlocks(10)
-	for i := 0; i < 10; i++ {
-		blockAndpvtdata = append(blockAndpvtdata,
-			&ledger.BlockAndPvtData{
-				Block:   blocks[i],
-				PvtData: samplePvtData(t, []uint64{uint64(i), uint64(i + 1)}),
-			},
-		)
+func TestBlockStorageCompression(t *testing.T) {
+	conf := testConfig(t)
+	conf.BlockStorageConfig = &ledger.BlockStorageConfig{
+		Compression:         ledger.BlockStorageCompressionSnappy,
+		MaxBlockfileSizeMBs: 1,
 	}
-	return blockAndpvtdata
-}
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
 
-func samplePvtData(t *testing.T, txNums []uint64) map[uint64]*ledger.TxPvtData {
-	txPvtWS := &rwsetutil.TxPvtRwSet{
-		NsPvtRwSet: []*rwsetutil.NsPvtRwSet{
-			{
-				NameSpace: "ns-1",
-				CollPvtRwSets: []*rwsetutil.CollPvtRwSet{
-					{
-						CollectionName: "coll-1",
-						KvRwSet: &kvrwset.KVRWSet{
-							Writes: []*kvrwset.KVWrite{
-								{
-									Key:   "testKey",
-									Value: []byte("testValue"),
-								},
-							},
-						},
-					},
-					{
-						CollectionName: "coll-2",
-						KvRwSet: &kvrwset.KVRWSet{
-							Writes: []*kvrwset.KVWrite{
-								{
-									Key:   "testKey",
-									Value: []byte("testValue"),
-								},
-							},
-						},
-					},
-				},
-			},
-		},
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blocks := []*common.Block{gb}
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{"key": strings.Repeat(fmt.Sprintf(`{"value":%d}`, i), 1000)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+		blocks = append(blocks, blkAndPvtdata.Block)
 	}
 
-	pvtWriteSet, err := txPvtWS.ToProtoMsg()
+	blocksSize := 0
+	for _, block := range blocks {
+		b, err := lgr.GetBlockByNumber(block.Header.Number)
+		require.NoError(t, err)
+		require.True(t, proto.Equal(block, b))
+		blocksSize += proto.Size(block)
+	}
+	txID, err := protoutil.GetOrComputeTxIDFromEnvelope(blocks[2].Data.Data[0])
 	require.NoError(t, err)
+	processedTx, err := lgr.GetTransactionByID(txID)
+	require.NoError(t, err)
+	expectedTxEnvelope, err := protoutil.GetEnvelopeFromBlock(blocks[2].Data.Data[0])
+	require.NoError(t, err)
+	require.True(t, proto.Equal(expectedTxEnvelope, processedTx.TransactionEnvelope))
 
-	var pvtData []*ledger.TxPvtData
-	for _, txNum := range txNums {
-		pvtData = append(pvtData, &ledger.TxPvtData{SeqInBlock: txNum, WriteSet: pvtWriteSet})
+	ledgerDir := filepath.Join(BlockStorePath(conf.RootFSPath), "chains", "testLedger")
+	compression, err := ioutil.ReadFile(filepath.Join(ledgerDir, "blockCompression.info"))
+	require.NoError(t, err)
+	require.Equal(t, "snappy", string(compression))
+	blockfile, err := os.Stat(filepath.Join(ledgerDir, "blockfile_000000"))
+	require.NoError(t, err)
+	require.Less(t, blockfile.Size(), int64(blocksSize/2))
+
+}
+
+type gzipBlockCodec struct{}
+
+func (c *gzipBlockCodec) Marshal(block *common.Block) ([]byte, error) {
+	b, err := proto.Marshal(block)
+	if err != nil {
+		return nil, err
 	}
-	return constructPvtdataMap(pvtData)
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-func btlPolicyForSampleData() pvtdatapolicy.BTLPolicy {
-	return btltestutil.SampleBTLPolicy(
-		map[[2]string]uint64{
-			{"ns-1", "coll-1"}: 0,
-			{"ns-1", "coll-2"}: 0,
-		},
-	)
+func (c *gzipBlockCodec) Unmarshal(b []byte) (*common.Block, error) {
+	r, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	protoBytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	block := &common.Block{}
+	if err := proto.Unmarshal(protoBytes, block); err != nil {
+		return nil, err
+	}
+	return block, nil
 }
 
-func prepareNextBlockForTest(t *testing.T, l ledger.PeerLedger, bg *testutil.BlockGenerator,
+func prepareNextBlockForTest(t testing.TB, l ledger.PeerLedger, bg *testutil.BlockGenerator,
 	txid string, pubKVs map[string]string, pvtKVs map[string]string) *ledger.BlockAndPvtData {
 	simulator, _ := l.NewTxSimulator(txid)
 	// simulating transaction
@@ -1527,3 +2688,411 @@ type bcSummary struct {
 	historyKey         string
 	historyVals        []string
 }
+
+func TestCommitWriteTimeout(t *testing.T) {
+	conf := testConfig(t)
+	conf.StateDBConfig.WriteTimeout = 100 * time.Millisecond
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	slowDBProvider := &slowVersionedDBProvider{
+		VersionedDBProvider: provider.dbProvider.VersionedDBProvider,
+		unblock:             make(chan struct{}),
+	}
+	provider.dbProvider.VersionedDBProvider = slowDBProvider
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	commitNotifications, err := lgr.CommitNotificationsChannel(make(chan struct{}))
+	require.NoError(t, err)
+
+	slowDBProvider.setStall(true)
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", map[string]string{"key1": "value2"}, nil)
+	err = lgr.CommitLegacy(blk2, &ledger.CommitOptions{})
+	expectedErr := &ledger.CommitTimeoutError{LedgerID: "testLedger", BlockNum: 2, Timeout: 100 * time.Millisecond}
+	require.Equal(t, expectedErr, err)
+	savepoint, err := slowDBProvider.db.VersionedDB.GetLatestSavePoint()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(1, 0), savepoint)
+
+	// the block is persisted and the subsequent commits are rejected while the write to the state database is pending
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), bcInfo.Height)
+	blk3 := bg.NextBlock([][]byte{})
+	require.Equal(t, expectedErr, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk3}, &ledger.CommitOptions{}))
+	require.Empty(t, commitNotifications)
+
+	// the state reads are blocked until the stalled write completes, so that the partially committed block is not visible
+	stateReadDone := make(chan struct{})
+	go func() {
+		defer close(stateReadDone)
+		qe, err := lgr.NewQueryExecutor()
+		require.NoError(t, err)
+		defer qe.Done()
+		val, err := qe.GetState("ns", "key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value2"), val)
+	}()
+	require.Never(t, func() bool {
+		select {
+		case <-stateReadDone:
+			return true
+		default:
+			return false
+		}
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	// once the stalled write completes, the commit of the block is completed and the commits resume
+	slowDBProvider.setStall(false)
+	close(slowDBProvider.unblock)
+	<-stateReadDone
+	commitNotification := <-commitNotifications
+	require.Equal(t, uint64(2), commitNotification.BlockNumber)
+	savepoint, err = slowDBProvider.db.VersionedDB.GetLatestSavePoint()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), savepoint.BlockNum)
+	require.Eventually(t, func() bool {
+		return lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk3}, &ledger.CommitOptions{}) == nil
+	}, time.Second, 10*time.Millisecond)
+	bcInfo, err = lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), bcInfo.Height)
+}
+
+func TestCommitWriteTimeoutFailure(t *testing.T) {
+	conf := testConfig(t)
+	conf.StateDBConfig.WriteTimeout = 100 * time.Millisecond
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	slowDBProvider := &slowVersionedDBProvider{
+		VersionedDBProvider: provider.dbProvider.VersionedDBProvider,
+		unblock:             make(chan struct{}),
+		err:                 errors.New("write failed"),
+	}
+	provider.dbProvider.VersionedDBProvider = slowDBProvider
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+
+	slowDBProvider.setStall(true)
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	err = lgr.CommitLegacy(blk1, &ledger.CommitOptions{})
+	require.Equal(t, &ledger.CommitTimeoutError{LedgerID: "testLedger", BlockNum: 1, Timeout: 100 * time.Millisecond}, err)
+
+	// a failure of the stalled write marks the ledger failed instead of crashing the peer
+	close(slowDBProvider.unblock)
+	blk2 := bg.NextBlock([][]byte{})
+	require.Eventually(t, func() bool {
+		err := lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk2}, &ledger.CommitOptions{})
+		return err != nil && err.Error() == "delayed commit of block [1] to the databases of ledger [testLedger] failed: error during commit to txmgr: write failed"
+	}, time.Second, 10*time.Millisecond)
+	lgr.Close()
+
+	// the state database is caught up with the block store when the ledger is reopened
+	slowDBProvider.setStall(false)
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk2}, &ledger.CommitOptions{}))
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+	val, err := qe.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+}
+
+// slowVersionedDBProvider wraps the handles of the state databases so as to stall the
+// writes to them, when asked, until the channel unblock is closed, and then fail them with err, if set
+type slowVersionedDBProvider struct {
+	statedb.VersionedDBProvider
+	unblock chan struct{}
+	stall   int32
+	err     error
+	db      *slowVersionedDB
+}
+
+func (p *slowVersionedDBProvider) GetDBHandle(id string, namespaceProvider statedb.NamespaceProvider) (statedb.VersionedDB, error) {
+	db, err := p.VersionedDBProvider.GetDBHandle(id, namespaceProvider)
+	if err != nil {
+		return nil, err
+	}
+	p.db = &slowVersionedDB{VersionedDB: db, provider: p}
+	return p.db, nil
+}
+
+func (p *slowVersionedDBProvider) setStall(stall bool) {
+	if stall {
+		atomic.StoreInt32(&p.stall, 1)
+	} else {
+		atomic.StoreInt32(&p.stall, 0)
+	}
+}
+
+type slowVersionedDB struct {
+	statedb.VersionedDB
+	provider *slowVersionedDBProvider
+}
+
+func (db *slowVersionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
+	if atomic.LoadInt32(&db.provider.stall) == 1 {
+		<-db.provider.unblock
+		if db.provider.err != nil {
+			return db.provider.err
+		}
+	}
+	return db.VersionedDB.ApplyUpdates(batch, height)
+}
+
+func TestGetPrivateDataHash(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProviderWithCollectionConfig(
+		t,
+		[]*nsCollBtlConfig{
+			{
+				namespace: "ns",
+				btlConfig: map[string]uint64{"coll": 0},
+			},
+		},
+		conf,
+	)
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", nil, map[string]string{"key1": "value1"})
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	// the clear value of key2 is not supplied, as is the case for a peer that is not a member of the collection
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", nil, map[string]string{"key2": "value2"})
+	blk2.PvtData = nil
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+
+	expectedHash := func(value string) []byte {
+		h, err := provider.initializer.HashProvider.GetHash(&bccsp.SHA256Opts{})
+		require.NoError(t, err)
+		_, err = h.Write([]byte(value))
+		require.NoError(t, err)
+		return h.Sum(nil)
+	}
+
+	hash, err := qe.GetPrivateDataHash("ns", "coll", "key1")
+	require.NoError(t, err)
+	require.Equal(t, expectedHash("value1"), hash)
+
+	_, err = qe.GetPrivateData("ns", "coll", "key2")
+	require.Contains(t, err.Error(), "private data matching public hash version is not available")
+	hash, err = qe.GetPrivateDataHash("ns", "coll", "key2")
+	require.NoError(t, err)
+	require.Equal(t, expectedHash("value2"), hash)
+
+	hash, err = qe.GetPrivateDataHash("ns", "coll", "non-existing-key")
+	require.NoError(t, err)
+	require.Nil(t, hash)
+}
+
+func TestReadAuthorizer(t *testing.T) {
+	provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	provider.initializer.ReadAuthorizer = func(namespace, key string) error {
+		if namespace == "ns2" {
+			return errors.New("ns2 is off limits")
+		}
+		return nil
+	}
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	commitTx := func(txid string, kvs map[string]map[string]string) {
+		sim, err := lgr.NewTxSimulator(txid)
+		require.NoError(t, err)
+		for ns, nsKVs := range kvs {
+			for k, v := range nsKVs {
+				// the reads by the simulators are not subject to the read authorizer
+				_, err := sim.GetState(ns, k)
+				require.NoError(t, err)
+				require.NoError(t, sim.SetState(ns, k, []byte(v)))
+			}
+		}
+		sim.Done()
+		simRes, err := sim.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		blk := bg.NextBlock([][]byte{pubSimBytes})
+		require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk}, &ledger.CommitOptions{}))
+		// the validation of the reads is not subject to the read authorizer
+		require.True(t, txflags.ValidationFlags(blk.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]).IsValid(0))
+	}
+	commitTx("txid-1", map[string]map[string]string{
+		"ns1": {"key1": "value1", "key2": "value2"},
+		"ns2": {"key1": "value1"},
+	})
+	commitTx("txid-2", map[string]map[string]string{
+		"ns2": {"key1": "value1-updated"},
+	})
+
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+
+	val, err := qe.GetState("ns1", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+
+	_, err = qe.GetState("ns2", "key1")
+	require.IsType(t, &ledger.ReadNotAuthorizedError{}, err)
+	require.EqualError(t, err, "read of key [key1] in namespace [ns2] is not authorized: ns2 is off limits")
+	_, err = qe.GetStateMultipleKeys("ns2", []string{"key1"})
+	require.IsType(t, &ledger.ReadNotAuthorizedError{}, err)
+
+	scanKeys := func(ns string) []string {
+		itr, err := qe.GetStateRangeScanIterator(ns, "", "")
+		require.NoError(t, err)
+		defer itr.Close()
+		keys := []string{}
+		for {
+			res, err := itr.Next()
+			require.NoError(t, err)
+			if res == nil {
+				return keys
+			}
+			keys = append(keys, res.(*queryresult.KV).Key)
+		}
+	}
+	require.Equal(t, []string{"key1", "key2"}, scanKeys("ns1"))
+	require.Empty(t, scanKeys("ns2"))
+}
+
+func TestChannelID(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	genesisBlock, err := configtxtest.MakeGenesisBlock("channel-1")
+	require.NoError(t, err)
+	lgr, err := provider.CreateFromGenesisBlockWithID("custom-id", genesisBlock)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	channelID, err := lgr.ChannelID()
+	require.NoError(t, err)
+	require.Equal(t, "channel-1", channelID)
+	// the cached channel ID is returned on the subsequent calls
+	channelID, err = lgr.ChannelID()
+	require.NoError(t, err)
+	require.Equal(t, "channel-1", channelID)
+
+	t.Run("bootstrapped-from-snapshot", func(t *testing.T) {
+		require.NoError(t, lgr.(*kvLedger).generateSnapshot(""))
+		freshProvider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+		defer freshProvider.Close()
+		bootstrappedLgr, _, err := freshProvider.CreateFromSnapshot(SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, "custom-id", 0))
+		require.NoError(t, err)
+		defer bootstrappedLgr.Close()
+
+		_, err = bootstrappedLgr.ChannelID()
+		require.EqualError(t, err, "ledger [custom-id] was bootstrapped from a snapshot and does not contain the genesis block")
+	})
+}
+
+func TestStateDBReadCache(t *testing.T) {
+	conf := testConfig(t)
+	conf.StateDBConfig.ReadCacheSize = 100
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	verifyState := func(expectedVal string) {
+		// the second read is served from the read cache
+		for i := 0; i < 2; i++ {
+			qe, err := lgr.NewQueryExecutor()
+			require.NoError(t, err)
+			val, err := qe.GetState("ns", "key1")
+			qe.Done()
+			require.NoError(t, err)
+			require.Equal(t, expectedVal, string(val))
+		}
+	}
+	verifyState("")
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	verifyState("value1")
+
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", map[string]string{"key1": "value2"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+	verifyState("value2")
+}
+
+func TestPebbleStateDB(t *testing.T) {
+	conf := testConfig(t)
+	conf.StateDBConfig.StateDatabase = ledger.Pebble
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid-%d", i),
+			map[string]string{fmt.Sprintf("key%d", i): fmt.Sprintf("value%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+	lgr.Close()
+	provider.Close()
+
+	// the state is persisted in the pebble db and not in the leveldb
+	entries, err := ioutil.ReadDir(StatePebbleDBPath(conf.RootFSPath))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	_, err = os.Stat(StateDBPath(conf.RootFSPath))
+	require.True(t, os.IsNotExist(err))
+
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+	itr, err := qe.GetStateRangeScanIterator("ns", "", "")
+	require.NoError(t, err)
+	defer itr.Close()
+	for i := 1; i <= 3; i++ {
+		res, err := itr.Next()
+		require.NoError(t, err)
+		kv := res.(*queryresult.KV)
+		require.Equal(t, fmt.Sprintf("key%d", i), kv.Key)
+		require.Equal(t, fmt.Sprintf("value%d", i), string(kv.Value))
+	}
+	res, err := itr.Next()
+	require.NoError(t, err)
+	require.Nil(t, res)
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			stateDBSavePoint: uint64(3),
+			stateDBKVs:       map[string]string{"key1": "value1", "key2": "value2", "key3": "value3"},
+		},
+	)
+}
@@ -12,9 +12,58 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/pvtdatastorage"
+	"github.com/pkg/errors"
 )
 
+const (
+	storeIDStore         = "idStore"
+	storeStateDB         = "stateDB"
+	storeConfigHistoryDB = "configHistoryDB"
+	storeBookkeepingDB   = "bookkeepingDB"
+	storeHistoryDB       = "historyDB"
+	storePvtdataStore    = "pvtdataStore"
+	storeBlockStore      = "blockstore"
+)
+
+// StoreRemovalStatus tells whether the data of a removed ledger is gone from a store
+type StoreRemovalStatus struct {
+	Store   string
+	Removed bool
+}
+
+// RemovalReport lists the stores checked for the data of a removed ledger. StateDBChecked is false if the state
+// database does not support checking the existence of the data of a ledger without creating it, in which case the
+// state database is not checked
+type RemovalReport struct {
+	LedgerID       string
+	Stores         []StoreRemovalStatus
+	StateDBChecked bool
+}
+
+// Add records whether the data of the ledger remains in the given store. This allows the callers that maintain
+// channel-specific data outside of the ledger, such as the transient store, to complete the report
+func (r *RemovalReport) Add(store string, dataRemains bool) {
+	r.Stores = append(r.Stores, StoreRemovalStatus{Store: store, Removed: !dataRemains})
+}
+
+// RemainingData returns the stores that still hold data of the ledger
+func (r *RemovalReport) RemainingData() []string {
+	var stores []string
+	for _, s := range r.Stores {
+		if !s.Removed {
+			stores = append(stores, s.Store)
+		}
+	}
+	return stores
+}
+
+// Complete returns true if the data of the ledger is gone from all the checked stores
+func (r *RemovalReport) Complete() bool {
+	return len(r.RemainingData()) == 0
+}
+
 type ledgerDataRemover struct {
 	blkStoreProvider     *blkstorage.BlockStoreProvider
 	statedbProvider      *privacyenabledstate.DBProvider
@@ -64,3 +113,38 @@ func (r *ledgerDataRemover) Drop(ledgerID string) error {
 	}
 	return nil
 }
+
+// verify adds to the report whether the data of the given ledger remains in each of the ledger DBs from which
+// the data is dropped by the function Drop
+func (r *ledgerDataRemover) verify(ledgerID string, report *RemovalReport) error {
+	if stateDBChecker, ok := r.statedbProvider.VersionedDBProvider.(statedb.ExistenceChecker); ok {
+		exists, err := stateDBChecker.Exists(ledgerID)
+		if err != nil {
+			return errors.WithMessage(err, "error while checking the existence of the data in stateDB")
+		}
+		report.Add(storeStateDB, exists)
+		report.StateDBChecked = true
+	}
+
+	type check struct {
+		store  string
+		exists func(string) (bool, error)
+	}
+	checks := []check{
+		{storeConfigHistoryDB, r.configHistoryMgr.Exists},
+		{storeBookkeepingDB, r.bookkeepingProvider.Exists},
+		{storePvtdataStore, r.pvtdataStoreProvider.Exists},
+		{storeBlockStore, r.blkStoreProvider.Exists},
+	}
+	if r.historydbProvider != nil {
+		checks = append(checks, check{storeHistoryDB, r.historydbProvider.Exists})
+	}
+	for _, c := range checks {
+		exists, err := c.exists(ledgerID)
+		if err != nil {
+			return errors.WithMessagef(err, "error while checking the existence of the data in %s", c.store)
+		}
+		report.Add(c.store, exists)
+	}
+	return nil
+}
@@ -35,6 +35,11 @@ func StateDBPath(rootFSPath string) string {
 	return filepath.Join(rootFSPath, "stateLeveldb")
 }
 
+// StatePebbleDBPath returns the absolute path of state pebble DB
+func StatePebbleDBPath(rootFSPath string) string {
+	return filepath.Join(rootFSPath, "statePebbledb")
+}
+
 // HistoryDBPath returns the absolute path of history DB
 func HistoryDBPath(rootFSPath string) string {
 	return filepath.Join(rootFSPath, "historyLeveldb")
@@ -0,0 +1,151 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// ExportedLedgerMetadata is the JSON representation of the metadata of a ledger in the ledger ID store, as written by
+// `Provider.ExportMetadata`. For a ledger bootstrapped from a snapshot, the metadata of the snapshot is carried as-is
+// in the fields BootSnapshotSignableMetadata and BootSnapshotAdditionalMetadata, whereas the field
+// BootstrappingSnapshotHash is included only for the inspection and is ignored on import
+type ExportedLedgerMetadata struct {
+	LedgerID                       string `json:"ledger_id"`
+	Status                         string `json:"status"`
+	BootstrappingSnapshotHash      string `json:"bootstrapping_snapshot_hash,omitempty"`
+	BootSnapshotSignableMetadata   string `json:"boot_snapshot_signable_metadata,omitempty"`
+	BootSnapshotAdditionalMetadata string `json:"boot_snapshot_additional_metadata,omitempty"`
+}
+
+// ExportMetadata writes the metadata of all the ledgers in the ledger ID store, irrespective of their status,
+// as a JSON array of `ExportedLedgerMetadata`. This is intended for backing up the ledger ID store, which can
+// be reconstructed from the output via ImportMetadata
+func (p *Provider) ExportMetadata(w io.Writer) error {
+	if err := p.acquireCloseRLock(); err != nil {
+		return err
+	}
+	defer p.closeLock.RUnlock()
+
+	allMetadata, err := p.idStore.getAllLedgerMetadata()
+	if err != nil {
+		return err
+	}
+	exported := []*ExportedLedgerMetadata{}
+	for _, m := range allMetadata {
+		e := &ExportedLedgerMetadata{
+			LedgerID: m.ledgerID,
+			Status:   m.metadata.Status.String(),
+		}
+		if bootSnapshotMetadata := m.metadata.BootSnapshotMetadata; bootSnapshotMetadata != nil {
+			snapshotMetadata, err := snapshotMetadataFromProto(bootSnapshotMetadata)
+			if err != nil {
+				return errors.WithMessagef(err, "error while exporting the metadata of ledger [%s]", m.ledgerID)
+			}
+			e.BootstrappingSnapshotHash = snapshotMetadata.SnapshotHashInHex
+			e.BootSnapshotSignableMetadata = bootSnapshotMetadata.SingableMetadata
+			e.BootSnapshotAdditionalMetadata = bootSnapshotMetadata.AdditionalMetadata
+		}
+		exported = append(exported, e)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", jsonFileIndent)
+	return errors.Wrap(encoder.Encode(exported), "error while writing the exported ledger metadata")
+}
+
+// ImportMetadata reads the metadata of the ledgers in the format written by ExportMetadata and adds it to the ledger
+// ID store. This is intended for reconstructing the ledger ID store when it is lost while the data of the ledgers
+// survives. If the metadata of any of the ledgers already exists in the ledger ID store, the import fails, unless
+// overwrite is set. The metadata of all the ledgers is written atomically
+func (p *Provider) ImportMetadata(r io.Reader, overwrite bool) error {
+	if err := p.acquireCloseRLock(); err != nil {
+		return err
+	}
+	defer p.closeLock.RUnlock()
+
+	var imported []*ExportedLedgerMetadata
+	if err := json.NewDecoder(r).Decode(&imported); err != nil {
+		return errors.Wrap(err, "error while reading the ledger metadata to import")
+	}
+
+	batch := &leveldb.Batch{}
+	ledgerIDs := map[string]struct{}{}
+	for _, e := range imported {
+		if e.LedgerID == "" {
+			return errors.New("cannot import ledger metadata with an empty ledger ID")
+		}
+		if _, ok := ledgerIDs[e.LedgerID]; ok {
+			return errors.Errorf("cannot import ledger metadata, ledger [%s] appears more than once", e.LedgerID)
+		}
+		ledgerIDs[e.LedgerID] = struct{}{}
+
+		status, ok := msgs.Status_value[e.Status]
+		if !ok {
+			return errors.Errorf("cannot import ledger metadata, unknown status [%s] for ledger [%s]", e.Status, e.LedgerID)
+		}
+		metadata := &msgs.LedgerMetadata{Status: msgs.Status(status)}
+		if e.BootSnapshotSignableMetadata != "" || e.BootSnapshotAdditionalMetadata != "" {
+			metadata.BootSnapshotMetadata = &msgs.BootSnapshotMetadata{
+				SingableMetadata:   e.BootSnapshotSignableMetadata,
+				AdditionalMetadata: e.BootSnapshotAdditionalMetadata,
+			}
+			if _, err := snapshotMetadataFromProto(metadata.BootSnapshotMetadata); err != nil {
+				return errors.WithMessagef(err, "cannot import ledger metadata, invalid boot snapshot metadata for ledger [%s]", e.LedgerID)
+			}
+		}
+
+		if !overwrite {
+			exists, err := p.idStore.ledgerIDExists(e.LedgerID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return errors.Errorf("cannot import ledger metadata, ledger [%s] already exists", e.LedgerID)
+			}
+		}
+		metadataBytes, err := proto.Marshal(metadata)
+		if err != nil {
+			return errors.Wrapf(err, "error marshalling ledger metadata")
+		}
+		batch.Put(metadataKey(e.LedgerID), metadataBytes)
+	}
+	logger.Infof("Importing the metadata of [%d] ledgers", len(imported))
+	return p.idStore.db.WriteBatch(batch, true)
+}
+
+type ledgerIDAndMetadata struct {
+	ledgerID string
+	metadata *msgs.LedgerMetadata
+}
+
+// getAllLedgerMetadata returns the metadata of all the ledgers in the order of the ledger IDs
+func (s *idStore) getAllLedgerMetadata() ([]*ledgerIDAndMetadata, error) {
+	var all []*ledgerIDAndMetadata
+	itr := s.db.GetIterator(metadataKeyPrefix, metadataKeyStop)
+	defer itr.Release()
+	for itr.Error() == nil && itr.Next() {
+		id := ledgerIDFromMetadataKey(itr.Key())
+		metadata := &msgs.LedgerMetadata{}
+		if err := proto.Unmarshal(itr.Value(), metadata); err != nil {
+			logger.Errorf("Error unmarshalling ledger metadata: %s", err)
+			return nil, errors.Wrapf(err, "error unmarshalling metadata of ledger [%s]", id)
+		}
+		all = append(all, &ledgerIDAndMetadata{id, metadata})
+	}
+	if err := itr.Error(); err != nil {
+		logger.Errorf("Error getting ledger metadata from idStore: %s", err)
+		return nil, errors.Wrapf(err, "error getting ledger metadata from idStore")
+	}
+	return all, nil
+}
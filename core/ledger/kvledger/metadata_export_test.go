@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportMetadata(t *testing.T) {
+	provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	for _, ledgerID := range []string{"ledger1", "ledger2"} {
+		_, gb := testutil.NewBlockGenerator(t, ledgerID, false)
+		lgr, err := provider.CreateFromGenesisBlock(gb)
+		require.NoError(t, err)
+		lgr.Close()
+	}
+	require.NoError(t, provider.idStore.updateLedgerStatus("ledger2", msgs.Status_INACTIVE))
+
+	signableMetadata, err := (&SnapshotSignableMetadata{ChannelName: "ledger3", LastBlockNumber: 10}).ToJSON()
+	require.NoError(t, err)
+	additionalMetadata, err := (&snapshotAdditionalMetadata{SnapshotHashInHex: "0123ab"}).ToJSON()
+	require.NoError(t, err)
+	require.NoError(t, provider.idStore.createLedgerID("ledger3", &msgs.LedgerMetadata{
+		Status: msgs.Status_ACTIVE,
+		BootSnapshotMetadata: &msgs.BootSnapshotMetadata{
+			SingableMetadata:   string(signableMetadata),
+			AdditionalMetadata: string(additionalMetadata),
+		},
+	}))
+
+	exported := &bytes.Buffer{}
+	require.NoError(t, provider.ExportMetadata(exported))
+	var exportedMetadata []*ExportedLedgerMetadata
+	require.NoError(t, json.Unmarshal(exported.Bytes(), &exportedMetadata))
+	require.Equal(t,
+		[]*ExportedLedgerMetadata{
+			{LedgerID: "ledger1", Status: "ACTIVE"},
+			{LedgerID: "ledger2", Status: "INACTIVE"},
+			{
+				LedgerID:                       "ledger3",
+				Status:                         "ACTIVE",
+				BootstrappingSnapshotHash:      "0123ab",
+				BootSnapshotSignableMetadata:   string(signableMetadata),
+				BootSnapshotAdditionalMetadata: string(additionalMetadata),
+			},
+		},
+		exportedMetadata,
+	)
+
+	// import into a fresh idStore
+	freshProvider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer freshProvider.Close()
+	require.NoError(t, freshProvider.ImportMetadata(bytes.NewReader(exported.Bytes()), false))
+
+	ids, err := freshProvider.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"ledger1", "ledger3"}, ids)
+	for _, ledgerID := range []string{"ledger1", "ledger2", "ledger3"} {
+		expected, err := provider.idStore.getLedgerMetadata(ledgerID)
+		require.NoError(t, err)
+		actual, err := freshProvider.idStore.getLedgerMetadata(ledgerID)
+		require.NoError(t, err)
+		require.Equal(t, expected.String(), actual.String())
+	}
+	reexported := &bytes.Buffer{}
+	require.NoError(t, freshProvider.ExportMetadata(reexported))
+	require.Equal(t, exported.String(), reexported.String())
+
+	t.Run("existing-metadata", func(t *testing.T) {
+		err := freshProvider.ImportMetadata(bytes.NewReader(exported.Bytes()), false)
+		require.EqualError(t, err, "cannot import ledger metadata, ledger [ledger1] already exists")
+		require.NoError(t, freshProvider.ImportMetadata(bytes.NewReader(exported.Bytes()), true))
+	})
+
+	t.Run("invalid-input", func(t *testing.T) {
+		testcases := []struct {
+			input       string
+			expectedErr string
+		}{
+			{
+				input:       `[{"ledger_id": "ledger4", "status": "UNKNOWN"}]`,
+				expectedErr: "cannot import ledger metadata, unknown status [UNKNOWN] for ledger [ledger4]",
+			},
+			{
+				input:       `[{"ledger_id": "ledger4", "status": "ACTIVE"}, {"ledger_id": "ledger4", "status": "ACTIVE"}]`,
+				expectedErr: "cannot import ledger metadata, ledger [ledger4] appears more than once",
+			},
+			{
+				input:       `[{"status": "ACTIVE"}]`,
+				expectedErr: "cannot import ledger metadata with an empty ledger ID",
+			},
+		}
+		for _, tc := range testcases {
+			err := freshProvider.ImportMetadata(bytes.NewReader([]byte(tc.input)), false)
+			require.EqualError(t, err, tc.expectedErr)
+		}
+		exists, err := freshProvider.Exists("ledger4")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		err = freshProvider.ImportMetadata(bytes.NewReader([]byte("not-json")), false)
+		require.Contains(t, err.Error(), "error while reading the ledger metadata to import")
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/validation"
 )
 
@@ -17,7 +18,23 @@ type stats struct {
 	blockProcessingTime            metrics.Histogram
 	blockAndPvtdataStoreCommitTime metrics.Histogram
 	statedbCommitTime              metrics.Histogram
+	commitDuration                 metrics.Histogram
+	blockSize                      metrics.Histogram
 	transactionsCount              metrics.Counter
+	snapshotGenerationDuration     metrics.Histogram
+	lastSnapshotHeight             metrics.Gauge
+	snapshotBytesWritten           metrics.Counter
+	snapshotGenerationInProgress   metrics.Gauge
+	snapshotGenerationProgress     metrics.Gauge
+	autoCompactions                metrics.Counter
+	missingPvtdataCount            metrics.Gauge
+	oldestMissingPvtdataBlock      metrics.Gauge
+	lastPvtdataReconciledTime      metrics.Gauge
+	pvtdataCompactions             metrics.Counter
+	pvtdataCompactionReclaimedSize metrics.Counter
+	rebuildInProgress              metrics.Gauge
+	rebuildReplayedHeight          metrics.Gauge
+	rebuildTargetHeight            metrics.Gauge
 }
 
 func newStats(metricsProvider metrics.Provider) *stats {
@@ -25,7 +42,23 @@ func newStats(metricsProvider metrics.Provider) *stats {
 	stats.blockProcessingTime = metricsProvider.NewHistogram(blockProcessingTimeOpts)
 	stats.blockAndPvtdataStoreCommitTime = metricsProvider.NewHistogram(blockAndPvtdataStoreCommitTimeOpts)
 	stats.statedbCommitTime = metricsProvider.NewHistogram(statedbCommitTimeOpts)
+	stats.commitDuration = metricsProvider.NewHistogram(commitDurationOpts)
+	stats.blockSize = metricsProvider.NewHistogram(blockSizeOpts)
 	stats.transactionsCount = metricsProvider.NewCounter(transactionCountOpts)
+	stats.snapshotGenerationDuration = metricsProvider.NewHistogram(snapshotGenerationDurationOpts)
+	stats.lastSnapshotHeight = metricsProvider.NewGauge(lastSnapshotHeightOpts)
+	stats.snapshotBytesWritten = metricsProvider.NewCounter(snapshotBytesWrittenOpts)
+	stats.snapshotGenerationInProgress = metricsProvider.NewGauge(snapshotGenerationInProgressOpts)
+	stats.snapshotGenerationProgress = metricsProvider.NewGauge(snapshotGenerationProgressOpts)
+	stats.autoCompactions = metricsProvider.NewCounter(autoCompactionsOpts)
+	stats.missingPvtdataCount = metricsProvider.NewGauge(missingPvtdataCountOpts)
+	stats.oldestMissingPvtdataBlock = metricsProvider.NewGauge(oldestMissingPvtdataBlockOpts)
+	stats.lastPvtdataReconciledTime = metricsProvider.NewGauge(lastPvtdataReconciledTimeOpts)
+	stats.pvtdataCompactions = metricsProvider.NewCounter(pvtdataCompactionsOpts)
+	stats.pvtdataCompactionReclaimedSize = metricsProvider.NewCounter(pvtdataCompactionReclaimedSizeOpts)
+	stats.rebuildInProgress = metricsProvider.NewGauge(rebuildInProgressOpts)
+	stats.rebuildReplayedHeight = metricsProvider.NewGauge(rebuildReplayedHeightOpts)
+	stats.rebuildTargetHeight = metricsProvider.NewGauge(rebuildTargetHeightOpts)
 	return stats
 }
 
@@ -52,6 +85,63 @@ func (s *ledgerStats) updateStatedbCommitTime(timeTaken time.Duration) {
 	s.stats.statedbCommitTime.With("channel", s.ledgerid).Observe(timeTaken.Seconds())
 }
 
+func (s *ledgerStats) updateCommitDuration(timeTaken time.Duration) {
+	s.stats.commitDuration.With("channel", s.ledgerid).Observe(timeTaken.Seconds())
+}
+
+func (s *ledgerStats) updateBlockSize(sizeInBytes int) {
+	s.stats.blockSize.With("channel", s.ledgerid).Observe(float64(sizeInBytes))
+}
+
+func (s *ledgerStats) updateSnapshotStats(timeTaken time.Duration, height uint64, bytesWritten int64) {
+	s.stats.snapshotGenerationDuration.With("channel", s.ledgerid).Observe(timeTaken.Seconds())
+	s.stats.lastSnapshotHeight.With("channel", s.ledgerid).Set(float64(height))
+	s.stats.snapshotBytesWritten.With("channel", s.ledgerid).Add(float64(bytesWritten))
+}
+
+func (s *ledgerStats) updateSnapshotGenerationInProgress(inProgress bool) {
+	v := float64(0)
+	if inProgress {
+		v = 1
+	}
+	s.stats.snapshotGenerationInProgress.With("channel", s.ledgerid).Set(v)
+}
+
+// snapshotGenerationProgressGauge returns the gauge that tracks the bytes written by the in-progress snapshot generation
+func (s *ledgerStats) snapshotGenerationProgressGauge() metrics.Gauge {
+	return s.stats.snapshotGenerationProgress.With("channel", s.ledgerid)
+}
+
+func (s *ledgerStats) updateAutoCompactions() {
+	s.stats.autoCompactions.With("channel", s.ledgerid).Add(1)
+}
+
+func (s *ledgerStats) updatePvtdataReconciliationStatus(status *ledger.PvtdataReconciliationStatus) {
+	s.stats.missingPvtdataCount.With("channel", s.ledgerid).Set(float64(status.MissingPvtdataCount))
+	s.stats.oldestMissingPvtdataBlock.With("channel", s.ledgerid).Set(float64(status.OldestMissingBlock))
+	if !status.LastReconciledTime.IsZero() {
+		s.stats.lastPvtdataReconciledTime.With("channel", s.ledgerid).Set(float64(status.LastReconciledTime.Unix()))
+	}
+}
+
+func (s *ledgerStats) updatePvtdataCompaction(reclaimedBytes int64) {
+	s.stats.pvtdataCompactions.With("channel", s.ledgerid).Add(1)
+	s.stats.pvtdataCompactionReclaimedSize.With("channel", s.ledgerid).Add(float64(reclaimedBytes))
+}
+
+func (s *ledgerStats) updateRebuildInProgress(inProgress bool, targetHeight uint64) {
+	v := float64(0)
+	if inProgress {
+		v = 1
+	}
+	s.stats.rebuildInProgress.With("channel", s.ledgerid).Set(v)
+	s.stats.rebuildTargetHeight.With("channel", s.ledgerid).Set(float64(targetHeight))
+}
+
+func (s *ledgerStats) updateRebuildReplayedHeight(replayedHeight uint64) {
+	s.stats.rebuildReplayedHeight.With("channel", s.ledgerid).Set(float64(replayedHeight))
+}
+
 func (s *ledgerStats) updateTransactionsStats(
 	txstatsInfo []*validation.TxStatInfo,
 ) {
@@ -106,6 +196,153 @@ var (
 		Buckets:      []float64{0.005, 0.01, 0.015, 0.05, 0.1, 1, 10},
 	}
 
+	commitDurationOpts = metrics.HistogramOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "commit_duration",
+		Help:         "Time taken in seconds for committing a block, including the validation and the commits to all the ledger databases.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+		Buckets:      []float64{0.005, 0.01, 0.015, 0.05, 0.1, 1, 10},
+	}
+
+	blockSizeOpts = metrics.HistogramOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "block_size_bytes",
+		Help:         "Size in bytes of the serialized blocks committed to the ledger.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+		Buckets:      []float64{1024, 10 * 1024, 100 * 1024, 1024 * 1024, 10 * 1024 * 1024, 100 * 1024 * 1024},
+	}
+
+	snapshotGenerationDurationOpts = metrics.HistogramOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "snapshot_generation_duration",
+		Help:         "Time taken in seconds for generating a snapshot of the ledger.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+		Buckets:      []float64{1, 10, 60, 300, 900, 1800, 3600},
+	}
+
+	lastSnapshotHeightOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "last_snapshot_height",
+		Help:         "Height of the chain in blocks at which the last snapshot of the ledger was generated.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	snapshotBytesWrittenOpts = metrics.CounterOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "snapshot_bytes_written",
+		Help:         "Number of bytes written to the snapshot files of the ledger.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	snapshotGenerationInProgressOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "snapshot_generation_in_progress",
+		Help:         "Set to 1 while a snapshot of the ledger is being generated and to 0 otherwise.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	snapshotGenerationProgressOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "snapshot_generation_progress_bytes",
+		Help:         "Number of bytes written to the snapshot files by the in-progress or the last snapshot generation of the ledger.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	autoCompactionsOpts = metrics.CounterOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "auto_compactions",
+		Help:         "Number of scheduled compactions of the state database and the history database completed.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	missingPvtdataCountOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "missing_pvtdata_count",
+		Help:         "Number of the private write sets of the eligible collections that are missing and pending the reconciliation.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	oldestMissingPvtdataBlockOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "oldest_missing_pvtdata_block",
+		Help:         "Lowest block number that misses the private data of an eligible collection, or 0 if no private data is missing.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	lastPvtdataReconciledTimeOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "last_pvtdata_reconciled_time",
+		Help:         "Unix time in seconds at which the private data fetched by the reconciler was last committed.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	pvtdataCompactionsOpts = metrics.CounterOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "pvtdata_compactions",
+		Help:         "Number of compactions of the pvtdata store completed, including the scheduled compactions.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	pvtdataCompactionReclaimedSizeOpts = metrics.CounterOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "pvtdata_compaction_reclaimed_bytes",
+		Help:         "Approximate number of bytes of the file system space reclaimed by the compactions of the pvtdata store.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	rebuildInProgressOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "rebuild_in_progress",
+		Help:         "Set to 1 while the blocks are being replayed into the state database or the history database of the ledger and to 0 otherwise.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	rebuildReplayedHeightOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "rebuild_replayed_height",
+		Help:         "Number of blocks replayed into the state database or the history database of the ledger by the in-progress or the last rebuild.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
+	rebuildTargetHeightOpts = metrics.GaugeOpts{
+		Namespace:    "ledger",
+		Subsystem:    "",
+		Name:         "rebuild_target_height",
+		Help:         "Number of blocks to be replayed into the state database or the history database of the ledger by the in-progress or the last rebuild.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+
 	transactionCountOpts = metrics.CounterOpts{
 		Namespace:    "ledger",
 		Subsystem:    "",
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package kvledger
 
 import (
+	"fmt"
+	"io/ioutil"
 	"testing"
 	"time"
 
@@ -141,12 +143,285 @@ func TestStatsBlockCommit(t *testing.T) {
 	)
 }
 
+func TestStatsCommitDurationAndBlockSize(t *testing.T) {
+	conf := testConfig(t)
+	testMetricProvider := testutilConstructMetricProvider()
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	provider, err := NewProvider(
+		&lgr.Initializer{
+			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+			MetricsProvider:               testMetricProvider.fakeProvider,
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	ledgerid := "ledger1"
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	l, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer l.Close()
+
+	for i := 1; i <= 2; i++ {
+		blockAndPvtdata := prepareNextBlockForTest(t, l, bg, fmt.Sprintf("txid%d", i),
+			map[string]string{fmt.Sprintf("key%d", i): fmt.Sprintf("value%d", i)}, nil)
+		require.NoError(t, l.CommitLegacy(blockAndPvtdata, &lgr.CommitOptions{}))
+	}
+
+	fakeHeightGauge := testMetricProvider.fakeBlockchainHeightGauge
+	require.Equal(t, []string{"channel", ledgerid}, fakeHeightGauge.WithArgsForCall(fakeHeightGauge.WithCallCount()-1))
+	require.Equal(t, float64(3), fakeHeightGauge.SetArgsForCall(fakeHeightGauge.SetCallCount()-1))
+
+	fakeCommitDurationHist := testMetricProvider.fakeCommitDurationHist
+	require.GreaterOrEqual(t, fakeCommitDurationHist.ObserveCallCount(), 2)
+	for i := 0; i < fakeCommitDurationHist.WithCallCount(); i++ {
+		require.Equal(t, []string{"channel", ledgerid}, fakeCommitDurationHist.WithArgsForCall(i))
+	}
+
+	// the sizes of all the blocks add up to the size of the block file
+	fakeBlockSizeHist := testMetricProvider.fakeBlockSizeHist
+	require.Equal(t, 3, fakeBlockSizeHist.ObserveCallCount())
+	totalBlocksSize := float64(0)
+	for i := 0; i < 3; i++ {
+		require.Equal(t, []string{"channel", ledgerid}, fakeBlockSizeHist.WithArgsForCall(i))
+		require.Greater(t, fakeBlockSizeHist.ObserveArgsForCall(i), float64(0))
+		totalBlocksSize += fakeBlockSizeHist.ObserveArgsForCall(i)
+	}
+	checkpoint, err := l.BlockStoreCheckpointInfo()
+	require.NoError(t, err)
+	require.Equal(t, float64(checkpoint.Offset), totalBlocksSize)
+}
+
+func TestStatsSnapshotGeneration(t *testing.T) {
+	conf := testConfig(t)
+	testMetricProvider := testutilConstructMetricProvider()
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	provider, err := NewProvider(
+		&lgr.Initializer{
+			DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+			MetricsProvider:               testMetricProvider.fakeProvider,
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	ledgerid := "ledger1"
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	l, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer l.Close()
+	blockAndPvtdata := prepareNextBlockForTest(t, l, bg, "txid1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, l.CommitLegacy(blockAndPvtdata, &lgr.CommitOptions{}))
+
+	require.NoError(t, l.(*kvLedger).generateSnapshot(""))
+
+	fakeDurationHist := testMetricProvider.fakeSnapshotGenerationDurationHist
+	require.Equal(t, 1, fakeDurationHist.ObserveCallCount())
+	require.Equal(t, []string{"channel", ledgerid}, fakeDurationHist.WithArgsForCall(0))
+	require.Greater(t, fakeDurationHist.ObserveArgsForCall(0), float64(0))
+
+	fakeHeightGauge := testMetricProvider.fakeLastSnapshotHeightGauge
+	require.Equal(t, 1, fakeHeightGauge.SetCallCount())
+	require.Equal(t, []string{"channel", ledgerid}, fakeHeightGauge.WithArgsForCall(0))
+	require.Equal(t, float64(2), fakeHeightGauge.SetArgsForCall(0))
+
+	// the bytes written add up to the size of the files in the snapshot dir
+	files, err := ioutil.ReadDir(SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, ledgerid, 1))
+	require.NoError(t, err)
+	snapshotSize := int64(0)
+	for _, f := range files {
+		snapshotSize += f.Size()
+	}
+	fakeBytesCounter := testMetricProvider.fakeSnapshotBytesWrittenCounter
+	require.Equal(t, 1, fakeBytesCounter.AddCallCount())
+	require.Equal(t, []string{"channel", ledgerid}, fakeBytesCounter.WithArgsForCall(0))
+	require.Equal(t, float64(snapshotSize), fakeBytesCounter.AddArgsForCall(0))
+
+	fakeInProgressGauge := testMetricProvider.fakeSnapshotGenerationInProgressGauge
+	require.Equal(t, 2, fakeInProgressGauge.SetCallCount())
+	require.Equal(t, []string{"channel", ledgerid}, fakeInProgressGauge.WithArgsForCall(0))
+	require.Equal(t, float64(1), fakeInProgressGauge.SetArgsForCall(0))
+	require.Equal(t, float64(0), fakeInProgressGauge.SetArgsForCall(1))
+
+	// the progress adds up to the size of the data files, i.e., the files other than the metadata files
+	fakeProgressGauge := testMetricProvider.fakeSnapshotGenerationProgressGauge
+	require.Equal(t, 1, fakeProgressGauge.SetCallCount())
+	require.Equal(t, float64(0), fakeProgressGauge.SetArgsForCall(0))
+	progress := float64(0)
+	for i := 0; i < fakeProgressGauge.AddCallCount(); i++ {
+		progress += fakeProgressGauge.AddArgsForCall(i)
+	}
+	dataFilesSize := snapshotSize
+	for _, f := range files {
+		if f.Name() == SnapshotSignableMetadataFileName || f.Name() == snapshotAdditionalMetadataFileName {
+			dataFilesSize -= f.Size()
+		}
+	}
+	require.Equal(t, float64(dataFilesSize), progress)
+}
+
+func TestStatsPvtdataReconciliation(t *testing.T) {
+	conf := testConfig(t)
+	testMetricProvider := testutilConstructMetricProvider()
+	ccInfoProvider := &mock.DeployedChaincodeInfoProvider{}
+	ccInfoProvider.CollectionInfoReturns(&peer.StaticCollectionConfig{BlockToLive: 0}, nil)
+	ccInfoProvider.AllCollectionsConfigPkgReturns(
+		testutilCollConfigPkg([]*peer.StaticCollectionConfig{{Name: "coll"}}),
+		nil,
+	)
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	provider, err := NewProvider(
+		&lgr.Initializer{
+			DeployedChaincodeInfoProvider: ccInfoProvider,
+			MetricsProvider:               testMetricProvider.fakeProvider,
+			Config:                        conf,
+			HashProvider:                  cryptoProvider,
+		},
+	)
+	require.NoError(t, err)
+	defer provider.Close()
+
+	ledgerid := "ledger1"
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	l, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer l.Close()
+
+	// the metrics are reported when the ledger is opened
+	fakeCountGauge := testMetricProvider.fakeMissingPvtdataCountGauge
+	fakeOldestBlockGauge := testMetricProvider.fakeOldestMissingPvtdataBlockGauge
+	fakeReconciledTimeGauge := testMetricProvider.fakeLastPvtdataReconciledTimeGauge
+	require.Equal(t, 1, fakeCountGauge.SetCallCount())
+	require.Equal(t, []string{"channel", ledgerid}, fakeCountGauge.WithArgsForCall(0))
+	require.Equal(t, float64(0), fakeCountGauge.SetArgsForCall(0))
+	require.Equal(t, 1, fakeOldestBlockGauge.SetCallCount())
+	require.Equal(t, 0, fakeReconciledTimeGauge.SetCallCount())
+
+	blk1 := prepareNextBlockForTest(t, l, bg, "txid-1", map[string]string{"key1": "value1"}, map[string]string{"pvtkey1": "pvtvalue1"})
+	pvtdataOfBlk1 := blk1.PvtData
+	blk1.PvtData = nil
+	blk1.MissingPvtData = make(lgr.TxMissingPvtData)
+	blk1.MissingPvtData.Add(0, "ns", "coll", true)
+	require.NoError(t, l.CommitLegacy(blk1, &lgr.CommitOptions{}))
+
+	status, err := l.GetPvtdataReconciliationStatus()
+	require.NoError(t, err)
+	require.Equal(t, &lgr.PvtdataReconciliationStatus{MissingPvtdataCount: 1, OldestMissingBlock: 1}, status)
+	require.Equal(t, 2, fakeCountGauge.SetCallCount())
+	require.Equal(t, float64(1), fakeCountGauge.SetArgsForCall(1))
+	require.Equal(t, float64(1), fakeOldestBlockGauge.SetArgsForCall(1))
+	require.Equal(t, 0, fakeReconciledTimeGauge.SetCallCount())
+
+	beforeReconcile := time.Now()
+	hashMismatches, err := l.CommitPvtDataOfOldBlocks(
+		[]*lgr.ReconciledPvtdata{{BlockNum: 1, WriteSets: pvtdataOfBlk1}},
+		nil,
+	)
+	require.NoError(t, err)
+	require.Empty(t, hashMismatches)
+
+	// the metrics are reported after the commit of the reconciled private data
+	require.Equal(t, 3, fakeCountGauge.SetCallCount())
+	require.Equal(t, float64(0), fakeCountGauge.SetArgsForCall(2))
+	require.Equal(t, float64(0), fakeOldestBlockGauge.SetArgsForCall(2))
+	require.Equal(t, 1, fakeReconciledTimeGauge.SetCallCount())
+	require.Equal(t, []string{"channel", ledgerid}, fakeReconciledTimeGauge.WithArgsForCall(0))
+	require.GreaterOrEqual(t, fakeReconciledTimeGauge.SetArgsForCall(0), float64(beforeReconcile.Unix()))
+
+	status, err = l.GetPvtdataReconciliationStatus()
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), status.MissingPvtdataCount)
+	require.Equal(t, uint64(0), status.OldestMissingBlock)
+	require.False(t, status.LastReconciledTime.Before(beforeReconcile))
+}
+
+func TestStatsRebuild(t *testing.T) {
+	conf := testConfig(t)
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	require.NoError(t, err)
+	newProvider := func(metricsProvider metrics.Provider) *Provider {
+		provider, err := NewProvider(
+			&lgr.Initializer{
+				DeployedChaincodeInfoProvider: &mock.DeployedChaincodeInfoProvider{},
+				MetricsProvider:               metricsProvider,
+				Config:                        conf,
+				HashProvider:                  cryptoProvider,
+			},
+		)
+		require.NoError(t, err)
+		return provider
+	}
+
+	ledgerid := "ledger1"
+	provider := newProvider(testutilConstructMetricProvider().fakeProvider)
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	l, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, l, bg, fmt.Sprintf("txid-%d", i), map[string]string{"key1": "value1"}, nil)
+		require.NoError(t, l.CommitLegacy(blkAndPvtdata, &lgr.CommitOptions{}))
+	}
+	l.Close()
+	provider.Close()
+	require.NoError(t, RebuildDBs(conf))
+
+	// the metrics are reported while the dropped databases are rebuilt upon opening the ledger
+	testMetricProvider := testutilConstructMetricProvider()
+	provider = newProvider(testMetricProvider.fakeProvider)
+	defer provider.Close()
+	l, err = provider.Open(ledgerid)
+	require.NoError(t, err)
+	defer l.Close()
+
+	fakeInProgressGauge := testMetricProvider.fakeRebuildInProgressGauge
+	require.Equal(t, 2, fakeInProgressGauge.SetCallCount())
+	require.Equal(t, []string{"channel", ledgerid}, fakeInProgressGauge.WithArgsForCall(0))
+	require.Equal(t, float64(1), fakeInProgressGauge.SetArgsForCall(0))
+	require.Equal(t, float64(0), fakeInProgressGauge.SetArgsForCall(1))
+
+	fakeTargetGauge := testMetricProvider.fakeRebuildTargetHeightGauge
+	require.Equal(t, float64(4), fakeTargetGauge.SetArgsForCall(0))
+
+	fakeReplayedGauge := testMetricProvider.fakeRebuildReplayedHeightGauge
+	require.Equal(t, 4, fakeReplayedGauge.SetCallCount())
+	for i := 0; i < 4; i++ {
+		require.Equal(t, float64(i+1), fakeReplayedGauge.SetArgsForCall(i))
+	}
+}
+
 type testMetricProvider struct {
 	fakeProvider                              *metricsfakes.Provider
 	fakeBlockProcessingTimeHist               *metricsfakes.Histogram
 	fakeBlockstorageCommitWithPvtDataTimeHist *metricsfakes.Histogram
 	fakeStatedbCommitTimeHist                 *metricsfakes.Histogram
+	fakeCommitDurationHist                    *metricsfakes.Histogram
+	fakeBlockSizeHist                         *metricsfakes.Histogram
 	fakeTransactionsCount                     *metricsfakes.Counter
+	fakeBlockchainHeightGauge                 *metricsfakes.Gauge
+	fakeSnapshotGenerationDurationHist        *metricsfakes.Histogram
+	fakeLastSnapshotHeightGauge               *metricsfakes.Gauge
+	fakeSnapshotBytesWrittenCounter           *metricsfakes.Counter
+	fakeSnapshotGenerationInProgressGauge     *metricsfakes.Gauge
+	fakeSnapshotGenerationProgressGauge       *metricsfakes.Gauge
+	fakeAutoCompactionsCounter                *metricsfakes.Counter
+	fakeMissingPvtdataCountGauge              *metricsfakes.Gauge
+	fakeOldestMissingPvtdataBlockGauge        *metricsfakes.Gauge
+	fakeLastPvtdataReconciledTimeGauge        *metricsfakes.Gauge
+	fakePvtdataCompactionsCounter             *metricsfakes.Counter
+	fakePvtdataCompactionReclaimedSizeCounter *metricsfakes.Counter
+	fakeRebuildInProgressGauge                *metricsfakes.Gauge
+	fakeRebuildReplayedHeightGauge            *metricsfakes.Gauge
+	fakeRebuildTargetHeightGauge              *metricsfakes.Gauge
 }
 
 func testutilConstructMetricProvider() *testMetricProvider {
@@ -154,9 +429,48 @@ func testutilConstructMetricProvider() *testMetricProvider {
 	fakeBlockProcessingTimeHist := testutilConstructHist()
 	fakeBlockstorageCommitWithPvtDataTimeHist := testutilConstructHist()
 	fakeStatedbCommitTimeHist := testutilConstructHist()
+	fakeCommitDurationHist := testutilConstructHist()
+	fakeBlockSizeHist := testutilConstructHist()
 	fakeTransactionsCount := testutilConstructCounter()
+	fakeBlockchainHeightGauge := testutilConstructGauge()
+	fakeSnapshotGenerationDurationHist := testutilConstructHist()
+	fakeLastSnapshotHeightGauge := testutilConstructGauge()
+	fakeSnapshotBytesWrittenCounter := testutilConstructCounter()
+	fakeSnapshotGenerationInProgressGauge := testutilConstructGauge()
+	fakeSnapshotGenerationProgressGauge := testutilConstructGauge()
+	fakeAutoCompactionsCounter := testutilConstructCounter()
+	fakeMissingPvtdataCountGauge := testutilConstructGauge()
+	fakeOldestMissingPvtdataBlockGauge := testutilConstructGauge()
+	fakeLastPvtdataReconciledTimeGauge := testutilConstructGauge()
+	fakePvtdataCompactionsCounter := testutilConstructCounter()
+	fakePvtdataCompactionReclaimedSizeCounter := testutilConstructCounter()
+	fakeRebuildInProgressGauge := testutilConstructGauge()
+	fakeRebuildReplayedHeightGauge := testutilConstructGauge()
+	fakeRebuildTargetHeightGauge := testutilConstructGauge()
 	fakeProvider.NewGaugeStub = func(opts metrics.GaugeOpts) metrics.Gauge {
-		// return a gauge for metrics in common/ledger
+		switch opts.Name {
+		case lastSnapshotHeightOpts.Name:
+			return fakeLastSnapshotHeightGauge
+		case snapshotGenerationInProgressOpts.Name:
+			return fakeSnapshotGenerationInProgressGauge
+		case snapshotGenerationProgressOpts.Name:
+			return fakeSnapshotGenerationProgressGauge
+		case missingPvtdataCountOpts.Name:
+			return fakeMissingPvtdataCountGauge
+		case oldestMissingPvtdataBlockOpts.Name:
+			return fakeOldestMissingPvtdataBlockGauge
+		case lastPvtdataReconciledTimeOpts.Name:
+			return fakeLastPvtdataReconciledTimeGauge
+		case rebuildInProgressOpts.Name:
+			return fakeRebuildInProgressGauge
+		case rebuildReplayedHeightOpts.Name:
+			return fakeRebuildReplayedHeightGauge
+		case rebuildTargetHeightOpts.Name:
+			return fakeRebuildTargetHeightGauge
+		case "blockchain_height":
+			// return a gauge for metrics in common/ledger
+			return fakeBlockchainHeightGauge
+		}
 		return testutilConstructGauge()
 	}
 	fakeProvider.NewHistogramStub = func(opts metrics.HistogramOpts) metrics.Histogram {
@@ -167,6 +481,12 @@ func testutilConstructMetricProvider() *testMetricProvider {
 			return fakeBlockstorageCommitWithPvtDataTimeHist
 		case statedbCommitTimeOpts.Name:
 			return fakeStatedbCommitTimeHist
+		case commitDurationOpts.Name:
+			return fakeCommitDurationHist
+		case blockSizeOpts.Name:
+			return fakeBlockSizeHist
+		case snapshotGenerationDurationOpts.Name:
+			return fakeSnapshotGenerationDurationHist
 		default:
 			// return a histogram for metrics in common/ledger
 			return testutilConstructHist()
@@ -177,6 +497,14 @@ func testutilConstructMetricProvider() *testMetricProvider {
 		switch opts.Name {
 		case transactionCountOpts.Name:
 			return fakeTransactionsCount
+		case snapshotBytesWrittenOpts.Name:
+			return fakeSnapshotBytesWrittenCounter
+		case autoCompactionsOpts.Name:
+			return fakeAutoCompactionsCounter
+		case pvtdataCompactionsOpts.Name:
+			return fakePvtdataCompactionsCounter
+		case pvtdataCompactionReclaimedSizeOpts.Name:
+			return fakePvtdataCompactionReclaimedSizeCounter
 		}
 		return nil
 	}
@@ -185,7 +513,24 @@ func testutilConstructMetricProvider() *testMetricProvider {
 		fakeBlockProcessingTimeHist,
 		fakeBlockstorageCommitWithPvtDataTimeHist,
 		fakeStatedbCommitTimeHist,
+		fakeCommitDurationHist,
+		fakeBlockSizeHist,
 		fakeTransactionsCount,
+		fakeBlockchainHeightGauge,
+		fakeSnapshotGenerationDurationHist,
+		fakeLastSnapshotHeightGauge,
+		fakeSnapshotBytesWrittenCounter,
+		fakeSnapshotGenerationInProgressGauge,
+		fakeSnapshotGenerationProgressGauge,
+		fakeAutoCompactionsCounter,
+		fakeMissingPvtdataCountGauge,
+		fakeOldestMissingPvtdataBlockGauge,
+		fakeLastPvtdataReconciledTimeGauge,
+		fakePvtdataCompactionsCounter,
+		fakePvtdataCompactionReclaimedSizeCounter,
+		fakeRebuildInProgressGauge,
+		fakeRebuildReplayedHeightGauge,
+		fakeRebuildTargetHeightGauge,
 	}
 }
 
@@ -0,0 +1,108 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"github.com/pkg/errors"
+)
+
+// rebuildNamespaces drops the data of the given namespaces from the state DB and the history DB and recomputes it from
+// the blocks up to the savepoints of these DBs, leaving the data of the other namespaces untouched. This is invoked
+// while opening the ledger, before the DBs are caught up with the block store, for the namespaces recorded via the
+// function RebuildNamespace. The progress is checkpointed periodically so that an interrupted rebuild resumes from the
+// last checkpoint, without dropping the namespaces again, when the ledger is opened next
+func (l *kvLedger) rebuildNamespaces(namespaces []string) error {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	if l.bootSnapshotMetadata != nil {
+		return errors.Errorf("cannot rebuild namespaces %s for ledger [%s] as the ledger is created from a snapshot", namespaces, l.ledgerID)
+	}
+	if firstAvailableBlock := l.blockStore.FirstAvailableBlockNumber(); firstAvailableBlock > 0 {
+		return errors.Errorf("cannot rebuild namespaces %s for ledger [%s] as the blocks below block [%d] are pruned", namespaces, l.ledgerID, firstAvailableBlock)
+	}
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	replayedHeight, resumed, err := l.rebuildCheckpointer.namespacesRebuildProgress()
+	if err != nil {
+		return err
+	}
+	if resumed {
+		logger.Infof("Resuming the rebuild of namespaces %s for ledger [%s] from block [%d]", namespaces, l.ledgerID, replayedHeight)
+	} else {
+		logger.Infof("Rebuilding namespaces %s for ledger [%s]", namespaces, l.ledgerID)
+	}
+
+	// the number of blocks to be recommitted to each DB
+	var stateDBHeight, historyDBHeight uint64
+	savepoint, err := l.txmgr.GetLastSavepoint()
+	if err != nil {
+		return err
+	}
+	if savepoint != nil {
+		stateDBHeight = savepoint.BlockNum + 1
+	}
+	if l.historyDB != nil {
+		savepoint, err := l.historyDB.GetLastSavepoint()
+		if err != nil {
+			return err
+		}
+		if savepoint != nil {
+			historyDBHeight = savepoint.BlockNum + 1
+		}
+	}
+	if !resumed {
+		if err := l.txmgr.DropNamespaces(namespaces); err != nil {
+			return errors.WithMessagef(err, "error while dropping namespaces %s from the state database", namespaces)
+		}
+		if l.historyDB != nil {
+			if err := l.historyDB.DropNamespaces(namespaces); err != nil {
+				return errors.WithMessagef(err, "error while dropping namespaces %s from the history database", namespaces)
+			}
+		}
+		if err := l.rebuildCheckpointer.checkpointNamespacesRebuild(0); err != nil {
+			return err
+		}
+	}
+
+	lastBlockNum := stateDBHeight
+	if historyDBHeight > lastBlockNum {
+		lastBlockNum = historyDBHeight
+	}
+	if lastBlockNum > info.Height {
+		lastBlockNum = info.Height
+	}
+	l.stats.updateRebuildInProgress(true, lastBlockNum)
+	defer l.stats.updateRebuildInProgress(false, lastBlockNum)
+	for blockNum := replayedHeight; blockNum < lastBlockNum; blockNum++ {
+		blockAndPvtdata, err := l.GetPvtDataAndBlockByNum(blockNum, nil)
+		if err != nil {
+			return err
+		}
+		if blockNum < stateDBHeight {
+			if err := l.txmgr.RecommitNamespaces(blockAndPvtdata, namespaces); err != nil {
+				return err
+			}
+		}
+		if blockNum < historyDBHeight {
+			if err := l.historyDB.RecommitNamespaces(blockAndPvtdata.Block, namespaces); err != nil {
+				return err
+			}
+		}
+		l.stats.updateRebuildReplayedHeight(blockNum + 1)
+		if isRebuildCheckpoint(blockNum+1, lastBlockNum) {
+			if err := l.rebuildCheckpointer.checkpointNamespacesRebuild(blockNum + 1); err != nil {
+				return err
+			}
+			logger.Infof("Recommitted blocks up to [%d] for namespaces %s of ledger [%s]", blockNum, namespaces, l.ledgerID)
+		}
+	}
+	logger.Infof("Rebuilt namespaces %s for ledger [%s] from [%d] blocks", namespaces, l.ledgerID, lastBlockNum)
+	return nil
+}
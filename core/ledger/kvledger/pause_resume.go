@@ -38,10 +38,59 @@ func pauseOrResumeChannel(rootFSPath, ledgerID string, status msgs.Status) error
 	}
 	defer fileLock.Unlock()
 
-	idStore, err := openIDStore(LedgerProviderPath(rootFSPath))
+	idStore, err := openIDStore(LedgerProviderPath(rootFSPath), leveldbhelper.OpenRetry{})
 	if err != nil {
 		return err
 	}
 	defer idStore.db.Close()
 	return idStore.updateLedgerStatus(ledgerID, status)
 }
+
+// Deactivate implements the corresponding method from interface ledger.PeerLedgerProvider. This is the counterpart
+// of PauseChannel for a running peer. The status of the ledger is updated to inactive, which makes Open to refuse the
+// ledger, while the data of the ledger is retained. The caller is expected to close the ledger, if opened
+func (p *Provider) Deactivate(ledgerID string) error {
+	if err := p.acquireCloseRLock(); err != nil {
+		return err
+	}
+	defer p.closeLock.RUnlock()
+
+	if err := p.changeLedgerStatus(ledgerID, msgs.Status_ACTIVE, msgs.Status_INACTIVE); err != nil {
+		return errors.WithMessagef(err, "cannot deactivate ledger [%s]", ledgerID)
+	}
+	logger.Infof("The ledger [%s] has been successfully deactivated", ledgerID)
+	return nil
+}
+
+// Reactivate implements the corresponding method from interface ledger.PeerLedgerProvider. This is the counterpart
+// of ResumeChannel for a running peer. The status of the ledger is updated to active, so that the ledger can be
+// opened again
+func (p *Provider) Reactivate(ledgerID string) error {
+	if err := p.acquireCloseRLock(); err != nil {
+		return err
+	}
+	defer p.closeLock.RUnlock()
+
+	if err := p.changeLedgerStatus(ledgerID, msgs.Status_INACTIVE, msgs.Status_ACTIVE); err != nil {
+		return errors.WithMessagef(err, "cannot reactivate ledger [%s]", ledgerID)
+	}
+	logger.Infof("The ledger [%s] has been successfully reactivated", ledgerID)
+	return nil
+}
+
+// changeLedgerStatus updates the status of the ledger from the given status to the new status. Updating a ledger
+// that is already in the new status is a no-op and a ledger in any other status is not updated.
+// The caller is expected to hold the closeLock
+func (p *Provider) changeLedgerStatus(ledgerID string, from, to msgs.Status) error {
+	metadata, err := p.idStore.getLedgerMetadata(ledgerID)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		return errors.New("ledger does not exist")
+	}
+	if metadata.Status != from && metadata.Status != to {
+		return errors.Errorf("ledger status is [%s]", metadata.Status)
+	}
+	return p.idStore.updateLedgerStatus(ledgerID, to)
+}
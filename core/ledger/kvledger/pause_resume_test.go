@@ -107,6 +107,72 @@ func TestPauseAndResumeErrors(t *testing.T) {
 	require.ErrorContains(t, err, "error unmarshalling ledger metadata")
 }
 
+func TestDeactivateAndReactivate(t *testing.T) {
+	conf := testConfig(t)
+	conf.HistoryDBConfig.Enabled = false
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	ledgerIDs := []string{constructTestLedgerID(0), constructTestLedgerID(1)}
+	for _, ledgerID := range ledgerIDs {
+		genesisBlock, _ := configtxtest.MakeGenesisBlock(ledgerID)
+		lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+		lgr.Close()
+	}
+
+	require.NoError(t, provider.Deactivate(ledgerIDs[0]))
+	// deactivating again should not fail
+	require.NoError(t, provider.Deactivate(ledgerIDs[0]))
+	activeLedgerIDs, err := provider.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{ledgerIDs[1]}, activeLedgerIDs)
+	_, err = provider.Open(ledgerIDs[0])
+	require.EqualError(t, err, "cannot open ledger [ledger_000000], ledger status is [INACTIVE]")
+
+	// a deactivated ledger cannot be created again
+	genesisBlock, _ := configtxtest.MakeGenesisBlock(ledgerIDs[0])
+	_, err = provider.CreateFromGenesisBlock(genesisBlock)
+	require.Error(t, err)
+
+	require.NoError(t, provider.Reactivate(ledgerIDs[0]))
+	// reactivating again should not fail
+	require.NoError(t, provider.Reactivate(ledgerIDs[0]))
+	activeLedgerIDs, err = provider.List()
+	require.NoError(t, err)
+	require.ElementsMatch(t, ledgerIDs, activeLedgerIDs)
+
+	// the data of the ledger is retained
+	lgr, err := provider.Open(ledgerIDs[0])
+	require.NoError(t, err)
+	defer lgr.Close()
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), bcInfo.Height)
+}
+
+func TestDeactivateAndReactivateErrors(t *testing.T) {
+	conf := testConfig(t)
+	conf.HistoryDBConfig.Enabled = false
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+
+	require.EqualError(t, provider.Deactivate("dummy"), "cannot deactivate ledger [dummy]: ledger does not exist")
+	require.EqualError(t, provider.Reactivate("dummy"), "cannot reactivate ledger [dummy]: ledger does not exist")
+
+	ledgerID := constructTestLedgerID(0)
+	genesisBlock, _ := configtxtest.MakeGenesisBlock(ledgerID)
+	lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	lgr.Close()
+	require.NoError(t, provider.idStore.updateLedgerStatus(ledgerID, msgs.Status_UNDER_DELETION))
+	require.EqualError(t, provider.Deactivate(ledgerID), "cannot deactivate ledger [ledger_000000]: ledger status is [UNDER_DELETION]")
+	require.EqualError(t, provider.Reactivate(ledgerID), "cannot reactivate ledger [ledger_000000]: ledger status is [UNDER_DELETION]")
+
+	provider.Close()
+	require.Equal(t, &ProviderClosedError{}, provider.Deactivate(ledgerID))
+	require.Equal(t, &ProviderClosedError{}, provider.Reactivate(ledgerID))
+}
+
 // verify status for paused ledgers and non-paused ledgers
 func assertLedgerStatus(t *testing.T, provider *Provider, genesisBlocks []*common.Block, numLedgers int, pausedLedgers []int) {
 	s := provider.idStore
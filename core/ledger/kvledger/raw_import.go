@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// rawImportMarker records, in the idStore, that the blocks are appended to a ledger via AppendBlockRaw. The state
+// database and the history database of such a ledger do not reflect the appended blocks and hence, the commits that
+// build upon the databases are rejected for the ledger. A nil rawImportMarker records nothing
+type rawImportMarker struct {
+	idStore  *idStore
+	ledgerID string
+	lock     sync.Mutex
+	marked   bool
+}
+
+// mark records that the blocks are appended to the ledger via AppendBlockRaw. The mark is persisted before the
+// first block is appended, so that the ledger stays marked across a crash
+func (m *rawImportMarker) mark() error {
+	if m == nil {
+		return nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.marked {
+		return nil
+	}
+	if err := m.idStore.markRawImport(m.ledgerID); err != nil {
+		return err
+	}
+	m.marked = true
+	return nil
+}
+
+func (m *rawImportMarker) isMarked() bool {
+	if m == nil {
+		return false
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.marked
+}
+
+// checkNotRawImport returns an error if the blocks are appended to the ledger via AppendBlockRaw
+func (l *kvLedger) checkNotRawImport(operation string) error {
+	if l.rawImportMarker.isMarked() {
+		return errors.Errorf("%s is not permitted on ledger [%s] as its blocks are appended via AppendBlockRaw", operation, l.ledgerID)
+	}
+	return nil
+}
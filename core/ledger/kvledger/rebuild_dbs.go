@@ -10,12 +10,15 @@ import (
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
 	"github.com/pkg/errors"
 )
 
 // RebuildDBs drops existing ledger databases.
-// Dropped database will be rebuilt upon server restart
+// Dropped database will be rebuilt upon server restart. The progress of the rebuild of each ledger is checkpointed
+// and, if the databases are not rebuilt yet for all the ledgers, the databases are not dropped again so that
+// the rebuild resumes upon server restart
 func RebuildDBs(config *ledger.Config) error {
 	rootFSPath := config.RootFSPath
 	fileLockPath := fileLockPath(rootFSPath)
@@ -34,6 +37,34 @@ func RebuildDBs(config *ledger.Config) error {
 	if len(ledgerIDs) > 0 {
 		return errors.Errorf("cannot rebuild databases because the peer contains channel(s) %s that were bootstrapped from snapshot", ledgerIDs)
 	}
+	ledgerIDs, err = blkstorage.GetLedgersWithPrunedBlocks(blockstorePath)
+	if err != nil {
+		return errors.WithMessage(err, "error while checking if any ledger has pruned blocks")
+	}
+	if len(ledgerIDs) > 0 {
+		return errors.Errorf("cannot rebuild databases because the peer contains channel(s) %s whose blocks are pruned", ledgerIDs)
+	}
+
+	idStore, err := openIDStore(LedgerProviderPath(rootFSPath), leveldbhelper.OpenRetry{})
+	if err != nil {
+		return err
+	}
+	defer idStore.db.Close()
+	ledgerIDs, err = idStore.getLedgersWithRebuildInProgress()
+	if err != nil {
+		return err
+	}
+	if len(ledgerIDs) > 0 {
+		for _, ledgerID := range ledgerIDs {
+			replayedHeight, _, err := idStore.getRebuildProgress(ledgerID)
+			if err != nil {
+				return err
+			}
+			logger.Infof("Rebuild of the databases for channel [%s] is in progress, [%d] blocks replayed", ledgerID, replayedHeight)
+		}
+		logger.Infof("The databases are not dropped again, the rebuild resumes upon peer restart")
+		return nil
+	}
 
 	if config.StateDBConfig.StateDatabase == ledger.CouchDB {
 		if err := statecouchdb.DropApplicationDBs(config.StateDBConfig.CouchDB); err != nil {
@@ -43,5 +74,71 @@ func RebuildDBs(config *ledger.Config) error {
 	if err := dropDBs(rootFSPath); err != nil {
 		return err
 	}
-	return blkstorage.DeleteBlockStoreIndex(blockstorePath)
+	if err := blkstorage.DeleteBlockStoreIndex(blockstorePath); err != nil {
+		return err
+	}
+	ledgerIDs, err = idStore.getActiveLedgerIDs()
+	if err != nil {
+		return err
+	}
+	return idStore.markRebuildInProgress(ledgerIDs)
+}
+
+// RebuildNamespace records the given namespace of the ledger to be rebuilt. Upon peer restart, the data of the
+// namespace is dropped from the state database and the history database and is recomputed from the blocks, while
+// the data of the other namespaces of the ledger is retained
+func RebuildNamespace(config *ledger.Config, ledgerID, namespace string) error {
+	if namespace == "" {
+		return errors.New("namespace to rebuild must be specified")
+	}
+	rootFSPath := config.RootFSPath
+	fileLock := leveldbhelper.NewFileLock(fileLockPath(rootFSPath))
+	if err := fileLock.Lock(); err != nil {
+		return errors.Wrap(err, "as another peer node command is executing,"+
+			" wait for that command to complete its execution or terminate it before retrying")
+	}
+	defer fileLock.Unlock()
+
+	ledgerIDs, err := blkstorage.GetLedgersBootstrappedFromSnapshot(BlockStorePath(rootFSPath))
+	if err != nil {
+		return errors.WithMessage(err, "error while checking if any ledger has been bootstrapped from snapshot")
+	}
+	for _, id := range ledgerIDs {
+		if id == ledgerID {
+			return errors.Errorf("cannot rebuild namespace [%s] because the channel [%s] was bootstrapped from snapshot", namespace, ledgerID)
+		}
+	}
+	ledgerIDs, err = blkstorage.GetLedgersWithPrunedBlocks(BlockStorePath(rootFSPath))
+	if err != nil {
+		return errors.WithMessage(err, "error while checking if any ledger has pruned blocks")
+	}
+	for _, id := range ledgerIDs {
+		if id == ledgerID {
+			return errors.Errorf("cannot rebuild namespace [%s] because the blocks of the channel [%s] are pruned", namespace, ledgerID)
+		}
+	}
+	if config.HistoryDBConfig != nil && config.HistoryDBConfig.Enabled && config.HistoryDBConfig.SampleEveryN > 1 {
+		return errors.Errorf("cannot rebuild namespace [%s] because the history database is sampled", namespace)
+	}
+
+	idStore, err := openIDStore(LedgerProviderPath(rootFSPath), leveldbhelper.OpenRetry{})
+	if err != nil {
+		return err
+	}
+	defer idStore.db.Close()
+	metadata, err := idStore.getLedgerMetadata(ledgerID)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		return errors.Errorf("cannot rebuild namespace [%s], ledger [%s] does not exist", namespace, ledgerID)
+	}
+	if metadata.Status != msgs.Status_ACTIVE && metadata.Status != msgs.Status_INACTIVE {
+		return errors.Errorf("cannot rebuild namespace [%s], ledger [%s] is in [%s] status", namespace, ledgerID, metadata.Status)
+	}
+	if err := idStore.addNamespacesToRebuild(ledgerID, []string{namespace}); err != nil {
+		return err
+	}
+	logger.Infof("The namespace [%s] of channel [%s] will be rebuilt upon peer restart", namespace, ledgerID)
+	return nil
 }
@@ -7,10 +7,14 @@ SPDX-License-Identifier: Apache-2.0
 package kvledger
 
 import (
+	"fmt"
 	"path/filepath"
 	"testing"
 
 	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/mock"
 	"github.com/hyperledger/fabric/internal/fileutil"
 	"github.com/stretchr/testify/require"
@@ -57,3 +61,247 @@ func TestRebuildDBs(t *testing.T) {
 	err = RebuildDBs(conf)
 	require.NoError(t, err)
 }
+
+func TestRebuildDBsPrunedLedger(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key": "value"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	require.NoError(t, lgr.PruneBlocks(1))
+	provider.Close()
+
+	require.EqualError(t, RebuildDBs(conf),
+		"cannot rebuild databases because the peer contains channel(s) [testLedger] whose blocks are pruned",
+	)
+	require.EqualError(t, RebuildNamespace(conf, "testLedger", "ns"),
+		"cannot rebuild namespace [ns] because the blocks of the channel [testLedger] are pruned",
+	)
+	empty, err := fileutil.DirEmpty(StateDBPath(conf.RootFSPath))
+	require.NoError(t, err)
+	require.False(t, empty)
+}
+
+func TestRebuildDBsResume(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	numLedgers := 2
+	for i := 0; i < numLedgers; i++ {
+		genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(i))
+		_, err := provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+	}
+	provider.Close()
+
+	require.NoError(t, RebuildDBs(conf))
+	verifyRebuildProgress := func(expectedLedgerIDs []string) {
+		idStore, err := openIDStore(LedgerProviderPath(conf.RootFSPath), leveldbhelper.OpenRetry{})
+		require.NoError(t, err)
+		defer idStore.db.Close()
+		ledgerIDs, err := idStore.getLedgersWithRebuildInProgress()
+		require.NoError(t, err)
+		require.Equal(t, expectedLedgerIDs, ledgerIDs)
+		for _, ledgerID := range ledgerIDs {
+			replayedHeight, inProgress, err := idStore.getRebuildProgress(ledgerID)
+			require.NoError(t, err)
+			require.True(t, inProgress)
+			require.Equal(t, uint64(0), replayedHeight)
+		}
+	}
+	verifyRebuildProgress([]string{constructTestLedgerID(0), constructTestLedgerID(1)})
+
+	// the rebuild of a ledger completes when the ledger is opened
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	lgr, err := provider.Open(constructTestLedgerID(0))
+	require.NoError(t, err)
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	savepoint, err := lgr.(*kvLedger).txmgr.GetLastSavepoint()
+	require.NoError(t, err)
+	require.Equal(t, bcInfo.Height-1, savepoint.BlockNum)
+	lgr.Close()
+	provider.Close()
+	verifyRebuildProgress([]string{constructTestLedgerID(1)})
+
+	// the databases are not dropped again while the rebuild of any ledger is in progress
+	require.NoError(t, RebuildDBs(conf))
+	empty, err := fileutil.DirEmpty(StateDBPath(conf.RootFSPath))
+	require.NoError(t, err)
+	require.False(t, empty)
+	verifyRebuildProgress([]string{constructTestLedgerID(1)})
+
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	lgr, err = provider.Open(constructTestLedgerID(1))
+	require.NoError(t, err)
+	lgr.Close()
+	provider.Close()
+	verifyRebuildProgress(nil)
+}
+
+func TestRebuildNamespace(t *testing.T) {
+	conf := testConfig(t)
+	nsCollBtlConfs := []*nsCollBtlConfig{
+		{
+			namespace: "ns",
+			btlConfig: map[string]uint64{"coll": 0},
+		},
+	}
+	provider := testutilNewProviderWithCollectionConfig(t, nsCollBtlConfs, conf)
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)},
+			map[string]string{"pvtKey1": fmt.Sprintf("pvtValue1.%d", i)},
+		)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+
+		simulator, err := lgr.NewTxSimulator(fmt.Sprintf("SimulateForNs2Blk%d", i))
+		require.NoError(t, err)
+		require.NoError(t, simulator.SetState("ns2", "key2", []byte(fmt.Sprintf("value2.%d", i))))
+		simulator.Done()
+		simRes, err := simulator.GetTxSimulationResults()
+		require.NoError(t, err)
+		pubSimBytes, err := simRes.GetPubSimulationBytes()
+		require.NoError(t, err)
+		require.NoError(t, lgr.CommitLegacy(&ledger.BlockAndPvtData{Block: bg.NextBlock([][]byte{pubSimBytes})}, &ledger.CommitOptions{}))
+	}
+
+	expectedSummary := &bcSummary{
+		stateDBSavePoint:   6,
+		stateDBKVs:         map[string]string{"key1": "value1.3"},
+		stateDBPvtKVs:      map[string]string{"pvtKey1": "pvtValue1.3"},
+		historyDBSavePoint: 6,
+		historyKey:         "key1",
+		historyVals:        []string{"value1.3", "value1.2", "value1.1"},
+	}
+	checkBCSummaryForTest(t, lgr, expectedSummary)
+
+	// drop the namespace so that its data can only be recovered by the rebuild
+	kvl := lgr.(*kvLedger)
+	require.NoError(t, kvl.txmgr.DropNamespaces([]string{"ns"}))
+	require.NoError(t, kvl.historyDB.DropNamespaces([]string{"ns"}))
+	qe, err := lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	val, err := qe.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Nil(t, val)
+	val, err = qe.GetPrivateData("ns", "coll", "pvtKey1")
+	require.NoError(t, err)
+	require.Nil(t, val)
+	qe.Done()
+	checkHistoryDBForTest(t, lgr, "key1", nil)
+
+	// rebuild should fail when provider is still open
+	err = RebuildNamespace(conf, "testLedger", "ns")
+	require.Contains(t, err.Error(), "as another peer node command is executing, wait for that command to complete its execution or terminate it before retrying")
+	lgr.Close()
+	provider.Close()
+
+	require.NoError(t, RebuildNamespace(conf, "testLedger", "ns"))
+
+	provider = testutilNewProviderWithCollectionConfig(t, nsCollBtlConfs, conf)
+	defer provider.Close()
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	checkBCSummaryForTest(t, lgr, expectedSummary)
+
+	qe, err = lgr.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+	val, err = qe.GetState("ns2", "key2")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value2.3"), val)
+
+	// the namespace is not rebuilt again on the subsequent opens
+	namespaces, err := provider.idStore.getNamespacesToRebuild("testLedger")
+	require.NoError(t, err)
+	require.Empty(t, namespaces)
+}
+
+func TestRebuildNamespaceResume(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	for i := 1; i <= 3; i++ {
+		blkAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("SimulateForBlk%d", i),
+			map[string]string{"key1": fmt.Sprintf("value1.%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blkAndPvtdata, &ledger.CommitOptions{}))
+	}
+
+	// simulate an interrupted rebuild that dropped the namespace and replayed the blocks up to block 1
+	kvl := lgr.(*kvLedger)
+	require.NoError(t, kvl.txmgr.DropNamespaces([]string{"ns"}))
+	require.NoError(t, kvl.historyDB.DropNamespaces([]string{"ns"}))
+	lgr.Close()
+	provider.Close()
+	require.NoError(t, RebuildNamespace(conf, "testLedger", "ns"))
+	idStore, err := openIDStore(LedgerProviderPath(conf.RootFSPath), leveldbhelper.OpenRetry{})
+	require.NoError(t, err)
+	_, started, err := idStore.getNamespacesRebuildProgress("testLedger")
+	require.NoError(t, err)
+	require.False(t, started)
+	require.NoError(t, idStore.updateNamespacesRebuildProgress("testLedger", 2))
+	idStore.db.Close()
+
+	// the rebuild resumes from block 2 without dropping the namespace again
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+	lgr, err = provider.Open("testLedger")
+	require.NoError(t, err)
+	defer lgr.Close()
+	checkBCSummaryForTest(t, lgr,
+		&bcSummary{
+			stateDBKVs:  map[string]string{"key1": "value1.3"},
+			historyKey:  "key1",
+			historyVals: []string{"value1.3", "value1.2"},
+		},
+	)
+	_, started, err = provider.idStore.getNamespacesRebuildProgress("testLedger")
+	require.NoError(t, err)
+	require.False(t, started)
+}
+
+func TestRebuildNamespaceErrors(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	genesisBlock, _ := configtxtest.MakeGenesisBlock("testLedger")
+	lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	lgr.Close()
+	provider.Close()
+
+	t.Run("namespace-not-specified", func(t *testing.T) {
+		err := RebuildNamespace(conf, "testLedger", "")
+		require.EqualError(t, err, "namespace to rebuild must be specified")
+	})
+
+	t.Run("ledger-does-not-exist", func(t *testing.T) {
+		err := RebuildNamespace(conf, "non-existing-ledger", "ns")
+		require.EqualError(t, err, "cannot rebuild namespace [ns], ledger [non-existing-ledger] does not exist")
+	})
+
+	t.Run("history-sampled", func(t *testing.T) {
+		sampledConf := *conf
+		sampledConf.HistoryDBConfig = &ledger.HistoryDBConfig{Enabled: true, SampleEveryN: 2}
+		err := RebuildNamespace(&sampledConf, "testLedger", "ns")
+		require.EqualError(t, err, "cannot rebuild namespace [ns] because the history database is sampled")
+	})
+
+	t.Run("ledger-created-from-snapshot", func(t *testing.T) {
+		kvl := &kvLedger{
+			ledgerID:             "testLedger",
+			bootSnapshotMetadata: &SnapshotMetadata{},
+		}
+		err := kvl.rebuildNamespaces([]string{"ns"})
+		require.EqualError(t, err, "cannot rebuild namespaces [ns] for ledger [testLedger] as the ledger is created from a snapshot")
+	})
+}
@@ -0,0 +1,53 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+// rebuildCheckpointInterval is the number of replayed blocks after which the progress of a rebuild is checkpointed
+const rebuildCheckpointInterval = 1000
+
+// rebuildCheckpointer checkpoints, in the idStore, the progress of replaying the blocks into the state database and
+// the history database of a ledger that is being rebuilt, i.e., after the databases are dropped via RebuildDBs or a
+// namespace is recorded via RebuildNamespace. A nil rebuildCheckpointer checkpoints nothing
+type rebuildCheckpointer struct {
+	idStore  *idStore
+	ledgerID string
+	// fullRebuild is true if the databases of the ledger were dropped via RebuildDBs and are not rebuilt yet
+	fullRebuild bool
+}
+
+// checkpointFullRebuild records the number of blocks replayed into the databases dropped via RebuildDBs. As the
+// databases maintain their own savepoints, an interrupted rebuild resumes from the savepoints and the checkpoint
+// serves for reporting the progress, and for RebuildDBs not to drop the databases again
+func (c *rebuildCheckpointer) checkpointFullRebuild(replayedHeight uint64) error {
+	if c == nil || !c.fullRebuild {
+		return nil
+	}
+	return c.idStore.updateRebuildProgress(c.ledgerID, replayedHeight)
+}
+
+// namespacesRebuildProgress returns the number of blocks replayed for the namespaces recorded via RebuildNamespace.
+// The returned bool is false if the rebuild of the namespaces has not started yet
+func (c *rebuildCheckpointer) namespacesRebuildProgress() (uint64, bool, error) {
+	if c == nil {
+		return 0, false, nil
+	}
+	return c.idStore.getNamespacesRebuildProgress(c.ledgerID)
+}
+
+// checkpointNamespacesRebuild records the number of blocks replayed for the namespaces recorded via RebuildNamespace
+func (c *rebuildCheckpointer) checkpointNamespacesRebuild(replayedHeight uint64) error {
+	if c == nil {
+		return nil
+	}
+	return c.idStore.updateNamespacesRebuildProgress(c.ledgerID, replayedHeight)
+}
+
+// isRebuildCheckpoint returns true if the progress of a rebuild is to be checkpointed after replaying the blocks up to
+// the given height
+func isRebuildCheckpoint(replayedHeight, targetHeight uint64) bool {
+	return replayedHeight%rebuildCheckpointInterval == 0 || replayedHeight == targetHeight
+}
@@ -30,6 +30,13 @@ func ResetAllKVLedgers(rootFSPath string) error {
 	if len(ledgerIDs) > 0 {
 		return errors.Errorf("cannot reset channels because the peer contains channel(s) %s that were bootstrapped from snapshot", ledgerIDs)
 	}
+	ledgerIDs, err = blkstorage.GetLedgersWithPrunedBlocks(blockstorePath)
+	if err != nil {
+		return err
+	}
+	if len(ledgerIDs) > 0 {
+		return errors.Errorf("cannot reset channels because the peer contains channel(s) %s whose blocks are pruned", ledgerIDs)
+	}
 
 	logger.Info("Resetting all channel ledgers to genesis block")
 	logger.Infof("Ledger data folder from config = [%s]", rootFSPath)
@@ -30,6 +30,13 @@ func RollbackKVLedger(rootFSPath, ledgerID string, blockNum uint64) error {
 	if len(ledgerIDs) > 0 {
 		return errors.Errorf("cannot rollback any channel because the peer contains channel(s) %s that were bootstrapped from snapshot", ledgerIDs)
 	}
+	ledgerIDs, err = blkstorage.GetLedgersWithPrunedBlocks(blockstorePath)
+	if err != nil {
+		return errors.WithMessage(err, "error while checking if any ledger has pruned blocks")
+	}
+	if len(ledgerIDs) > 0 {
+		return errors.Errorf("cannot rollback any channel because the peer contains channel(s) %s whose blocks are pruned", ledgerIDs)
+	}
 
 	if err := blkstorage.ValidateRollbackParams(blockstorePath, ledgerID, blockNum); err != nil {
 		return err
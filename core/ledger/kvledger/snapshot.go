@@ -17,6 +17,10 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
@@ -48,6 +52,7 @@ type SnapshotSignableMetadata struct {
 	PreviousBlockHashInHex string            `json:"previous_block_hash"`
 	FilesAndHashes         map[string]string `json:"snapshot_files_raw_hashes"`
 	StateDBType            string            `json:"state_db_type"`
+	BaseSnapshot           *BaseSnapshotInfo `json:"base_snapshot,omitempty"`
 }
 
 func (m *SnapshotSignableMetadata) ToJSON() ([]byte, error) {
@@ -90,26 +95,50 @@ func (j *SnapshotMetadataJSONs) ToMetadata() (*SnapshotMetadata, error) {
 }
 
 // generateSnapshot generates a snapshot. This function should be invoked when commit on the kvledger are paused
-// after committing the last block fully and further the commits should not be resumed till this function finishes
-func (l *kvLedger) generateSnapshot() error {
+// after committing the last block fully and further the commits should not be resumed till this function finishes.
+// The snapshot is generated under the outputDir, if supplied, otherwise under the configured snapshots root dir
+func (l *kvLedger) generateSnapshot(outputDir string) error {
+	l.snapshotThrottle.acquireGenerationSlot()
+	defer l.snapshotThrottle.releaseGenerationSlot()
+	l.stats.updateSnapshotGenerationInProgress(true)
+	defer l.stats.updateSnapshotGenerationInProgress(false)
+
+	startTime := time.Now()
 	snapshotsRootDir := l.config.SnapshotsConfig.RootDir
+	if outputDir != "" {
+		if err := initSnapshotOutputDir(outputDir); err != nil {
+			return err
+		}
+		snapshotsRootDir = outputDir
+	}
 	bcInfo, err := l.GetBlockchainInfo()
 	if err != nil {
 		return err
 	}
 	lastBlockNum := bcInfo.Height - 1
+	snapshotTempDirPrefix := fmt.Sprintf("%s-%d-", l.ledgerID, lastBlockNum)
+	if err := removeStaleSnapshotTempDirs(SnapshotsTempDirPath(snapshotsRootDir), snapshotTempDirPrefix); err != nil {
+		return err
+	}
 	snapshotTempDir, err := ioutil.TempDir(
 		SnapshotsTempDirPath(snapshotsRootDir),
-		fmt.Sprintf("%s-%d-", l.ledgerID, lastBlockNum),
+		snapshotTempDirPrefix,
 	)
 	if err != nil {
 		return errors.Wrapf(err, "error while creating temp dir [%s]", snapshotTempDir)
 	}
 	defer os.RemoveAll(snapshotTempDir)
 
-	newHashFunc := func() (hash.Hash, error) {
-		return l.hashProvider.GetHash(snapshotHashOpts)
-	}
+	progressGauge := l.stats.snapshotGenerationProgressGauge()
+	progressGauge.Set(0)
+	newHashFunc := l.snapshotThrottle.wrapNewHashFunc(
+		func() (hash.Hash, error) {
+			return l.hashProvider.GetHash(snapshotHashOpts)
+		},
+		func(n int) {
+			progressGauge.Add(float64(n))
+		},
+	)
 
 	txIDsExportSummary, err := l.blockStore.ExportTxIds(snapshotTempDir, newHashFunc)
 	if err != nil {
@@ -123,15 +152,46 @@ func (l *kvLedger) generateSnapshot() error {
 	}
 	logger.Debugw("Exported collection config history", "channelID", l.ledgerID)
 
-	stateDBExportSummary, err := l.txmgr.ExportPubStateAndPvtStateHashes(snapshotTempDir, newHashFunc)
-	if err != nil {
-		return err
+	if l.config.SnapshotsConfig.CompactStateDB {
+		if err := l.txmgr.CompactStateDB(); err != nil {
+			return err
+		}
+		logger.Debugw("Compacted state database", "channelID", l.ledgerID)
+	}
+
+	var baseSnapshot *BaseSnapshotInfo
+	if l.config.SnapshotsConfig.Incremental {
+		if baseSnapshot, err = findBaseSnapshot(snapshotsRootDir, l.ledgerID, lastBlockNum); err != nil {
+			return err
+		}
+	}
+
+	var stateDBExportSummary map[string][]byte
+	if baseSnapshot == nil {
+		stateDBExportSummary, err = l.txmgr.ExportPubStateAndPvtStateHashes(snapshotTempDir, newHashFunc)
+		if err != nil {
+			return err
+		}
+		logger.Debugw("Exported public state and private state hashes", "channelID", l.ledgerID)
+	} else {
+		deleteCandidates, err := l.collectDeleteCandidates(baseSnapshot.LastBlockNumber, lastBlockNum)
+		if err != nil {
+			return err
+		}
+		stateDBExportSummary, err = l.txmgr.ExportPubStateAndPvtStateHashesDelta(
+			snapshotTempDir, newHashFunc, baseSnapshot.LastBlockNumber, deleteCandidates,
+		)
+		if err != nil {
+			return err
+		}
+		logger.Debugw("Exported the changes in public state and private state hashes since the base snapshot",
+			"channelID", l.ledgerID, "baseSnapshotBlockNum", baseSnapshot.LastBlockNumber)
 	}
-	logger.Debugw("Exported public state and private state hashes", "channelID", l.ledgerID)
 
 	if err := l.generateSnapshotMetadataFiles(
 		snapshotTempDir, txIDsExportSummary,
 		configsHistoryExportSummary, stateDBExportSummary,
+		baseSnapshot,
 	); err != nil {
 		return err
 	}
@@ -140,6 +200,10 @@ func (l *kvLedger) generateSnapshot() error {
 	if err := fileutil.SyncDir(snapshotTempDir); err != nil {
 		return err
 	}
+	bytesWritten, err := dirSize(snapshotTempDir)
+	if err != nil {
+		return err
+	}
 	slgr := SnapshotsDirForLedger(snapshotsRootDir, l.ledgerID)
 	if err := os.MkdirAll(slgr, 0o755); err != nil {
 		return errors.Wrapf(err, "error while creating final dir for snapshot:%s", slgr)
@@ -151,14 +215,92 @@ func (l *kvLedger) generateSnapshot() error {
 	if err := os.Rename(snapshotTempDir, slgrht); err != nil {
 		return errors.Wrapf(err, "error while renaming dir [%s] to [%s]:", snapshotTempDir, slgrht)
 	}
-	return fileutil.SyncParentDir(slgrht)
+	if err := fileutil.SyncParentDir(slgrht); err != nil {
+		return err
+	}
+	l.stats.updateSnapshotStats(time.Since(startTime), bcInfo.Height, bytesWritten)
+
+	if retain := l.config.SnapshotsConfig.RetainSnapshots; retain > 0 && outputDir == "" {
+		// a failure in removing the old snapshots does not fail the generated snapshot
+		if err := l.removeOldSnapshots(snapshotsRootDir, retain); err != nil {
+			logger.Errorw("Failed to remove the old snapshots", "channelID", l.ledgerID, "error", err)
+		}
+	}
+	return nil
+}
+
+// dirSize returns the total size of the files in the given dir
+func dirSize(dir string) (int64, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error while reading dir [%s]", dir)
+	}
+	size := int64(0)
+	for _, f := range files {
+		size += f.Size()
+	}
+	return size, nil
+}
+
+// removeStaleSnapshotTempDirs removes the temp dirs, under the given dir, that were left behind by an earlier
+// interrupted generation of the same snapshot. A temp dir is created by ioutil.TempDir and hence, its name is the
+// given prefix followed by a random number. Matching the suffix with digits only avoids removing the temp dirs of
+// another ledger whose ID begins with the given prefix
+func removeStaleSnapshotTempDirs(dir, prefix string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error while reading dir [%s]", dir)
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		if _, err := strconv.ParseUint(strings.TrimPrefix(e.Name(), prefix), 10, 64); err != nil {
+			continue
+		}
+		staleDir := filepath.Join(dir, e.Name())
+		logger.Infow("Removing the temp dir left behind by an interrupted snapshot generation", "dir", staleDir)
+		if err := os.RemoveAll(staleDir); err != nil {
+			return errors.Wrapf(err, "error while deleting the dir: %s", staleDir)
+		}
+	}
+	return nil
+}
+
+// initSnapshotOutputDir creates the temp and the completed snapshots dirs under the given output dir, if missing,
+// and verifies that the output dir is writable. Unlike the configured snapshots root dir, the existing temp dir
+// is not cleaned up, as the output dir may be shared by the snapshot generation of other ledgers
+func initSnapshotOutputDir(outputDir string) error {
+	if !filepath.IsAbs(outputDir) {
+		return errors.Errorf("invalid path: %s. The path for the snapshot output dir is expected to be an absolute path", outputDir)
+	}
+	inProgressSnapshotsPath := SnapshotsTempDirPath(outputDir)
+	completedSnapshotsPath := CompletedSnapshotsPath(outputDir)
+	for _, dir := range []string{inProgressSnapshotsPath, completedSnapshotsPath} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return errors.Wrapf(err, "error while creating the dir: %s, ensure peer has write access to the snapshot output dir", dir)
+		}
+	}
+	testFile, err := ioutil.TempFile(inProgressSnapshotsPath, "writable-check-")
+	if err != nil {
+		return errors.Wrapf(err, "snapshot output dir [%s] is not writable", outputDir)
+	}
+	testFile.Close()
+	if err := os.Remove(testFile.Name()); err != nil {
+		return errors.Wrapf(err, "error while deleting the file: %s", testFile.Name())
+	}
+	return fileutil.SyncDir(outputDir)
 }
 
 func (l *kvLedger) generateSnapshotMetadataFiles(
 	dir string,
 	txIDsExportSummary,
 	configsHistoryExportSummary,
-	stateDBExportSummary map[string][]byte) error {
+	stateDBExportSummary map[string][]byte,
+	baseSnapshot *BaseSnapshotInfo) error {
 	// generate metadata file
 	filesAndHashes := map[string]string{}
 	for fileName, hashsum := range txIDsExportSummary {
@@ -186,6 +328,7 @@ func (l *kvLedger) generateSnapshotMetadataFiles(
 		PreviousBlockHashInHex: hex.EncodeToString(bcInfo.PreviousBlockHash),
 		FilesAndHashes:         filesAndHashes,
 		StateDBType:            stateDBType,
+		BaseSnapshot:           baseSnapshot,
 	}
 
 	signableMetadataBytes, err := signableMetadata.ToJSON()
@@ -221,6 +364,11 @@ func (l *kvLedger) generateSnapshotMetadataFiles(
 // This function creates a new ledger from the supplied snapshot. If a failure happens during this
 // process, the partially created ledger is deleted
 func (p *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, string, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, "", err
+	}
+	defer p.closeLock.RUnlock()
+
 	metadataJSONs, err := loadSnapshotMetadataJSONs(snapshotDir)
 	if err != nil {
 		return nil, "", errors.WithMessagef(err, "error while loading metadata")
@@ -235,7 +383,17 @@ func (p *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, st
 		return nil, "", errors.WithMessagef(err, "error while verifying snapshot")
 	}
 
+	if metadata.BaseSnapshot != nil {
+		return nil, "", errors.Errorf(
+			"snapshot [%s] is an incremental snapshot over the snapshot at block [%d] and cannot be used for creating a ledger",
+			snapshotDir, metadata.BaseSnapshot.LastBlockNumber,
+		)
+	}
+
 	ledgerID := metadata.ChannelName
+	if err := p.validateLedgerID(ledgerID); err != nil {
+		return nil, "", err
+	}
 	lastBlockNum := metadata.LastBlockNumber
 	logger.Debugw("Verified hashes", "snapshotDir", snapshotDir, "ledgerID", ledgerID)
 
@@ -254,6 +412,10 @@ func (p *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, st
 		PreviousBlockHash: previousBlkHash,
 	}
 
+	if err := p.beginCreation(ledgerID); err != nil {
+		return nil, "", err
+	}
+	defer p.endCreation(ledgerID)
 	if err = p.idStore.createLedgerID(
 		ledgerID,
 		&msgs.LedgerMetadata{
@@ -294,7 +456,7 @@ func (p *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, st
 			Retriever:                     configHistoryRetiever,
 		},
 	)
-	purgeMgrBuilder := pvtstatepurgemgmt.NewPurgeMgrBuilder(ledgerID, btlPolicy, p.bookkeepingProvider)
+	purgeMgrBuilder := pvtstatepurgemgmt.NewPurgeMgrBuilder(ledgerID, btlPolicy, pubStateBTL(p.initializer.Config), p.bookkeepingProvider)
 	logger.Debugw("Constructed pvtdata hashes consumer for purge Mgr", "ledgerID", ledgerID)
 
 	pvtdataStoreBuilder, err := p.pvtdataStoreProvider.SnapshotDataImporterFor(
@@ -330,7 +492,7 @@ func (p *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, st
 		logger.Debugw("Preparing history db", "ledgerID", ledgerID)
 	}
 
-	lgr, err := p.open(ledgerID, metadata, true)
+	lgr, err := p.open(ledgerID, metadata, true, false)
 	if err != nil {
 		return nil, "", p.deleteUnderConstructionLedger(
 			lgr,
@@ -349,6 +511,35 @@ func (p *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, st
 	return lgr, ledgerID, nil
 }
 
+// VerifySnapshot verifies the integrity of the snapshot in the given dir. It checks the hash of the signable metadata
+// file against the snapshot hash recorded in the additional metadata file and the hashes of the individual snapshot
+// files against the ones listed in the signable metadata file. The files are checked in the order of their names and
+// the returned error names the first mismatching file. On success, the snapshot hash that covers the whole snapshot
+// is returned
+func (p *Provider) VerifySnapshot(snapshotDir string) ([]byte, error) {
+	if err := p.acquireCloseRLock(); err != nil {
+		return nil, err
+	}
+	defer p.closeLock.RUnlock()
+
+	metadataJSONs, err := loadSnapshotMetadataJSONs(snapshotDir)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while loading metadata")
+	}
+	metadata, err := metadataJSONs.ToMetadata()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while unmarshalling metadata")
+	}
+	if err := verifySnapshot(snapshotDir, metadata, p.initializer.HashProvider); err != nil {
+		return nil, errors.WithMessagef(err, "error while verifying snapshot")
+	}
+	snapshotHash, err := hex.DecodeString(metadata.SnapshotHashInHex)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while decoding snapshot hash")
+	}
+	return snapshotHash, nil
+}
+
 func loadSnapshotMetadataJSONs(snapshotDir string) (*SnapshotMetadataJSONs, error) {
 	signableMetadataFilePath := filepath.Join(snapshotDir, SnapshotSignableMetadataFileName)
 	signableMetadataBytes, err := ioutil.ReadFile(signableMetadataFilePath)
@@ -377,8 +568,13 @@ func verifySnapshot(snapshotDir string, snapshotMetadata *SnapshotMetadata, hash
 	}
 
 	filesAndHashes := snapshotMetadata.FilesAndHashes
-	for f, h := range filesAndHashes {
-		if err := verifyFileHash(snapshotDir, f, h, hashProvider); err != nil {
+	files := make([]string, 0, len(filesAndHashes))
+	for f := range filesAndHashes {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		if err := verifyFileHash(snapshotDir, f, filesAndHashes[f], hashProvider); err != nil {
 			return err
 		}
 	}
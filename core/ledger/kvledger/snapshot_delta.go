@@ -0,0 +1,135 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/hyperledger/fabric/internal/pkg/txflags"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/pkg/errors"
+)
+
+// BaseSnapshotInfo identifies the snapshot over which an incremental snapshot is generated. The base snapshot may
+// itself be an incremental snapshot and hence, the incremental snapshots form a chain that ends in a full snapshot.
+// The state as of an incremental snapshot is obtained by applying the snapshots in the chain in the order of their
+// block numbers, starting from the full snapshot
+type BaseSnapshotInfo struct {
+	LastBlockNumber   uint64 `json:"last_block_number"`
+	SnapshotHashInHex string `json:"snapshot_hash"`
+}
+
+// findBaseSnapshot returns the information of the most recent snapshot of the ledger, under the given snapshots root dir,
+// that is generated below the given block number. A nil value is returned if no such snapshot is present
+func findBaseSnapshot(snapshotsRootDir, ledgerID string, blockNum uint64) (*BaseSnapshotInfo, error) {
+	snapshotDirs, err := fileutil.ListSubdirs(SnapshotsDirForLedger(snapshotsRootDir, ledgerID))
+	if os.IsNotExist(errors.Cause(err)) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while listing the snapshots of ledger [%s]", ledgerID)
+	}
+
+	found := false
+	baseBlockNum := uint64(0)
+	for _, d := range snapshotDirs {
+		n, err := strconv.ParseUint(d, 10, 64)
+		if err != nil || n >= blockNum {
+			continue
+		}
+		if !found || n > baseBlockNum {
+			found = true
+			baseBlockNum = n
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+
+	baseSnapshotDir := SnapshotDirForLedgerBlockNum(snapshotsRootDir, ledgerID, baseBlockNum)
+	metadataJSONs, err := loadSnapshotMetadataJSONs(baseSnapshotDir)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while loading metadata of the base snapshot [%s]", baseSnapshotDir)
+	}
+	metadata, err := metadataJSONs.ToMetadata()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while unmarshalling metadata of the base snapshot [%s]", baseSnapshotDir)
+	}
+	return &BaseSnapshotInfo{
+		LastBlockNumber:   metadata.LastBlockNumber,
+		SnapshotHashInHex: metadata.SnapshotHashInHex,
+	}, nil
+}
+
+// collectDeleteCandidates returns the keys and the private data key hashes that are deleted, or purged, by the valid
+// transactions in the blocks after the block `baseBlockNum` up to the block `lastBlockNum`. A key in the returned set
+// may have been written again by a later transaction and hence, is deleted only if it is not present in the state
+func (l *kvLedger) collectDeleteCandidates(baseBlockNum, lastBlockNum uint64) (*privacyenabledstate.KeysSet, error) {
+	keysSet := privacyenabledstate.NewKeysSet()
+	itr, err := l.blockStore.RetrieveBlocks(baseBlockNum + 1)
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+
+	for blockNum := baseBlockNum + 1; blockNum <= lastBlockNum; blockNum++ {
+		res, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		block := res.(*common.Block)
+		txsFilter := txflags.ValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		for txNum, envBytes := range block.Data.Data {
+			if txsFilter.IsInvalid(txNum) {
+				continue
+			}
+			env, err := protoutil.GetEnvelopeFromBlock(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			payload, err := protoutil.UnmarshalPayload(env.Payload)
+			if err != nil {
+				return nil, err
+			}
+			chdr, err := protoutil.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+			if err != nil {
+				return nil, err
+			}
+			if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+				continue
+			}
+			respPayload, err := protoutil.GetActionFromEnvelope(envBytes)
+			if err != nil {
+				return nil, err
+			}
+			txRWSet := &rwsetutil.TxRwSet{}
+			if err := txRWSet.FromProtoBytes(respPayload.Results); err != nil {
+				return nil, err
+			}
+			for _, nsRWSet := range txRWSet.NsRwSets {
+				for _, w := range nsRWSet.KvRwSet.Writes {
+					if w.IsDelete {
+						keysSet.AddPubKey(nsRWSet.NameSpace, w.Key)
+					}
+				}
+				for _, collHashedRWSet := range nsRWSet.CollHashedRwSets {
+					for _, w := range collHashedRWSet.HashedRwSet.HashedWrites {
+						if w.IsDelete || w.IsPurge {
+							keysSet.AddKeyHash(nsRWSet.NameSpace, collHashedRWSet.CollectionName, w.KeyHash)
+						}
+					}
+				}
+			}
+		}
+	}
+	return keysSet, nil
+}
@@ -65,6 +65,9 @@ type requestResponse struct {
 // It returns an error if the specified block number is smaller than the last committed block number
 // or the requested block number already exists.
 func (l *kvLedger) SubmitSnapshotRequest(blockNumber uint64) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
 	l.snapshotMgr.events <- &event{requestAdd, blockNumber}
 	response := <-l.snapshotMgr.requestResponses
 	return response.err
@@ -73,6 +76,9 @@ func (l *kvLedger) SubmitSnapshotRequest(blockNumber uint64) error {
 // CancelSnapshotRequest cancels the previously submitted request.
 // It returns an error if such a request does not exist or is under processing.
 func (l *kvLedger) CancelSnapshotRequest(blockNumber uint64) error {
+	if err := l.checkNotClosed(); err != nil {
+		return err
+	}
 	l.snapshotMgr.events <- &event{requestCancel, blockNumber}
 	response := <-l.snapshotMgr.requestResponses
 	return response.err
@@ -80,6 +86,9 @@ func (l *kvLedger) CancelSnapshotRequest(blockNumber uint64) error {
 
 // PendingSnapshotRequests returns a list of block numbers for the pending (or under processing) snapshot requests.
 func (l *kvLedger) PendingSnapshotRequests() ([]uint64, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
 	return l.snapshotMgr.snapshotRequestBookkeeper.list()
 }
 
@@ -130,13 +139,16 @@ func (l *kvLedger) processSnapshotMgmtEvents(lastCommittedBlockNumber uint64) {
 		case commitDone:
 			lastCommittedBlockNumber = e.blockNumber
 			committerStatus = idle
+			if err := l.addScheduledSnapshotRequest(lastCommittedBlockNumber); err != nil {
+				logger.Errorw("Failed to add the scheduled snapshot request", "channelID", l.ledgerID, "blockNumber", lastCommittedBlockNumber, "error", err)
+			}
 			if lastCommittedBlockNumber != l.snapshotMgr.snapshotRequestBookkeeper.smallestRequestBlockNum {
 				continue
 			}
 			snapshotInProgress = true
 			go func() {
 				logger.Infow("Generating snapshot", "channelID", l.ledgerID, "lastCommittedBlockNumber", lastCommittedBlockNumber)
-				if err := l.generateSnapshot(); err != nil {
+				if err := l.generateSnapshot(""); err != nil {
 					logger.Errorw("Failed to generate snapshot", "channelID", l.ledgerID, "lastCommittedBlockNumber", lastCommittedBlockNumber, "error", err)
 				} else {
 					logger.Infow("Generated snapshot", "channelID", l.ledgerID, "lastCommittedBlockNumber", lastCommittedBlockNumber)
@@ -196,7 +208,7 @@ func (l *kvLedger) processSnapshotMgmtEvents(lastCommittedBlockNumber uint64) {
 				snapshotInProgress = true
 				go func() {
 					logger.Infow("Generating snapshot", "channelID", l.ledgerID, "lastCommittedBlockNumber", lastCommittedBlockNumber)
-					if err := l.generateSnapshot(); err != nil {
+					if err := l.generateSnapshot(""); err != nil {
 						logger.Errorw("Failed to generate snapshot", "channelID", l.ledgerID, "lastCommittedBlockNumber", lastCommittedBlockNumber, "error", err)
 					} else {
 						logger.Infow("Generated snapshot", "channelID", l.ledgerID, "lastCommittedBlockNumber", lastCommittedBlockNumber)
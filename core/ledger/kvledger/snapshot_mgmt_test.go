@@ -332,16 +332,17 @@ func TestSnapshotRequestsErrorPaths(t *testing.T) {
 	provider.Close()
 
 	_, err = provider.Open(ledgerID)
-	require.Contains(t, err.Error(), "leveldb: closed")
+	require.Equal(t, &ProviderClosedError{}, err)
 
+	expectedErr := &ledger.LedgerClosedError{LedgerID: ledgerID}
 	err = l.SubmitSnapshotRequest(20)
-	require.Contains(t, err.Error(), "leveldb: closed")
+	require.Equal(t, expectedErr, err)
 
 	err = l.CancelSnapshotRequest(1)
-	require.Contains(t, err.Error(), "leveldb: closed")
+	require.Equal(t, expectedErr, err)
 
 	_, err = l.PendingSnapshotRequests()
-	require.EqualError(t, err, "internal leveldb error while obtaining db iterator: leveldb: closed")
+	require.Equal(t, expectedErr, err)
 }
 
 func equal(slice1 []uint64, slice2 []uint64) bool {
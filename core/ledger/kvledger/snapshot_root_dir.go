@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+// migrateSnapshots moves the completed snapshots of all the ledgers from the previous snapshots root dir to the new
+// one. A snapshot is moved by renaming its dir and hence, the two root dirs are expected to be on the same filesystem.
+// The in-progress snapshots are not moved, as these are discarded on opening the provider anyway. If the migration
+// fails midway, it resumes with the remaining snapshots on the next attempt, as the new root dir is recorded only
+// after a successful migration
+func migrateSnapshots(prevRootDir, newRootDir string) error {
+	prevCompletedSnapshotsPath := CompletedSnapshotsPath(prevRootDir)
+	exists, err := fileutil.DirExists(prevCompletedSnapshotsPath)
+	if err != nil {
+		return errors.WithMessagef(err, "error while checking the previous snapshots dir %s", prevCompletedSnapshotsPath)
+	}
+	if !exists {
+		logger.Warnf("The previous snapshots dir %s does not exist, no snapshots to migrate", prevCompletedSnapshotsPath)
+		return nil
+	}
+
+	ledgerIDs, err := fileutil.ListSubdirs(prevCompletedSnapshotsPath)
+	if err != nil {
+		return errors.WithMessagef(err, "error while listing the previous snapshots dir %s", prevCompletedSnapshotsPath)
+	}
+	logger.Infof("Migrating the snapshots from [%s] to [%s]", prevRootDir, newRootDir)
+	for _, ledgerID := range ledgerIDs {
+		prevLedgerSnapshotsDir := SnapshotsDirForLedger(prevRootDir, ledgerID)
+		newLedgerSnapshotsDir := SnapshotsDirForLedger(newRootDir, ledgerID)
+		snapshotDirs, err := fileutil.ListSubdirs(prevLedgerSnapshotsDir)
+		if err != nil {
+			return errors.WithMessagef(err, "error while listing the previous snapshots dir %s", prevLedgerSnapshotsDir)
+		}
+		if err := os.MkdirAll(newLedgerSnapshotsDir, 0o755); err != nil {
+			return errors.Wrapf(err, "error while creating the dir: %s", newLedgerSnapshotsDir)
+		}
+		for _, snapshotDir := range snapshotDirs {
+			src := filepath.Join(prevLedgerSnapshotsDir, snapshotDir)
+			dest := filepath.Join(newLedgerSnapshotsDir, snapshotDir)
+			exists, err := fileutil.DirExists(dest)
+			if err != nil {
+				return errors.WithMessagef(err, "error while checking the dir %s", dest)
+			}
+			if exists {
+				return errors.Errorf("cannot migrate the snapshot %s, the dir %s already exists", src, dest)
+			}
+			if err := os.Rename(src, dest); err != nil {
+				return errors.Wrapf(err, "error while moving the snapshot dir %s to %s", src, dest)
+			}
+			logger.Infof("Migrated the snapshot %s to %s", src, dest)
+		}
+		if err := fileutil.SyncDir(newLedgerSnapshotsDir); err != nil {
+			return err
+		}
+		if err := os.Remove(prevLedgerSnapshotsDir); err != nil {
+			logger.Warnf("Could not remove the previous snapshots dir %s: %s", prevLedgerSnapshotsDir, err)
+		}
+	}
+	return fileutil.SyncDir(CompletedSnapshotsPath(newRootDir))
+}
+
+// getSnapshotsRootDir returns the snapshots root dir that was configured when the provider was last opened.
+// An empty string is returned if it has not been recorded, which is the case for a provider created with a
+// version of the peer that did not record the snapshots root dir
+func (s *idStore) getSnapshotsRootDir() (string, error) {
+	dir, err := s.db.Get(snapshotsRootDirKey)
+	if err != nil {
+		return "", errors.WithMessage(err, "error while retrieving the snapshots root dir from idStore")
+	}
+	return string(dir), nil
+}
+
+func (s *idStore) setSnapshotsRootDir(dir string) error {
+	return errors.WithMessage(
+		s.db.Put(snapshotsRootDirKey, []byte(dir), true),
+		"error while recording the snapshots root dir in idStore",
+	)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelocateSnapshotsRootDir(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	require.NoError(t, lgr.SubmitSnapshotRequest(0))
+	require.Eventually(t, func() bool {
+		exists, err := lgr.(*kvLedger).snapshotExists(1)
+		require.NoError(t, err)
+		return exists
+	}, time.Minute, 100*time.Millisecond)
+	lgr.Close()
+	provider.Close()
+
+	prevSnapshotsRootDir := conf.SnapshotsConfig.RootDir
+	relocationDir := t.TempDir()
+	snapshotExistsAt := func(rootDir string) bool {
+		exists, err := fileutil.DirExists(SnapshotDirForLedgerBlockNum(rootDir, "testLedger", 1))
+		require.NoError(t, err)
+		return exists
+	}
+
+	t.Run("migrate", func(t *testing.T) {
+		newSnapshotsRootDir := filepath.Join(relocationDir, "relocated-snapshots")
+		conf.SnapshotsConfig = &ledger.SnapshotsConfig{
+			RootDir:          newSnapshotsRootDir,
+			MigrateSnapshots: true,
+		}
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		lgr, err := provider.Open("testLedger")
+		require.NoError(t, err)
+		defer lgr.Close()
+
+		exists, err := lgr.(*kvLedger).snapshotExists(1)
+		require.NoError(t, err)
+		require.True(t, exists)
+		require.False(t, snapshotExistsAt(prevSnapshotsRootDir))
+		require.EqualError(t, lgr.SubmitSnapshotRequest(1), "snapshot already generated for block number 1")
+		prevSnapshotsRootDir = newSnapshotsRootDir
+	})
+
+	t.Run("no-migrate", func(t *testing.T) {
+		newSnapshotsRootDir := filepath.Join(relocationDir, "fresh-snapshots")
+		conf.SnapshotsConfig = &ledger.SnapshotsConfig{
+			RootDir: newSnapshotsRootDir,
+		}
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		lgr, err := provider.Open("testLedger")
+		require.NoError(t, err)
+		defer lgr.Close()
+
+		exists, err := lgr.(*kvLedger).snapshotExists(1)
+		require.NoError(t, err)
+		require.False(t, exists)
+		require.True(t, snapshotExistsAt(prevSnapshotsRootDir))
+	})
+}
@@ -0,0 +1,131 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+// snapshotScheduler submits a request for a snapshot at the last committed block of a ledger in the background
+// at the interval configured via SnapshotsConfig.AutoGenerateInterval
+type snapshotScheduler struct {
+	l        *kvLedger
+	interval time.Duration
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+func newSnapshotScheduler(l *kvLedger, interval time.Duration) *snapshotScheduler {
+	return &snapshotScheduler{
+		l:        l,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+}
+
+func (s *snapshotScheduler) run() {
+	defer close(s.doneCh)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			// the request fails if the snapshot for the last committed block is already generated
+			// or requested, which is expected when no block is committed since the last tick
+			if err := s.l.SubmitSnapshotRequest(0); err != nil {
+				logger.Debugf("[%s] Skipping the scheduled snapshot: %s", s.l.ledgerID, err)
+			}
+		}
+	}
+}
+
+// stop stops the scheduled snapshot requests and waits for the background goroutine to exit
+func (s *snapshotScheduler) stop() {
+	close(s.stopCh)
+	<-s.doneCh
+}
+
+// addScheduledSnapshotRequest adds a snapshot request for the given block if the block number is a multiple of
+// SnapshotsConfig.AutoGenerateEveryNBlocks. This is invoked by the snapshot management goroutine on a block commit,
+// before the pending requests are checked for a snapshot due at the block
+func (l *kvLedger) addScheduledSnapshotRequest(blockNum uint64) error {
+	everyNBlocks := l.config.SnapshotsConfig.AutoGenerateEveryNBlocks
+	if everyNBlocks == 0 || blockNum == 0 || blockNum%everyNBlocks != 0 {
+		return nil
+	}
+	bookkeeper := l.snapshotMgr.snapshotRequestBookkeeper
+	exists, err := bookkeeper.exist(blockNum)
+	if err != nil || exists {
+		return err
+	}
+	return bookkeeper.add(blockNum)
+}
+
+// removeOldSnapshots removes the snapshots of the ledger under the given snapshots root dir other than the `retain`
+// most recent ones. A snapshot that is in the chain of base snapshots of a retained incremental snapshot is retained
+// as well, as an incremental snapshot is of no use without its base snapshots
+func (l *kvLedger) removeOldSnapshots(snapshotsRootDir string, retain int) error {
+	snapshotsDir := SnapshotsDirForLedger(snapshotsRootDir, l.ledgerID)
+	snapshotDirs, err := fileutil.ListSubdirs(snapshotsDir)
+	if err != nil {
+		return errors.WithMessagef(err, "error while listing the snapshots of ledger [%s]", l.ledgerID)
+	}
+	var blockNums []uint64
+	for _, d := range snapshotDirs {
+		if n, err := strconv.ParseUint(d, 10, 64); err == nil {
+			blockNums = append(blockNums, n)
+		}
+	}
+	if len(blockNums) <= retain {
+		return nil
+	}
+	sort.Slice(blockNums, func(i, j int) bool { return blockNums[i] > blockNums[j] })
+
+	retained := map[uint64]struct{}{}
+	for _, n := range blockNums[:retain] {
+		for {
+			if _, ok := retained[n]; ok {
+				break
+			}
+			retained[n] = struct{}{}
+			snapshotDir := SnapshotDirForLedgerBlockNum(snapshotsRootDir, l.ledgerID, n)
+			metadataJSONs, err := loadSnapshotMetadataJSONs(snapshotDir)
+			if err != nil {
+				return errors.WithMessagef(err, "error while loading metadata of the snapshot [%s]", snapshotDir)
+			}
+			metadata, err := metadataJSONs.ToMetadata()
+			if err != nil {
+				return errors.WithMessagef(err, "error while unmarshalling metadata of the snapshot [%s]", snapshotDir)
+			}
+			if metadata.BaseSnapshot == nil {
+				break
+			}
+			n = metadata.BaseSnapshot.LastBlockNumber
+		}
+	}
+
+	for _, n := range blockNums[retain:] {
+		if _, ok := retained[n]; ok {
+			continue
+		}
+		snapshotDir := SnapshotDirForLedgerBlockNum(snapshotsRootDir, l.ledgerID, n)
+		if err := os.RemoveAll(snapshotDir); err != nil {
+			return errors.Wrapf(err, "error while removing the snapshot [%s]", snapshotDir)
+		}
+		logger.Infow("Removed old snapshot", "channelID", l.ledgerID, "blockNumber", n)
+	}
+	return fileutil.SyncDir(snapshotsDir)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotSchedulerEveryNBlocks(t *testing.T) {
+	conf := testConfig(t)
+	conf.SnapshotsConfig.AutoGenerateEveryNBlocks = 2
+	conf.SnapshotsConfig.RetainSnapshots = 2
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	require.Nil(t, lgr.(*kvLedger).snapshotScheduler)
+
+	for i := 1; i <= 6; i++ {
+		blockAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid%d", i), map[string]string{"key1": "value1"}, nil)
+		require.NoError(t, lgr.CommitLegacy(blockAndPvtdata, &ledger.CommitOptions{}))
+	}
+
+	// the snapshots are generated at blocks 2, 4, and 6 and the snapshot at block 2 is removed
+	require.Eventually(t, func() bool {
+		requests, err := lgr.PendingSnapshotRequests()
+		require.NoError(t, err)
+		return len(requests) == 0
+	}, time.Minute, 10*time.Millisecond)
+	snapshotDirs, err := fileutil.ListSubdirs(SnapshotsDirForLedger(conf.SnapshotsConfig.RootDir, "testLedger"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"4", "6"}, snapshotDirs)
+}
+
+func TestSnapshotSchedulerInterval(t *testing.T) {
+	conf := testConfig(t)
+	conf.SnapshotsConfig.AutoGenerateInterval = 10 * time.Millisecond
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	kvlgr := lgr.(*kvLedger)
+	require.NotNil(t, kvlgr.snapshotScheduler)
+
+	blockAndPvtdata := prepareNextBlockForTest(t, lgr, bg, "txid1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blockAndPvtdata, &ledger.CommitOptions{}))
+	require.Eventually(t, func() bool {
+		exists, err := kvlgr.snapshotExists(1)
+		require.NoError(t, err)
+		return exists
+	}, time.Minute, 10*time.Millisecond)
+
+	// the background goroutine exits on closing the ledger
+	lgr.Close()
+	select {
+	case <-kvlgr.snapshotScheduler.doneCh:
+	default:
+		t.Fatal("the snapshot scheduler goroutine is expected to have exited on close")
+	}
+}
+
+func TestRemoveOldSnapshots(t *testing.T) {
+	conf := testConfig(t)
+	conf.SnapshotsConfig.Incremental = true
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
+
+	// a full snapshot at block 1 followed by the incremental snapshots at blocks 2 and 3
+	for i := 1; i <= 3; i++ {
+		blockAndPvtdata := prepareNextBlockForTest(t, lgr, bg, fmt.Sprintf("txid%d", i), map[string]string{"key1": fmt.Sprintf("value%d", i)}, nil)
+		require.NoError(t, lgr.CommitLegacy(blockAndPvtdata, &ledger.CommitOptions{}))
+		require.NoError(t, kvlgr.generateSnapshot(""))
+	}
+
+	listSnapshots := func() []string {
+		snapshotDirs, err := fileutil.ListSubdirs(SnapshotsDirForLedger(conf.SnapshotsConfig.RootDir, "testLedger"))
+		require.NoError(t, err)
+		return snapshotDirs
+	}
+
+	// the base snapshots of the retained incremental snapshot are retained
+	require.NoError(t, kvlgr.removeOldSnapshots(conf.SnapshotsConfig.RootDir, 1))
+	require.ElementsMatch(t, []string{"1", "2", "3"}, listSnapshots())
+
+	// a full snapshot at block 4 leaves the earlier snapshots out of the chain
+	kvlgr.config.SnapshotsConfig.Incremental = false
+	blockAndPvtdata := prepareNextBlockForTest(t, lgr, bg, "txid4", map[string]string{"key1": "value4"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blockAndPvtdata, &ledger.CommitOptions{}))
+	require.NoError(t, kvlgr.generateSnapshot(""))
+	require.NoError(t, kvlgr.removeOldSnapshots(conf.SnapshotsConfig.RootDir, 2))
+	require.ElementsMatch(t, []string{"1", "2", "3", "4"}, listSnapshots())
+	require.NoError(t, kvlgr.removeOldSnapshots(conf.SnapshotsConfig.RootDir, 1))
+	require.ElementsMatch(t, []string{"4"}, listSnapshots())
+}
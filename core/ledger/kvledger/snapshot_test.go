@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package kvledger
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -32,6 +33,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	kvledgermock "github.com/hyperledger/fabric/core/ledger/kvledger/mock"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/msgs"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
 	"github.com/hyperledger/fabric/core/ledger/mock"
 	"github.com/hyperledger/fabric/internal/fileutil"
@@ -61,7 +63,7 @@ func TestSnapshotGenerationAndNewLedgerCreation(t *testing.T) {
 	require.NoError(t, err)
 	defer lgr.Close()
 	kvlgr := lgr.(*kvLedger)
-	require.NoError(t, kvlgr.generateSnapshot())
+	require.NoError(t, kvlgr.generateSnapshot(""))
 	verifySnapshotOutput(t,
 		&expectedSnapshotOutput{
 			snapshotRootDir:   snapshotRootDir,
@@ -87,7 +89,7 @@ func TestSnapshotGenerationAndNewLedgerCreation(t *testing.T) {
 		nil,
 	)
 	require.NoError(t, kvlgr.CommitLegacy(blockAndPvtdata1, &ledger.CommitOptions{}))
-	require.NoError(t, kvlgr.generateSnapshot())
+	require.NoError(t, kvlgr.generateSnapshot(""))
 	verifySnapshotOutput(t,
 		&expectedSnapshotOutput{
 			snapshotRootDir:   snapshotRootDir,
@@ -121,7 +123,7 @@ func TestSnapshotGenerationAndNewLedgerCreation(t *testing.T) {
 		},
 	)
 	require.NoError(t, kvlgr.CommitLegacy(blockAndPvtdata2, &ledger.CommitOptions{}))
-	require.NoError(t, kvlgr.generateSnapshot())
+	require.NoError(t, kvlgr.generateSnapshot(""))
 	verifySnapshotOutput(t,
 		&expectedSnapshotOutput{
 			snapshotRootDir:   snapshotRootDir,
@@ -149,7 +151,7 @@ func TestSnapshotGenerationAndNewLedgerCreation(t *testing.T) {
 		nil,
 	)
 	require.NoError(t, kvlgr.CommitLegacy(blockAndPvtdata3, &ledger.CommitOptions{}))
-	require.NoError(t, kvlgr.generateSnapshot())
+	require.NoError(t, kvlgr.generateSnapshot(""))
 	verifySnapshotOutput(t,
 		&expectedSnapshotOutput{
 			snapshotRootDir:   snapshotRootDir,
@@ -203,11 +205,311 @@ func TestSnapshotGenerationAndNewLedgerCreation(t *testing.T) {
 		)
 	})
 
+	t.Run("create-ledger-from-snapshot-with-history-enabled-later", func(t *testing.T) {
+		conf := testConfig(t)
+		conf.HistoryDBConfig.Enabled = false
+		p := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		destLedger, _, err := p.CreateFromSnapshot(snapshotDir)
+		require.NoError(t, err)
+		destLedger.Close()
+		p.Close()
+
+		conf.HistoryDBConfig.Enabled = true
+		p = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer p.Close()
+		destLedger, err = p.Open(kvlgr.ledgerID)
+		require.NoError(t, err)
+		defer destLedger.Close()
+
+		historydbSavepoint, err := destLedger.(*kvLedger).historyDB.GetLastSavepoint()
+		require.NoError(t, err)
+		require.Equal(t, version.NewHeight(3, math.MaxUint64), historydbSavepoint)
+	})
+
 	t.Run("create-ledger-from-snapshot-error-paths", func(t *testing.T) {
 		testCreateLedgerFromSnapshotErrorPaths(t, snapshotDir)
 	})
 }
 
+func TestSnapshotGenerationWithCompactedStateDB(t *testing.T) {
+	conf := testConfig(t)
+	conf.SnapshotsConfig.CompactStateDB = true
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedgerid", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
+
+	pubKVs := map[string]string{}
+	for i := 0; i < 10; i++ {
+		pubKVs[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	blk1 := prepareNextBlockForTest(t, kvlgr, bg, "SimulateForBlk1", pubKVs, nil)
+	require.NoError(t, kvlgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	// delete half of the keys
+	sim, err := kvlgr.NewTxSimulator("SimulateForBlk2")
+	require.NoError(t, err)
+	for i := 0; i < 10; i += 2 {
+		require.NoError(t, sim.DeleteState("ns", fmt.Sprintf("key%d", i)))
+	}
+	sim.Done()
+	simRes, err := sim.GetTxSimulationResults()
+	require.NoError(t, err)
+	pubSimBytes, err := simRes.GetPubSimulationBytes()
+	require.NoError(t, err)
+	blk2 := bg.NextBlock([][]byte{pubSimBytes})
+	require.NoError(t, kvlgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk2}, &ledger.CommitOptions{}))
+
+	require.NoError(t, kvlgr.generateSnapshot(""))
+	snapshotDir := SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, kvlgr.ledgerID, 2)
+	destLedger := testCreateLedgerFromSnapshot(t, snapshotDir, kvlgr.ledgerID)
+
+	qe, err := destLedger.NewQueryExecutor()
+	require.NoError(t, err)
+	defer qe.Done()
+	itr, err := qe.GetNamespaceFullScanIterator("ns")
+	require.NoError(t, err)
+	defer itr.Close()
+	exportedKVs := map[string]string{}
+	for {
+		res, err := itr.Next()
+		require.NoError(t, err)
+		if res == nil {
+			break
+		}
+		kv := res.(*ledger.VersionedKV)
+		exportedKVs[kv.Key] = string(kv.Value)
+	}
+	expectedKVs := map[string]string{}
+	for i := 1; i < 10; i += 2 {
+		expectedKVs[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	require.Equal(t, expectedKVs, exportedKVs)
+}
+
+func TestIncrementalSnapshotGeneration(t *testing.T) {
+	conf := testConfig(t)
+	conf.SnapshotsConfig.Incremental = true
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedgerid", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
+
+	loadSignableMetadata := func(blockNum uint64) *SnapshotSignableMetadata {
+		snapshotDir := SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, kvlgr.ledgerID, blockNum)
+		b, err := ioutil.ReadFile(filepath.Join(snapshotDir, SnapshotSignableMetadataFileName))
+		require.NoError(t, err)
+		m := &SnapshotSignableMetadata{}
+		require.NoError(t, json.Unmarshal(b, m))
+		return m
+	}
+
+	pubKVs := map[string]string{}
+	for i := 0; i < 4; i++ {
+		pubKVs[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	blk1 := prepareNextBlockForTest(t, kvlgr, bg, "SimulateForBlk1", pubKVs, nil)
+	require.NoError(t, kvlgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	// a full snapshot is generated in the absence of an earlier snapshot
+	require.NoError(t, kvlgr.generateSnapshot(""))
+	require.Nil(t, loadSignableMetadata(1).BaseSnapshot)
+
+	// update key1, delete key2, and delete and recreate key3
+	sim, err := kvlgr.NewTxSimulator("SimulateForBlk2")
+	require.NoError(t, err)
+	require.NoError(t, sim.SetState("ns", "key1", []byte("newValue1")))
+	require.NoError(t, sim.DeleteState("ns", "key2"))
+	require.NoError(t, sim.DeleteState("ns", "key3"))
+	sim.Done()
+	simRes, err := sim.GetTxSimulationResults()
+	require.NoError(t, err)
+	pubSimBytes, err := simRes.GetPubSimulationBytes()
+	require.NoError(t, err)
+	blk2 := bg.NextBlock([][]byte{pubSimBytes})
+	require.NoError(t, kvlgr.CommitLegacy(&ledger.BlockAndPvtData{Block: blk2}, &ledger.CommitOptions{}))
+	blk3 := prepareNextBlockForTest(t, kvlgr, bg, "SimulateForBlk3", map[string]string{"key3": "newValue3"}, nil)
+	require.NoError(t, kvlgr.CommitLegacy(blk3, &ledger.CommitOptions{}))
+
+	require.NoError(t, kvlgr.generateSnapshot(""))
+	baseSignableMetadata, err := ioutil.ReadFile(filepath.Join(
+		SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, kvlgr.ledgerID, 1), SnapshotSignableMetadataFileName,
+	))
+	require.NoError(t, err)
+	baseSnapshotHash := computeHashForTest(t, provider, baseSignableMetadata)
+	require.Equal(t,
+		&BaseSnapshotInfo{LastBlockNumber: 1, SnapshotHashInHex: baseSnapshotHash},
+		loadSignableMetadata(3).BaseSnapshot,
+	)
+
+	snapshotDir := SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, kvlgr.ledgerID, 3)
+	readKeys := func(dataFileName, metadataFileName string) []string {
+		reader, err := privacyenabledstate.NewSnapshotReader(snapshotDir, dataFileName, metadataFileName)
+		require.NoError(t, err)
+		defer reader.Close()
+		keys := []string{}
+		for {
+			_, record, err := reader.Next()
+			require.NoError(t, err)
+			if record == nil {
+				return keys
+			}
+			keys = append(keys, string(record.Key))
+		}
+	}
+	require.Equal(t, []string{"key1", "key3"},
+		readKeys(privacyenabledstate.PubStateDataFileName, privacyenabledstate.PubStateMetadataFileName),
+	)
+	require.Equal(t, []string{"key2"},
+		readKeys(privacyenabledstate.PubStateDeletesDataFileName, privacyenabledstate.PubStateDeletesMetadataFileName),
+	)
+
+	_, _, err = provider.CreateFromSnapshot(snapshotDir)
+	require.EqualError(t, err, fmt.Sprintf(
+		"snapshot [%s] is an incremental snapshot over the snapshot at block [1] and cannot be used for creating a ledger",
+		snapshotDir,
+	))
+}
+
+func TestSnapshotGenerationInOutputDir(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedgerid", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
+
+	blk1 := prepareNextBlockForTest(t, kvlgr, bg, "SimulateForBlk1",
+		map[string]string{"key1": "value1.1", "key2": "value2.1"}, nil)
+	require.NoError(t, kvlgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	t.Run("output-dir-is-created-if-missing", func(t *testing.T) {
+		outputDir := filepath.Join(t.TempDir(), "snapshots")
+		require.NoError(t, kvlgr.generateSnapshot(outputDir))
+		verifySnapshotOutput(t,
+			&expectedSnapshotOutput{
+				snapshotRootDir:   outputDir,
+				ledgerID:          kvlgr.ledgerID,
+				lastBlockNumber:   1,
+				lastBlockHash:     protoutil.BlockHeaderHash(blk1.Block.Header),
+				previousBlockHash: blk1.Block.Header.PreviousHash,
+				lastCommitHash:    kvlgr.commitHash,
+				stateDBType:       simpleKeyValueDB,
+				expectedBinaryFiles: []string{
+					"txids.data", "txids.metadata",
+					"public_state.data", "public_state.metadata",
+				},
+			},
+		)
+
+		// nothing is generated under the configured snapshots root dir
+		empty, err := fileutil.DirEmpty(CompletedSnapshotsPath(conf.SnapshotsConfig.RootDir))
+		require.NoError(t, err)
+		require.True(t, empty)
+
+		destLedger := testCreateLedgerFromSnapshot(t,
+			SnapshotDirForLedgerBlockNum(outputDir, kvlgr.ledgerID, 1),
+			kvlgr.ledgerID,
+		)
+		destBCInfo, err := destLedger.GetBlockchainInfo()
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), destBCInfo.Height)
+	})
+
+	t.Run("stale-temp-dirs-are-removed", func(t *testing.T) {
+		outputDir := filepath.Join(t.TempDir(), "snapshots")
+		tempDir := SnapshotsTempDirPath(outputDir)
+		// a partial snapshot of the same height, a temp dir of another ledger whose ID
+		// begins with the ledgerID, and an unrelated file
+		staleDir := filepath.Join(tempDir, kvlgr.ledgerID+"-1-123456")
+		otherLedgerDir := filepath.Join(tempDir, kvlgr.ledgerID+"-1-5-123456")
+		for _, dir := range []string{staleDir, otherLedgerDir} {
+			require.NoError(t, os.MkdirAll(dir, 0o755))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "txids.data"), []byte("partial data"), 0o644))
+		}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, kvlgr.ledgerID+"-1-654321"), []byte("junk"), 0o644))
+
+		require.NoError(t, kvlgr.generateSnapshot(outputDir))
+		require.NoDirExists(t, staleDir)
+		require.DirExists(t, otherLedgerDir)
+		require.FileExists(t, filepath.Join(tempDir, kvlgr.ledgerID+"-1-654321"))
+		exists, err := fileutil.DirExists(SnapshotDirForLedgerBlockNum(outputDir, kvlgr.ledgerID, 1))
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("empty-output-dir-falls-back-to-root-dir", func(t *testing.T) {
+		require.NoError(t, kvlgr.generateSnapshot(""))
+		exists, err := fileutil.DirExists(SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, kvlgr.ledgerID, 1))
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("invalid-output-dir", func(t *testing.T) {
+		err := kvlgr.generateSnapshot("relative/path")
+		require.EqualError(t, err, "invalid path: relative/path. The path for the snapshot output dir is expected to be an absolute path")
+
+		outputFile := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, ioutil.WriteFile(outputFile, []byte("dummy"), 0o644))
+		err = kvlgr.generateSnapshot(outputFile)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "ensure peer has write access to the snapshot output dir")
+	})
+}
+
+func TestVerifySnapshot(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedgerid", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	kvlgr := lgr.(*kvLedger)
+
+	blk1 := prepareNextBlockForTest(t, kvlgr, bg, "SimulateForBlk1",
+		map[string]string{"key1": "value1.1", "key2": "value2.1"}, nil)
+	require.NoError(t, kvlgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	require.NoError(t, kvlgr.generateSnapshot(""))
+	snapshotDir := SnapshotDirForLedgerBlockNum(conf.SnapshotsConfig.RootDir, kvlgr.ledgerID, 1)
+
+	signableMetadataBytes, err := ioutil.ReadFile(filepath.Join(snapshotDir, SnapshotSignableMetadataFileName))
+	require.NoError(t, err)
+	expectedSnapshotHash := sha256.Sum256(signableMetadataBytes)
+
+	snapshotHash, err := provider.VerifySnapshot(snapshotDir)
+	require.NoError(t, err)
+	require.Equal(t, expectedSnapshotHash[:], snapshotHash)
+
+	// flip a byte in one of the data files
+	dataFile := filepath.Join(snapshotDir, "public_state.data")
+	require.NoError(t, os.Chmod(dataFile, 0o644))
+	content, err := ioutil.ReadFile(dataFile)
+	require.NoError(t, err)
+	content[len(content)-1] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(dataFile, content, 0o644))
+
+	_, err = provider.VerifySnapshot(snapshotDir)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error while verifying snapshot: hash mismatch for file [public_state.data]")
+
+	_, err = provider.VerifySnapshot(t.TempDir())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error while loading metadata")
+}
+
 func TestSnapshotDBTypeCouchDB(t *testing.T) {
 	conf := testConfig(t)
 	fmt.Printf("snapshotRootDir %s\n", conf.SnapshotsConfig.RootDir)
@@ -222,7 +524,7 @@ func TestSnapshotDBTypeCouchDB(t *testing.T) {
 
 	// artificially set the db type
 	kvlgr.config.StateDBConfig.StateDatabase = ledger.CouchDB
-	require.NoError(t, kvlgr.generateSnapshot())
+	require.NoError(t, kvlgr.generateSnapshot(""))
 	verifySnapshotOutput(t,
 		&expectedSnapshotOutput{
 			snapshotRootDir: conf.SnapshotsConfig.RootDir,
@@ -259,7 +561,7 @@ func TestSnapshotCouchDBIndexCreation(t *testing.T) {
 			nil,
 		)
 		require.NoError(t, kvlgr.CommitLegacy(blockAndPvtdata, &ledger.CommitOptions{}))
-		require.NoError(t, kvlgr.generateSnapshot())
+		require.NoError(t, kvlgr.generateSnapshot(""))
 		snapshotDir := SnapshotDirForLedgerBlockNum(snapshotRootDir, kvlgr.ledgerID, 1)
 
 		cceventmgmt.Initialize(nil)
@@ -476,10 +778,16 @@ func TestSnapshotDirPathsCreation(t *testing.T) {
 		require.Len(t, f, 1)
 	}
 
+	// add a partially generated snapshot in the temp dir
+	partialSnapshotDir := filepath.Join(inProgressSnapshotsPath, "ledger1-10-123456")
+	require.NoError(t, os.MkdirAll(partialSnapshotDir, 0o755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(partialSnapshotDir, "txids.data"), []byte("partial data"), 0o644))
+
 	// verify that upon subsequent opening, kvledgerProvider removes any under-processing snapshots,
 	// potentially from a previous crash, from the temp dir but it does not remove any files from the final dir
 	provider.Close()
 	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	require.NoDirExists(t, partialSnapshotDir)
 	f, err := ioutil.ReadDir(inProgressSnapshotsPath)
 	require.NoError(t, err)
 	require.Len(t, f, 0)
@@ -548,7 +856,7 @@ func TestGenerateSnapshotErrors(t *testing.T) {
 		require.NoError(t, os.RemoveAll( // remove the base tempdir so that the snapshot tempdir creation fails
 			SnapshotsTempDirPath(conf.SnapshotsConfig.RootDir),
 		))
-		err := kvlgr.generateSnapshot()
+		err := kvlgr.generateSnapshot("")
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "error while creating temp dir")
 	})
@@ -556,7 +864,7 @@ func TestGenerateSnapshotErrors(t *testing.T) {
 	t.Run("block store returns error", func(t *testing.T) {
 		closeAndReopenLedgerProvider()
 		provider.blkStoreProvider.Close() // close the blockstore provider to trigger the error
-		err := kvlgr.generateSnapshot()
+		err := kvlgr.generateSnapshot("")
 		require.Error(t, err)
 		errStackTrace := fmt.Sprintf("%+v", err)
 		require.Contains(t, errStackTrace, "internal leveldb error while obtaining db iterator")
@@ -566,7 +874,7 @@ func TestGenerateSnapshotErrors(t *testing.T) {
 	t.Run("config history mgr returns error", func(t *testing.T) {
 		closeAndReopenLedgerProvider()
 		provider.configHistoryMgr.Close() // close the configHistoryMgr to trigger the error
-		err := kvlgr.generateSnapshot()
+		err := kvlgr.generateSnapshot("")
 		require.Error(t, err)
 		errStackTrace := fmt.Sprintf("%+v", err)
 		require.Contains(t, errStackTrace, "internal leveldb error while obtaining db iterator")
@@ -576,7 +884,7 @@ func TestGenerateSnapshotErrors(t *testing.T) {
 	t.Run("statedb returns error", func(t *testing.T) {
 		closeAndReopenLedgerProvider()
 		provider.dbProvider.Close() // close the dbProvider to trigger the error
-		err := kvlgr.generateSnapshot()
+		err := kvlgr.generateSnapshot("")
 		require.Error(t, err)
 		errStackTrace := fmt.Sprintf("%+v", err)
 		require.Contains(t, errStackTrace, "internal leveldb error while obtaining db iterator")
@@ -592,14 +900,14 @@ func TestGenerateSnapshotErrors(t *testing.T) {
 			filepath.Join(snapshotFinalDir, "dummyFile"),
 			[]byte("dummy file"), 0o444),
 		)
-		err := kvlgr.generateSnapshot()
+		err := kvlgr.generateSnapshot("")
 		require.Contains(t, err.Error(), "error while renaming dir")
 	})
 
 	t.Run("deletes the temp folder upon error", func(t *testing.T) {
 		closeAndReopenLedgerProvider()
 		provider.blkStoreProvider.Close() // close the blockstore provider to trigger an error
-		err := kvlgr.generateSnapshot()
+		err := kvlgr.generateSnapshot("")
 		require.Error(t, err)
 
 		empty, err := fileutil.DirEmpty(SnapshotsTempDirPath(conf.SnapshotsConfig.RootDir))
@@ -951,6 +1259,18 @@ func verifyCreatedLedger(t *testing.T,
 		},
 		destBCInfo,
 	)
+	extendedBCInfo, err := l.GetBlockchainInfoExtended()
+	require.NoError(t, err)
+	require.Equal(t,
+		&ledger.ExtendedBlockchainInfo{
+			Height:                      e.lastBlockNumber + 1,
+			CurrentBlockHash:            e.lastBlockHash,
+			PreviousBlockHash:           e.previousBlockHash,
+			BootstrappingSnapshotHeight: e.lastBlockNumber + 1,
+			EarliestAvailableBlock:      e.lastBlockNumber + 1,
+		},
+		extendedBCInfo,
+	)
 
 	statedbSavepoint, err := l.txmgr.GetLastSavepoint()
 	require.NoError(t, err)
@@ -0,0 +1,106 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"hash"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/snapshot"
+)
+
+// minThrottleDelay is the smallest delay for which a write sleeps. The smaller delays are accumulated and
+// served by a later write, so that the writes of a few bytes do not pay the cost of a sleep each
+const minThrottleDelay = 10 * time.Millisecond
+
+// snapshotThrottle limits the number of the concurrent snapshot generations and the rate at which the snapshot files
+// are written. A single instance is shared by all the ledgers of a provider
+type snapshotThrottle struct {
+	generationSlots chan struct{}
+	writeLimiter    *writeRateLimiter
+}
+
+func newSnapshotThrottle(maxConcurrentGenerations int, maxBytesPerSec int64) *snapshotThrottle {
+	t := &snapshotThrottle{}
+	if maxConcurrentGenerations > 0 {
+		t.generationSlots = make(chan struct{}, maxConcurrentGenerations)
+	}
+	if maxBytesPerSec > 0 {
+		t.writeLimiter = &writeRateLimiter{bytesPerSec: maxBytesPerSec}
+	}
+	return t
+}
+
+// acquireGenerationSlot blocks till a snapshot generation is allowed to start
+func (t *snapshotThrottle) acquireGenerationSlot() {
+	if t.generationSlots != nil {
+		t.generationSlots <- struct{}{}
+	}
+}
+
+func (t *snapshotThrottle) releaseGenerationSlot() {
+	if t.generationSlots != nil {
+		<-t.generationSlots
+	}
+}
+
+// wrapNewHashFunc returns a NewHashFunc whose hashes are throttled as per the write rate limit and report the
+// number of bytes hashed to the given function. The snapshot.FileWriter passes every byte written to a snapshot file
+// through the hash and hence, throttling the hash throttles the writes to the snapshot files
+func (t *snapshotThrottle) wrapNewHashFunc(newHashFunc snapshot.NewHashFunc, onWrite func(n int)) snapshot.NewHashFunc {
+	return func() (hash.Hash, error) {
+		h, err := newHashFunc()
+		if err != nil {
+			return nil, err
+		}
+		return &throttledHash{
+			Hash:         h,
+			writeLimiter: t.writeLimiter,
+			onWrite:      onWrite,
+		}, nil
+	}
+}
+
+type throttledHash struct {
+	hash.Hash
+	writeLimiter *writeRateLimiter
+	onWrite      func(n int)
+}
+
+func (h *throttledHash) Write(p []byte) (int, error) {
+	if h.writeLimiter != nil {
+		h.writeLimiter.wait(len(p))
+	}
+	n, err := h.Hash.Write(p)
+	h.onWrite(n)
+	return n, err
+}
+
+// writeRateLimiter spaces out the writes such that the bytes written do not exceed the configured rate. It tracks the
+// time by which the bytes written so far would have been written at the configured rate and a write sleeps till
+// its bytes are due
+type writeRateLimiter struct {
+	lock        sync.Mutex
+	bytesPerSec int64
+	due         time.Time
+}
+
+func (r *writeRateLimiter) wait(n int) {
+	r.lock.Lock()
+	now := time.Now()
+	if r.due.Before(now) {
+		r.due = now
+	}
+	r.due = r.due.Add(time.Duration(int64(n) * int64(time.Second) / r.bytesPerSec))
+	delay := r.due.Sub(now)
+	r.lock.Unlock()
+
+	if delay >= minThrottleDelay {
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"crypto/sha256"
+	"hash"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotThrottleGenerationSlots(t *testing.T) {
+	throttle := newSnapshotThrottle(1, 0)
+	throttle.acquireGenerationSlot()
+
+	acquired := make(chan struct{})
+	go func() {
+		throttle.acquireGenerationSlot()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second generation started while the first one is in progress")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	throttle.releaseGenerationSlot()
+	select {
+	case <-acquired:
+	case <-time.After(10 * time.Second):
+		t.Fatal("second generation did not start after the first one finished")
+	}
+	throttle.releaseGenerationSlot()
+
+	// no limit on the concurrent generations
+	unlimited := newSnapshotThrottle(0, 0)
+	for i := 0; i < 10; i++ {
+		unlimited.acquireGenerationSlot()
+	}
+}
+
+func TestSnapshotThrottleWriteRate(t *testing.T) {
+	newHashFunc := func() (hash.Hash, error) {
+		return sha256.New(), nil
+	}
+
+	t.Run("limited", func(t *testing.T) {
+		bytesWritten := 0
+		h, err := newSnapshotThrottle(0, 100*1024).wrapNewHashFunc(newHashFunc, func(n int) { bytesWritten += n })()
+		require.NoError(t, err)
+
+		data := make([]byte, 10*1024)
+		startTime := time.Now()
+		for i := 0; i < 5; i++ {
+			_, err := h.Write(data)
+			require.NoError(t, err)
+		}
+		// 50KB at 100KB per second
+		require.GreaterOrEqual(t, time.Since(startTime), 400*time.Millisecond)
+		require.Equal(t, 50*1024, bytesWritten)
+
+		expectedHash := sha256.New()
+		for i := 0; i < 5; i++ {
+			expectedHash.Write(data)
+		}
+		require.Equal(t, expectedHash.Sum(nil), h.Sum(nil))
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		bytesWritten := 0
+		h, err := newSnapshotThrottle(0, 0).wrapNewHashFunc(newHashFunc, func(n int) { bytesWritten += n })()
+		require.NoError(t, err)
+		_, err = h.Write(make([]byte, 10*1024*1024))
+		require.NoError(t, err)
+		require.Equal(t, 10*1024*1024, bytesWritten)
+	})
+}
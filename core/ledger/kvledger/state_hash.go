@@ -0,0 +1,49 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"hash"
+
+	"github.com/pkg/errors"
+)
+
+// ComputeStateHash implements the corresponding method in interface ledger.PeerLedger
+// The commits are held off while the state is traversed, so that the digest corresponds to a single height
+func (l *kvLedger) ComputeStateHash(height uint64) ([]byte, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if height > bcInfo.Height {
+		return nil, errors.Errorf("requested height [%d] is above the current height [%d] of ledger [%s]", height, bcInfo.Height, l.ledgerID)
+	}
+	if height < bcInfo.Height {
+		return nil, errors.Errorf("requested height [%d] is below the current height [%d] of ledger [%s], the state hash can be computed only for the current height",
+			height, bcInfo.Height, l.ledgerID)
+	}
+	savepoint, err := l.txmgr.GetLastSavepoint()
+	if err != nil {
+		return nil, err
+	}
+	if savepoint == nil || savepoint.BlockNum+1 != height {
+		return nil, errors.Errorf("state database of ledger [%s] is not at the current height [%d]", l.ledgerID, height)
+	}
+
+	logger.Infof("[%s] Computing the state hash at height [%d]", l.ledgerID, height)
+	return l.txmgr.ComputePubStateHash(
+		func() (hash.Hash, error) {
+			return l.hashProvider.GetHash(snapshotHashOpts)
+		},
+	)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStateHash(t *testing.T) {
+	provider1 := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider1.Close()
+	provider2 := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider2.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr1, err := provider1.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr1.Close()
+	lgr2, err := provider2.CreateFromGenesisBlock(proto.Clone(gb).(*common.Block))
+	require.NoError(t, err)
+	defer lgr2.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr1, bg, "txid-1", map[string]string{"key1": "value1", "key2": "value2"}, nil)
+	blk2 := prepareNextBlockForTest(t, lgr1, bg, "txid-2", map[string]string{"key1": "value1-updated"}, nil)
+	for _, blk := range []*ledger.BlockAndPvtData{blk1, blk2} {
+		blkCopy := &ledger.BlockAndPvtData{Block: proto.Clone(blk.Block).(*common.Block)}
+		require.NoError(t, lgr1.CommitLegacy(blk, &ledger.CommitOptions{}))
+		require.NoError(t, lgr2.CommitLegacy(blkCopy, &ledger.CommitOptions{}))
+	}
+
+	hash1, err := lgr1.ComputeStateHash(3)
+	require.NoError(t, err)
+	require.NotEmpty(t, hash1)
+	hash2, err := lgr2.ComputeStateHash(3)
+	require.NoError(t, err)
+	require.Equal(t, hash1, hash2)
+
+	_, err = lgr1.ComputeStateHash(4)
+	require.EqualError(t, err, "requested height [4] is above the current height [3] of ledger [testLedger]")
+	_, err = lgr1.ComputeStateHash(2)
+	require.EqualError(t, err, "requested height [2] is below the current height [3] of ledger [testLedger], the state hash can be computed only for the current height")
+
+	// the hash changes with the state
+	blk3 := prepareNextBlockForTest(t, lgr1, bg, "txid-3", map[string]string{"key2": "value2-updated"}, nil)
+	require.NoError(t, lgr1.CommitLegacy(blk3, &ledger.CommitOptions{}))
+	hash3, err := lgr1.ComputeStateHash(4)
+	require.NoError(t, err)
+	require.NotEqual(t, hash1, hash3)
+}
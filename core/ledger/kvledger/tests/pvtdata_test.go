@@ -8,8 +8,10 @@ package tests
 
 import (
 	"testing"
+	"time"
 
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -156,6 +158,23 @@ func TestBTL(t *testing.T) {
 	})
 	blk2 := l.cutBlockAndCommitLegacy()
 
+	// key2 is scheduled to be purged with the commit of block 8, i.e., the sixth block from the next block
+	scheduledPurges, err := l.lgr.GetPvtdataScheduledForPurge(5)
+	require.NoError(t, err)
+	require.Empty(t, scheduledPurges)
+	scheduledPurges, err = l.lgr.GetPvtdataScheduledForPurge(6)
+	require.NoError(t, err)
+	require.Equal(t, []*ledger.ScheduledPvtdataPurge{
+		{
+			ExpiringBlock:   8,
+			CommittingBlock: 2,
+			Namespace:       "cc1",
+			Collection:      "coll2",
+			KeyHash:         util.ComputeStringHash("key2"),
+			Key:             "key2",
+		},
+	}, scheduledPurges)
+
 	// commit 5 more blocks with some random key/vals
 	for i := 0; i < 5; i++ {
 		l.simulateDataTx("", func(s *simulator) {
@@ -216,8 +235,25 @@ func TestAppInitiatedPrivateDataPurge(t *testing.T) {
 		// set key2 to a new value, after purge
 		s.setPvtdata("cc1", "coll2", "key2", "value2_new")
 	})
+	purgeEvents := make(chan *ledger.PvtdataPurgeEvent, 10)
+	cancel := l.lgr.RegisterPvtdataPurgeListener(func(event *ledger.PvtdataPurgeEvent) { purgeEvents <- event })
+	defer cancel()
 	l.cutBlockAndCommitLegacy()
 
+	// key2 is not deleted from the state by the purge, as it is written again in the same block
+	select {
+	case event := <-purgeEvents:
+		require.Equal(t, &ledger.PvtdataPurgeEvent{
+			BlockNumber: 3,
+			Purges: []*ledger.PvtdataPurge{
+				{TxNum: 0, Namespace: "cc1", Collection: "coll1", KeyHash: util.ComputeStringHash("key1"), Key: "key1"},
+				{TxNum: 0, Namespace: "cc1", Collection: "coll2", KeyHash: util.ComputeStringHash("key2")},
+			},
+		}, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the pvtdata purge event")
+	}
+
 	l.verifyPvtState("cc1", "coll1", "key1", "")           // key1 should have been purged from the state
 	l.verifyPvtState("cc1", "coll2", "key2", "value2_new") // key2 should be present with new value
 	l.verifyPvtState("cc1", "coll2", "key3", "value3")
@@ -79,14 +79,14 @@ func TestV11CommitHashes(t *testing.T) {
 		description               string
 		v11SampleDataPath         string
 		preResetCommitHashExists  bool
-		resetFunc                 func(h *testLedger, ledgerFSRoot string)
+		resetFunc                 func(ledgerID string, height uint64, ledgerFSRoot string)
 		postResetCommitHashExists bool
 	}{
 		{
 			"Reset (no existing CommitHash)",
 			"testdata/v11/sample_ledgers/ledgersData.zip",
 			false,
-			func(h *testLedger, ledgerFSRoot string) {
+			func(ledgerID string, height uint64, ledgerFSRoot string) {
 				require.NoError(t, kvledger.ResetAllKVLedgers(ledgerFSRoot))
 			},
 			true,
@@ -96,8 +96,8 @@ func TestV11CommitHashes(t *testing.T) {
 			"Rollback to genesis block (no existing CommitHash)",
 			"testdata/v11/sample_ledgers/ledgersData.zip",
 			false,
-			func(h *testLedger, ledgerFSRoot string) {
-				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, h.lgrid, 0))
+			func(ledgerID string, height uint64, ledgerFSRoot string) {
+				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, ledgerID, 0))
 			},
 			true,
 		},
@@ -106,8 +106,8 @@ func TestV11CommitHashes(t *testing.T) {
 			"Rollback to block other than genesis block (no existing CommitHash)",
 			"testdata/v11/sample_ledgers/ledgersData.zip",
 			false,
-			func(h *testLedger, ledgerFSRoot string) {
-				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, h.lgrid, h.currentHeight()/2+1))
+			func(ledgerID string, height uint64, ledgerFSRoot string) {
+				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, ledgerID, height/2+1))
 			},
 			false,
 		},
@@ -116,7 +116,7 @@ func TestV11CommitHashes(t *testing.T) {
 			"Reset (existing CommitHash)",
 			"testdata/v11/sample_ledgers_with_commit_hashes/ledgersData.zip",
 			true,
-			func(h *testLedger, ledgerFSRoot string) {
+			func(ledgerID string, height uint64, ledgerFSRoot string) {
 				require.NoError(t, kvledger.ResetAllKVLedgers(ledgerFSRoot))
 			},
 			true,
@@ -126,8 +126,8 @@ func TestV11CommitHashes(t *testing.T) {
 			"Rollback to genesis block (existing CommitHash)",
 			"testdata/v11/sample_ledgers_with_commit_hashes/ledgersData.zip",
 			true,
-			func(h *testLedger, ledgerFSRoot string) {
-				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, h.lgrid, 0))
+			func(ledgerID string, height uint64, ledgerFSRoot string) {
+				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, ledgerID, 0))
 			},
 			true,
 		},
@@ -136,8 +136,8 @@ func TestV11CommitHashes(t *testing.T) {
 			"Rollback to block other than genesis block (existing CommitHash)",
 			"testdata/v11/sample_ledgers_with_commit_hashes/ledgersData.zip",
 			true,
-			func(h *testLedger, ledgerFSRoot string) {
-				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, h.lgrid, h.currentHeight()/2+1))
+			func(ledgerID string, height uint64, ledgerFSRoot string) {
+				require.NoError(t, kvledger.RollbackKVLedger(ledgerFSRoot, ledgerID, height/2+1))
 			},
 			true,
 		},
@@ -161,7 +161,7 @@ func TestV11CommitHashes(t *testing.T) {
 func testV11CommitHashes(t *testing.T,
 	v11DataPath string,
 	preResetCommitHashExists bool,
-	resetFunc func(*testLedger, string),
+	resetFunc func(string, uint64, string),
 	postResetCommitHashExists bool,
 ) {
 	env := newEnv(t)
@@ -188,8 +188,9 @@ func testV11CommitHashes(t *testing.T,
 		h.verifyCommitHashNotExists()
 	}
 
+	height := h.currentHeight()
 	env.closeLedgerMgmt()
-	resetFunc(h, ledgerFSRoot)
+	resetFunc("ledger1", height, ledgerFSRoot)
 	env.initLedgerMgmt()
 
 	h = env.openTestLedger("ledger1")
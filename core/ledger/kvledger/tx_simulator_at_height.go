@@ -0,0 +1,217 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"math"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
+	"github.com/pkg/errors"
+)
+
+var errUnsupportedAtHeight = errors.New("the operation is not supported by a simulator that is pinned to a height")
+
+// keyModificationRetriever is implemented by the history query executor
+type keyModificationRetriever interface {
+	GetKeyModificationBelowHeight(namespace, key string, height uint64) (*queryresult.KeyModification, error)
+}
+
+// NewTxSimulatorAtHeight implements the corresponding method from interface ledger.PeerLedger
+func (l *kvLedger) NewTxSimulatorAtHeight(txid string, height uint64) (ledger.TxSimulator, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	if l.historyDB == nil {
+		return nil, &ledger.HistoryDBNotEnabledError{LedgerID: l.ledgerID}
+	}
+	if err := l.acquireFreezeRLock(); err != nil {
+		return nil, err
+	}
+	defer l.freezeLock.RUnlock()
+
+	// the block APIs lock is held so that the block store and the history database are not
+	// moved ahead by a concurrent commit while the height is checked
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+	if err := l.waitForPendingHistoryCommits(); err != nil {
+		return nil, err
+	}
+
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if height > bcInfo.Height {
+		return nil, errors.Errorf("height [%d] is above the current height [%d] of the ledger", height, bcInfo.Height)
+	}
+	if l.bootSnapshotMetadata != nil && height <= l.bootSnapshotMetadata.LastBlockNumber {
+		return nil, errors.Errorf(
+			"height [%d] is not above the last block number [%d] in the snapshot from which the ledger was bootstrapped",
+			height, l.bootSnapshotMetadata.LastBlockNumber,
+		)
+	}
+	if height > 0 {
+		savepoint, err := l.historyDB.GetLastSavepoint()
+		if err != nil {
+			return nil, err
+		}
+		if l.historyDBCommitsPaused || savepoint == nil || savepoint.BlockNum < height-1 {
+			return nil, errors.Errorf("history database is not caught up to the height [%d]", height)
+		}
+	}
+
+	historyQE, err := l.historyDB.NewQueryExecutor(l.blockStore)
+	if err != nil {
+		return nil, err
+	}
+	sim, err := l.txmgr.NewTxSimulator(txid)
+	if err != nil {
+		return nil, err
+	}
+	return &txSimulatorAtHeight{
+		TxSimulator:             sim,
+		height:                  height,
+		historyQE:               historyQE.(keyModificationRetriever),
+		bootstrappedByASnapshot: l.bootSnapshotMetadata != nil,
+		blockToLive:             pubStateBTL(l.config),
+	}, nil
+}
+
+// txSimulatorAtHeight serves the public state reads as of a height. The value of a key as of the height is the
+// value written by the last transaction below the height that wrote the key, as recorded in the history database.
+// The writes are delegated to the wrapped simulator, which also holds the commit lock of the txmgr till the
+// simulation is done, but the simulation results are not handed out, as they are not meant to be endorsed.
+// The reads of a namespace with a block-to-live are rejected, as the expiry of its keys is not recorded in the
+// history database.
+type txSimulatorAtHeight struct {
+	ledger.TxSimulator
+	height                  uint64
+	historyQE               keyModificationRetriever
+	bootstrappedByASnapshot bool
+	blockToLive             map[string]uint64
+}
+
+func (s *txSimulatorAtHeight) GetState(namespace, key string) ([]byte, error) {
+	if s.blockToLive[namespace] > 0 {
+		return nil, errors.Errorf(
+			"state of namespace [%s] as of height [%d] is not available as the keys of the namespace expire per the configured block-to-live",
+			namespace, s.height,
+		)
+	}
+	keyModification, err := s.historyQE.GetKeyModificationBelowHeight(namespace, key, s.height)
+	if err != nil {
+		return nil, err
+	}
+	if keyModification != nil {
+		if keyModification.IsDelete {
+			return nil, nil
+		}
+		return keyModification.Value, nil
+	}
+	if !s.bootstrappedByASnapshot {
+		// the history covers all the blocks and hence the key did not exist at the height
+		return nil, nil
+	}
+
+	// for a ledger bootstrapped from a snapshot, the history does not cover the blocks in the snapshot. If the key
+	// has not been written since the snapshot, the current value is the value as of the height
+	keyModification, err = s.historyQE.GetKeyModificationBelowHeight(namespace, key, math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	if keyModification != nil {
+		return nil, errors.Errorf(
+			"value of key [%s] in namespace [%s] as of height [%d] is not available as the key was imported from the snapshot and modified later",
+			key, namespace, s.height,
+		)
+	}
+	return s.TxSimulator.GetState(namespace, key)
+}
+
+func (s *txSimulatorAtHeight) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, err := s.GetState(namespace, key)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (s *txSimulatorAtHeight) GetTxSimulationResults() (*ledger.TxSimulationResults, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetStateMetadata(namespace, key string) (map[string][]byte, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetStateVersion(namespace, key string) (*kvrwset.Version, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetStateProof(namespace, key string) (*stateproof.Proof, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetStateRangeScanIterator(namespace, startKey, endKey string) (commonledger.ResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetStateRangeScanIteratorWithPagination(namespace, startKey, endKey string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) ExecuteQuery(namespace, query string) (commonledger.ResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetNamespaceFullScanIterator(namespace string) (commonledger.ResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetPrivateData(namespace, collection, key string) ([]byte, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetPrivateDataHash(namespace, collection, key string) ([]byte, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetPrivateDataMetadata(namespace, collection, key string) (map[string][]byte, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetPrivateDataMetadataByHash(namespace, collection string, keyhash []byte) (map[string][]byte, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetPrivateDataMultipleKeys(namespace, collection string, keys []string) ([][]byte, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetPrivateDataRangeScanIterator(namespace, collection, startKey, endKey string) (commonledger.ResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) GetPrivateDataHashByRange(namespace, collection string, startKeyHash, endKeyHash []byte) (commonledger.ResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
+
+func (s *txSimulatorAtHeight) ExecuteQueryOnPrivateData(namespace, collection, query string) (commonledger.ResultsIterator, error) {
+	return nil, errUnsupportedAtHeight
+}
@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTxSimulatorAtHeight(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+	blk2 := prepareNextBlockForTest(t, lgr, bg, "txid-2", map[string]string{"key1": "value2", "key2": "value2"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk2, &ledger.CommitOptions{}))
+
+	readAtHeight := func(height uint64) map[string][]byte {
+		sim, err := lgr.NewTxSimulatorAtHeight("txid-sim", height)
+		require.NoError(t, err)
+		defer sim.Done()
+		values, err := sim.GetStateMultipleKeys("ns", []string{"key1", "key2"})
+		require.NoError(t, err)
+		return map[string][]byte{"key1": values[0], "key2": values[1]}
+	}
+
+	require.Equal(t, map[string][]byte{"key1": nil, "key2": nil}, readAtHeight(1))
+	require.Equal(t, map[string][]byte{"key1": []byte("value1"), "key2": nil}, readAtHeight(2))
+	require.Equal(t, map[string][]byte{"key1": []byte("value2"), "key2": []byte("value2")}, readAtHeight(3))
+
+	_, err = lgr.NewTxSimulatorAtHeight("txid-sim", 4)
+	require.EqualError(t, err, "height [4] is above the current height [3] of the ledger")
+
+	t.Run("writes-are-not-committed", func(t *testing.T) {
+		sim, err := lgr.NewTxSimulatorAtHeight("txid-sim", 2)
+		require.NoError(t, err)
+		require.NoError(t, sim.SetState("ns", "key1", []byte("value-sim")))
+		val, err := sim.GetState("ns", "key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value1"), val)
+		_, err = sim.GetTxSimulationResults()
+		require.Equal(t, errUnsupportedAtHeight, err)
+		sim.Done()
+		checkStateDBForTest(t, lgr, map[string]string{"key1": "value2", "key2": "value2"}, nil)
+	})
+
+	t.Run("unsupported-queries", func(t *testing.T) {
+		sim, err := lgr.NewTxSimulatorAtHeight("txid-sim", 2)
+		require.NoError(t, err)
+		defer sim.Done()
+		_, err = sim.GetStateRangeScanIterator("ns", "", "")
+		require.Equal(t, errUnsupportedAtHeight, err)
+		_, err = sim.GetPrivateData("ns", "coll", "key1")
+		require.Equal(t, errUnsupportedAtHeight, err)
+	})
+}
+
+func TestNewTxSimulatorAtHeightWithBlockToLive(t *testing.T) {
+	conf := testConfig(t)
+	conf.StateDBConfig.BlockToLive = map[string]uint64{"ns-btl": 1}
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+	blk1 := prepareNextBlockForTest(t, lgr, bg, "txid-1", map[string]string{"key1": "value1"}, nil)
+	require.NoError(t, lgr.CommitLegacy(blk1, &ledger.CommitOptions{}))
+
+	sim, err := lgr.NewTxSimulatorAtHeight("txid-sim", 2)
+	require.NoError(t, err)
+	defer sim.Done()
+	val, err := sim.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value1"), val)
+	_, err = sim.GetState("ns-btl", "key1")
+	require.EqualError(t, err, "state of namespace [ns-btl] as of height [2] is not available as the keys of the namespace expire per the configured block-to-live")
+}
+
+func TestNewTxSimulatorAtHeightWithoutHistoryDB(t *testing.T) {
+	conf := testConfig(t)
+	conf.HistoryDBConfig.Enabled = false
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	_, err = lgr.NewTxSimulatorAtHeight("txid-sim", 1)
+	require.Equal(t, &ledger.HistoryDBNotEnabledError{LedgerID: "testLedger"}, err)
+}
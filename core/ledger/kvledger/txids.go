@@ -0,0 +1,89 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+// TxIDsInRange implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) TxIDsInRange(startBlockNum, endBlockNum uint64) (commonledger.ResultsIterator, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+
+	bcInfo, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if endBlockNum > bcInfo.Height {
+		endBlockNum = bcInfo.Height
+	}
+	itr, err := l.blockStore.RetrieveTxIDsInRange(startBlockNum, endBlockNum)
+	if err != nil {
+		return nil, err
+	}
+	return &txIDsItr{itr}, nil
+}
+
+// TxIDsByChaincodeName implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) TxIDsByChaincodeName(chaincodeName string) (commonledger.ResultsIterator, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+
+	itr, err := l.blockStore.RetrieveTxIDsByChaincodeName(chaincodeName)
+	if err != nil {
+		return nil, err
+	}
+	return &txIDsItr{itr}, nil
+}
+
+// TxIDsByEndorserMSPID implements the corresponding method in interface ledger.PeerLedger
+func (l *kvLedger) TxIDsByEndorserMSPID(mspID string) (commonledger.ResultsIterator, error) {
+	if err := l.checkNotClosed(); err != nil {
+		return nil, err
+	}
+	l.blockAPIsRWLock.RLock()
+	defer l.blockAPIsRWLock.RUnlock()
+
+	itr, err := l.blockStore.RetrieveTxIDsByEndorserMSPID(mspID)
+	if err != nil {
+		return nil, err
+	}
+	return &txIDsItr{itr}, nil
+}
+
+type txIDsItr struct {
+	itr interface {
+		Next() (*blkstorage.TxIDInfo, error)
+		Close()
+	}
+}
+
+func (t *txIDsItr) Next() (commonledger.QueryResult, error) {
+	txIDInfo, err := t.itr.Next()
+	if err != nil || txIDInfo == nil {
+		return nil, err
+	}
+	return &ledger.TxIDInfo{
+		TxID:           txIDInfo.TxID,
+		BlockNum:       txIDInfo.BlockNum,
+		TxNum:          txIDInfo.TxNum,
+		ValidationCode: txIDInfo.ValidationCode,
+	}, nil
+}
+
+func (t *txIDsItr) Close() {
+	t.itr.Close()
+}
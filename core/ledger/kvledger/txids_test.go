@@ -0,0 +1,143 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric-protos-go/peer"
+	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/mock"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxIDsInRange(t *testing.T) {
+	provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	for _, txID := range []string{"txid-1", "txid-2", "txid-3"} {
+		blk := prepareNextBlockForTest(t, lgr, bg, txID, map[string]string{"key1": txID}, nil)
+		require.NoError(t, lgr.CommitLegacy(blk, &ledger.CommitOptions{}))
+	}
+
+	retrieveTxIDs := func(startBlockNum, endBlockNum uint64) []*ledger.TxIDInfo {
+		itr, err := lgr.TxIDsInRange(startBlockNum, endBlockNum)
+		require.NoError(t, err)
+		defer itr.Close()
+		var txIDInfos []*ledger.TxIDInfo
+		for {
+			res, err := itr.Next()
+			require.NoError(t, err)
+			if res == nil {
+				return txIDInfos
+			}
+			txIDInfos = append(txIDInfos, res.(*ledger.TxIDInfo))
+		}
+	}
+
+	expectedTxIDs := func(startBlockNum, endBlockNum uint64) []*ledger.TxIDInfo {
+		var txIDInfos []*ledger.TxIDInfo
+		for blockNum := startBlockNum; blockNum < endBlockNum; blockNum++ {
+			block, err := lgr.GetBlockByNumber(blockNum)
+			require.NoError(t, err)
+			for txNum, txEnvelopeBytes := range block.Data.Data {
+				txID, err := protoutil.GetOrComputeTxIDFromEnvelope(txEnvelopeBytes)
+				require.NoError(t, err)
+				txIDInfos = append(txIDInfos, &ledger.TxIDInfo{
+					TxID:           txID,
+					BlockNum:       blockNum,
+					TxNum:          uint64(txNum),
+					ValidationCode: peer.TxValidationCode_VALID,
+				})
+			}
+		}
+		return txIDInfos
+	}
+
+	txIDInfos := retrieveTxIDs(1, 4)
+	require.Len(t, txIDInfos, 3)
+	require.Equal(t, expectedTxIDs(1, 4), txIDInfos)
+	require.Equal(t, expectedTxIDs(0, 4), retrieveTxIDs(0, 4))
+	require.Equal(t, expectedTxIDs(2, 3), retrieveTxIDs(2, 3))
+
+	// the end of the range is capped at the current height
+	require.Equal(t, expectedTxIDs(2, 4), retrieveTxIDs(2, 100))
+
+	// a reversed or an empty range yields no transactions
+	require.Empty(t, retrieveTxIDs(3, 1))
+	require.Empty(t, retrieveTxIDs(2, 2))
+	require.Empty(t, retrieveTxIDs(4, 10))
+}
+
+func TestTxIDsByChaincodeNameAndEndorserMSPID(t *testing.T) {
+	conf := testConfig(t)
+	conf.BlockStorageConfig = &ledger.BlockStorageConfig{
+		IndexChaincodeName:  true,
+		IndexEndorserMSPIDs: true,
+	}
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", true)
+	lgr, err := provider.CreateFromGenesisBlock(gb)
+	require.NoError(t, err)
+	defer lgr.Close()
+
+	var expectedTxIDs []*ledger.TxIDInfo
+	for i, value := range []string{"value-1", "value-2", "value-3"} {
+		blk := prepareNextBlockForTest(t, lgr, bg, "", map[string]string{"key1": value}, nil)
+		require.NoError(t, lgr.CommitLegacy(blk, &ledger.CommitOptions{}))
+		txID, err := protoutil.GetOrComputeTxIDFromEnvelope(blk.Block.Data.Data[0])
+		require.NoError(t, err)
+		expectedTxIDs = append(expectedTxIDs, &ledger.TxIDInfo{
+			TxID:           txID,
+			BlockNum:       uint64(i + 1),
+			ValidationCode: peer.TxValidationCode_VALID,
+		})
+	}
+
+	collect := func(itr commonledger.ResultsIterator, err error) []*ledger.TxIDInfo {
+		require.NoError(t, err)
+		defer itr.Close()
+		var txIDInfos []*ledger.TxIDInfo
+		for {
+			res, err := itr.Next()
+			require.NoError(t, err)
+			if res == nil {
+				return txIDInfos
+			}
+			txIDInfos = append(txIDInfos, res.(*ledger.TxIDInfo))
+		}
+	}
+
+	require.Equal(t, expectedTxIDs, collect(lgr.TxIDsByChaincodeName("foo")))
+	require.Empty(t, collect(lgr.TxIDsByChaincodeName("bar")))
+	require.Equal(t, expectedTxIDs, collect(lgr.TxIDsByEndorserMSPID("SampleOrg")))
+	require.Empty(t, collect(lgr.TxIDsByEndorserMSPID("AnotherOrg")))
+
+	t.Run("index-not-enabled", func(t *testing.T) {
+		provider := testutilNewProvider(testConfig(t), t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		_, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+		lgr, err := provider.CreateFromGenesisBlock(gb)
+		require.NoError(t, err)
+		defer lgr.Close()
+
+		_, err = lgr.TxIDsByChaincodeName("foo")
+		require.EqualError(t, err, "transactions cannot be retrieved by the attribute [ChaincodeName] as it is not maintained in index")
+		_, err = lgr.TxIDsByEndorserMSPID("SampleOrg")
+		require.EqualError(t, err, "transactions cannot be retrieved by the attribute [EndorserMSPID] as it is not maintained in index")
+	})
+}
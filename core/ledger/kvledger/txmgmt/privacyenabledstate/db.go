@@ -7,11 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package privacyenabledstate
 
 import (
+	"bytes"
 	"encoding/base64"
 	"strings"
 
 	"github.com/hyperledger/fabric-lib-go/healthz"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/ledger"
@@ -21,6 +23,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statepebble"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/pkg/errors"
 )
@@ -31,6 +34,9 @@ const (
 	nsJoiner       = "$$"
 	pvtDataPrefix  = "p"
 	hashDataPrefix = "h"
+
+	// maxDropNamespacesBatchSize is the maximum number of keys deleted in one go by the function DropNamespaces
+	maxDropNamespacesBatchSize = 1000
 )
 
 // StateDBConfig encapsulates the configuration for stateDB on the ledger.
@@ -41,6 +47,18 @@ type StateDBConfig struct {
 	// It is internally computed by the ledger component,
 	// so it is not in ledger.StateDBConfig and not exposed to other components.
 	LevelDBPath string
+	// PebbleDBPath is the filesystem path when statedb type is "pebble".
+	// It is internally computed by the ledger component in the same way as LevelDBPath.
+	PebbleDBPath string
+	// Encryptor, if not nil, encrypts the values in the leveldb when statedb type is "goleveldb".
+	// It is set by the ledger component from ledger.Config.
+	Encryptor leveldbhelper.Encryptor
+	// LevelDBTuning, if not nil, overrides the goleveldb defaults when statedb type is "goleveldb".
+	// It is set by the ledger component from ledger.StateDBConfig.LevelDB.
+	LevelDBTuning *leveldbhelper.Tuning
+	// LevelDBOpenRetry is the retry of the open of the leveldb when statedb type is "goleveldb".
+	// It is set by the ledger component from ledger.Config.
+	LevelDBOpenRetry leveldbhelper.OpenRetry
 }
 
 // DBProvider encapsulates other providers such as VersionedDBProvider and
@@ -49,6 +67,9 @@ type DBProvider struct {
 	VersionedDBProvider statedb.VersionedDBProvider
 	HealthCheckRegistry ledger.HealthCheckRegistry
 	bookkeepingProvider *bookkeeping.Provider
+	readCacheSize       int
+	// partialSavepointInterval is passed to the VersionedDBs that implement statedb.PartialSavepointCapable
+	partialSavepointInterval int
 }
 
 // NewDBProvider constructs an instance of DBProvider
@@ -62,12 +83,24 @@ func NewDBProvider(
 	var vdbProvider statedb.VersionedDBProvider
 	var err error
 
-	if stateDBConf != nil && stateDBConf.StateDatabase == ledger.CouchDB {
+	switch {
+	case stateDBConf != nil && stateDBConf.StateDatabase == ledger.CouchDB:
 		if vdbProvider, err = statecouchdb.NewVersionedDBProvider(stateDBConf.CouchDB, metricsProvider, sysNamespaces); err != nil {
 			return nil, err
 		}
-	} else {
-		if vdbProvider, err = stateleveldb.NewVersionedDBProvider(stateDBConf.LevelDBPath); err != nil {
+	case stateDBConf != nil && stateDBConf.StateDatabase == ledger.Pebble:
+		if vdbProvider, err = statepebble.NewVersionedDBProvider(stateDBConf.PebbleDBPath); err != nil {
+			return nil, err
+		}
+	default:
+		if vdbProvider, err = stateleveldb.NewVersionedDBProvider(
+			stateDBConf.LevelDBPath,
+			stateDBConf.PerNamespacePartitioning,
+			stateDBConf.Encryptor,
+			stateDBConf.LevelDBTuning,
+			stateDBConf.LevelDBOpenRetry,
+			stateDBConf.TotalQueryLimit,
+		); err != nil {
 			return nil, err
 		}
 	}
@@ -77,6 +110,10 @@ func NewDBProvider(
 		HealthCheckRegistry: healthCheckRegistry,
 		bookkeepingProvider: bookkeeperProvider,
 	}
+	if stateDBConf != nil && stateDBConf.StateDBConfig != nil {
+		dbProvider.readCacheSize = stateDBConf.ReadCacheSize
+		dbProvider.partialSavepointInterval = stateDBConf.PartialSavepointInterval
+	}
 
 	err = dbProvider.RegisterHealthChecker()
 	if err != nil {
@@ -88,7 +125,7 @@ func NewDBProvider(
 
 // RegisterHealthChecker registers the underlying stateDB with the healthChecker.
 // For now, we register only the CouchDB as it runs as a separate process but not
-// for the GoLevelDB as it is an embedded database.
+// for the GoLevelDB and the Pebble as they are embedded databases.
 func (p *DBProvider) RegisterHealthChecker() error {
 	if healthChecker, ok := p.VersionedDBProvider.(healthz.HealthChecker); ok {
 		return p.HealthCheckRegistry.RegisterChecker("couchdb", healthChecker)
@@ -102,12 +139,22 @@ func (p *DBProvider) GetDBHandle(id string, chInfoProvider channelInfoProvider)
 	if err != nil {
 		return nil, err
 	}
+	if psc, ok := vdb.(statedb.PartialSavepointCapable); ok && p.partialSavepointInterval > 0 {
+		psc.SetPartialSavepointInterval(uint64(p.partialSavepointInterval))
+	}
 	bookkeeper := p.bookkeepingProvider.GetDBHandle(id, bookkeeping.MetadataPresenceIndicator)
 	metadataHint, err := newMetadataHint(bookkeeper)
 	if err != nil {
 		return nil, err
 	}
-	return NewDB(vdb, id, metadataHint)
+	db, err := NewDB(vdb, id, metadataHint)
+	if err != nil {
+		return nil, err
+	}
+	if p.readCacheSize > 0 {
+		db.readCache = newReadCache(p.readCacheSize)
+	}
+	return db, nil
 }
 
 // Close closes all the VersionedDB instances and releases any resources held by VersionedDBProvider
@@ -124,12 +171,14 @@ func (p *DBProvider) Drop(ledgerid string) error {
 type DB struct {
 	statedb.VersionedDB
 	metadataHint *metadataHint
+	// readCache, if set, caches the values returned by the function GetState
+	readCache *readCache
 }
 
 // NewDB wraps a VersionedDB instance. The public data is managed directly by the wrapped versionedDB.
 // For managing the hashed data and private data, this implementation creates separate namespaces in the wrapped db
 func NewDB(vdb statedb.VersionedDB, ledgerid string, metadataHint *metadataHint) (*DB, error) {
-	return &DB{vdb, metadataHint}, nil
+	return &DB{VersionedDB: vdb, metadataHint: metadataHint}, nil
 }
 
 // IsBulkOptimizable checks whether the underlying statedb implements statedb.BulkOptimizable
@@ -145,6 +194,21 @@ func (s *DB) LoadCommittedVersionsOfPubAndHashedKeys(pubKeys []*statedb.Composit
 	if !ok {
 		return nil
 	}
+	return bulkOptimizable.LoadCommittedVersions(s.mergeHashedKeys(pubKeys, hashedKeys))
+}
+
+// LoadCommittedStatesOfPubAndHashedKeys loads committed values of given public and hashed states, so that the
+// subsequent reads of these states do not cost a round trip each to the underlying statedb
+func (s *DB) LoadCommittedStatesOfPubAndHashedKeys(pubKeys []*statedb.CompositeKey,
+	hashedKeys []*HashedCompositeKey) error {
+	bulkOptimizable, ok := s.VersionedDB.(statedb.BulkOptimizable)
+	if !ok {
+		return nil
+	}
+	return bulkOptimizable.LoadCommittedStates(s.mergeHashedKeys(pubKeys, hashedKeys))
+}
+
+func (s *DB) mergeHashedKeys(pubKeys []*statedb.CompositeKey, hashedKeys []*HashedCompositeKey) []*statedb.CompositeKey {
 	// Here, hashedKeys are merged into pubKeys to get a combined set of keys for combined loading
 	for _, key := range hashedKeys {
 		ns := deriveHashedDataNs(key.Namespace, key.CollectionName)
@@ -160,13 +224,7 @@ func (s *DB) LoadCommittedVersionsOfPubAndHashedKeys(pubKeys []*statedb.Composit
 			Key:       keyHashStr,
 		})
 	}
-
-	err := bulkOptimizable.LoadCommittedVersions(pubKeys)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return pubKeys
 }
 
 // ClearCachedVersions clears the version cache
@@ -177,6 +235,27 @@ func (s *DB) ClearCachedVersions() {
 	}
 }
 
+// Compact compacts the physical storage of the underlying statedb if the statedb implements statedb.Compactable.
+// Otherwise, this is a no-op
+func (s *DB) Compact() error {
+	compactable, ok := s.VersionedDB.(statedb.Compactable)
+	if !ok {
+		return nil
+	}
+	return compactable.Compact()
+}
+
+// ApproximateKeyCount returns an estimate of the number of keys in the underlying statedb, including
+// the keys for the private data and the hashes of the private data, if the statedb implements
+// statedb.KeyCountEstimator. Otherwise, an error is returned
+func (s *DB) ApproximateKeyCount() (uint64, error) {
+	estimator, ok := s.VersionedDB.(statedb.KeyCountEstimator)
+	if !ok {
+		return 0, errors.New("approximate key count is not supported by the state database")
+	}
+	return estimator.ApproximateKeyCount()
+}
+
 // GetChaincodeEventListener returns a struct that implements cceventmgmt.ChaincodeLifecycleEventListener
 // if the underlying statedb implements statedb.IndexCapable.
 func (s *DB) GetChaincodeEventListener() cceventmgmt.ChaincodeLifecycleEventListener {
@@ -187,6 +266,23 @@ func (s *DB) GetChaincodeEventListener() cceventmgmt.ChaincodeLifecycleEventList
 	return nil
 }
 
+// GetState overrides the function in statedb.VersionedDB so as to serve the reads from the read cache, if enabled
+func (s *DB) GetState(namespace, key string) (*statedb.VersionedValue, error) {
+	if s.readCache == nil {
+		return s.VersionedDB.GetState(namespace, key)
+	}
+	vv, ok, generation := s.readCache.get(namespace, key)
+	if ok {
+		return vv, nil
+	}
+	vv, err := s.VersionedDB.GetState(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	s.readCache.add(namespace, key, vv, generation)
+	return vv, nil
+}
+
 // GetPrivateData gets the value of a private data item identified by a tuple <namespace, collection, key>
 func (s *DB) GetPrivateData(namespace, collection, key string) (*statedb.VersionedValue, error) {
 	return s.GetState(derivePvtDataNs(namespace, collection), key)
@@ -240,6 +336,63 @@ func (s *DB) GetPrivateDataRangeScanIterator(namespace, collection, startKey, en
 	return s.GetStateRangeScanIterator(derivePvtDataNs(namespace, collection), startKey, endKey)
 }
 
+// GetValueHashRangeScanIterator returns an iterator over the value hashes of the private data items of a collection
+// whose key hashes are between the given key hashes. startKeyHash is included in the results and endKeyHash is excluded.
+// A nil startKeyHash refers to the first key hash and a nil endKeyHash refers to the last key hash. The key in each of
+// the returned results is the key hash. When the underlying db does not support the bytes keys, the key hashes are stored
+// in the encoded form that does not preserve the order of the key hashes. In this case, all the key hashes of the
+// collection are scanned, the key hashes outside the range are skipped, and the results are not in the order of the key hashes
+func (s *DB) GetValueHashRangeScanIterator(namespace, collection string, startKeyHash, endKeyHash []byte) (statedb.ResultsIterator, error) {
+	hashedDataNs := deriveHashedDataNs(namespace, collection)
+	if s.BytesKeySupported() {
+		return s.GetStateRangeScanIterator(hashedDataNs, string(startKeyHash), string(endKeyHash))
+	}
+	dbItr, err := s.GetStateRangeScanIterator(hashedDataNs, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &encodedKeyHashRangeItr{
+		dbItr:        dbItr,
+		startKeyHash: startKeyHash,
+		endKeyHash:   endKeyHash,
+	}, nil
+}
+
+// encodedKeyHashRangeItr decodes the key hashes that are stored in the encoded form and skips the ones outside the range
+type encodedKeyHashRangeItr struct {
+	dbItr        statedb.ResultsIterator
+	startKeyHash []byte
+	endKeyHash   []byte
+}
+
+func (itr *encodedKeyHashRangeItr) Next() (*statedb.VersionedKV, error) {
+	for {
+		kv, err := itr.dbItr.Next()
+		if err != nil || kv == nil {
+			return kv, err
+		}
+		keyHash, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while decoding the key hash [%s]", kv.Key)
+		}
+		if bytes.Compare(keyHash, itr.startKeyHash) < 0 ||
+			(len(itr.endKeyHash) > 0 && bytes.Compare(keyHash, itr.endKeyHash) >= 0) {
+			continue
+		}
+		return &statedb.VersionedKV{
+			CompositeKey: &statedb.CompositeKey{
+				Namespace: kv.Namespace,
+				Key:       string(keyHash),
+			},
+			VersionedValue: kv.VersionedValue,
+		}, nil
+	}
+}
+
+func (itr *encodedKeyHashRangeItr) Close() {
+	itr.dbItr.Close()
+}
+
 // ExecuteQueryOnPrivateData executes the given query and returns an iterator that contains results of type specific to the underlying data store.
 func (s DB) ExecuteQueryOnPrivateData(namespace, collection, query string) (statedb.ResultsIterator, error) {
 	return s.ExecuteQuery(derivePvtDataNs(namespace, collection), query)
@@ -260,9 +413,76 @@ func (s *DB) ApplyPrivacyAwareUpdates(updates *UpdateBatch, height *version.Heig
 	if err := s.metadataHint.setMetadataUsedFlag(updates); err != nil {
 		return err
 	}
+	if s.readCache != nil {
+		s.readCache.startCommit(combinedUpdates.UpdateBatch)
+		defer s.readCache.endCommit()
+	}
 	return s.VersionedDB.ApplyUpdates(combinedUpdates.UpdateBatch, height)
 }
 
+// DropNamespaces deletes the public data, the hashes of the private data, and the private data of the given
+// namespaces, leaving the savepoint unchanged. The data of the namespaces can then be recomputed from the blocks
+func (s *DB) DropNamespaces(namespaces []string) error {
+	toDrop := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		toDrop[ns] = struct{}{}
+	}
+	itr, err := s.VersionedDB.GetFullScanIterator(
+		func(ns string) bool {
+			if i := strings.Index(ns, nsJoiner); i >= 0 {
+				ns = ns[:i]
+			}
+			_, ok := toDrop[ns]
+			return !ok
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer itr.Close()
+
+	applyDeletes := func(batch *statedb.UpdateBatch) error {
+		if s.readCache != nil {
+			s.readCache.startCommit(batch)
+			defer s.readCache.endCommit()
+		}
+		return s.VersionedDB.ApplyUpdates(batch, nil)
+	}
+
+	batch := statedb.NewUpdateBatch()
+	numKeys, numDeleted := 0, 0
+	for {
+		kv, err := itr.Next()
+		if err != nil {
+			return err
+		}
+		if kv == nil {
+			break
+		}
+		batch.Delete(kv.Namespace, kv.Key, kv.Version)
+		numKeys++
+		numDeleted++
+		if numKeys >= maxDropNamespacesBatchSize {
+			if err := applyDeletes(batch); err != nil {
+				return err
+			}
+			batch = statedb.NewUpdateBatch()
+			numKeys = 0
+		}
+	}
+	if err := applyDeletes(batch); err != nil {
+		return err
+	}
+	logger.Infof("Dropped namespaces %s from statedb, removed [%d] keys", namespaces, numDeleted)
+	return nil
+}
+
+// MetadataEverUsedFor returns false if it is known that the metadata has never been set for any key in the
+// namespace, in which case the metadata of the keys is not read from the statedb
+func (s *DB) MetadataEverUsedFor(namespace string) bool {
+	return s.metadataHint.metadataEverUsedFor(namespace)
+}
+
 // GetStateMetadata implements corresponding function in interface DB. This implementation provides
 // an optimization such that it keeps track if a namespaces has never stored metadata for any of
 // its items, the value 'nil' is returned without going to the db. This is intended to be invoked
@@ -8,9 +8,11 @@ package privacyenabledstate
 
 import (
 	"crypto/rand"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"sort"
 	"testing"
 
 	"github.com/hyperledger/fabric-protos-go/peer"
@@ -20,6 +22,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	testmock "github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate/mock"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	statedbmock "github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/mock"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
 	"github.com/hyperledger/fabric/core/ledger/mock"
@@ -255,6 +258,106 @@ func testGetStateRangeScanIterator(t *testing.T, env TestEnv) {
 	testItr(t, pvtItr4, []string{"key5", "key6"})
 }
 
+func TestGetValueHashRangeScanIterator(t *testing.T) {
+	for _, env := range testEnvs {
+		t.Run(env.GetName(), func(t *testing.T) {
+			testGetValueHashRangeScanIterator(t, env)
+		})
+	}
+}
+
+func testGetValueHashRangeScanIterator(t *testing.T, env TestEnv) {
+	env.Init(t)
+	defer env.Cleanup()
+	db := env.GetDBHandle(generateLedgerID(t))
+
+	updates := NewUpdateBatch()
+	expectedVals := map[string]*statedb.VersionedValue{}
+	var keyHashes []string
+	for i := 1; i <= 4; i++ {
+		value := []byte(fmt.Sprintf("pvt_value%d", i))
+		putPvtUpdates(t, updates, "ns1", "coll1", testKey(i), value, version.NewHeight(1, uint64(i)))
+		keyHash := string(util.ComputeStringHash(testKey(i)))
+		keyHashes = append(keyHashes, keyHash)
+		expectedVals[keyHash] = &statedb.VersionedValue{Value: util.ComputeHash(value), Version: version.NewHeight(1, uint64(i))}
+	}
+	putPvtUpdates(t, updates, "ns1", "coll2", "key5", []byte("pvt_value5"), version.NewHeight(1, 5))
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(1, 5)))
+	sort.Strings(keyHashes)
+
+	testValueHashItr := func(startKeyHash, endKeyHash []byte, expectedKeyHashes []string) {
+		itr, err := db.GetValueHashRangeScanIterator("ns1", "coll1", startKeyHash, endKeyHash)
+		require.NoError(t, err)
+		defer itr.Close()
+		var keyHashes []string
+		for {
+			kv, err := itr.Next()
+			require.NoError(t, err)
+			if kv == nil {
+				break
+			}
+			require.Equal(t, expectedVals[kv.Key], kv.VersionedValue)
+			keyHashes = append(keyHashes, kv.Key)
+		}
+		if db.BytesKeySupported() {
+			require.Equal(t, expectedKeyHashes, keyHashes)
+		} else {
+			require.ElementsMatch(t, expectedKeyHashes, keyHashes)
+		}
+	}
+	testValueHashItr(nil, nil, keyHashes)
+	testValueHashItr([]byte(keyHashes[1]), nil, keyHashes[1:])
+	testValueHashItr([]byte(keyHashes[1]), []byte(keyHashes[3]), keyHashes[1:3])
+	testValueHashItr(nil, []byte(keyHashes[0]), nil)
+}
+
+func TestEncodedKeyHashRangeItr(t *testing.T) {
+	keyHashes := [][]byte{{0x00, 0x01}, {0x7f}, {0xf0, 0x01}, {0xff}}
+	dbItr := &statedbmock.ResultsIterator{}
+	// the base64 encoded key hashes are not in the order of the key hashes
+	for i, keyHash := range [][]byte{keyHashes[3], keyHashes[0], keyHashes[2], keyHashes[1]} {
+		dbItr.NextReturnsOnCall(i, &statedb.VersionedKV{
+			CompositeKey:   &statedb.CompositeKey{Namespace: "ns1$$hcoll1", Key: base64.StdEncoding.EncodeToString(keyHash)},
+			VersionedValue: &statedb.VersionedValue{Value: []byte("value-hash"), Version: version.NewHeight(1, 1)},
+		}, nil)
+	}
+	itr := &encodedKeyHashRangeItr{
+		dbItr:        dbItr,
+		startKeyHash: keyHashes[1],
+		endKeyHash:   keyHashes[3],
+	}
+	var results []*statedb.VersionedKV
+	for {
+		kv, err := itr.Next()
+		require.NoError(t, err)
+		if kv == nil {
+			break
+		}
+		results = append(results, kv)
+	}
+	require.Equal(t, []*statedb.VersionedKV{
+		{
+			CompositeKey:   &statedb.CompositeKey{Namespace: "ns1$$hcoll1", Key: string(keyHashes[2])},
+			VersionedValue: &statedb.VersionedValue{Value: []byte("value-hash"), Version: version.NewHeight(1, 1)},
+		},
+		{
+			CompositeKey:   &statedb.CompositeKey{Namespace: "ns1$$hcoll1", Key: string(keyHashes[1])},
+			VersionedValue: &statedb.VersionedValue{Value: []byte("value-hash"), Version: version.NewHeight(1, 1)},
+		},
+	}, results)
+	itr.Close()
+	require.Equal(t, 1, dbItr.CloseCallCount())
+
+	dbItr = &statedbmock.ResultsIterator{}
+	dbItr.NextReturns(&statedb.VersionedKV{
+		CompositeKey:   &statedb.CompositeKey{Namespace: "ns1$$hcoll1", Key: "not-base64!"},
+		VersionedValue: &statedb.VersionedValue{},
+	}, nil)
+	itr = &encodedKeyHashRangeItr{dbItr: dbItr}
+	_, err := itr.Next()
+	require.EqualError(t, err, "error while decoding the key hash [not-base64!]: illegal base64 data at input byte 3")
+}
+
 func TestQueryOnCouchDB(t *testing.T) {
 	for _, env := range testEnvs {
 		_, ok := env.(*CouchDBTestEnv)
@@ -595,6 +698,10 @@ func TestDrop(t *testing.T) {
 			testDrop(t, env)
 		})
 
+		if _, ok := env.(*PebbleDBTestEnv); ok {
+			// pebble panics on the use of a closed db
+			continue
+		}
 		t.Run("test-drop-error-propagation", func(t *testing.T) {
 			env.Init(t)
 			defer env.Cleanup()
@@ -644,6 +751,56 @@ func testDrop(t *testing.T, env TestEnv) {
 	require.Nil(t, vv)
 }
 
+func TestDropNamespaces(t *testing.T) {
+	for _, env := range testEnvs {
+		t.Run(env.GetName(), func(t *testing.T) {
+			testDropNamespaces(t, env)
+		})
+	}
+}
+
+func testDropNamespaces(t *testing.T, env TestEnv) {
+	env.Init(t)
+	defer env.Cleanup()
+	db := env.GetDBHandle(generateLedgerID(t))
+
+	updates := NewUpdateBatch()
+	for _, ns := range []string{"ns", "ns1", "ns2"} {
+		for i := 0; i < 5; i++ {
+			updates.PubUpdates.Put(ns, testKey(i), []byte("value"), version.NewHeight(1, 1))
+			putPvtUpdates(t, updates, ns, "coll1", testKey(i), []byte("pvt_value"), version.NewHeight(1, 2))
+		}
+	}
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(2, 2)))
+
+	require.NoError(t, db.DropNamespaces([]string{"ns1"}))
+	for _, ns := range []string{"ns", "ns1", "ns2"} {
+		vv, err := db.GetState(ns, testKey(0))
+		require.NoError(t, err)
+		pvtVV, err := db.GetPrivateData(ns, "coll1", testKey(0))
+		require.NoError(t, err)
+		hashVV, err := db.GetPrivateDataHash(ns, "coll1", testKey(0))
+		require.NoError(t, err)
+		if ns == "ns1" {
+			require.Nil(t, vv)
+			require.Nil(t, pvtVV)
+			require.Nil(t, hashVV)
+			continue
+		}
+		require.Equal(t, []byte("value"), vv.Value)
+		require.Equal(t, []byte("pvt_value"), pvtVV.Value)
+		require.Equal(t, util.ComputeStringHash("pvt_value"), hashVV.Value)
+	}
+
+	itr, err := db.GetStateRangeScanIterator("ns1", "", "")
+	require.NoError(t, err)
+	testItr(t, itr, []string{})
+
+	savepoint, err := db.GetLatestSavePoint()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(2, 2), savepoint)
+}
+
 //go:generate counterfeiter -o mock/channelinfo_provider.go -fake-name ChannelInfoProvider . channelInfoProvider
 
 func TestPossibleNamespaces(t *testing.T) {
@@ -0,0 +1,112 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privacyenabledstate
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+)
+
+// readCache is a bounded LRU cache of the values read from the statedb, including the absence of a key.
+// The keys updated by a commit are evicted before the updates are applied to the statedb and no value read
+// from the statedb is added to the cache while a commit is in progress. In addition, a value is added only if no
+// commit started since the value was read from the statedb. Together, these ensure that a value that is
+// superseded by a commit is never served from the cache after the commit
+type readCache struct {
+	maxEntries int
+
+	mutex            sync.Mutex
+	lru              *list.List // of *readCacheEntry, the most recently used at the front
+	elements         map[readCacheKey]*list.Element
+	generation       uint64
+	commitInProgress bool
+}
+
+type readCacheKey struct {
+	namespace, key string
+}
+
+type readCacheEntry struct {
+	key   readCacheKey
+	value *statedb.VersionedValue
+}
+
+func newReadCache(maxEntries int) *readCache {
+	return &readCache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		elements:   map[readCacheKey]*list.Element{},
+	}
+}
+
+// get returns the cached value and true, if the key is present in the cache. Otherwise, it returns the
+// generation of the cache, which is to be passed to the function add along with the value read from the statedb
+func (c *readCache) get(namespace, key string) (*statedb.VersionedValue, bool, uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.elements[readCacheKey{namespace, key}]
+	if !ok {
+		return nil, false, c.generation
+	}
+	c.lru.MoveToFront(e)
+	return copyVersionedValue(e.Value.(*readCacheEntry).value), true, 0
+}
+
+// add adds the value read from the statedb, unless a commit started after the generation was obtained
+func (c *readCache) add(namespace, key string, value *statedb.VersionedValue, generation uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.commitInProgress || generation != c.generation {
+		return
+	}
+	k := readCacheKey{namespace, key}
+	if e, ok := c.elements[k]; ok {
+		e.Value.(*readCacheEntry).value = copyVersionedValue(value)
+		c.lru.MoveToFront(e)
+		return
+	}
+	if c.lru.Len() >= c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.elements, oldest.Value.(*readCacheEntry).key)
+	}
+	c.elements[k] = c.lru.PushFront(&readCacheEntry{key: k, value: copyVersionedValue(value)})
+}
+
+// startCommit evicts the keys updated by the batch and holds off the additions until the function endCommit is invoked
+func (c *readCache) startCommit(batch *statedb.UpdateBatch) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	c.commitInProgress = true
+	for _, ns := range batch.GetUpdatedNamespaces() {
+		for key := range batch.GetUpdates(ns) {
+			if e, ok := c.elements[readCacheKey{ns, key}]; ok {
+				c.lru.Remove(e)
+				delete(c.elements, e.Value.(*readCacheEntry).key)
+			}
+		}
+	}
+}
+
+func (c *readCache) endCommit() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+	c.commitInProgress = false
+}
+
+// copyVersionedValue returns a shallow copy so that a caller modifying the returned struct does not affect the cache
+func copyVersionedValue(vv *statedb.VersionedValue) *statedb.VersionedValue {
+	if vv == nil {
+		return nil
+	}
+	vvCopy := *vv
+	return &vvCopy
+}
@@ -0,0 +1,181 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privacyenabledstate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/internal/version"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCache(t *testing.T) {
+	vv := func(val string) *statedb.VersionedValue {
+		return &statedb.VersionedValue{Value: []byte(val), Version: version.NewHeight(1, 1)}
+	}
+
+	t.Run("lru-eviction", func(t *testing.T) {
+		c := newReadCache(2)
+		_, ok, gen := c.get("ns", "key1")
+		require.False(t, ok)
+		c.add("ns", "key1", vv("value1"), gen)
+		_, _, gen = c.get("ns", "key2")
+		c.add("ns", "key2", nil, gen)
+
+		// key1 becomes the most recently used and key2 gets evicted on adding key3
+		val, ok, _ := c.get("ns", "key1")
+		require.True(t, ok)
+		require.Equal(t, vv("value1"), val)
+		_, _, gen = c.get("ns", "key3")
+		c.add("ns", "key3", vv("value3"), gen)
+
+		_, ok, _ = c.get("ns", "key2")
+		require.False(t, ok)
+		val, ok, _ = c.get("ns", "key3")
+		require.True(t, ok)
+		require.Equal(t, vv("value3"), val)
+	})
+
+	t.Run("absent-key-cached", func(t *testing.T) {
+		c := newReadCache(2)
+		_, _, gen := c.get("ns", "key1")
+		c.add("ns", "key1", nil, gen)
+		val, ok, _ := c.get("ns", "key1")
+		require.True(t, ok)
+		require.Nil(t, val)
+	})
+
+	t.Run("commit-evicts-updated-keys", func(t *testing.T) {
+		c := newReadCache(10)
+		for _, key := range []string{"key1", "key2"} {
+			_, _, gen := c.get("ns", key)
+			c.add("ns", key, vv("value"), gen)
+		}
+		batch := statedb.NewUpdateBatch()
+		batch.Delete("ns", "key1", version.NewHeight(2, 1))
+		c.startCommit(batch)
+		c.endCommit()
+
+		_, ok, _ := c.get("ns", "key1")
+		require.False(t, ok)
+		_, ok, _ = c.get("ns", "key2")
+		require.True(t, ok)
+	})
+
+	t.Run("read-overlapping-commit-not-cached", func(t *testing.T) {
+		c := newReadCache(10)
+		batch := statedb.NewUpdateBatch()
+		batch.Put("ns", "key1", []byte("value-new"), version.NewHeight(2, 1))
+
+		// the value is read from the statedb before the commit and added after the commit
+		_, _, gen := c.get("ns", "key1")
+		c.startCommit(batch)
+		c.endCommit()
+		c.add("ns", "key1", vv("value-old"), gen)
+		_, ok, _ := c.get("ns", "key1")
+		require.False(t, ok)
+
+		// the value is read and added while the commit is in progress
+		c.startCommit(batch)
+		_, _, gen = c.get("ns", "key1")
+		c.add("ns", "key1", vv("value-old"), gen)
+		c.endCommit()
+		_, ok, _ = c.get("ns", "key1")
+		require.False(t, ok)
+	})
+
+	t.Run("cached-value-not-shared", func(t *testing.T) {
+		c := newReadCache(10)
+		_, _, gen := c.get("ns", "key1")
+		c.add("ns", "key1", vv("value1"), gen)
+		val, _, _ := c.get("ns", "key1")
+		val.Version = version.NewHeight(5, 5)
+		val, _, _ = c.get("ns", "key1")
+		require.Equal(t, version.NewHeight(1, 1), val.Version)
+	})
+}
+
+func TestDBWithReadCache(t *testing.T) {
+	testEnv := &LevelDBTestEnv{}
+	testEnv.Init(t)
+	defer testEnv.Cleanup()
+	testEnv.provider.readCacheSize = 10
+	db := testEnv.GetDBHandle("testledger")
+	require.NotNil(t, db.readCache)
+
+	updates := NewUpdateBatch()
+	updates.PubUpdates.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	updates.PvtUpdates.Put("ns1", "coll1", "key1", []byte("pvt-value1"), version.NewHeight(1, 1))
+	updates.HashUpdates.Put("ns1", "coll1", []byte("hashed-key1"), []byte("hashed-value1"), version.NewHeight(1, 1))
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(1, 1)))
+
+	valueOf := func(vv *statedb.VersionedValue) string {
+		if vv == nil {
+			return ""
+		}
+		return string(vv.Value)
+	}
+	verify := func(expectedPub, expectedPvt, expectedHashed string) {
+		// the second read is served from the cache and returns the same value
+		for i := 0; i < 2; i++ {
+			vv, err := db.GetState("ns1", "key1")
+			require.NoError(t, err)
+			require.Equal(t, expectedPub, valueOf(vv))
+			vv, err = db.GetPrivateData("ns1", "coll1", "key1")
+			require.NoError(t, err)
+			require.Equal(t, expectedPvt, valueOf(vv))
+			vv, err = db.GetValueHash("ns1", "coll1", []byte("hashed-key1"))
+			require.NoError(t, err)
+			require.Equal(t, expectedHashed, valueOf(vv))
+		}
+	}
+	verify("value1", "pvt-value1", "hashed-value1")
+
+	updates = NewUpdateBatch()
+	updates.PubUpdates.Put("ns1", "key1", []byte("value2"), version.NewHeight(2, 1))
+	updates.PvtUpdates.Put("ns1", "coll1", "key1", []byte("pvt-value2"), version.NewHeight(2, 1))
+	updates.HashUpdates.Put("ns1", "coll1", []byte("hashed-key1"), []byte("hashed-value2"), version.NewHeight(2, 1))
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(2, 1)))
+	verify("value2", "pvt-value2", "hashed-value2")
+
+	updates = NewUpdateBatch()
+	updates.PubUpdates.Delete("ns1", "key1", version.NewHeight(3, 1))
+	updates.PvtUpdates.Delete("ns1", "coll1", "key1", version.NewHeight(3, 1))
+	updates.HashUpdates.Delete("ns1", "coll1", []byte("hashed-key1"), version.NewHeight(3, 1))
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(3, 1)))
+	verify("", "", "")
+}
+
+func BenchmarkGetStateWithReadCache(b *testing.B) {
+	for _, readCacheSize := range []int{0, 1000} {
+		b.Run(fmt.Sprintf("readCacheSize=%d", readCacheSize), func(b *testing.B) {
+			bookkeepingTestEnv := bookkeeping.NewTestEnv(b)
+			defer bookkeepingTestEnv.Cleanup()
+			metadataHint, err := newMetadataHint(bookkeepingTestEnv.TestProvider.GetDBHandle("ledger1", bookkeeping.MetadataPresenceIndicator))
+			require.NoError(b, err)
+			mockVersionedDB := &mock.VersionedDB{}
+			mockVersionedDB.GetStateReturns(&statedb.VersionedValue{Value: []byte("value"), Version: version.NewHeight(1, 1)}, nil)
+			db, err := NewDB(mockVersionedDB, "ledger1", metadataHint)
+			require.NoError(b, err)
+			if readCacheSize > 0 {
+				db.readCache = newReadCache(readCacheSize)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := db.GetState("ns", fmt.Sprintf("key%d", i%100)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(mockVersionedDB.GetStateCallCount())/float64(b.N), "dbgets/op")
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"hash"
 	"path/filepath"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/ledger/snapshot"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
@@ -25,6 +26,11 @@ const (
 	PubStateMetadataFileName       = "public_state.metadata"
 	PvtStateHashesFileName         = "private_state_hashes.data"
 	PvtStateHashesMetadataFileName = "private_state_hashes.metadata"
+
+	PubStateDeletesDataFileName           = "public_state_deletes.data"
+	PubStateDeletesMetadataFileName       = "public_state_deletes.metadata"
+	PvtStateHashesDeletesDataFileName     = "private_state_hashes_deletes.data"
+	PvtStateHashesDeletesMetadataFileName = "private_state_hashes_deletes.metadata"
 )
 
 // ExportPubStateAndPvtStateHashes generates four files in the specified dir. The files, public_state.data and public_state.metadata
@@ -32,6 +38,45 @@ const (
 // The file format for public state and the private state hashes are the same. The data files contains a series serialized proto message SnapshotRecord
 // and the metadata files contains a series of tuple <namespace, num entries for the namespace in the data file>.
 func (s *DB) ExportPubStateAndPvtStateHashes(dir string, newHashFunc snapshot.NewHashFunc) (map[string][]byte, error) {
+	return s.exportPubStateAndPvtStateHashes(dir, newHashFunc, func(*statedb.VersionedKV) bool { return true })
+}
+
+// ExportPubStateAndPvtStateHashesDelta generates the files for an incremental snapshot over a snapshot that was generated at
+// the block `baseBlockNum`. The files public_state.data, public_state.metadata, private_state_hashes.data, and
+// private_state_hashes.metadata are generated in the same format as by the function `ExportPubStateAndPvtStateHashes` but
+// contain only the entries that are updated after the block `baseBlockNum`. In addition, the keys in the `deleteCandidates`
+// that are not present in the state are exported as the deleted keys in the files public_state_deletes.data and
+// public_state_deletes.metadata, and in the files private_state_hashes_deletes.data and private_state_hashes_deletes.metadata.
+// The deleted keys are exported as the records with only the key set
+func (s *DB) ExportPubStateAndPvtStateHashesDelta(
+	dir string,
+	newHashFunc snapshot.NewHashFunc,
+	baseBlockNum uint64,
+	deleteCandidates *KeysSet,
+) (map[string][]byte, error) {
+	snapshotFilesInfo, err := s.exportPubStateAndPvtStateHashes(dir, newHashFunc,
+		func(kv *statedb.VersionedKV) bool {
+			return kv.Version.BlockNum > baseBlockNum
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	deletesFilesInfo, err := s.exportDeletedKeys(dir, newHashFunc, deleteCandidates)
+	if err != nil {
+		return nil, err
+	}
+	for f, h := range deletesFilesInfo {
+		snapshotFilesInfo[f] = h
+	}
+	return snapshotFilesInfo, nil
+}
+
+func (s *DB) exportPubStateAndPvtStateHashes(
+	dir string,
+	newHashFunc snapshot.NewHashFunc,
+	include func(*statedb.VersionedKV) bool,
+) (map[string][]byte, error) {
 	itr, err := s.GetFullScanIterator(isPvtdataNs)
 	if err != nil {
 		return nil, err
@@ -48,6 +93,9 @@ func (s *DB) ExportPubStateAndPvtStateHashes(dir string, newHashFunc snapshot.Ne
 		if kv == nil {
 			break
 		}
+		if !include(kv) {
+			continue
+		}
 
 		namespace := kv.Namespace
 		snapshotRecord := &SnapshotRecord{
@@ -124,6 +172,54 @@ func (s *DB) ExportPubStateAndPvtStateHashes(dir string, newHashFunc snapshot.Ne
 	return snapshotFilesInfo, nil
 }
 
+// ComputePubStateHash computes a digest of the public state by traversing the state in the same order as for exporting
+// the public state in a snapshot. Each record, along with its namespace, is fed to the hash function with the
+// length of each prefixed, so that the digest depends on the boundaries between the records
+func (s *DB) ComputePubStateHash(newHashFunc snapshot.NewHashFunc) ([]byte, error) {
+	itr, err := s.GetFullScanIterator(
+		func(namespace string) bool {
+			return isPvtdataNs(namespace) || isHashedDataNs(namespace)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+
+	h, err := newHashFunc()
+	if err != nil {
+		return nil, err
+	}
+	buf := proto.NewBuffer(nil)
+	for {
+		kv, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if kv == nil {
+			break
+		}
+		buf.Reset()
+		if err := buf.EncodeStringBytes(kv.Namespace); err != nil {
+			return nil, errors.Wrap(err, "error while encoding the namespace")
+		}
+		if err := buf.EncodeMessage(
+			&SnapshotRecord{
+				Key:      []byte(kv.Key),
+				Value:    kv.Value,
+				Metadata: kv.Metadata,
+				Version:  kv.Version.ToBytes(),
+			},
+		); err != nil {
+			return nil, errors.Wrap(err, "error while encoding the state record")
+		}
+		if _, err := h.Write(buf.Bytes()); err != nil {
+			return nil, errors.Wrap(err, "error while computing the hash of the public state")
+		}
+	}
+	return h.Sum(nil), nil
+}
+
 // SnapshotWriter generates two files, a data file and a metadata file. The datafile contains a series of tuples <key, dbValue>
 // and the metadata file contains a series of tuples <namesapce, number-of-tuples-in-the-data-file-that-belong-to-this-namespace>
 type SnapshotWriter struct {
@@ -325,6 +421,12 @@ func (r *worldStateSnapshotReader) Next() (*statedb.VersionedKV, error) {
 			r.namespacesThatUseMetadata[namespace] = struct{}{}
 		}
 
+		if err := r.invokePubStateConsumers(
+			namespace, string(snapshotRecord.Key), version,
+		); err != nil {
+			return nil, err
+		}
+
 		return &statedb.VersionedKV{
 			CompositeKey: &statedb.CompositeKey{
 				Namespace: namespace,
@@ -411,6 +513,25 @@ func (r *worldStateSnapshotReader) invokePvtdataHashesConsumers(
 	return nil
 }
 
+// invokePubStateConsumers passes the public state key to the pvtdata hashes consumers that also implement the
+// interface SnapshotPubStateConsumer
+func (r *worldStateSnapshotReader) invokePubStateConsumers(
+	ns string,
+	key string,
+	version *version.Height,
+) error {
+	for _, c := range r.pvtdataHashesConsumers {
+		l, ok := c.(SnapshotPubStateConsumer)
+		if !ok {
+			continue
+		}
+		if err := l.ConsumeSnapshotPubStateData(ns, key, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *worldStateSnapshotReader) invokeDoneOnPvtdataHashesConsumers() error {
 	if len(r.pvtdataHashesConsumers) == 0 {
 		return nil
@@ -562,3 +683,9 @@ type SnapshotPvtdataHashesConsumer interface {
 	ConsumeSnapshotData(namespace, coll string, keyHash []byte, valueHash []byte, version *version.Height) error
 	Done() error
 }
+
+// SnapshotPubStateConsumer is optionally implemented by a SnapshotPvtdataHashesConsumer that, in addition, consumes
+// the public state keys while the public state is imported from a snapshot
+type SnapshotPubStateConsumer interface {
+	ConsumeSnapshotPubStateData(namespace, key string, version *version.Height) error
+}
@@ -0,0 +1,160 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privacyenabledstate
+
+import (
+	"sort"
+
+	"github.com/hyperledger/fabric/common/ledger/snapshot"
+)
+
+// KeysSet holds a set of keys of the public state and a set of key hashes of the private data
+type KeysSet struct {
+	pubKeys    map[string]map[string]struct{}
+	hashedKeys map[nsColl]map[string]struct{}
+}
+
+type nsColl struct {
+	ns, coll string
+}
+
+// NewKeysSet constructs an empty KeysSet
+func NewKeysSet() *KeysSet {
+	return &KeysSet{
+		pubKeys:    map[string]map[string]struct{}{},
+		hashedKeys: map[nsColl]map[string]struct{}{},
+	}
+}
+
+// AddPubKey adds a key of the public state to the set
+func (s *KeysSet) AddPubKey(ns, key string) {
+	keys, ok := s.pubKeys[ns]
+	if !ok {
+		keys = map[string]struct{}{}
+		s.pubKeys[ns] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// AddKeyHash adds a hash of a private data key to the set
+func (s *KeysSet) AddKeyHash(ns, coll string, keyHash []byte) {
+	c := nsColl{ns, coll}
+	keys, ok := s.hashedKeys[c]
+	if !ok {
+		keys = map[string]struct{}{}
+		s.hashedKeys[c] = keys
+	}
+	keys[string(keyHash)] = struct{}{}
+}
+
+// exportDeletedKeys exports the keys in the set that are not present in the state. The keys are exported in the
+// order of namespaces and keys, so that the keys of a namespace are contiguous in the data files
+func (s *DB) exportDeletedKeys(dir string, newHashFunc snapshot.NewHashFunc, keysSet *KeysSet) (map[string][]byte, error) {
+	snapshotFilesInfo := map[string][]byte{}
+	if keysSet == nil {
+		return snapshotFilesInfo, nil
+	}
+
+	var pubDeletesWriter *SnapshotWriter
+	for _, ns := range sortedKeys(keysSet.pubKeys) {
+		for _, key := range sortedSet(keysSet.pubKeys[ns]) {
+			vv, err := s.GetState(ns, key)
+			if err != nil {
+				return nil, err
+			}
+			if vv != nil {
+				continue
+			}
+			if pubDeletesWriter == nil {
+				if pubDeletesWriter, err = NewSnapshotWriter(
+					dir,
+					PubStateDeletesDataFileName,
+					PubStateDeletesMetadataFileName,
+					newHashFunc,
+				); err != nil {
+					return nil, err
+				}
+				defer pubDeletesWriter.Close()
+			}
+			if err := pubDeletesWriter.AddData(ns, &SnapshotRecord{Key: []byte(key)}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	hashedNamespaces := map[string]nsColl{}
+	var sortedHashedNamespaces []string
+	for c := range keysSet.hashedKeys {
+		hashedNs := deriveHashedDataNs(c.ns, c.coll)
+		hashedNamespaces[hashedNs] = c
+		sortedHashedNamespaces = append(sortedHashedNamespaces, hashedNs)
+	}
+	sort.Strings(sortedHashedNamespaces)
+	var pvtStateHashesDeletesWriter *SnapshotWriter
+	for _, hashedNs := range sortedHashedNamespaces {
+		c := hashedNamespaces[hashedNs]
+		for _, keyHash := range sortedSet(keysSet.hashedKeys[c]) {
+			vv, err := s.GetValueHash(c.ns, c.coll, []byte(keyHash))
+			if err != nil {
+				return nil, err
+			}
+			if vv != nil {
+				continue
+			}
+			if pvtStateHashesDeletesWriter == nil {
+				if pvtStateHashesDeletesWriter, err = NewSnapshotWriter(
+					dir,
+					PvtStateHashesDeletesDataFileName,
+					PvtStateHashesDeletesMetadataFileName,
+					newHashFunc,
+				); err != nil {
+					return nil, err
+				}
+				defer pvtStateHashesDeletesWriter.Close()
+			}
+			if err := pvtStateHashesDeletesWriter.AddData(hashedNs, &SnapshotRecord{Key: []byte(keyHash)}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if pubDeletesWriter != nil {
+		dataHash, metadataHash, err := pubDeletesWriter.Done()
+		if err != nil {
+			return nil, err
+		}
+		snapshotFilesInfo[PubStateDeletesDataFileName] = dataHash
+		snapshotFilesInfo[PubStateDeletesMetadataFileName] = metadataHash
+	}
+	if pvtStateHashesDeletesWriter != nil {
+		dataHash, metadataHash, err := pvtStateHashesDeletesWriter.Done()
+		if err != nil {
+			return nil, err
+		}
+		snapshotFilesInfo[PvtStateHashesDeletesDataFileName] = dataHash
+		snapshotFilesInfo[PvtStateHashesDeletesMetadataFileName] = metadataHash
+	}
+	return snapshotFilesInfo, nil
+}
+
+func sortedKeys(m map[string]map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSet(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
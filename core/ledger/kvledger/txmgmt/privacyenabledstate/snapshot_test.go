@@ -191,6 +191,78 @@ func testSnapshotWithSampleData(t *testing.T, env TestEnv,
 	require.Equal(t, filesAndHashesSrcDB, filesAndHashesDestDB)
 }
 
+func TestSnapshotDelta(t *testing.T) {
+	for _, env := range testEnvs {
+		t.Run(env.GetName(), func(t *testing.T) {
+			testSnapshotDelta(t, env)
+		})
+	}
+}
+
+func testSnapshotDelta(t *testing.T, env TestEnv) {
+	env.Init(t)
+	defer env.Cleanup()
+	db := env.GetDBHandle(generateLedgerID(t))
+
+	updateBatch := NewUpdateBatch()
+	for i := 0; i < 3; i++ {
+		updateBatch.PubUpdates.Put("ns1", fmt.Sprintf("key-%d", i), []byte("value"), version.NewHeight(1, 1))
+		updateBatch.HashUpdates.Put("ns1", "coll1", []byte(fmt.Sprintf("key-hash-%d", i)), []byte("value-hash"), version.NewHeight(1, 1))
+	}
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(updateBatch, version.NewHeight(1, 1)))
+
+	// key-1 is updated and key-2 is deleted after the base snapshot
+	updateBatch = NewUpdateBatch()
+	updateBatch.PubUpdates.Put("ns1", "key-1", []byte("new-value"), version.NewHeight(2, 1))
+	updateBatch.PubUpdates.Delete("ns1", "key-2", version.NewHeight(2, 1))
+	updateBatch.HashUpdates.Put("ns1", "coll1", []byte("key-hash-1"), []byte("new-value-hash"), version.NewHeight(2, 1))
+	updateBatch.HashUpdates.Delete("ns1", "coll1", []byte("key-hash-2"), version.NewHeight(2, 1))
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(updateBatch, version.NewHeight(2, 1)))
+
+	deleteCandidates := NewKeysSet()
+	deleteCandidates.AddPubKey("ns1", "key-1")
+	deleteCandidates.AddPubKey("ns1", "key-2")
+	deleteCandidates.AddKeyHash("ns1", "coll1", []byte("key-hash-1"))
+	deleteCandidates.AddKeyHash("ns1", "coll1", []byte("key-hash-2"))
+
+	snapshotDir := t.TempDir()
+	filesAndHashes, err := db.ExportPubStateAndPvtStateHashesDelta(snapshotDir, testNewHashFunc, 1, deleteCandidates)
+	require.NoError(t, err)
+	require.Len(t, filesAndHashes, 8)
+
+	readRecords := func(dataFileName, metadataFileName string) map[string][]string {
+		reader, err := NewSnapshotReader(snapshotDir, dataFileName, metadataFileName)
+		require.NoError(t, err)
+		defer reader.Close()
+		records := map[string][]string{}
+		for {
+			ns, record, err := reader.Next()
+			require.NoError(t, err)
+			if record == nil {
+				return records
+			}
+			records[ns] = append(records[ns], string(record.Key)+"="+string(record.Value))
+		}
+	}
+	hashedNs := deriveHashedDataNs("ns1", "coll1")
+	require.Equal(t,
+		map[string][]string{"ns1": {"key-1=new-value"}},
+		readRecords(PubStateDataFileName, PubStateMetadataFileName),
+	)
+	require.Equal(t,
+		map[string][]string{hashedNs: {"key-hash-1=new-value-hash"}},
+		readRecords(PvtStateHashesFileName, PvtStateHashesMetadataFileName),
+	)
+	require.Equal(t,
+		map[string][]string{"ns1": {"key-2="}},
+		readRecords(PubStateDeletesDataFileName, PubStateDeletesMetadataFileName),
+	)
+	require.Equal(t,
+		map[string][]string{hashedNs: {"key-hash-2="}},
+		readRecords(PvtStateHashesDeletesDataFileName, PvtStateHashesDeletesMetadataFileName),
+	)
+}
+
 func verifyExportedSnapshot(
 	t *testing.T,
 	snapshotDir string,
@@ -671,6 +743,20 @@ type snapshotPvtdataHashesConsumer interface {
 	SnapshotPvtdataHashesConsumer
 }
 
+type pubStateConsumer struct {
+	*mock.SnapshotPvtdataHashesConsumer
+	consumed []*statedb.VersionedKV
+	err      error
+}
+
+func (c *pubStateConsumer) ConsumeSnapshotPubStateData(namespace, key string, version *version.Height) error {
+	c.consumed = append(c.consumed, &statedb.VersionedKV{
+		CompositeKey:   &statedb.CompositeKey{Namespace: namespace, Key: key},
+		VersionedValue: &statedb.VersionedValue{Version: version},
+	})
+	return c.err
+}
+
 func TestSnapshotImportPvtdataHashesConsumer(t *testing.T) {
 	for _, dbEnv := range testEnvs {
 		testSnapshotImportPvtdataHashesConsumer(t, dbEnv)
@@ -727,6 +813,37 @@ func testSnapshotImportPvtdataHashesConsumer(t *testing.T, dbEnv TestEnv) {
 		}
 	})
 
+	t.Run("snapshot-import-invokes-pub-state-consumer-"+dbEnv.GetName(), func(t *testing.T) {
+		init()
+		consumer := &pubStateConsumer{SnapshotPvtdataHashesConsumer: &mock.SnapshotPvtdataHashesConsumer{}}
+		err := dbEnv.GetProvider().ImportFromSnapshot(
+			generateLedgerID(t),
+			version.NewHeight(10, 10),
+			snapshotDir,
+			consumer,
+		)
+		require.NoError(t, err)
+		require.Equal(t, []*statedb.VersionedKV{
+			{
+				CompositeKey:   &statedb.CompositeKey{Namespace: "ns-1", Key: "key-1"},
+				VersionedValue: &statedb.VersionedValue{Version: version.NewHeight(1, 1)},
+			},
+		}, consumer.consumed)
+		require.Equal(t, 1, consumer.ConsumeSnapshotDataCallCount())
+
+		consumer = &pubStateConsumer{
+			SnapshotPvtdataHashesConsumer: &mock.SnapshotPvtdataHashesConsumer{},
+			err:                           errors.New("cannot-consume-pub-state"),
+		}
+		err = dbEnv.GetProvider().ImportFromSnapshot(
+			generateLedgerID(t),
+			version.NewHeight(10, 10),
+			snapshotDir,
+			consumer,
+		)
+		require.EqualError(t, err, "cannot-consume-pub-state")
+	})
+
 	t.Run("snapshot-import-propages-error-from-consumer-"+dbEnv.GetName(), func(t *testing.T) {
 		init()
 		consumers := []*mock.SnapshotPvtdataHashesConsumer{
@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privacyenabledstate
+
+import (
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
+)
+
+// GetPubStateProof computes the state root of the public state, as defined in the package stateproof, along with
+// the proof for the given key. The state root is computed by traversing the entire public state, in the same order
+// as for computing the digest of the public state, and hence this function should be used judiciously. A nil proof
+// is returned if the key does not exist. The `BlockNum` of the proof is left for the caller to set
+func (s *DB) GetPubStateProof(namespace, key string) (*stateproof.Proof, error) {
+	itr, err := s.GetFullScanIterator(
+		func(namespace string) bool {
+			return isPvtdataNs(namespace) || isHashedDataNs(namespace)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+
+	proof := &stateproof.Proof{
+		Namespace: namespace,
+		Key:       key,
+	}
+	found := false
+	stateTree := stateproof.NewTreeBuilder()
+	var currentNamespace string
+	var namespaceTree *stateproof.TreeBuilder
+
+	addNamespaceToStateTree := func() {
+		namespaceRoot, keyPath := namespaceTree.Done()
+		isTarget := currentNamespace == namespace && found
+		if isTarget {
+			proof.KeyPath = keyPath
+		}
+		stateTree.Add(stateproof.NamespaceLeafHash(currentNamespace, namespaceRoot), isTarget)
+	}
+
+	for {
+		kv, err := itr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if kv == nil {
+			break
+		}
+		if namespaceTree == nil || kv.Namespace != currentNamespace {
+			if namespaceTree != nil {
+				addNamespaceToStateTree()
+			}
+			currentNamespace = kv.Namespace
+			namespaceTree = stateproof.NewTreeBuilder()
+		}
+		isTarget := kv.Namespace == namespace && kv.Key == key
+		if isTarget {
+			found = true
+			proof.Value = kv.Value
+		}
+		namespaceTree.Add(stateproof.KeyLeafHash(kv.Key, kv.Value), isTarget)
+	}
+	if namespaceTree != nil {
+		addNamespaceToStateTree()
+	}
+
+	if !found {
+		return nil, nil
+	}
+	proof.Root, proof.NamespacePath = stateTree.Done()
+	return proof, nil
+}
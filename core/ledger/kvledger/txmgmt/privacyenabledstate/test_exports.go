@@ -32,7 +32,7 @@ type TestEnv interface {
 
 // Tests will be run against each environment in this array
 // For example, to skip CouchDB tests, remove &CouchDBLockBasedEnv{}
-var testEnvs = []TestEnv{&LevelDBTestEnv{}, &CouchDBTestEnv{}}
+var testEnvs = []TestEnv{&LevelDBTestEnv{}, &PebbleDBTestEnv{}, &CouchDBTestEnv{}}
 
 ///////////// LevelDB Environment //////////////
 
@@ -53,8 +53,8 @@ func (env *LevelDBTestEnv) Init(t testing.TB) {
 		&disabled.Provider{},
 		&mock.HealthCheckRegistry{},
 		&StateDBConfig{
-			&ledger.StateDBConfig{},
-			dbPath,
+			StateDBConfig: &ledger.StateDBConfig{},
+			LevelDBPath:   dbPath,
 		},
 		[]string{"lscc", "_lifecycle"},
 	)
@@ -97,6 +97,66 @@ func (env *LevelDBTestEnv) Cleanup() {
 	env.bookkeeperTestEnv.Cleanup()
 }
 
+///////////// Pebble Environment //////////////
+
+// PebbleDBTestEnv implements TestEnv interface for pebble based storage
+type PebbleDBTestEnv struct {
+	t                 testing.TB
+	provider          *DBProvider
+	bookkeeperTestEnv *bookkeeping.TestEnv
+}
+
+// Init implements corresponding function from interface TestEnv
+func (env *PebbleDBTestEnv) Init(t testing.TB) {
+	env.bookkeeperTestEnv = bookkeeping.NewTestEnv(t)
+	dbProvider, err := NewDBProvider(
+		env.bookkeeperTestEnv.TestProvider,
+		&disabled.Provider{},
+		&mock.HealthCheckRegistry{},
+		&StateDBConfig{
+			StateDBConfig: &ledger.StateDBConfig{StateDatabase: ledger.Pebble},
+			PebbleDBPath:  t.TempDir(),
+		},
+		[]string{"lscc", "_lifecycle"},
+	)
+	require.NoError(t, err)
+	env.t = t
+	env.provider = dbProvider
+}
+
+// StartExternalResource will be an empty implementation for pebble test environment.
+func (env *PebbleDBTestEnv) StartExternalResource() {
+	// empty implementation
+}
+
+// StopExternalResource will be an empty implementation for pebble test environment.
+func (env *PebbleDBTestEnv) StopExternalResource() {
+	// empty implementation
+}
+
+// GetDBHandle implements corresponding function from interface TestEnv
+func (env *PebbleDBTestEnv) GetDBHandle(id string) *DB {
+	db, err := env.provider.GetDBHandle(id, nil)
+	require.NoError(env.t, err)
+	return db
+}
+
+// GetProvider returns DBProvider
+func (env *PebbleDBTestEnv) GetProvider() *DBProvider {
+	return env.provider
+}
+
+// GetName implements corresponding function from interface TestEnv
+func (env *PebbleDBTestEnv) GetName() string {
+	return "pebbleDBTestEnv"
+}
+
+// Cleanup implements corresponding function from interface TestEnv
+func (env *PebbleDBTestEnv) Cleanup() {
+	env.provider.Close()
+	env.bookkeeperTestEnv.Cleanup()
+}
+
 ///////////// CouchDB Environment //////////////
 
 // CouchDBTestEnv implements TestEnv interface for couchdb based storage
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package pvtstatepurgemgmt
 
 import (
+	"math"
+
 	proto "github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/util"
@@ -17,11 +19,13 @@ import (
 var logger = flogging.MustGetLogger("pvtstatepurgemgmt")
 
 const (
-	expiryPrefix = '1'
+	expiryPrefix         = '1'
+	pubStateExpiryPrefix = '2'
 )
 
 type expiryKeeper struct {
-	db *leveldbhelper.DBHandle
+	db     *leveldbhelper.DBHandle
+	prefix byte
 }
 
 // expiryInfo encapsulates an 'expiryInfoKey' and corresponding private data keys.
@@ -40,7 +44,14 @@ type expiryInfoKey struct {
 }
 
 func newExpiryKeeper(ledgerid string, provider *bookkeeping.Provider) *expiryKeeper {
-	return &expiryKeeper{provider.GetDBHandle(ledgerid, bookkeeping.PvtdataExpiry)}
+	return &expiryKeeper{provider.GetDBHandle(ledgerid, bookkeeping.PvtdataExpiry), expiryPrefix}
+}
+
+// newPubStateExpiryKeeper returns an expiryKeeper for the public state keys of the namespaces that have a
+// block-to-live configured. The entries are kept in the same db as the entries for the private data, under a
+// different prefix. In these entries, the public state keys are recorded with an empty collection name
+func newPubStateExpiryKeeper(ledgerid string, provider *bookkeeping.Provider) *expiryKeeper {
+	return &expiryKeeper{provider.GetDBHandle(ledgerid, bookkeeping.PvtdataExpiry), pubStateExpiryPrefix}
 }
 
 // update keeps track of the list of keys and their corresponding expiry block number
@@ -56,24 +67,36 @@ func newExpiryKeeper(ledgerid string, provider *bookkeeping.Provider) *expiryKee
 // at the time of the commit of the block number 45 and the second entry was created at the time of the commit of the block number 40, however
 // both are expiring with the commit of block number 50.
 func (ek *expiryKeeper) update(toTrack []*expiryInfo, toClear []*expiryInfoKey) error {
+	if len(toTrack) == 0 && len(toClear) == 0 {
+		return nil
+	}
 	updateBatch := ek.db.NewUpdateBatch()
 	for _, expinfo := range toTrack {
-		k, v, err := encodeKV(expinfo)
+		k, v, err := encodeKV(ek.prefix, expinfo)
 		if err != nil {
 			return err
 		}
 		updateBatch.Put(k, v)
 	}
 	for _, expinfokey := range toClear {
-		updateBatch.Delete(encodeExpiryInfoKey(expinfokey))
+		updateBatch.Delete(encodeExpiryInfoKey(ek.prefix, expinfokey))
 	}
 	return ek.db.WriteBatch(updateBatch, true)
 }
 
 // retrieve returns the keys info that are supposed to be expired by the given block number
 func (ek *expiryKeeper) retrieve(expiringAtBlkNum uint64) ([]*expiryInfo, error) {
-	startKey := encodeExpiryInfoKey(&expiryInfoKey{expiryBlk: expiringAtBlkNum, committingBlk: 0})
-	endKey := encodeExpiryInfoKey(&expiryInfoKey{expiryBlk: expiringAtBlkNum + 1, committingBlk: 0})
+	return ek.retrieveRange(expiringAtBlkNum, expiringAtBlkNum)
+}
+
+// retrieveRange returns the keys info that are supposed to be expired by the block numbers in the range
+// [startBlkNum, endBlkNum], ordered by the expiring block and then, by the committing block
+func (ek *expiryKeeper) retrieveRange(startBlkNum, endBlkNum uint64) ([]*expiryInfo, error) {
+	startKey := encodeExpiryInfoKey(ek.prefix, &expiryInfoKey{expiryBlk: startBlkNum, committingBlk: 0})
+	endKey := []byte{ek.prefix + 1}
+	if endBlkNum < math.MaxUint64 {
+		endKey = encodeExpiryInfoKey(ek.prefix, &expiryInfoKey{expiryBlk: endBlkNum + 1, committingBlk: 0})
+	}
 	itr, err := ek.db.GetIterator(startKey, endKey)
 	if err != nil {
 		return nil, err
@@ -93,7 +116,7 @@ func (ek *expiryKeeper) retrieve(expiringAtBlkNum uint64) ([]*expiryInfo, error)
 
 // retrieveByExpiryKey retrieves the expiryInfo for given expiryKey
 func (ek *expiryKeeper) retrieveByExpiryKey(expiryKey *expiryInfoKey) (*expiryInfo, error) {
-	key := encodeExpiryInfoKey(expiryKey)
+	key := encodeExpiryInfoKey(ek.prefix, expiryKey)
 	value, err := ek.db.Get(key)
 	if err != nil {
 		return nil, err
@@ -101,14 +124,14 @@ func (ek *expiryKeeper) retrieveByExpiryKey(expiryKey *expiryInfoKey) (*expiryIn
 	return decodeExpiryInfo(key, value)
 }
 
-func encodeKV(expinfo *expiryInfo) (key []byte, value []byte, err error) {
-	key = encodeExpiryInfoKey(expinfo.expiryInfoKey)
+func encodeKV(prefix byte, expinfo *expiryInfo) (key []byte, value []byte, err error) {
+	key = encodeExpiryInfoKey(prefix, expinfo.expiryInfoKey)
 	value, err = encodeExpiryInfoValue(expinfo.pvtdataKeys)
 	return
 }
 
-func encodeExpiryInfoKey(expinfoKey *expiryInfoKey) []byte {
-	key := append([]byte{expiryPrefix}, util.EncodeOrderPreservingVarUint64(expinfoKey.expiryBlk)...)
+func encodeExpiryInfoKey(prefix byte, expinfoKey *expiryInfoKey) []byte {
+	key := append([]byte{prefix}, util.EncodeOrderPreservingVarUint64(expinfoKey.expiryBlk)...)
 	return append(key, util.EncodeOrderPreservingVarUint64(expinfoKey.committingBlk)...)
 }
 
@@ -21,7 +21,7 @@ func TestExpiryKVEncoding(t *testing.T) {
 	pvtdataKeys.add("ns1", "coll-1", "key-1", []byte("key-1-hash"))
 	expiryInfo := &expiryInfo{&expiryInfoKey{expiryBlk: 10, committingBlk: 2}, pvtdataKeys}
 	t.Logf("expiryInfo:%s", spew.Sdump(expiryInfo))
-	k, v, err := encodeKV(expiryInfo)
+	k, v, err := encodeKV(expiryPrefix, expiryInfo)
 	require.NoError(t, err)
 	expiryInfo1, err := decodeExpiryInfo(k, v)
 	require.NoError(t, err)
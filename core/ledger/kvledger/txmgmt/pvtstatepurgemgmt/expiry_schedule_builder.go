@@ -90,6 +90,42 @@ func buildExpirySchedule(
 	return expiryScheduleBuilder.getExpiryInfo(), nil
 }
 
+// buildPubStateExpirySchedule returns the expiry schedule of the public state keys written by the update batch
+// in the namespaces that have a block-to-live configured. The deletes of the keys are not tracked as a key
+// that is deleted before its expiry is not purged (see the function 'prepareWorkingsetFor')
+func buildPubStateExpirySchedule(pubStateBTL map[string]uint64, pubUpdates *privacyenabledstate.PubUpdateBatch) []*expiryInfo {
+	scheduleEntries := make(map[expiryInfoKey]*PvtdataKeys)
+	for ns, btl := range pubStateBTL {
+		if btl == 0 {
+			continue
+		}
+		for key, vv := range pubUpdates.GetUpdates(ns) {
+			if isDelete(vv) {
+				continue
+			}
+			committingBlk := vv.Version.BlockNum
+			expiryBlk := pvtdatapolicy.ComputeExpiringBlock(ns, "", committingBlk, btl)
+			if neverExpires(expiryBlk) {
+				continue
+			}
+			expinfoKey := expiryInfoKey{committingBlk: committingBlk, expiryBlk: expiryBlk}
+			pubStateKeys, ok := scheduleEntries[expinfoKey]
+			if !ok {
+				pubStateKeys = newPvtdataKeys()
+				scheduleEntries[expinfoKey] = pubStateKeys
+			}
+			pubStateKeys.add(ns, "", key, nil)
+		}
+	}
+
+	var listExpinfo []*expiryInfo
+	for expinfoKey, pubStateKeys := range scheduleEntries {
+		expinfoKeyCopy := expinfoKey
+		listExpinfo = append(listExpinfo, &expiryInfo{expiryInfoKey: &expinfoKeyCopy, pvtdataKeys: pubStateKeys})
+	}
+	return listExpinfo
+}
+
 func isDelete(versionedValue *statedb.VersionedValue) bool {
 	return versionedValue.Value == nil
 }
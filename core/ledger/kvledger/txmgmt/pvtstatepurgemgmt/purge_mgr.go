@@ -7,9 +7,12 @@ SPDX-License-Identifier: Apache-2.0
 package pvtstatepurgemgmt
 
 import (
+	"bytes"
 	"math"
+	"sort"
 	"sync"
 
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
@@ -19,11 +22,14 @@ import (
 )
 
 // PurgeMgr keeps track of the expiry of private data and the private data hashes based on block-to-live
-// parameter specified in the corresponding collection config
+// parameter specified in the corresponding collection config. In addition, it keeps track of the expiry of
+// the public state keys of the namespaces for which a block-to-live is configured in the ledger config
 type PurgeMgr struct {
-	btlPolicy pvtdatapolicy.BTLPolicy
-	db        *privacyenabledstate.DB
-	expKeeper *expiryKeeper
+	btlPolicy    pvtdatapolicy.BTLPolicy
+	pubStateBTL  map[string]uint64
+	db           *privacyenabledstate.DB
+	expKeeper    *expiryKeeper
+	pubExpKeeper *expiryKeeper
 
 	lock    *sync.Mutex
 	waitGrp *sync.WaitGroup
@@ -32,10 +38,12 @@ type PurgeMgr struct {
 }
 
 type workingset struct {
-	toPurge             expiryInfoMap
-	toClearFromSchedule []*expiryInfoKey
-	expiringBlk         uint64
-	err                 error
+	toPurge                expiryInfoMap
+	toClearFromSchedule    []*expiryInfoKey
+	pubToPurge             []*statedb.CompositeKey
+	pubToClearFromSchedule []*expiryInfoKey
+	expiringBlk            uint64
+	err                    error
 }
 
 type expiryInfoMap map[privacyenabledstate.HashedCompositeKey]*keyAndVersion
@@ -46,14 +54,23 @@ type keyAndVersion struct {
 	purgeKeyOnly    bool
 }
 
-// InstantiatePurgeMgr instantiates a PurgeMgr.
-func InstantiatePurgeMgr(ledgerid string, db *privacyenabledstate.DB, btlPolicy pvtdatapolicy.BTLPolicy, bookkeepingProvider *bookkeeping.Provider) (*PurgeMgr, error) {
+// InstantiatePurgeMgr instantiates a PurgeMgr. The 'pubStateBTL' maps a namespace to the block-to-live of
+// its public state keys, where a zero or a missing value means that the keys never expire
+func InstantiatePurgeMgr(
+	ledgerid string,
+	db *privacyenabledstate.DB,
+	btlPolicy pvtdatapolicy.BTLPolicy,
+	pubStateBTL map[string]uint64,
+	bookkeepingProvider *bookkeeping.Provider,
+) (*PurgeMgr, error) {
 	return &PurgeMgr{
-		btlPolicy: btlPolicy,
-		db:        db,
-		expKeeper: newExpiryKeeper(ledgerid, bookkeepingProvider),
-		lock:      &sync.Mutex{},
-		waitGrp:   &sync.WaitGroup{},
+		btlPolicy:    btlPolicy,
+		pubStateBTL:  pubStateBTL,
+		db:           db,
+		expKeeper:    newExpiryKeeper(ledgerid, bookkeepingProvider),
+		pubExpKeeper: newPubStateExpiryKeeper(ledgerid, bookkeepingProvider),
+		lock:         &sync.Mutex{},
+		waitGrp:      &sync.WaitGroup{},
 	}, nil
 }
 
@@ -142,21 +159,27 @@ func (p *PurgeMgr) addMissingPvtDataToWorkingSet(pvtKeys privacyenabledstate.Pvt
 	}
 }
 
-// UpdateExpiryInfo persists the expiry information for the private data and private data hashes
+// UpdateExpiryInfo persists the expiry information for the private data and private data hashes, and for the
+// public state keys of the namespaces that have a block-to-live configured
 // This function is expected to be invoked before the updates are applied to the statedb for the block
 // commit
 func (p *PurgeMgr) UpdateExpiryInfo(
+	pubUpdates *privacyenabledstate.PubUpdateBatch,
 	pvtUpdates *privacyenabledstate.PvtUpdateBatch,
 	hashedUpdates *privacyenabledstate.HashedUpdateBatch) error {
 	expiryInfoUpdates, err := buildExpirySchedule(p.btlPolicy, pvtUpdates, hashedUpdates)
 	if err != nil {
 		return err
 	}
-	return p.expKeeper.update(expiryInfoUpdates, nil)
+	if err := p.expKeeper.update(expiryInfoUpdates, nil); err != nil {
+		return err
+	}
+	return p.pubExpKeeper.update(buildPubStateExpirySchedule(p.pubStateBTL, pubUpdates), nil)
 }
 
-// AddExpiredEntriesToUpdateBatch add the expired pvtdata to the updateBatch of next block to be committed
+// AddExpiredEntriesToUpdateBatch add the expired pvtdata and public state keys to the updateBatch of next block to be committed
 func (p *PurgeMgr) AddExpiredEntriesToUpdateBatch(
+	pubUpdates *privacyenabledstate.PubUpdateBatch,
 	pvtUpdates *privacyenabledstate.PvtUpdateBatch,
 	hashedUpdates *privacyenabledstate.HashedUpdateBatch) error {
 	p.lock.Lock()
@@ -165,6 +188,15 @@ func (p *PurgeMgr) AddExpiredEntriesToUpdateBatch(
 		return p.workingset.err
 	}
 
+	// The expired public state keys that are updated in the current block are retained, as the update resets their expiry
+	for _, k := range p.workingset.pubToPurge {
+		if pubUpdates.Exists(k.Namespace, k.Key) {
+			continue
+		}
+		logger.Debugf("Adding the expired public state key [ns=%s, key=%s] to the delete list in the update batch", k.Namespace, k.Key)
+		pubUpdates.Delete(k.Namespace, k.Key, version.NewHeight(p.workingset.expiringBlk, math.MaxUint64))
+	}
+
 	// For each key selected for purging, check if the key is not getting updated in the current block,
 	// add its deletion in the update batches for pvt and hashed updates
 	for compositeHashedKey, keyAndVersion := range p.workingset.toPurge {
@@ -203,7 +235,10 @@ func (p *PurgeMgr) AddExpiredEntriesToUpdateBatch(
 // entries updates and block commit
 func (p *PurgeMgr) BlockCommitDone() error {
 	defer func() { p.workingset = nil }()
-	return p.expKeeper.update(nil, p.workingset.toClearFromSchedule)
+	if err := p.expKeeper.update(nil, p.workingset.toClearFromSchedule); err != nil {
+		return err
+	}
+	return p.pubExpKeeper.update(nil, p.workingset.pubToClearFromSchedule)
 }
 
 // prepareWorkingsetFor returns a working set for a given expiring block 'expiringAtBlk'.
@@ -211,6 +246,10 @@ func (p *PurgeMgr) BlockCommitDone() error {
 func (p *PurgeMgr) prepareWorkingsetFor(expiringAtBlk uint64) *workingset {
 	logger.Debugf("Preparing potential purge list working-set for expiringAtBlk [%d]", expiringAtBlk)
 	workingset := &workingset{expiringBlk: expiringAtBlk}
+	if err := p.addExpiringPubStateKeys(workingset); err != nil {
+		workingset.err = err
+		return workingset
+	}
 	// Retrieve the keys from expiryKeeper
 	expiryInfo, err := p.expKeeper.retrieve(expiringAtBlk)
 	if err != nil {
@@ -235,46 +274,151 @@ func (p *PurgeMgr) prepareWorkingsetFor(expiringAtBlk uint64) *workingset {
 	for purgeEntryK, purgeEntryV := range toPurge {
 		logger.Debugf("Evaluating for hashedKey [%s]", purgeEntryK)
 		expiryInfoKeysToClear = append(expiryInfoKeysToClear, &expiryInfoKey{committingBlk: purgeEntryV.committingBlock, expiryBlk: expiringAtBlk})
-		currentVersion, err := p.db.GetKeyHashVersion(purgeEntryK.Namespace, purgeEntryK.CollectionName, []byte(purgeEntryK.KeyHash))
+		expiring, err := p.stillExpiring(purgeEntryK, purgeEntryV)
 		if err != nil {
 			workingset.err = err
 			return workingset
 		}
+		if !expiring {
+			delete(toPurge, purgeEntryK)
+		}
+	}
+	// Final keys to purge from state
+	workingset.toPurge = toPurge
+	// Keys to clear from expiryKeeper
+	workingset.toClearFromSchedule = expiryInfoKeysToClear
+	return workingset
+}
 
-		if sameVersion(currentVersion, purgeEntryV.committingBlock) {
-			logger.Debugf(
-				"The version of the hashed key in the committed state and in the expiry entry is same " +
-					"hence, keeping the entry in the purge list")
+// addExpiringPubStateKeys adds to the working set the public state keys that expire with the commit of the block
+// 'workingset.expiringBlk'. A key that is written again, or deleted, in a later block than the one recorded in the
+// expiry entry is not purged. Also, a key is not purged if the block-to-live of its namespace is no longer configured
+func (p *PurgeMgr) addExpiringPubStateKeys(workingset *workingset) error {
+	expiryInfo, err := p.pubExpKeeper.retrieve(workingset.expiringBlk)
+	if err != nil {
+		return err
+	}
+	if len(expiryInfo) == 0 {
+		return nil
+	}
+
+	var candidates []*statedb.CompositeKey
+	var committingBlks []uint64
+	for _, expinfo := range expiryInfo {
+		workingset.pubToClearFromSchedule = append(workingset.pubToClearFromSchedule, expinfo.expiryInfoKey)
+		for ns, colls := range expinfo.pvtdataKeys.Map {
+			if p.pubStateBTL[ns] == 0 {
+				continue
+			}
+			for _, keysAndHashes := range colls.Map {
+				for _, keyAndHash := range keysAndHashes.List {
+					candidates = append(candidates, &statedb.CompositeKey{Namespace: ns, Key: keyAndHash.Key})
+					committingBlks = append(committingBlks, expinfo.expiryInfoKey.committingBlk)
+				}
+			}
+		}
+	}
+	if p.db.IsBulkOptimizable() {
+		if err := p.db.LoadCommittedVersionsOfPubAndHashedKeys(candidates, nil); err != nil {
+			return err
+		}
+	}
+
+	for i, k := range candidates {
+		currentVersion, err := p.db.GetVersion(k.Namespace, k.Key)
+		if err != nil {
+			return err
+		}
+		if !sameVersion(currentVersion, committingBlks[i]) {
+			logger.Debugf("The public state key [ns=%s, key=%s] is updated in a later block, hence not purging it", k.Namespace, k.Key)
 			continue
 		}
+		workingset.pubToPurge = append(workingset.pubToPurge, k)
+	}
+	logger.Debugf("Total [%d] public state keys to purge with the commit of block [%d]", len(workingset.pubToPurge), workingset.expiringBlk)
+	return nil
+}
+
+// stillExpiring returns whether the key hash and the key (if present, in the expiry entry) are yet to be purged
+// as per the expiry entry, i.e., are not updated in a later block than the one recorded in the expiry entry.
+// If only the key is yet to be purged, the 'purgeKeyOnly' is set in the expiry entry
+func (p *PurgeMgr) stillExpiring(purgeEntryK privacyenabledstate.HashedCompositeKey, purgeEntryV *keyAndVersion) (bool, error) {
+	currentVersion, err := p.db.GetKeyHashVersion(purgeEntryK.Namespace, purgeEntryK.CollectionName, []byte(purgeEntryK.KeyHash))
+	if err != nil {
+		return false, err
+	}
+
+	if sameVersion(currentVersion, purgeEntryV.committingBlock) {
+		logger.Debugf(
+			"The version of the hashed key in the committed state and in the expiry entry is same " +
+				"hence, keeping the entry in the purge list")
+		return true, nil
+	}
+
+	logger.Debugf("The version of the hashed key in the committed state and in the expiry entry is different")
+	if purgeEntryV.key != "" {
+		logger.Debugf("The expiry entry also contains the raw key along with the key hash")
+		committedPvtVerVal, err := p.db.GetPrivateData(purgeEntryK.Namespace, purgeEntryK.CollectionName, purgeEntryV.key)
+		if err != nil {
+			return false, err
+		}
 
-		logger.Debugf("The version of the hashed key in the committed state and in the expiry entry is different")
-		if purgeEntryV.key != "" {
-			logger.Debugf("The expiry entry also contains the raw key along with the key hash")
-			committedPvtVerVal, err := p.db.GetPrivateData(purgeEntryK.Namespace, purgeEntryK.CollectionName, purgeEntryV.key)
+		if sameVersionFromVal(committedPvtVerVal, purgeEntryV.committingBlock) {
+			logger.Debugf(
+				"The version of the pvt key in the committed state and in the expiry entry is same" +
+					"Including only key in the purge list and not the hashed key")
+			purgeEntryV.purgeKeyOnly = true
+			return true, nil
+		}
+	}
+
+	// If we reached here, the keyhash and private key (if present, in the expiry entry) have been updated in a later block, therefore remove from current purge list
+	logger.Debugf("Removing from purge list - the key hash and key (if present, in the expiry entry)")
+	return false, nil
+}
+
+// ScheduledPurges returns the private data keys and key hashes that are scheduled to be purged with the commit of
+// the blocks in the range [startBlk, endBlk], ordered by the expiring block, committing block, namespace, collection,
+// and key hash. The keys and key hashes that are updated in a later block than the one that set the expiry are excluded
+func (p *PurgeMgr) ScheduledPurges(startBlk, endBlk uint64) ([]*ledger.ScheduledPvtdataPurge, error) {
+	listExpinfo, err := p.expKeeper.retrieveRange(startBlk, endBlk)
+	if err != nil {
+		return nil, err
+	}
+
+	var scheduledPurges []*ledger.ScheduledPvtdataPurge
+	for _, expinfo := range listExpinfo {
+		var purges []*ledger.ScheduledPvtdataPurge
+		for purgeEntryK, purgeEntryV := range transformToExpiryInfoMap([]*expiryInfo{expinfo}) {
+			expiring, err := p.stillExpiring(purgeEntryK, purgeEntryV)
 			if err != nil {
-				workingset.err = err
-				return workingset
+				return nil, err
 			}
-
-			if sameVersionFromVal(committedPvtVerVal, purgeEntryV.committingBlock) {
-				logger.Debugf(
-					"The version of the pvt key in the committed state and in the expiry entry is same" +
-						"Including only key in the purge list and not the hashed key")
-				purgeEntryV.purgeKeyOnly = true
+			if !expiring {
 				continue
 			}
+			purges = append(purges, &ledger.ScheduledPvtdataPurge{
+				ExpiringBlock:   expinfo.expiryInfoKey.expiryBlk,
+				CommittingBlock: expinfo.expiryInfoKey.committingBlk,
+				Namespace:       purgeEntryK.Namespace,
+				Collection:      purgeEntryK.CollectionName,
+				KeyHash:         []byte(purgeEntryK.KeyHash),
+				Key:             purgeEntryV.key,
+				PurgesKeyOnly:   purgeEntryV.purgeKeyOnly,
+			})
 		}
-
-		// If we reached here, the keyhash and private key (if present, in the expiry entry) have been updated in a later block, therefore remove from current purge list
-		logger.Debugf("Removing from purge list - the key hash and key (if present, in the expiry entry)")
-		delete(toPurge, purgeEntryK)
+		sort.Slice(purges, func(i, j int) bool {
+			if purges[i].Namespace != purges[j].Namespace {
+				return purges[i].Namespace < purges[j].Namespace
+			}
+			if purges[i].Collection != purges[j].Collection {
+				return purges[i].Collection < purges[j].Collection
+			}
+			return bytes.Compare(purges[i].KeyHash, purges[j].KeyHash) < 0
+		})
+		scheduledPurges = append(scheduledPurges, purges...)
 	}
-	// Final keys to purge from state
-	workingset.toPurge = toPurge
-	// Keys to clear from expiryKeeper
-	workingset.toClearFromSchedule = expiryInfoKeysToClear
-	return workingset
+	return scheduledPurges, nil
 }
 
 func (p *PurgeMgr) preloadCommittedVersionsInCache(expInfoMap expiryInfoMap) error {
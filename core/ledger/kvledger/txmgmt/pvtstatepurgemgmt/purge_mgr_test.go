@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
@@ -84,6 +85,23 @@ func testPurgeMgr(t *testing.T, dbEnv privacyenabledstate.TestEnv) {
 	testHelper.checkPvtdataExists("ns2", "coll3", "pvtkey3", []byte("pvtvalue3-1"))
 	testHelper.checkPvtdataDoesNotExist("ns1", "coll4", "pvtkey4")
 
+	// the entry of pvtkey2 scheduled with block 1 is excluded as the key is updated in block 2
+	scheduledPurges, err := testHelper.purgeMgr.ScheduledPurges(3, 4)
+	require.NoError(t, err)
+	require.Equal(t,
+		[]*ledger.ScheduledPvtdataPurge{
+			{
+				ExpiringBlock:   3,
+				CommittingBlock: 1,
+				Namespace:       "ns1",
+				Collection:      "coll1",
+				KeyHash:         util.ComputeStringHash("pvtkey1"),
+				Key:             "pvtkey1",
+			},
+		},
+		scheduledPurges,
+	)
+
 	noPvtdataUpdates := privacyenabledstate.NewUpdateBatch()
 	testHelper.commitUpdatesForTesting(3, noPvtdataUpdates)
 	testHelper.checkPvtdataDoesNotExist("ns1", "coll1", "pvtkey1")
@@ -280,10 +298,65 @@ func TestOnlyHashDeleteBeforeExpiryBlock(t *testing.T) {
 	testHelper.checkPvtdataDoesNotExist("ns", "coll", "pvtkey")
 }
 
+func TestPurgeMgrForPubState(t *testing.T) {
+	for _, dbEnv := range testEnvs {
+		t.Run(dbEnv.GetName(), func(t *testing.T) { testPurgeMgrForPubState(t, dbEnv) })
+	}
+}
+
+func testPurgeMgrForPubState(t *testing.T, dbEnv privacyenabledstate.TestEnv) {
+	ledgerid := "testledger-purge-mgr-pub-state"
+	testHelper := &testHelper{pubStateBTL: map[string]uint64{"ns1": 1}}
+	testHelper.init(t, ledgerid, btltestutil.SampleBTLPolicy(nil), dbEnv)
+	defer testHelper.cleanup()
+
+	block1Updates := privacyenabledstate.NewUpdateBatch()
+	block1Updates.PubUpdates.Put("ns1", "key1", []byte("value1-1"), version.NewHeight(1, 1))
+	block1Updates.PubUpdates.Put("ns1", "key2", []byte("value2-1"), version.NewHeight(1, 1))
+	block1Updates.PubUpdates.Put("ns1", "key3", []byte("value3-1"), version.NewHeight(1, 2))
+	block1Updates.PubUpdates.Put("ns2", "key1", []byte("value1-1"), version.NewHeight(1, 2))
+	testHelper.commitUpdatesForTesting(1, block1Updates)
+	testHelper.checkPubStateExpiryEntryExistsForBlockNum(3, 1)
+
+	block2Updates := privacyenabledstate.NewUpdateBatch()
+	block2Updates.PubUpdates.Put("ns1", "key2", []byte("value2-2"), version.NewHeight(2, 1))
+	block2Updates.PubUpdates.Delete("ns1", "key3", version.NewHeight(2, 1))
+	testHelper.commitUpdatesForTesting(2, block2Updates)
+	testHelper.checkPubStateExpiryEntryExistsForBlockNum(4, 1)
+
+	// block-3 purges the keys written by block-1 and not updated later
+	testHelper.commitUpdatesForTesting(3, privacyenabledstate.NewUpdateBatch())
+	testHelper.checkPubStateDoesNotExist("ns1", "key1")
+	testHelper.checkPubStateExists("ns1", "key2", []byte("value2-2"))
+	testHelper.checkPubStateDoesNotExist("ns1", "key3")
+	testHelper.checkPubStateExists("ns2", "key1", []byte("value1-1"))
+	testHelper.checkPubStateExpiryEntryExistsForBlockNum(3, 0)
+
+	// block-4 updates the expiring key and hence, the key is retained
+	block4Updates := privacyenabledstate.NewUpdateBatch()
+	block4Updates.PubUpdates.Put("ns1", "key2", []byte("value2-4"), version.NewHeight(4, 1))
+	testHelper.commitUpdatesForTesting(4, block4Updates)
+	testHelper.checkPubStateExists("ns1", "key2", []byte("value2-4"))
+	testHelper.checkPubStateExpiryEntryExistsForBlockNum(4, 0)
+	testHelper.checkPubStateExpiryEntryExistsForBlockNum(6, 1)
+
+	testHelper.commitUpdatesForTesting(5, privacyenabledstate.NewUpdateBatch())
+	testHelper.checkPubStateExists("ns1", "key2", []byte("value2-4"))
+
+	// the keys are not purged once the block-to-live is no longer configured for the namespace
+	var err error
+	testHelper.purgeMgr, err = InstantiatePurgeMgr(ledgerid, testHelper.db, btltestutil.SampleBTLPolicy(nil), nil, testHelper.bookkeepingEnv.TestProvider)
+	require.NoError(t, err)
+	testHelper.commitUpdatesForTesting(6, privacyenabledstate.NewUpdateBatch())
+	testHelper.checkPubStateExists("ns1", "key2", []byte("value2-4"))
+	testHelper.checkPubStateExpiryEntryExistsForBlockNum(6, 0)
+}
+
 type testHelper struct {
 	t              *testing.T
 	bookkeepingEnv *bookkeeping.TestEnv
 	dbEnv          privacyenabledstate.TestEnv
+	pubStateBTL    map[string]uint64
 
 	db       *privacyenabledstate.DB
 	purgeMgr *PurgeMgr
@@ -296,7 +369,7 @@ func (h *testHelper) init(t *testing.T, ledgerid string, btlPolicy pvtdatapolicy
 	h.dbEnv = dbEnv
 	h.db = h.dbEnv.GetDBHandle(ledgerid)
 	var err error
-	if h.purgeMgr, err = InstantiatePurgeMgr(ledgerid, h.db, btlPolicy, h.bookkeepingEnv.TestProvider); err != nil {
+	if h.purgeMgr, err = InstantiatePurgeMgr(ledgerid, h.db, btlPolicy, h.pubStateBTL, h.bookkeepingEnv.TestProvider); err != nil {
 		t.Fatalf("err:%s", err)
 	}
 }
@@ -308,8 +381,8 @@ func (h *testHelper) cleanup() {
 
 func (h *testHelper) commitUpdatesForTesting(blkNum uint64, updates *privacyenabledstate.UpdateBatch) {
 	h.purgeMgr.PrepareForExpiringKeys(blkNum)
-	require.NoError(h.t, h.purgeMgr.UpdateExpiryInfo(updates.PvtUpdates, updates.HashUpdates))
-	require.NoError(h.t, h.purgeMgr.AddExpiredEntriesToUpdateBatch(updates.PvtUpdates, updates.HashUpdates))
+	require.NoError(h.t, h.purgeMgr.UpdateExpiryInfo(updates.PubUpdates, updates.PvtUpdates, updates.HashUpdates))
+	require.NoError(h.t, h.purgeMgr.AddExpiredEntriesToUpdateBatch(updates.PubUpdates, updates.PvtUpdates, updates.HashUpdates))
 	require.NoError(h.t, h.db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(blkNum, 1)))
 	h.db.ClearCachedVersions()
 	require.NoError(h.t, h.purgeMgr.BlockCommitDone())
@@ -352,6 +425,19 @@ func (h *testHelper) checkOnlyKeyHashExists(ns, coll, key string) {
 	require.NotNil(h.t, hashVersion)
 }
 
+func (h *testHelper) checkPubStateExists(ns, key string, value []byte) {
+	vv, err := h.db.GetState(ns, key)
+	require.NoError(h.t, err)
+	require.NotNil(h.t, vv)
+	require.Equal(h.t, value, vv.Value)
+}
+
+func (h *testHelper) checkPubStateDoesNotExist(ns, key string) {
+	vv, err := h.db.GetState(ns, key)
+	require.NoError(h.t, err)
+	require.Nil(h.t, vv)
+}
+
 func (h *testHelper) fetchPvtdataFronDB(ns, coll, key string) (kv *statedb.VersionedValue, hashVersion *version.Height) {
 	var err error
 	kv, err = h.db.GetPrivateData(ns, coll, key)
@@ -372,3 +458,9 @@ func (h *testHelper) checkNoExpiryEntryExistsForBlockNum(expiringBlk uint64) {
 	require.NoError(h.t, err)
 	require.Len(h.t, expInfo, 0)
 }
+
+func (h *testHelper) checkPubStateExpiryEntryExistsForBlockNum(expiringBlk uint64, expectedNumEntries int) {
+	expInfo, err := h.purgeMgr.pubExpKeeper.retrieve(expiringBlk)
+	require.NoError(h.t, err)
+	require.Len(h.t, expInfo, expectedNumEntries)
+}
@@ -14,19 +14,24 @@ import (
 )
 
 type PurgeMgrBuilder struct {
-	btlPolicy pvtdatapolicy.BTLPolicy
-	expKeeper *expiryKeeper
+	btlPolicy    pvtdatapolicy.BTLPolicy
+	pubStateBTL  map[string]uint64
+	expKeeper    *expiryKeeper
+	pubExpKeeper *expiryKeeper
 }
 
 // NewPurgeMgrBuilder returns PurgeMgrBuilder that builds the entries for the purgr manager for a given ledger
 func NewPurgeMgrBuilder(
 	ledgerID string,
 	btlPolicy pvtdatapolicy.BTLPolicy,
+	pubStateBTL map[string]uint64,
 	bookkeepingProvider *bookkeeping.Provider,
 ) *PurgeMgrBuilder {
 	return &PurgeMgrBuilder{
-		btlPolicy: btlPolicy,
-		expKeeper: newExpiryKeeper(ledgerID, bookkeepingProvider),
+		btlPolicy:    btlPolicy,
+		pubStateBTL:  pubStateBTL,
+		expKeeper:    newExpiryKeeper(ledgerID, bookkeepingProvider),
+		pubExpKeeper: newPubStateExpiryKeeper(ledgerID, bookkeepingProvider),
 	}
 }
 
@@ -66,6 +71,37 @@ func (b *PurgeMgrBuilder) ConsumeSnapshotData(
 	return nil
 }
 
+// ConsumeSnapshotPubStateData implements the function in the interface privacyenabledstate.SnapshotPubStateConsumer.
+// This is intended to be invoked for populating the expiry data in the purge manager for the public state keys of
+// the namespaces that have a block-to-live configured
+func (b *PurgeMgrBuilder) ConsumeSnapshotPubStateData(namespace, key string, version *version.Height) error {
+	btl := b.pubStateBTL[namespace]
+	if btl == 0 {
+		return nil
+	}
+	committingBlk := version.BlockNum
+	expiringBlock := pvtdatapolicy.ComputeExpiringBlock(namespace, "", committingBlk, btl)
+	if neverExpires(expiringBlock) {
+		return nil
+	}
+
+	expInfo, err := b.pubExpKeeper.retrieveByExpiryKey(
+		&expiryInfoKey{
+			committingBlk: committingBlk,
+			expiryBlk:     expiringBlock,
+		},
+	)
+	if err != nil {
+		return errors.WithMessage(err, "error from bookkeeper")
+	}
+	if expInfo.pvtdataKeys.Map == nil {
+		expInfo.pvtdataKeys.Map = make(map[string]*Collections)
+	}
+
+	expInfo.pvtdataKeys.add(namespace, "", key, nil)
+	return b.pubExpKeeper.update([]*expiryInfo{expInfo}, nil)
+}
+
 func (b *PurgeMgrBuilder) Done() error {
 	return nil
 }
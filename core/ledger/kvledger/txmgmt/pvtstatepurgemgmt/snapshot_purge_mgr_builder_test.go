@@ -11,6 +11,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
@@ -31,7 +32,7 @@ func TestPurgeMgrBuilder(t *testing.T) {
 		},
 	)
 
-	purgeMgrBuilder := NewPurgeMgrBuilder(ledgerID, btlPolicy, bookkeepingProvider)
+	purgeMgrBuilder := NewPurgeMgrBuilder(ledgerID, btlPolicy, nil, bookkeepingProvider)
 
 	require.NoError(t,
 		purgeMgrBuilder.ConsumeSnapshotData(
@@ -79,7 +80,7 @@ func TestPurgeMgrBuilder(t *testing.T) {
 		),
 	)
 
-	purgeMgr, err := InstantiatePurgeMgr(ledgerID, nil, btlPolicy, bookkeepingProvider)
+	purgeMgr, err := InstantiatePurgeMgr(ledgerID, nil, btlPolicy, nil, bookkeepingProvider)
 	require.NoError(t, err)
 
 	testcases := []struct {
@@ -189,7 +190,7 @@ func TestPurgeMgrBuilderErrorsPropagation(t *testing.T) {
 		btlPolicy = &btltestutil.ErrorCausingBTLPolicy{
 			Err: errors.New("btl-error"),
 		}
-		purgeMgrBuilder := NewPurgeMgrBuilder("test-ledger", btlPolicy, bookkeepingProvider)
+		purgeMgrBuilder := NewPurgeMgrBuilder("test-ledger", btlPolicy, nil, bookkeepingProvider)
 		err := purgeMgrBuilder.ConsumeSnapshotData(
 			"ns",
 			"coll",
@@ -203,7 +204,7 @@ func TestPurgeMgrBuilderErrorsPropagation(t *testing.T) {
 	t.Run("bookkeeper-returns-error", func(t *testing.T) {
 		init()
 		bookkeepingProvider.Close()
-		purgeMgrBuilder := NewPurgeMgrBuilder("test-ledger", btlPolicy, bookkeepingProvider)
+		purgeMgrBuilder := NewPurgeMgrBuilder("test-ledger", btlPolicy, nil, bookkeepingProvider)
 		err := purgeMgrBuilder.ConsumeSnapshotData(
 			"ns",
 			"coll",
@@ -214,3 +215,34 @@ func TestPurgeMgrBuilderErrorsPropagation(t *testing.T) {
 		require.Contains(t, err.Error(), "error from bookkeeper")
 	})
 }
+
+func TestPurgeMgrBuilderForPubState(t *testing.T) {
+	bookkeepingEnv := bookkeeping.NewTestEnv(t)
+	defer bookkeepingEnv.Cleanup()
+
+	purgeMgrBuilder := NewPurgeMgrBuilder(
+		"test-ledger",
+		btltestutil.SampleBTLPolicy(nil),
+		map[string]uint64{"ns1": 2},
+		bookkeepingEnv.TestProvider,
+	)
+	require.NoError(t, purgeMgrBuilder.ConsumeSnapshotPubStateData("ns1", "key1", version.NewHeight(5, 1)))
+	require.NoError(t, purgeMgrBuilder.ConsumeSnapshotPubStateData("ns1", "key2", version.NewHeight(5, 2)))
+	require.NoError(t, purgeMgrBuilder.ConsumeSnapshotPubStateData("ns2", "key1", version.NewHeight(5, 1)))
+	require.NoError(t, purgeMgrBuilder.Done())
+
+	expectedPubStateKeys := newPvtdataKeys()
+	expectedPubStateKeys.add("ns1", "", "key1", nil)
+	expectedPubStateKeys.add("ns1", "", "key2", nil)
+
+	expInfo, err := purgeMgrBuilder.pubExpKeeper.retrieve(8)
+	require.NoError(t, err)
+	require.Len(t, expInfo, 1)
+	require.Equal(t, &expiryInfoKey{committingBlk: 5, expiryBlk: 8}, expInfo[0].expiryInfoKey)
+	require.True(t, proto.Equal(expectedPubStateKeys, expInfo[0].pvtdataKeys))
+
+	// the public state keys are not mixed with the private data keys
+	expInfo, err = purgeMgrBuilder.expKeeper.retrieve(8)
+	require.NoError(t, err)
+	require.Nil(t, expInfo)
+}
@@ -915,6 +915,49 @@ func TestPaginatedRangeQuery(t *testing.T, dbProvider statedb.VersionedDBProvide
 	require.NoError(t, err)
 }
 
+// TestPaginatedRangeQueryBookmark tests that the bookmark of a paginated range query is the key at which the next
+// page starts, so that the paging logic of a chaincode behaves the same on all the state databases
+func TestPaginatedRangeQueryBookmark(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db, err := dbProvider.GetDBHandle("testpaginatedrangequerybookmark", nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Open())
+	defer db.Close()
+	batch := statedb.NewUpdateBatch()
+	for i := 1; i <= 5; i++ {
+		batch.Put("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)), version.NewHeight(1, uint64(i)))
+	}
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 5)))
+
+	// the bookmark is the next key in the range
+	bookmark, err := executeRangeQuery(t, db, "ns1", "key1", "key5", int32(2), []string{"key1", "key2"})
+	require.NoError(t, err)
+	require.Equal(t, "key3", bookmark)
+
+	// the keys added before and deleted at the bookmark do not affect the next page
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns1", "key25", []byte("value25"), version.NewHeight(2, 1))
+	batch.Delete("ns1", "key3", version.NewHeight(2, 2))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 2)))
+
+	// the bookmark of an exhausted range is the end key, which retrieves an empty page when passed back
+	bookmark, err = executeRangeQuery(t, db, "ns1", bookmark, "key5", int32(2), []string{"key4"})
+	require.NoError(t, err)
+	require.Equal(t, "key5", bookmark)
+	bookmark, err = executeRangeQuery(t, db, "ns1", bookmark, "key5", int32(2), []string{})
+	require.NoError(t, err)
+	require.Equal(t, "key5", bookmark)
+
+	// the bookmark is the end key even if the last page is full
+	bookmark, err = executeRangeQuery(t, db, "ns1", "key4", "key6", int32(2), []string{"key4", "key5"})
+	require.NoError(t, err)
+	require.Equal(t, "key6", bookmark)
+
+	// the bookmark of an exhausted range without an end key is empty
+	bookmark, err = executeRangeQuery(t, db, "ns1", "key4", "", int32(2), []string{"key4", "key5"})
+	require.NoError(t, err)
+	require.Equal(t, "", bookmark)
+}
+
 // TestRangeQuerySpecialCharacters tests range queries for keys with special characters and/or non-English characters
 func TestRangeQuerySpecialCharacters(t *testing.T, dbProvider statedb.VersionedDBProvider) {
 	db, err := dbProvider.GetDBHandle("testrangequeryspecialcharacters", nil)
@@ -0,0 +1,303 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonquery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/pkg/errors"
+)
+
+// RangeScanFunc returns an iterator over the key-values of a namespace, in the order of the keys, starting at
+// the given key (inclusive). An empty start key denotes the first key of the namespace
+type RangeScanFunc func(startKey string) (statedb.ResultsIterator, error)
+
+// Execute evaluates the query over the key-values returned by the `rangeScan`, for the state databases that
+// cannot evaluate the query natively. The limit and the skip in the query, if any, are honored. No index is
+// used, so the query scans all the key-values of the namespace. The matching results of a sorted query are
+// collected and sorted in memory and, if `maxSortedResults` is greater than zero, a sorted query that matches
+// more than `maxSortedResults` results fails
+func Execute(q *Query, rangeScan RangeScanFunc, maxSortedResults int) (statedb.QueryResultsIterator, error) {
+	return execute(q, rangeScan, "", q.Skip(), int32(q.Limit()), maxSortedResults)
+}
+
+// ExecuteWithPagination is similar to the function Execute, except that the `pageSize` takes precedence over
+// the limit in the query, if any, and the skip in the query, if any, applies only to the first page. For an
+// unsorted query, the bookmark is the key of the first result of the next page. For a sorted query, the bookmark
+// is an opaque encoding of the values of the sort fields and the key of the first result of the next page, where
+// the results with equal values of the sort fields are ordered by their keys. In either case, the next page starts
+// at the position of the bookmark in the results, so that a commit in between the pages does not cause the results
+// that remain unchanged to be skipped or repeated
+func ExecuteWithPagination(q *Query, rangeScan RangeScanFunc, bookmark string, pageSize int32, maxSortedResults int) (statedb.QueryResultsIterator, error) {
+	skip := q.Skip()
+	if bookmark != "" {
+		skip = 0
+	}
+	return execute(q, rangeScan, bookmark, skip, pageSize, maxSortedResults)
+}
+
+func execute(q *Query, rangeScan RangeScanFunc, bookmark string, skip int, limit int32, maxSortedResults int) (statedb.QueryResultsIterator, error) {
+	if !q.Sorted() {
+		dbItr, err := rangeScan(bookmark)
+		if err != nil {
+			return nil, err
+		}
+		return &queryScanner{query: q, dbItr: dbItr, skip: skip, limit: limit}, nil
+	}
+
+	var start *sortedResult
+	if bookmark != "" {
+		var err error
+		if start, err = decodeSortedBookmark(q, bookmark); err != nil {
+			return nil, errors.WithMessagef(err, "invalid bookmark [%s] for a sorted query", bookmark)
+		}
+	}
+	results, err := sortedResults(q, rangeScan, maxSortedResults)
+	if err != nil {
+		return nil, err
+	}
+	next := 0
+	if start != nil {
+		next = sort.Search(len(results), func(i int) bool {
+			return !results[i].less(q, start)
+		})
+	}
+	return &sortedQueryScanner{query: q, results: results, next: next + skip, limit: limit}, nil
+}
+
+// sortedResult is a result of a sorted query along with the values of the sort fields of the matching document
+type sortedResult struct {
+	kv         *statedb.VersionedKV
+	sortValues []sortValue
+}
+
+// less returns true if the result sorts before the other result. The results with equal values of the sort fields
+// are ordered by their keys
+func (r *sortedResult) less(q *Query, other *sortedResult) bool {
+	if c := q.compareSortValues(r.sortValues, other.sortValues); c != 0 {
+		return c < 0
+	}
+	return r.kv.Key < other.kv.Key
+}
+
+// sortedBookmark is the JSON encoding of a bookmark of a sorted query. Each element of the Values holds the value
+// of a sort field in a single element array, or is an empty array if the document does not contain the field
+type sortedBookmark struct {
+	Key    string          `json:"key"`
+	Values [][]interface{} `json:"values"`
+}
+
+func encodeSortedBookmark(r *sortedResult) string {
+	b := &sortedBookmark{Key: r.kv.Key, Values: make([][]interface{}, len(r.sortValues))}
+	for i, v := range r.sortValues {
+		b.Values[i] = []interface{}{}
+		if v.present {
+			b.Values[i] = []interface{}{v.value}
+		}
+	}
+	// the values are decoded from JSON and hence are marshalled without an error
+	bookmarkJSON, _ := json.Marshal(b)
+	return base64.RawURLEncoding.EncodeToString(bookmarkJSON)
+}
+
+func decodeSortedBookmark(q *Query, bookmark string) (*sortedResult, error) {
+	bookmarkJSON, err := base64.RawURLEncoding.DecodeString(bookmark)
+	if err != nil {
+		return nil, errors.Wrap(err, "error decoding bookmark")
+	}
+	v, err := decode(bookmarkJSON)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the bookmark is not a JSON object")
+	}
+	key, ok := b["key"].(string)
+	if !ok {
+		return nil, errors.New("the bookmark does not contain the key")
+	}
+	values, ok := b["values"].([]interface{})
+	if !ok || len(values) != len(q.sort) {
+		return nil, errors.New("the bookmark does not match the sort fields of the query")
+	}
+	r := &sortedResult{
+		kv:         &statedb.VersionedKV{CompositeKey: &statedb.CompositeKey{Key: key}},
+		sortValues: make([]sortValue, len(values)),
+	}
+	for i, value := range values {
+		switch a, _ := value.([]interface{}); {
+		case a == nil || len(a) > 1:
+			return nil, errors.New("the bookmark does not match the sort fields of the query")
+		case len(a) == 1:
+			r.sortValues[i] = sortValue{value: a[0], present: true}
+		}
+	}
+	return r, nil
+}
+
+// sortedResults evaluates a sorted query by loading all the matching results in memory. An error is returned if
+// `maxResults` is greater than zero and the query matches more results
+func sortedResults(q *Query, rangeScan RangeScanFunc, maxResults int) ([]*sortedResult, error) {
+	dbItr, err := rangeScan("")
+	if err != nil {
+		return nil, err
+	}
+	scanner := &queryScanner{query: q, dbItr: dbItr}
+	defer scanner.Close()
+
+	s := &sortableResults{query: q}
+	for {
+		kv, doc, err := scanner.nextMatch()
+		if err != nil {
+			return nil, err
+		}
+		if kv == nil {
+			break
+		}
+		if maxResults > 0 && len(s.results) == maxResults {
+			return nil, errors.Errorf("the sorted query matches more than %d results, which is the limit on the results sorted in memory", maxResults)
+		}
+		s.results = append(s.results, &sortedResult{kv: kv, sortValues: q.sortValues(doc)})
+		s.docs = append(s.docs, doc)
+	}
+	// the scan returns the results in the order of the keys and hence the stable sort orders the results with
+	// equal values of the sort fields by their keys
+	sort.Stable(s)
+
+	for i, r := range s.results {
+		if r.kv, err = project(q, r.kv, s.docs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return s.results, nil
+}
+
+type sortableResults struct {
+	query   *Query
+	results []*sortedResult
+	docs    []map[string]interface{}
+}
+
+func (s *sortableResults) Len() int {
+	return len(s.results)
+}
+
+func (s *sortableResults) Less(i, j int) bool {
+	return s.query.compareSortValues(s.results[i].sortValues, s.results[j].sortValues) < 0
+}
+
+func (s *sortableResults) Swap(i, j int) {
+	s.results[i], s.results[j] = s.results[j], s.results[i]
+	s.docs[i], s.docs[j] = s.docs[j], s.docs[i]
+}
+
+func project(q *Query, kv *statedb.VersionedKV, doc map[string]interface{}) (*statedb.VersionedKV, error) {
+	value, err := q.Project(kv.Value, doc)
+	if err != nil {
+		return nil, err
+	}
+	return &statedb.VersionedKV{
+		CompositeKey: kv.CompositeKey,
+		VersionedValue: &statedb.VersionedValue{
+			Value:    value,
+			Metadata: kv.Metadata,
+			Version:  kv.Version,
+		},
+	}, nil
+}
+
+// queryScanner returns the results of an unsorted query while scanning the keys of the namespace in order
+type queryScanner struct {
+	query                *Query
+	dbItr                statedb.ResultsIterator
+	skip                 int
+	limit                int32
+	totalRecordsReturned int32
+}
+
+func (scanner *queryScanner) Next() (*statedb.VersionedKV, error) {
+	if scanner.limit > 0 && scanner.totalRecordsReturned >= scanner.limit {
+		return nil, nil
+	}
+	for {
+		kv, doc, err := scanner.nextMatch()
+		if err != nil || kv == nil {
+			return nil, err
+		}
+		if scanner.skip > 0 {
+			scanner.skip--
+			continue
+		}
+		scanner.totalRecordsReturned++
+		return project(scanner.query, kv, doc)
+	}
+}
+
+func (scanner *queryScanner) nextMatch() (*statedb.VersionedKV, map[string]interface{}, error) {
+	for {
+		kv, err := scanner.dbItr.Next()
+		if err != nil || kv == nil {
+			return nil, nil, err
+		}
+		if doc, ok := scanner.query.Match(kv.Value); ok {
+			return kv, doc, nil
+		}
+	}
+}
+
+func (scanner *queryScanner) Close() {
+	scanner.dbItr.Close()
+}
+
+// GetBookmarkAndClose returns the key of the next matching result, if any
+func (scanner *queryScanner) GetBookmarkAndClose() string {
+	defer scanner.Close()
+	kv, _, err := scanner.nextMatch()
+	if err != nil || kv == nil {
+		return ""
+	}
+	return kv.Key
+}
+
+// sortedQueryScanner returns the results of a sorted query that are loaded in memory
+type sortedQueryScanner struct {
+	query                *Query
+	results              []*sortedResult
+	next                 int
+	limit                int32
+	totalRecordsReturned int32
+}
+
+func (scanner *sortedQueryScanner) Next() (*statedb.VersionedKV, error) {
+	if scanner.limit > 0 && scanner.totalRecordsReturned >= scanner.limit {
+		return nil, nil
+	}
+	if scanner.next >= len(scanner.results) {
+		return nil, nil
+	}
+	r := scanner.results[scanner.next]
+	scanner.next++
+	scanner.totalRecordsReturned++
+	return r.kv, nil
+}
+
+func (scanner *sortedQueryScanner) Close() {
+	scanner.results = nil
+}
+
+// GetBookmarkAndClose returns the bookmark that encodes the position of the next result, if any
+func (scanner *sortedQueryScanner) GetBookmarkAndClose() string {
+	defer scanner.Close()
+	if scanner.next >= len(scanner.results) {
+		return ""
+	}
+	return encodeSortedBookmark(scanner.results[scanner.next])
+}
@@ -0,0 +1,769 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonquery
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Query is a parsed rich query in the syntax of the CouchDB Mango queries, restricted to the following subset,
+// so that a query written for CouchDB gives the same results when evaluated by this package
+//
+// The query parameters "selector" (required), "fields", "sort", "limit", and "skip" are supported. The parameter
+// "use_index" is accepted and ignored so that the queries meant for CouchDB need not be altered.
+//
+// In the selector, a field name may refer to a nested field via the dot notation (e.g., "owner.name") and the
+// following operators are supported
+//   - combination operators: $and, $or, $nor, and $not
+//   - condition operators: $eq, $ne, $gt, $gte, $lt, $lte, $exists, $type, $in, $nin, $size, $all, $elemMatch,
+//     $regex, and $not
+//
+// The values are compared in the CouchDB collation order (null < false < true < numbers < strings < arrays <
+// objects), except that the strings are compared bytewise rather than per the Unicode collation. A condition
+// on a field that is not present in a document does not match, with the exception of {"$exists": false}.
+// Only the JSON objects are treated as documents, the values that are not JSON objects never match a query.
+//
+// No secondary index is maintained on the values, so the query is evaluated by a full scan of the namespace, and
+// the results of a sorted query are held in memory up to a limit (see the function Execute).
+type Query struct {
+	selector condition
+	fields   [][]string
+	sort     []*sortField
+	limit    int
+	skip     int
+}
+
+type sortField struct {
+	path []string
+	desc bool
+}
+
+// Parse parses the given rich query
+func Parse(query string) (*Query, error) {
+	v, err := decode([]byte(query))
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid query")
+	}
+	params, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid query: the query must be a JSON object")
+	}
+	q := &Query{}
+	for _, name := range sortedKeys(params) {
+		param := params[name]
+		switch name {
+		case "selector":
+			s, ok := param.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("invalid query: the selector must be a JSON object")
+			}
+			if q.selector, err = parseSelector(s); err != nil {
+				return nil, errors.WithMessage(err, "invalid query")
+			}
+		case "fields":
+			if q.fields, err = parseFields(param); err != nil {
+				return nil, errors.WithMessage(err, "invalid query")
+			}
+		case "sort":
+			if q.sort, err = parseSort(param); err != nil {
+				return nil, errors.WithMessage(err, "invalid query")
+			}
+		case "limit":
+			if q.limit, err = parseNonNegativeInt(param); err != nil {
+				return nil, errors.WithMessage(err, "invalid query: invalid limit")
+			}
+		case "skip":
+			if q.skip, err = parseNonNegativeInt(param); err != nil {
+				return nil, errors.WithMessage(err, "invalid query: invalid skip")
+			}
+		case "use_index":
+			// the indexes are specific to CouchDB
+		default:
+			return nil, errors.Errorf("invalid query: unsupported query parameter [%s]", name)
+		}
+	}
+	if q.selector == nil {
+		return nil, errors.New("invalid query: the selector is missing")
+	}
+	return q, nil
+}
+
+// Match decodes the given value and returns the decoded document if it satisfies the selector of the query
+func (q *Query) Match(value []byte) (map[string]interface{}, bool) {
+	v, err := decode(value)
+	if err != nil {
+		return nil, false
+	}
+	doc, ok := v.(map[string]interface{})
+	if !ok || !q.selector.match(doc) {
+		return nil, false
+	}
+	return doc, true
+}
+
+// Sorted returns true if the query specifies a sort order for the results
+func (q *Query) Sorted() bool {
+	return len(q.sort) > 0
+}
+
+// Less returns true if the document `doc1` sorts before the document `doc2` as per the sort order of the query.
+// A document that does not contain a field sorts before the documents that do
+func (q *Query) Less(doc1, doc2 map[string]interface{}) bool {
+	return q.compareSortValues(q.sortValues(doc1), q.sortValues(doc2)) < 0
+}
+
+// sortValue is the value of a sort field in a document, with present set to false if the document does not
+// contain the field
+type sortValue struct {
+	value   interface{}
+	present bool
+}
+
+func (q *Query) sortValues(doc map[string]interface{}) []sortValue {
+	values := make([]sortValue, len(q.sort))
+	for i, f := range q.sort {
+		values[i].value, values[i].present = lookup(doc, f.path)
+	}
+	return values
+}
+
+// compareSortValues compares the values of the sort fields of two documents as per the sort order of the query
+func (q *Query) compareSortValues(values1, values2 []sortValue) int {
+	for i, f := range q.sort {
+		v1, v2 := values1[i], values2[i]
+		var c int
+		switch {
+		case !v1.present && !v2.present:
+			continue
+		case !v1.present:
+			c = -1
+		case !v2.present:
+			c = 1
+		default:
+			c = compare(v1.value, v2.value)
+		}
+		if c == 0 {
+			continue
+		}
+		if f.desc {
+			return -c
+		}
+		return c
+	}
+	return 0
+}
+
+// Limit returns the maximum number of results, as specified in the query. A zero value means no limit
+func (q *Query) Limit() int {
+	return q.limit
+}
+
+// Skip returns the number of the results to skip, as specified in the query
+func (q *Query) Skip() int {
+	return q.skip
+}
+
+// Project returns the value to be returned in the results for a matching document. If the query does not
+// specify the fields, the original value is returned as is, otherwise the value contains only the specified
+// fields that are present in the document
+func (q *Query) Project(value []byte, doc map[string]interface{}) ([]byte, error) {
+	if len(q.fields) == 0 {
+		return value, nil
+	}
+	projected := map[string]interface{}{}
+	for _, path := range q.fields {
+		v, ok := lookup(doc, path)
+		if !ok {
+			continue
+		}
+		m := projected
+		for _, p := range path[:len(path)-1] {
+			child, ok := m[p].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				m[p] = child
+			}
+			m = child
+		}
+		m[path[len(path)-1]] = v
+	}
+	return json.Marshal(projected)
+}
+
+// condition is a parsed selector, or a part thereof, that is matched against a document or against the value
+// of a field in a document
+type condition interface {
+	match(v interface{}) bool
+}
+
+type andCondition []condition
+
+func (c andCondition) match(v interface{}) bool {
+	for _, sub := range c {
+		if !sub.match(v) {
+			return false
+		}
+	}
+	return true
+}
+
+type orCondition []condition
+
+func (c orCondition) match(v interface{}) bool {
+	for _, sub := range c {
+		if sub.match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+type notCondition struct {
+	condition
+}
+
+func (c notCondition) match(v interface{}) bool {
+	return !c.condition.match(v)
+}
+
+// fieldCondition applies an operator to the value of a field in a document
+type fieldCondition struct {
+	path []string
+	op   operator
+}
+
+func (c *fieldCondition) match(v interface{}) bool {
+	fieldValue, ok := lookup(v, c.path)
+	return c.op(fieldValue, ok)
+}
+
+// operator matches the value of a field, where `present` is false if the field is not present in the document
+type operator func(v interface{}, present bool) bool
+
+func parseSelector(selector map[string]interface{}) (condition, error) {
+	var conditions andCondition
+	for _, name := range sortedKeys(selector) {
+		arg := selector[name]
+		if !strings.HasPrefix(name, "$") {
+			c, err := parseFieldConditions(splitPath(name), arg)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, c)
+			continue
+		}
+		c, err := parseCombination(name, arg)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, c)
+	}
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+	return conditions, nil
+}
+
+func parseCombination(name string, arg interface{}) (condition, error) {
+	switch name {
+	case "$and", "$or", "$nor":
+		args, ok := arg.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("the argument of %s must be an array", name)
+		}
+		var conditions []condition
+		for _, a := range args {
+			s, ok := a.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("the elements of the argument of %s must be JSON objects", name)
+			}
+			c, err := parseSelector(s)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, c)
+		}
+		switch name {
+		case "$and":
+			return andCondition(conditions), nil
+		case "$or":
+			return orCondition(conditions), nil
+		default:
+			return notCondition{orCondition(conditions)}, nil
+		}
+	case "$not":
+		s, ok := arg.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("the argument of $not must be a JSON object")
+		}
+		c, err := parseSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		return notCondition{c}, nil
+	default:
+		return nil, errors.Errorf("unsupported operator [%s]", name)
+	}
+}
+
+// parseFieldConditions parses the conditions on the field at the given path. The argument is either an object
+// of operators, an object of conditions on the nested fields, or a value for an implicit $eq
+func parseFieldConditions(path []string, arg interface{}) (condition, error) {
+	ops, ok := arg.(map[string]interface{})
+	if !ok || len(ops) == 0 {
+		return &fieldCondition{path, eqOperator(arg)}, nil
+	}
+	if !isOperatorObject(ops) {
+		var conditions andCondition
+		for _, name := range sortedKeys(ops) {
+			if strings.HasPrefix(name, "$") {
+				return nil, errors.Errorf("operator [%s] cannot be mixed with the nested fields", name)
+			}
+			c, err := parseFieldConditions(append(append([]string{}, path...), splitPath(name)...), ops[name])
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, c)
+		}
+		if len(conditions) == 1 {
+			return conditions[0], nil
+		}
+		return conditions, nil
+	}
+	var conditions andCondition
+	for _, name := range sortedKeys(ops) {
+		if !strings.HasPrefix(name, "$") {
+			return nil, errors.Errorf("field [%s] cannot be mixed with the operators", name)
+		}
+		if name == "$not" {
+			c, err := parseFieldConditions(path, ops[name])
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, notCondition{c})
+			continue
+		}
+		op, err := parseOperator(name, ops[name])
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, &fieldCondition{path, op})
+	}
+	if len(conditions) == 1 {
+		return conditions[0], nil
+	}
+	return conditions, nil
+}
+
+func isOperatorObject(obj map[string]interface{}) bool {
+	for name := range obj {
+		if strings.HasPrefix(name, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseOperator(name string, arg interface{}) (operator, error) {
+	switch name {
+	case "$eq":
+		return eqOperator(arg), nil
+	case "$ne":
+		return func(v interface{}, present bool) bool {
+			return present && compare(v, arg) != 0
+		}, nil
+	case "$gt", "$gte", "$lt", "$lte":
+		return comparisonOperator(name, arg), nil
+	case "$exists":
+		exists, ok := arg.(bool)
+		if !ok {
+			return nil, errors.New("the argument of $exists must be a boolean")
+		}
+		return func(v interface{}, present bool) bool {
+			return present == exists
+		}, nil
+	case "$type":
+		t, ok := arg.(string)
+		if !ok || !isValidType(t) {
+			return nil, errors.Errorf("invalid argument of $type [%v]", arg)
+		}
+		return func(v interface{}, present bool) bool {
+			return present && typeOf(v) == t
+		}, nil
+	case "$in", "$nin":
+		values, ok := arg.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("the argument of %s must be an array", name)
+		}
+		in := name == "$in"
+		return func(v interface{}, present bool) bool {
+			return present && contains(values, v) == in
+		}, nil
+	case "$size":
+		size, err := parseNonNegativeInt(arg)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid argument of $size")
+		}
+		return func(v interface{}, present bool) bool {
+			a, ok := v.([]interface{})
+			return ok && len(a) == size
+		}, nil
+	case "$all":
+		values, ok := arg.([]interface{})
+		if !ok {
+			return nil, errors.New("the argument of $all must be an array")
+		}
+		return func(v interface{}, present bool) bool {
+			a, ok := v.([]interface{})
+			if !ok {
+				return false
+			}
+			for _, value := range values {
+				if !contains(a, value) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "$elemMatch":
+		s, ok := arg.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("the argument of $elemMatch must be a JSON object")
+		}
+		var c condition
+		var err error
+		if isFieldOperatorObject(s) {
+			// the operators apply to the elements themselves, e.g., {"$elemMatch": {"$gt": 5}}
+			c, err = parseFieldConditions(nil, s)
+		} else {
+			c, err = parseSelector(s)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, present bool) bool {
+			a, ok := v.([]interface{})
+			if !ok {
+				return false
+			}
+			for _, e := range a {
+				if c.match(e) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "$regex":
+		pattern, ok := arg.(string)
+		if !ok {
+			return nil, errors.New("the argument of $regex must be a string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid argument of $regex")
+		}
+		return func(v interface{}, present bool) bool {
+			s, ok := v.(string)
+			return ok && re.MatchString(s)
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported operator [%s]", name)
+	}
+}
+
+func isFieldOperatorObject(obj map[string]interface{}) bool {
+	for name := range obj {
+		switch name {
+		case "$and", "$or", "$nor":
+			return false
+		}
+		if !strings.HasPrefix(name, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+func eqOperator(arg interface{}) operator {
+	return func(v interface{}, present bool) bool {
+		return present && compare(v, arg) == 0
+	}
+}
+
+func comparisonOperator(name string, arg interface{}) operator {
+	return func(v interface{}, present bool) bool {
+		if !present {
+			return false
+		}
+		c := compare(v, arg)
+		switch name {
+		case "$gt":
+			return c > 0
+		case "$gte":
+			return c >= 0
+		case "$lt":
+			return c < 0
+		default:
+			return c <= 0
+		}
+	}
+}
+
+func contains(values []interface{}, v interface{}) bool {
+	for _, value := range values {
+		if compare(value, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidType(t string) bool {
+	switch t {
+	case "null", "boolean", "number", "string", "array", "object":
+		return true
+	}
+	return false
+}
+
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// typeRank returns the rank of the type of a value in the CouchDB collation order
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case json.Number:
+		return 2
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	default:
+		return 5
+	}
+}
+
+// compare compares two decoded JSON values in the CouchDB collation order
+func compare(v1, v2 interface{}) int {
+	r1, r2 := typeRank(v1), typeRank(v2)
+	if r1 != r2 {
+		return compareInts(r1, r2)
+	}
+	switch t1 := v1.(type) {
+	case nil:
+		return 0
+	case bool:
+		t2 := v2.(bool)
+		switch {
+		case t1 == t2:
+			return 0
+		case !t1:
+			return -1
+		default:
+			return 1
+		}
+	case json.Number:
+		return compareNumbers(t1, v2.(json.Number))
+	case string:
+		return strings.Compare(t1, v2.(string))
+	case []interface{}:
+		t2 := v2.([]interface{})
+		for i := 0; i < len(t1) && i < len(t2); i++ {
+			if c := compare(t1[i], t2[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(t1), len(t2))
+	default:
+		o1, o2 := v1.(map[string]interface{}), v2.(map[string]interface{})
+		k1, k2 := sortedKeys(o1), sortedKeys(o2)
+		for i := 0; i < len(k1) && i < len(k2); i++ {
+			if c := strings.Compare(k1[i], k2[i]); c != 0 {
+				return c
+			}
+			if c := compare(o1[k1[i]], o2[k2[i]]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(k1), len(k2))
+	}
+}
+
+func compareNumbers(n1, n2 json.Number) int {
+	if i1, err := n1.Int64(); err == nil {
+		if i2, err := n2.Int64(); err == nil {
+			switch {
+			case i1 < i2:
+				return -1
+			case i1 > i2:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+	f1, f2 := toFloat(n1), toFloat(n2)
+	switch {
+	case f1 < f2:
+		return -1
+	case f1 > f2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toFloat(n json.Number) float64 {
+	f, err := n.Float64()
+	if err != nil {
+		// the number is out of range for a float64
+		if strings.HasPrefix(string(n), "-") {
+			return math.Inf(-1)
+		}
+		return math.Inf(1)
+	}
+	return f
+}
+
+func compareInts(i1, i2 int) int {
+	switch {
+	case i1 < i2:
+		return -1
+	case i1 > i2:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func lookup(v interface{}, path []string) (interface{}, bool) {
+	for _, p := range path {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[p]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func parseFields(arg interface{}) ([][]string, error) {
+	fields, ok := arg.([]interface{})
+	if !ok {
+		return nil, errors.New("the fields must be an array of strings")
+	}
+	var paths [][]string
+	for _, f := range fields {
+		name, ok := f.(string)
+		if !ok || name == "" {
+			return nil, errors.New("the fields must be an array of strings")
+		}
+		paths = append(paths, splitPath(name))
+	}
+	return paths, nil
+}
+
+// parseSort parses the sort order, which is an array of the field names, for an ascending sort, or of the
+// objects of the form {"field": "asc"} or {"field": "desc"}
+func parseSort(arg interface{}) ([]*sortField, error) {
+	fields, ok := arg.([]interface{})
+	if !ok {
+		return nil, errors.New("the sort must be an array")
+	}
+	var sortFields []*sortField
+	for _, f := range fields {
+		switch t := f.(type) {
+		case string:
+			sortFields = append(sortFields, &sortField{path: splitPath(t)})
+		case map[string]interface{}:
+			if len(t) != 1 {
+				return nil, errors.New("each sort object must contain exactly one field")
+			}
+			for name, dir := range t {
+				switch dir {
+				case "asc":
+					sortFields = append(sortFields, &sortField{path: splitPath(name)})
+				case "desc":
+					sortFields = append(sortFields, &sortField{path: splitPath(name), desc: true})
+				default:
+					return nil, errors.Errorf("invalid sort direction [%v] for field [%s]", dir, name)
+				}
+			}
+		default:
+			return nil, errors.New("the elements of the sort must be strings or JSON objects")
+		}
+	}
+	return sortFields, nil
+}
+
+func parseNonNegativeInt(arg interface{}) (int, error) {
+	n, ok := arg.(json.Number)
+	if !ok {
+		return 0, errors.Errorf("[%v] is not a number", arg)
+	}
+	i, err := strconv.Atoi(string(n))
+	if err != nil || i < 0 {
+		return 0, errors.Errorf("[%s] is not a non-negative integer", n)
+	}
+	return i, nil
+}
+
+func splitPath(name string) []string {
+	return strings.Split(name, ".")
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// decode decodes a single JSON value, retaining the numbers as json.Number so as not to lose the precision
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, errors.Wrap(err, "error decoding JSON")
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
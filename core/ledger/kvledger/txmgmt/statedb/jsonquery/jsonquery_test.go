@@ -0,0 +1,184 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package jsonquery
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	doc := []byte(`{
+		"name": "marble1",
+		"size": 10,
+		"price": 2.5,
+		"sold": false,
+		"tags": ["red", "round", "glass"],
+		"scores": [3, 8, 12],
+		"owner": {"name": "tom", "address": {"city": "paris"}},
+		"parts": [{"kind": "core", "weight": 2}, {"kind": "shell", "weight": 5}],
+		"note": null
+	}`)
+
+	testCases := []struct {
+		selector string
+		match    bool
+	}{
+		{`{}`, true},
+		{`{"name": "marble1"}`, true},
+		{`{"name": "marble2"}`, false},
+		{`{"size": 10.0}`, true},
+		{`{"owner.name": "tom"}`, true},
+		{`{"owner": {"address": {"city": "paris"}}}`, true},
+		{`{"owner.address.city": {"$ne": "paris"}}`, false},
+		{`{"size": {"$gt": 5, "$lt": 11}}`, true},
+		{`{"size": {"$gte": 10, "$lte": 10}}`, true},
+		{`{"size": {"$gt": 10}}`, false},
+		{`{"price": {"$lt": 3}}`, true},
+		{`{"name": {"$gt": 100}}`, true},
+		{`{"sold": {"$eq": false}}`, true},
+		{`{"note": null}`, true},
+		{`{"missing": null}`, false},
+		{`{"missing": {"$exists": false}}`, true},
+		{`{"missing": {"$ne": 1}}`, false},
+		{`{"name": {"$exists": true}}`, true},
+		{`{"tags": {"$type": "array"}, "owner": {"$type": "object"}}`, true},
+		{`{"size": {"$type": "string"}}`, false},
+		{`{"size": {"$in": [1, 10]}}`, true},
+		{`{"size": {"$nin": [1, 10]}}`, false},
+		{`{"tags": {"$size": 3}}`, true},
+		{`{"tags": {"$all": ["round", "red"]}}`, true},
+		{`{"tags": {"$all": ["round", "blue"]}}`, false},
+		{`{"scores": {"$elemMatch": {"$gt": 10}}}`, true},
+		{`{"scores": {"$elemMatch": {"$gt": 12}}}`, false},
+		{`{"parts": {"$elemMatch": {"kind": "shell", "weight": {"$gt": 4}}}}`, true},
+		{`{"parts": {"$elemMatch": {"kind": "core", "weight": {"$gt": 4}}}}`, false},
+		{`{"name": {"$regex": "^marble[0-9]$"}}`, true},
+		{`{"size": {"$regex": "10"}}`, false},
+		{`{"size": {"$not": {"$gt": 5}}}`, false},
+		{`{"$and": [{"size": 10}, {"sold": false}]}`, true},
+		{`{"$or": [{"size": 11}, {"sold": true}]}`, false},
+		{`{"$nor": [{"size": 11}, {"sold": true}]}`, true},
+		{`{"$not": {"owner.name": "jerry"}}`, true},
+		{`{"name": "marble1", "$or": [{"size": 1}, {"owner.address.city": "paris"}]}`, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.selector, func(t *testing.T) {
+			q, err := Parse(fmt.Sprintf(`{"selector": %s}`, tc.selector))
+			require.NoError(t, err)
+			_, match := q.Match(doc)
+			require.Equal(t, tc.match, match)
+		})
+	}
+
+	q, err := Parse(`{"selector": {}}`)
+	require.NoError(t, err)
+	for _, value := range []string{`not json`, `[1, 2]`, `"a string"`, `{"a": 1} {"b": 2}`} {
+		_, match := q.Match([]byte(value))
+		require.False(t, match, value)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		query       string
+		expectedErr string
+	}{
+		{`not json`, "invalid query: error decoding JSON: invalid character 'o' in literal null (expecting 'u')"},
+		{`[]`, "invalid query: the query must be a JSON object"},
+		{`{"limit": 5}`, "invalid query: the selector is missing"},
+		{`{"selector": []}`, "invalid query: the selector must be a JSON object"},
+		{`{"selector": {}, "bookmark": "b"}`, "invalid query: unsupported query parameter [bookmark]"},
+		{`{"selector": {}, "limit": -1}`, "invalid query: invalid limit: [-1] is not a non-negative integer"},
+		{`{"selector": {}, "skip": "1"}`, "invalid query: invalid skip: [1] is not a number"},
+		{`{"selector": {}, "fields": "name"}`, "invalid query: the fields must be an array of strings"},
+		{`{"selector": {}, "sort": [{"name": "up"}]}`, "invalid query: invalid sort direction [up] for field [name]"},
+		{`{"selector": {"$text": "a"}}`, "invalid query: unsupported operator [$text]"},
+		{`{"selector": {"size": {"$mod": [2, 0]}}}`, "invalid query: unsupported operator [$mod]"},
+		{`{"selector": {"size": {"$gt": 1, "unit": "cm"}}}`, "invalid query: field [unit] cannot be mixed with the operators"},
+		{`{"selector": {"$or": {"size": 1}}}`, "invalid query: the argument of $or must be an array"},
+		{`{"selector": {"name": {"$regex": "("}}}`, "invalid query: invalid argument of $regex: error parsing regexp: missing closing ): `(`"},
+		{`{"selector": {"name": {"$type": "date"}}}`, "invalid query: invalid argument of $type [date]"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := Parse(tc.query)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+
+	_, err := Parse(`{"selector": {}, "use_index": ["_design/indexOwnerDoc", "indexOwner"]}`)
+	require.NoError(t, err)
+}
+
+func TestSortAndProject(t *testing.T) {
+	q, err := Parse(`{"selector": {}, "sort": [{"color": "asc"}, {"size": "desc"}], "fields": ["color", "dims.height"], "limit": 3, "skip": 1}`)
+	require.NoError(t, err)
+	require.True(t, q.Sorted())
+	require.Equal(t, 3, q.Limit())
+	require.Equal(t, 1, q.Skip())
+
+	values := []string{
+		`{"color": "red", "size": 1, "dims": {"height": 3, "width": 4}}`,
+		`{"color": "blue", "size": 2}`,
+		`{"color": "red", "size": 5}`,
+		`{"size": 7}`,
+		`{"color": 7}`,
+	}
+	var docs []map[string]interface{}
+	for _, v := range values {
+		doc, match := q.Match([]byte(v))
+		require.True(t, match)
+		docs = append(docs, doc)
+	}
+	// a missing field sorts first and numbers sort before strings
+	expectedOrder := []int{3, 4, 1, 2, 0}
+	for i := 0; i < len(expectedOrder)-1; i++ {
+		require.True(t, q.Less(docs[expectedOrder[i]], docs[expectedOrder[i+1]]), "position %d", i)
+		require.False(t, q.Less(docs[expectedOrder[i+1]], docs[expectedOrder[i]]), "position %d", i)
+	}
+
+	projected, err := q.Project([]byte(values[0]), docs[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"color": "red", "dims": {"height": 3}}`, string(projected))
+
+	q, err = Parse(`{"selector": {}}`)
+	require.NoError(t, err)
+	require.False(t, q.Sorted())
+	projected, err = q.Project([]byte(values[1]), docs[1])
+	require.NoError(t, err)
+	require.Equal(t, values[1], string(projected))
+}
+
+func TestSortedBookmark(t *testing.T) {
+	q, err := Parse(`{"selector": {}, "sort": [{"color": "asc"}, {"size": "desc"}, {"owner": "asc"}]}`)
+	require.NoError(t, err)
+	doc, match := q.Match([]byte(`{"color": null, "size": 12345678901234567890}`))
+	require.True(t, match)
+	r := &sortedResult{
+		kv:         &statedb.VersionedKV{CompositeKey: &statedb.CompositeKey{Key: "key1"}},
+		sortValues: q.sortValues(doc),
+	}
+	decoded, err := decodeSortedBookmark(q, encodeSortedBookmark(r))
+	require.NoError(t, err)
+	require.Equal(t, "key1", decoded.kv.Key)
+	require.Equal(t, r.sortValues, decoded.sortValues)
+	require.False(t, decoded.less(q, r))
+	require.False(t, r.less(q, decoded))
+
+	otherQuery, err := Parse(`{"selector": {}, "sort": ["color"]}`)
+	require.NoError(t, err)
+	_, err = decodeSortedBookmark(otherQuery, encodeSortedBookmark(r))
+	require.EqualError(t, err, "the bookmark does not match the sort fields of the query")
+	_, err = decodeSortedBookmark(q, "!")
+	require.Error(t, err)
+}
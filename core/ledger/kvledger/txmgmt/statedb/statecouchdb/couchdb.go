@@ -166,6 +166,20 @@ type batchRetrieveDocMetadataResponse struct {
 	} `json:"rows"`
 }
 
+// bulkGetResponse is used for processing REST bulk get responses from CouchDB
+type bulkGetResponse struct {
+	Results []struct {
+		ID   string `json:"id"`
+		Docs []struct {
+			OK    json.RawMessage `json:"ok"`
+			Error *struct {
+				Error  string `json:"error"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"docs"`
+	} `json:"results"`
+}
+
 // batchUpdateResponse defines a structure for batch update response
 type batchUpdateResponse struct {
 	ID     string `json:"id"`
@@ -1381,6 +1395,89 @@ func (dbclient *couchDatabase) batchRetrieveDocumentMetadata(keys []string) ([]*
 	return docMetadataArray, nil
 }
 
+// batchRetrieveDocuments retrieves the latest revision of the documents for a set of keys, along with
+// the attachments, in a single request via the `_bulk_get` API of CouchDB. The returned documents are
+// in the order of the keys and a nil document is returned for a key that does not exist or is deleted
+func (dbclient *couchDatabase) batchRetrieveDocuments(keys []string) ([]*couchDoc, error) {
+	couchdbLogger.Debugf("[%s] Entering BatchRetrieveDocuments()  keys=%s", dbclient.dbName, keys)
+
+	bulkGetURL, err := url.Parse(dbclient.couchInstance.url())
+	if err != nil {
+		couchdbLogger.Errorf("URL parse error: %s", err)
+		return nil, errors.Wrapf(err, "error parsing CouchDB URL: %s", dbclient.couchInstance.url())
+	}
+
+	queryParms := bulkGetURL.Query()
+	queryParms.Add("attachments", "true")
+
+	type docRef struct {
+		ID string `json:"id"`
+	}
+	docRefs := make([]docRef, len(keys))
+	for i, key := range keys {
+		if !utf8.ValidString(key) {
+			return nil, errors.Errorf("doc id [%x] not a valid utf8 string", key)
+		}
+		docRefs[i] = docRef{ID: key}
+	}
+	jsonKeys, err := json.Marshal(map[string]interface{}{"docs": docRefs})
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshalling json data")
+	}
+
+	// get the number of retries
+	maxRetries := dbclient.couchInstance.conf.MaxRetries
+
+	resp, _, err := dbclient.handleRequest(http.MethodPost, "BatchRetrieveDocuments", bulkGetURL, jsonKeys, "", "", maxRetries, true, &queryParms, "_bulk_get")
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+
+	jsonResponseRaw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading response body")
+	}
+	jsonResponse := &bulkGetResponse{}
+	if err := json.Unmarshal(jsonResponseRaw, jsonResponse); err != nil {
+		return nil, errors.Wrap(err, "error unmarshalling json data")
+	}
+	if len(jsonResponse.Results) != len(keys) {
+		return nil, errors.Errorf("unexpected number of results from bulk get, expected %d, found %d",
+			len(keys), len(jsonResponse.Results))
+	}
+
+	docs := make([]*couchDoc, len(keys))
+	for i, result := range jsonResponse.Results {
+		if result.ID != keys[i] {
+			return nil, errors.Errorf("unexpected doc id [%s] in the results of bulk get, expected [%s]", result.ID, keys[i])
+		}
+		if len(result.Docs) == 0 {
+			continue
+		}
+		doc := result.Docs[0]
+		if doc.Error != nil {
+			if doc.Error.Error == "not_found" {
+				continue
+			}
+			return nil, errors.Errorf("error while retrieving doc with ID %s: %s, reason: %s", result.ID, doc.Error.Error, doc.Error.Reason)
+		}
+		docMetadata := &docMetadata{}
+		if err := json.Unmarshal(doc.OK, docMetadata); err != nil {
+			return nil, errors.Wrap(err, "error unmarshalling json data")
+		}
+		var attachments []*attachmentInfo
+		for attachmentName, attachment := range docMetadata.AttachmentsInfo {
+			attachment.Name = attachmentName
+			attachments = append(attachments, attachment)
+		}
+		docs[i] = &couchDoc{jsonValue: doc.OK, attachments: attachments}
+	}
+
+	couchdbLogger.Debugf("[%s] Exiting BatchRetrieveDocuments()", dbclient.dbName)
+	return docs, nil
+}
+
 func (dbclient *couchDatabase) insertDocuments(docs []*couchDoc) error {
 	responses, err := dbclient.batchUpdateDocuments(docs)
 	if err != nil {
@@ -189,6 +189,8 @@ func keyValToCouchDoc(kv *keyValue) (*couchDoc, error) {
 type couchSavepointData struct {
 	BlockNum uint64 `json:"BlockNum"`
 	TxNum    uint64 `json:"TxNum"`
+	// Partial, if present, is the height up to which the updates of the block next to the savepoint are committed
+	Partial *couchSavepointData `json:"Partial,omitempty"`
 }
 
 type channelMetadata struct {
@@ -217,14 +219,41 @@ func encodeSavepoint(height *version.Height) (*couchDoc, error) {
 	return &couchDoc{jsonValue: savepointDocJSON, attachments: nil}, nil
 }
 
+// encodePartialSavepoint encodes the savepoint along with the height up to which the updates of the next block
+// are committed
+func encodePartialSavepoint(height *version.Height, partial *version.Height) (*couchDoc, error) {
+	savepointDoc := &couchSavepointData{
+		BlockNum: height.BlockNum,
+		TxNum:    height.TxNum,
+		Partial:  &couchSavepointData{BlockNum: partial.BlockNum, TxNum: partial.TxNum},
+	}
+	savepointDocJSON, err := json.Marshal(savepointDoc)
+	if err != nil {
+		err = errors.Wrap(err, "failed to marshal savepoint data")
+		logger.Errorf("%+v", err)
+		return nil, err
+	}
+	return &couchDoc{jsonValue: savepointDocJSON, attachments: nil}, nil
+}
+
 func decodeSavepoint(couchDoc *couchDoc) (*version.Height, error) {
+	savepoint, _, err := decodeSavepointAndPartial(couchDoc)
+	return savepoint, err
+}
+
+// decodeSavepointAndPartial decodes the savepoint and the partial savepoint, which is nil if not present
+func decodeSavepointAndPartial(couchDoc *couchDoc) (*version.Height, *version.Height, error) {
 	savepointDoc := &couchSavepointData{}
 	if err := json.Unmarshal(couchDoc.jsonValue, &savepointDoc); err != nil {
 		err = errors.Wrap(err, "failed to unmarshal savepoint data")
 		logger.Errorf("%+v", err)
-		return nil, err
+		return nil, nil, err
+	}
+	var partial *version.Height
+	if savepointDoc.Partial != nil {
+		partial = &version.Height{BlockNum: savepointDoc.Partial.BlockNum, TxNum: savepointDoc.Partial.TxNum}
 	}
-	return &version.Height{BlockNum: savepointDoc.BlockNum, TxNum: savepointDoc.TxNum}, nil
+	return &version.Height{BlockNum: savepointDoc.BlockNum, TxNum: savepointDoc.TxNum}, partial, nil
 }
 
 func encodeChannelMetadata(metadataDoc *channelMetadata) (*couchDoc, error) {
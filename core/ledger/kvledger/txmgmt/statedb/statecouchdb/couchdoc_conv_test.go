@@ -90,3 +90,22 @@ func testSortJSON(t *testing.T, filePrefix int) {
 	require.NoError(t, err)
 	require.Equal(t, string(expected), prettyPrintJSON.String())
 }
+
+func TestSavepointAndPartialConversion(t *testing.T) {
+	doc, err := encodeSavepoint(version.NewHeight(5, 3))
+	require.NoError(t, err)
+	savepoint, partial, err := decodeSavepointAndPartial(doc)
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(5, 3), savepoint)
+	require.Nil(t, partial)
+
+	doc, err = encodePartialSavepoint(version.NewHeight(5, 3), version.NewHeight(6, 99))
+	require.NoError(t, err)
+	savepoint, partial, err = decodeSavepointAndPartial(doc)
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(5, 3), savepoint)
+	require.Equal(t, version.NewHeight(6, 99), partial)
+	savepoint, err = decodeSavepoint(doc)
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(5, 3), savepoint)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package statecouchdb
+
+import (
+	"fmt"
+)
+
+// subNsStateRetriever implements `batch` interface and wraps the function
+// `couchdb.batchRetrieveDocuments` for allowing parallel execution of this
+// function for different sets of keys within a namespace. Different sets
+// of keys are expected to be created based on the batch update size configured
+// for the database.
+type subNsStateRetriever struct {
+	db              *couchDatabase
+	keys            []string
+	executionResult []*keyValue
+}
+
+// retrieveNsStates retrieves the latest state of the given keys of a namespace via the bulk get API. The returned
+// values are in the order of the keys and a nil value is returned for a key that does not exist
+func retrieveNsStates(db *couchDatabase, keys []string) ([]*keyValue, error) {
+	// construct one batch per group of keys based on maxBatchSize
+	maxBatchSize := db.couchInstance.maxBatchUpdateSize()
+	batches := []batch{}
+	remainingKeys := keys
+	for {
+		numKeys := minimum(maxBatchSize, len(remainingKeys))
+		if numKeys == 0 {
+			break
+		}
+		batch := &subNsStateRetriever{db: db, keys: remainingKeys[:numKeys]}
+		batches = append(batches, batch)
+		remainingKeys = remainingKeys[numKeys:]
+	}
+	if err := executeBatches(batches); err != nil {
+		return nil, err
+	}
+	// accumulate results from each batch
+	executionResults := make([]*keyValue, 0, len(keys))
+	for _, b := range batches {
+		executionResults = append(executionResults, b.(*subNsStateRetriever).executionResult...)
+	}
+	return executionResults, nil
+}
+
+func (b *subNsStateRetriever) execute() error {
+	docs, err := b.db.batchRetrieveDocuments(b.keys)
+	if err != nil {
+		return err
+	}
+	b.executionResult = make([]*keyValue, len(docs))
+	for i, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		if b.executionResult[i], err = couchDocToKeyValue(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *subNsStateRetriever) String() string {
+	return fmt.Sprintf("subNsStateRetriever:db=%s, num keys=%d", b.db.dbName, len(b.keys))
+}
@@ -253,10 +253,17 @@ type VersionedDB struct {
 	namespaceDBs       map[string]*couchDatabase // One database per namespace.
 	channelMetadata    *channelMetadata          // Store channel name and namespaceDBInfo
 	committedDataCache *versionsCache            // Used as a local cache during bulk processing of a block.
+	committedStates    statesCache               // Used as a local cache of the values during bulk processing of a block.
 	verCacheLock       sync.RWMutex
 	mux                sync.RWMutex
 	redoLogger         *redoLogger
 	cache              *cache
+	// partialSavepointInterval, if non-zero, is the number of transactions of a block after which a partial
+	// savepoint is recorded during the commit of the block
+	partialSavepointInterval uint64
+	// partialSavepoint is the partial savepoint left by the commit of a block that was interrupted by a crash.
+	// The updates covered by it are skipped when the block is committed again
+	partialSavepoint *version.Height
 }
 
 // newVersionedDB constructs an instance of VersionedDB
@@ -285,10 +292,15 @@ func newVersionedDB(couchInstance *couchInstance, redoLogger *redoLogger, dbName
 	if err != nil {
 		return nil, err
 	}
-	savepoint, err := vdb.GetLatestSavePoint()
+	savepoint, partialSavepoint, err := vdb.getSavepointAndPartial()
 	if err != nil {
 		return nil, err
 	}
+	if partialSavepoint != nil {
+		logger.Infof("chain [%s]: commit of block [%d] was interrupted after the updates up to the transaction [%d]",
+			chainName, partialSavepoint.BlockNum, partialSavepoint.TxNum)
+		vdb.partialSavepoint = partialSavepoint
+	}
 
 	isNewDB := savepoint == nil
 	if err = vdb.initChannelMetadata(isNewDB, nsProvider); err != nil {
@@ -444,9 +456,41 @@ func (vdb *VersionedDB) LoadCommittedVersions(keys []*statedb.CompositeKey) erro
 	vdb.verCacheLock.Lock()
 	defer vdb.verCacheLock.Unlock()
 	vdb.committedDataCache = committedDataCache
+	vdb.committedStates = nil
+	return nil
+}
+
+// LoadCommittedStates loads the committed values of the given keys via the bulk get API into a local cache, so that
+// the subsequent invocations of GetState for these keys, such as for merging the metadata of the keys written by
+// the transactions during the validation of a block, do not cost a round trip each. The cache is emptied on the
+// next update of the db, on the next invocation of `LoadCommittedVersions`, or on `ClearCachedVersions`
+func (vdb *VersionedDB) LoadCommittedStates(keys []*statedb.CompositeKey) error {
+	committedStates := statesCache{}
+	nsKeys := map[string][]string{}
+	for _, k := range keys {
+		nsKeys[k.Namespace] = append(nsKeys[k.Namespace], k.Key)
+	}
+	for ns, keys := range nsKeys {
+		vals, err := vdb.GetStateMultipleKeys(ns, keys)
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			committedStates.set(ns, key, vals[i])
+		}
+	}
+	vdb.verCacheLock.Lock()
+	defer vdb.verCacheLock.Unlock()
+	vdb.committedStates = committedStates
 	return nil
 }
 
+func (vdb *VersionedDB) getCommittedState(namespace, key string) (*statedb.VersionedValue, bool) {
+	vdb.verCacheLock.RLock()
+	defer vdb.verCacheLock.RUnlock()
+	return vdb.committedStates.get(namespace, key)
+}
+
 // GetVersion implements method in VersionedDB interface
 func (vdb *VersionedDB) GetVersion(namespace string, key string) (*version.Height, error) {
 	version, keyFound := vdb.GetCachedVersion(namespace, key)
@@ -490,6 +534,11 @@ func (vdb *VersionedDB) BytesKeySupported() bool {
 func (vdb *VersionedDB) GetState(namespace string, key string) (*statedb.VersionedValue, error) {
 	logger.Debugf("GetState(). ns=%s, key=%s", namespace, key)
 
+	// (0) read the KV from the values loaded in bulk for the block under processing, if available
+	if vv, ok := vdb.getCommittedState(namespace, key); ok {
+		return vv, nil
+	}
+
 	// (1) read the KV from the cache if available
 	cacheEnabled := vdb.cache.enabled(namespace)
 	if cacheEnabled {
@@ -548,15 +597,59 @@ func (vdb *VersionedDB) readFromDB(namespace, key string) (*keyValue, error) {
 	return kv, nil
 }
 
-// GetStateMultipleKeys implements method in VersionedDB interface
+// GetStateMultipleKeys implements method in VersionedDB interface. The keys that are not found in the caches
+// are retrieved from the database via the bulk get API, instead of a round trip per key
 func (vdb *VersionedDB) GetStateMultipleKeys(namespace string, keys []string) ([]*statedb.VersionedValue, error) {
 	vals := make([]*statedb.VersionedValue, len(keys))
+	cacheEnabled := vdb.cache.enabled(namespace)
+	var missingKeys []string
+	var missingKeyIndexes []int
 	for i, key := range keys {
-		val, err := vdb.GetState(namespace, key)
-		if err != nil {
+		if vv, ok := vdb.getCommittedState(namespace, key); ok {
+			vals[i] = vv
+			continue
+		}
+		if cacheEnabled {
+			cv, err := vdb.cache.getState(vdb.chainName, namespace, key)
+			if err != nil {
+				return nil, err
+			}
+			if cv != nil {
+				if vals[i], err = constructVersionedValue(cv); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		if err := validateKey(key); err != nil {
 			return nil, err
 		}
-		vals[i] = val
+		missingKeys = append(missingKeys, key)
+		missingKeyIndexes = append(missingKeyIndexes, i)
+	}
+	if len(missingKeys) == 0 {
+		return vals, nil
+	}
+
+	db, err := vdb.getNamespaceDBHandle(namespace)
+	if err != nil {
+		return nil, err
+	}
+	kvs, err := retrieveNsStates(db, missingKeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, kv := range kvs {
+		if kv == nil {
+			continue
+		}
+		vals[missingKeyIndexes[i]] = kv.VersionedValue
+		if cacheEnabled {
+			cacheValue := constructCacheValue(kv.VersionedValue, kv.revision)
+			if err := vdb.cache.putState(vdb.chainName, namespace, missingKeys[i], cacheValue); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return vals, nil
 }
@@ -715,6 +808,60 @@ func (vdb *VersionedDB) ApplyUpdates(updates *statedb.UpdateBatch, height *versi
 }
 
 func (vdb *VersionedDB) applyUpdates(updates *statedb.UpdateBatch, height *version.Height) error {
+	if height == nil {
+		return vdb.commitUpdates(updates, nil)
+	}
+	if vdb.partialSavepoint != nil && vdb.partialSavepoint.BlockNum == height.BlockNum {
+		logger.Infof("chain [%s]: skipping the updates of block [%d] up to the transaction [%d] that are already committed",
+			vdb.chainName, height.BlockNum, vdb.partialSavepoint.TxNum)
+		updates = updates.UpdatesAfter(vdb.partialSavepoint)
+	}
+	if vdb.partialSavepointInterval == 0 || height.TxNum < vdb.partialSavepointInterval {
+		if err := vdb.commitUpdates(updates, height); err != nil {
+			return err
+		}
+		vdb.partialSavepoint = nil
+		return nil
+	}
+
+	savepoint, err := vdb.GetLatestSavePoint()
+	if err != nil {
+		return err
+	}
+	batches, heights := updates.SplitByTxNum(height, vdb.partialSavepointInterval)
+	for i, batch := range batches {
+		if i == len(batches)-1 {
+			break
+		}
+		if err := vdb.commitUpdates(batch, nil); err != nil {
+			return err
+		}
+		// a partial savepoint cannot be recorded without a savepoint, which is the case only for the first
+		// block of a new db. The first block is committed again entirely in the event of a crash
+		if savepoint != nil {
+			if err := vdb.recordPartialSavepoint(savepoint, heights[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := vdb.commitUpdates(batches[len(batches)-1], height); err != nil {
+		return err
+	}
+	vdb.partialSavepoint = nil
+	return nil
+}
+
+// SetPartialSavepointInterval implements method in statedb.PartialSavepointCapable interface
+func (vdb *VersionedDB) SetPartialSavepointInterval(txs uint64) {
+	vdb.partialSavepointInterval = txs
+}
+
+func (vdb *VersionedDB) commitUpdates(updates *statedb.UpdateBatch, height *version.Height) error {
+	// the values loaded in bulk are superseded by the updates
+	vdb.verCacheLock.Lock()
+	vdb.committedStates = nil
+	vdb.verCacheLock.Unlock()
+
 	// TODO a note about https://jira.hyperledger.org/browse/FAB-8622
 	// The write lock is needed only for the stage 2.
 
@@ -782,12 +929,13 @@ func (vdb *VersionedDB) postCommitProcessing(committers []*committer, namespaces
 	}
 }
 
-// ClearCachedVersions clears committedVersions and revisionNumbers
+// ClearCachedVersions clears committedVersions and revisionNumbers, along with the values loaded via LoadCommittedStates
 func (vdb *VersionedDB) ClearCachedVersions() {
 	logger.Debugf("Clear Cache")
 	vdb.verCacheLock.Lock()
 	defer vdb.verCacheLock.Unlock()
 	vdb.committedDataCache = newVersionCache()
+	vdb.committedStates = nil
 }
 
 // Open implements method in VersionedDB interface
@@ -821,19 +969,38 @@ func (vdb *VersionedDB) recordSavepoint(height *version.Height) error {
 	return nil
 }
 
+// recordPartialSavepoint records, along with the savepoint, the height up to which the updates of the block next to
+// the savepoint are committed. The partial savepoint is removed when the savepoint of the block is recorded
+func (vdb *VersionedDB) recordPartialSavepoint(savepoint *version.Height, partial *version.Height) error {
+	savepointCouchDoc, err := encodePartialSavepoint(savepoint, partial)
+	if err != nil {
+		return err
+	}
+	if _, err := vdb.metadataDB.saveDoc(savepointDocID, "", savepointCouchDoc); err != nil {
+		logger.Errorf("Failed to save the partial savepoint to DB %s", err.Error())
+		return err
+	}
+	return nil
+}
+
 // GetLatestSavePoint implements method in VersionedDB interface
 func (vdb *VersionedDB) GetLatestSavePoint() (*version.Height, error) {
-	var err error
+	savepoint, _, err := vdb.getSavepointAndPartial()
+	return savepoint, err
+}
+
+// getSavepointAndPartial returns the savepoint along with the partial savepoint, if any
+func (vdb *VersionedDB) getSavepointAndPartial() (*version.Height, *version.Height, error) {
 	couchDoc, _, err := vdb.metadataDB.readDoc(savepointDocID)
 	if err != nil {
 		logger.Errorf("Failed to read savepoint data %s", err.Error())
-		return nil, err
+		return nil, nil, err
 	}
 	// ReadDoc() not found (404) will result in nil response, in these cases return height nil
 	if couchDoc == nil || couchDoc.jsonValue == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
-	return decodeSavepoint(couchDoc)
+	return decodeSavepointAndPartial(couchDoc)
 }
 
 // initChannelMetadata initizlizes channelMetadata and build NamespaceDBInfo mapping if not present
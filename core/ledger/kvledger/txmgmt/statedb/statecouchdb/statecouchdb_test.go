@@ -493,6 +493,13 @@ func TestPaginatedRangeQuery(t *testing.T) {
 	commontests.TestPaginatedRangeQuery(t, vdbEnv.DBProvider)
 }
 
+func TestPaginatedRangeQueryBookmark(t *testing.T) {
+	vdbEnv.init(t, nil)
+	defer vdbEnv.cleanup()
+
+	commontests.TestPaginatedRangeQueryBookmark(t, vdbEnv.DBProvider)
+}
+
 func TestRangeQuerySpecialCharacters(t *testing.T) {
 	vdbEnv.init(t, nil)
 	defer vdbEnv.cleanup()
@@ -1351,6 +1358,73 @@ func TestMissingRevisionRetrievalFromCache(t *testing.T) {
 	require.Equal(t, "rev2", revisions["key2"])
 }
 
+func TestLoadCommittedStates(t *testing.T) {
+	vdbEnv.init(t, nil)
+	defer vdbEnv.cleanup()
+	chainID := "testloadcommittedstates"
+	db, err := vdbEnv.DBProvider.GetDBHandle(chainID, nil)
+	require.NoError(t, err)
+	vdb := db.(*VersionedDB)
+
+	// store a plain value, a json value, a value with metadata, and a deleted key
+	batch := statedb.NewUpdateBatch()
+	vv1 := &statedb.VersionedValue{Value: []byte("value1"), Version: version.NewHeight(1, 1)}
+	vv2 := &statedb.VersionedValue{Value: []byte(`{"asset_name":"marble1","color":"blue"}`), Version: version.NewHeight(1, 2)}
+	vv3 := &statedb.VersionedValue{Value: []byte("value3"), Metadata: []byte("metadata3"), Version: version.NewHeight(1, 3)}
+	batch.Put("ns1", "key1", vv1.Value, vv1.Version)
+	batch.Put("ns1", "key2", vv2.Value, vv2.Version)
+	batch.PutValAndMetadata("ns1", "key3", vv3.Value, vv3.Metadata, vv3.Version)
+	batch.Put("ns1", "key4", []byte("value4"), version.NewHeight(1, 4))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 4)))
+	batch = statedb.NewUpdateBatch()
+	batch.Delete("ns1", "key4", version.NewHeight(2, 1))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 1)))
+
+	// the keys are retrieved via the bulk get, including the deleted and the non-existing keys
+	vals, err := db.GetStateMultipleKeys("ns1", []string{"key1", "key2", "key3", "key4", "key5"})
+	require.NoError(t, err)
+	require.Equal(t, []*statedb.VersionedValue{vv1, vv2, vv3, nil, nil}, vals)
+
+	keys := []*statedb.CompositeKey{
+		{Namespace: "ns1", Key: "key1"},
+		{Namespace: "ns1", Key: "key2"},
+		{Namespace: "ns1", Key: "key3"},
+		{Namespace: "ns1", Key: "key4"},
+		{Namespace: "ns1", Key: "key5"},
+		{Namespace: "ns2", Key: "key1"},
+	}
+	require.NoError(t, vdb.LoadCommittedStates(keys))
+	for _, k := range keys {
+		expectedVal, err := db.GetState(k.Namespace, k.Key)
+		require.NoError(t, err)
+		val, ok := vdb.getCommittedState(k.Namespace, k.Key)
+		require.True(t, ok)
+		require.Equal(t, expectedVal, val)
+	}
+	val, ok := vdb.getCommittedState("ns1", "key1")
+	require.True(t, ok)
+	require.Equal(t, vv1, val)
+
+	// the loaded values are dropped on the next update
+	batch = statedb.NewUpdateBatch()
+	vv1Updated := &statedb.VersionedValue{Value: []byte("value1-updated"), Version: version.NewHeight(3, 1)}
+	batch.Put("ns1", "key1", vv1Updated.Value, vv1Updated.Version)
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(3, 1)))
+	_, ok = vdb.getCommittedState("ns1", "key1")
+	require.False(t, ok)
+	val, err = db.GetState("ns1", "key1")
+	require.NoError(t, err)
+	require.Equal(t, vv1Updated, val)
+
+	// the loaded values are dropped on ClearCachedVersions
+	require.NoError(t, vdb.LoadCommittedStates(keys))
+	_, ok = vdb.getCommittedState("ns1", "key1")
+	require.True(t, ok)
+	vdb.ClearCachedVersions()
+	_, ok = vdb.getCommittedState("ns1", "key1")
+	require.False(t, ok)
+}
+
 func TestChannelMetadata(t *testing.T) {
 	vdbEnv.init(t, sysNamespaces)
 	defer vdbEnv.cleanup()
@@ -2101,3 +2175,67 @@ func (d *dummyFullScanIter) Next() (*statedb.VersionedKV, error) {
 
 func (d *dummyFullScanIter) Close() {
 }
+
+func TestPartialSavepoint(t *testing.T) {
+	vdbEnv.init(t, nil)
+	defer vdbEnv.cleanup()
+
+	getVDB := func() *VersionedDB {
+		db, err := vdbEnv.DBProvider.GetDBHandle("testpartialsavepoint", nil)
+		require.NoError(t, err)
+		vdb := db.(*VersionedDB)
+		vdb.SetPartialSavepointInterval(2)
+		return vdb
+	}
+	blockUpdates := func(blockNum uint64, val string) *statedb.UpdateBatch {
+		batch := statedb.NewUpdateBatch()
+		for i := uint64(0); i < 5; i++ {
+			batch.Put("ns", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("%s-%d", val, i)), version.NewHeight(blockNum, i))
+		}
+		return batch
+	}
+	verifyVals := func(vdb *VersionedDB, expectedVals ...string) {
+		for i, expectedVal := range expectedVals {
+			vv, err := vdb.GetState("ns", fmt.Sprintf("key%d", i))
+			require.NoError(t, err)
+			require.Equal(t, expectedVal, string(vv.Value))
+		}
+	}
+
+	vdb := getVDB()
+	require.NoError(t, vdb.ApplyUpdates(blockUpdates(1, "block1"), version.NewHeight(1, 4)))
+	verifyVals(vdb, "block1-0", "block1-1", "block1-2", "block1-3", "block1-4")
+	savepoint, partial, err := vdb.getSavepointAndPartial()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(1, 4), savepoint)
+	require.Nil(t, partial)
+
+	// simulate a crash after the updates of the first two transactions of the block 2 are committed. The values
+	// committed are altered so as to verify that these are not committed again during recovery
+	committedPart := statedb.NewUpdateBatch()
+	committedPart.Put("ns", "key0", []byte("committed-0"), version.NewHeight(2, 0))
+	committedPart.Put("ns", "key1", []byte("committed-1"), version.NewHeight(2, 1))
+	require.NoError(t, vdb.commitUpdates(committedPart, nil))
+	require.NoError(t, vdb.recordPartialSavepoint(version.NewHeight(1, 4), version.NewHeight(2, 1)))
+	vdbEnv.closeAndReopen()
+
+	vdb = getVDB()
+	require.Equal(t, version.NewHeight(2, 1), vdb.partialSavepoint)
+	savepoint, err = vdb.GetLatestSavePoint()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(1, 4), savepoint)
+	require.NoError(t, vdb.ApplyUpdates(blockUpdates(2, "block2"), version.NewHeight(2, 4)))
+	verifyVals(vdb, "committed-0", "committed-1", "block2-2", "block2-3", "block2-4")
+	savepoint, partial, err = vdb.getSavepointAndPartial()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(2, 4), savepoint)
+	require.Nil(t, partial)
+	require.Nil(t, vdb.partialSavepoint)
+
+	// the partial savepoint of a block does not affect the commit of a different block
+	require.NoError(t, vdb.recordPartialSavepoint(version.NewHeight(2, 4), version.NewHeight(2, 1)))
+	vdbEnv.closeAndReopen()
+	vdb = getVDB()
+	require.NoError(t, vdb.ApplyUpdates(blockUpdates(3, "block3"), version.NewHeight(3, 4)))
+	verifyVals(vdb, "block3-0", "block3-1", "block3-2", "block3-3", "block3-4")
+}
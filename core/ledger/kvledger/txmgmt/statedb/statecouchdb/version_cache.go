@@ -8,6 +8,7 @@ package statecouchdb
 
 import (
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 )
 
 type (
@@ -54,3 +55,19 @@ func (c *versionsCache) setVerAndRev(ns, key string, ver *version.Height, rev st
 	c.vers[ns][key] = ver
 	c.revs[ns][key] = rev
 }
+
+// statesCache contains the committed values of the keys, including a nil value for a key that does not exist.
+// Used as a local cache during bulk processing of a block.
+type statesCache map[string]map[string]*statedb.VersionedValue
+
+func (c statesCache) get(ns, key string) (*statedb.VersionedValue, bool) {
+	vv, ok := c[ns][key]
+	return vv, ok
+}
+
+func (c statesCache) set(ns, key string, vv *statedb.VersionedValue) {
+	if _, ok := c[ns]; !ok {
+		c[ns] = map[string]*statedb.VersionedValue{}
+	}
+	c[ns][key] = vv
+}
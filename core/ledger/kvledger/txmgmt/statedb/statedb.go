@@ -49,6 +49,9 @@ type VersionedDB interface {
 	// startKey is inclusive
 	// endKey is exclusive
 	// pageSize parameter limits the number of returned results
+	// The bookmark returned by the iterator is the key at which the next page starts, that is, the key following the
+	// returned results or, if the range is exhausted, the endKey. The next page is retrieved by passing the bookmark
+	// as the startKey, which continues the scan even if the keys are added or deleted in the meantime
 	// The returned ResultsIterator contains results of type *VersionedKV
 	GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (QueryResultsIterator, error)
 	// ExecuteQuery executes the given query and returns an iterator that contains results of type *VersionedKV.
@@ -99,6 +102,9 @@ type NamespaceProvider interface {
 type BulkOptimizable interface {
 	LoadCommittedVersions(keys []*CompositeKey) error
 	GetCachedVersion(namespace, key string) (*version.Height, bool)
+	// LoadCommittedStates loads the committed values of the given keys in bulk, which are then served by
+	// GetState until the next update of the db or an invocation of ClearCachedVersions
+	LoadCommittedStates(keys []*CompositeKey) error
 	ClearCachedVersions()
 }
 
@@ -109,6 +115,38 @@ type IndexCapable interface {
 	ProcessIndexesForChaincodeDeploy(namespace string, indexFilesData map[string][]byte) error
 }
 
+// Compactable interface provides additional functions for
+// databases capable of compacting their physical storage
+type Compactable interface {
+	// Compact removes the deleted and overwritten entries from the physical storage.
+	// This does not alter the data visible via the VersionedDB functions
+	Compact() error
+}
+
+// KeyCountEstimator interface provides additional functions for
+// databases capable of cheaply estimating the number of keys they hold
+type KeyCountEstimator interface {
+	// ApproximateKeyCount returns an estimate of the number of keys in the db, without a full scan
+	ApproximateKeyCount() (uint64, error)
+}
+
+// ExistenceChecker interface provides additional functions for
+// db providers capable of checking the existence of a db without creating it
+type ExistenceChecker interface {
+	// Exists returns true if the db for the given id holds any data
+	Exists(id string) (bool, error)
+}
+
+// PartialSavepointCapable interface provides additional functions for
+// databases capable of committing the updates of a large block in parts
+type PartialSavepointCapable interface {
+	// SetPartialSavepointInterval causes the updates of a block to be committed in parts, each covering the given
+	// number of transactions and followed by a partial savepoint at the height of the last transaction of the part.
+	// The updates covered by a partial savepoint are skipped when the block is committed again during the recovery
+	// after a crash. A value of 0 commits the updates of a block together
+	SetPartialSavepointInterval(txs uint64)
+}
+
 // FullScanIterator provides a mean to iterate over entire statedb. The intended use of this iterator
 // is to generate the snapshot files for the statedb
 type FullScanIterator interface {
@@ -261,6 +299,58 @@ func (batch *UpdateBatch) Merge(toMerge *UpdateBatch) {
 	}
 }
 
+// SplitByTxNum splits the updates of the block of the `height` into batches, each holding the updates made by
+// the transactions in a consecutive range of `txsPerBatch` transactions, and returns the batches in the order of
+// the transactions along with the height of the last transaction in the range of each batch. The `height` is
+// the height of the last transaction of the block. The updates whose version is not of a transaction up to
+// the `height`, such as the deletes of the expired private data, are added to the last batch, which is returned
+// with the `height`
+func (batch *UpdateBatch) SplitByTxNum(height *version.Height, txsPerBatch uint64) ([]*UpdateBatch, []*version.Height) {
+	numBatches := height.TxNum/txsPerBatch + 1
+	batches := make([]*UpdateBatch, numBatches)
+	for i := range batches {
+		batches[i] = NewUpdateBatch()
+		batches[i].ContainsPostOrderWrites = batch.ContainsPostOrderWrites
+	}
+	for ns, nsUpdates := range batch.Updates {
+		for key, vv := range nsUpdates.M {
+			i := numBatches - 1
+			if vv.Version != nil && vv.Version.BlockNum == height.BlockNum && vv.Version.TxNum <= height.TxNum {
+				i = vv.Version.TxNum / txsPerBatch
+			}
+			batches[i].Update(ns, key, vv)
+		}
+	}
+
+	var nonEmptyBatches []*UpdateBatch
+	var heights []*version.Height
+	for i, b := range batches {
+		if len(b.Updates) == 0 && uint64(i) != numBatches-1 {
+			continue
+		}
+		nonEmptyBatches = append(nonEmptyBatches, b)
+		heights = append(heights, version.NewHeight(height.BlockNum, (uint64(i)+1)*txsPerBatch-1))
+	}
+	heights[len(heights)-1] = height
+	return nonEmptyBatches, heights
+}
+
+// UpdatesAfter returns a batch that holds the updates in this batch, leaving out the updates whose version is of
+// a transaction in the block of the `height` that is not after the `height`
+func (batch *UpdateBatch) UpdatesAfter(height *version.Height) *UpdateBatch {
+	remaining := NewUpdateBatch()
+	remaining.ContainsPostOrderWrites = batch.ContainsPostOrderWrites
+	for ns, nsUpdates := range batch.Updates {
+		for key, vv := range nsUpdates.M {
+			if vv.Version != nil && vv.Version.BlockNum == height.BlockNum && vv.Version.TxNum <= height.TxNum {
+				continue
+			}
+			remaining.Update(ns, key, vv)
+		}
+	}
+	return remaining
+}
+
 func (batch *UpdateBatch) getOrCreateNsUpdates(ns string) *nsUpdates {
 	nsUpdates := batch.Updates[ns]
 	if nsUpdates == nil {
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package statedb
 
 import (
+	"math"
 	"sort"
 	"testing"
 
@@ -174,3 +175,56 @@ func TestMergeUpdateBatch(t *testing.T) {
 	expectedBatch.Put("ns2", "key6", []byte("batch2_value6"), version.NewHeight(8, 8))
 	require.Equal(t, expectedBatch, batch1)
 }
+
+func TestSplitByTxNum(t *testing.T) {
+	batch := NewUpdateBatch()
+	batch.ContainsPostOrderWrites = true
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(5, 0))
+	batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(5, 2))
+	batch.Delete("ns2", "key3", version.NewHeight(5, 3))
+	batch.Put("ns2", "key4", []byte("value4"), version.NewHeight(5, 9))
+	batch.Delete("ns2", "key5", version.NewHeight(5, math.MaxUint64))
+	batch.Put("ns3", "key6", []byte("value6"), version.NewHeight(4, 1))
+
+	batches, heights := batch.SplitByTxNum(version.NewHeight(5, 9), 3)
+
+	expectedBatch1 := NewUpdateBatch()
+	expectedBatch1.ContainsPostOrderWrites = true
+	expectedBatch1.Put("ns1", "key1", []byte("value1"), version.NewHeight(5, 0))
+	expectedBatch1.Put("ns1", "key2", []byte("value2"), version.NewHeight(5, 2))
+	expectedBatch2 := NewUpdateBatch()
+	expectedBatch2.ContainsPostOrderWrites = true
+	expectedBatch2.Delete("ns2", "key3", version.NewHeight(5, 3))
+	expectedBatch3 := NewUpdateBatch()
+	expectedBatch3.ContainsPostOrderWrites = true
+	expectedBatch3.Put("ns2", "key4", []byte("value4"), version.NewHeight(5, 9))
+	expectedBatch3.Delete("ns2", "key5", version.NewHeight(5, math.MaxUint64))
+	expectedBatch3.Put("ns3", "key6", []byte("value6"), version.NewHeight(4, 1))
+	// the range of the transactions 6 to 8 does not hold any update
+	require.Equal(t, []*UpdateBatch{expectedBatch1, expectedBatch2, expectedBatch3}, batches)
+	require.Equal(t, []*version.Height{version.NewHeight(5, 2), version.NewHeight(5, 5), version.NewHeight(5, 9)}, heights)
+
+	batches, heights = batch.SplitByTxNum(version.NewHeight(5, 9), 10)
+	require.Equal(t, []*UpdateBatch{batch}, batches)
+	require.Equal(t, []*version.Height{version.NewHeight(5, 9)}, heights)
+
+	batches, heights = NewUpdateBatch().SplitByTxNum(version.NewHeight(5, 9), 3)
+	require.Equal(t, []*UpdateBatch{NewUpdateBatch()}, batches)
+	require.Equal(t, []*version.Height{version.NewHeight(5, 9)}, heights)
+}
+
+func TestUpdatesAfter(t *testing.T) {
+	batch := NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(5, 0))
+	batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(5, 2))
+	batch.Delete("ns2", "key3", version.NewHeight(5, 3))
+	batch.Delete("ns2", "key4", version.NewHeight(5, math.MaxUint64))
+	batch.Put("ns3", "key5", []byte("value5"), version.NewHeight(4, 1))
+
+	expectedBatch := NewUpdateBatch()
+	expectedBatch.Delete("ns2", "key3", version.NewHeight(5, 3))
+	expectedBatch.Delete("ns2", "key4", version.NewHeight(5, math.MaxUint64))
+	expectedBatch.Put("ns3", "key5", []byte("value5"), version.NewHeight(4, 1))
+	require.Equal(t, expectedBatch, batch.UpdatesAfter(version.NewHeight(5, 2)))
+	require.Equal(t, batch, batch.UpdatesAfter(version.NewHeight(4, 0)))
+}
@@ -0,0 +1,216 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateleveldb
+
+import (
+	"encoding/hex"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/ledger/dataformat"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/internal/fileutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// configDBName is the name of the logical db, in the main leveldb, that holds the configuration
+	// the statedb was created with. _ is used as prefix because this is not allowed in a channelname
+	configDBName = "_stateleveldb"
+	// namespacesDirName is the dir, under the main leveldb dir, that holds the leveldb for each namespace
+	namespacesDirName = "namespaces"
+	// namespaceDirPrefix is prefixed to the hex encoded namespace for naming the leveldb dir of a namespace.
+	// The namespace is hex encoded because the namespaces of the pvtdata hashes contain characters such as '$'
+	namespaceDirPrefix = "ns-"
+)
+
+var perNamespacePartitioningKey = []byte("perNamespacePartitioning")
+
+// checkPartitioningMode verifies that the statedb is opened with the same partitioning mode it was created with.
+// The statedb created by the earlier versions does not contain the mode and such a statedb holds all the data
+// in the main leveldb. The partitioning mode cannot be changed on an existing statedb because the data would
+// not be found at the expected place. For changing the mode, the statedb needs to be rebuilt from the blockstore
+// (e.g., via "peer node rebuild-dbs")
+func checkPartitioningMode(dbProvider *leveldbhelper.Provider, dbPath string, dbWasEmpty, perNamespacePartitioning bool) error {
+	configDB := dbProvider.GetDBHandle(configDBName)
+	val, err := configDB.Get(perNamespacePartitioningKey)
+	if err != nil {
+		return err
+	}
+
+	createdWithPartitioning := false
+	switch {
+	case val != nil:
+		if createdWithPartitioning, err = strconv.ParseBool(string(val)); err != nil {
+			return errors.Wrapf(err, "error while decoding the partitioning mode of the statedb at [%s]", dbPath)
+		}
+	case dbWasEmpty:
+		createdWithPartitioning = perNamespacePartitioning
+	}
+
+	if createdWithPartitioning != perNamespacePartitioning {
+		return errors.Errorf(
+			"the statedb at [%s] was created with per-namespace partitioning set to [%t], which differs from the configured value [%t]; "+
+				"rebuild the statedb in order to change the partitioning mode",
+			dbPath, createdWithPartitioning, perNamespacePartitioning,
+		)
+	}
+	if val == nil {
+		return configDB.Put(perNamespacePartitioningKey, []byte(strconv.FormatBool(createdWithPartitioning)), true)
+	}
+	return nil
+}
+
+// namespacePartitions manages a separate leveldb for each namespace. Each of these leveldbs is
+// shared across channels in the same manner as the main leveldb
+type namespacePartitions struct {
+	dir       string
+	encryptor leveldbhelper.Encryptor
+	tuning    *leveldbhelper.Tuning
+	openRetry leveldbhelper.OpenRetry
+
+	mux       sync.RWMutex
+	providers map[string]*leveldbhelper.Provider
+}
+
+// openNamespacePartitions opens the leveldbs of the namespaces that exist under the given dir
+func openNamespacePartitions(dir string, encryptor leveldbhelper.Encryptor, tuning *leveldbhelper.Tuning, openRetry leveldbhelper.OpenRetry) (*namespacePartitions, error) {
+	p := &namespacePartitions{
+		dir:       dir,
+		encryptor: encryptor,
+		tuning:    tuning,
+		openRetry: openRetry,
+		providers: map[string]*leveldbhelper.Provider{},
+	}
+	exists, err := fileutil.DirExists(dir)
+	if err != nil || !exists {
+		return p, err
+	}
+	subdirs, err := fileutil.ListSubdirs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, subdir := range subdirs {
+		if !strings.HasPrefix(subdir, namespaceDirPrefix) {
+			continue
+		}
+		ns, err := hex.DecodeString(strings.TrimPrefix(subdir, namespaceDirPrefix))
+		if err != nil {
+			p.close()
+			return nil, errors.Wrapf(err, "error while decoding the namespace from the dir [%s]", subdir)
+		}
+		if _, err := p.getOrCreate(string(ns)); err != nil {
+			p.close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// get returns the leveldb of the namespace or nil if the namespace does not have a leveldb yet
+func (p *namespacePartitions) get(ns string) *leveldbhelper.Provider {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	return p.providers[ns]
+}
+
+// getOrCreate returns the leveldb of the namespace and creates it if it does not exist
+func (p *namespacePartitions) getOrCreate(ns string) (*leveldbhelper.Provider, error) {
+	if provider := p.get(ns); provider != nil {
+		return provider, nil
+	}
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	if provider := p.providers[ns]; provider != nil {
+		return provider, nil
+	}
+	provider, err := leveldbhelper.NewProvider(
+		&leveldbhelper.Conf{
+			DBPath:            filepath.Join(p.dir, namespaceDirPrefix+hex.EncodeToString([]byte(ns))),
+			ExpectedFormat:    dataformat.CurrentFormat,
+			OpenRetries:       p.openRetry.Retries,
+			OpenRetryInterval: p.openRetry.Interval,
+			Encryptor:         p.encryptor,
+			Tuning:            p.tuning,
+		},
+	)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "error while opening the leveldb for namespace [%s]", ns)
+	}
+	p.providers[ns] = provider
+	return provider, nil
+}
+
+// namespaces returns the namespaces that have a leveldb, in lexical order
+func (p *namespacePartitions) namespaces() []string {
+	p.mux.RLock()
+	defer p.mux.RUnlock()
+	namespaces := make([]string, 0, len(p.providers))
+	for ns := range p.providers {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+func (p *namespacePartitions) drop(dbName string) error {
+	for _, ns := range p.namespaces() {
+		if err := p.get(ns).Drop(dbName); err != nil {
+			return errors.WithMessagef(err, "error while dropping the data from the leveldb for namespace [%s]", ns)
+		}
+	}
+	return nil
+}
+
+func (p *namespacePartitions) close() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for _, provider := range p.providers {
+		provider.Close()
+	}
+}
+
+// partitionedFullDBScanner returns the key-values from the leveldbs of the namespaces, one namespace
+// after the other. As the namespaces are visited in lexical order, the overall results are in the
+// lexical order of <Namespace, key>, same as the fullDBScanner on a single leveldb
+type partitionedFullDBScanner struct {
+	dbs     []*leveldbhelper.DBHandle
+	current *fullDBScanner
+	toSkip  func(namespace string) bool
+}
+
+func (s *partitionedFullDBScanner) Next() (*statedb.VersionedKV, error) {
+	for {
+		if s.current == nil {
+			if len(s.dbs) == 0 {
+				return nil, nil
+			}
+			scanner, err := newFullDBScanner(s.dbs[0], s.toSkip)
+			if err != nil {
+				return nil, err
+			}
+			s.current, s.dbs = scanner, s.dbs[1:]
+		}
+		versionedKV, err := s.current.Next()
+		if versionedKV != nil || err != nil {
+			return versionedKV, err
+		}
+		s.current.Close()
+		s.current = nil
+	}
+}
+
+func (s *partitionedFullDBScanner) Close() {
+	if s == nil {
+		return
+	}
+	s.current.Close()
+}
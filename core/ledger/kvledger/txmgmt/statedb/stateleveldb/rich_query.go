@@ -0,0 +1,42 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateleveldb
+
+import (
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/jsonquery"
+)
+
+// ExecuteQuery implements method in VersionedDB interface. The query is a CouchDB style rich query, restricted to
+// the subset documented in the package jsonquery. No index is maintained on the JSON values, so the query is
+// evaluated by scanning all the keys of the namespace and the results of a sorted query are collected and sorted
+// in memory, up to the configured total query limit, beyond which a sorted query fails. Hence, the rich queries on
+// leveldb are meant for the namespaces with a modest number of keys, and the indexes packaged with a chaincode for
+// CouchDB are not used
+func (vdb *versionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
+	q, err := jsonquery.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return jsonquery.Execute(q, vdb.rangeScanFunc(namespace), vdb.totalQueryLimit)
+}
+
+// ExecuteQueryWithPagination implements method in VersionedDB interface. The pagination semantics are described
+// in the function jsonquery.ExecuteWithPagination
+func (vdb *versionedDB) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (statedb.QueryResultsIterator, error) {
+	q, err := jsonquery.Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return jsonquery.ExecuteWithPagination(q, vdb.rangeScanFunc(namespace), bookmark, pageSize, vdb.totalQueryLimit)
+}
+
+func (vdb *versionedDB) rangeScanFunc(namespace string) jsonquery.RangeScanFunc {
+	return func(startKey string) (statedb.ResultsIterator, error) {
+		return vdb.GetStateRangeScanIterator(namespace, startKey, "")
+	}
+}
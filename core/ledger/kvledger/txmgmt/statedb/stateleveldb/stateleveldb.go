@@ -8,6 +8,8 @@ package stateleveldb
 
 import (
 	"bytes"
+	"path/filepath"
+	"sort"
 
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/dataformat"
@@ -26,31 +28,69 @@ var (
 	nsKeySep               = []byte{0x00}
 	lastKeyIndicator       = byte(0x01)
 	savePointKey           = []byte{'s'}
+	partialSavepointKey    = []byte{'p'}
 	maxDataImportBatchSize = 4 * 1024 * 1024
 )
 
 // VersionedDBProvider implements interface VersionedDBProvider
 type VersionedDBProvider struct {
 	dbProvider *leveldbhelper.Provider
-}
-
-// NewVersionedDBProvider instantiates VersionedDBProvider
-func NewVersionedDBProvider(dbPath string) (*VersionedDBProvider, error) {
-	logger.Debugf("constructing VersionedDBProvider dbPath=%s", dbPath)
+	// partitions is nil unless the per-namespace partitioning is enabled, in which case the data of each namespace
+	// is kept in a separate leveldb and the main leveldb holds only the savepoints
+	partitions *namespacePartitions
+	// totalQueryLimit, if greater than zero, bounds the number of results that a sorted rich query collects
+	totalQueryLimit int
+}
+
+// NewVersionedDBProvider instantiates VersionedDBProvider. If `perNamespacePartitioning` is true, the data of
+// each namespace is kept in a separate leveldb under the dir `dbPath`/namespaces. The partitioning mode is recorded
+// when the statedb is created and opening an existing statedb with a different mode results in an error.
+// The `tuning`, if not nil, and the `openRetry` apply to the main leveldb as well as to the leveldbs of the namespaces.
+// The `totalQueryLimit`, if greater than zero, bounds the number of results that a sorted rich query collects in memory
+func NewVersionedDBProvider(dbPath string, perNamespacePartitioning bool, encryptor leveldbhelper.Encryptor, tuning *leveldbhelper.Tuning, openRetry leveldbhelper.OpenRetry, totalQueryLimit int) (*VersionedDBProvider, error) {
+	logger.Debugf("constructing VersionedDBProvider dbPath=%s, perNamespacePartitioning=%t", dbPath, perNamespacePartitioning)
+	formatInfo, err := leveldbhelper.RetrieveDataFormatInfo(dbPath, openRetry)
+	if err != nil {
+		return nil, err
+	}
 	dbProvider, err := leveldbhelper.NewProvider(
 		&leveldbhelper.Conf{
-			DBPath:         dbPath,
-			ExpectedFormat: dataformat.CurrentFormat,
+			DBPath:            dbPath,
+			ExpectedFormat:    dataformat.CurrentFormat,
+			OpenRetries:       openRetry.Retries,
+			OpenRetryInterval: openRetry.Interval,
+			Encryptor:         encryptor,
+			Tuning:            tuning,
 		})
 	if err != nil {
 		return nil, err
 	}
-	return &VersionedDBProvider{dbProvider}, nil
+	if err := checkPartitioningMode(dbProvider, dbPath, formatInfo.IsDBEmpty, perNamespacePartitioning); err != nil {
+		dbProvider.Close()
+		return nil, err
+	}
+	provider := &VersionedDBProvider{dbProvider: dbProvider, totalQueryLimit: totalQueryLimit}
+	if perNamespacePartitioning {
+		if provider.partitions, err = openNamespacePartitions(filepath.Join(dbPath, namespacesDirName), encryptor, tuning, openRetry); err != nil {
+			dbProvider.Close()
+			return nil, err
+		}
+	}
+	return provider, nil
 }
 
 // GetDBHandle gets the handle to a named database
 func (provider *VersionedDBProvider) GetDBHandle(dbName string, namespaceProvider statedb.NamespaceProvider) (statedb.VersionedDB, error) {
-	return newVersionedDB(provider.dbProvider.GetDBHandle(dbName), dbName), nil
+	return provider.newVersionedDB(dbName), nil
+}
+
+func (provider *VersionedDBProvider) newVersionedDB(dbName string) *versionedDB {
+	return &versionedDB{
+		db:              provider.dbProvider.GetDBHandle(dbName),
+		dbName:          dbName,
+		partitions:      provider.partitions,
+		totalQueryLimit: provider.totalQueryLimit,
+	}
 }
 
 // ImportFromSnapshot loads the public state and pvtdata hashes from the snapshot files previously generated
@@ -59,7 +99,7 @@ func (provider *VersionedDBProvider) ImportFromSnapshot(
 	savepoint *version.Height,
 	itr statedb.FullScanIterator,
 ) error {
-	vdb := newVersionedDB(provider.dbProvider.GetDBHandle(dbName), dbName)
+	vdb := provider.newVersionedDB(dbName)
 	return vdb.importState(itr, savepoint)
 }
 
@@ -70,24 +110,75 @@ func (provider *VersionedDBProvider) BytesKeySupported() bool {
 
 // Close closes the underlying db
 func (provider *VersionedDBProvider) Close() {
+	if provider.partitions != nil {
+		provider.partitions.close()
+	}
 	provider.dbProvider.Close()
 }
 
 // Drop drops channel-specific data from the state leveldb.
 // It is not an error if a database does not exist.
 func (provider *VersionedDBProvider) Drop(dbName string) error {
+	if provider.partitions != nil {
+		if err := provider.partitions.drop(dbName); err != nil {
+			return err
+		}
+	}
 	return provider.dbProvider.Drop(dbName)
 }
 
+// Exists implements method in interface statedb.ExistenceChecker.
+// The channel-specific data is looked up without creating any new leveldb
+func (provider *VersionedDBProvider) Exists(dbName string) (bool, error) {
+	empty, err := provider.newVersionedDB(dbName).IsEmpty()
+	return !empty, err
+}
+
 // VersionedDB implements VersionedDB interface
 type versionedDB struct {
-	db     *leveldbhelper.DBHandle
-	dbName string
+	db         *leveldbhelper.DBHandle
+	dbName     string
+	partitions *namespacePartitions
+	// totalQueryLimit, if greater than zero, bounds the number of results that a sorted rich query collects
+	totalQueryLimit int
+	// partialSavepointInterval, if non-zero, is the number of transactions of a block after which a partial
+	// savepoint is recorded during the commit of the block. This applies only to the per-namespace partitioning,
+	// as otherwise the updates of a block are written atomically
+	partialSavepointInterval uint64
+}
+
+// dataDB returns the db that holds the data of the given namespace. Without the per-namespace partitioning,
+// this is the same db that holds the savepoint. A nil db is returned if the namespace does not have a
+// partition yet, unless `create` is true
+func (vdb *versionedDB) dataDB(namespace string, create bool) (*leveldbhelper.DBHandle, error) {
+	if vdb.partitions == nil {
+		return vdb.db, nil
+	}
+	if !create {
+		provider := vdb.partitions.get(namespace)
+		if provider == nil {
+			return nil, nil
+		}
+		return provider.GetDBHandle(vdb.dbName), nil
+	}
+	provider, err := vdb.partitions.getOrCreate(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetDBHandle(vdb.dbName), nil
 }
 
-// newVersionedDB constructs an instance of VersionedDB
-func newVersionedDB(db *leveldbhelper.DBHandle, dbName string) *versionedDB {
-	return &versionedDB{db, dbName}
+// dataDBs returns the dbs that hold the data of the namespaces, in the lexical order of the namespaces
+func (vdb *versionedDB) dataDBs() []*leveldbhelper.DBHandle {
+	if vdb.partitions == nil {
+		return []*leveldbhelper.DBHandle{vdb.db}
+	}
+	namespaces := vdb.partitions.namespaces()
+	dbs := make([]*leveldbhelper.DBHandle, len(namespaces))
+	for i, ns := range namespaces {
+		dbs[i] = vdb.partitions.get(ns).GetDBHandle(vdb.dbName)
+	}
+	return dbs
 }
 
 // Open implements method in VersionedDB interface
@@ -114,7 +205,11 @@ func (vdb *versionedDB) BytesKeySupported() bool {
 // GetState implements method in VersionedDB interface
 func (vdb *versionedDB) GetState(namespace string, key string) (*statedb.VersionedValue, error) {
 	logger.Debugf("GetState(). ns=%s, key=%s", namespace, key)
-	dbVal, err := vdb.db.Get(encodeDataKey(namespace, key))
+	db, err := vdb.dataDB(namespace, false)
+	if err != nil || db == nil {
+		return nil, err
+	}
+	dbVal, err := db.Get(encodeDataKey(namespace, key))
 	if err != nil {
 		return nil, err
 	}
@@ -126,25 +221,43 @@ func (vdb *versionedDB) GetState(namespace string, key string) (*statedb.Version
 
 // GetVersion implements method in VersionedDB interface
 func (vdb *versionedDB) GetVersion(namespace string, key string) (*version.Height, error) {
-	versionedValue, err := vdb.GetState(namespace, key)
-	if err != nil {
+	db, err := vdb.dataDB(namespace, false)
+	if err != nil || db == nil {
 		return nil, err
 	}
-	if versionedValue == nil {
-		return nil, nil
+	dbVal, err := db.Get(encodeDataKey(namespace, key))
+	if err != nil || dbVal == nil {
+		return nil, err
 	}
-	return versionedValue.Version, nil
+	return decodeVersion(dbVal)
 }
 
 // GetStateMultipleKeys implements method in VersionedDB interface
+// All the keys are read from a single snapshot of the db
 func (vdb *versionedDB) GetStateMultipleKeys(namespace string, keys []string) ([]*statedb.VersionedValue, error) {
 	vals := make([]*statedb.VersionedValue, len(keys))
+	db, err := vdb.dataDB(namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return vals, nil
+	}
+	dataKeys := make([][]byte, len(keys))
 	for i, key := range keys {
-		val, err := vdb.GetState(namespace, key)
-		if err != nil {
+		dataKeys[i] = encodeDataKey(namespace, key)
+	}
+	dbVals, err := db.GetMultiple(dataKeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, dbVal := range dbVals {
+		if dbVal == nil {
+			continue
+		}
+		if vals[i], err = decodeValue(dbVal); err != nil {
 			return nil, err
 		}
-		vals[i] = val
 	}
 	return vals, nil
 }
@@ -164,42 +277,30 @@ func (vdb *versionedDB) GetStateRangeScanIteratorWithPagination(namespace string
 	if endKey == "" {
 		dataEndKey[len(dataEndKey)-1] = lastKeyIndicator
 	}
-	dbItr, err := vdb.db.GetIterator(dataStartKey, dataEndKey)
+	db, err := vdb.dataDB(namespace, false)
 	if err != nil {
 		return nil, err
 	}
-	return newKVScanner(namespace, dbItr, pageSize), nil
-}
-
-// ExecuteQuery implements method in VersionedDB interface
-func (vdb *versionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
-	return nil, errors.New("ExecuteQuery not supported for leveldb")
-}
-
-// ExecuteQueryWithPagination implements method in VersionedDB interface
-func (vdb *versionedDB) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (statedb.QueryResultsIterator, error) {
-	return nil, errors.New("ExecuteQueryWithMetadata not supported for leveldb")
+	if db == nil {
+		return newKVScanner(namespace, iterator.NewEmptyIterator(nil), pageSize, endKey), nil
+	}
+	dbItr, err := db.GetIterator(dataStartKey, dataEndKey)
+	if err != nil {
+		return nil, err
+	}
+	return newKVScanner(namespace, dbItr, pageSize, endKey), nil
 }
 
 // ApplyUpdates implements method in VersionedDB interface
 func (vdb *versionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
+	if vdb.partitions != nil {
+		return vdb.applyUpdatesToPartitions(batch, height)
+	}
 	dbBatch := vdb.db.NewUpdateBatch()
 	namespaces := batch.GetUpdatedNamespaces()
 	for _, ns := range namespaces {
-		updates := batch.GetUpdates(ns)
-		for k, vv := range updates {
-			dataKey := encodeDataKey(ns, k)
-			logger.Debugf("Channel [%s]: Applying key(string)=[%s] key(bytes)=[%#v]", vdb.dbName, string(dataKey), dataKey)
-
-			if vv.Value == nil {
-				dbBatch.Delete(dataKey)
-			} else {
-				encodedVal, err := encodeValue(vv)
-				if err != nil {
-					return err
-				}
-				dbBatch.Put(dataKey, encodedVal)
-			}
+		if err := vdb.addUpdatesToBatch(dbBatch, ns, batch.GetUpdates(ns)); err != nil {
+			return err
 		}
 	}
 	// Record a savepoint at a given height
@@ -213,6 +314,104 @@ func (vdb *versionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version
 	return vdb.db.WriteBatch(dbBatch, true)
 }
 
+// applyUpdatesToPartitions writes the updates of each namespace to the leveldb of the namespace and then
+// writes the savepoint to the main leveldb. As a write batch cannot span multiple leveldbs, the savepoint
+// is written only after the batches of all the namespaces are synced to the disk. In the event of a crash in
+// between, the savepoint continues to point to the previous block and the block is committed again during
+// recovery, which simply rewrites the same values to the namespaces. If the partialSavepointInterval is set,
+// the updates of a large block are written in parts, each followed by a partial savepoint, and the updates covered
+// by the partial savepoint are not written again during recovery
+func (vdb *versionedDB) applyUpdatesToPartitions(batch *statedb.UpdateBatch, height *version.Height) error {
+	if height == nil {
+		return vdb.writeToPartitions(batch)
+	}
+	partialSavepoint, err := vdb.getPartialSavepoint()
+	if err != nil {
+		return err
+	}
+	if partialSavepoint != nil && partialSavepoint.BlockNum == height.BlockNum {
+		logger.Infof("Channel [%s]: skipping the updates of block [%d] up to the transaction [%d] that are already committed",
+			vdb.dbName, height.BlockNum, partialSavepoint.TxNum)
+		batch = batch.UpdatesAfter(partialSavepoint)
+	}
+
+	if vdb.partialSavepointInterval == 0 || height.TxNum < vdb.partialSavepointInterval {
+		if err := vdb.writeToPartitions(batch); err != nil {
+			return err
+		}
+	} else {
+		batches, heights := batch.SplitByTxNum(height, vdb.partialSavepointInterval)
+		for i, b := range batches {
+			if err := vdb.writeToPartitions(b); err != nil {
+				return err
+			}
+			if i == len(batches)-1 {
+				break
+			}
+			if err := vdb.db.Put(partialSavepointKey, heights[i].ToBytes(), true); err != nil {
+				return err
+			}
+		}
+	}
+	dbBatch := vdb.db.NewUpdateBatch()
+	dbBatch.Put(savePointKey, height.ToBytes())
+	dbBatch.Delete(partialSavepointKey)
+	return vdb.db.WriteBatch(dbBatch, true)
+}
+
+func (vdb *versionedDB) writeToPartitions(batch *statedb.UpdateBatch) error {
+	namespaces := batch.GetUpdatedNamespaces()
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		db, err := vdb.dataDB(ns, true)
+		if err != nil {
+			return err
+		}
+		dbBatch := db.NewUpdateBatch()
+		if err := vdb.addUpdatesToBatch(dbBatch, ns, batch.GetUpdates(ns)); err != nil {
+			return err
+		}
+		if err := db.WriteBatch(dbBatch, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetPartialSavepointInterval implements method in statedb.PartialSavepointCapable interface
+func (vdb *versionedDB) SetPartialSavepointInterval(txs uint64) {
+	vdb.partialSavepointInterval = txs
+}
+
+// getPartialSavepoint returns the height up to which the updates of the block next to the savepoint are written,
+// if the commit of the block was interrupted
+func (vdb *versionedDB) getPartialSavepoint() (*version.Height, error) {
+	heightBytes, err := vdb.db.Get(partialSavepointKey)
+	if err != nil || heightBytes == nil {
+		return nil, err
+	}
+	height, _, err := version.NewHeightFromBytes(heightBytes)
+	return height, err
+}
+
+func (vdb *versionedDB) addUpdatesToBatch(dbBatch *leveldbhelper.UpdateBatch, ns string, updates map[string]*statedb.VersionedValue) error {
+	for k, vv := range updates {
+		dataKey := encodeDataKey(ns, k)
+		logger.Debugf("Channel [%s]: Applying key(string)=[%s] key(bytes)=[%#v]", vdb.dbName, string(dataKey), dataKey)
+
+		if vv.Value == nil {
+			dbBatch.Delete(dataKey)
+		} else {
+			encodedVal, err := encodeValue(vv)
+			if err != nil {
+				return err
+			}
+			dbBatch.Put(dataKey, encodedVal)
+		}
+	}
+	return nil
+}
+
 // GetLatestSavePoint implements method in VersionedDB interface
 func (vdb *versionedDB) GetLatestSavePoint() (*version.Height, error) {
 	versionBytes, err := vdb.db.Get(savePointKey)
@@ -235,6 +434,12 @@ func (vdb *versionedDB) GetLatestSavePoint() (*version.Height, error) {
 // to skip one or more namespaces from the returned results. The intended use of this iterator
 // is to generate the snapshot files for the stateleveldb
 func (vdb *versionedDB) GetFullScanIterator(skipNamespace func(string) bool) (statedb.FullScanIterator, error) {
+	if vdb.partitions != nil {
+		return &partitionedFullDBScanner{
+			dbs:    vdb.dataDBs(),
+			toSkip: skipNamespace,
+		}, nil
+	}
 	return newFullDBScanner(vdb.db, skipNamespace)
 }
 
@@ -245,7 +450,8 @@ func (vdb *versionedDB) importState(itr statedb.FullScanIterator, savepoint *ver
 	if itr == nil {
 		return vdb.db.Put(savePointKey, savepoint.ToBytes(), true)
 	}
-	dbBatch := vdb.db.NewUpdateBatch()
+	db := vdb.db
+	dbBatch := db.NewUpdateBatch()
 	batchSize := 0
 	for {
 		versionedKV, err := itr.Next()
@@ -255,6 +461,19 @@ func (vdb *versionedDB) importState(itr statedb.FullScanIterator, savepoint *ver
 		if versionedKV == nil {
 			break
 		}
+		nsDB, err := vdb.dataDB(versionedKV.Namespace, true)
+		if err != nil {
+			return err
+		}
+		if nsDB != db {
+			// the snapshot iterator returns the namespaces one after the other and hence, with the
+			// per-namespace partitioning, the batch is flushed only once per namespace
+			if err := db.WriteBatch(dbBatch, true); err != nil {
+				return err
+			}
+			batchSize = 0
+			db, dbBatch = nsDB, nsDB.NewUpdateBatch()
+		}
 		dbKey := encodeDataKey(versionedKV.Namespace, versionedKV.Key)
 		dbValue, err := encodeValue(versionedKV.VersionedValue)
 		if err != nil {
@@ -263,20 +482,65 @@ func (vdb *versionedDB) importState(itr statedb.FullScanIterator, savepoint *ver
 		batchSize += len(dbKey) + len(dbValue)
 		dbBatch.Put(dbKey, dbValue)
 		if batchSize >= maxDataImportBatchSize {
-			if err := vdb.db.WriteBatch(dbBatch, true); err != nil {
+			if err := db.WriteBatch(dbBatch, true); err != nil {
 				return err
 			}
 			batchSize = 0
 			dbBatch.Reset()
 		}
 	}
+	if db != vdb.db {
+		if err := db.WriteBatch(dbBatch, true); err != nil {
+			return err
+		}
+		return vdb.db.Put(savePointKey, savepoint.ToBytes(), true)
+	}
 	dbBatch.Put(savePointKey, savepoint.ToBytes())
 	return vdb.db.WriteBatch(dbBatch, true)
 }
 
+// Compact implements method in interface statedb.Compactable
+func (vdb *versionedDB) Compact() error {
+	if err := vdb.db.Compact(); err != nil {
+		return err
+	}
+	if vdb.partitions == nil {
+		return nil
+	}
+	for _, db := range vdb.dataDBs() {
+		if err := db.Compact(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApproximateKeyCount implements method in interface statedb.KeyCountEstimator.
+// Only the keys that hold the data are counted and the savepoint is excluded
+func (vdb *versionedDB) ApproximateKeyCount() (uint64, error) {
+	count := uint64(0)
+	for _, db := range vdb.dataDBs() {
+		c, err := db.ApproximateKeyCount(dataKeyPrefix, dataKeyStopper)
+		if err != nil {
+			return 0, err
+		}
+		count += c
+	}
+	return count, nil
+}
+
 // IsEmpty return true if the statedb does not have any content
 func (vdb *versionedDB) IsEmpty() (bool, error) {
-	return vdb.db.IsEmpty()
+	empty, err := vdb.db.IsEmpty()
+	if err != nil || !empty || vdb.partitions == nil {
+		return empty, err
+	}
+	for _, db := range vdb.dataDBs() {
+		if empty, err = db.IsEmpty(); err != nil || !empty {
+			return empty, err
+		}
+	}
+	return true, nil
 }
 
 func encodeDataKey(ns, key string) []byte {
@@ -300,10 +564,12 @@ type kvScanner struct {
 	dbItr                iterator.Iterator
 	requestedLimit       int32
 	totalRecordsReturned int32
+	// endKey is returned as the bookmark once the range is exhausted, as with CouchDB
+	endKey string
 }
 
-func newKVScanner(namespace string, dbItr iterator.Iterator, requestedLimit int32) *kvScanner {
-	return &kvScanner{namespace, dbItr, requestedLimit, 0}
+func newKVScanner(namespace string, dbItr iterator.Iterator, requestedLimit int32, endKey string) *kvScanner {
+	return &kvScanner{namespace, dbItr, requestedLimit, 0, endKey}
 }
 
 func (scanner *kvScanner) Next() (*statedb.VersionedKV, error) {
@@ -338,8 +604,11 @@ func (scanner *kvScanner) Close() {
 	scanner.dbItr.Release()
 }
 
+// GetBookmarkAndClose returns the key at which the next page starts, that is, the next key in the range or,
+// if the range is exhausted, the end key of the range. Passing the bookmark back as the start key of the range
+// continues the scan after the keys already returned, even if the keys are added or deleted in the meantime
 func (scanner *kvScanner) GetBookmarkAndClose() string {
-	retval := ""
+	retval := scanner.endKey
 	if scanner.dbItr.Next() {
 		dbKey := scanner.dbItr.Key()
 		_, key := decodeDataKey(dbKey)
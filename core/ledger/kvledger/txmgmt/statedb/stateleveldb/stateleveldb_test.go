@@ -7,9 +7,14 @@ SPDX-License-Identifier: Apache-2.0
 package stateleveldb
 
 import (
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/commontests"
@@ -61,27 +66,148 @@ func testDataKeyEncoding(t *testing.T, dbName string, ns string, key string) {
 	require.Equal(t, key, key1)
 }
 
-// TestQueryOnLevelDB tests queries on levelDB.
-func TestQueryOnLevelDB(t *testing.T) {
+func TestQuery(t *testing.T) {
 	env := NewTestVDBEnv(t)
 	defer env.Cleanup()
-	db, err := env.DBProvider.GetDBHandle("testquery", nil)
+	commontests.TestQuery(t, env.DBProvider)
+}
+
+func TestQueryWithPaginationAndSort(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	db, err := env.DBProvider.GetDBHandle("testquerywithpaginationandsort", nil)
 	require.NoError(t, err)
-	require.NoError(t, db.Open())
-	defer db.Close()
+
 	batch := statedb.NewUpdateBatch()
-	jsonValue1 := `{"asset_name": "marble1","color": "blue","size": 1,"owner": "tom"}`
-	batch.Put("ns1", "key1", []byte(jsonValue1), version.NewHeight(1, 1))
-
-	savePoint := version.NewHeight(2, 22)
-	require.NoError(t, db.ApplyUpdates(batch, savePoint))
-
-	// query for owner=jerry, use namespace "ns1"
-	// As queries are not supported in levelDB, call to ExecuteQuery()
-	// should return a error message
-	itr, err := db.ExecuteQuery("ns1", `{"selector":{"owner":"jerry"}}`)
-	require.Error(t, err, "ExecuteQuery not supported for leveldb")
-	require.Nil(t, itr)
+	sizes := []int{5, 3, 8, 1, 9, 2, 7}
+	for i, size := range sizes {
+		value := fmt.Sprintf(`{"asset_name": "marble%d", "color": "blue", "size": %d, "owner": {"name": "tom"}}`, i, size)
+		batch.Put("ns1", fmt.Sprintf("key%d", i), []byte(value), version.NewHeight(1, uint64(i)))
+	}
+	batch.Put("ns1", "key-red", []byte(`{"asset_name": "marble-red", "color": "red", "size": 4}`), version.NewHeight(1, 10))
+	batch.Put("ns1", "key-not-json", []byte("not json"), version.NewHeight(1, 11))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 11)))
+
+	collectKeys := func(itr statedb.ResultsIterator) []string {
+		var keys []string
+		for {
+			kv, err := itr.Next()
+			require.NoError(t, err)
+			if kv == nil {
+				return keys
+			}
+			keys = append(keys, kv.Key)
+		}
+	}
+
+	t.Run("unsorted", func(t *testing.T) {
+		query := `{"selector": {"owner.name": "tom", "size": {"$gte": 2}}}`
+		itr, err := db.ExecuteQueryWithPagination("ns1", query, "", 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key0", "key1"}, collectKeys(itr))
+		bookmark := itr.GetBookmarkAndClose()
+		require.Equal(t, "key2", bookmark)
+
+		itr, err = db.ExecuteQueryWithPagination("ns1", query, bookmark, 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key2", "key4"}, collectKeys(itr))
+		bookmark = itr.GetBookmarkAndClose()
+		require.Equal(t, "key5", bookmark)
+
+		itr, err = db.ExecuteQueryWithPagination("ns1", query, bookmark, 2)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key5", "key6"}, collectKeys(itr))
+		require.Equal(t, "", itr.GetBookmarkAndClose())
+	})
+
+	t.Run("sorted", func(t *testing.T) {
+		query := `{"selector": {"size": {"$gt": 0}}, "sort": [{"size": "desc"}], "fields": ["asset_name", "owner.name"], "skip": 1}`
+		itr, err := db.ExecuteQueryWithPagination("ns1", query, "", 3)
+		require.NoError(t, err)
+		kv, err := itr.Next()
+		require.NoError(t, err)
+		require.Equal(t, "key2", kv.Key)
+		require.JSONEq(t, `{"asset_name": "marble2", "owner": {"name": "tom"}}`, string(kv.Value))
+		require.Equal(t, []string{"key6", "key0"}, collectKeys(itr))
+		bookmark := itr.GetBookmarkAndClose()
+		require.NotEmpty(t, bookmark)
+
+		itr, err = db.ExecuteQueryWithPagination("ns1", query, bookmark, 3)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key-red", "key1", "key5"}, collectKeys(itr))
+		bookmark = itr.GetBookmarkAndClose()
+		require.NotEmpty(t, bookmark)
+
+		itr, err = db.ExecuteQueryWithPagination("ns1", query, bookmark, 3)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key3"}, collectKeys(itr))
+		require.Equal(t, "", itr.GetBookmarkAndClose())
+
+		_, err = db.ExecuteQueryWithPagination("ns1", query, "key1", 3)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid bookmark [key1] for a sorted query")
+	})
+
+	t.Run("sorted with a commit in between the pages", func(t *testing.T) {
+		query := `{"selector": {"size": {"$gt": 0}}, "sort": [{"size": "desc"}]}`
+		itr, err := db.ExecuteQueryWithPagination("ns1", query, "", 3)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key4", "key2", "key6"}, collectKeys(itr))
+		bookmark := itr.GetBookmarkAndClose()
+
+		// the results before the bookmark are altered and a result with the same size as the first result of the
+		// next page is added before it in the order of the keys
+		batch := statedb.NewUpdateBatch()
+		batch.Delete("ns1", "key4", version.NewHeight(2, 0))
+		batch.Put("ns1", "key-big", []byte(`{"size": 20}`), version.NewHeight(2, 1))
+		batch.Put("ns1", "key", []byte(`{"size": 5}`), version.NewHeight(2, 2))
+		require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 2)))
+		defer func() {
+			batch := statedb.NewUpdateBatch()
+			batch.Put("ns1", "key4", []byte(`{"asset_name": "marble4", "color": "blue", "size": 9, "owner": {"name": "tom"}}`), version.NewHeight(3, 0))
+			batch.Delete("ns1", "key-big", version.NewHeight(3, 1))
+			batch.Delete("ns1", "key", version.NewHeight(3, 2))
+			require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(3, 2)))
+		}()
+
+		itr, err = db.ExecuteQueryWithPagination("ns1", query, bookmark, 3)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key0", "key-red", "key1"}, collectKeys(itr))
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		itr, err := db.ExecuteQuery("ns1", `{"selector": {"color": {"$in": ["red", "blue"]}}, "sort": ["size"], "limit": 2}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key3", "key5"}, collectKeys(itr))
+
+		itr, err = db.ExecuteQuery("ns1", `{"selector": {"owner": {"$exists": false}}, "limit": 5}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key-red"}, collectKeys(itr))
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		_, err := db.ExecuteQuery("ns1", `{"selector": {"size": {"$mod": [2, 0]}}}`)
+		require.EqualError(t, err, "invalid query: unsupported operator [$mod]")
+	})
+
+	t.Run("total query limit", func(t *testing.T) {
+		db.(*versionedDB).totalQueryLimit = 3
+		defer func() { db.(*versionedDB).totalQueryLimit = 0 }()
+
+		expectedErr := "the sorted query matches more than 3 results, which is the limit on the results sorted in memory"
+		_, err := db.ExecuteQuery("ns1", `{"selector": {"color": "blue"}, "sort": ["size"]}`)
+		require.EqualError(t, err, expectedErr)
+		_, err = db.ExecuteQueryWithPagination("ns1", `{"selector": {"color": "blue"}, "sort": ["size"]}`, "", 2)
+		require.EqualError(t, err, expectedErr)
+
+		itr, err := db.ExecuteQuery("ns1", `{"selector": {"size": {"$lt": 4}}, "sort": ["size"]}`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"key3", "key5", "key1"}, collectKeys(itr))
+		// the unsorted queries are not buffered and hence not limited
+		itr, err = db.ExecuteQuery("ns1", `{"selector": {"color": "blue"}}`)
+		require.NoError(t, err)
+		require.Len(t, collectKeys(itr), 7)
+	})
 }
 
 func TestGetStateMultipleKeys(t *testing.T) {
@@ -122,6 +248,12 @@ func TestPaginatedRangeQuery(t *testing.T) {
 	commontests.TestPaginatedRangeQuery(t, env.DBProvider)
 }
 
+func TestPaginatedRangeQueryBookmark(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestPaginatedRangeQueryBookmark(t, env.DBProvider)
+}
+
 func TestRangeQuerySpecialCharacters(t *testing.T) {
 	env := NewTestVDBEnv(t)
 	defer env.Cleanup()
@@ -256,6 +388,30 @@ func TestDrop(t *testing.T) {
 	commontests.TestDrop(t, env.DBProvider, checkDBsAfterDropFunc)
 }
 
+func TestExists(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	require.Implements(t, (*statedb.ExistenceChecker)(nil), env.DBProvider)
+
+	exists, err := env.DBProvider.Exists("testexists")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	db, err := env.DBProvider.GetDBHandle("testexists", nil)
+	require.NoError(t, err)
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns", "key", []byte("value"), version.NewHeight(1, 1))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 1)))
+	exists, err = env.DBProvider.Exists("testexists")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	require.NoError(t, env.DBProvider.Drop("testexists"))
+	exists, err = env.DBProvider.Exists("testexists")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
 func TestDropErrorPath(t *testing.T) {
 	env := NewTestVDBEnv(t)
 	defer env.Cleanup()
@@ -267,6 +423,34 @@ func TestDropErrorPath(t *testing.T) {
 	require.EqualError(t, env.DBProvider.Drop("testdroperror"), "internal leveldb error while obtaining db iterator: leveldb: closed")
 }
 
+func TestCompact(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+
+	db, err := env.DBProvider.GetDBHandle("testcompact", nil)
+	require.NoError(t, err)
+	require.Implements(t, (*statedb.Compactable)(nil), db)
+
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns", "key1", []byte("value1"), version.NewHeight(1, 1))
+	batch.Put("ns", "key2", []byte("value2"), version.NewHeight(1, 2))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 2)))
+	batch = statedb.NewUpdateBatch()
+	batch.Delete("ns", "key1", version.NewHeight(2, 1))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 1)))
+
+	require.NoError(t, db.(statedb.Compactable).Compact())
+	vv, err := db.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Nil(t, vv)
+	vv, err = db.GetState("ns", "key2")
+	require.NoError(t, err)
+	require.Equal(t, &statedb.VersionedValue{Value: []byte("value2"), Version: version.NewHeight(1, 2)}, vv)
+	savepoint, err := db.GetLatestSavePoint()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(2, 1), savepoint)
+}
+
 type dummyFullScanIter struct {
 	err error
 	kv  *statedb.VersionedKV
@@ -278,3 +462,223 @@ func (d *dummyFullScanIter) Next() (*statedb.VersionedKV, error) {
 
 func (d *dummyFullScanIter) Close() {
 }
+
+func TestPerNamespacePartitioning(t *testing.T) {
+	newProvider := func(t *testing.T, dbPath string) *VersionedDBProvider {
+		provider, err := NewVersionedDBProvider(dbPath, true, nil, nil, leveldbhelper.OpenRetry{}, 0)
+		require.NoError(t, err)
+		t.Cleanup(provider.Close)
+		return provider
+	}
+
+	testCases := []struct {
+		name string
+		test func(*testing.T, statedb.VersionedDBProvider)
+	}{
+		{"basic-rw", commontests.TestBasicRW},
+		{"multi-db-basic-rw", commontests.TestMultiDBBasicRW},
+		{"deletes", commontests.TestDeletes},
+		{"iterator", commontests.TestIterator},
+		{"get-state-multiple-keys", commontests.TestGetStateMultipleKeys},
+		{"get-version", commontests.TestGetVersion},
+		{"value-and-metadata-writes", commontests.TestValueAndMetadataWrites},
+		{"paginated-range-query", commontests.TestPaginatedRangeQuery},
+		{"paginated-range-query-bookmark", commontests.TestPaginatedRangeQueryBookmark},
+		{"range-query-special-characters", commontests.TestRangeQuerySpecialCharacters},
+		{"apply-updates-with-nil-height", commontests.TestApplyUpdatesWithNilHeight},
+		{"data-export-import", commontests.TestDataExportImport},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, newProvider(t, t.TempDir()))
+		})
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		provider := newProvider(t, t.TempDir())
+		commontests.TestDrop(t, provider, func(channelName string) {
+			for _, db := range provider.newVersionedDB(channelName).dataDBs() {
+				empty, err := db.IsEmpty()
+				require.NoError(t, err)
+				require.True(t, empty)
+			}
+		})
+	})
+
+	t.Run("data-is-partitioned-and-persisted", func(t *testing.T) {
+		dbPath := t.TempDir()
+		provider, err := NewVersionedDBProvider(dbPath, true, nil, nil, leveldbhelper.OpenRetry{}, 0)
+		require.NoError(t, err)
+		db, err := provider.GetDBHandle("testpartitions", nil)
+		require.NoError(t, err)
+
+		batch := statedb.NewUpdateBatch()
+		batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+		batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(1, 2))
+		batch.Put("ns2$$hcoll", "key1", []byte("value3"), version.NewHeight(1, 3))
+		require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 3)))
+		provider.Close()
+
+		subdirs, err := ioutil.ReadDir(filepath.Join(dbPath, namespacesDirName))
+		require.NoError(t, err)
+		require.Len(t, subdirs, 2)
+		require.Equal(t, namespaceDirPrefix+hex.EncodeToString([]byte("ns1")), subdirs[0].Name())
+		require.Equal(t, namespaceDirPrefix+hex.EncodeToString([]byte("ns2$$hcoll")), subdirs[1].Name())
+
+		provider = newProvider(t, dbPath)
+		db, err = provider.GetDBHandle("testpartitions", nil)
+		require.NoError(t, err)
+		savepoint, err := db.GetLatestSavePoint()
+		require.NoError(t, err)
+		require.Equal(t, version.NewHeight(1, 3), savepoint)
+
+		vv, err := db.GetState("ns2$$hcoll", "key1")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value3"), vv.Value)
+		vv, err = db.GetState("ns3", "key1")
+		require.NoError(t, err)
+		require.Nil(t, vv)
+
+		itr, err := db.GetStateRangeScanIterator("ns1", "", "")
+		require.NoError(t, err)
+		commontests.TestItrWithoutClose(t, itr, []string{"key1", "key2"})
+		itr, err = db.GetStateRangeScanIterator("ns3", "", "")
+		require.NoError(t, err)
+		commontests.TestItrWithoutClose(t, itr, []string{})
+
+		fullScanItr, err := db.GetFullScanIterator(func(string) bool { return false })
+		require.NoError(t, err)
+		defer fullScanItr.Close()
+		keys := []*statedb.CompositeKey{}
+		for {
+			kv, err := fullScanItr.Next()
+			require.NoError(t, err)
+			if kv == nil {
+				break
+			}
+			keys = append(keys, kv.CompositeKey)
+		}
+		require.Equal(t,
+			[]*statedb.CompositeKey{
+				{Namespace: "ns1", Key: "key1"},
+				{Namespace: "ns1", Key: "key2"},
+				{Namespace: "ns2$$hcoll", Key: "key1"},
+			},
+			keys,
+		)
+
+		count, err := db.(statedb.KeyCountEstimator).ApproximateKeyCount()
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), count)
+		require.NoError(t, db.(statedb.Compactable).Compact())
+		empty, err := db.(*versionedDB).IsEmpty()
+		require.NoError(t, err)
+		require.False(t, empty)
+	})
+
+	t.Run("partial-savepoint", func(t *testing.T) {
+		provider := newProvider(t, t.TempDir())
+		db, err := provider.GetDBHandle("testpartialsavepoint", nil)
+		require.NoError(t, err)
+		vdb := db.(*versionedDB)
+		vdb.SetPartialSavepointInterval(2)
+
+		blockUpdates := func(blockNum uint64, val string) *statedb.UpdateBatch {
+			batch := statedb.NewUpdateBatch()
+			for i := uint64(0); i < 5; i++ {
+				batch.Put(fmt.Sprintf("ns%d", i%2), fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("%s-%d", val, i)), version.NewHeight(blockNum, i))
+			}
+			return batch
+		}
+		verifyVals := func(expectedVals ...string) {
+			for i, expectedVal := range expectedVals {
+				vv, err := vdb.GetState(fmt.Sprintf("ns%d", i%2), fmt.Sprintf("key%d", i))
+				require.NoError(t, err)
+				require.Equal(t, expectedVal, string(vv.Value))
+			}
+		}
+		verifySavepoints := func(expectedSavepoint, expectedPartialSavepoint *version.Height) {
+			savepoint, err := vdb.GetLatestSavePoint()
+			require.NoError(t, err)
+			require.Equal(t, expectedSavepoint, savepoint)
+			partialSavepoint, err := vdb.getPartialSavepoint()
+			require.NoError(t, err)
+			require.Equal(t, expectedPartialSavepoint, partialSavepoint)
+		}
+
+		require.NoError(t, vdb.ApplyUpdates(blockUpdates(1, "block1"), version.NewHeight(1, 4)))
+		verifyVals("block1-0", "block1-1", "block1-2", "block1-3", "block1-4")
+		verifySavepoints(version.NewHeight(1, 4), nil)
+
+		// simulate a crash after the updates of the first two transactions of the block 2 are written. The values
+		// written are altered so as to verify that these are not written again during recovery
+		writtenPart := statedb.NewUpdateBatch()
+		writtenPart.Put("ns0", "key0", []byte("written-0"), version.NewHeight(2, 0))
+		writtenPart.Put("ns1", "key1", []byte("written-1"), version.NewHeight(2, 1))
+		require.NoError(t, vdb.writeToPartitions(writtenPart))
+		require.NoError(t, vdb.db.Put(partialSavepointKey, version.NewHeight(2, 1).ToBytes(), true))
+		verifySavepoints(version.NewHeight(1, 4), version.NewHeight(2, 1))
+
+		require.NoError(t, vdb.ApplyUpdates(blockUpdates(2, "block2"), version.NewHeight(2, 4)))
+		verifyVals("written-0", "written-1", "block2-2", "block2-3", "block2-4")
+		verifySavepoints(version.NewHeight(2, 4), nil)
+
+		// the partial savepoint of a block does not affect the commit of a different block
+		require.NoError(t, vdb.db.Put(partialSavepointKey, version.NewHeight(2, 1).ToBytes(), true))
+		require.NoError(t, vdb.ApplyUpdates(blockUpdates(3, "block3"), version.NewHeight(3, 4)))
+		verifyVals("block3-0", "block3-1", "block3-2", "block3-3", "block3-4")
+		verifySavepoints(version.NewHeight(3, 4), nil)
+	})
+}
+
+func TestPartitioningModeCannotBeChanged(t *testing.T) {
+	writeData := func(t *testing.T, dbPath string, perNamespacePartitioning bool) {
+		provider, err := NewVersionedDBProvider(dbPath, perNamespacePartitioning, nil, nil, leveldbhelper.OpenRetry{}, 0)
+		require.NoError(t, err)
+		defer provider.Close()
+		db, err := provider.GetDBHandle("testmode", nil)
+		require.NoError(t, err)
+		batch := statedb.NewUpdateBatch()
+		batch.Put("ns", "key", []byte("value"), version.NewHeight(1, 1))
+		require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 1)))
+	}
+
+	verifyData := func(t *testing.T, dbPath string, perNamespacePartitioning bool) {
+		provider, err := NewVersionedDBProvider(dbPath, perNamespacePartitioning, nil, nil, leveldbhelper.OpenRetry{}, 0)
+		require.NoError(t, err)
+		defer provider.Close()
+		db, err := provider.GetDBHandle("testmode", nil)
+		require.NoError(t, err)
+		vv, err := db.GetState("ns", "key")
+		require.NoError(t, err)
+		require.Equal(t, []byte("value"), vv.Value)
+	}
+
+	for _, perNamespacePartitioning := range []bool{false, true} {
+		dbPath := t.TempDir()
+		writeData(t, dbPath, perNamespacePartitioning)
+		verifyData(t, dbPath, perNamespacePartitioning)
+
+		_, err := NewVersionedDBProvider(dbPath, !perNamespacePartitioning, nil, nil, leveldbhelper.OpenRetry{}, 0)
+		require.EqualError(t, err, fmt.Sprintf(
+			"the statedb at [%s] was created with per-namespace partitioning set to [%t], which differs from the configured value [%t]; "+
+				"rebuild the statedb in order to change the partitioning mode",
+			dbPath, perNamespacePartitioning, !perNamespacePartitioning,
+		))
+		verifyData(t, dbPath, perNamespacePartitioning)
+	}
+
+	t.Run("statedb-without-mode", func(t *testing.T) {
+		// a statedb created by an earlier version does not record the mode and holds the data in the main leveldb
+		dbPath := t.TempDir()
+		writeData(t, dbPath, false)
+		provider, err := NewVersionedDBProvider(dbPath, false, nil, nil, leveldbhelper.OpenRetry{}, 0)
+		require.NoError(t, err)
+		require.NoError(t, provider.dbProvider.Drop(configDBName))
+		provider.Close()
+
+		_, err = NewVersionedDBProvider(dbPath, true, nil, nil, leveldbhelper.OpenRetry{}, 0)
+		require.Error(t, err)
+		verifyData(t, dbPath, false)
+	})
+}
@@ -9,6 +9,7 @@ package stateleveldb
 import (
 	"testing"
 
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,7 +24,7 @@ type TestVDBEnv struct {
 func NewTestVDBEnv(t testing.TB) *TestVDBEnv {
 	t.Logf("Creating new TestVDBEnv")
 	dbPath := t.TempDir()
-	dbProvider, err := NewVersionedDBProvider(dbPath)
+	dbProvider, err := NewVersionedDBProvider(dbPath, false, nil, nil, leveldbhelper.OpenRetry{}, 0)
 	require.NoError(t, err)
 	return &TestVDBEnv{t, dbProvider, dbPath}
 }
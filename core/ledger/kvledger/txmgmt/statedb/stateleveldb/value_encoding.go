@@ -10,8 +10,13 @@ import (
 	proto "github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
+// dbValueVersionField is the field number of the version in the message DBValue
+const dbValueVersionField = 1
+
 // encodeValue encodes the value, version, and metadata
 func encodeValue(v *statedb.VersionedValue) ([]byte, error) {
 	return proto.Marshal(
@@ -42,3 +47,29 @@ func decodeValue(encodedValue []byte) (*statedb.VersionedValue, error) {
 	}
 	return &statedb.VersionedValue{Version: ver, Value: val, Metadata: metadata}, nil
 }
+
+// decodeVersion decodes only the version from the statedb value bytes, without copying the value and the metadata
+func decodeVersion(encodedValue []byte) (*version.Height, error) {
+	b := encodedValue
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		if num == dbValueVersionField && typ == protowire.BytesType {
+			versionBytes, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			ver, _, err := version.NewHeightFromBytes(versionBytes)
+			return ver, err
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil, errors.New("version not found in the statedb value")
+}
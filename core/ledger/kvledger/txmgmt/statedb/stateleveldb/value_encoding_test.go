@@ -50,4 +50,15 @@ func testEncodeDecodeVersionedValues(t *testing.T, v *statedb.VersionedValue) {
 	decodedVal, err := decodeValue(encodedVal)
 	require.NoError(t, err)
 	require.Equal(t, v, decodedVal)
+	decodedVersion, err := decodeVersion(encodedVal)
+	require.NoError(t, err)
+	require.Equal(t, v.Version, decodedVersion)
+}
+
+func TestDecodeVersionErrors(t *testing.T) {
+	_, err := decodeVersion(nil)
+	require.EqualError(t, err, "version not found in the statedb value")
+
+	_, err = decodeVersion([]byte{0x0a, 0x05, 0x01})
+	require.EqualError(t, err, "unexpected EOF")
 }
@@ -0,0 +1,570 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package statepebble
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/dataformat"
+	"github.com/hyperledger/fabric/core/ledger/internal/version"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/pkg/errors"
+)
+
+var logger = flogging.MustGetLogger("statepebble")
+
+const (
+	// internalDBName is used to keep track of data related to internals such as data format
+	// _ is used as name because this is not allowed as a channelname
+	internalDBName = "_"
+)
+
+var (
+	dbNameKeySep           = []byte{0x00}
+	dataKeyPrefix          = []byte{'d'}
+	dataKeyStopper         = []byte{'e'}
+	nsKeySep               = []byte{0x00}
+	lastKeyIndicator       = byte(0x01)
+	savePointKey           = []byte{'s'}
+	formatVersionKey       = []byte{'f'} // a single key in the internal db whose value indicates the version of the data format
+	maxDataImportBatchSize = 4 * 1024 * 1024
+)
+
+// VersionedDBProvider implements interface VersionedDBProvider backed by a single pebble db that is shared
+// by all the channels. The keys of a channel are prefixed with the name of the channel
+type VersionedDBProvider struct {
+	db     *pebble.DB
+	dbPath string
+
+	closeOnce sync.Once
+}
+
+// NewVersionedDBProvider instantiates VersionedDBProvider for the pebble db at the dir `dbPath`
+func NewVersionedDBProvider(dbPath string) (*VersionedDBProvider, error) {
+	logger.Debugf("constructing VersionedDBProvider dbPath=%s", dbPath)
+	db, err := pebble.Open(dbPath, &pebble.Options{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error opening pebble db at [%s]", dbPath)
+	}
+	provider := &VersionedDBProvider{
+		db:     db,
+		dbPath: dbPath,
+	}
+	if err := provider.checkFormat(dataformat.CurrentFormat); err != nil {
+		provider.Close()
+		return nil, err
+	}
+	return provider, nil
+}
+
+// checkFormat sets the data format if the db is empty (i.e., opening for the first time) and otherwise,
+// returns an error if the data format recorded in the db differs from the expected format
+func (provider *VersionedDBProvider) checkFormat(expectedFormat string) error {
+	empty, err := isEmptyRange(provider.db, nil, nil)
+	if err != nil {
+		return err
+	}
+	formatKey := dbKey(internalDBName, formatVersionKey)
+	if empty {
+		logger.Infof("DB is empty Setting db format as %s", expectedFormat)
+		return errors.Wrap(provider.db.Set(formatKey, []byte(expectedFormat), pebble.Sync), "error writing data format to pebble db")
+	}
+	format, err := get(provider.db, formatKey)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(format, []byte(expectedFormat)) {
+		return &dataformat.ErrFormatMismatch{
+			ExpectedFormat: expectedFormat,
+			Format:         string(format),
+			DBInfo:         fmt.Sprintf("pebble at [%s]", provider.dbPath),
+		}
+	}
+	return nil
+}
+
+// GetDBHandle gets the handle to a named database
+func (provider *VersionedDBProvider) GetDBHandle(dbName string, namespaceProvider statedb.NamespaceProvider) (statedb.VersionedDB, error) {
+	return provider.newVersionedDB(dbName), nil
+}
+
+func (provider *VersionedDBProvider) newVersionedDB(dbName string) *versionedDB {
+	return &versionedDB{
+		db:     provider.db,
+		dbName: dbName,
+	}
+}
+
+// ImportFromSnapshot loads the public state and pvtdata hashes from the snapshot files previously generated
+func (provider *VersionedDBProvider) ImportFromSnapshot(
+	dbName string,
+	savepoint *version.Height,
+	itr statedb.FullScanIterator,
+) error {
+	vdb := provider.newVersionedDB(dbName)
+	return vdb.importState(itr, savepoint)
+}
+
+// BytesKeySupported returns true if a db created supports bytes as a key
+func (provider *VersionedDBProvider) BytesKeySupported() bool {
+	return true
+}
+
+// Close closes the underlying db
+func (provider *VersionedDBProvider) Close() {
+	provider.closeOnce.Do(func() {
+		if err := provider.db.Close(); err != nil {
+			logger.Warnf("Error while closing pebble db at [%s]: %s", provider.dbPath, err)
+		}
+	})
+}
+
+// Drop drops channel-specific data from the state pebble db.
+// It is not an error if a database does not exist.
+func (provider *VersionedDBProvider) Drop(dbName string) error {
+	start, end := dbRange(dbName)
+	return errors.Wrap(provider.db.DeleteRange(start, end, pebble.Sync), "error dropping data from pebble db")
+}
+
+// Exists implements method in interface statedb.ExistenceChecker
+func (provider *VersionedDBProvider) Exists(dbName string) (bool, error) {
+	empty, err := provider.newVersionedDB(dbName).IsEmpty()
+	return !empty, err
+}
+
+// versionedDB implements VersionedDB interface
+type versionedDB struct {
+	db     *pebble.DB
+	dbName string
+}
+
+// Open implements method in VersionedDB interface
+func (vdb *versionedDB) Open() error {
+	// do nothing because shared db is used
+	return nil
+}
+
+// Close implements method in VersionedDB interface
+func (vdb *versionedDB) Close() {
+	// do nothing because shared db is used
+}
+
+// ValidateKeyValue implements method in VersionedDB interface
+func (vdb *versionedDB) ValidateKeyValue(key string, value []byte) error {
+	return nil
+}
+
+// BytesKeySupported implements method in VersionedDB interface
+func (vdb *versionedDB) BytesKeySupported() bool {
+	return true
+}
+
+// GetState implements method in VersionedDB interface
+func (vdb *versionedDB) GetState(namespace string, key string) (*statedb.VersionedValue, error) {
+	logger.Debugf("GetState(). ns=%s, key=%s", namespace, key)
+	dbVal, err := get(vdb.db, vdb.dbKey(encodeDataKey(namespace, key)))
+	if err != nil || dbVal == nil {
+		return nil, err
+	}
+	return decodeValue(dbVal)
+}
+
+// GetVersion implements method in VersionedDB interface
+func (vdb *versionedDB) GetVersion(namespace string, key string) (*version.Height, error) {
+	versionedValue, err := vdb.GetState(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	if versionedValue == nil {
+		return nil, nil
+	}
+	return versionedValue.Version, nil
+}
+
+// GetStateMultipleKeys implements method in VersionedDB interface
+// All the keys are read from a single snapshot of the db
+func (vdb *versionedDB) GetStateMultipleKeys(namespace string, keys []string) ([]*statedb.VersionedValue, error) {
+	snapshot := vdb.db.NewSnapshot()
+	defer snapshot.Close()
+
+	vals := make([]*statedb.VersionedValue, len(keys))
+	for i, key := range keys {
+		dbVal, err := get(snapshot, vdb.dbKey(encodeDataKey(namespace, key)))
+		if err != nil {
+			return nil, err
+		}
+		if dbVal == nil {
+			continue
+		}
+		if vals[i], err = decodeValue(dbVal); err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// GetStateRangeScanIterator implements method in VersionedDB interface
+// startKey is inclusive
+// endKey is exclusive
+func (vdb *versionedDB) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (statedb.ResultsIterator, error) {
+	// pageSize = 0 denotes unlimited page size
+	return vdb.GetStateRangeScanIteratorWithPagination(namespace, startKey, endKey, 0)
+}
+
+// GetStateRangeScanIteratorWithPagination implements method in VersionedDB interface
+func (vdb *versionedDB) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (statedb.QueryResultsIterator, error) {
+	dataStartKey := encodeDataKey(namespace, startKey)
+	dataEndKey := encodeDataKey(namespace, endKey)
+	if endKey == "" {
+		dataEndKey[len(dataEndKey)-1] = lastKeyIndicator
+	}
+	return newKVScanner(namespace, vdb.newIterator(dataStartKey, dataEndKey), pageSize, endKey), nil
+}
+
+// ExecuteQuery implements method in VersionedDB interface
+func (vdb *versionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
+	return nil, errors.New("ExecuteQuery not supported for pebble")
+}
+
+// ExecuteQueryWithPagination implements method in VersionedDB interface
+func (vdb *versionedDB) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (statedb.QueryResultsIterator, error) {
+	return nil, errors.New("ExecuteQueryWithMetadata not supported for pebble")
+}
+
+// ApplyUpdates implements method in VersionedDB interface
+func (vdb *versionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
+	dbBatch := vdb.db.NewBatch()
+	defer dbBatch.Close()
+	for _, ns := range batch.GetUpdatedNamespaces() {
+		for k, vv := range batch.GetUpdates(ns) {
+			dataKey := encodeDataKey(ns, k)
+			logger.Debugf("Channel [%s]: Applying key(string)=[%s] key(bytes)=[%#v]", vdb.dbName, string(dataKey), dataKey)
+
+			if vv.Value == nil {
+				if err := dbBatch.Delete(vdb.dbKey(dataKey), nil); err != nil {
+					return errors.Wrap(err, "error adding delete to pebble batch")
+				}
+				continue
+			}
+			encodedVal, err := encodeValue(vv)
+			if err != nil {
+				return err
+			}
+			if err := dbBatch.Set(vdb.dbKey(dataKey), encodedVal, nil); err != nil {
+				return errors.Wrap(err, "error adding put to pebble batch")
+			}
+		}
+	}
+	// Record a savepoint at a given height
+	// If a given height is nil, it denotes that we are committing pvt data of old blocks.
+	// In this case, we should not store a savepoint for recovery. The lastUpdatedOldBlockList
+	// in the pvtstore acts as a savepoint for pvt data.
+	if height != nil {
+		if err := dbBatch.Set(vdb.dbKey(savePointKey), height.ToBytes(), nil); err != nil {
+			return errors.Wrap(err, "error adding savepoint to pebble batch")
+		}
+	}
+	return errors.Wrap(dbBatch.Commit(pebble.Sync), "error writing batch to pebble db")
+}
+
+// GetLatestSavePoint implements method in VersionedDB interface
+func (vdb *versionedDB) GetLatestSavePoint() (*version.Height, error) {
+	versionBytes, err := get(vdb.db, vdb.dbKey(savePointKey))
+	if err != nil || versionBytes == nil {
+		return nil, err
+	}
+	version, _, err := version.NewHeightFromBytes(versionBytes)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// GetFullScanIterator implements method in VersionedDB interface. This function returns a
+// FullScanIterator that can be used to iterate over entire data in the statedb for a channel.
+// `skipNamespace` parameter can be used to control if the consumer wants the FullScanIterator
+// to skip one or more namespaces from the returned results
+func (vdb *versionedDB) GetFullScanIterator(skipNamespace func(string) bool) (statedb.FullScanIterator, error) {
+	return &fullDBScanner{
+		vdb:    vdb,
+		dbItr:  vdb.newIterator(dataKeyPrefix, dataKeyStopper),
+		toSkip: skipNamespace,
+	}, nil
+}
+
+// importState loads the state from a previously snapshotted state. The parameter itr provides access to
+// the snapshotted state
+func (vdb *versionedDB) importState(itr statedb.FullScanIterator, savepoint *version.Height) error {
+	dbBatch := vdb.db.NewBatch()
+	defer dbBatch.Close()
+	if itr != nil {
+		for {
+			versionedKV, err := itr.Next()
+			if err != nil {
+				return err
+			}
+			if versionedKV == nil {
+				break
+			}
+			dbValue, err := encodeValue(versionedKV.VersionedValue)
+			if err != nil {
+				return err
+			}
+			if err := dbBatch.Set(vdb.dbKey(encodeDataKey(versionedKV.Namespace, versionedKV.Key)), dbValue, nil); err != nil {
+				return errors.Wrap(err, "error adding put to pebble batch")
+			}
+			if len(dbBatch.Repr()) >= maxDataImportBatchSize {
+				if err := dbBatch.Commit(pebble.Sync); err != nil {
+					return errors.Wrap(err, "error writing batch to pebble db")
+				}
+				dbBatch.Reset()
+			}
+		}
+	}
+	if err := dbBatch.Set(vdb.dbKey(savePointKey), savepoint.ToBytes(), nil); err != nil {
+		return errors.Wrap(err, "error adding savepoint to pebble batch")
+	}
+	return errors.Wrap(dbBatch.Commit(pebble.Sync), "error writing batch to pebble db")
+}
+
+// Compact implements method in interface statedb.Compactable
+func (vdb *versionedDB) Compact() error {
+	start, end := dbRange(vdb.dbName)
+	return errors.Wrap(vdb.db.Compact(start, end), "error compacting pebble db")
+}
+
+// IsEmpty return true if the statedb does not have any content
+func (vdb *versionedDB) IsEmpty() (bool, error) {
+	start, end := dbRange(vdb.dbName)
+	return isEmptyRange(vdb.db, start, end)
+}
+
+// dbKey prefixes the key with the name of the channel
+func (vdb *versionedDB) dbKey(key []byte) []byte {
+	return dbKey(vdb.dbName, key)
+}
+
+// newIterator returns an iterator over the keys of the channel between startKey (inclusive) and endKey (exclusive).
+// The keys returned by the iterator do not include the channel prefix
+func (vdb *versionedDB) newIterator(startKey, endKey []byte) *dbIterator {
+	return &dbIterator{
+		itr: vdb.db.NewIter(&pebble.IterOptions{
+			LowerBound: vdb.dbKey(startKey),
+			UpperBound: vdb.dbKey(endKey),
+		}),
+		prefixLen: len(vdb.dbName) + len(dbNameKeySep),
+	}
+}
+
+// dbIterator adapts a pebble iterator to the semantics of the leveldb iterator, i.e., the function Next
+// moves to the first key on the first invocation
+type dbIterator struct {
+	itr       *pebble.Iterator
+	prefixLen int
+	started   bool
+	// seeked is set when the iterator is already positioned by a seek, in which case the function Next
+	// returns seekValid without moving the iterator
+	seeked    bool
+	seekValid bool
+}
+
+func (i *dbIterator) Next() bool {
+	switch {
+	case !i.started:
+		i.started = true
+		return i.itr.First()
+	case i.seeked:
+		i.seeked = false
+		return i.seekValid
+	default:
+		return i.itr.Next()
+	}
+}
+
+// Key returns a copy of the current key without the channel prefix
+func (i *dbIterator) Key() []byte {
+	return append([]byte(nil), i.itr.Key()[i.prefixLen:]...)
+}
+
+// Value returns a copy of the current value
+func (i *dbIterator) Value() []byte {
+	return append([]byte(nil), i.itr.Value()...)
+}
+
+// seekToNextNamespace positions the iterator such that the function Next moves to the first key of the
+// namespace following the given namespace
+func (i *dbIterator) seekToNextNamespace(vdb *versionedDB, ns string) {
+	i.started = true
+	i.seeked = true
+	i.seekValid = i.itr.SeekGE(vdb.dbKey(dataKeyStarterForNextNamespace(ns)))
+}
+
+func (i *dbIterator) Error() error {
+	return i.itr.Error()
+}
+
+func (i *dbIterator) Close() {
+	if err := i.itr.Close(); err != nil {
+		logger.Warnf("Error while closing pebble iterator: %s", err)
+	}
+}
+
+type kvScanner struct {
+	namespace            string
+	dbItr                *dbIterator
+	requestedLimit       int32
+	totalRecordsReturned int32
+	// endKey is returned as the bookmark once the range is exhausted, as with CouchDB
+	endKey string
+}
+
+func newKVScanner(namespace string, dbItr *dbIterator, requestedLimit int32, endKey string) *kvScanner {
+	return &kvScanner{namespace, dbItr, requestedLimit, 0, endKey}
+}
+
+func (scanner *kvScanner) Next() (*statedb.VersionedKV, error) {
+	if scanner.requestedLimit > 0 && scanner.totalRecordsReturned >= scanner.requestedLimit {
+		return nil, nil
+	}
+	if !scanner.dbItr.Next() {
+		return nil, errors.Wrap(scanner.dbItr.Error(), "internal pebble error while retrieving data from db iterator")
+	}
+
+	_, key := decodeDataKey(scanner.dbItr.Key())
+	vv, err := decodeValue(scanner.dbItr.Value())
+	if err != nil {
+		return nil, err
+	}
+
+	scanner.totalRecordsReturned++
+	return &statedb.VersionedKV{
+		CompositeKey: &statedb.CompositeKey{
+			Namespace: scanner.namespace,
+			Key:       key,
+		},
+		VersionedValue: vv,
+	}, nil
+}
+
+func (scanner *kvScanner) Close() {
+	scanner.dbItr.Close()
+}
+
+// GetBookmarkAndClose returns the key at which the next page starts, that is, the next key in the range or,
+// if the range is exhausted, the end key of the range. Passing the bookmark back as the start key of the range
+// continues the scan after the keys already returned, even if the keys are added or deleted in the meantime
+func (scanner *kvScanner) GetBookmarkAndClose() string {
+	retval := scanner.endKey
+	if scanner.dbItr.Next() {
+		_, key := decodeDataKey(scanner.dbItr.Key())
+		retval = key
+	}
+	scanner.Close()
+	return retval
+}
+
+type fullDBScanner struct {
+	vdb    *versionedDB
+	dbItr  *dbIterator
+	toSkip func(namespace string) bool
+}
+
+// Next returns the key-values in the lexical order of <Namespace, key>
+func (s *fullDBScanner) Next() (*statedb.VersionedKV, error) {
+	for s.dbItr.Next() {
+		ns, key := decodeDataKey(s.dbItr.Key())
+		if s.toSkip(ns) {
+			s.dbItr.seekToNextNamespace(s.vdb, ns)
+			continue
+		}
+		versionedVal, err := decodeValue(s.dbItr.Value())
+		if err != nil {
+			return nil, err
+		}
+		return &statedb.VersionedKV{
+			CompositeKey: &statedb.CompositeKey{
+				Namespace: ns,
+				Key:       key,
+			},
+			VersionedValue: versionedVal,
+		}, nil
+	}
+	return nil, errors.Wrap(s.dbItr.Error(), "internal pebble error while retrieving data from db iterator")
+}
+
+func (s *fullDBScanner) Close() {
+	if s == nil {
+		return
+	}
+	s.dbItr.Close()
+}
+
+// reader is implemented by both pebble.DB and pebble.Snapshot
+type reader interface {
+	Get(key []byte) ([]byte, io.Closer, error)
+}
+
+// get returns a copy of the value for the key or nil if the key does not exist
+func get(r reader, key []byte) ([]byte, error) {
+	val, closer, err := r.Get(key)
+	if err == pebble.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving key from pebble db")
+	}
+	defer closer.Close()
+	return append([]byte{}, val...), nil
+}
+
+// isEmptyRange returns true if the db does not have any key between start (inclusive) and end (exclusive).
+// A nil start or end denotes an unbounded range on the corresponding side
+func isEmptyRange(db *pebble.DB, start, end []byte) (bool, error) {
+	itr := db.NewIter(&pebble.IterOptions{LowerBound: start, UpperBound: end})
+	hasKey := itr.First()
+	err := itr.Error()
+	if closeErr := itr.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "internal pebble error while obtaining db iterator")
+	}
+	return !hasKey, nil
+}
+
+func dbKey(dbName string, key []byte) []byte {
+	k := append([]byte(dbName), dbNameKeySep...)
+	return append(k, key...)
+}
+
+// dbRange returns the range of the keys of the channel with start inclusive and end exclusive
+func dbRange(dbName string) ([]byte, []byte) {
+	return dbKey(dbName, nil), append([]byte(dbName), lastKeyIndicator)
+}
+
+func encodeDataKey(ns, key string) []byte {
+	k := append([]byte{}, dataKeyPrefix...)
+	k = append(k, []byte(ns)...)
+	k = append(k, nsKeySep...)
+	return append(k, []byte(key)...)
+}
+
+func decodeDataKey(encodedDataKey []byte) (string, string) {
+	split := bytes.SplitN(encodedDataKey, nsKeySep, 2)
+	return string(split[0][1:]), string(split[1])
+}
+
+func dataKeyStarterForNextNamespace(ns string) []byte {
+	k := append([]byte{}, dataKeyPrefix...)
+	k = append(k, []byte(ns)...)
+	return append(k, lastKeyIndicator)
+}
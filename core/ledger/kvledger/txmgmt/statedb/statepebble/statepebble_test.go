@@ -0,0 +1,238 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package statepebble
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/dataformat"
+	"github.com/hyperledger/fabric/core/ledger/internal/version"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/commontests"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBasicRW(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestBasicRW(t, env.DBProvider)
+}
+
+func TestMultiDBBasicRW(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestMultiDBBasicRW(t, env.DBProvider)
+}
+
+func TestDeletes(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestDeletes(t, env.DBProvider)
+}
+
+func TestIterator(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestIterator(t, env.DBProvider)
+}
+
+func TestDataKeyEncoding(t *testing.T) {
+	for _, key := range []string{"key", ""} {
+		ns, k := decodeDataKey(encodeDataKey("ns", key))
+		require.Equal(t, "ns", ns)
+		require.Equal(t, key, k)
+	}
+}
+
+func TestQueryNotSupported(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	db, err := env.DBProvider.GetDBHandle("testquery", nil)
+	require.NoError(t, err)
+
+	itr, err := db.ExecuteQuery("ns1", `{"selector":{"owner":"jerry"}}`)
+	require.EqualError(t, err, "ExecuteQuery not supported for pebble")
+	require.Nil(t, itr)
+}
+
+func TestGetStateMultipleKeys(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestGetStateMultipleKeys(t, env.DBProvider)
+}
+
+func TestGetVersion(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestGetVersion(t, env.DBProvider)
+}
+
+func TestValueAndMetadataWrites(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestValueAndMetadataWrites(t, env.DBProvider)
+}
+
+func TestPaginatedRangeQuery(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestPaginatedRangeQuery(t, env.DBProvider)
+}
+
+func TestPaginatedRangeQueryBookmark(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestPaginatedRangeQueryBookmark(t, env.DBProvider)
+}
+
+func TestRangeQuerySpecialCharacters(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestRangeQuerySpecialCharacters(t, env.DBProvider)
+}
+
+func TestApplyUpdatesWithNilHeight(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestApplyUpdatesWithNilHeight(t, env.DBProvider)
+}
+
+func TestDataExportImport(t *testing.T) {
+	// smaller batch size for testing to cover the boundary case of writing the final batch
+	maxDataImportBatchSize = 10
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestDataExportImport(
+		t,
+		env.DBProvider,
+	)
+}
+
+func TestImportStateErrorPropagation(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+
+	err := env.DBProvider.ImportFromSnapshot(
+		"test-db",
+		version.NewHeight(2, 2),
+		&dummyFullScanIter{
+			err: errors.New("error while reading from source"),
+		},
+	)
+	require.EqualError(t, err, "error while reading from source")
+}
+
+func TestDrop(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+
+	checkDBsAfterDropFunc := func(channelName string) {
+		empty, err := env.DBProvider.newVersionedDB(channelName).IsEmpty()
+		require.NoError(t, err)
+		require.True(t, empty)
+	}
+
+	commontests.TestDrop(t, env.DBProvider, checkDBsAfterDropFunc)
+}
+
+func TestExists(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	require.Implements(t, (*statedb.ExistenceChecker)(nil), env.DBProvider)
+
+	exists, err := env.DBProvider.Exists("testexists")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	db, err := env.DBProvider.GetDBHandle("testexists", nil)
+	require.NoError(t, err)
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns", "key", []byte("value"), version.NewHeight(1, 1))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 1)))
+	exists, err = env.DBProvider.Exists("testexists")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// a channel whose name is a prefix of the name of another channel is not affected
+	exists, err = env.DBProvider.Exists("testexist")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, env.DBProvider.Drop("testexists"))
+	exists, err = env.DBProvider.Exists("testexists")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestCompact(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+
+	db, err := env.DBProvider.GetDBHandle("testcompact", nil)
+	require.NoError(t, err)
+	require.Implements(t, (*statedb.Compactable)(nil), db)
+
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns", "key1", []byte("value1"), version.NewHeight(1, 1))
+	batch.Put("ns", "key2", []byte("value2"), version.NewHeight(1, 2))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 2)))
+	batch = statedb.NewUpdateBatch()
+	batch.Delete("ns", "key1", version.NewHeight(2, 1))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 1)))
+
+	require.NoError(t, db.(statedb.Compactable).Compact())
+	vv, err := db.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Nil(t, vv)
+	vv, err = db.GetState("ns", "key2")
+	require.NoError(t, err)
+	require.Equal(t, &statedb.VersionedValue{Value: []byte("value2"), Version: version.NewHeight(1, 2)}, vv)
+	savepoint, err := db.GetLatestSavePoint()
+	require.NoError(t, err)
+	require.Equal(t, version.NewHeight(2, 1), savepoint)
+}
+
+func TestReopen(t *testing.T) {
+	dbPath := t.TempDir()
+	provider, err := NewVersionedDBProvider(dbPath)
+	require.NoError(t, err)
+	db, err := provider.GetDBHandle("testreopen", nil)
+	require.NoError(t, err)
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns", "key1", []byte("value1"), version.NewHeight(1, 1))
+	require.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 1)))
+	provider.Close()
+	// close is idempotent
+	provider.Close()
+
+	provider, err = NewVersionedDBProvider(dbPath)
+	require.NoError(t, err)
+	db, err = provider.GetDBHandle("testreopen", nil)
+	require.NoError(t, err)
+	vv, err := db.GetState("ns", "key1")
+	require.NoError(t, err)
+	require.Equal(t, &statedb.VersionedValue{Value: []byte("value1"), Version: version.NewHeight(1, 1)}, vv)
+
+	// a db with a different data format is not opened
+	require.NoError(t, provider.checkFormat(dataformat.CurrentFormat))
+	err = provider.checkFormat("unexpected-format")
+	require.IsType(t, &dataformat.ErrFormatMismatch{}, err)
+	provider.Close()
+}
+
+type dummyFullScanIter struct {
+	err error
+	kv  *statedb.VersionedKV
+}
+
+func (d *dummyFullScanIter) Next() (*statedb.VersionedKV, error) {
+	return d.kv, d.err
+}
+
+func (d *dummyFullScanIter) Close() {
+}
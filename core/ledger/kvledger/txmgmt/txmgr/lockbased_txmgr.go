@@ -8,6 +8,7 @@ package txmgr
 
 import (
 	"bytes"
+	"io"
 	"sync"
 
 	"github.com/golang/protobuf/proto"
@@ -44,6 +45,7 @@ type LockBasedTxMgr struct {
 	oldBlockCommit      sync.Mutex
 	currentUpdates      *currentUpdates
 	hashFunc            rwsetutil.HashFunc
+	commitHashWorkers   int
 }
 
 // pvtdataPurgeMgr wraps the actual purge manager and an additional flag 'usedOnce'
@@ -79,10 +81,15 @@ type Initializer struct {
 	DB                  *privacyenabledstate.DB
 	StateListeners      []ledger.StateListener
 	BtlPolicy           pvtdatapolicy.BTLPolicy
+	PubStateBTL         map[string]uint64
 	BookkeepingProvider *bookkeeping.Provider
 	CCInfoProvider      ledger.DeployedChaincodeInfoProvider
 	CustomTxProcessors  map[common.HeaderType]ledger.CustomTxProcessor
 	HashFunc            rwsetutil.HashFunc
+	// CommitHashWorkers, when greater than zero, causes ValidateAndPrepare not to return the bytes of the update
+	// batch for the commit hash, which are instead written, by the given number of workers, via the function
+	// returned by UpdateBatchBytesWriter
+	CommitHashWorkers int
 }
 
 // NewLockBasedTxMgr constructs a new instance of NewLockBasedTxMgr
@@ -95,16 +102,18 @@ func NewLockBasedTxMgr(initializer *Initializer) (*LockBasedTxMgr, error) {
 		return nil, err
 	}
 	txmgr := &LockBasedTxMgr{
-		ledgerid:       initializer.LedgerID,
-		db:             initializer.DB,
-		stateListeners: initializer.StateListeners,
-		ccInfoProvider: initializer.CCInfoProvider,
-		hashFunc:       initializer.HashFunc,
+		ledgerid:          initializer.LedgerID,
+		db:                initializer.DB,
+		stateListeners:    initializer.StateListeners,
+		ccInfoProvider:    initializer.CCInfoProvider,
+		hashFunc:          initializer.HashFunc,
+		commitHashWorkers: initializer.CommitHashWorkers,
 	}
 	pvtstatePurgeMgr, err := pvtstatepurgemgmt.InstantiatePurgeMgr(
 		initializer.LedgerID,
 		initializer.DB,
 		initializer.BtlPolicy,
+		initializer.PubStateBTL,
 		initializer.BookkeepingProvider)
 	if err != nil {
 		return nil, err
@@ -131,6 +140,16 @@ func (txmgr *LockBasedTxMgr) NewQueryExecutor(txid string) (ledger.QueryExecutor
 	return qe, nil
 }
 
+// NewQueryExecutorWithReadAuthorizer returns a query executor that consults the given readAuthorizer for each read
+// of the public state. A point read of a key that the readAuthorizer rejects returns a ReadNotAuthorizedError and
+// such a key is skipped in the results of a range scan or a query. A nil readAuthorizer authorizes all the reads
+func (txmgr *LockBasedTxMgr) NewQueryExecutorWithReadAuthorizer(txid string, readAuthorizer func(namespace, key string) error) (ledger.QueryExecutor, error) {
+	qe := newQueryExecutor(txmgr, txid, nil, true, txmgr.hashFunc)
+	qe.readAuthorizer = readAuthorizer
+	txmgr.commitRWLock.RLock()
+	return qe, nil
+}
+
 // NewQueryExecutorNoCollChecks is a workaround to make the initilization of lifecycle cache
 // work. The issue is that in the current lifecycle code the cache is initialized via Initialize
 // function of a statelistener which gets invoked during ledger opening. This invovation eventually
@@ -191,10 +210,72 @@ func (txmgr *LockBasedTxMgr) ValidateAndPrepare(blockAndPvtdata *ledger.BlockAnd
 		return nil, nil, nil, err
 	}
 
+	if txmgr.commitHashWorkers > 0 {
+		return appPurgeUpdates, txstatsInfo, nil, nil
+	}
 	updateBytes, err := deterministicBytesForPubAndHashUpdates(batch)
 	return appPurgeUpdates, txstatsInfo, updateBytes, err
 }
 
+// UsesCommitHashWorkers returns true if the transaction manager is initialized with CommitHashWorkers
+func (txmgr *LockBasedTxMgr) UsesCommitHashWorkers() bool {
+	return txmgr.commitHashWorkers > 0
+}
+
+// UpdateBatchBytesWriter returns a function that writes the bytes of the update batch prepared by the last
+// invocation of ValidateAndPrepare, which are otherwise returned by ValidateAndPrepare, when the transaction manager
+// is initialized with CommitHashWorkers. The returned function can be invoked in the background until the
+// prepared batch is committed, as the batch is not changed in the meantime
+func (txmgr *LockBasedTxMgr) UpdateBatchBytesWriter() func(w io.Writer) error {
+	batch := txmgr.currentUpdates.batch
+	return func(w io.Writer) error {
+		return writeDeterministicBytesForPubAndHashUpdates(batch, txmgr.commitHashWorkers, w)
+	}
+}
+
+// PreviewStateDelta validates the block, as ValidateAndPrepare does, and returns the resulting changes to the
+// public state without preparing the block for commit. The validation is performed on a copy of the block so that
+// the validation codes are not set in the supplied block. The current values of the updated keys are read under the
+// same lock as the validation and hence, the delta is consistent with the state that the block is validated against
+func (txmgr *LockBasedTxMgr) PreviewStateDelta(blockAndPvtdata *ledger.BlockAndPvtData) (*ledger.StateDelta, error) {
+	txmgr.pvtdataPurgeMgr.WaitForPrepareToFinish()
+	txmgr.oldBlockCommit.Lock()
+	defer txmgr.oldBlockCommit.Unlock()
+
+	blockCopy := &ledger.BlockAndPvtData{
+		Block:          proto.Clone(blockAndPvtdata.Block).(*common.Block),
+		PvtData:        blockAndPvtdata.PvtData,
+		MissingPvtData: blockAndPvtdata.MissingPvtData,
+	}
+	batch, _, _, err := txmgr.commitBatchPreparer.ValidateAndPrepareBatch(blockCopy, true)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := &ledger.StateDelta{
+		Namespaces: map[string]map[string]*ledger.KeyChange{},
+	}
+	for _, ns := range batch.PubUpdates.GetUpdatedNamespaces() {
+		nsDelta := map[string]*ledger.KeyChange{}
+		for key, vv := range batch.PubUpdates.GetUpdates(ns) {
+			oldVV, err := txmgr.db.GetState(ns, key)
+			if err != nil {
+				return nil, err
+			}
+			change := &ledger.KeyChange{
+				NewValue: vv.Value,
+				IsDelete: vv.Value == nil,
+			}
+			if oldVV != nil {
+				change.OldValue = oldVV.Value
+			}
+			nsDelta[key] = change
+		}
+		delta.Namespaces[ns] = nsDelta
+	}
+	return delta, nil
+}
+
 // RemoveStaleAndCommitPvtDataOfOldBlocks implements method in interface `txmgmt.TxMgr`
 // The following six operations are performed:
 // (1) constructs the unique pvt data from the passed reconciledPvtdata
@@ -495,6 +576,12 @@ func (txmgr *LockBasedTxMgr) UpdateBatchWithAppInitiatedPvtKeysToPurge(keys map[
 	txmgr.currentUpdates.purgeAppInitiatedPvtKeys(keys)
 }
 
+// ScheduledPvtdataPurges returns the private data keys that are scheduled to be purged, as per the block-to-live of
+// their collections, with the commit of the blocks in the range [startBlk, endBlk]
+func (txmgr *LockBasedTxMgr) ScheduledPvtdataPurges(startBlk, endBlk uint64) ([]*ledger.ScheduledPvtdataPurge, error) {
+	return txmgr.pvtdataPurgeMgr.ScheduledPurges(startBlk, endBlk)
+}
+
 // Commit implements method in interface `txmgmt.TxMgr`
 func (txmgr *LockBasedTxMgr) Commit() error {
 	// we need to acquire a lock on oldBlockCommit. The following are the two reasons:
@@ -527,12 +614,12 @@ func (txmgr *LockBasedTxMgr) Commit() error {
 	}
 
 	if err := txmgr.pvtdataPurgeMgr.UpdateExpiryInfo(
-		txmgr.currentUpdates.batch.PvtUpdates, txmgr.currentUpdates.batch.HashUpdates); err != nil {
+		txmgr.currentUpdates.batch.PubUpdates, txmgr.currentUpdates.batch.PvtUpdates, txmgr.currentUpdates.batch.HashUpdates); err != nil {
 		return err
 	}
 
 	if err := txmgr.pvtdataPurgeMgr.AddExpiredEntriesToUpdateBatch(
-		txmgr.currentUpdates.batch.PvtUpdates, txmgr.currentUpdates.batch.HashUpdates); err != nil {
+		txmgr.currentUpdates.batch.PubUpdates, txmgr.currentUpdates.batch.PvtUpdates, txmgr.currentUpdates.batch.HashUpdates); err != nil {
 		return err
 	}
 
@@ -543,11 +630,13 @@ func (txmgr *LockBasedTxMgr) Commit() error {
 		txmgr.commitRWLock.Unlock()
 		return err
 	}
-	txmgr.commitRWLock.Unlock()
 	// only while holding a lock on oldBlockCommit, we should clear the cache as the
 	// cache is being used by the old pvtData committer to load the version of
 	// hashedKeys. Also, note that the PrepareForExpiringKeys uses the cache.
+	// The cache is cleared before releasing the commit lock so that the simulations,
+	// which may read the versions via the cache, do not see the versions from before this commit
 	txmgr.clearCache()
+	txmgr.commitRWLock.Unlock()
 	logger.Debugf("Updates committed to state database and the write lock is released")
 
 	// purge manager should be called (in this call the purge mgr removes the expiry entries from schedules) after committing to statedb
@@ -607,6 +696,53 @@ func (txmgr *LockBasedTxMgr) CommitLostBlock(blockAndPvtdata *ledger.BlockAndPvt
 	return txmgr.Commit()
 }
 
+// DropNamespaces deletes the data of the given namespaces from the statedb, leaving the savepoint unchanged. This is
+// expected to be invoked while the ledger is being opened, before any block is committed
+func (txmgr *LockBasedTxMgr) DropNamespaces(namespaces []string) error {
+	if err := txmgr.db.DropNamespaces(namespaces); err != nil {
+		return err
+	}
+	txmgr.clearCache()
+	return nil
+}
+
+// RecommitNamespaces recommits the updates of the given namespaces in the block to the statedb, without changing the
+// savepoint. This is used for recomputing the data of the namespaces, after dropping it via DropNamespaces, from the
+// blocks up to the savepoint. As the updates of the other namespaces are left out, the block is not validated again
+// and the validation codes recorded in the block are used
+func (txmgr *LockBasedTxMgr) RecommitNamespaces(blockAndPvtdata *ledger.BlockAndPvtData, namespaces []string) error {
+	batch, _, _, err := txmgr.commitBatchPreparer.ValidateAndPrepareBatch(blockAndPvtdata, false)
+	if err != nil {
+		return err
+	}
+	retainNamespaces(batch, namespaces)
+	if err := txmgr.db.ApplyPrivacyAwareUpdates(batch, nil); err != nil {
+		return err
+	}
+	txmgr.clearCache()
+	return nil
+}
+
+// retainNamespaces removes from the batch the updates of the namespaces other than the given ones
+func retainNamespaces(batch *privacyenabledstate.UpdateBatch, namespaces []string) {
+	retain := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		retain[ns] = struct{}{}
+	}
+	for ns := range batch.PubUpdates.Updates {
+		if _, ok := retain[ns]; !ok {
+			delete(batch.PubUpdates.Updates, ns)
+		}
+	}
+	for _, updateMap := range []privacyenabledstate.UpdateMap{batch.HashUpdates.UpdateMap, batch.PvtUpdates.UpdateMap} {
+		for ns := range updateMap {
+			if _, ok := retain[ns]; !ok {
+				delete(updateMap, ns)
+			}
+		}
+	}
+}
+
 // ExportPubStateAndPvtStateHashes simply delegates the call to the statedb for exporting the data for a snapshot.
 // It is assumed that the consumer would invoke this function when the commits are paused
 func (txmgr *LockBasedTxMgr) ExportPubStateAndPvtStateHashes(dir string, newHashFunc snapshot.NewHashFunc) (map[string][]byte, error) {
@@ -614,6 +750,29 @@ func (txmgr *LockBasedTxMgr) ExportPubStateAndPvtStateHashes(dir string, newHash
 	return txmgr.db.ExportPubStateAndPvtStateHashes(dir, newHashFunc)
 }
 
+// ExportPubStateAndPvtStateHashesDelta simply delegates the call to the statedb for exporting the data for an
+// incremental snapshot. It is assumed that the consumer would invoke this function when the commits are paused
+func (txmgr *LockBasedTxMgr) ExportPubStateAndPvtStateHashesDelta(
+	dir string,
+	newHashFunc snapshot.NewHashFunc,
+	baseBlockNum uint64,
+	deleteCandidates *privacyenabledstate.KeysSet,
+) (map[string][]byte, error) {
+	return txmgr.db.ExportPubStateAndPvtStateHashesDelta(dir, newHashFunc, baseBlockNum, deleteCandidates)
+}
+
+// ComputePubStateHash simply delegates the call to the statedb for computing the digest of the public state.
+// It is assumed that the consumer would invoke this function when the commits are paused
+func (txmgr *LockBasedTxMgr) ComputePubStateHash(newHashFunc snapshot.NewHashFunc) ([]byte, error) {
+	return txmgr.db.ComputePubStateHash(newHashFunc)
+}
+
+// CompactStateDB simply delegates the call to the statedb for compacting its physical storage.
+// It is assumed that the consumer would invoke this function when the commits are paused
+func (txmgr *LockBasedTxMgr) CompactStateDB() error {
+	return txmgr.db.Compact()
+}
+
 func extractStateUpdates(batch *privacyenabledstate.UpdateBatch, namespaces []string) ledger.StateUpdates {
 	su := make(ledger.StateUpdates)
 	for _, namespace := range namespaces {
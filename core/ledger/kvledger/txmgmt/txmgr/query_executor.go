@@ -15,6 +15,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statemetadata"
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/pkg/errors"
 )
@@ -36,6 +37,8 @@ type queryExecutor struct {
 	hasher            rwsetutil.HashFunc
 	txid              string
 	privateReads      *ledger.PrivateReads
+	// readAuthorizer, if set, is consulted for the reads of the public state
+	readAuthorizer func(namespace, key string) error
 }
 
 func newQueryExecutor(txmgr *LockBasedTxMgr,
@@ -68,6 +71,9 @@ func (q *queryExecutor) getState(ns, key string) ([]byte, []byte, error) {
 	if err := q.checkDone(); err != nil {
 		return nil, nil, err
 	}
+	if err := q.authorizeRead(ns, key); err != nil {
+		return nil, nil, err
+	}
 	versionedValue, err := q.txmgr.db.GetState(ns, key)
 	if err != nil {
 		return nil, nil, err
@@ -84,6 +90,9 @@ func (q *queryExecutor) GetStateMetadata(ns, key string) (map[string][]byte, err
 	if err := q.checkDone(); err != nil {
 		return nil, err
 	}
+	if err := q.authorizeRead(ns, key); err != nil {
+		return nil, err
+	}
 	var metadata []byte
 	var err error
 	if !q.collectReadset {
@@ -98,14 +107,73 @@ func (q *queryExecutor) GetStateMetadata(ns, key string) (map[string][]byte, err
 	return statemetadata.Deserialize(metadata)
 }
 
+// GetStateVersion implements method in interface `ledger.QueryExecutor`
+func (q *queryExecutor) GetStateVersion(ns, key string) (*kvrwset.Version, error) {
+	if err := q.checkDone(); err != nil {
+		return nil, err
+	}
+	if err := q.authorizeRead(ns, key); err != nil {
+		return nil, err
+	}
+	// the version is read without retrieving the value, where supported by the statedb
+	ver, err := q.txmgr.db.GetVersion(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	if q.collectReadset {
+		q.rwsetBuilder.AddToReadSet(ns, key, ver)
+	}
+	if ver == nil {
+		return nil, nil
+	}
+	return &kvrwset.Version{BlockNum: ver.BlockNum, TxNum: ver.TxNum}, nil
+}
+
+// GetStateProof implements method in interface `ledger.QueryExecutor`
+func (q *queryExecutor) GetStateProof(ns, key string) (*stateproof.Proof, error) {
+	if err := q.checkDone(); err != nil {
+		return nil, err
+	}
+	if err := q.authorizeRead(ns, key); err != nil {
+		return nil, err
+	}
+	versionedValue, err := q.txmgr.db.GetState(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	_, _, ver := decomposeVersionedValue(versionedValue)
+	if q.collectReadset {
+		q.rwsetBuilder.AddToReadSet(ns, key, ver)
+	}
+	if ver == nil {
+		return nil, nil
+	}
+	// the commits are held off while the query executor is in use, so the proof and the savepoint are at the same height
+	proof, err := q.txmgr.db.GetPubStateProof(ns, key)
+	if err != nil || proof == nil {
+		return nil, err
+	}
+	savepoint, err := q.txmgr.db.GetLatestSavePoint()
+	if err != nil {
+		return nil, err
+	}
+	proof.BlockNum = savepoint.BlockNum
+	return proof, nil
+}
+
 // GetStateMultipleKeys implements method in interface `ledger.QueryExecutor`
 func (q *queryExecutor) GetStateMultipleKeys(ns string, keys []string) ([][]byte, error) {
 	if err := q.checkDone(); err != nil {
 		return nil, err
 	}
+	for _, key := range keys {
+		if err := q.authorizeRead(ns, key); err != nil {
+			return nil, err
+		}
+	}
 	versionedValues, err := q.txmgr.db.GetStateMultipleKeys(ns, keys)
 	if err != nil {
-		return nil, nil
+		return nil, err
 	}
 	values := make([][]byte, len(versionedValues))
 	for i, versionedValue := range versionedValues {
@@ -140,6 +208,7 @@ func (q *queryExecutor) GetStateRangeScanIterator(namespace string, startKey str
 	if err != nil {
 		return nil, err
 	}
+	itr.readAuthorizer = q.readAuthorizer
 	q.itrs = append(q.itrs, itr)
 	return itr, nil
 }
@@ -162,6 +231,7 @@ func (q *queryExecutor) GetStateRangeScanIteratorWithPagination(namespace string
 	if err != nil {
 		return nil, err
 	}
+	itr.readAuthorizer = q.readAuthorizer
 	q.itrs = append(q.itrs, itr)
 	return itr, nil
 }
@@ -175,7 +245,7 @@ func (q *queryExecutor) ExecuteQuery(namespace, query string) (commonledger.Resu
 	if err != nil {
 		return nil, err
 	}
-	return &queryResultsItr{DBItr: dbItr, RWSetBuilder: q.rwsetBuilder}, nil
+	return &queryResultsItr{DBItr: dbItr, RWSetBuilder: q.rwsetBuilder, readAuthorizer: q.readAuthorizer}, nil
 }
 
 func (q *queryExecutor) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (ledger.QueryResultsIterator, error) {
@@ -186,7 +256,7 @@ func (q *queryExecutor) ExecuteQueryWithPagination(namespace, query, bookmark st
 	if err != nil {
 		return nil, err
 	}
-	return &queryResultsItr{DBItr: dbItr, RWSetBuilder: q.rwsetBuilder}, nil
+	return &queryResultsItr{DBItr: dbItr, RWSetBuilder: q.rwsetBuilder, readAuthorizer: q.readAuthorizer}, nil
 }
 
 // GetPrivateData implements method in interface `ledger.QueryExecutor`
@@ -226,6 +296,18 @@ func (q *queryExecutor) GetPrivateData(ns, coll, key string) ([]byte, error) {
 	return val, nil
 }
 
+// authorizeRead consults the readAuthorizer, if set, for the read of the given key of the public state
+func (q *queryExecutor) authorizeRead(ns, key string) error {
+	if q.readAuthorizer == nil {
+		return nil
+	}
+	if err := q.readAuthorizer(ns, key); err != nil {
+		return &ledger.ReadNotAuthorizedError{Namespace: ns, Key: key, Cause: err}
+	}
+	return nil
+}
+
+// GetPrivateDataHash implements method in interface `ledger.QueryExecutor`
 func (q *queryExecutor) GetPrivateDataHash(ns, coll, key string) ([]byte, error) {
 	if err := q.validateCollName(ns, coll); err != nil {
 		return nil, err
@@ -344,6 +426,22 @@ func (q *queryExecutor) GetPrivateDataRangeScanIterator(ns, coll, startKey, endK
 	return &pvtdataResultsItr{ns, coll, dbItr}, nil
 }
 
+// GetPrivateDataHashByRange implements method in interface `ledger.QueryExecutor`.
+// Same as for the function GetPrivateDataRangeScanIterator, the reads are not recorded in the read-set
+func (q *queryExecutor) GetPrivateDataHashByRange(ns, coll string, startKeyHash, endKeyHash []byte) (commonledger.ResultsIterator, error) {
+	if err := q.validateCollName(ns, coll); err != nil {
+		return nil, err
+	}
+	if err := q.checkDone(); err != nil {
+		return nil, err
+	}
+	dbItr, err := q.txmgr.db.GetValueHashRangeScanIterator(ns, coll, startKeyHash, endKeyHash)
+	if err != nil {
+		return nil, err
+	}
+	return &pvtdataHashResultsItr{ns, coll, dbItr}, nil
+}
+
 // ExecuteQueryOnPrivateData implements method in interface `ledger.QueryExecutor`
 func (q *queryExecutor) ExecuteQueryOnPrivateData(ns, coll, query string) (commonledger.ResultsIterator, error) {
 	if err := q.validateCollName(ns, coll); err != nil {
@@ -359,6 +457,18 @@ func (q *queryExecutor) ExecuteQueryOnPrivateData(ns, coll, query string) (commo
 	return &pvtdataResultsItr{ns, coll, dbItr}, nil
 }
 
+// GetNamespaceFullScanIterator implements method in interface `ledger.QueryExecutor`
+func (q *queryExecutor) GetNamespaceFullScanIterator(namespace string) (commonledger.ResultsIterator, error) {
+	if err := q.checkDone(); err != nil {
+		return nil, err
+	}
+	dbItr, err := q.txmgr.db.GetStateRangeScanIterator(namespace, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return &namespaceFullScanItr{dbItr: dbItr}, nil
+}
+
 // Done implements method in interface `ledger.QueryExecutor`
 func (q *queryExecutor) Done() {
 	logger.Debugf("Done with transaction simulation / query execution [%s]", q.txid)
@@ -397,6 +507,8 @@ type resultsItr struct {
 	rwSetBuilder            *rwsetutil.RWSetBuilder
 	rangeQueryInfo          *kvrwset.RangeQueryInfo
 	rangeQueryResultsHelper *rwsetutil.RangeQueryResultsHelper
+	// readAuthorizer, if set, is consulted for each key and the keys that it rejects are skipped
+	readAuthorizer func(namespace, key string) error
 }
 
 func newResultsItr(ns string, startKey string, endKey string, pageSize int32,
@@ -436,22 +548,28 @@ func newResultsItr(ns string, startKey string, endKey string, pageSize int32,
 // set the EndKey and ItrExhausted in the Close() function but it may not be desirable to change
 // transactional behaviour based on whether the Close() was invoked or not
 func (itr *resultsItr) Next() (commonledger.QueryResult, error) {
-	queryResult, err := itr.dbItr.Next()
-	if err != nil {
-		return nil, err
-	}
-	if err := itr.updateRangeQueryInfo(queryResult); err != nil {
-		return nil, err
-	}
-	if queryResult == nil {
-		return nil, nil
-	}
+	for {
+		queryResult, err := itr.dbItr.Next()
+		if err != nil {
+			return nil, err
+		}
+		if err := itr.updateRangeQueryInfo(queryResult); err != nil {
+			return nil, err
+		}
+		if queryResult == nil {
+			return nil, nil
+		}
+		if itr.readAuthorizer != nil && itr.readAuthorizer(queryResult.Namespace, queryResult.Key) != nil {
+			logger.Debugf("Skipping the key [%s] in namespace [%s] as its read is not authorized", queryResult.Key, queryResult.Namespace)
+			continue
+		}
 
-	return &queryresult.KV{
-		Namespace: queryResult.Namespace,
-		Key:       queryResult.Key,
-		Value:     queryResult.Value,
-	}, nil
+		return &queryresult.KV{
+			Namespace: queryResult.Namespace,
+			Key:       queryResult.Key,
+			Value:     queryResult.Value,
+		}, nil
+	}
 }
 
 // GetBookmarkAndClose implements method in interface ledger.ResultsIterator
@@ -498,6 +616,8 @@ func (itr *resultsItr) Close() {
 type queryResultsItr struct {
 	DBItr        statedb.ResultsIterator
 	RWSetBuilder *rwsetutil.RWSetBuilder
+
+	readAuthorizer func(namespace, key string) error
 }
 
 // Next implements method in interface ledger.ResultsIterator
@@ -506,6 +626,12 @@ func (itr *queryResultsItr) Next() (commonledger.QueryResult, error) {
 	if err != nil {
 		return nil, err
 	}
+	for queryResult != nil && itr.readAuthorizer != nil && itr.readAuthorizer(queryResult.Namespace, queryResult.Key) != nil {
+		logger.Debugf("Skipping the key [%s] in namespace [%s] as its read is not authorized", queryResult.Key, queryResult.Namespace)
+		if queryResult, err = itr.DBItr.Next(); err != nil {
+			return nil, err
+		}
+	}
 	if queryResult == nil {
 		return nil, nil
 	}
@@ -574,6 +700,72 @@ func (itr *pvtdataResultsItr) Close() {
 	itr.dbItr.Close()
 }
 
+// pvtdataHashResultsItr iterates over the key hashes of a collection and returns the value hashes
+type pvtdataHashResultsItr struct {
+	ns    string
+	coll  string
+	dbItr statedb.ResultsIterator
+}
+
+// Next implements method in interface ledger.ResultsIterator
+func (itr *pvtdataHashResultsItr) Next() (commonledger.QueryResult, error) {
+	queryResult, err := itr.dbItr.Next()
+	if err != nil {
+		return nil, err
+	}
+	if queryResult == nil {
+		return nil, nil
+	}
+	pvtdataHashKV := &ledger.PvtdataHashKV{
+		Namespace:  itr.ns,
+		Collection: itr.coll,
+		KeyHash:    []byte(queryResult.Key),
+		ValueHash:  queryResult.Value,
+	}
+	if queryResult.Version != nil {
+		pvtdataHashKV.BlockNum = queryResult.Version.BlockNum
+		pvtdataHashKV.TxNum = queryResult.Version.TxNum
+	}
+	return pvtdataHashKV, nil
+}
+
+// Close implements method in interface ledger.ResultsIterator
+func (itr *pvtdataHashResultsItr) Close() {
+	itr.dbItr.Close()
+}
+
+// namespaceFullScanItr iterates over all the keys in a namespace and returns the versioned values
+type namespaceFullScanItr struct {
+	dbItr statedb.ResultsIterator
+}
+
+// Next implements method in interface ledger.ResultsIterator
+func (itr *namespaceFullScanItr) Next() (commonledger.QueryResult, error) {
+	queryResult, err := itr.dbItr.Next()
+	if err != nil {
+		return nil, err
+	}
+	if queryResult == nil {
+		return nil, nil
+	}
+	versionedKV := &ledger.VersionedKV{
+		Namespace: queryResult.Namespace,
+		Key:       queryResult.Key,
+		Value:     queryResult.Value,
+		Metadata:  queryResult.Metadata,
+	}
+	if queryResult.Version != nil {
+		versionedKV.BlockNum = queryResult.Version.BlockNum
+		versionedKV.TxNum = queryResult.Version.TxNum
+	}
+	return versionedKV, nil
+}
+
+// Close implements method in interface ledger.ResultsIterator
+func (itr *namespaceFullScanItr) Close() {
+	itr.dbItr.Close()
+}
+
 func (q *queryExecutor) addRangeQueryInfo() {
 	if !q.collectReadset {
 		return
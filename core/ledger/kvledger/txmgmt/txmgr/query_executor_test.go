@@ -7,13 +7,16 @@ SPDX-License-Identifier: Apache-2.0
 package txmgr
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"sort"
 	"testing"
 
 	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/internal/version"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
@@ -69,6 +72,91 @@ func TestPvtdataResultsItr(t *testing.T) {
 	testItr(t, resItr, "ns4", "coll1", []string{})
 }
 
+func TestPvtdataHashResultsItr(t *testing.T) {
+	testEnv := testEnvsMap[levelDBtestEnvName]
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns1", "coll1"}: 0,
+			{"ns1", "coll2"}: 0,
+		},
+	)
+	testEnv.init(t, "test-pvtdata-hash-range-queries", btlPolicy)
+	defer testEnv.cleanup()
+
+	txMgr := testEnv.getTxMgr()
+	populateCollConfigForTest(t, txMgr, []collConfigkey{{"ns1", "coll1"}, {"ns1", "coll2"}}, version.NewHeight(1, 0))
+
+	updates := privacyenabledstate.NewUpdateBatch()
+	putPvtUpdates(t, updates, "ns1", "coll1", "key1", []byte("pvt_value1"), version.NewHeight(1, 1))
+	putPvtUpdates(t, updates, "ns1", "coll1", "key2", []byte("pvt_value2"), version.NewHeight(1, 2))
+	putPvtUpdates(t, updates, "ns1", "coll2", "key3", []byte("pvt_value3"), version.NewHeight(1, 3))
+	require.NoError(t, txMgr.db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(1, 3)))
+
+	expectedResults := []*ledger.PvtdataHashKV{
+		{
+			Namespace:  "ns1",
+			Collection: "coll1",
+			KeyHash:    util.ComputeStringHash("key1"),
+			ValueHash:  util.ComputeStringHash("pvt_value1"),
+			BlockNum:   1,
+			TxNum:      1,
+		},
+		{
+			Namespace:  "ns1",
+			Collection: "coll1",
+			KeyHash:    util.ComputeStringHash("key2"),
+			ValueHash:  util.ComputeStringHash("pvt_value2"),
+			BlockNum:   1,
+			TxNum:      2,
+		},
+	}
+	sort.Slice(expectedResults, func(i, j int) bool {
+		return bytes.Compare(expectedResults[i].KeyHash, expectedResults[j].KeyHash) < 0
+	})
+
+	collectResults := func(itr commonledger.ResultsIterator) []*ledger.PvtdataHashKV {
+		defer itr.Close()
+		var results []*ledger.PvtdataHashKV
+		for {
+			queryResult, err := itr.Next()
+			require.NoError(t, err)
+			if queryResult == nil {
+				return results
+			}
+			results = append(results, queryResult.(*ledger.PvtdataHashKV))
+		}
+	}
+
+	qe := newQueryExecutor(txMgr, "", nil, true, testHashFunc)
+	resItr, err := qe.GetPrivateDataHashByRange("ns1", "coll1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, expectedResults, collectResults(resItr))
+
+	resItr, err = qe.GetPrivateDataHashByRange("ns1", "coll1", expectedResults[1].KeyHash, nil)
+	require.NoError(t, err)
+	require.Equal(t, expectedResults[1:], collectResults(resItr))
+
+	resItr, err = qe.GetPrivateDataHashByRange("ns1", "coll1", nil, expectedResults[1].KeyHash)
+	require.NoError(t, err)
+	require.Equal(t, expectedResults[:1], collectResults(resItr))
+
+	_, err = qe.GetPrivateDataHashByRange("ns1", "coll3", nil, nil)
+	require.EqualError(t, err, "collection [coll3] not defined in the collection config for chaincode [ns1]")
+
+	// the reads are not recorded in the read-set of the simulation and the writes are not allowed thereafter
+	simulator, err := txMgr.NewTxSimulator("txid1")
+	require.NoError(t, err)
+	resItr, err = simulator.GetPrivateDataHashByRange("ns1", "coll1", nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, expectedResults, collectResults(resItr))
+	require.EqualError(t, simulator.SetState("ns1", "key", []byte("value")),
+		"txid [txid1]: unsuppored transaction. Transaction has already performed queries on pvt data. Writes are not allowed")
+	simulator.Done()
+	simRes, err := simulator.GetTxSimulationResults()
+	require.NoError(t, err)
+	require.Empty(t, simRes.PubSimulationResults.NsRwset)
+}
+
 func testItr(t *testing.T, itr commonledger.ResultsIterator, expectedNs string, expectedColl string, expectedKeys []string) {
 	t.Logf("Testing itr for [%d] keys", len(expectedKeys))
 	defer itr.Close()
@@ -149,6 +149,14 @@ func (s *txSimulator) GetPrivateDataRangeScanIterator(namespace, collection, sta
 	return s.queryExecutor.GetPrivateDataRangeScanIterator(namespace, collection, startKey, endKey)
 }
 
+// GetPrivateDataHashByRange implements method in interface `ledger.TxSimulator`
+func (s *txSimulator) GetPrivateDataHashByRange(namespace, collection string, startKeyHash, endKeyHash []byte) (commonledger.ResultsIterator, error) {
+	if err := s.checkBeforePvtdataQueries(); err != nil {
+		return nil, err
+	}
+	return s.queryExecutor.GetPrivateDataHashByRange(namespace, collection, startKeyHash, endKeyHash)
+}
+
 // SetPrivateDataMetadata implements method in interface `ledger.TxSimulator`
 func (s *txSimulator) SetPrivateDataMetadata(namespace, collection, key string, metadata map[string][]byte) error {
 	if err := s.queryExecutor.validateCollName(namespace, collection); err != nil {
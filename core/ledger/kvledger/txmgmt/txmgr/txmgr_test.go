@@ -26,6 +26,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	btltestutil "github.com/hyperledger/fabric/core/ledger/pvtdatapolicy/testutil"
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/stretchr/testify/require"
 )
@@ -545,6 +546,185 @@ func testIteratorWithDeletes(t *testing.T, env testEnv) {
 	require.Equal(t, createTestKey(5), kv.(*queryresult.KV).Key)
 }
 
+func TestNamespaceFullScanIterator(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Logf("Running test for TestEnv = %s", testEnv.getName())
+		testLedgerID := "testnamespacefullscanitr"
+		testEnv.init(t, testLedgerID, nil)
+		testNamespaceFullScanIterator(t, testEnv)
+		testEnv.cleanup()
+	}
+}
+
+func testNamespaceFullScanIterator(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+
+	s, _ := txMgr.NewTxSimulator("test_tx1")
+	for _, i := range []int{3, 1, 5, 2, 4} {
+		require.NoError(t, s.SetState("ns1", createTestKey(i), createTestValue(i)))
+	}
+	for _, i := range []int{6, 7} {
+		require.NoError(t, s.SetState("ns2", createTestKey(i), createTestValue(i)))
+	}
+	s.Done()
+	txRWSet1, _ := s.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet1.PubSimulationResults)
+
+	s, _ = txMgr.NewTxSimulator("test_tx2")
+	require.NoError(t, s.SetState("ns1", createTestKey(2), []byte("updated-value")))
+	s.Done()
+	txRWSet2, _ := s.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet2.PubSimulationResults)
+
+	qe, err := txMgr.NewQueryExecutor("test_tx3")
+	require.NoError(t, err)
+	defer qe.Done()
+	itr, err := qe.GetNamespaceFullScanIterator("ns1")
+	require.NoError(t, err)
+	defer itr.Close()
+
+	var results []*ledger.VersionedKV
+	for {
+		res, err := itr.Next()
+		require.NoError(t, err)
+		if res == nil {
+			break
+		}
+		results = append(results, res.(*ledger.VersionedKV))
+	}
+	require.Len(t, results, 5)
+	for i, res := range results {
+		require.Equal(t, "ns1", res.Namespace)
+		require.Equal(t, createTestKey(i+1), res.Key)
+	}
+	require.Equal(t, []byte("updated-value"), results[1].Value)
+	require.Equal(t, createTestValue(1), results[0].Value)
+	require.Equal(t, results[0].BlockNum+1, results[1].BlockNum)
+	for _, i := range []int{0, 2, 3, 4} {
+		require.Equal(t, results[0].BlockNum, results[i].BlockNum)
+		require.Equal(t, uint64(0), results[i].TxNum)
+	}
+}
+
+func TestGetStateVersion(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Logf("Running test for TestEnv = %s", testEnv.getName())
+		testLedgerID := "testgetstateversion"
+		testEnv.init(t, testLedgerID, nil)
+		testGetStateVersion(t, testEnv)
+		testEnv.cleanup()
+	}
+}
+
+func testGetStateVersion(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+
+	// commit a block with two transactions, each writing a different key
+	var rwSets [][]byte
+	for i := 1; i <= 2; i++ {
+		s, _ := txMgr.NewTxSimulator(fmt.Sprintf("test_tx%d", i))
+		require.NoError(t, s.SetState("ns1", createTestKey(i), createTestValue(i)))
+		s.Done()
+		txRWSet, _ := s.GetTxSimulationResults()
+		rwSetBytes, err := proto.Marshal(txRWSet.PubSimulationResults)
+		require.NoError(t, err)
+		rwSets = append(rwSets, rwSetBytes)
+	}
+	block := txMgrHelper.bg.NextBlock(rwSets)
+	_, _, _, err := txMgr.ValidateAndPrepare(&ledger.BlockAndPvtData{Block: block}, true)
+	require.NoError(t, err)
+	require.NoError(t, txMgr.Commit())
+
+	qe, err := txMgr.NewQueryExecutor("test_tx3")
+	require.NoError(t, err)
+	defer qe.Done()
+	ver, err := qe.GetStateVersion("ns1", createTestKey(1))
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&kvrwset.Version{BlockNum: block.Header.Number, TxNum: 0}, ver))
+	ver, err = qe.GetStateVersion("ns1", createTestKey(2))
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&kvrwset.Version{BlockNum: block.Header.Number, TxNum: 1}, ver))
+
+	ver, err = qe.GetStateVersion("ns1", "non-existent-key")
+	require.NoError(t, err)
+	require.Nil(t, ver)
+
+	// the version read by a simulator is recorded in the read-set
+	s, _ := txMgr.NewTxSimulator("test_tx4")
+	_, err = s.GetStateVersion("ns1", createTestKey(2))
+	require.NoError(t, err)
+	s.Done()
+	txRWSet, _ := s.GetTxSimulationResults()
+	reads := txRWSet.PubSimulationResults.NsRwset[0].Rwset
+	kvRWSet := &kvrwset.KVRWSet{}
+	require.NoError(t, proto.Unmarshal(reads, kvRWSet))
+	require.Len(t, kvRWSet.Reads, 1)
+	require.Equal(t, createTestKey(2), kvRWSet.Reads[0].Key)
+	require.True(t, proto.Equal(&kvrwset.Version{BlockNum: block.Header.Number, TxNum: 1}, kvRWSet.Reads[0].Version))
+}
+
+func TestGetStateProof(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Logf("Running test for TestEnv = %s", testEnv.getName())
+		testLedgerID := "testgetstateproof"
+		testEnv.init(t, testLedgerID, nil)
+		testGetStateProof(t, testEnv)
+		testEnv.cleanup()
+	}
+}
+
+func testGetStateProof(t *testing.T, env testEnv) {
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+
+	s, _ := txMgr.NewTxSimulator("test_tx1")
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, s.SetState("ns1", createTestKey(i), createTestValue(i)))
+	}
+	require.NoError(t, s.SetState("ns2", createTestKey(1), createTestValue(1)))
+	require.NoError(t, s.SetState("ns3", createTestKey(1), createTestValue(1)))
+	s.Done()
+	txRWSet, _ := s.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet.PubSimulationResults)
+
+	qe, err := txMgr.NewQueryExecutor("test_tx2")
+	require.NoError(t, err)
+	defer qe.Done()
+
+	var root []byte
+	verifyProof := func(ns string, i int) {
+		proof, err := qe.GetStateProof(ns, createTestKey(i))
+		require.NoError(t, err)
+		require.Equal(t, ns, proof.Namespace)
+		require.Equal(t, createTestKey(i), proof.Key)
+		require.Equal(t, createTestValue(i), proof.Value)
+		require.Equal(t, uint64(1), proof.BlockNum)
+		if root == nil {
+			root = proof.Root
+		}
+		// the proofs for all the keys lead to the same state root
+		require.Equal(t, root, proof.Root)
+		require.NoError(t, stateproof.Verify(proof, root))
+	}
+	for i := 1; i <= 5; i++ {
+		verifyProof("ns1", i)
+	}
+	verifyProof("ns2", 1)
+	verifyProof("ns3", 1)
+
+	proof, err := qe.GetStateProof("ns1", "non-existent-key")
+	require.NoError(t, err)
+	require.Nil(t, proof)
+
+	// a proof does not verify for a value other than the committed one
+	proof, err = qe.GetStateProof("ns2", createTestKey(1))
+	require.NoError(t, err)
+	proof.Value = createTestValue(2)
+	require.Error(t, stateproof.Verify(proof, root))
+}
+
 func TestTxValidationWithItr(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
@@ -659,6 +839,13 @@ func testGetSetMultipeKeys(t *testing.T, env testEnv) {
 	for i, v := range values {
 		require.Equal(t, multipleKeyMap[multipleKeys[i+5]], v)
 	}
+
+	// a mix of present, absent, and repeated keys
+	values, err := qe.GetStateMultipleKeys(cID,
+		[]string{createTestKey(3), createTestKey(11), createTestKey(1), createTestKey(3), createTestKey(12)},
+	)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{createTestValue(3), nil, createTestValue(1), createTestValue(3), nil}, values)
 }
 
 func createTestKey(i int) string {
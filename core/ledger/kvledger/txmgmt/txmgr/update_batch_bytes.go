@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package txmgr
 
 import (
+	"io"
 	"sort"
 
 	"github.com/golang/protobuf/proto"
@@ -27,35 +28,88 @@ import (
 // A Similar treatment is given to the repetitive entries for a collection within a namespace.
 // For illustration, see the corresponding unit tests
 func deterministicBytesForPubAndHashUpdates(u *privacyenabledstate.UpdateBatch) ([]byte, error) {
-	pubUpdates := u.PubUpdates
-	hashUpdates := u.HashUpdates.UpdateMap
-	namespaces := dedupAndSort(
-		pubUpdates.GetUpdatedNamespaces(),
-		hashUpdates.GetUpdatedNamespaces(),
-	)
-
 	kvWrites := []*KVWrite{}
-	for _, ns := range namespaces {
+	for _, ns := range namespacesForUpdateBatchBytes(u) {
+		kvWrites = append(kvWrites, genKVsForNamespace(u, ns)...)
+	}
+	updates := &Updates{
+		Kvwrites: kvWrites,
+	}
+
+	batchBytes, err := proto.Marshal(updates)
+	return batchBytes, errors.Wrap(err, "error constructing deterministic bytes from update batch")
+}
+
+// writeDeterministicBytesForPubAndHashUpdates writes the same bytes as returned by the function
+// deterministicBytesForPubAndHashUpdates to the given writer. The bytes for the namespaces are constructed in
+// parallel by the given number of workers and are written in the order of the namespaces. This relies on the
+// encoding of a repeated field, where the encoding of the whole 'Updates' message is the concatenation of the
+// encodings of the 'Updates' messages that hold the consecutive parts of the field
+func writeDeterministicBytesForPubAndHashUpdates(u *privacyenabledstate.UpdateBatch, workers int, w io.Writer) error {
+	namespaces := namespacesForUpdateBatchBytes(u)
+	type nsBytes struct {
+		bytes []byte
+		err   error
+	}
+	// each result channel is buffered so that the workers do not block on the results that are not consumed
+	// after a failure
+	results := make([]chan *nsBytes, len(namespaces))
+	for i := range results {
+		results[i] = make(chan *nsBytes, 1)
+	}
+	jobs := make(chan int, len(namespaces))
+	for i := range namespaces {
+		jobs <- i
+	}
+	close(jobs)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range jobs {
+				b, err := proto.Marshal(&Updates{Kvwrites: genKVsForNamespace(u, namespaces[j])})
+				results[j] <- &nsBytes{b, err}
+			}
+		}()
+	}
+
+	for _, result := range results {
+		r := <-result
+		if r.err != nil {
+			return errors.Wrap(r.err, "error constructing deterministic bytes from update batch")
+		}
+		if _, err := w.Write(r.bytes); err != nil {
+			return errors.Wrap(err, "error writing deterministic bytes of update batch")
+		}
+	}
+	return nil
+}
+
+// namespacesForUpdateBatchBytes returns the namespaces included in the deterministic bytes of the update batch,
+// in the order in which they appear in the bytes
+func namespacesForUpdateBatchBytes(u *privacyenabledstate.UpdateBatch) []string {
+	namespaces := []string{}
+	for _, ns := range dedupAndSort(
+		u.PubUpdates.GetUpdatedNamespaces(),
+		u.HashUpdates.UpdateMap.GetUpdatedNamespaces(),
+	) {
 		if ns == "" {
 			// an empty namespace is used for persisting the channel config
 			// skipping the channel config from including into commit hash computation
 			// as this proto uses maps and hence is non deterministic
 			continue
 		}
-		kvs := genKVsFromNsUpdates(pubUpdates.GetUpdates(ns))
-		collsForNs, ok := hashUpdates[ns]
-		if ok {
-			kvs = append(kvs, genKVsFromCollsUpdates(collsForNs)...)
-		}
-		kvs[0].Namespace = ns
-		kvWrites = append(kvWrites, kvs...)
-	}
-	updates := &Updates{
-		Kvwrites: kvWrites,
+		namespaces = append(namespaces, ns)
 	}
+	return namespaces
+}
 
-	batchBytes, err := proto.Marshal(updates)
-	return batchBytes, errors.Wrap(err, "error constructing deterministic bytes from update batch")
+func genKVsForNamespace(u *privacyenabledstate.UpdateBatch, ns string) []*KVWrite {
+	kvs := genKVsFromNsUpdates(u.PubUpdates.GetUpdates(ns))
+	collsForNs, ok := u.HashUpdates.UpdateMap[ns]
+	if ok {
+		kvs = append(kvs, genKVsFromCollsUpdates(collsForNs)...)
+	}
+	kvs[0].Namespace = ns
+	return kvs
 }
 
 func genKVsFromNsUpdates(nsUpdates map[string]*statedb.VersionedValue) []*KVWrite {
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package txmgr
 
 import (
+	"bytes"
+	"fmt"
 	"testing"
 
 	proto "github.com/golang/protobuf/proto"
@@ -151,3 +153,27 @@ func TestUpdateBatchBytesBuilderOnlyChannelConfig(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedBytes, bytes)
 }
+
+func TestWriteUpdateBatchBytes(t *testing.T) {
+	updateBatch := privacyenabledstate.NewUpdateBatch()
+	for i := 0; i < 20; i++ {
+		ns := fmt.Sprintf("ns%d", i)
+		updateBatch.PubUpdates.Put(ns, "key1", []byte("value1"), version.NewHeight(1, uint64(i)))
+		updateBatch.PubUpdates.Delete(ns, "key2", version.NewHeight(1, uint64(i)))
+		updateBatch.HashUpdates.Put(ns, "coll1", []byte("key3"), []byte("value3"), version.NewHeight(1, uint64(i)))
+	}
+	updateBatch.HashUpdates.Put("ns20", "coll1", []byte("key4"), []byte("value4"), version.NewHeight(1, 20))
+	updateBatch.PubUpdates.Put("", "resourcesconfigtx.CHANNEL_CONFIG_KEY", []byte("value1"), version.NewHeight(1, 21))
+
+	expectedBytes, err := deterministicBytesForPubAndHashUpdates(updateBatch)
+	require.NoError(t, err)
+	for _, workers := range []int{1, 3, 50} {
+		buf := &bytes.Buffer{}
+		require.NoError(t, writeDeterministicBytesForPubAndHashUpdates(updateBatch, workers, buf))
+		require.Equal(t, expectedBytes, buf.Bytes())
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, writeDeterministicBytesForPubAndHashUpdates(privacyenabledstate.NewUpdateBatch(), 2, buf))
+	require.Empty(t, buf.Bytes())
+}
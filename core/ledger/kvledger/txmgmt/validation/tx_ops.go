@@ -119,9 +119,8 @@ func (txops txOps) applyMetadata(ns, coll string, metadataWrite *kvrwset.KVMetad
 
 // retrieveLatestState returns the value of the key from the precedingUpdates (if the key was operated upon by a previous tran in the block).
 // If the key not present in the precedingUpdates, then this function, pulls the latest value from statedb
-// TODO FAB-11328, pulling from state for (especially for couchdb) will pay significant performance penalty so a bulkload would be helpful.
-// Further, all the keys that gets written will be required to pull from statedb by vscc for endorsement policy check (in the case of key level
-// endorsement) and hence, the bulkload should be combined
+// For the statedbs that are bulk optimizable (i.e., couchdb), the committed values of such keys are loaded in bulk before the validation
+// of the block (see function `preLoadCommittedStateOfWSet`), so that pulling from the statedb does not cost a round trip per key
 func retrieveLatestState(ns, coll, key string,
 	precedingUpdates *publicAndHashUpdates, db *privacyenabledstate.DB) (*statedb.VersionedValue, error) {
 	var vv *statedb.VersionedValue
@@ -77,6 +77,50 @@ func (v *validator) preLoadCommittedVersionOfRSet(blk *block) error {
 	return nil
 }
 
+// preLoadCommittedStateOfWSet loads committed values of the keys in the write sets of the transactions whose
+// committed value or metadata is merged into the updates (see function `prepareTxOps`) into a cache, so that
+// the merge does not read the keys from the statedb one at a time
+func (v *validator) preLoadCommittedStateOfWSet(blk *block) error {
+	var pubKeys []*statedb.CompositeKey
+	var hashedKeys []*privacyenabledstate.HashedCompositeKey
+	pubKeysMap := make(map[statedb.CompositeKey]interface{})
+	hashedKeysMap := make(map[privacyenabledstate.HashedCompositeKey]interface{})
+
+	for _, tx := range blk.txs {
+		txops := txOps{}
+		if err := txops.applyTxRwset(tx.rwset); err != nil {
+			// the error surfaces when the write set is applied, if the transaction turns out to be valid
+			continue
+		}
+		for ck, keyop := range txops {
+			if keyop.isDelete() || keyop.isUpsertAndMetadataUpdate() {
+				continue
+			}
+			if keyop.isOnlyUpsert() && !v.db.MetadataEverUsedFor(ck.ns) {
+				continue
+			}
+			if ck.coll == "" {
+				compositeKey := statedb.CompositeKey{Namespace: ck.ns, Key: ck.key}
+				if _, ok := pubKeysMap[compositeKey]; !ok {
+					pubKeysMap[compositeKey] = nil
+					pubKeys = append(pubKeys, &compositeKey)
+				}
+				continue
+			}
+			hashedCompositeKey := privacyenabledstate.HashedCompositeKey{Namespace: ck.ns, CollectionName: ck.coll, KeyHash: ck.key}
+			if _, ok := hashedKeysMap[hashedCompositeKey]; !ok {
+				hashedKeysMap[hashedCompositeKey] = nil
+				hashedKeys = append(hashedKeys, &hashedCompositeKey)
+			}
+		}
+	}
+
+	if len(pubKeys) > 0 || len(hashedKeys) > 0 {
+		return v.db.LoadCommittedStatesOfPubAndHashedKeys(pubKeys, hashedKeys)
+	}
+	return nil
+}
+
 // validateAndPrepareBatch performs validation and prepares the batch for final writes
 func (v *validator) validateAndPrepareBatch(blk *block, doMVCCValidation bool) (*publicAndHashUpdates, []*AppInitiatedPurgeUpdate, error) {
 	// Check whether statedb implements BulkOptimizable interface. For now,
@@ -87,6 +131,9 @@ func (v *validator) validateAndPrepareBatch(blk *block, doMVCCValidation bool) (
 		if err != nil {
 			return nil, nil, err
 		}
+		if err := v.preLoadCommittedStateOfWSet(blk); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	updates := newPubAndHashUpdates()
@@ -194,6 +194,90 @@ func TestValidatorBulkLoadingOfCache(t *testing.T) {
 	}
 }
 
+func TestValidatorBulkLoadingOfWriteSetStates(t *testing.T) {
+	testDBEnv := testEnvs[levelDBtestEnvName]
+	testDBEnv.Init(t)
+	defer testDBEnv.Cleanup()
+	db := testDBEnv.GetDBHandle("testdb")
+
+	// metadata is used in namespace ns1 but never in namespace ns2
+	batch := privacyenabledstate.NewUpdateBatch()
+	batch.PubUpdates.PutValAndMetadata("ns1", "key1", []byte("value1"), []byte("metadata1"), version.NewHeight(1, 0))
+	batch.PubUpdates.Put("ns2", "key1", []byte("value1"), version.NewHeight(1, 1))
+	require.NoError(t, db.ApplyPrivacyAwareUpdates(batch, version.NewHeight(1, 1)))
+	require.True(t, db.MetadataEverUsedFor("ns1"))
+	require.False(t, db.MetadataEverUsedFor("ns2"))
+
+	spyVDB := &bulkLoadingSpyVDB{VersionedDB: db.VersionedDB}
+	db.VersionedDB = spyVDB
+	testValidator := &validator{db: db, hashFunc: testHashFunc}
+
+	metadata := map[string][]byte{"entry1": []byte("meta")}
+	rwsetBuilder1 := rwsetutil.NewRWSetBuilder()
+	// upserts in a namespace with metadata need the committed metadata
+	rwsetBuilder1.AddToWriteSet("ns1", "key1", []byte("value1-new"))
+	// upserts in a namespace without metadata do not need the committed state
+	rwsetBuilder1.AddToWriteSet("ns2", "key1", []byte("value1-new"))
+	// metadata updates need the committed value
+	rwsetBuilder1.AddToMetadataWriteSet("ns2", "key2", metadata)
+	rwsetBuilder1.AddToHashedMetadataWriteSet("ns2", "coll1", "pvtKey1", metadata)
+	rwsetBuilder2 := rwsetutil.NewRWSetBuilder()
+	// upserts along with the metadata and deletes do not need the committed state
+	rwsetBuilder2.AddToWriteSet("ns1", "key2", []byte("value2"))
+	rwsetBuilder2.AddToMetadataWriteSet("ns1", "key2", metadata)
+	rwsetBuilder2.AddToWriteSet("ns1", "key3", nil)
+	// the keys are loaded only once
+	rwsetBuilder2.AddToMetadataWriteSet("ns2", "key2", metadata)
+
+	var trans []*transaction
+	for i, tranRWSet := range getTestPubSimulationRWSet(t, rwsetBuilder1, rwsetBuilder2) {
+		trans = append(trans, &transaction{
+			id:             fmt.Sprintf("txid-%d", i),
+			indexInBlock:   i,
+			validationCode: peer.TxValidationCode_VALID,
+			rwset:          tranRWSet,
+		})
+	}
+	require.NoError(t, testValidator.preLoadCommittedStateOfWSet(&block{num: 2, txs: trans}))
+	require.ElementsMatch(t,
+		[]*statedb.CompositeKey{
+			{Namespace: "ns1", Key: "key1"},
+			{Namespace: "ns2", Key: "key2"},
+			{Namespace: "ns2$$hcoll1", Key: string(util.ComputeStringHash("pvtKey1"))},
+		},
+		spyVDB.loadedStates,
+	)
+
+	// nothing is loaded when no committed state is needed
+	spyVDB.loadedStates = nil
+	rwsetBuilder3 := rwsetutil.NewRWSetBuilder()
+	rwsetBuilder3.AddToWriteSet("ns2", "key1", []byte("value1-new"))
+	tx := &transaction{id: "txid-3", rwset: getTestPubSimulationRWSet(t, rwsetBuilder3)[0]}
+	require.NoError(t, testValidator.preLoadCommittedStateOfWSet(&block{num: 3, txs: []*transaction{tx}}))
+	require.Nil(t, spyVDB.loadedStates)
+}
+
+// bulkLoadingSpyVDB makes a VersionedDB bulk optimizable and records the keys passed for loading the committed states
+type bulkLoadingSpyVDB struct {
+	statedb.VersionedDB
+	loadedStates []*statedb.CompositeKey
+}
+
+func (s *bulkLoadingSpyVDB) LoadCommittedVersions(keys []*statedb.CompositeKey) error {
+	return nil
+}
+
+func (s *bulkLoadingSpyVDB) GetCachedVersion(namespace, key string) (*version.Height, bool) {
+	return nil, false
+}
+
+func (s *bulkLoadingSpyVDB) LoadCommittedStates(keys []*statedb.CompositeKey) error {
+	s.loadedStates = keys
+	return nil
+}
+
+func (s *bulkLoadingSpyVDB) ClearCachedVersions() {}
+
 func TestValidator(t *testing.T) {
 	testDBEnv := testEnvs[levelDBtestEnvName]
 	testDBEnv.Init(t)
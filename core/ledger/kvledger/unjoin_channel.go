@@ -34,7 +34,7 @@ func UnjoinChannel(config *ledger.Config, ledgerID string) error {
 	}
 	defer fileLock.Unlock()
 
-	idStore, err := openIDStore(LedgerProviderPath(config.RootFSPath))
+	idStore, err := openIDStore(LedgerProviderPath(config.RootFSPath), levelDBOpenRetryFor(config))
 	if err != nil {
 		return errors.WithMessagef(err, "unjoin channel [%s]", ledgerID)
 	}
@@ -61,13 +61,57 @@ func UnjoinChannel(config *ledger.Config, ledgerID string) error {
 	return nil
 }
 
+// VerifyChannelRemoved verifies that no data of the given ledger remains in the ledger ID store and in the ledger
+// stores, such as after an unjoin that was interrupted by a crash. The returned report lists the stores that still
+// hold data of the ledger. This function is to be invoked while the peer is shut down.
+func VerifyChannelRemoved(config *ledger.Config, ledgerID string) (*RemovalReport, error) {
+	fileLock := leveldbhelper.NewFileLock(fileLockPath(config.RootFSPath))
+	if err := fileLock.Lock(); err != nil {
+		return nil, errors.WithMessage(err, "as another peer node command is executing,"+
+			" wait for that command to complete its execution or terminate it before retrying")
+	}
+	defer fileLock.Unlock()
+
+	idStore, err := openIDStore(LedgerProviderPath(config.RootFSPath), levelDBOpenRetryFor(config))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "verify removal of channel [%s]", ledgerID)
+	}
+	defer idStore.db.Close()
+
+	exists, err := idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "verify removal of channel [%s]", ledgerID)
+	}
+	report := &RemovalReport{LedgerID: ledgerID}
+	report.Add(storeIDStore, exists)
+
+	if err := withLedgerDataRemover(config, func(r *ledgerDataRemover) error {
+		return r.verify(ledgerID, report)
+	}); err != nil {
+		return nil, errors.WithMessagef(err, "verify removal of channel [%s]", ledgerID)
+	}
+	return report, nil
+}
+
 // removeLedgerData removes the data for a given ledger. This function should be invoked when the peer is not running and the caller should hold the file lock for the KVLedgerProvider
 func removeLedgerData(config *ledger.Config, ledgerID string) error {
+	return withLedgerDataRemover(config, func(r *ledgerDataRemover) error {
+		return r.Drop(ledgerID)
+	})
+}
+
+// withLedgerDataRemover opens the providers of the ledger stores, invokes the given function with a ledgerDataRemover
+// over these providers and closes the providers. This function should be invoked when the peer is not running and the
+// caller should hold the file lock for the KVLedgerProvider
+func withLedgerDataRemover(config *ledger.Config, f func(r *ledgerDataRemover) error) error {
+	openRetry := levelDBOpenRetryFor(config)
+	blkStoreConf := blkstorage.NewConf(
+		BlockStorePath(config.RootFSPath),
+		maxBlockFileSize,
+	)
+	blkStoreConf.SetIndexDBOpenRetry(openRetry)
 	blkStoreProvider, err := blkstorage.NewProvider(
-		blkstorage.NewConf(
-			BlockStorePath(config.RootFSPath),
-			maxBlockFileSize,
-		),
+		blkStoreConf,
 		&blkstorage.IndexConfig{AttrsToIndex: attrsToIndex},
 		&disabled.Provider{},
 	)
@@ -76,7 +120,7 @@ func removeLedgerData(config *ledger.Config, ledgerID string) error {
 	}
 	defer blkStoreProvider.Close()
 
-	bookkeepingProvider, err := bookkeeping.NewProvider(BookkeeperDBPath(config.RootFSPath))
+	bookkeepingProvider, err := bookkeeping.NewProvider(BookkeeperDBPath(config.RootFSPath), openRetry)
 	if err != nil {
 		return err
 	}
@@ -87,8 +131,10 @@ func removeLedgerData(config *ledger.Config, ledgerID string) error {
 		&disabled.Provider{},
 		&noopHealthCheckRegistry{},
 		&privacyenabledstate.StateDBConfig{
-			StateDBConfig: config.StateDBConfig,
-			LevelDBPath:   StateDBPath(config.RootFSPath),
+			StateDBConfig:    config.StateDBConfig,
+			LevelDBPath:      StateDBPath(config.RootFSPath),
+			PebbleDBPath:     StatePebbleDBPath(config.RootFSPath),
+			LevelDBOpenRetry: openRetry,
 		},
 		[]string{},
 	)
@@ -101,6 +147,7 @@ func removeLedgerData(config *ledger.Config, ledgerID string) error {
 		&pvtdatastorage.PrivateDataConfig{
 			PrivateDataConfig: config.PrivateDataConfig,
 			StorePath:         PvtDataStorePath(config.RootFSPath),
+			OpenRetry:         openRetry,
 		},
 	)
 	if err != nil {
@@ -110,6 +157,9 @@ func removeLedgerData(config *ledger.Config, ledgerID string) error {
 
 	historydbProvider, err := history.NewDBProvider(
 		HistoryDBPath(config.RootFSPath),
+		1,
+		false,
+		openRetry,
 	)
 	if err != nil {
 		return err
@@ -119,6 +169,7 @@ func removeLedgerData(config *ledger.Config, ledgerID string) error {
 	configHistoryMgr, err := confighistory.NewMgr(
 		ConfigHistoryDBPath(config.RootFSPath),
 		&noopDeployedChaincodeInfoProvider{},
+		openRetry,
 	)
 	if err != nil {
 		return err
@@ -133,7 +184,7 @@ func removeLedgerData(config *ledger.Config, ledgerID string) error {
 		historydbProvider:    historydbProvider,
 		pvtdataStoreProvider: pvtdataStoreProvider,
 	}
-	return ledgerDataRemover.Drop(ledgerID)
+	return f(ledgerDataRemover)
 }
 
 type noopHealthCheckRegistry struct{}
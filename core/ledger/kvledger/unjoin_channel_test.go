@@ -53,6 +53,74 @@ func TestUnjoinChannel(t *testing.T) {
 	verifyLedgerDoesNotExist(t, provider, ledgerID)
 }
 
+func TestVerifyChannelRemoved(t *testing.T) {
+	conf := testConfig(t)
+	conf.HistoryDBConfig.Enabled = true
+
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	for _, ledgerID := range []string{"ledger_unjoined", "ledger_partially_removed"} {
+		genesisBlock, err := configtxtest.MakeGenesisBlock(ledgerID)
+		require.NoError(t, err)
+		_, err = provider.CreateFromGenesisBlock(genesisBlock)
+		require.NoError(t, err)
+	}
+	provider.Close()
+
+	// the data of a joined channel is reported
+	report, err := VerifyChannelRemoved(conf, "ledger_unjoined")
+	require.NoError(t, err)
+	require.False(t, report.Complete())
+	require.Subset(t, report.RemainingData(), []string{"idStore", "stateDB", "historyDB", "blockstore"})
+
+	require.NoError(t, UnjoinChannel(conf, "ledger_unjoined"))
+	report, err = VerifyChannelRemoved(conf, "ledger_unjoined")
+	require.NoError(t, err)
+	require.True(t, report.Complete())
+	require.True(t, report.StateDBChecked)
+	require.Equal(t, "ledger_unjoined", report.LedgerID)
+	require.ElementsMatch(t,
+		[]StoreRemovalStatus{
+			{Store: "idStore", Removed: true},
+			{Store: "stateDB", Removed: true},
+			{Store: "configHistoryDB", Removed: true},
+			{Store: "bookkeepingDB", Removed: true},
+			{Store: "historyDB", Removed: true},
+			{Store: "pvtdataStore", Removed: true},
+			{Store: "blockstore", Removed: true},
+		},
+		report.Stores,
+	)
+
+	// simulate a removal interrupted by a crash after the statedb and the history db have been dropped
+	require.NoError(t, withLedgerDataRemover(conf, func(r *ledgerDataRemover) error {
+		if err := r.statedbProvider.Drop("ledger_partially_removed"); err != nil {
+			return err
+		}
+		return r.historydbProvider.Drop("ledger_partially_removed")
+	}))
+	report, err = VerifyChannelRemoved(conf, "ledger_partially_removed")
+	require.NoError(t, err)
+	require.False(t, report.Complete())
+	require.Contains(t, report.RemainingData(), "idStore")
+	require.Contains(t, report.RemainingData(), "blockstore")
+	require.NotContains(t, report.RemainingData(), "stateDB")
+	require.NotContains(t, report.RemainingData(), "historyDB")
+
+	report.Add("transientStore", true)
+	require.Equal(t, "transientStore", report.RemainingData()[len(report.RemainingData())-1])
+}
+
+func TestVerifyChannelRemovedWithRunningPeerErrors(t *testing.T) {
+	conf := testConfig(t)
+
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	defer provider.Close()
+
+	_, err := VerifyChannelRemoved(conf, "ledger_unjoined")
+	require.ErrorContains(t, err, "as another peer node command is executing,"+
+		" wait for that command to complete its execution or terminate it before retrying")
+}
+
 // Unjoining an unjoined channel is an error.
 func TestUnjoinUnjoinedChannelErrors(t *testing.T) {
 	conf := testConfig(t)
@@ -11,6 +11,7 @@ import (
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
+	"github.com/hyperledger/fabric/internal/fileutil"
 	"github.com/pkg/errors"
 )
 
@@ -62,3 +63,50 @@ func UpgradeDBs(config *ledger.Config) error {
 
 	return idStore.upgradeFormat()
 }
+
+// DataFormatVersion returns the data format version stored in the idStore of the ledgers under the configured
+// ledger root dir. Unlike opening a ledger provider, this does not fail if the stored format differs from the
+// current format and hence can be used by the upgrade tooling for deciding whether an upgrade is needed.
+// An empty string is returned if the idStore has never been initialized.
+// This should not be invoked while a peer that uses the same ledger root dir is running.
+func DataFormatVersion(config *ledger.Config) (string, error) {
+	dbPath := LedgerProviderPath(config.RootFSPath)
+	exists, err := fileutil.DirExists(dbPath)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", nil
+	}
+	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: dbPath})
+	db.Open()
+	defer db.Close()
+	format, err := db.Get(formatKey)
+	if err != nil {
+		return "", errors.WithMessagef(err, "error while reading the data format from the leveldb for channel-IDs at [%s]", dbPath)
+	}
+	return string(format), nil
+}
+
+// SetDataFormatIfEmpty writes the given data format in the idStore of the ledgers under the configured ledger
+// root dir, if the idStore does not contain a data format. This is intended for the recovery scenarios where
+// only the format key is missing from an otherwise correct idStore, for instance, after a manual restore.
+// This is a no-op if the idStore already contains the same format and an error is returned if the idStore
+// contains a different format.
+func SetDataFormatIfEmpty(config *ledger.Config, format string) error {
+	rootFSPath := config.RootFSPath
+	fileLockPath := fileLockPath(rootFSPath)
+	fileLock := leveldbhelper.NewFileLock(fileLockPath)
+	if err := fileLock.Lock(); err != nil {
+		return errors.Wrap(err, "as another peer node command is executing,"+
+			" wait for that command to complete its execution or terminate it before retrying")
+	}
+	defer fileLock.Unlock()
+
+	dbPath := LedgerProviderPath(rootFSPath)
+	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: dbPath})
+	db.Open()
+	defer db.Close()
+	idStore := &idStore{db, dbPath}
+	return idStore.setFormatIfEmpty(format)
+}
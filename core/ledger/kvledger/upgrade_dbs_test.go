@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"testing"
 
+	configtxtest "github.com/hyperledger/fabric/common/configtx/test"
 	"github.com/hyperledger/fabric/common/ledger/dataformat"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger/mock"
 	"github.com/hyperledger/fabric/internal/fileutil"
 	"github.com/pkg/errors"
@@ -64,3 +66,75 @@ func TestUpgradeAlreadyUptodateFormat(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, isEmpty)
 }
+
+func TestDataFormatVersion(t *testing.T) {
+	conf := testConfig(t)
+
+	format, err := DataFormatVersion(conf)
+	require.NoError(t, err)
+	require.Equal(t, "", format)
+	exists, err := fileutil.DirExists(LedgerProviderPath(conf.RootFSPath))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	provider.Close()
+	format, err = DataFormatVersion(conf)
+	require.NoError(t, err)
+	require.Equal(t, dataformat.CurrentFormat, format)
+
+	// a format that would cause ErrFormatMismatch on opening the provider is returned without error
+	provider = testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	require.NoError(t, provider.idStore.db.Put(formatKey, []byte(dataformat.PreviousFormat), true))
+	provider.Close()
+	format, err = DataFormatVersion(conf)
+	require.NoError(t, err)
+	require.Equal(t, dataformat.PreviousFormat, format)
+}
+
+func TestSetDataFormatIfEmpty(t *testing.T) {
+	conf := testConfig(t)
+	provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+	genesisBlock, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(0))
+	lgr, err := provider.CreateFromGenesisBlock(genesisBlock)
+	require.NoError(t, err)
+	lgr.Close()
+	require.NoError(t, provider.idStore.db.Delete(formatKey, true))
+	provider.Close()
+
+	// the idStore cannot be opened without the format key
+	_, err = openIDStore(LedgerProviderPath(conf.RootFSPath), leveldbhelper.OpenRetry{})
+	require.IsType(t, &dataformat.ErrFormatMismatch{}, err)
+
+	t.Run("empty", func(t *testing.T) {
+		require.NoError(t, SetDataFormatIfEmpty(conf, dataformat.CurrentFormat))
+		format, err := DataFormatVersion(conf)
+		require.NoError(t, err)
+		require.Equal(t, dataformat.CurrentFormat, format)
+		provider := testutilNewProvider(conf, t, &mock.DeployedChaincodeInfoProvider{})
+		defer provider.Close()
+		exists, err := provider.Exists(constructTestLedgerID(0))
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("matching", func(t *testing.T) {
+		require.NoError(t, SetDataFormatIfEmpty(conf, dataformat.CurrentFormat))
+		format, err := DataFormatVersion(conf)
+		require.NoError(t, err)
+		require.Equal(t, dataformat.CurrentFormat, format)
+	})
+
+	t.Run("conflicting", func(t *testing.T) {
+		err := SetDataFormatIfEmpty(conf, dataformat.PreviousFormat)
+		expectedErr := &dataformat.ErrFormatMismatch{
+			ExpectedFormat: dataformat.PreviousFormat,
+			Format:         dataformat.CurrentFormat,
+			DBInfo:         fmt.Sprintf("leveldb for channel-IDs at [%s]", LedgerProviderPath(conf.RootFSPath)),
+		}
+		require.EqualError(t, err, expectedErr.Error())
+		format, err := DataFormatVersion(conf)
+		require.NoError(t, err)
+		require.Equal(t, dataformat.CurrentFormat, format)
+	})
+}
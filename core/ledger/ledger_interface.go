@@ -9,6 +9,7 @@ package ledger
 import (
 	"fmt"
 	"hash"
+	"io"
 	"time"
 
 	"github.com/golang/protobuf/proto"
@@ -20,11 +21,36 @@ import (
 	"github.com/hyperledger/fabric/bccsp"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
 )
 
 const (
 	GoLevelDB = "goleveldb"
 	CouchDB   = "CouchDB"
+	Pebble    = "pebble"
+)
+
+const (
+	// BlockStorageSyncPerBlock syncs the block files to the disk after every block
+	BlockStorageSyncPerBlock = "PerBlock"
+	// BlockStorageSyncPerN syncs the block files to the disk after every N blocks
+	BlockStorageSyncPerN = "PerN"
+	// BlockStorageSyncOnClose syncs the block files to the disk only when a block file is full or the ledger is closed
+	BlockStorageSyncOnClose = "OnClose"
+)
+
+const (
+	// BlockStorageCompressionNone stores the blocks in the block files uncompressed
+	BlockStorageCompressionNone = "none"
+	// BlockStorageCompressionSnappy compresses each block in the block files with snappy
+	BlockStorageCompressionSnappy = "snappy"
+)
+
+const (
+	// LevelDBCompressionSnappy compresses the blocks of data of a goleveldb database with snappy
+	LevelDBCompressionSnappy = "snappy"
+	// LevelDBCompressionNone stores the blocks of data of a goleveldb database uncompressed
+	LevelDBCompressionNone = "none"
 )
 
 // Initializer encapsulates dependencies for PeerLedgerProvider
@@ -37,8 +63,40 @@ type Initializer struct {
 	HealthCheckRegistry             HealthCheckRegistry
 	Config                          *Config
 	CustomTxProcessors              map[common.HeaderType]CustomTxProcessor
-	HashProvider                    HashProvider
-}
+	// HashProvider is required and is used for computing the range query merkle hashes in the read-write sets
+	// and the hashes of the snapshot files. The hashes of the private data keys and values are fixed to SHA-256,
+	// as these are a part of the hashed read-write sets that are computed by the endorsers and are matched against
+	// by the private data store and gossip on every peer of the channel
+	HashProvider HashProvider
+	// LedgerIDValidator, if set, is invoked for the ID of a ledger before the ledger is created. A non-nil
+	// error rejects the ledger creation. The ledger IDs are used as parts of the database keys and the
+	// directory names, so the validator is expected to reject the IDs that could corrupt the on-disk layout
+	LedgerIDValidator func(ledgerID string) error
+	// LedgerCreationListener, if set, is notified after a ledger is created from a genesis block
+	LedgerCreationListener LedgerCreationListener
+	// GenesisValidation controls the checks performed on a genesis block before a ledger is created from it.
+	// The zero value is GenesisValidationStrict
+	GenesisValidation GenesisValidation
+	// ReadAuthorizer, if set, is consulted for the reads of the public state made via the query executors returned
+	// by PeerLedger.NewQueryExecutor. A non-nil error rejects the read; a point read then returns a
+	// ReadNotAuthorizedError and a range scan or a query skips the key. The reads made by the transaction simulators
+	// and the reads made internally by the ledger, including those for validating the transactions, are not subject
+	// to the ReadAuthorizer. Note that a page of a paginated range scan may contain fewer results than the page
+	// size when some of the keys are skipped
+	ReadAuthorizer func(namespace, key string) error
+}
+
+// GenesisValidation identifies the checks performed on a genesis block before a ledger is created from it
+type GenesisValidation int
+
+const (
+	// GenesisValidationStrict requires the genesis block to carry a transaction with a channel header
+	GenesisValidationStrict GenesisValidation = iota
+	// GenesisValidationRelaxed does not inspect the transactions in the genesis block and only requires the block
+	// number to be 0 and the data hash in the block header to match the block data. This allows the test harnesses
+	// to create lightweight ledgers from a genesis block that does not contain a config transaction
+	GenesisValidationRelaxed
+)
 
 // Config is a structure used to configure a ledger provider.
 type Config struct {
@@ -50,18 +108,113 @@ type Config struct {
 	PrivateDataConfig *PrivateDataConfig
 	// HistoryDBConfig holds the configuration parameters for the transaction history database.
 	HistoryDBConfig *HistoryDBConfig
+	// BlockStorageConfig holds the configuration parameters for the block storage.
+	BlockStorageConfig *BlockStorageConfig
 	// SnapshotsConfig holds the configuration parameters for the snapshots.
 	SnapshotsConfig *SnapshotsConfig
+	// RejectWritesWhenFrozen, when set, causes the commits and the creation of transaction simulators
+	// on a frozen ledger to fail with a `LedgerFrozenError`. Otherwise, these operations block
+	// until the ledger is unfrozen.
+	RejectWritesWhenFrozen bool
+	// MaxOpenLedgers limits the number of the opened ledgers that keep their block file open. The state, history,
+	// and private data databases are shared across the ledgers and hence, the block files are the file descriptors
+	// held per ledger. When the limit is reached, the least recently committed ledger that is not in the middle of
+	// a commit releases its block file, which is transparently reopened on its next commit. The reads from a ledger
+	// are not affected by the limit. A value of zero means no limit
+	MaxOpenLedgers int
+	// LevelDBOpenRetries is the number of additional attempts made to open a goleveldb database of the ledger, such
+	// as the block index, the private data store, the history database, and the state database, if the first attempt
+	// fails. This covers the case where the lock of a database is not yet released by the operating system when the
+	// peer is restarted after an unclean shutdown. A value of zero causes the open to fail on the first error
+	LevelDBOpenRetries int
+	// LevelDBOpenRetryInterval is the wait before the first retry of the open of a goleveldb database, which doubles
+	// after every subsequent retry
+	LevelDBOpenRetryInterval time.Duration
+	// DataEncryptor, when not nil, encrypts the data of each ledger at rest in the block files, the state database,
+	// and the private data store, with the ledger ID passed to the encryptor so that each ledger can have a separate
+	// key. In the databases, the values are encrypted and the keys, such as the names of the state keys and the
+	// hashes of the private data keys, are stored unencrypted. The state database is required to be goleveldb.
+	// The encryption cannot be turned on or off, and the encryptor cannot be changed, for the existing ledgers.
+	DataEncryptor DataEncryptor
+}
+
+// DataEncryptor encrypts and decrypts the data of the ledgers at rest. `kvledger.NewBCCSPDataEncryptor` is
+// an implementation that derives a separate key for each ledger from a master key held by a BCCSP
+type DataEncryptor interface {
+	Encrypt(ledgerID string, plaintext []byte) ([]byte, error)
+	Decrypt(ledgerID string, ciphertext []byte) ([]byte, error)
 }
 
 // StateDBConfig is a structure used to configure the state parameters for the ledger.
 type StateDBConfig struct {
 	// StateDatabase is the database to use for storing last known state.  The
-	// two supported options are "goleveldb" and "CouchDB" (captured in the constants GoLevelDB and CouchDB respectively).
+	// supported options are "goleveldb", "CouchDB", and "pebble" (captured in the constants GoLevelDB, CouchDB,
+	// and Pebble respectively).
 	StateDatabase string
 	// CouchDB is the configuration for CouchDB.  It is used when StateDatabase
 	// is set to "CouchDB".
 	CouchDB *CouchDBConfig
+	// PerNamespacePartitioning, when true, keeps the data of each namespace in a separate leveldb.
+	// It is used only when StateDatabase is set to "goleveldb" and cannot be changed for an
+	// existing state database without rebuilding it.
+	PerNamespacePartitioning bool
+	// TotalQueryLimit, when greater than zero, is the maximum number of results that a sorted rich query collects
+	// and sorts in memory when StateDatabase is set to "goleveldb". A sorted query that matches more results fails.
+	TotalQueryLimit int
+	// WriteTimeout bounds the duration of the writes to the state database and the history database
+	// during the commit of a block. If the writes do not complete within this duration, the commit
+	// returns a CommitTimeoutError, while the block stays persisted and the writes complete in the
+	// background. Zero means no timeout.
+	WriteTimeout time.Duration
+	// AutoCompactInterval, when non-zero, is the interval at which the physical storage of the state database
+	// and the history database of each open ledger is compacted in the background. A compaction is skipped if
+	// the ledger is under heavy commit load at the time. It does not apply to CouchDB.
+	AutoCompactInterval time.Duration
+	// ReadCacheSize, when non-zero, is the maximum number of entries in an LRU cache of the values read from
+	// the state database via the function GetState. The cached entries of the keys updated by a block are
+	// evicted as part of the commit of the block, so a value superseded by a commit is never served from the cache
+	ReadCacheSize int
+	// CommitHashWorkers, when greater than zero, causes the commit hash of a block, when maintained by the ledger,
+	// to be computed in the background, with the bytes of the state updates of the namespaces constructed in parallel
+	// by this many workers. The computation overlaps only with the commit of the same block to the private data
+	// store and is joined before the block is added to the block store, as the commit hash is a part of the block
+	// and chains the commit hash of the previous block. Hence, it does not overlap with the validation of the next
+	// block. The resulting commit hashes are the same as the ones computed otherwise
+	CommitHashWorkers int
+	// PartialSavepointInterval, when non-zero, causes the updates of a block with more transactions than this number
+	// to be committed to the state database in parts, each covering this many transactions and followed by a partial
+	// savepoint, so that the recovery after a crash in the middle of the commit of the block does not apply the
+	// committed parts again. This applies to CouchDB and to the goleveldb with the per-namespace partitioning, as
+	// the goleveldb otherwise commits the updates of a block atomically
+	PartialSavepointInterval int
+	// LevelDB, when not nil, tunes the goleveldb databases of the state when StateDatabase is set to "goleveldb".
+	LevelDB *LevelDBConfig
+	// BlockToLive maps a namespace to the number of blocks for which a public state key of the namespace lives
+	// after it is written, analogous to the block-to-live of a private data collection. A key written by the block
+	// number N is deleted from the state with the commit of the block number N+BlockToLive+1, unless the key is
+	// written again in the meantime. A zero or a missing value means that the keys never expire. The block-to-live
+	// in effect at the time of a write applies to the write, and removing the block-to-live of a namespace stops
+	// the expiry of its keys. As the expiry changes the state, and hence the validation of the transactions that
+	// read the expired keys, the configuration must be the same on all the peers of a channel.
+	BlockToLive map[string]uint64
+}
+
+// LevelDBConfig is a structure used to tune a goleveldb database. A zero value for a field leaves the goleveldb
+// default in place. The options can be changed for an existing database, as they affect only the data written
+// after the change.
+type LevelDBConfig struct {
+	// BloomFilterBitsPerKey, when greater than zero, enables a bloom filter with the given number of bits per key,
+	// which saves the disk reads for the keys that do not exist. Ten bits per key is a typical value.
+	BloomFilterBitsPerKey int
+	// BlockCacheSizeMBs is the size, in mega bytes (MB), of the cache of the uncompressed blocks of data.
+	BlockCacheSizeMBs int
+	// WriteBufferSizeMBs is the size, in mega bytes (MB), of the in-memory buffer of the writes that is flushed
+	// to the disk when full. A larger buffer speeds up the bulk writes at the expense of a longer recovery.
+	WriteBufferSizeMBs int
+	// Compression is the compression of the blocks of data. The supported options are "snappy" and "none"
+	// (captured in the constants LevelDBCompressionSnappy and LevelDBCompressionNone). An empty value is
+	// treated as "snappy".
+	Compression string
 }
 
 // CouchDBConfig is a structure used to configure a CouchInstance.
@@ -118,24 +271,136 @@ type PrivateDataConfig struct {
 	// from other peers. A chance for eligible deprioritized missing data
 	// would be given after every DeprioritizedDataReconcilerInterval
 	DeprioritizedDataReconcilerInterval time.Duration
+	// CompactionInterval, when non-zero, is the interval at which the physical storage of the private data of
+	// a ledger is compacted in the background. A scheduled compaction is skipped while the ledger is under heavy
+	// commit load
+	CompactionInterval time.Duration
 }
 
 // HistoryDBConfig is a structure used to configure the transaction history database.
 type HistoryDBConfig struct {
 	Enabled bool
+	// SampleEveryN, when greater than 1, causes only every Nth modification of a key, along with the latest
+	// modification, to be recorded in the history database. A value of 0 or 1 records the full history.
+	SampleEveryN uint64
+	// CommitPipelineDepth, when greater than zero, makes the history commit asynchronous, i.e., the commit of a block
+	// to the history database proceeds in the background, so that it overlaps with the processing of the subsequent
+	// blocks. The other steps of the commit of a block are not affected. The blocks are committed to the history
+	// database in order and the commit of a new block waits if the history commits of this many blocks are pending.
+	// The history queries wait for the pending commits to finish. If a history commit fails, the subsequent commits
+	// and history queries return the error until the ledger is reopened, which catches up the history database.
+	// A value of zero commits a block to the history database as part of the commit of the block
+	CommitPipelineDepth int
+	// StoreValues, when set, causes the transaction ID, the timestamp, and the value written by a transaction to be
+	// stored along with the history entry of the key, so that the history queries do not have to read the
+	// transactions from the block store. This increases the size of the history database by roughly the size of
+	// the written values. The entries committed while this is not set continue to be served from the block store
+	StoreValues bool
+}
+
+// BlockStorageConfig is a structure used to configure the block storage.
+type BlockStorageConfig struct {
+	// SyncMode determines when the block files are synced to the disk. The supported options are "PerBlock",
+	// "PerN", and "OnClose" (captured in the constants BlockStorageSyncPerBlock, BlockStorageSyncPerN, and
+	// BlockStorageSyncOnClose respectively). An empty value is treated as "PerBlock". With the other options,
+	// the blocks appended since the last sync may be lost in a crash and are truncated from the block files
+	// when the ledger is reopened.
+	SyncMode string
+	// SyncEveryN is the number of blocks after which the block files are synced in the "PerN" mode.
+	SyncEveryN int
+	// RetainBlocks, when greater than zero, is the number of the most recent blocks that are retained when the old
+	// blocks are pruned automatically as the new blocks are committed. The pruning never goes past the last config
+	// block, nor past the blocks that the state DB, the history DB, or the pvtdata store have not committed yet.
+	// The blocks are deleted in units of block files, so more blocks than configured may be retained. As with
+	// PeerLedger.PruneBlocks, the rebuild, rollback, and reset of a pruned ledger are not supported and the missing
+	// pvtdata of the pruned blocks is not reconciled.
+	RetainBlocks uint64
+	// MaxBlockfileSizeMBs, when greater than zero, is the size, in mega bytes (MB), beyond which the blocks are added
+	// to a new block file. A value of zero is treated as 64 MB. The size applies to the existing ledgers as well.
+	MaxBlockfileSizeMBs int
+	// Compression is the compression of the blocks in the block files. The supported options are "none" and "snappy"
+	// (captured in the constants BlockStorageCompressionNone and BlockStorageCompressionSnappy). An empty value is
+	// treated as "none". A ledger retains the compression it was created with and hence, a change applies only to
+	// the ledgers created afterwards. The offline rollback and reset of the ledger are not supported with a compression.
+	Compression string
+	// IndexChaincodeName, when true, maintains an index of the endorser transactions by the name of the invoked chaincode,
+	// which serves `PeerLedger.TxIDsByChaincodeName`. The blocks committed before the index is enabled are indexed
+	// only when the block index is rebuilt, e.g., via the command `peer node rebuild-dbs`.
+	IndexChaincodeName bool
+	// IndexEndorserMSPIDs, when true, maintains an index of the endorser transactions by the MSP IDs of their endorsers,
+	// which serves `PeerLedger.TxIDsByEndorserMSPID`. As with IndexChaincodeName, the blocks committed before the index
+	// is enabled are indexed only when the block index is rebuilt.
+	IndexEndorserMSPIDs bool
+	// Codec, when not nil, is used for encoding the blocks in the block files in place of the default protobuf
+	// encoding. The codec cannot be changed for an existing ledger. The offline rollback and reset of the ledger
+	// are not supported with a codec.
+	Codec BlockCodec
+	// Archiver, when not nil, is used by `PeerLedger.ArchiveBlocks` for moving the old block files to an external
+	// storage, such as an object store, and for fetching them back when an archived block is retrieved. The peer
+	// sets this to a `blkstorage.DirArchiver` when `ledger.blockchain.archiveDir` is configured.
+	Archiver BlockfileArchiver
+	// IndexLevelDB, when not nil, tunes the goleveldb database that holds the block indexes.
+	IndexLevelDB *LevelDBConfig
+}
+
+// BlockCodec encodes the blocks for storing in the block files and decodes them back
+type BlockCodec interface {
+	Marshal(block *common.Block) ([]byte, error)
+	Unmarshal(b []byte) (*common.Block, error)
+}
+
+// BlockfileArchiver stores the complete block files of a ledger in an external storage and retrieves them back.
+// `blkstorage.DirArchiver` is an implementation that archives the block files into a directory
+type BlockfileArchiver interface {
+	Archive(ledgerID string, fileNum int, filePath string) error
+	Retrieve(ledgerID string, fileNum int, w io.Writer) error
 }
 
 // SnapshotsConfig is a structure used to configure snapshot function
 type SnapshotsConfig struct {
 	// RootDir is the top-level directory for the snapshots.
 	RootDir string
+	// CompactStateDB, when set, compacts the physical storage of the state database before exporting
+	// the state for a snapshot, so that the export does not have to skip over the deleted entries.
+	// This has effect only for the leveldb based state database.
+	CompactStateDB bool
+	// MigrateSnapshots, when set, moves the previously generated snapshots to the RootDir if the RootDir
+	// differs from the one that was used the last time the ledger provider was opened. When not set, the
+	// snapshots in the previous location are left as is and are no longer accessible via the ledger.
+	MigrateSnapshots bool
+	// Incremental, when set, generates a snapshot as an incremental snapshot over the most recent snapshot of the
+	// ledger present under the RootDir. An incremental snapshot contains only the state that is updated or deleted
+	// after the base snapshot and records the block number and the hash of the base snapshot in its metadata. A full
+	// snapshot is generated if no earlier snapshot of the ledger is present. An incremental snapshot cannot be used
+	// directly for creating a ledger.
+	Incremental bool
+	// MaxConcurrentGenerations limits the number of snapshots that are generated at the same time across all the
+	// ledgers. A snapshot that exceeds the limit is generated when an in-progress generation finishes and the commits
+	// on its ledger remain paused till then. A value of zero places no limit.
+	MaxConcurrentGenerations int
+	// MaxWriteRateMBs limits the rate, in megabytes per second, at which the snapshot files are written, shared by
+	// all the snapshots being generated, so that a snapshot generation leaves the disk bandwidth for the commits on
+	// the other ledgers. A value of zero places no limit.
+	MaxWriteRateMBs int
+	// AutoGenerateEveryNBlocks, when non-zero, generates a snapshot of each ledger at every block whose block number
+	// is a multiple of this value.
+	AutoGenerateEveryNBlocks uint64
+	// AutoGenerateInterval, when non-zero, is the interval at which a snapshot of each open ledger is generated at
+	// its last committed block. No snapshot is generated if no block is committed since the previous snapshot.
+	AutoGenerateInterval time.Duration
+	// RetainSnapshots, when non-zero, is the number of the most recent snapshots of a ledger that are retained
+	// under the RootDir. The older snapshots, whether generated automatically or on request, are removed after a
+	// snapshot is generated, except for those that are the base snapshots of a retained incremental snapshot.
+	RetainSnapshots int
 }
 
 // PeerLedgerProvider provides handle to ledger instances
 type PeerLedgerProvider interface {
 	// CreateFromGenesisBlock creates a new ledger with the given genesis block.
 	// This function guarantees that the creation of ledger and committing the genesis block would an atomic action
-	// The channel id retrieved from the genesis block is treated as a ledger id
+	// The channel id retrieved from the genesis block is treated as a ledger id.
+	// A ledger left under construction by an earlier creation that failed or crashed is deleted and created afresh,
+	// so that the creation can be retried. The creation fails if the ledger already exists and is active
 	CreateFromGenesisBlock(genesisBlock *common.Block) (PeerLedger, error)
 	// CreateFromSnapshot creates a new ledger from a snapshot and returns the ledger and channel id.
 	// The channel id retrieved from snapshot metadata is treated as a ledger id
@@ -146,6 +411,11 @@ type PeerLedgerProvider interface {
 	Exists(ledgerID string) (bool, error)
 	// List lists the ids of the existing ledgers
 	List() ([]string, error)
+	// Deactivate marks an active ledger as inactive, without deleting any of its data. An inactive ledger is
+	// neither listed nor opened till it is reactivated. The caller is expected to close the ledger before deactivating
+	Deactivate(ledgerID string) error
+	// Reactivate marks an inactive ledger as active, so that the ledger can be opened again
+	Reactivate(ledgerID string) error
 	// Close closes the PeerLedgerProvider
 	Close()
 }
@@ -159,16 +429,34 @@ type PeerLedger interface {
 	TxIDExists(txID string) (bool, error)
 	// GetTransactionByID retrieves a transaction by id
 	GetTransactionByID(txID string) (*peer.ProcessedTransaction, error)
+	// GetTransactionsByIDs retrieves the transactions for the given ids, in the same order. This is equivalent to
+	// invoking GetTransactionByID for each of the ids but the locations of all the transactions are looked up in a
+	// single pass over the block index. An error is returned if any of the transactions is not available
+	GetTransactionsByIDs(txIDs []string) ([]*peer.ProcessedTransaction, error)
 	// GetBlockByHash returns a block given it's hash
 	GetBlockByHash(blockHash []byte) (*common.Block, error)
 	// GetBlockByTxID returns a block which contains a transaction
 	GetBlockByTxID(txID string) (*common.Block, error)
 	// GetTxValidationCodeByTxID returns transaction validation code and block number in which the transaction was committed
 	GetTxValidationCodeByTxID(txID string) (peer.TxValidationCode, uint64, error)
+	// GetLastBlock returns the highest committed block. Unlike retrieving the blockchain info followed by the block
+	// at height-1, the height and the block are read under the same lock and hence a concurrent commit cannot slip in
+	// between. An `EmptyLedgerError` is returned if the ledger does not contain any block
+	GetLastBlock() (*common.Block, error)
 	// NewTxSimulator gives handle to a transaction simulator.
 	// A client can obtain more than one 'TxSimulator's for parallel execution.
 	// Any snapshoting/synchronization should be performed at the implementation level if required
 	NewTxSimulator(txid string) (TxSimulator, error)
+	// NewTxSimulatorAtHeight gives handle to a transaction simulator whose reads see the public state as of the
+	// given height, i.e., the state after committing the blocks below the given height. This is intended for the
+	// deterministic replay and the what-if analysis and hence the results of the simulation are not meant to be
+	// endorsed. The state as of a past height is reconstructed from the history database and the block store, so
+	// this returns a `HistoryDBNotEnabledError` if the history database is not enabled. Only the public state reads
+	// of individual keys are supported; the other queries return an error. The reads of a namespace with a
+	// block-to-live configured via StateDBConfig.BlockToLive return an error, as the expiry of the keys is not
+	// recorded in the history database. The writes are accepted but the function GetTxSimulationResults returns an
+	// error. An error is returned if the given height is above the current height of the ledger
+	NewTxSimulatorAtHeight(txid string, height uint64) (TxSimulator, error)
 	// NewQueryExecutor gives handle to a query executor.
 	// A client can obtain more than one 'QueryExecutor's for parallel execution.
 	// Any synchronization should be performed at the implementation level if required
@@ -176,7 +464,51 @@ type PeerLedger interface {
 	// NewHistoryQueryExecutor gives handle to a history query executor.
 	// A client can obtain more than one 'HistoryQueryExecutor's for parallel execution.
 	// Any synchronization should be performed at the implementation level if required
+	// It returns a `HistoryDBNotEnabledError` if the history database is not enabled and a
+	// `HistoryDBRebuildInProgressError` while the history database is being rebuilt in the background
 	NewHistoryQueryExecutor() (HistoryQueryExecutor, error)
+	// HistoryRebuildStatus reports the progress of the background rebuild of the history database, which
+	// happens when the ledger is opened with the option `DeferHistoryRebuild`. It returns a
+	// `HistoryDBNotEnabledError` if the history database is not enabled
+	HistoryRebuildStatus() (*HistoryRebuildStatus, error)
+	// RollbackHistoryDB removes the history entries for the blocks above `toBlock` and resets the history DB
+	// savepoint to `toBlock`. The state DB and the block store are not affected. The removed history is
+	// re-populated from the block store when the history DB is caught up via `CatchUpHistoryDB` or on the next ledger open.
+	// It returns an error if `toBlock` is above the current savepoint of the history DB.
+	RollbackHistoryDB(toBlock uint64) error
+	// HistoryDBSavepoint returns the height up to which the history database has been populated, or nil if no
+	// block has been committed to the history database. It returns a `HistoryDBNotEnabledError` if the history
+	// database is not enabled
+	HistoryDBSavepoint() (*HistoryDBSavepoint, error)
+	// CatchUpHistoryDB commits to the history database the blocks from its savepoint to the tip of the block store
+	// and resumes the history database commits that are paused by `RollbackHistoryDB`. The block commits are held off
+	// only between the batches of blocks. As the catch-up starts from the savepoint of the history database, a failed
+	// catch-up can be retried and a catch-up on an up-to-date history database is a no-op. It returns a
+	// `HistoryDBNotEnabledError` if the history database is not enabled and a `HistoryDBRebuildInProgressError`
+	// while the history database is being rebuilt in the background
+	CatchUpHistoryDB() error
+	// BlockStoreCheckpointInfo returns a point-in-time copy of the block store checkpoint, i.e., the block file and
+	// the offset in that file up to which the blocks have been persisted. This can be used by backup tools for
+	// copying the block files up to a safe boundary while the ledger is open and blocks are being committed.
+	BlockStoreCheckpointInfo() (*BlockStoreCheckpoint, error)
+	// GetBlockchainInfoExtended returns the same height and hashes as GetBlockchainInfo along with the height of
+	// the snapshot from which the ledger was bootstrapped and the earliest block that can be retrieved from the
+	// ledger. Tools that iterate over the blocks can use this to avoid requesting the blocks that are not present.
+	GetBlockchainInfoExtended() (*ExtendedBlockchainInfo, error)
+	// PruneBlocks discards the blocks below `beforeBlock` from the block store. An attempt to retrieve a pruned
+	// block, or a transaction in a pruned block, returns a `blkstorage.ErrBlockPruned` error. The last committed
+	// block cannot be pruned. As with a ledger bootstrapped from a snapshot, the databases and the block index of a
+	// pruned ledger cannot be rebuilt from the blocks; hence, the rebuild, rollback, and reset of the ledger are not
+	// supported and the missing private data of the pruned blocks is not reconciled.
+	PruneBlocks(beforeBlock uint64) error
+	// ArchiveBlocks moves the block files that contain only the blocks below `beforeBlock` to the archive configured
+	// in `BlockStorageConfig.Archiver`. The archived blocks remain retrievable via the block retrieval APIs, which
+	// fetch the block file back from the archive when needed.
+	ArchiveBlocks(beforeBlock uint64) error
+	// Backup writes a copy of the ledger under `targetDir` while the ledger keeps committing blocks. The dir can be
+	// used as the ledger root dir (`Config.RootFSPath`) for restoring the ledger. The state DB, the history DB, and
+	// the block index are rebuilt when the restored ledger is opened for the first time.
+	Backup(targetDir string) error
 	// GetPvtDataAndBlockByNum returns the block and the corresponding pvt data.
 	// The pvt data is filtered by the list of 'ns/collections' supplied
 	// A nil filter does not filter any results and causes retrieving all the pvt data for the given blockNum
@@ -188,8 +520,25 @@ type PeerLedger interface {
 	// CommitLegacy commits the block and the corresponding pvt data in an atomic operation following the v14 validation/commit path
 	// TODO: add a new Commit() path that replaces CommitLegacy() for the validation refactor described in FAB-12221
 	CommitLegacy(blockAndPvtdata *BlockAndPvtData, commitOpts *CommitOptions) error
+	// PreviewCommit validates the block against the current state, as CommitLegacy does, and returns the changes
+	// to the public state that committing the block would cause, without persisting anything. The invalid
+	// transactions do not contribute to the returned delta. The supplied block is not modified. An error is
+	// returned if the block is not the next block in the chain
+	PreviewCommit(blockAndPvtdata *BlockAndPvtData) (*StateDelta, error)
+	// AppendBlockRaw appends the block to the block store and updates the block and txid indexes without
+	// validating the transactions and without updating the state database and the history database. This is
+	// intended for importing a historical chain for analysis, where only the block queries are of interest.
+	// The block is expected to carry the transaction validation codes in its metadata. An error is returned
+	// if the block number is not the next in sequence or the previous hash does not match the last block.
+	// The state database is not caught up with the appended blocks and hence, the state reads are stale. Once a
+	// block is appended via this function, the ledger is marked as such and the subsequent CommitLegacy and
+	// CommitPvtDataOfOldBlocks are rejected, including after the ledger is reopened
+	AppendBlockRaw(block *common.Block) error
 	// GetConfigHistoryRetriever returns the ConfigHistoryRetriever
 	GetConfigHistoryRetriever() (ConfigHistoryRetriever, error)
+	// CollectionConfigAt returns the collection config package of the given namespace as it was at the given
+	// block number. It returns an error if the namespace had no collection config defined at that height.
+	CollectionConfigAt(namespace string, blockNum uint64) (*peer.CollectionConfigPackage, error)
 	// CommitPvtDataOfOldBlocks commits the private data corresponding to already committed block
 	// If hashes for some of the private data supplied in this function does not match
 	// the corresponding hash present in the block, the unmatched private data is not
@@ -197,12 +546,36 @@ type PeerLedger interface {
 	CommitPvtDataOfOldBlocks(reconciledPvtdata []*ReconciledPvtdata, unreconciled MissingPvtDataInfo) ([]*PvtdataHashMismatch, error)
 	// GetMissingPvtDataTracker return the MissingPvtDataTracker
 	GetMissingPvtDataTracker() (MissingPvtDataTracker, error)
+	// MissingPvtDataInfo returns, for each block up to `maxBlock`, the private data of the eligible collections
+	// that is missing and pending the reconciliation. Unlike the MissingPvtDataTracker, which hands out the entries
+	// of the most recent blocks in the order of the reconciliation priority, this reports all the outstanding
+	// entries. The expired entries are not reported. An empty result is returned if no private data is missing
+	MissingPvtDataInfo(maxBlock uint64) (MissingPvtDataInfo, error)
+	// GetPvtdataReconciliationStatus returns the progress of the reconciliation of the missing private data of the
+	// eligible collections, so that it can be determined whether the peer has caught up the private data of the
+	// collections that it is eligible for, for instance, after being added to a collection
+	GetPvtdataReconciliationStatus() (*PvtdataReconciliationStatus, error)
+	// CompactPvtdataStore compacts the physical storage of the private data of the ledger so as to reclaim the space
+	// occupied by the private data that is purged as per the block-to-live policy of the collections or otherwise.
+	// The compaction proceeds alongside the block commits. The function returns the approximate number of bytes reclaimed
+	CompactPvtdataStore() (reclaimedBytes int64, err error)
 	// DoesPvtDataInfoExist returns true when
 	// (1) the ledger has pvtdata associated with the given block number (or)
 	// (2) a few or all pvtdata associated with the given block number is missing but the
 	//     missing info is recorded in the ledger (or)
 	// (3) the block is committed and does not contain any pvtData.
 	DoesPvtDataInfoExist(blockNum uint64) (bool, error)
+	// UpdatePvtDataConfig updates the purge interval and the batch parameters (MaxBatchSize and BatchesInterval)
+	// of the private data store without requiring a restart. The new values take effect from the next cycle of
+	// the corresponding background processing. Other fields in the supplied config are ignored.
+	UpdatePvtDataConfig(cfg *PrivateDataConfig) error
+	// Freeze waits for the in-flight commit, if any, to finish and then prevents the commits and the creation of
+	// new transaction simulators until the ledger is unfrozen. Depending on `Config.RejectWritesWhenFrozen`,
+	// such operations either block or fail with a `LedgerFrozenError`. The read queries are not affected.
+	// This is intended for maintenance windows, such as a manual compaction or a copy of the ledger files.
+	Freeze() error
+	// Unfreeze resumes the commits and the creation of transaction simulators on a frozen ledger
+	Unfreeze() error
 
 	// SubmitSnapshotRequest submits a snapshot request for the specified height.
 	// The request will be stored in the ledger until the ledger's block height is equal to
@@ -222,6 +595,56 @@ type PeerLedger interface {
 	// CommitNotifications channel to close. There is expected to be only one consumer at a time. The function returns error
 	// if already a CommitNotification channel is active.
 	CommitNotificationsChannel(done <-chan struct{}) (<-chan *CommitNotification, error)
+	// RegisterCommitListener registers a listener that is invoked with each block after the block is committed,
+	// in the order of the blocks. Multiple listeners can be registered and each listener is invoked on a goroutine
+	// of its own, so that a slow listener does not hold off the commits. For each listener, up to a bounded number
+	// of the committed blocks are buffered and, beyond that, the blocks are dropped for the listener with a warning
+	// logged. A listener can detect the dropped blocks by the gap in the block numbers. The returned function
+	// unregisters the listener, after which the listener is not invoked
+	RegisterCommitListener(fn func(committedBlock *common.Block)) (cancel func())
+	// RegisterPvtdataPurgeListener registers a listener that is invoked, after the commit of a block, with the purges of
+	// the private data keys initiated by the transactions of the block via the function TxSimulator.PurgePrivateData.
+	// The listener is not invoked for a block that does not purge any private data. The listeners are invoked and
+	// buffered the same way as the ones registered via RegisterCommitListener
+	RegisterPvtdataPurgeListener(fn func(event *PvtdataPurgeEvent)) (cancel func())
+	// GetPvtdataScheduledForPurge returns the private data keys that are scheduled to be purged, as per the
+	// block-to-live of their collections, with the commit of the next `numBlocks` blocks, ordered by the expiring
+	// block. A key that is written again after the write that set its expiry is excluded, as it is not purged.
+	// However, the result reflects the state at the time of the call and the keys that are written again in the
+	// blocks yet to be committed are still purged at their new expiry instead
+	GetPvtdataScheduledForPurge(numBlocks uint64) ([]*ScheduledPvtdataPurge, error)
+	// ComputeStateHash returns a digest of the entire public state, computed over the <namespace, key, value, metadata,
+	// version> tuples in the order of the namespaces and the keys. Two ledgers with an identical public state in the
+	// same type of state database produce the same digest. As the state database maintains only the latest state,
+	// the digest can be computed only for the current height of the ledger and an error is returned for any other height
+	ComputeStateHash(height uint64) ([]byte, error)
+	// CommitBackpressure returns an estimate, in the range 0 to 1, of how saturated the block store write path is.
+	// The estimate is based on the fraction of the recent time spent in appending the blocks to the block store and a
+	// value close to 1 indicates that the commits are limited by the block store. The consumer that supplies the blocks
+	// for commit can use this to slow down the intake. This is cheap to call and does not block the commits
+	CommitBackpressure() float64
+	// ChannelID returns the channel ID from the channel header of the genesis block of the ledger, which may
+	// differ from the ledger ID if the ledger was created with a custom ID. An error is returned if the ledger
+	// was bootstrapped from a snapshot, as such a ledger does not contain the genesis block
+	ChannelID() (string, error)
+	// TxIDsInRange returns an iterator over the transactions in the blocks from startBlockNum (inclusive) to
+	// endBlockNum (exclusive), in the order of their commit. The endBlockNum is capped at the current height and
+	// an empty iterator is returned if endBlockNum is not greater than startBlockNum. The transaction IDs are read
+	// from the block store index and the transaction envelopes, without loading the complete blocks.
+	// The returned ResultsIterator contains results of type *TxIDInfo
+	TxIDsInRange(startBlockNum, endBlockNum uint64) (commonledger.ResultsIterator, error)
+	// TxIDsByChaincodeName returns an iterator over the endorser transactions that invoke the given chaincode, in the
+	// order of their commit. This requires `BlockStorageConfig.IndexChaincodeName` to be enabled.
+	// The returned ResultsIterator contains results of type *TxIDInfo
+	TxIDsByChaincodeName(chaincodeName string) (commonledger.ResultsIterator, error)
+	// TxIDsByEndorserMSPID returns an iterator over the endorser transactions that carry an endorsement from a member
+	// of the given MSP, in the order of their commit. This requires `BlockStorageConfig.IndexEndorserMSPIDs` to be enabled.
+	// The returned ResultsIterator contains results of type *TxIDInfo
+	TxIDsByEndorserMSPID(mspID string) (commonledger.ResultsIterator, error)
+	// GetMostRecentConfigBlock returns the most recent config block of the ledger. The block is located via the
+	// index of the last config block recorded in the metadata of the last block, if present. Otherwise, the
+	// blocks are scanned backward, up to a bounded number of blocks, for a config block
+	GetMostRecentConfigBlock() (*common.Block, error)
 }
 
 // SimpleQueryExecutor encapsulates basic functions
@@ -250,6 +673,13 @@ type QueryExecutor interface {
 	SimpleQueryExecutor
 	// GetStateMetadata returns the metadata for given namespace and key
 	GetStateMetadata(namespace, key string) (map[string][]byte, error)
+	// GetStateVersion returns the version of the current value of the given namespace and key, i.e., the block number
+	// and the transaction number at which the value was committed. A nil version is returned for a non-existent key
+	GetStateVersion(namespace, key string) (*kvrwset.Version, error)
+	// GetStateProof returns a proof that the given key has its current value in the public state, which can be verified
+	// against the state root via the function `stateproof.Verify`. The proof is computed by traversing the entire public
+	// state and hence this function should be used judiciously. A nil proof is returned for a non-existent key
+	GetStateProof(namespace, key string) (*stateproof.Proof, error)
 	// GetStateMultipleKeys gets the values for multiple keys in a single call
 	GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error)
 	// GetStateRangeScanIteratorWithPagination returns an iterator that contains all the key-values between given key ranges.
@@ -284,20 +714,64 @@ type QueryExecutor interface {
 	// can be supplied as empty strings. However, a full scan shuold be used judiciously for performance reasons.
 	// The returned ResultsIterator contains results of type *KV which is defined in fabric-protos/ledger/queryresult.
 	GetPrivateDataRangeScanIterator(namespace, collection, startKey, endKey string) (commonledger.ResultsIterator, error)
+	// GetPrivateDataHashByRange returns an iterator over the hashes of the private data items of a collection whose key hashes
+	// are between the given key hashes. startKeyHash is included in the results and endKeyHash is excluded. A nil startKeyHash
+	// refers to the first key hash and a nil endKeyHash refers to the last key hash. Like the function `GetPrivateDataHash`,
+	// this can be invoked on any peer, including the peers that are not authorized to have the private data of the collection,
+	// for instance, for verifying the private data shared by the other organizations. The results are in the order of the
+	// key hashes, except for CouchDB as the state database, in which case the order is unspecified.
+	// The returned ResultsIterator contains results of type *PvtdataHashKV.
+	GetPrivateDataHashByRange(namespace, collection string, startKeyHash, endKeyHash []byte) (commonledger.ResultsIterator, error)
 	// ExecuteQuery executes the given query and returns an iterator that contains results of type specific to the underlying data store.
 	// Only used for state databases that support query
 	// For a chaincode, the namespace corresponds to the chaincodeId
 	// The returned ResultsIterator contains results of type *KV which is defined in fabric-protos/ledger/queryresult.
 	ExecuteQueryOnPrivateData(namespace, collection, query string) (commonledger.ResultsIterator, error)
+	// GetNamespaceFullScanIterator returns an iterator over all the keys in the public state of the given namespace,
+	// in the sorted order of the keys. This is intended for exporting the entire state of a namespace and the reads
+	// performed via this iterator are not recorded in the read-set of a transaction.
+	// The returned ResultsIterator contains results of type *VersionedKV.
+	GetNamespaceFullScanIterator(namespace string) (commonledger.ResultsIterator, error)
 	// Done releases resources occupied by the QueryExecutor
 	Done()
 }
 
+// VersionedKV is the result type returned by the iterator from `QueryExecutor.GetNamespaceFullScanIterator`.
+// BlockNum and TxNum together form the version of the value, i.e., the height of the transaction that last wrote the key
+type VersionedKV struct {
+	Namespace string
+	Key       string
+	Value     []byte
+	Metadata  []byte
+	BlockNum  uint64
+	TxNum     uint64
+}
+
+// PvtdataHashKV is the result type returned by the iterator from `QueryExecutor.GetPrivateDataHashByRange`.
+// BlockNum and TxNum together form the version of the value, i.e., the height of the transaction that last wrote the key
+type PvtdataHashKV struct {
+	Namespace  string
+	Collection string
+	KeyHash    []byte
+	ValueHash  []byte
+	BlockNum   uint64
+	TxNum      uint64
+}
+
 // HistoryQueryExecutor executes the history queries
 type HistoryQueryExecutor interface {
 	// GetHistoryForKey retrieves the history of values for a key.
 	// The returned ResultsIterator contains results of type *KeyModification which is defined in fabric-protos/ledger/queryresult.
 	GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error)
+	// GetHistoryForKeyRange retrieves the history of values for a key, restricted to the modifications committed
+	// in the blocks from the startBlock to the endBlock, both inclusive. Similar to the function GetHistoryForKey,
+	// the results are of type *KeyModification and are returned in the order of newest to oldest
+	GetHistoryForKeyRange(namespace string, key string, startBlock, endBlock uint64) (commonledger.ResultsIterator, error)
+	// GetHistoryForKeyWithPagination retrieves the history of values for a key, a page at a time, in the order of
+	// newest to oldest. The page size parameter limits the number of returned results and a page size of zero denotes
+	// an unlimited page. The bookmark returned by the iterator, if not empty, is used for retrieving the next page.
+	// An empty bookmark starts at the latest modification of the key
+	GetHistoryForKeyWithPagination(namespace string, key string, bookmark string, pageSize int32) (QueryResultsIterator, error)
 }
 
 // TxSimulator simulates a transaction on a consistent snapshot of the 'as recent state as possible'
@@ -348,6 +822,14 @@ type QueryResultsIterator interface {
 	GetBookmarkAndClose() string
 }
 
+// TxIDInfo encapsulates the transaction ID of a transaction along with its position in the ledger and its validation code
+type TxIDInfo struct {
+	TxID           string
+	BlockNum       uint64
+	TxNum          uint64
+	ValidationCode peer.TxValidationCode
+}
+
 // TxPvtData encapsulates the transaction number and pvt write-set for a transaction
 type TxPvtData struct {
 	SeqInBlock uint64
@@ -420,9 +902,54 @@ type BlockPvtdata struct {
 	MissingPvtData TxMissingPvtData
 }
 
+// OpenOptions encapsulates options associated with opening an existing ledger.
+type OpenOptions struct {
+	// DeferHistoryRebuild, when set, lets the ledger open without first catching up the history database with
+	// the block store (e.g., after the history database is dropped). The history database is instead rebuilt in
+	// the background and the history queries return a `HistoryDBRebuildInProgressError` until it is caught up
+	DeferHistoryRebuild bool
+}
+
+// HistoryRebuildStatus reports the progress of the background rebuild of the history database
+type HistoryRebuildStatus struct {
+	// InProgress is true while the history database is being rebuilt
+	InProgress bool
+	// HistoryHeight is the number of blocks that the history database has caught up with
+	HistoryHeight uint64
+	// BlockStoreHeight is the height of the block store, i.e., the height the history database is catching up to
+	BlockStoreHeight uint64
+	// Err is the error that stopped the rebuild, if any. The rebuild is retried on the next ledger open
+	Err error
+}
+
+// HistoryDBSavepoint is the height of the last transaction committed to the history database
+type HistoryDBSavepoint struct {
+	BlockNum uint64
+	TxNum    uint64
+}
+
 // CommitOptions encapsulates options associated with a block commit.
 type CommitOptions struct {
 	FetchPvtDataFromLedger bool
+	// SkipValidation indicates that the transactions in the block have already been validated upstream
+	// (e.g., when catching up from a trusted source). The transaction validation codes present in the
+	// block metadata are trusted and the MVCC validation of the transactions is skipped. The block is
+	// still required to be the next block in the chain
+	SkipValidation bool
+}
+
+// StateDelta contains the changes to the public state that would result from committing a block
+type StateDelta struct {
+	// Namespaces maps a namespace to the changes of the keys, by the key, in the namespace
+	Namespaces map[string]map[string]*KeyChange
+}
+
+// KeyChange contains the value of a key before and after committing a block. OldValue is nil if the key does
+// not exist before the commit. NewValue is nil, and IsDelete is set, if the key is deleted by the block
+type KeyChange struct {
+	OldValue []byte
+	NewValue []byte
+	IsDelete bool
 }
 
 // PvtCollFilter represents the set of the collection names (as keys of the map with value 'true')
@@ -664,6 +1191,112 @@ func (e *InvalidCollNameError) Error() string {
 	return fmt.Sprintf("collection [%s] not defined in the collection config for chaincode [%s]", e.Coll, e.Ns)
 }
 
+// ExtendedBlockchainInfo extends the information in common.BlockchainInfo with the range of the available blocks
+type ExtendedBlockchainInfo struct {
+	Height            uint64
+	CurrentBlockHash  []byte
+	PreviousBlockHash []byte
+	// BootstrappingSnapshotHeight is the height of the ledger at the snapshot from which the ledger was bootstrapped,
+	// i.e., the number of the last block in the snapshot plus one. This is 0 for a ledger that was created from a
+	// genesis block
+	BootstrappingSnapshotHeight uint64
+	// EarliestAvailableBlock is the lowest block number that can be retrieved from the ledger. The blocks below this
+	// are either not present because the ledger was bootstrapped from a snapshot or have been pruned
+	EarliestAvailableBlock uint64
+}
+
+// BlockStoreCheckpoint captures the position in the block files up to which the blocks have been persisted
+type BlockStoreCheckpoint struct {
+	// FileSuffixNum is the suffix number of the block file that is currently being appended to
+	FileSuffixNum int
+	// Offset is the number of bytes persisted in the block file with suffix FileSuffixNum
+	Offset int
+	// LastBlockNumber is the number of the highest block persisted in the block files
+	LastBlockNumber uint64
+	// NoBlockFiles is true if no block has been persisted in the block files, which is the case for a
+	// ledger bootstrapped from a snapshot before committing any block. LastBlockNumber is not meaningful in this case
+	NoBlockFiles bool
+}
+
+// HistoryDBNotEnabledError is returned whenever a history database
+// operation is requested on a ledger for which the history database is not enabled
+type HistoryDBNotEnabledError struct {
+	LedgerID string
+}
+
+func (e *HistoryDBNotEnabledError) Error() string {
+	return fmt.Sprintf("history database is not enabled for ledger [%s]", e.LedgerID)
+}
+
+// HistoryDBRebuildInProgressError is returned when a history query is requested on a ledger
+// whose history database is being rebuilt in the background
+type HistoryDBRebuildInProgressError struct {
+	LedgerID string
+}
+
+func (e *HistoryDBRebuildInProgressError) Error() string {
+	return fmt.Sprintf("history rebuild in progress for ledger [%s]", e.LedgerID)
+}
+
+// LedgerFrozenError is returned when a commit or the creation of a transaction simulator
+// is requested on a frozen ledger and the ledger is configured to reject such operations
+type LedgerFrozenError struct {
+	LedgerID string
+}
+
+func (e *LedgerFrozenError) Error() string {
+	return fmt.Sprintf("ledger [%s] is frozen", e.LedgerID)
+}
+
+// LedgerClosedError is returned when an operation is invoked on a ledger after the ledger,
+// or the provider that opened the ledger, is closed
+type LedgerClosedError struct {
+	LedgerID string
+}
+
+func (e *LedgerClosedError) Error() string {
+	return fmt.Sprintf("ledger [%s] is closed", e.LedgerID)
+}
+
+// CommitTimeoutError is returned when the writes to the state database and the history database during
+// the commit of a block do not complete within the duration configured via StateDBConfig.WriteTimeout.
+// It does not mean that the commit failed: the block is persisted in the block store and the pvtdata store
+// but its application to the databases is pending. The writes are left running in the background and the
+// save point of a database whose write has not completed is not advanced. The ledger rejects the subsequent
+// commits with this error until the writes complete, at which point the commit of the block is completed,
+// including the notifications to the commit listeners, and the commits resume. If the writes fail, the
+// subsequent commits are rejected with the error of the writes until the ledger is reopened, and the
+// databases are caught up with the block store when the ledger is opened next time
+type CommitTimeoutError struct {
+	LedgerID string
+	BlockNum uint64
+	Timeout  time.Duration
+}
+
+func (e *CommitTimeoutError) Error() string {
+	return fmt.Sprintf("commit of block [%d] to the state and history databases of ledger [%s] did not complete within %s",
+		e.BlockNum, e.LedgerID, e.Timeout)
+}
+
+// ReadNotAuthorizedError is returned by a query executor when the Initializer.ReadAuthorizer rejects the read of a key
+type ReadNotAuthorizedError struct {
+	Namespace, Key string
+	Cause          error
+}
+
+func (e *ReadNotAuthorizedError) Error() string {
+	return fmt.Sprintf("read of key [%s] in namespace [%s] is not authorized: %s", e.Key, e.Namespace, e.Cause)
+}
+
+// EmptyLedgerError is returned when the last block is requested from a ledger that does not contain any block
+type EmptyLedgerError struct {
+	LedgerID string
+}
+
+func (e *EmptyLedgerError) Error() string {
+	return fmt.Sprintf("ledger [%s] does not contain any block", e.LedgerID)
+}
+
 // PvtdataHashMismatch is used when the hash of private write-set
 // does not match the corresponding hash present in the block
 // or there is a mismatch with the boot-KV-hashes present in the
@@ -770,6 +1403,13 @@ type ChaincodeLifecycleEventProvider interface {
 	RegisterListener(channelID string, listener ChaincodeLifecycleEventListener, needsExistingChaincodesDefinitions bool) error
 }
 
+// LedgerCreationListener enables the downstream components to initialize for a newly created ledger
+type LedgerCreationListener interface {
+	// HandleLedgerCreated is invoked after the ledger is created from the genesis block and the ledger is
+	// marked active. An error returned by this function is logged and does not affect the created ledger
+	HandleLedgerCreated(ledgerID string, genesisBlockHash []byte) error
+}
+
 // CustomTxProcessor allows to generate simulation results during commit time for custom transactions.
 // A custom processor may represent the information in a propriety fashion and can use this process to translate
 // the information into the form of `TxSimulationResults`. Because, the original information is signed in a
@@ -807,6 +1447,51 @@ type CommitNotification struct {
 	TxsInfo     []*CommitNotificationTxInfo
 }
 
+// PvtdataPurgeEvent is passed to the listeners registered via PeerLedger.RegisterPvtdataPurgeListener.
+// It contains the purges of the private data keys initiated by the transactions of a block, ordered by the
+// transaction number, namespace, collection, and key hash
+type PvtdataPurgeEvent struct {
+	BlockNumber uint64
+	Purges      []*PvtdataPurge
+}
+
+// PvtdataPurge identifies a private data key purged by a transaction. The Key is set if the purge deletes the key
+// from the private data of this peer, and is empty otherwise, for instance, if the peer is not eligible for the
+// private data of the collection or the key is written again by a later transaction in the same block
+type PvtdataPurge struct {
+	TxNum      uint64
+	Namespace  string
+	Collection string
+	KeyHash    []byte
+	Key        string
+}
+
+// ScheduledPvtdataPurge identifies a private data key that is scheduled to be purged with the commit of the block
+// ExpiringBlock, as per the block-to-live of its collection, since the write by the block CommittingBlock. The Key is
+// empty if this peer does not have the private data. The PurgesKeyOnly, when set, denotes that only the private data
+// is purged and not the key hash, as the key hash is written again by a later block for which the peer is missing
+// the private data
+type ScheduledPvtdataPurge struct {
+	ExpiringBlock   uint64
+	CommittingBlock uint64
+	Namespace       string
+	Collection      string
+	KeyHash         []byte
+	Key             string
+	PurgesKeyOnly   bool
+}
+
+// PvtdataReconciliationStatus is returned by the function PeerLedger.GetPvtdataReconciliationStatus.
+// MissingPvtdataCount is the number of the private write sets of the eligible collections that are missing and
+// not expired, counting one per transaction and collection, and OldestMissingBlock is the lowest block number among
+// these, or zero if no private data is missing. LastReconciledTime is the time at which the private data fetched by
+// the reconciler was last committed to the ledger since the peer started, and is the zero time if none has been
+type PvtdataReconciliationStatus struct {
+	MissingPvtdataCount uint64
+	OldestMissingBlock  uint64
+	LastReconciledTime  time.Time
+}
+
 // CommitNotificationTxInfo contains the details of a transaction that is included in the CommitNotification
 // ChaincodeID will be nil if the transaction is not an endorser transaction. This may or may not be nil if the tranasction is invalid.
 // Specifically, it will be nil if the transaction is marked invalid by the validator (e.g., bad payload or insufficient endorements) and it will be non-nil if the transaction is marked invalid for concurrency conflicts.
@@ -828,3 +1513,4 @@ type CommitNotificationTxInfo struct {
 //go:generate counterfeiter -o mock/cc_event_listener.go -fake-name ChaincodeLifecycleEventListener . ChaincodeLifecycleEventListener
 //go:generate counterfeiter -o mock/custom_tx_processor.go -fake-name CustomTxProcessor . CustomTxProcessor
 //go:generate counterfeiter -o mock/cc_event_provider.go -fake-name ChaincodeLifecycleEventProvider . ChaincodeLifecycleEventProvider
+//go:generate counterfeiter -o mock/ledger_creation_listener.go -fake-name LedgerCreationListener . LedgerCreationListener
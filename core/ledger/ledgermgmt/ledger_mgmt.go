@@ -59,6 +59,8 @@ type Initializer struct {
 	Config                          *ledger.Config
 	HashProvider                    ledger.HashProvider
 	EbMetadataProvider              MetadataProvider
+	LedgerIDValidator               func(ledgerID string) error
+	ReadAuthorizer                  func(namespace, key string) error
 }
 
 // NewLedgerMgr creates a new LedgerMgr
@@ -79,6 +81,8 @@ func NewLedgerMgr(initializer *Initializer) *LedgerMgr {
 			Config:                          initializer.Config,
 			CustomTxProcessors:              initializer.CustomTxProcessors,
 			HashProvider:                    initializer.HashProvider,
+			LedgerIDValidator:               initializer.LedgerIDValidator,
+			ReadAuthorizer:                  initializer.ReadAuthorizer,
 		},
 	)
 	if err != nil {
@@ -221,6 +225,51 @@ func (m *LedgerMgr) OpenLedger(id string) (ledger.PeerLedger, error) {
 	}, nil
 }
 
+// DeactivateLedger marks the ledger for the given id as inactive and closes the ledger, if opened. The data of the
+// ledger is retained and the ledger can be reactivated later via ReactivateLedger
+func (m *LedgerMgr) DeactivateLedger(id string) error {
+	logger.Infof("Deactivating ledger with id = %s", id)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err := m.ledgerProvider.Deactivate(id); err != nil {
+		return err
+	}
+	if l, ok := m.openedLedgers[id]; ok {
+		l.Close()
+		delete(m.openedLedgers, id)
+	}
+	logger.Infof("Deactivated ledger with id = %s", id)
+	return nil
+}
+
+// ReactivateLedger marks the inactive ledger for the given id as active and returns the opened ledger. If the ledger
+// cannot be opened, the ledger is left inactive
+func (m *LedgerMgr) ReactivateLedger(id string) (ledger.PeerLedger, error) {
+	logger.Infof("Reactivating ledger with id = %s", id)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.openedLedgers[id]; ok {
+		return nil, ErrLedgerAlreadyOpened
+	}
+	if err := m.ledgerProvider.Reactivate(id); err != nil {
+		return nil, err
+	}
+	l, err := m.ledgerProvider.Open(id)
+	if err != nil {
+		if deactivationErr := m.ledgerProvider.Deactivate(id); deactivationErr != nil {
+			logger.Errorf("Error while deactivating ledger [%s] after failing to open it: %s", id, deactivationErr)
+		}
+		return nil, err
+	}
+	m.openedLedgers[id] = l
+	logger.Infof("Reactivated ledger with id = %s", id)
+	return &closableLedger{
+		ledgerMgr:  m,
+		id:         id,
+		PeerLedger: l,
+	}, nil
+}
+
 // GetLedgerIDs returns the ids of the ledgers created
 func (m *LedgerMgr) GetLedgerIDs() ([]string, error) {
 	m.lock.Lock()
@@ -71,6 +71,41 @@ func TestLedgerMgmt(t *testing.T) {
 	ledgerMgr.Close()
 }
 
+func TestDeactivateAndReactivateLedger(t *testing.T) {
+	_, ledgerMgr, cleanup := setup(t)
+	defer cleanup()
+
+	ledgerID := constructTestLedgerID(0)
+	gb, _ := test.MakeGenesisBlock(ledgerID)
+	_, err := ledgerMgr.CreateLedger(ledgerID, gb)
+	require.NoError(t, err)
+
+	require.NoError(t, ledgerMgr.DeactivateLedger(ledgerID))
+	ids, err := ledgerMgr.GetLedgerIDs()
+	require.NoError(t, err)
+	require.Empty(t, ids)
+	_, err = ledgerMgr.OpenLedger(ledgerID)
+	require.EqualError(t, err, "cannot open ledger [ledger_000000], ledger status is [INACTIVE]")
+
+	l, err := ledgerMgr.ReactivateLedger(ledgerID)
+	require.NoError(t, err)
+	bcInfo, err := l.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), bcInfo.Height)
+	ids, err = ledgerMgr.GetLedgerIDs()
+	require.NoError(t, err)
+	require.Equal(t, []string{ledgerID}, ids)
+
+	_, err = ledgerMgr.ReactivateLedger(ledgerID)
+	require.Equal(t, ErrLedgerAlreadyOpened, err)
+	_, err = ledgerMgr.OpenLedger(ledgerID)
+	require.Equal(t, ErrLedgerAlreadyOpened, err)
+
+	require.EqualError(t, ledgerMgr.DeactivateLedger("non-existent-ledger"), "cannot deactivate ledger [non-existent-ledger]: ledger does not exist")
+	_, err = ledgerMgr.ReactivateLedger("non-existent-ledger")
+	require.EqualError(t, err, "cannot reactivate ledger [non-existent-ledger]: ledger does not exist")
+}
+
 // TestCreateLedgerFromSnapshot first creates a ledger using a genesis block and generates a snapshot.
 // After it, it tests creating ledger from the snapshot.
 func TestCreateLedgerFromSnapshot(t *testing.T) {
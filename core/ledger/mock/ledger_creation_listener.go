@@ -0,0 +1,117 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package mock
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/core/ledger"
+)
+
+type LedgerCreationListener struct {
+	HandleLedgerCreatedStub        func(string, []byte) error
+	handleLedgerCreatedMutex       sync.RWMutex
+	handleLedgerCreatedArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+	}
+	handleLedgerCreatedReturns struct {
+		result1 error
+	}
+	handleLedgerCreatedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LedgerCreationListener) HandleLedgerCreated(arg1 string, arg2 []byte) error {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.handleLedgerCreatedMutex.Lock()
+	ret, specificReturn := fake.handleLedgerCreatedReturnsOnCall[len(fake.handleLedgerCreatedArgsForCall)]
+	fake.handleLedgerCreatedArgsForCall = append(fake.handleLedgerCreatedArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+	}{arg1, arg2Copy})
+	fake.recordInvocation("HandleLedgerCreated", []interface{}{arg1, arg2Copy})
+	fake.handleLedgerCreatedMutex.Unlock()
+	if fake.HandleLedgerCreatedStub != nil {
+		return fake.HandleLedgerCreatedStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.handleLedgerCreatedReturns
+	return fakeReturns.result1
+}
+
+func (fake *LedgerCreationListener) HandleLedgerCreatedCallCount() int {
+	fake.handleLedgerCreatedMutex.RLock()
+	defer fake.handleLedgerCreatedMutex.RUnlock()
+	return len(fake.handleLedgerCreatedArgsForCall)
+}
+
+func (fake *LedgerCreationListener) HandleLedgerCreatedCalls(stub func(string, []byte) error) {
+	fake.handleLedgerCreatedMutex.Lock()
+	defer fake.handleLedgerCreatedMutex.Unlock()
+	fake.HandleLedgerCreatedStub = stub
+}
+
+func (fake *LedgerCreationListener) HandleLedgerCreatedArgsForCall(i int) (string, []byte) {
+	fake.handleLedgerCreatedMutex.RLock()
+	defer fake.handleLedgerCreatedMutex.RUnlock()
+	argsForCall := fake.handleLedgerCreatedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *LedgerCreationListener) HandleLedgerCreatedReturns(result1 error) {
+	fake.handleLedgerCreatedMutex.Lock()
+	defer fake.handleLedgerCreatedMutex.Unlock()
+	fake.HandleLedgerCreatedStub = nil
+	fake.handleLedgerCreatedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LedgerCreationListener) HandleLedgerCreatedReturnsOnCall(i int, result1 error) {
+	fake.handleLedgerCreatedMutex.Lock()
+	defer fake.handleLedgerCreatedMutex.Unlock()
+	fake.HandleLedgerCreatedStub = nil
+	if fake.handleLedgerCreatedReturnsOnCall == nil {
+		fake.handleLedgerCreatedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.handleLedgerCreatedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LedgerCreationListener) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.handleLedgerCreatedMutex.RLock()
+	defer fake.handleLedgerCreatedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LedgerCreationListener) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ ledger.LedgerCreationListener = new(LedgerCreationListener)
@@ -4,8 +4,10 @@ package mock
 import (
 	"sync"
 
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 	ledgera "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
 )
 
 type QueryExecutor struct {
@@ -58,6 +60,19 @@ type QueryExecutor struct {
 		result1 ledger.QueryResultsIterator
 		result2 error
 	}
+	GetNamespaceFullScanIteratorStub        func(string) (ledgera.ResultsIterator, error)
+	getNamespaceFullScanIteratorMutex       sync.RWMutex
+	getNamespaceFullScanIteratorArgsForCall []struct {
+		arg1 string
+	}
+	getNamespaceFullScanIteratorReturns struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	getNamespaceFullScanIteratorReturnsOnCall map[int]struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
 	GetPrivateDataStub        func(string, string, string) ([]byte, error)
 	getPrivateDataMutex       sync.RWMutex
 	getPrivateDataArgsForCall []struct {
@@ -88,6 +103,22 @@ type QueryExecutor struct {
 		result1 []byte
 		result2 error
 	}
+	GetPrivateDataHashByRangeStub        func(string, string, []byte, []byte) (ledgera.ResultsIterator, error)
+	getPrivateDataHashByRangeMutex       sync.RWMutex
+	getPrivateDataHashByRangeArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+		arg4 []byte
+	}
+	getPrivateDataHashByRangeReturns struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	getPrivateDataHashByRangeReturnsOnCall map[int]struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
 	GetPrivateDataMetadataStub        func(string, string, string) (map[string][]byte, error)
 	getPrivateDataMetadataMutex       sync.RWMutex
 	getPrivateDataMetadataArgsForCall []struct {
@@ -191,6 +222,20 @@ type QueryExecutor struct {
 		result1 [][]byte
 		result2 error
 	}
+	GetStateProofStub        func(string, string) (*stateproof.Proof, error)
+	getStateProofMutex       sync.RWMutex
+	getStateProofArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getStateProofReturns struct {
+		result1 *stateproof.Proof
+		result2 error
+	}
+	getStateProofReturnsOnCall map[int]struct {
+		result1 *stateproof.Proof
+		result2 error
+	}
 	GetStateRangeScanIteratorStub        func(string, string, string) (ledgera.ResultsIterator, error)
 	getStateRangeScanIteratorMutex       sync.RWMutex
 	getStateRangeScanIteratorArgsForCall []struct {
@@ -222,6 +267,20 @@ type QueryExecutor struct {
 		result1 ledger.QueryResultsIterator
 		result2 error
 	}
+	GetStateVersionStub        func(string, string) (*kvrwset.Version, error)
+	getStateVersionMutex       sync.RWMutex
+	getStateVersionArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getStateVersionReturns struct {
+		result1 *kvrwset.Version
+		result2 error
+	}
+	getStateVersionReturnsOnCall map[int]struct {
+		result1 *kvrwset.Version
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -444,6 +503,69 @@ func (fake *QueryExecutor) ExecuteQueryWithPaginationReturnsOnCall(i int, result
 	}{result1, result2}
 }
 
+func (fake *QueryExecutor) GetNamespaceFullScanIterator(arg1 string) (ledgera.ResultsIterator, error) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	ret, specificReturn := fake.getNamespaceFullScanIteratorReturnsOnCall[len(fake.getNamespaceFullScanIteratorArgsForCall)]
+	fake.getNamespaceFullScanIteratorArgsForCall = append(fake.getNamespaceFullScanIteratorArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetNamespaceFullScanIterator", []interface{}{arg1})
+	fake.getNamespaceFullScanIteratorMutex.Unlock()
+	if fake.GetNamespaceFullScanIteratorStub != nil {
+		return fake.GetNamespaceFullScanIteratorStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getNamespaceFullScanIteratorReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *QueryExecutor) GetNamespaceFullScanIteratorCallCount() int {
+	fake.getNamespaceFullScanIteratorMutex.RLock()
+	defer fake.getNamespaceFullScanIteratorMutex.RUnlock()
+	return len(fake.getNamespaceFullScanIteratorArgsForCall)
+}
+
+func (fake *QueryExecutor) GetNamespaceFullScanIteratorCalls(stub func(string) (ledgera.ResultsIterator, error)) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	defer fake.getNamespaceFullScanIteratorMutex.Unlock()
+	fake.GetNamespaceFullScanIteratorStub = stub
+}
+
+func (fake *QueryExecutor) GetNamespaceFullScanIteratorArgsForCall(i int) string {
+	fake.getNamespaceFullScanIteratorMutex.RLock()
+	defer fake.getNamespaceFullScanIteratorMutex.RUnlock()
+	argsForCall := fake.getNamespaceFullScanIteratorArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *QueryExecutor) GetNamespaceFullScanIteratorReturns(result1 ledgera.ResultsIterator, result2 error) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	defer fake.getNamespaceFullScanIteratorMutex.Unlock()
+	fake.GetNamespaceFullScanIteratorStub = nil
+	fake.getNamespaceFullScanIteratorReturns = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *QueryExecutor) GetNamespaceFullScanIteratorReturnsOnCall(i int, result1 ledgera.ResultsIterator, result2 error) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	defer fake.getNamespaceFullScanIteratorMutex.Unlock()
+	fake.GetNamespaceFullScanIteratorStub = nil
+	if fake.getNamespaceFullScanIteratorReturnsOnCall == nil {
+		fake.getNamespaceFullScanIteratorReturnsOnCall = make(map[int]struct {
+			result1 ledgera.ResultsIterator
+			result2 error
+		})
+	}
+	fake.getNamespaceFullScanIteratorReturnsOnCall[i] = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *QueryExecutor) GetPrivateData(arg1 string, arg2 string, arg3 string) ([]byte, error) {
 	fake.getPrivateDataMutex.Lock()
 	ret, specificReturn := fake.getPrivateDataReturnsOnCall[len(fake.getPrivateDataArgsForCall)]
@@ -574,6 +696,82 @@ func (fake *QueryExecutor) GetPrivateDataHashReturnsOnCall(i int, result1 []byte
 	}{result1, result2}
 }
 
+func (fake *QueryExecutor) GetPrivateDataHashByRange(arg1 string, arg2 string, arg3 []byte, arg4 []byte) (ledgera.ResultsIterator, error) {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	var arg4Copy []byte
+	if arg4 != nil {
+		arg4Copy = make([]byte, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	ret, specificReturn := fake.getPrivateDataHashByRangeReturnsOnCall[len(fake.getPrivateDataHashByRangeArgsForCall)]
+	fake.getPrivateDataHashByRangeArgsForCall = append(fake.getPrivateDataHashByRangeArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+		arg4 []byte
+	}{arg1, arg2, arg3Copy, arg4Copy})
+	fake.recordInvocation("GetPrivateDataHashByRange", []interface{}{arg1, arg2, arg3Copy, arg4Copy})
+	fake.getPrivateDataHashByRangeMutex.Unlock()
+	if fake.GetPrivateDataHashByRangeStub != nil {
+		return fake.GetPrivateDataHashByRangeStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getPrivateDataHashByRangeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *QueryExecutor) GetPrivateDataHashByRangeCallCount() int {
+	fake.getPrivateDataHashByRangeMutex.RLock()
+	defer fake.getPrivateDataHashByRangeMutex.RUnlock()
+	return len(fake.getPrivateDataHashByRangeArgsForCall)
+}
+
+func (fake *QueryExecutor) GetPrivateDataHashByRangeCalls(stub func(string, string, []byte, []byte) (ledgera.ResultsIterator, error)) {
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	defer fake.getPrivateDataHashByRangeMutex.Unlock()
+	fake.GetPrivateDataHashByRangeStub = stub
+}
+
+func (fake *QueryExecutor) GetPrivateDataHashByRangeArgsForCall(i int) (string, string, []byte, []byte) {
+	fake.getPrivateDataHashByRangeMutex.RLock()
+	defer fake.getPrivateDataHashByRangeMutex.RUnlock()
+	argsForCall := fake.getPrivateDataHashByRangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *QueryExecutor) GetPrivateDataHashByRangeReturns(result1 ledgera.ResultsIterator, result2 error) {
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	defer fake.getPrivateDataHashByRangeMutex.Unlock()
+	fake.GetPrivateDataHashByRangeStub = nil
+	fake.getPrivateDataHashByRangeReturns = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *QueryExecutor) GetPrivateDataHashByRangeReturnsOnCall(i int, result1 ledgera.ResultsIterator, result2 error) {
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	defer fake.getPrivateDataHashByRangeMutex.Unlock()
+	fake.GetPrivateDataHashByRangeStub = nil
+	if fake.getPrivateDataHashByRangeReturnsOnCall == nil {
+		fake.getPrivateDataHashByRangeReturnsOnCall = make(map[int]struct {
+			result1 ledgera.ResultsIterator
+			result2 error
+		})
+	}
+	fake.getPrivateDataHashByRangeReturnsOnCall[i] = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *QueryExecutor) GetPrivateDataMetadata(arg1 string, arg2 string, arg3 string) (map[string][]byte, error) {
 	fake.getPrivateDataMetadataMutex.Lock()
 	ret, specificReturn := fake.getPrivateDataMetadataReturnsOnCall[len(fake.getPrivateDataMetadataArgsForCall)]
@@ -1042,6 +1240,70 @@ func (fake *QueryExecutor) GetStateMultipleKeysReturnsOnCall(i int, result1 [][]
 	}{result1, result2}
 }
 
+func (fake *QueryExecutor) GetStateProof(arg1 string, arg2 string) (*stateproof.Proof, error) {
+	fake.getStateProofMutex.Lock()
+	ret, specificReturn := fake.getStateProofReturnsOnCall[len(fake.getStateProofArgsForCall)]
+	fake.getStateProofArgsForCall = append(fake.getStateProofArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetStateProof", []interface{}{arg1, arg2})
+	fake.getStateProofMutex.Unlock()
+	if fake.GetStateProofStub != nil {
+		return fake.GetStateProofStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getStateProofReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *QueryExecutor) GetStateProofCallCount() int {
+	fake.getStateProofMutex.RLock()
+	defer fake.getStateProofMutex.RUnlock()
+	return len(fake.getStateProofArgsForCall)
+}
+
+func (fake *QueryExecutor) GetStateProofCalls(stub func(string, string) (*stateproof.Proof, error)) {
+	fake.getStateProofMutex.Lock()
+	defer fake.getStateProofMutex.Unlock()
+	fake.GetStateProofStub = stub
+}
+
+func (fake *QueryExecutor) GetStateProofArgsForCall(i int) (string, string) {
+	fake.getStateProofMutex.RLock()
+	defer fake.getStateProofMutex.RUnlock()
+	argsForCall := fake.getStateProofArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *QueryExecutor) GetStateProofReturns(result1 *stateproof.Proof, result2 error) {
+	fake.getStateProofMutex.Lock()
+	defer fake.getStateProofMutex.Unlock()
+	fake.GetStateProofStub = nil
+	fake.getStateProofReturns = struct {
+		result1 *stateproof.Proof
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *QueryExecutor) GetStateProofReturnsOnCall(i int, result1 *stateproof.Proof, result2 error) {
+	fake.getStateProofMutex.Lock()
+	defer fake.getStateProofMutex.Unlock()
+	fake.GetStateProofStub = nil
+	if fake.getStateProofReturnsOnCall == nil {
+		fake.getStateProofReturnsOnCall = make(map[int]struct {
+			result1 *stateproof.Proof
+			result2 error
+		})
+	}
+	fake.getStateProofReturnsOnCall[i] = struct {
+		result1 *stateproof.Proof
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *QueryExecutor) GetStateRangeScanIterator(arg1 string, arg2 string, arg3 string) (ledgera.ResultsIterator, error) {
 	fake.getStateRangeScanIteratorMutex.Lock()
 	ret, specificReturn := fake.getStateRangeScanIteratorReturnsOnCall[len(fake.getStateRangeScanIteratorArgsForCall)]
@@ -1173,6 +1435,70 @@ func (fake *QueryExecutor) GetStateRangeScanIteratorWithPaginationReturnsOnCall(
 	}{result1, result2}
 }
 
+func (fake *QueryExecutor) GetStateVersion(arg1 string, arg2 string) (*kvrwset.Version, error) {
+	fake.getStateVersionMutex.Lock()
+	ret, specificReturn := fake.getStateVersionReturnsOnCall[len(fake.getStateVersionArgsForCall)]
+	fake.getStateVersionArgsForCall = append(fake.getStateVersionArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetStateVersion", []interface{}{arg1, arg2})
+	fake.getStateVersionMutex.Unlock()
+	if fake.GetStateVersionStub != nil {
+		return fake.GetStateVersionStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getStateVersionReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *QueryExecutor) GetStateVersionCallCount() int {
+	fake.getStateVersionMutex.RLock()
+	defer fake.getStateVersionMutex.RUnlock()
+	return len(fake.getStateVersionArgsForCall)
+}
+
+func (fake *QueryExecutor) GetStateVersionCalls(stub func(string, string) (*kvrwset.Version, error)) {
+	fake.getStateVersionMutex.Lock()
+	defer fake.getStateVersionMutex.Unlock()
+	fake.GetStateVersionStub = stub
+}
+
+func (fake *QueryExecutor) GetStateVersionArgsForCall(i int) (string, string) {
+	fake.getStateVersionMutex.RLock()
+	defer fake.getStateVersionMutex.RUnlock()
+	argsForCall := fake.getStateVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *QueryExecutor) GetStateVersionReturns(result1 *kvrwset.Version, result2 error) {
+	fake.getStateVersionMutex.Lock()
+	defer fake.getStateVersionMutex.Unlock()
+	fake.GetStateVersionStub = nil
+	fake.getStateVersionReturns = struct {
+		result1 *kvrwset.Version
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *QueryExecutor) GetStateVersionReturnsOnCall(i int, result1 *kvrwset.Version, result2 error) {
+	fake.getStateVersionMutex.Lock()
+	defer fake.getStateVersionMutex.Unlock()
+	fake.GetStateVersionStub = nil
+	if fake.getStateVersionReturnsOnCall == nil {
+		fake.getStateVersionReturnsOnCall = make(map[int]struct {
+			result1 *kvrwset.Version
+			result2 error
+		})
+	}
+	fake.getStateVersionReturnsOnCall[i] = struct {
+		result1 *kvrwset.Version
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *QueryExecutor) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -1184,10 +1510,14 @@ func (fake *QueryExecutor) Invocations() map[string][][]interface{} {
 	defer fake.executeQueryOnPrivateDataMutex.RUnlock()
 	fake.executeQueryWithPaginationMutex.RLock()
 	defer fake.executeQueryWithPaginationMutex.RUnlock()
+	fake.getNamespaceFullScanIteratorMutex.RLock()
+	defer fake.getNamespaceFullScanIteratorMutex.RUnlock()
 	fake.getPrivateDataMutex.RLock()
 	defer fake.getPrivateDataMutex.RUnlock()
 	fake.getPrivateDataHashMutex.RLock()
 	defer fake.getPrivateDataHashMutex.RUnlock()
+	fake.getPrivateDataHashByRangeMutex.RLock()
+	defer fake.getPrivateDataHashByRangeMutex.RUnlock()
 	fake.getPrivateDataMetadataMutex.RLock()
 	defer fake.getPrivateDataMetadataMutex.RUnlock()
 	fake.getPrivateDataMetadataByHashMutex.RLock()
@@ -1202,10 +1532,14 @@ func (fake *QueryExecutor) Invocations() map[string][][]interface{} {
 	defer fake.getStateMetadataMutex.RUnlock()
 	fake.getStateMultipleKeysMutex.RLock()
 	defer fake.getStateMultipleKeysMutex.RUnlock()
+	fake.getStateProofMutex.RLock()
+	defer fake.getStateProofMutex.RUnlock()
 	fake.getStateRangeScanIteratorMutex.RLock()
 	defer fake.getStateRangeScanIteratorMutex.RUnlock()
 	fake.getStateRangeScanIteratorWithPaginationMutex.RLock()
 	defer fake.getStateRangeScanIteratorWithPaginationMutex.RUnlock()
+	fake.getStateVersionMutex.RLock()
+	defer fake.getStateVersionMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -4,8 +4,10 @@ package mock
 import (
 	"sync"
 
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 	ledgera "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/stateproof"
 )
 
 type TxSimulator struct {
@@ -119,6 +121,19 @@ type TxSimulator struct {
 	executeUpdateReturnsOnCall map[int]struct {
 		result1 error
 	}
+	GetNamespaceFullScanIteratorStub        func(string) (ledgera.ResultsIterator, error)
+	getNamespaceFullScanIteratorMutex       sync.RWMutex
+	getNamespaceFullScanIteratorArgsForCall []struct {
+		arg1 string
+	}
+	getNamespaceFullScanIteratorReturns struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	getNamespaceFullScanIteratorReturnsOnCall map[int]struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
 	GetPrivateDataStub        func(string, string, string) ([]byte, error)
 	getPrivateDataMutex       sync.RWMutex
 	getPrivateDataArgsForCall []struct {
@@ -149,6 +164,22 @@ type TxSimulator struct {
 		result1 []byte
 		result2 error
 	}
+	GetPrivateDataHashByRangeStub        func(string, string, []byte, []byte) (ledgera.ResultsIterator, error)
+	getPrivateDataHashByRangeMutex       sync.RWMutex
+	getPrivateDataHashByRangeArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+		arg4 []byte
+	}
+	getPrivateDataHashByRangeReturns struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	getPrivateDataHashByRangeReturnsOnCall map[int]struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
 	GetPrivateDataMetadataStub        func(string, string, string) (map[string][]byte, error)
 	getPrivateDataMetadataMutex       sync.RWMutex
 	getPrivateDataMetadataArgsForCall []struct {
@@ -252,6 +283,20 @@ type TxSimulator struct {
 		result1 [][]byte
 		result2 error
 	}
+	GetStateProofStub        func(string, string) (*stateproof.Proof, error)
+	getStateProofMutex       sync.RWMutex
+	getStateProofArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getStateProofReturns struct {
+		result1 *stateproof.Proof
+		result2 error
+	}
+	getStateProofReturnsOnCall map[int]struct {
+		result1 *stateproof.Proof
+		result2 error
+	}
 	GetStateRangeScanIteratorStub        func(string, string, string) (ledgera.ResultsIterator, error)
 	getStateRangeScanIteratorMutex       sync.RWMutex
 	getStateRangeScanIteratorArgsForCall []struct {
@@ -283,6 +328,20 @@ type TxSimulator struct {
 		result1 ledger.QueryResultsIterator
 		result2 error
 	}
+	GetStateVersionStub        func(string, string) (*kvrwset.Version, error)
+	getStateVersionMutex       sync.RWMutex
+	getStateVersionArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	getStateVersionReturns struct {
+		result1 *kvrwset.Version
+		result2 error
+	}
+	getStateVersionReturnsOnCall map[int]struct {
+		result1 *kvrwset.Version
+		result2 error
+	}
 	GetTxSimulationResultsStub        func() (*ledger.TxSimulationResults, error)
 	getTxSimulationResultsMutex       sync.RWMutex
 	getTxSimulationResultsArgsForCall []struct {
@@ -915,6 +974,69 @@ func (fake *TxSimulator) ExecuteUpdateReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *TxSimulator) GetNamespaceFullScanIterator(arg1 string) (ledgera.ResultsIterator, error) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	ret, specificReturn := fake.getNamespaceFullScanIteratorReturnsOnCall[len(fake.getNamespaceFullScanIteratorArgsForCall)]
+	fake.getNamespaceFullScanIteratorArgsForCall = append(fake.getNamespaceFullScanIteratorArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("GetNamespaceFullScanIterator", []interface{}{arg1})
+	fake.getNamespaceFullScanIteratorMutex.Unlock()
+	if fake.GetNamespaceFullScanIteratorStub != nil {
+		return fake.GetNamespaceFullScanIteratorStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getNamespaceFullScanIteratorReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *TxSimulator) GetNamespaceFullScanIteratorCallCount() int {
+	fake.getNamespaceFullScanIteratorMutex.RLock()
+	defer fake.getNamespaceFullScanIteratorMutex.RUnlock()
+	return len(fake.getNamespaceFullScanIteratorArgsForCall)
+}
+
+func (fake *TxSimulator) GetNamespaceFullScanIteratorCalls(stub func(string) (ledgera.ResultsIterator, error)) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	defer fake.getNamespaceFullScanIteratorMutex.Unlock()
+	fake.GetNamespaceFullScanIteratorStub = stub
+}
+
+func (fake *TxSimulator) GetNamespaceFullScanIteratorArgsForCall(i int) string {
+	fake.getNamespaceFullScanIteratorMutex.RLock()
+	defer fake.getNamespaceFullScanIteratorMutex.RUnlock()
+	argsForCall := fake.getNamespaceFullScanIteratorArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *TxSimulator) GetNamespaceFullScanIteratorReturns(result1 ledgera.ResultsIterator, result2 error) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	defer fake.getNamespaceFullScanIteratorMutex.Unlock()
+	fake.GetNamespaceFullScanIteratorStub = nil
+	fake.getNamespaceFullScanIteratorReturns = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *TxSimulator) GetNamespaceFullScanIteratorReturnsOnCall(i int, result1 ledgera.ResultsIterator, result2 error) {
+	fake.getNamespaceFullScanIteratorMutex.Lock()
+	defer fake.getNamespaceFullScanIteratorMutex.Unlock()
+	fake.GetNamespaceFullScanIteratorStub = nil
+	if fake.getNamespaceFullScanIteratorReturnsOnCall == nil {
+		fake.getNamespaceFullScanIteratorReturnsOnCall = make(map[int]struct {
+			result1 ledgera.ResultsIterator
+			result2 error
+		})
+	}
+	fake.getNamespaceFullScanIteratorReturnsOnCall[i] = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *TxSimulator) GetPrivateData(arg1 string, arg2 string, arg3 string) ([]byte, error) {
 	fake.getPrivateDataMutex.Lock()
 	ret, specificReturn := fake.getPrivateDataReturnsOnCall[len(fake.getPrivateDataArgsForCall)]
@@ -1045,6 +1167,82 @@ func (fake *TxSimulator) GetPrivateDataHashReturnsOnCall(i int, result1 []byte,
 	}{result1, result2}
 }
 
+func (fake *TxSimulator) GetPrivateDataHashByRange(arg1 string, arg2 string, arg3 []byte, arg4 []byte) (ledgera.ResultsIterator, error) {
+	var arg3Copy []byte
+	if arg3 != nil {
+		arg3Copy = make([]byte, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	var arg4Copy []byte
+	if arg4 != nil {
+		arg4Copy = make([]byte, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	ret, specificReturn := fake.getPrivateDataHashByRangeReturnsOnCall[len(fake.getPrivateDataHashByRangeArgsForCall)]
+	fake.getPrivateDataHashByRangeArgsForCall = append(fake.getPrivateDataHashByRangeArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 []byte
+		arg4 []byte
+	}{arg1, arg2, arg3Copy, arg4Copy})
+	fake.recordInvocation("GetPrivateDataHashByRange", []interface{}{arg1, arg2, arg3Copy, arg4Copy})
+	fake.getPrivateDataHashByRangeMutex.Unlock()
+	if fake.GetPrivateDataHashByRangeStub != nil {
+		return fake.GetPrivateDataHashByRangeStub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getPrivateDataHashByRangeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *TxSimulator) GetPrivateDataHashByRangeCallCount() int {
+	fake.getPrivateDataHashByRangeMutex.RLock()
+	defer fake.getPrivateDataHashByRangeMutex.RUnlock()
+	return len(fake.getPrivateDataHashByRangeArgsForCall)
+}
+
+func (fake *TxSimulator) GetPrivateDataHashByRangeCalls(stub func(string, string, []byte, []byte) (ledgera.ResultsIterator, error)) {
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	defer fake.getPrivateDataHashByRangeMutex.Unlock()
+	fake.GetPrivateDataHashByRangeStub = stub
+}
+
+func (fake *TxSimulator) GetPrivateDataHashByRangeArgsForCall(i int) (string, string, []byte, []byte) {
+	fake.getPrivateDataHashByRangeMutex.RLock()
+	defer fake.getPrivateDataHashByRangeMutex.RUnlock()
+	argsForCall := fake.getPrivateDataHashByRangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *TxSimulator) GetPrivateDataHashByRangeReturns(result1 ledgera.ResultsIterator, result2 error) {
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	defer fake.getPrivateDataHashByRangeMutex.Unlock()
+	fake.GetPrivateDataHashByRangeStub = nil
+	fake.getPrivateDataHashByRangeReturns = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *TxSimulator) GetPrivateDataHashByRangeReturnsOnCall(i int, result1 ledgera.ResultsIterator, result2 error) {
+	fake.getPrivateDataHashByRangeMutex.Lock()
+	defer fake.getPrivateDataHashByRangeMutex.Unlock()
+	fake.GetPrivateDataHashByRangeStub = nil
+	if fake.getPrivateDataHashByRangeReturnsOnCall == nil {
+		fake.getPrivateDataHashByRangeReturnsOnCall = make(map[int]struct {
+			result1 ledgera.ResultsIterator
+			result2 error
+		})
+	}
+	fake.getPrivateDataHashByRangeReturnsOnCall[i] = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *TxSimulator) GetPrivateDataMetadata(arg1 string, arg2 string, arg3 string) (map[string][]byte, error) {
 	fake.getPrivateDataMetadataMutex.Lock()
 	ret, specificReturn := fake.getPrivateDataMetadataReturnsOnCall[len(fake.getPrivateDataMetadataArgsForCall)]
@@ -1513,6 +1711,70 @@ func (fake *TxSimulator) GetStateMultipleKeysReturnsOnCall(i int, result1 [][]by
 	}{result1, result2}
 }
 
+func (fake *TxSimulator) GetStateProof(arg1 string, arg2 string) (*stateproof.Proof, error) {
+	fake.getStateProofMutex.Lock()
+	ret, specificReturn := fake.getStateProofReturnsOnCall[len(fake.getStateProofArgsForCall)]
+	fake.getStateProofArgsForCall = append(fake.getStateProofArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetStateProof", []interface{}{arg1, arg2})
+	fake.getStateProofMutex.Unlock()
+	if fake.GetStateProofStub != nil {
+		return fake.GetStateProofStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getStateProofReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *TxSimulator) GetStateProofCallCount() int {
+	fake.getStateProofMutex.RLock()
+	defer fake.getStateProofMutex.RUnlock()
+	return len(fake.getStateProofArgsForCall)
+}
+
+func (fake *TxSimulator) GetStateProofCalls(stub func(string, string) (*stateproof.Proof, error)) {
+	fake.getStateProofMutex.Lock()
+	defer fake.getStateProofMutex.Unlock()
+	fake.GetStateProofStub = stub
+}
+
+func (fake *TxSimulator) GetStateProofArgsForCall(i int) (string, string) {
+	fake.getStateProofMutex.RLock()
+	defer fake.getStateProofMutex.RUnlock()
+	argsForCall := fake.getStateProofArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *TxSimulator) GetStateProofReturns(result1 *stateproof.Proof, result2 error) {
+	fake.getStateProofMutex.Lock()
+	defer fake.getStateProofMutex.Unlock()
+	fake.GetStateProofStub = nil
+	fake.getStateProofReturns = struct {
+		result1 *stateproof.Proof
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *TxSimulator) GetStateProofReturnsOnCall(i int, result1 *stateproof.Proof, result2 error) {
+	fake.getStateProofMutex.Lock()
+	defer fake.getStateProofMutex.Unlock()
+	fake.GetStateProofStub = nil
+	if fake.getStateProofReturnsOnCall == nil {
+		fake.getStateProofReturnsOnCall = make(map[int]struct {
+			result1 *stateproof.Proof
+			result2 error
+		})
+	}
+	fake.getStateProofReturnsOnCall[i] = struct {
+		result1 *stateproof.Proof
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *TxSimulator) GetStateRangeScanIterator(arg1 string, arg2 string, arg3 string) (ledgera.ResultsIterator, error) {
 	fake.getStateRangeScanIteratorMutex.Lock()
 	ret, specificReturn := fake.getStateRangeScanIteratorReturnsOnCall[len(fake.getStateRangeScanIteratorArgsForCall)]
@@ -1644,6 +1906,70 @@ func (fake *TxSimulator) GetStateRangeScanIteratorWithPaginationReturnsOnCall(i
 	}{result1, result2}
 }
 
+func (fake *TxSimulator) GetStateVersion(arg1 string, arg2 string) (*kvrwset.Version, error) {
+	fake.getStateVersionMutex.Lock()
+	ret, specificReturn := fake.getStateVersionReturnsOnCall[len(fake.getStateVersionArgsForCall)]
+	fake.getStateVersionArgsForCall = append(fake.getStateVersionArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("GetStateVersion", []interface{}{arg1, arg2})
+	fake.getStateVersionMutex.Unlock()
+	if fake.GetStateVersionStub != nil {
+		return fake.GetStateVersionStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getStateVersionReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *TxSimulator) GetStateVersionCallCount() int {
+	fake.getStateVersionMutex.RLock()
+	defer fake.getStateVersionMutex.RUnlock()
+	return len(fake.getStateVersionArgsForCall)
+}
+
+func (fake *TxSimulator) GetStateVersionCalls(stub func(string, string) (*kvrwset.Version, error)) {
+	fake.getStateVersionMutex.Lock()
+	defer fake.getStateVersionMutex.Unlock()
+	fake.GetStateVersionStub = stub
+}
+
+func (fake *TxSimulator) GetStateVersionArgsForCall(i int) (string, string) {
+	fake.getStateVersionMutex.RLock()
+	defer fake.getStateVersionMutex.RUnlock()
+	argsForCall := fake.getStateVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *TxSimulator) GetStateVersionReturns(result1 *kvrwset.Version, result2 error) {
+	fake.getStateVersionMutex.Lock()
+	defer fake.getStateVersionMutex.Unlock()
+	fake.GetStateVersionStub = nil
+	fake.getStateVersionReturns = struct {
+		result1 *kvrwset.Version
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *TxSimulator) GetStateVersionReturnsOnCall(i int, result1 *kvrwset.Version, result2 error) {
+	fake.getStateVersionMutex.Lock()
+	defer fake.getStateVersionMutex.Unlock()
+	fake.GetStateVersionStub = nil
+	if fake.getStateVersionReturnsOnCall == nil {
+		fake.getStateVersionReturnsOnCall = make(map[int]struct {
+			result1 *kvrwset.Version
+			result2 error
+		})
+	}
+	fake.getStateVersionReturnsOnCall[i] = struct {
+		result1 *kvrwset.Version
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *TxSimulator) GetTxSimulationResults() (*ledger.TxSimulationResults, error) {
 	fake.getTxSimulationResultsMutex.Lock()
 	ret, specificReturn := fake.getTxSimulationResultsReturnsOnCall[len(fake.getTxSimulationResultsArgsForCall)]
@@ -2165,10 +2491,14 @@ func (fake *TxSimulator) Invocations() map[string][][]interface{} {
 	defer fake.executeQueryWithPaginationMutex.RUnlock()
 	fake.executeUpdateMutex.RLock()
 	defer fake.executeUpdateMutex.RUnlock()
+	fake.getNamespaceFullScanIteratorMutex.RLock()
+	defer fake.getNamespaceFullScanIteratorMutex.RUnlock()
 	fake.getPrivateDataMutex.RLock()
 	defer fake.getPrivateDataMutex.RUnlock()
 	fake.getPrivateDataHashMutex.RLock()
 	defer fake.getPrivateDataHashMutex.RUnlock()
+	fake.getPrivateDataHashByRangeMutex.RLock()
+	defer fake.getPrivateDataHashByRangeMutex.RUnlock()
 	fake.getPrivateDataMetadataMutex.RLock()
 	defer fake.getPrivateDataMetadataMutex.RUnlock()
 	fake.getPrivateDataMetadataByHashMutex.RLock()
@@ -2183,10 +2513,14 @@ func (fake *TxSimulator) Invocations() map[string][][]interface{} {
 	defer fake.getStateMetadataMutex.RUnlock()
 	fake.getStateMultipleKeysMutex.RLock()
 	defer fake.getStateMultipleKeysMutex.RUnlock()
+	fake.getStateProofMutex.RLock()
+	defer fake.getStateProofMutex.RUnlock()
 	fake.getStateRangeScanIteratorMutex.RLock()
 	defer fake.getStateRangeScanIteratorMutex.RUnlock()
 	fake.getStateRangeScanIteratorWithPaginationMutex.RLock()
 	defer fake.getStateRangeScanIteratorWithPaginationMutex.RUnlock()
+	fake.getStateVersionMutex.RLock()
+	defer fake.getStateVersionMutex.RUnlock()
 	fake.getTxSimulationResultsMutex.RLock()
 	defer fake.getTxSimulationResultsMutex.RUnlock()
 	fake.purgePrivateDataMutex.RLock()
@@ -504,6 +504,32 @@ func TestCommitPvtDataOfOldBlocksWithDeprioritization(t *testing.T) {
 				}
 			}
 
+			// all the missing data is reported irrespective of the prioritization
+			allMissingData, err := store.GetMissingPvtDataInfo(2)
+			require.NoError(t, err)
+			require.Equal(t, len(missingDataSummary), len(allMissingData))
+			for blkNum, txsMissingData := range missingDataSummary {
+				require.Equal(t, len(txsMissingData), len(allMissingData[blkNum]))
+				for txNum, expectedMissingData := range txsMissingData {
+					require.ElementsMatch(t, expectedMissingData, allMissingData[blkNum][txNum])
+				}
+			}
+			allMissingData, err = store.GetMissingPvtDataInfo(1)
+			require.NoError(t, err)
+			require.Len(t, allMissingData, 1)
+			require.Contains(t, allMissingData, uint64(1))
+
+			expectedCount := 0
+			for _, txsMissingData := range missingDataSummary {
+				for _, expectedMissingData := range txsMissingData {
+					expectedCount += len(expectedMissingData)
+				}
+			}
+			count, oldestBlock, err := store.GetMissingPvtDataSummary(2)
+			require.NoError(t, err)
+			require.Equal(t, uint64(expectedCount), count)
+			require.Equal(t, uint64(1), oldestBlock)
+
 			oldBlockTxPvtDataInfo := []*blockTxPvtDataInfoForTest{
 				{
 					blkNum: 1,
@@ -553,6 +579,15 @@ func TestCommitPvtDataOfOldBlocksWithDeprioritization(t *testing.T) {
 			deprioMissingData, err = store.getMissingData(elgDeprioritizedMissingDataGroup, 3)
 			require.NoError(t, err)
 			require.Equal(t, make(ledger.MissingPvtDataInfo), deprioMissingData)
+
+			allMissingData, err = store.GetMissingPvtDataInfo(2)
+			require.NoError(t, err)
+			require.Equal(t, make(ledger.MissingPvtDataInfo), allMissingData)
+
+			count, oldestBlock, err = store.GetMissingPvtDataSummary(2)
+			require.NoError(t, err)
+			require.Equal(t, uint64(0), count)
+			require.Equal(t, uint64(0), oldestBlock)
 		})
 	}
 }
@@ -23,8 +23,8 @@ const (
 	maxUpgradeBatchSize = 4 * 1024 * 1024 // 4 MB
 )
 
-func CheckAndConstructHashedIndex(storePath string, ledgerIDs []string) error {
-	info, err := leveldbhelper.RetrieveDataFormatInfo(storePath)
+func CheckAndConstructHashedIndex(storePath string, ledgerIDs []string, openRetry leveldbhelper.OpenRetry) error {
+	info, err := leveldbhelper.RetrieveDataFormatInfo(storePath, openRetry)
 	if err != nil {
 		return err
 	}
@@ -34,7 +34,7 @@ func CheckAndConstructHashedIndex(storePath string, ledgerIDs []string) error {
 	}
 
 	if info.FormatVerison == previousDataVersion {
-		if err := constructHashedIndex(storePath, ledgerIDs); err != nil {
+		if err := constructHashedIndex(storePath, ledgerIDs, openRetry); err != nil {
 			return err
 		}
 		return nil
@@ -45,11 +45,13 @@ func CheckAndConstructHashedIndex(storePath string, ledgerIDs []string) error {
 
 // constructHashedIndex creates the HashedIndex entries for the private data keys and at the end sets the
 // data format version to the current version (2.5)
-func constructHashedIndex(storePath string, ledgerIDs []string) error {
+func constructHashedIndex(storePath string, ledgerIDs []string, openRetry leveldbhelper.OpenRetry) error {
 	p, err := leveldbhelper.NewProvider(
 		&leveldbhelper.Conf{
-			DBPath:         storePath,
-			ExpectedFormat: previousDataVersion,
+			DBPath:            storePath,
+			ExpectedFormat:    previousDataVersion,
+			OpenRetries:       openRetry.Retries,
+			OpenRetryInterval: openRetry.Interval,
 		})
 	if err != nil {
 		return err
@@ -13,6 +13,7 @@ import (
 
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	btltestutil "github.com/hyperledger/fabric/core/ledger/pvtdatapolicy/testutil"
 	"github.com/hyperledger/fabric/core/ledger/util"
@@ -32,7 +33,7 @@ func TestConstructHashedIndexAndUpgradeDataFmtRetroactively(t *testing.T) {
 	require.NoError(t, testutil.CopyDir("testdata/v11_v12/ledgersData/pvtdataStore", testWorkingDir, false))
 	storePath := filepath.Join(testWorkingDir, "pvtdataStore")
 
-	require.NoError(t, CheckAndConstructHashedIndex(storePath, []string{"ch1"}))
+	require.NoError(t, CheckAndConstructHashedIndex(storePath, []string{"ch1"}, leveldbhelper.OpenRetry{}))
 
 	pvtdataConf := pvtDataConf()
 	pvtdataConf.StorePath = storePath
@@ -204,7 +205,7 @@ func TestConstructHashedIndexAndUpgradeDataFmtRetroactively(t *testing.T) {
 	// keep this as last test as this closes the storeProvider
 	t.Run("hashed-indexs-construction-is-done-only-once", func(t *testing.T) {
 		storeProvider.Close()
-		err := constructHashedIndex(storePath, []string{"ch1"})
+		err := constructHashedIndex(storePath, []string{"ch1"}, leveldbhelper.OpenRetry{})
 		require.ErrorContains(t, err, "data format = [2.5], expected format = []")
 	})
 }
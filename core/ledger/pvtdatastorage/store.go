@@ -43,6 +43,12 @@ type PrivateDataConfig struct {
 	// It is internally computed by the ledger component,
 	// so it is not in ledger.PrivateDataConfig and not exposed to other components.
 	StorePath string
+	// Encryptor, if not nil, encrypts the values in the private data storage.
+	// It is set by the ledger component from ledger.Config.
+	Encryptor leveldbhelper.Encryptor
+	// OpenRetry is the retry of the open of the private data storage.
+	// It is set by the ledger component from ledger.Config.
+	OpenRetry leveldbhelper.OpenRetry
 }
 
 // Store manages the permanent storage of private write sets for a ledger
@@ -53,6 +59,9 @@ type Store struct {
 	batchesInterval int
 	maxBatchSize    int
 	purgeInterval   uint64
+	// configLock guards the batchesInterval, maxBatchSize, and purgeInterval
+	// as these can be updated while the store is in use
+	configLock sync.RWMutex
 
 	isEmpty            bool
 	lastCommittedBlock uint64
@@ -179,8 +188,11 @@ type lastUpdatedOldBlocksList []uint64
 func NewProvider(conf *PrivateDataConfig) (*Provider, error) {
 	dbProvider, err := leveldbhelper.NewProvider(
 		&leveldbhelper.Conf{
-			DBPath:         conf.StorePath,
-			ExpectedFormat: currentDataVersion,
+			DBPath:            conf.StorePath,
+			ExpectedFormat:    currentDataVersion,
+			OpenRetries:       conf.OpenRetry.Retries,
+			OpenRetryInterval: conf.OpenRetry.Interval,
+			Encryptor:         conf.Encryptor,
 		})
 	if err != nil {
 		return nil, err
@@ -253,6 +265,12 @@ func (p *Provider) Drop(ledgerid string) error {
 	return p.dbProvider.Drop(ledgerid)
 }
 
+// Exists returns true if the pvtdata store holds any data for the given ledger
+func (p *Provider) Exists(ledgerid string) (bool, error) {
+	empty, err := p.dbProvider.GetDBHandle(ledgerid).IsEmpty()
+	return !empty, err
+}
+
 //////// store functions  ////////////////
 //////////////////////////////////////////
 
@@ -705,6 +723,79 @@ func (s *Store) getMissingData(group []byte, maxBlock int) (ledger.MissingPvtDat
 	return missingPvtDataInfo, nil
 }
 
+// GetMissingPvtDataInfo returns the missing private data information of eligible collections for all the blocks
+// up to the given block number, irrespective of whether the entries are prioritized for the reconciliation or not.
+// The expired entries are not included. An empty result is returned if no private data of an eligible collection
+// is missing
+func (s *Store) GetMissingPvtDataInfo(maxBlock uint64) (ledger.MissingPvtDataInfo, error) {
+	missingPvtDataInfo := make(ledger.MissingPvtDataInfo)
+	err := s.forEachElgMissingDataEntry(maxBlock, func(missingDataKey *missingDataKey, bitmap *bitset.BitSet) {
+		for index, isSet := bitmap.NextSet(0); isSet; index, isSet = bitmap.NextSet(index + 1) {
+			missingPvtDataInfo.Add(missingDataKey.blkNum, uint64(index), missingDataKey.ns, missingDataKey.coll)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return missingPvtDataInfo, nil
+}
+
+// GetMissingPvtDataSummary returns the number of the private write sets of the eligible collections that are missing
+// in the blocks up to the given block number, counting one per transaction and collection, and the lowest block
+// number among these. Similar to the function GetMissingPvtDataInfo, the expired entries are not included. Unlike
+// that function, the entries are not loaded in memory. The returned block number is zero if no entry is missing
+func (s *Store) GetMissingPvtDataSummary(maxBlock uint64) (uint64, uint64, error) {
+	count, oldestBlock := uint64(0), uint64(0)
+	err := s.forEachElgMissingDataEntry(maxBlock, func(missingDataKey *missingDataKey, bitmap *bitset.BitSet) {
+		count += uint64(bitmap.Count())
+		if oldestBlock == 0 || missingDataKey.blkNum < oldestBlock {
+			oldestBlock = missingDataKey.blkNum
+		}
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return count, oldestBlock, nil
+}
+
+func (s *Store) forEachElgMissingDataEntry(maxBlock uint64, fn func(*missingDataKey, *bitset.BitSet)) error {
+	lastCommittedBlock := atomic.LoadUint64(&s.lastCommittedBlock)
+	if maxBlock > lastCommittedBlock {
+		maxBlock = lastCommittedBlock
+	}
+
+	for _, group := range [][]byte{elgPrioritizedMissingDataGroup, elgDeprioritizedMissingDataGroup} {
+		startKey, endKey := createRangeScanKeysForElgMissingData(maxBlock, group)
+		if err := func() error {
+			dbItr, err := s.db.GetIterator(startKey, endKey)
+			if err != nil {
+				return err
+			}
+			defer dbItr.Release()
+
+			for dbItr.Next() {
+				missingDataKey := decodeElgMissingDataKey(dbItr.Key())
+				expired, err := isExpired(missingDataKey.nsCollBlk, s.btlPolicy, lastCommittedBlock)
+				if err != nil {
+					return err
+				}
+				if expired {
+					continue
+				}
+				bitmap, err := decodeMissingDataValue(dbItr.Value())
+				if err != nil {
+					return err
+				}
+				fn(missingDataKey, bitmap)
+			}
+			return errors.Wrap(dbItr.Error(), "error while iterating over the missing data entries")
+		}(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // FetchBootKVHashes returns the KVHashes from the data that was loaded from a snapshot at the time of
 // bootstrapping. This function returns an error if the supplied blkNum is greater than the last block
 // number in the booting snapshot
@@ -754,8 +845,74 @@ func (s *Store) ProcessCollsEligibilityEnabled(committingBlk uint64, nsCollMap m
 	return nil
 }
 
+// UpdateConfig updates the purge interval and the batch parameters used while converting the
+// ineligible missing data entries into eligible entries. The in-progress processing, if any,
+// continues with the previous values and the new values take effect from the next cycle
+func (s *Store) UpdateConfig(conf *ledger.PrivateDataConfig) error {
+	if conf.MaxBatchSize < 0 {
+		return errors.Errorf("invalid max batch size [%d], it should be a non-negative integer", conf.MaxBatchSize)
+	}
+	if conf.BatchesInterval < 0 {
+		return errors.Errorf("invalid batches interval [%d], it should be a non-negative integer", conf.BatchesInterval)
+	}
+	if conf.PurgeInterval <= 0 {
+		return errors.Errorf("invalid purge interval [%d], it should be a positive integer", conf.PurgeInterval)
+	}
+	s.configLock.Lock()
+	defer s.configLock.Unlock()
+	s.maxBatchSize = conf.MaxBatchSize
+	s.batchesInterval = conf.BatchesInterval
+	s.purgeInterval = uint64(conf.PurgeInterval)
+	logger.Infof("[%s] Updated pvtdata store config: maxBatchSize=%d, batchesInterval=%d, purgeInterval=%d",
+		s.ledgerid, conf.MaxBatchSize, conf.BatchesInterval, conf.PurgeInterval)
+	return nil
+}
+
+// Config returns the current values of the purge interval and the batch parameters of the store
+func (s *Store) Config() *ledger.PrivateDataConfig {
+	s.configLock.RLock()
+	defer s.configLock.RUnlock()
+	return &ledger.PrivateDataConfig{
+		MaxBatchSize:    s.maxBatchSize,
+		BatchesInterval: s.batchesInterval,
+		PurgeInterval:   int(s.purgeInterval),
+	}
+}
+
+// Compact compacts the physical storage of the store so as to reclaim the space occupied by the entries that
+// are purged as per the BTL policy or deleted otherwise. The compaction waits for an in-progress purge to complete
+// and the purges are held off until the compaction completes. The function returns the approximate number of bytes
+// reclaimed, computed from the file system space used before and after the compaction. As the compaction also
+// flushes the entries that are not yet written to the files, the returned value is zero if the space used grows
+func (s *Store) Compact() (int64, error) {
+	s.purgerLock.Lock()
+	defer s.purgerLock.Unlock()
+
+	sizeBefore, err := s.db.ApproximateSize()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.db.Compact(); err != nil {
+		return 0, err
+	}
+	sizeAfter, err := s.db.ApproximateSize()
+	if err != nil {
+		return 0, err
+	}
+	reclaimedBytes := sizeBefore - sizeAfter
+	if reclaimedBytes < 0 {
+		reclaimedBytes = 0
+	}
+	logger.Debugf("[%s] Compacted pvtdata store, approximate size before compaction=%d bytes, after compaction=%d bytes",
+		s.ledgerid, sizeBefore, sizeAfter)
+	return reclaimedBytes, nil
+}
+
 func (s *Store) performPurgeIfScheduled(latestCommittedBlk uint64) {
-	if latestCommittedBlk%s.purgeInterval != 0 {
+	s.configLock.RLock()
+	purgeInterval := s.purgeInterval
+	s.configLock.RUnlock()
+	if latestCommittedBlk%purgeInterval != 0 {
 		return
 	}
 	go func() {
@@ -930,6 +1087,9 @@ func (s *Store) processCollElgEvents() error {
 	logger.Debugf("Starting to process collection eligibility events")
 	s.purgerLock.Lock()
 	defer s.purgerLock.Unlock()
+	s.configLock.RLock()
+	maxBatchSize, batchesInterval := s.maxBatchSize, s.batchesInterval
+	s.configLock.RUnlock()
 	collElgStartKey, collElgEndKey := createRangeScanKeysForCollElg()
 	eventItr, err := s.db.GetIterator(collElgStartKey, collElgEndKey)
 	if err != nil {
@@ -970,12 +1130,12 @@ func (s *Store) processCollElgEvents() error {
 						copyVal,
 					)
 					collEntriesConverted++
-					if batch.Len() > s.maxBatchSize {
+					if batch.Len() > maxBatchSize {
 						if err := s.db.WriteBatch(batch, true); err != nil {
 							return err
 						}
 						batch.Reset()
-						sleepTime := time.Duration(s.batchesInterval)
+						sleepTime := time.Duration(batchesInterval)
 						logger.Infof("Going to sleep for %d milliseconds between batches. Entries for [ns=%s, coll=%s] converted so far = %d",
 							sleepTime, ns, coll, collEntriesConverted)
 						s.purgerLock.Unlock()
@@ -999,6 +1159,11 @@ func (s *Store) processCollElgEvents() error {
 	return nil
 }
 
+// GetDBSnapshot returns a point-in-time view of the data of the ledger in the store. The snapshot should be released after the use
+func (s *Store) GetDBSnapshot() (*leveldbhelper.Snapshot, error) {
+	return s.db.GetSnapshot()
+}
+
 // LastCommittedBlockHeight returns the height of the last committed block
 func (s *Store) LastCommittedBlockHeight() (uint64, error) {
 	if s.isEmpty {
@@ -589,6 +589,55 @@ func TestStorePurge(t *testing.T) {
 	))
 }
 
+func TestStoreCompact(t *testing.T) {
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns-1", "coll-1"}: 1,
+			{"ns-1", "coll-2"}: 0,
+		},
+	)
+	conf := pvtDataConf()
+	// the purges are performed explicitly in this test
+	conf.PurgeInterval = 1000
+	env := NewTestStoreEnv(t, "TestStoreCompact", btlPolicy, conf)
+	defer env.Cleanup()
+	s := env.TestStore
+
+	require.NoError(t, s.Commit(0, nil, nil, nil))
+	for blkNum := uint64(1); blkNum <= 200; blkNum++ {
+		var testData []*ledger.TxPvtData
+		for txNum := uint64(0); txNum < 5; txNum++ {
+			testData = append(testData, produceSamplePvtdata(t, txNum, []string{"ns-1:coll-1", "ns-1:coll-2"}))
+		}
+		require.NoError(t, s.Commit(blkNum, testData, nil, nil))
+	}
+	// the first compaction flushes the committed entries to the files
+	_, err := s.Compact()
+	require.NoError(t, err)
+
+	require.NoError(t, s.purgeExpiredData(0, 200))
+	reclaimedBytes, err := s.Compact()
+	require.NoError(t, err)
+	require.Greater(t, reclaimedBytes, int64(0))
+
+	// compaction does not alter the contents of the store
+	retrievedData, err := s.GetPvtDataByBlockNum(200, nil)
+	require.NoError(t, err)
+	require.Len(t, retrievedData, 5)
+	retrievedData, err = s.GetPvtDataByBlockNum(100, nil)
+	require.NoError(t, err)
+	require.Len(t, retrievedData, 5)
+	for _, d := range retrievedData {
+		require.True(t, d.Has("ns-1", "coll-2"))
+		require.False(t, d.Has("ns-1", "coll-1"))
+	}
+
+	// nothing is reclaimed by compacting an already compacted store
+	reclaimedBytes, err = s.Compact()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), reclaimedBytes)
+}
+
 func TestStoreState(t *testing.T) {
 	btlPolicy := btltestutil.SampleBTLPolicy(
 		map[[2]string]uint64{
@@ -136,21 +136,15 @@ func TestSnapshot(t *testing.T) {
 	_, err = snapshotSvc.QueryPendings(context.Background(), signedRequest)
 	require.EqualError(t, err, "fake-check-acl-error")
 
-	// verify panic from generate/cancel after ledger is closed
+	// verify error from generate/cancel after ledger is closed
 	lgr.Close()
 	fakeLedgerGetter.GetLedgerReturns(lgr)
 	fakeACLProvider.CheckACLNoChannelReturns(nil)
 
-	require.PanicsWithError(
-		t,
-		"send on closed channel",
-		func() { snapshotSvc.Generate(context.Background(), signedRequest) },
-	)
-	require.PanicsWithError(
-		t,
-		"send on closed channel",
-		func() { snapshotSvc.Cancel(context.Background(), signedRequest) },
-	)
+	_, err = snapshotSvc.Generate(context.Background(), signedRequest)
+	require.EqualError(t, err, "ledger [testsnapshot] is closed")
+	_, err = snapshotSvc.Cancel(context.Background(), signedRequest)
+	require.EqualError(t, err, "ledger [testsnapshot] is closed")
 }
 
 func createSignedRequest(channelID string, blockNumber uint64) *pb.SignedSnapshotRequest {
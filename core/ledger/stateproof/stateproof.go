@@ -0,0 +1,169 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateproof
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// The state root is the root of a two level Merkle tree over the public state of a ledger. The leaves of the
+// lower level tree of a namespace are the keys of the namespace, in the sorted order of the keys, and the leaves
+// of the upper level tree are the namespaces, in the sorted order of the namespaces, each with the root of its
+// lower level tree. The leaves of a tree are paired from left to right at each level of the tree and the last
+// node of a level with an odd number of nodes is carried to the next level as is. All the hashes are SHA-256
+// with a prefix byte that tells the leaves apart from the internal nodes
+const (
+	leafPrefix     = byte(0)
+	internalPrefix = byte(1)
+)
+
+// Proof is a proof that a key in a namespace has the given value in the public state of a ledger at the height
+// at which the proof is generated, i.e., after the commit of the block `BlockNum`. The proof is verified against
+// the state root at the same height via the function `Verify`. A light client can trust a state root that is
+// reported by a sufficient number of peers, for instance, by requesting the proofs from several peers at the
+// same block number and comparing the roots
+type Proof struct {
+	Namespace string
+	Key       string
+	Value     []byte
+	BlockNum  uint64
+	// Root is the state root as computed by the peer that generated the proof
+	Root []byte
+	// KeyPath contains the siblings on the path from the leaf of the key to the root of the tree of the namespace
+	KeyPath []*PathNode
+	// NamespacePath contains the siblings on the path from the leaf of the namespace to the state root
+	NamespacePath []*PathNode
+}
+
+// PathNode is a sibling of a node on the path from a leaf to the root of a Merkle tree
+type PathNode struct {
+	Hash []byte
+	// Left is true if the sibling is the left child of the parent
+	Left bool
+}
+
+// Verify verifies that the proof leads to the given trusted state root
+func Verify(proof *Proof, root []byte) error {
+	if proof == nil {
+		return errors.New("nil proof")
+	}
+	computedRoot := proof.ComputeRoot()
+	if !bytes.Equal(computedRoot, root) {
+		return errors.Errorf("proof for key [%s] in namespace [%s] does not lead to the state root, computed root = [%x], expected root = [%x]",
+			proof.Key, proof.Namespace, computedRoot, root)
+	}
+	return nil
+}
+
+// ComputeRoot computes the state root from the key, the value, and the paths in the proof
+func (p *Proof) ComputeRoot() []byte {
+	namespaceRoot := foldPath(KeyLeafHash(p.Key, p.Value), p.KeyPath)
+	return foldPath(NamespaceLeafHash(p.Namespace, namespaceRoot), p.NamespacePath)
+}
+
+// KeyLeafHash returns the hash of the leaf for a key and its value in the tree of a namespace
+func KeyLeafHash(key string, value []byte) []byte {
+	valueHash := sha256.Sum256(value)
+	return leafHash([]byte(key), valueHash[:])
+}
+
+// NamespaceLeafHash returns the hash of the leaf for a namespace and the root of its tree in the upper level tree
+func NamespaceLeafHash(namespace string, namespaceRoot []byte) []byte {
+	return leafHash([]byte(namespace), namespaceRoot)
+}
+
+func leafHash(name, hash []byte) []byte {
+	h := sha256.New()
+	lenBytes := make([]byte, binary.MaxVarintLen64)
+	h.Write([]byte{leafPrefix})
+	h.Write(lenBytes[:binary.PutUvarint(lenBytes, uint64(len(name)))])
+	h.Write(name)
+	h.Write(hash)
+	return h.Sum(nil)
+}
+
+func internalNodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{internalPrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+func foldPath(hash []byte, path []*PathNode) []byte {
+	for _, n := range path {
+		if n.Left {
+			hash = internalNodeHash(n.Hash, hash)
+		} else {
+			hash = internalNodeHash(hash, n.Hash)
+		}
+	}
+	return hash
+}
+
+// TreeBuilder computes the root of a Merkle tree from the leaves that are added in order, without holding all the
+// leaves in memory, along with the path from a leaf, marked as the target, to the root
+type TreeBuilder struct {
+	nodes []*treeNode
+	path  []*PathNode
+}
+
+type treeNode struct {
+	level  int
+	hash   []byte
+	target bool
+}
+
+// NewTreeBuilder returns a new TreeBuilder
+func NewTreeBuilder() *TreeBuilder {
+	return &TreeBuilder{}
+}
+
+// Add adds the next leaf to the tree
+func (b *TreeBuilder) Add(leafHash []byte, target bool) {
+	n := &treeNode{hash: leafHash, target: target}
+	for len(b.nodes) > 0 && b.nodes[len(b.nodes)-1].level == n.level {
+		left := b.nodes[len(b.nodes)-1]
+		b.nodes = b.nodes[:len(b.nodes)-1]
+		n = b.merge(left, n)
+	}
+	b.nodes = append(b.nodes, n)
+}
+
+// Done returns the root of the tree and the path from the target leaf to the root. The root is nil for a tree
+// with no leaves and the path is nil if no leaf is marked as the target
+func (b *TreeBuilder) Done() (root []byte, path []*PathNode) {
+	if len(b.nodes) == 0 {
+		return nil, nil
+	}
+	// the nodes that are left are the roots of the subtrees of decreasing heights, and a subtree is paired
+	// with the tree of all the subtrees on its right, as the last node of a level is carried to the next level
+	n := b.nodes[len(b.nodes)-1]
+	for i := len(b.nodes) - 2; i >= 0; i-- {
+		n = b.merge(b.nodes[i], n)
+	}
+	b.nodes = nil
+	return n.hash, b.path
+}
+
+func (b *TreeBuilder) merge(left, right *treeNode) *treeNode {
+	switch {
+	case left.target:
+		b.path = append(b.path, &PathNode{Hash: right.hash})
+	case right.target:
+		b.path = append(b.path, &PathNode{Hash: left.hash, Left: true})
+	}
+	return &treeNode{
+		level:  left.level + 1,
+		hash:   internalNodeHash(left.hash, right.hash),
+		target: left.target || right.target,
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateproof
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeBuilder(t *testing.T) {
+	root, path := NewTreeBuilder().Done()
+	require.Nil(t, root)
+	require.Nil(t, path)
+
+	for numLeaves := 1; numLeaves <= 17; numLeaves++ {
+		var leaves [][]byte
+		for i := 0; i < numLeaves; i++ {
+			leaves = append(leaves, KeyLeafHash(fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i))))
+		}
+		expectedRoot := computeRootLevelByLevel(leaves)
+
+		for target := 0; target < numLeaves; target++ {
+			b := NewTreeBuilder()
+			for i, leaf := range leaves {
+				b.Add(leaf, i == target)
+			}
+			root, path := b.Done()
+			require.Equal(t, expectedRoot, root, "numLeaves = %d, target = %d", numLeaves, target)
+			require.Equal(t, expectedRoot, foldPath(leaves[target], path), "numLeaves = %d, target = %d", numLeaves, target)
+		}
+	}
+}
+
+// computeRootLevelByLevel computes the root by pairing the nodes at each level and carrying the last node of a
+// level with an odd number of nodes to the next level
+func computeRootLevelByLevel(nodes [][]byte) []byte {
+	for len(nodes) > 1 {
+		var next [][]byte
+		for i := 0; i < len(nodes); i += 2 {
+			if i+1 == len(nodes) {
+				next = append(next, nodes[i])
+				continue
+			}
+			next = append(next, internalNodeHash(nodes[i], nodes[i+1]))
+		}
+		nodes = next
+	}
+	return nodes[0]
+}
+
+func TestVerify(t *testing.T) {
+	namespaces := map[string][]string{
+		"ns1": {"key1", "key2", "key3"},
+		"ns2": {"key1"},
+		"ns3": {"key1", "key2"},
+	}
+	// generates the proof for the target key in the same manner as the ledger does while traversing the state
+	generateProof := func(targetNs, targetKey string) *Proof {
+		proof := &Proof{Namespace: targetNs, Key: targetKey, Value: []byte(targetNs + targetKey)}
+		stateTree := NewTreeBuilder()
+		for _, ns := range []string{"ns1", "ns2", "ns3"} {
+			nsTree := NewTreeBuilder()
+			for _, key := range namespaces[ns] {
+				nsTree.Add(KeyLeafHash(key, []byte(ns+key)), ns == targetNs && key == targetKey)
+			}
+			nsRoot, keyPath := nsTree.Done()
+			if ns == targetNs {
+				proof.KeyPath = keyPath
+			}
+			stateTree.Add(NamespaceLeafHash(ns, nsRoot), ns == targetNs)
+		}
+		proof.Root, proof.NamespacePath = stateTree.Done()
+		return proof
+	}
+
+	root := generateProof("ns1", "key1").Root
+	for ns, keys := range namespaces {
+		for _, key := range keys {
+			proof := generateProof(ns, key)
+			require.Equal(t, root, proof.Root)
+			require.NoError(t, Verify(proof, root))
+		}
+	}
+
+	proof := generateProof("ns3", "key2")
+	proof.Value = []byte("another-value")
+	require.Contains(t, Verify(proof, root).Error(), "proof for key [key2] in namespace [ns3] does not lead to the state root")
+
+	proof = generateProof("ns3", "key2")
+	proof.Namespace = "ns2"
+	require.Error(t, Verify(proof, root))
+
+	proof = generateProof("ns3", "key2")
+	proof.KeyPath[0].Left = !proof.KeyPath[0].Left
+	require.Error(t, Verify(proof, root))
+
+	require.EqualError(t, Verify(nil, root), "nil proof")
+}
@@ -163,6 +163,9 @@ type Peer struct {
 	mutex    sync.RWMutex
 	channels map[string]*Channel
 
+	// channelStatusLock serializes the deactivation and the reactivation of the channels
+	channelStatusLock sync.Mutex
+
 	configCallbacks []channelconfig.BundleActor
 }
 
@@ -224,6 +227,60 @@ func (p *Peer) CreateChannelFromSnapshot(
 	return nil
 }
 
+// DeactivateChannel stops the channel without deleting any of its data. The peer stops receiving, gossiping and
+// committing the blocks of the channel, and the ledger of the channel is closed and marked inactive, so that the
+// channel is not loaded again when the peer restarts. The channel can be reactivated via ReactivateChannel
+func (p *Peer) DeactivateChannel(cid string) error {
+	p.channelStatusLock.Lock()
+	defer p.channelStatusLock.Unlock()
+
+	if p.Channel(cid) == nil {
+		return errors.Errorf("channel [%s] does not exist or is not active", cid)
+	}
+	p.GossipService.StopChannel(cid)
+
+	p.mutex.Lock()
+	delete(p.channels, cid)
+	p.mutex.Unlock()
+
+	if err := p.LedgerMgr.DeactivateLedger(cid); err != nil {
+		return errors.WithMessagef(err, "channel [%s] has been stopped but its ledger could not be deactivated,"+
+			" the channel will be loaded again when the peer restarts", cid)
+	}
+	peerLogger.Infof("Deactivated channel [%s]", cid)
+	return nil
+}
+
+// ReactivateChannel reactivates a channel that was deactivated via DeactivateChannel. The ledger of the channel is
+// opened and the peer resumes receiving, gossiping and committing the blocks of the channel
+func (p *Peer) ReactivateChannel(
+	cid string,
+	deployedCCInfoProvider ledger.DeployedChaincodeInfoProvider,
+	legacyLifecycleValidation plugindispatcher.LifecycleResources,
+	newLifecycleValidation plugindispatcher.CollectionAndLifecycleResources,
+) error {
+	p.channelStatusLock.Lock()
+	defer p.channelStatusLock.Unlock()
+
+	if p.Channel(cid) != nil {
+		return errors.Errorf("channel [%s] is already active", cid)
+	}
+	l, err := p.LedgerMgr.ReactivateLedger(cid)
+	if err != nil {
+		return err
+	}
+	if err := p.createChannel(cid, l, deployedCCInfoProvider, legacyLifecycleValidation, newLifecycleValidation); err != nil {
+		if deactivationErr := p.LedgerMgr.DeactivateLedger(cid); deactivationErr != nil {
+			peerLogger.Errorf("Failed to deactivate the ledger of channel [%s] again: %s", cid, deactivationErr)
+		}
+		return errors.WithMessagef(err, "cannot reactivate channel [%s]", cid)
+	}
+
+	p.initChannel(cid)
+	peerLogger.Infof("Reactivated channel [%s]", cid)
+	return nil
+}
+
 // RetrievePersistedChannelConfig retrieves the persisted channel config from statedb
 func RetrievePersistedChannelConfig(ledger ledger.PeerLedger) (*common.Config, error) {
 	qe, err := ledger.NewQueryExecutor()
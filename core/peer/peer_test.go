@@ -212,6 +212,54 @@ func TestCreateChannel(t *testing.T) {
 	}
 }
 
+func TestDeactivateAndReactivateChannel(t *testing.T) {
+	peerInstance, cleanup := NewTestPeer(t)
+	defer cleanup()
+
+	var initArgs []string
+	peerInstance.Initialize(
+		func(cid string) { initArgs = append(initArgs, cid) },
+		nil,
+		plugin.MapBasedMapper(map[string]validation.PluginFactory{}),
+		&ledgermocks.DeployedChaincodeInfoProvider{},
+		nil,
+		nil,
+		runtime.NumCPU(),
+	)
+
+	testChannelID := fmt.Sprintf("mytestchannelid-%d", rand.Int())
+	block, err := configtxtest.MakeGenesisBlock(testChannelID)
+	require.NoError(t, err)
+	require.NoError(t, peerInstance.CreateChannel(testChannelID, block, &ledgermocks.DeployedChaincodeInfoProvider{}, nil, nil))
+
+	require.NoError(t, peerInstance.DeactivateChannel(testChannelID))
+	require.Nil(t, peerInstance.Channel(testChannelID))
+	require.Nil(t, peerInstance.GetLedger(testChannelID))
+	require.Empty(t, peerInstance.GetChannelsInfo())
+	ledgerIDs, err := peerInstance.LedgerMgr.GetLedgerIDs()
+	require.NoError(t, err)
+	require.Empty(t, ledgerIDs)
+
+	err = peerInstance.DeactivateChannel(testChannelID)
+	require.EqualError(t, err, fmt.Sprintf("channel [%s] does not exist or is not active", testChannelID))
+	// the channel cannot be joined again while deactivated
+	require.Error(t, peerInstance.CreateChannel(testChannelID, block, &ledgermocks.DeployedChaincodeInfoProvider{}, nil, nil))
+
+	require.NoError(t, peerInstance.ReactivateChannel(testChannelID, &ledgermocks.DeployedChaincodeInfoProvider{}, nil, nil))
+	require.Equal(t, []string{testChannelID, testChannelID}, initArgs)
+	ledger := peerInstance.GetLedger(testChannelID)
+	require.NotNil(t, ledger)
+	// the data of the channel is retained
+	block, err = ConfigBlockFromLedger(ledger)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), block.Header.Number)
+
+	err = peerInstance.ReactivateChannel(testChannelID, &ledgermocks.DeployedChaincodeInfoProvider{}, nil, nil)
+	require.EqualError(t, err, fmt.Sprintf("channel [%s] is already active", testChannelID))
+	err = peerInstance.ReactivateChannel("non-existent-channel", &ledgermocks.DeployedChaincodeInfoProvider{}, nil, nil)
+	require.EqualError(t, err, "cannot reactivate ledger [non-existent-channel]: ledger does not exist")
+}
+
 func TestCreateChannelBySnapshot(t *testing.T) {
 	peerInstance, cleanup := NewTestPeer(t)
 	defer cleanup()
@@ -73,6 +73,8 @@ const (
 	JoinChain            string = "JoinChain"
 	JoinChainBySnapshot  string = "JoinChainBySnapshot"
 	JoinBySnapshotStatus string = "JoinBySnapshotStatus"
+	DeactivateChannel    string = "DeactivateChannel"
+	ReactivateChannel    string = "ReactivateChannel"
 	GetConfigBlock       string = "GetConfigBlock"
 	GetChannelConfig     string = "GetChannelConfig"
 	GetChannels          string = "GetChannels"
@@ -87,6 +89,7 @@ func (e *PeerConfiger) Init(stub shim.ChaincodeStubInterface) pb.Response {
 // Invoke is called for the following:
 // # to process joining a chain (called by app as a transaction proposal)
 // # to get the current configuration block (called by app)
+// # to deactivate or reactivate a joined channel (called by admin)
 // # to update the configuration block (called by committer)
 // Peer calls this function with 2 arguments:
 // # args[0] is the function name, which must be JoinChain, GetConfigBlock or
@@ -185,6 +188,22 @@ func (e *PeerConfiger) InvokeNoShim(args [][]byte, sp *pb.SignedProposal) pb.Res
 			return shim.Error(fmt.Sprintf("access denied for [%s]: %s", fname, err))
 		}
 		return e.joinBySnapshotStatus()
+	case DeactivateChannel:
+		if len(args[1]) == 0 {
+			return shim.Error("Cannot deactivate the channel, no channel ID provided")
+		}
+		if err = e.aclProvider.CheckACL(resources.Cscc_DeactivateChannel, "", sp); err != nil {
+			return shim.Error(fmt.Sprintf("access denied for [%s]: %s", fname, err))
+		}
+		return e.deactivateChannel(string(args[1]))
+	case ReactivateChannel:
+		if len(args[1]) == 0 {
+			return shim.Error("Cannot reactivate the channel, no channel ID provided")
+		}
+		if err = e.aclProvider.CheckACL(resources.Cscc_ReactivateChannel, "", sp); err != nil {
+			return shim.Error(fmt.Sprintf("access denied for [%s]: %s", fname, err))
+		}
+		return e.reactivateChannel(string(args[1]), e.deployedCCInfoProvider, e.legacyLifecycle, e.newLifecycle)
 	case GetConfigBlock:
 		// 2. check policy
 		if err = e.aclProvider.CheckACL(resources.Cscc_GetConfigBlock, string(args[1]), sp); err != nil {
@@ -282,6 +301,31 @@ func (e *PeerConfiger) JoinChainBySnapshot(
 	return shim.Success(nil)
 }
 
+// deactivateChannel stops the processing of the specified channel and deactivates its ledger. The data of
+// the channel is retained so that the channel can be reactivated later
+func (e *PeerConfiger) deactivateChannel(channelID string) pb.Response {
+	if err := e.peer.DeactivateChannel(channelID); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
+// reactivateChannel reactivates the ledger of the specified deactivated channel and resumes the processing
+// of the channel
+func (e *PeerConfiger) reactivateChannel(
+	channelID string,
+	deployedCCInfoProvider ledger.DeployedChaincodeInfoProvider,
+	lr plugindispatcher.LifecycleResources,
+	nr plugindispatcher.CollectionAndLifecycleResources,
+) pb.Response {
+	if err := e.peer.ReactivateChannel(channelID, deployedCCInfoProvider, lr, nr); err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success(nil)
+}
+
 // Return the current configuration block for the specified channelID. If the
 // peer doesn't belong to the channel, return error
 func (e *PeerConfiger) getConfigBlock(channelID []byte) pb.Response {
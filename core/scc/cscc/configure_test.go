@@ -8,6 +8,7 @@ package cscc
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"testing"
@@ -431,6 +432,102 @@ func TestConfigerInvokeJoinChainBySnapshot(t *testing.T) {
 	require.Contains(t, res.Message, "access denied for [JoinChainBySnapshot]")
 }
 
+func TestConfigerInvokeDeactivateAndReactivateChannel(t *testing.T) {
+	testDir := t.TempDir()
+
+	ledgerInitializer := ledgermgmttest.NewInitializer(testDir)
+	ledgerInitializer.CustomTxProcessors = map[cb.HeaderType]ledger.CustomTxProcessor{
+		cb.HeaderType_CONFIG: &peer.ConfigTxProcessor{},
+	}
+	ledgerMgr := ledgermgmt.NewLedgerMgr(ledgerInitializer)
+	defer ledgerMgr.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer()
+
+	cscc := newPeerConfiger(t, ledgerMgr, grpcServer, listener.Addr().String())
+
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	sProp := validSignedProposal()
+	sProp.Signature = sProp.ProposalBytes
+	mockACLProvider := cscc.aclProvider.(*mocks.ACLProvider)
+	mockStub := &mocks.ChaincodeStub{}
+	mockStub.GetSignedProposalReturns(sProp, nil)
+
+	blockBytes := mockConfigBlock()
+	channelID, err := protoutil.GetChannelIDFromBlockBytes(blockBytes)
+	require.NoError(t, err)
+	mockStub.GetArgsReturns([][]byte{[]byte(JoinChain), blockBytes})
+	res := cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.OK), res.Status, "invoke JoinChain failed with: %v", res.Message)
+
+	channels := func() []*pb.ChannelInfo {
+		mockStub.GetArgsReturns([][]byte{[]byte(GetChannels)})
+		res := cscc.Invoke(mockStub)
+		require.Equal(t, int32(shim.OK), res.Status)
+		cqr := &pb.ChannelQueryResponse{}
+		require.NoError(t, proto.Unmarshal(res.Payload, cqr))
+		return cqr.GetChannels()
+	}
+
+	// deactivate the channel
+	mockStub.GetArgsReturns([][]byte{[]byte(DeactivateChannel), []byte(channelID)})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.OK), res.Status, "invoke DeactivateChannel failed with: %v", res.Message)
+	require.Empty(t, channels())
+	require.Nil(t, cscc.peer.GetLedger(channelID))
+
+	mockStub.GetArgsReturns([][]byte{[]byte(DeactivateChannel), []byte(channelID)})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.ERROR), res.Status)
+	require.Equal(t, fmt.Sprintf("channel [%s] does not exist or is not active", channelID), res.Message)
+
+	// reactivate the channel
+	mockStub.GetArgsReturns([][]byte{[]byte(ReactivateChannel), []byte(channelID)})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.OK), res.Status, "invoke ReactivateChannel failed with: %v", res.Message)
+	require.Len(t, channels(), 1)
+	lgr := cscc.peer.GetLedger(channelID)
+	require.NotNil(t, lgr)
+	bcInfo, err := lgr.GetBlockchainInfo()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), bcInfo.Height)
+
+	mockStub.GetArgsReturns([][]byte{[]byte(ReactivateChannel), []byte(channelID)})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.ERROR), res.Status)
+	require.Equal(t, fmt.Sprintf("channel [%s] is already active", channelID), res.Message)
+
+	// error path due to missing or empty argument
+	mockStub.GetArgsReturns([][]byte{[]byte(DeactivateChannel)})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.ERROR), res.Status)
+	require.Equal(t, "Incorrect number of arguments, 1", res.Message)
+	mockStub.GetArgsReturns([][]byte{[]byte(DeactivateChannel), []byte("")})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.ERROR), res.Status)
+	require.Equal(t, "Cannot deactivate the channel, no channel ID provided", res.Message)
+	mockStub.GetArgsReturns([][]byte{[]byte(ReactivateChannel), []byte("")})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.ERROR), res.Status)
+	require.Equal(t, "Cannot reactivate the channel, no channel ID provided", res.Message)
+
+	// error path due to CheckACL error
+	mockACLProvider.CheckACLReturns(errors.New("Failed authorization"))
+	mockStub.GetArgsReturns([][]byte{[]byte(DeactivateChannel), []byte(channelID)})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.ERROR), res.Status)
+	require.Contains(t, res.Message, "access denied for [DeactivateChannel]")
+	mockStub.GetArgsReturns([][]byte{[]byte(ReactivateChannel), []byte(channelID)})
+	res = cscc.Invoke(mockStub)
+	require.Equal(t, int32(shim.ERROR), res.Status)
+	require.Contains(t, res.Message, "access denied for [ReactivateChannel]")
+	require.NotNil(t, cscc.peer.GetLedger(channelID))
+}
+
 func TestConfigerInvokeGetChannelConfig(t *testing.T) {
 	testDir := t.TempDir()
 
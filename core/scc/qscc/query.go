@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
@@ -40,6 +41,7 @@ func (e *LedgerQuerier) Chaincode() shim.Chaincode { return e }
 // - GetBlockByNumber returns a block
 // - GetBlockByHash returns a block
 // - GetTransactionByID returns a transaction
+// - GetTransactionsByIDs returns a list of transactions
 type LedgerQuerier struct {
 	aclProvider aclmgmt.ACLProvider
 	ledgers     LedgerGetter
@@ -49,11 +51,12 @@ var qscclogger = flogging.MustGetLogger("qscc")
 
 // These are function names from Invoke first parameter
 const (
-	GetChainInfo       string = "GetChainInfo"
-	GetBlockByNumber   string = "GetBlockByNumber"
-	GetBlockByHash     string = "GetBlockByHash"
-	GetTransactionByID string = "GetTransactionByID"
-	GetBlockByTxID     string = "GetBlockByTxID"
+	GetChainInfo         string = "GetChainInfo"
+	GetBlockByNumber     string = "GetBlockByNumber"
+	GetBlockByHash       string = "GetBlockByHash"
+	GetTransactionByID   string = "GetTransactionByID"
+	GetTransactionsByIDs string = "GetTransactionsByIDs"
+	GetBlockByTxID       string = "GetBlockByTxID"
 )
 
 // Init is called once per chain when the chain is created.
@@ -72,6 +75,7 @@ func (e *LedgerQuerier) Init(stub shim.ChaincodeStubInterface) pb.Response {
 // # GetBlockByNumber: Return the block specified by block number in args[2]
 // # GetBlockByHash: Return the block specified by block hash in args[2]
 // # GetTransactionByID: Return the transaction specified by ID in args[2]
+// # GetTransactionsByIDs: Return the transactions specified by the IDs in args[2:], each preceded by its length
 func (e *LedgerQuerier) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	args := stub.GetArgs()
 
@@ -116,6 +120,8 @@ func (e *LedgerQuerier) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	switch fname {
 	case GetTransactionByID:
 		return getTransactionByID(targetLedger, args[2])
+	case GetTransactionsByIDs:
+		return getTransactionsByIDs(targetLedger, args[2:])
 	case GetBlockByNumber:
 		return getBlockByNumber(targetLedger, args[2])
 	case GetBlockByHash:
@@ -147,6 +153,31 @@ func getTransactionByID(vledger ledger.PeerLedger, tid []byte) pb.Response {
 	return shim.Success(bytes)
 }
 
+func getTransactionsByIDs(vledger ledger.PeerLedger, tids [][]byte) pb.Response {
+	txIDs := make([]string, len(tids))
+	for i, tid := range tids {
+		if tid == nil {
+			return shim.Error("Transaction ID must not be nil.")
+		}
+		txIDs[i] = string(tid)
+	}
+
+	processedTrans, err := vledger.GetTransactionsByIDs(txIDs)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to get transactions with ids %s, error %s", txIDs, err))
+	}
+
+	// the transactions are returned in the delimited format, in which each message is preceded by its length
+	buffer := proto.NewBuffer(nil)
+	for _, processedTran := range processedTrans {
+		if err := buffer.EncodeMessage(processedTran); err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	return shim.Success(buffer.Bytes())
+}
+
 func getBlockByNumber(vledger ledger.PeerLedger, number []byte) pb.Response {
 	if number == nil {
 		return shim.Error("Block number must not be nil.")
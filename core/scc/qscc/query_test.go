@@ -11,6 +11,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-chaincode-go/shimtest"
 	"github.com/hyperledger/fabric-protos-go/common"
@@ -133,6 +134,31 @@ func TestQueryGetTransactionByID(t *testing.T) {
 	require.Equal(t, int32(shim.ERROR), res.Status, "GetTransactionByID should have failed due to incorrect number of arguments")
 }
 
+func TestQueryGetTransactionsByIDs(t *testing.T) {
+	chainid := "mytestchainid9"
+	path := t.TempDir()
+
+	stub, _, cleanup, err := setupTestLedger(t, chainid, path)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer cleanup()
+
+	args := [][]byte{[]byte(GetTransactionsByIDs), []byte(chainid), []byte("1"), []byte("2")}
+	prop := resetProvider(resources.Qscc_GetTransactionsByIDs, chainid, &peer2.SignedProposal{}, nil)
+	res := stub.MockInvokeWithSignedProposal("1", args, prop)
+	require.Equal(t, int32(shim.ERROR), res.Status, "GetTransactionsByIDs should have failed with invalid txids: 1, 2")
+
+	args = [][]byte{[]byte(GetTransactionsByIDs), []byte(chainid), []byte("1"), []byte(nil)}
+	res = stub.MockInvoke("2", args)
+	require.Equal(t, int32(shim.ERROR), res.Status, "GetTransactionsByIDs should have failed with invalid txid: nil")
+
+	// Test with wrong number of parameters
+	args = [][]byte{[]byte(GetTransactionsByIDs), []byte(chainid)}
+	res = stub.MockInvoke("3", args)
+	require.Equal(t, int32(shim.ERROR), res.Status, "GetTransactionsByIDs should have failed due to incorrect number of arguments")
+}
+
 func TestQueryGetBlockByNumber(t *testing.T) {
 	chainid := "mytestchainid3"
 	path := t.TempDir()
@@ -396,6 +422,7 @@ func TestQueryGeneratedBlock(t *testing.T) {
 	require.Equal(t, int32(shim.OK), res.Status, "GetBlockByHash should have succeeded for block 1 hash")
 
 	// drill into the block to find the transaction ids it contains
+	var txIDs [][]byte
 	for _, d := range block1.Data.Data {
 		ebytes := d
 		if ebytes != nil {
@@ -421,15 +448,30 @@ func TestQueryGeneratedBlock(t *testing.T) {
 					prop = resetProvider(resources.Qscc_GetTransactionByID, chainid, nil, nil)
 					res = stub.MockInvokeWithSignedProposal("4", args, prop)
 					require.Equal(t, int32(shim.OK), res.Status, "GetTransactionById should have succeeded for txid: %s", chdr.TxId)
+					txIDs = append(txIDs, []byte(chdr.TxId))
 				}
 			}
 		}
 	}
+
+	// the transactions are returned in the order of the requested ids
+	args = append([][]byte{[]byte(GetTransactionsByIDs), []byte(chainid)}, txIDs[1], txIDs[0])
+	prop = resetProvider(resources.Qscc_GetTransactionsByIDs, chainid, nil, nil)
+	res = stub.MockInvokeWithSignedProposal("5", args, prop)
+	require.Equal(t, int32(shim.OK), res.Status, "GetTransactionsByIDs should have succeeded for txids: %s", txIDs)
+	buffer := proto.NewBuffer(res.Payload)
+	for _, i := range []int{1, 0} {
+		processedTran := &peer2.ProcessedTransaction{}
+		require.NoError(t, buffer.DecodeMessage(processedTran))
+		env, err := protoutil.GetEnvelopeFromBlock(block1.Data.Data[i])
+		require.NoError(t, err)
+		require.True(t, proto.Equal(env, processedTran.TransactionEnvelope))
+	}
+	require.Empty(t, buffer.Unread())
 }
 
 func addBlockForTesting(t *testing.T, chainid string, p *peer.Peer) *common.Block {
 	ledger := p.GetLedger(chainid)
-	defer ledger.Close()
 
 	txid1 := util.GenerateUUID()
 	simulator, _ := ledger.NewTxSimulator(txid1)
@@ -271,6 +271,44 @@ func TestPackageDropTransientStorage(t *testing.T) {
 	verifyStoreDropped(t, store)
 }
 
+func TestPackageExistsTransientStorage(t *testing.T) {
+	env := initTestEnv(t)
+
+	populateTestStore(t, env.store)
+	ledgerID := env.store.ledgerID
+	sp := env.storeProvider.(*storeProvider)
+
+	// a storage pending deletion exists
+	require.NoError(t, sp.markStorageForDelete("pending-deletion"))
+	env.storeProvider.Close()
+
+	exists, err := Exists(env.storedir, ledgerID)
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = Exists(env.storedir, "pending-deletion")
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = Exists(env.storedir, "never-created")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, Drop(env.storedir, ledgerID))
+	require.NoError(t, Drop(env.storedir, "pending-deletion"))
+	exists, err = Exists(env.storedir, ledgerID)
+	require.NoError(t, err)
+	require.False(t, exists)
+	exists, err = Exists(env.storedir, "pending-deletion")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// checking while the peer is open is a lock error
+	sp2, err := NewStoreProvider(env.storedir)
+	require.NoError(t, err)
+	defer sp2.Close()
+	_, err = Exists(env.storedir, ledgerID)
+	require.ErrorContains(t, err, "as another peer node command is executing")
+}
+
 func TestLockFileIsAdjacentToTransientStorageFolder(t *testing.T) {
 	env := initTestEnv(t)
 
@@ -0,0 +1,228 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package transientstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/pkg/errors"
+)
+
+var (
+	// the private write sets are checked for the age at a tenth of the maximum age, within these bounds
+	minRetentionCheckInterval = time.Second
+	maxRetentionCheckInterval = time.Minute
+	// maxRetentionPurgeBatchSize is the maximum number of the db entries deleted in a single batch
+	// during the purge based on the age and the size
+	maxRetentionPurgeBatchSize = 3000
+
+	sizeOpts = metrics.GaugeOpts{
+		Namespace:    "transientstore",
+		Subsystem:    "",
+		Name:         "size_bytes",
+		Help:         "Size in bytes of the private write sets of a channel in the transient store.",
+		LabelNames:   []string{"channel"},
+		StatsdFormat: "%{#fqname}.%{channel}",
+	}
+)
+
+// retention purges the private write sets of a store based on their age and the size of the store.
+// The purge runs in the background, at an interval and whenever the size of the store exceeds the maximum size
+type retention struct {
+	maxAge        time.Duration
+	maxSize       int64
+	checkInterval time.Duration
+
+	trigger      chan struct{}
+	stop         chan struct{}
+	done         chan struct{}
+	shutdownOnce sync.Once
+}
+
+func newRetention(maxAge time.Duration, maxSize uint64) *retention {
+	checkInterval := maxRetentionCheckInterval
+	if maxAge > 0 && maxAge/10 < checkInterval {
+		checkInterval = maxAge / 10
+	}
+	if checkInterval < minRetentionCheckInterval {
+		checkInterval = minRetentionCheckInterval
+	}
+	return &retention{
+		maxAge:        maxAge,
+		maxSize:       int64(maxSize),
+		checkInterval: checkInterval,
+		trigger:       make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+func (r *retention) run(s *Store) {
+	defer close(r.done)
+	ticker := time.NewTicker(r.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		case <-r.trigger:
+		}
+		if err := s.purgeByRetentionPolicy(time.Now()); err != nil {
+			logger.Errorf("Failed to purge private data from transient store [%s] as per the retention policy: %s", s.ledgerID, err)
+		}
+	}
+}
+
+// sizeChanged triggers the purge if the store exceeds the maximum size, without waiting for the purge to complete
+func (r *retention) sizeChanged(size int64) {
+	if r == nil || r.maxSize == 0 || size <= r.maxSize {
+		return
+	}
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// shutdown stops the background purge and waits for an in-progress purge to complete
+func (r *retention) shutdown() {
+	if r == nil {
+		return
+	}
+	r.shutdownOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+	})
+}
+
+// computeSize returns the total size of the private write sets in the store, as recorded in the purge index by
+// height. The private write sets that are persisted by the previous versions do not record the size and are excluded
+func (s *Store) computeSize() (int64, error) {
+	iter, err := s.db.GetIterator(createPurgeIndexByHeightRangeStartKey(0), []byte{purgeIndexByHeightPrefix + 1})
+	if err != nil {
+		return 0, err
+	}
+	defer iter.Release()
+
+	size := int64(0)
+	for iter.Next() {
+		_, entrySize, _ := decodePurgeIndexValue(iter.Value())
+		size += int64(entrySize)
+	}
+	return size, errors.Wrap(iter.Error(), "error while iterating over the purge index")
+}
+
+func (s *Store) addSize(delta int64) {
+	s.sizeLock.Lock()
+	s.size += delta
+	size := s.size
+	s.sizeLock.Unlock()
+
+	s.sizeGauge.Set(float64(size))
+	s.retention.sizeChanged(size)
+}
+
+func (s *Store) currentSize() int64 {
+	s.sizeLock.Lock()
+	defer s.sizeLock.Unlock()
+	return s.size
+}
+
+// purgeByRetentionPolicy removes the private write sets that were persisted before `now` minus the maximum age
+// and, while the store exceeds the maximum size, the private write sets received at the lowest block heights.
+// The private write sets persisted by the previous versions do not record the time of persistence and hence are
+// purged only for the size or by the function PurgeBelowHeight
+func (s *Store) purgeByRetentionPolicy(now time.Time) error {
+	s.purgeLock.Lock()
+	defer s.purgeLock.Unlock()
+
+	maxAge := s.retention.maxAge
+	cutoff := now.Add(-maxAge)
+	excessSize := int64(0)
+	if s.retention.maxSize > 0 {
+		excessSize = s.currentSize() - s.retention.maxSize
+	}
+	if maxAge == 0 && excessSize <= 0 {
+		return nil
+	}
+
+	iter, err := s.db.GetIterator(createPurgeIndexByHeightRangeStartKey(0), []byte{purgeIndexByHeightPrefix + 1})
+	if err != nil {
+		return err
+	}
+	defer iter.Release()
+
+	dbBatch := s.db.NewUpdateBatch()
+	purgedSize, purgedCount := int64(0), 0
+	writeBatch := func() error {
+		if dbBatch.Len() == 0 {
+			return nil
+		}
+		if err := s.db.WriteBatch(dbBatch, true); err != nil {
+			return err
+		}
+		s.addSize(-purgedSize)
+		dbBatch.Reset()
+		purgedSize = 0
+		return nil
+	}
+
+	// As the private write sets are received at the height of the ledger at the time, a private write set received
+	// at a greater height than another was persisted after the other. Hence, the scan for the aged private write sets
+	// stops at the first greater height than a private write set that is not aged
+	stopAboveHeight, stopHeightFound := uint64(0), false
+	for iter.Next() {
+		compositeKeyPurgeIndexByHeight := iter.Key()
+		txid, uuid, blockHeight, err := splitCompositeKeyOfPurgeIndexByHeight(compositeKeyPurgeIndexByHeight)
+		if err != nil {
+			return err
+		}
+		if stopHeightFound && blockHeight > stopAboveHeight && excessSize <= 0 {
+			break
+		}
+
+		persistTime, size, ok := decodePurgeIndexValue(iter.Value())
+		aged := maxAge > 0 && ok && persistTime.Before(cutoff)
+		if !aged && excessSize <= 0 {
+			if maxAge == 0 {
+				break
+			}
+			if ok && !stopHeightFound {
+				stopAboveHeight, stopHeightFound = blockHeight, true
+			}
+			continue
+		}
+
+		logger.Debugf("Purging from transient store private data simulated at block [%d] as per the retention policy: txid [%s] uuid [%s]", blockHeight, txid, uuid)
+		dbBatch.Delete(createCompositeKeyForPvtRWSet(txid, uuid, blockHeight))
+		dbBatch.Delete(createCompositeKeyForPurgeIndexByTxid(txid, uuid, blockHeight))
+		dbBatch.Delete(compositeKeyPurgeIndexByHeight)
+		purgedSize += int64(size)
+		excessSize -= int64(size)
+		purgedCount++
+
+		if dbBatch.Len() >= maxRetentionPurgeBatchSize {
+			if err := writeBatch(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return errors.Wrap(err, "error while iterating over the purge index")
+	}
+	if err := writeBatch(); err != nil {
+		return err
+	}
+	if purgedCount > 0 {
+		logger.Infof("Purged [%d] private write sets from transient store [%s] as per the retention policy", purgedCount, s.ledgerID)
+	}
+	return nil
+}
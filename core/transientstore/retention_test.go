@@ -0,0 +1,163 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package transientstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/metrics/metricsfakes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeIndexValueEncoding(t *testing.T) {
+	persistTime := time.Unix(1600000000, 123)
+	decodedTime, size, ok := decodePurgeIndexValue(encodePurgeIndexValue(persistTime, 4096))
+	require.True(t, ok)
+	require.True(t, persistTime.Equal(decodedTime))
+	require.Equal(t, uint64(4096), size)
+
+	_, _, ok = decodePurgeIndexValue(emptyValue)
+	require.False(t, ok)
+}
+
+func TestStoreSize(t *testing.T) {
+	storedir := filepath.Join(t.TempDir(), "transientstore")
+	fakeGauge := &metricsfakes.Gauge{}
+	fakeGauge.WithReturns(fakeGauge)
+	fakeProvider := &metricsfakes.Provider{}
+	fakeProvider.NewGaugeReturns(fakeGauge)
+
+	storeProvider, err := NewStoreProviderWithConfig(storedir, &Config{MetricsProvider: fakeProvider})
+	require.NoError(t, err)
+	store, err := storeProvider.OpenStore("testledger")
+	require.NoError(t, err)
+	require.Equal(t, sizeOpts, fakeProvider.NewGaugeArgsForCall(0))
+	require.Equal(t, []string{"channel", "testledger"}, fakeGauge.WithArgsForCall(0))
+
+	// the same store is returned when opened again
+	sameStore, err := storeProvider.OpenStore("testledger")
+	require.NoError(t, err)
+	require.Same(t, store, sameStore)
+
+	pvtdata := samplePvtDataWithConfigInfo(t)
+	pvtdataBytes, err := proto.Marshal(pvtdata)
+	require.NoError(t, err)
+	entrySize := int64(len(pvtdataBytes) + 1)
+
+	require.NoError(t, store.Persist("txid-1", 10, pvtdata))
+	require.NoError(t, store.Persist("txid-2", 10, pvtdata))
+	require.NoError(t, store.Persist("txid-3", 11, pvtdata))
+	require.NoError(t, store.Persist("txid-4", 12, pvtdata))
+	// a private write set persisted by a previous version does not contribute to the size
+	require.NoError(t, store.persistOldProto("txid-5", 9, samplePvtData(t)))
+	require.Equal(t, 4*entrySize, store.currentSize())
+	require.Equal(t, float64(4*entrySize), fakeGauge.SetArgsForCall(fakeGauge.SetCallCount()-1))
+
+	require.NoError(t, store.PurgeByTxids([]string{"txid-1"}))
+	require.Equal(t, 3*entrySize, store.currentSize())
+	require.NoError(t, store.PurgeBelowHeight(11))
+	require.Equal(t, 2*entrySize, store.currentSize())
+	require.Equal(t, float64(2*entrySize), fakeGauge.SetArgsForCall(fakeGauge.SetCallCount()-1))
+
+	// the size is computed from the persisted entries when the store is opened by a new provider
+	storeProvider.Close()
+	storeProvider, err = NewStoreProvider(storedir)
+	require.NoError(t, err)
+	defer storeProvider.Close()
+	store, err = storeProvider.OpenStore("testledger")
+	require.NoError(t, err)
+	require.Equal(t, 2*entrySize, store.currentSize())
+}
+
+func TestPurgeByMaxAge(t *testing.T) {
+	storeProvider, err := NewStoreProviderWithConfig(
+		filepath.Join(t.TempDir(), "transientstore"),
+		&Config{MaxAge: time.Hour},
+	)
+	require.NoError(t, err)
+	defer storeProvider.Close()
+	store, err := storeProvider.OpenStore("testledger")
+	require.NoError(t, err)
+
+	pvtdata := samplePvtDataWithConfigInfo(t)
+	require.NoError(t, store.persistOldProto("txid-0", 9, samplePvtData(t)))
+	require.NoError(t, store.Persist("txid-1", 10, pvtdata))
+	require.NoError(t, store.Persist("txid-2", 11, pvtdata))
+	cutoff := time.Now()
+	time.Sleep(time.Millisecond)
+	require.NoError(t, store.Persist("txid-3", 11, pvtdata))
+	require.NoError(t, store.Persist("txid-4", 12, pvtdata))
+	sizeBeforePurge := store.currentSize()
+
+	// the private write sets persisted before the cutoff are purged, except the one without the time of persistence
+	require.NoError(t, store.purgeByRetentionPolicy(cutoff.Add(time.Hour)))
+	require.Equal(t, []string{"txid-0", "txid-3", "txid-4"}, txidsInStore(t, store))
+	require.Equal(t, sizeBeforePurge/2, store.currentSize())
+
+	require.NoError(t, store.purgeByRetentionPolicy(time.Now().Add(2*time.Hour)))
+	require.Equal(t, []string{"txid-0"}, txidsInStore(t, store))
+	require.Equal(t, int64(0), store.currentSize())
+}
+
+func TestPurgeByMaxSize(t *testing.T) {
+	pvtdata := samplePvtDataWithConfigInfo(t)
+	pvtdataBytes, err := proto.Marshal(pvtdata)
+	require.NoError(t, err)
+	entrySize := uint64(len(pvtdataBytes) + 1)
+
+	storeProvider, err := NewStoreProviderWithConfig(
+		filepath.Join(t.TempDir(), "transientstore"),
+		&Config{MaxSize: 2 * entrySize},
+	)
+	require.NoError(t, err)
+	defer storeProvider.Close()
+	store, err := storeProvider.OpenStore("testledger")
+	require.NoError(t, err)
+
+	require.NoError(t, store.persistOldProto("txid-0", 9, samplePvtData(t)))
+	require.NoError(t, store.Persist("txid-1", 10, pvtdata))
+	require.NoError(t, store.Persist("txid-2", 11, pvtdata))
+	require.Equal(t, []string{"txid-0", "txid-1", "txid-2"}, txidsInStore(t, store))
+
+	// exceeding the maximum size triggers the purge of the private write sets received at the lowest heights
+	require.NoError(t, store.Persist("txid-3", 12, pvtdata))
+	require.Eventually(t, func() bool {
+		return store.currentSize() <= int64(2*entrySize)
+	}, 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"txid-2", "txid-3"}, txidsInStore(t, store))
+}
+
+func TestRetentionDisabled(t *testing.T) {
+	env := initTestEnv(t)
+	defer env.storeProvider.Close()
+	require.Nil(t, env.store.retention)
+
+	r := newRetention(time.Second, 0)
+	require.Equal(t, minRetentionCheckInterval, r.checkInterval)
+	r = newRetention(time.Hour, 0)
+	require.Equal(t, maxRetentionCheckInterval, r.checkInterval)
+	r = newRetention(0, 1024)
+	require.Equal(t, maxRetentionCheckInterval, r.checkInterval)
+}
+
+func txidsInStore(t *testing.T, store *Store) []string {
+	iter, err := store.db.GetIterator(createPurgeIndexByHeightRangeStartKey(0), []byte{purgeIndexByHeightPrefix + 1})
+	require.NoError(t, err)
+	defer iter.Release()
+
+	var txids []string
+	for iter.Next() {
+		txid, _, _, err := splitCompositeKeyOfPurgeIndexByHeight(iter.Key())
+		require.NoError(t, err)
+		txids = append(txids, txid)
+	}
+	require.NoError(t, iter.Error())
+	return txids
+}
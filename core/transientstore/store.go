@@ -8,12 +8,16 @@ package transientstore
 
 import (
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
 	"github.com/hyperledger/fabric-protos-go/transientstore"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/common/metrics"
+	"github.com/hyperledger/fabric/common/metrics/disabled"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/pkg/errors"
@@ -53,6 +57,20 @@ type RWSetScanner interface {
 	Close()
 }
 
+// Config configures the purge of the private write sets from the transient store based on their age and size,
+// in addition to the purge by the transaction IDs and by the block height
+type Config struct {
+	// MaxAge, if non-zero, is the duration after which a private write set is purged
+	MaxAge time.Duration
+	// MaxSize, if non-zero, is the size in bytes of the private write sets of a channel above which
+	// the oldest private write sets of the channel are purged
+	MaxSize uint64
+	// MetricsProvider is used to report the size of the transient store. The metrics are disabled if not set
+	MetricsProvider metrics.Provider
+	// OpenRetry is the retry of the open of the leveldb of the transient store, when the first attempt fails
+	OpenRetry leveldbhelper.OpenRetry
+}
+
 // EndorserPvtSimulationResults captures the details of the simulation results specific to an endorser
 type EndorserPvtSimulationResults struct {
 	ReceivedAtBlockHeight          uint64
@@ -69,12 +87,27 @@ type EndorserPvtSimulationResults struct {
 type storeProvider struct {
 	dbProvider *leveldbhelper.Provider
 	fileLock   *leveldbhelper.FileLock
+	config     *Config
+	sizeGauge  metrics.Gauge
+
+	// stores caches the opened stores so that the size of a store is tracked by a single instance
+	storesLock sync.Mutex
+	stores     map[string]*Store
 }
 
 // store holds an instance of a levelDB.
 type Store struct {
-	db       *leveldbhelper.DBHandle
-	ledgerID string
+	db        *leveldbhelper.DBHandle
+	ledgerID  string
+	sizeGauge metrics.Gauge
+	// retention is set when the store is configured to purge the private write sets based on their age or size
+	retention *retention
+
+	// purgeLock serializes the purges so that the size of a purged private write set is deducted only once
+	purgeLock sync.Mutex
+	// size is the total size of the private write sets in the store and is guarded by sizeLock
+	sizeLock sync.Mutex
+	size     int64
 }
 
 // RwsetScanner helps iterating over results
@@ -86,6 +119,12 @@ type RwsetScanner struct {
 
 // NewStoreProvider instantiates TransientStoreProvider
 func NewStoreProvider(path string) (StoreProvider, error) {
+	return NewStoreProviderWithConfig(path, &Config{})
+}
+
+// NewStoreProviderWithConfig instantiates TransientStoreProvider that purges the private write sets
+// as per the supplied config
+func NewStoreProviderWithConfig(path string, config *Config) (StoreProvider, error) {
 	// Ensure the routine is invoked while the peer is down.
 	lockPath := filepath.Join(filepath.Dir(path), transientStorageLockName)
 	lock := leveldbhelper.NewFileLock(lockPath)
@@ -94,7 +133,7 @@ func NewStoreProvider(path string) (StoreProvider, error) {
 			" wait for that command to complete its execution or terminate it before retrying")
 	}
 
-	provider, err := newStoreProvider(path, lock)
+	provider, err := newStoreProvider(path, lock, config)
 	if err != nil {
 		lock.Unlock()
 		return nil, errors.WithMessagef(err, "could not construct storage provider in folder [%s]", path)
@@ -105,19 +144,33 @@ func NewStoreProvider(path string) (StoreProvider, error) {
 
 // Private method used to unwind a dependency between the package level Drop and NewStoreProvider routines.
 // This routine must be invoked while holding the newStoreProvider file lock.
-func newStoreProvider(providerPath string, fileLock *leveldbhelper.FileLock) (*storeProvider, error) {
+func newStoreProvider(providerPath string, fileLock *leveldbhelper.FileLock, config *Config) (*storeProvider, error) {
 	logger.Debugw("opening provider", "providerPath", providerPath)
 
 	if !fileLock.IsLocked() {
 		panic("newStoreProvider invoked without holding 'fileLock'")
 	}
 
-	dbProvider, err := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: providerPath})
+	dbProvider, err := leveldbhelper.NewProvider(&leveldbhelper.Conf{
+		DBPath:            providerPath,
+		OpenRetries:       config.OpenRetry.Retries,
+		OpenRetryInterval: config.OpenRetry.Interval,
+	})
 	if err != nil {
 		return nil, errors.WithMessage(err, "could not open dbprovider")
 	}
 
-	provider := &storeProvider{dbProvider: dbProvider, fileLock: fileLock}
+	metricsProvider := config.MetricsProvider
+	if metricsProvider == nil {
+		metricsProvider = &disabled.Provider{}
+	}
+	provider := &storeProvider{
+		dbProvider: dbProvider,
+		fileLock:   fileLock,
+		config:     config,
+		sizeGauge:  metricsProvider.NewGauge(sizeOpts),
+		stores:     make(map[string]*Store),
+	}
 
 	// purge any databases marked for deletion.  This may occur at the next peer init after a
 	// transient storage deletion failed due to a crash or system error.
@@ -130,12 +183,38 @@ func newStoreProvider(providerPath string, fileLock *leveldbhelper.FileLock) (*s
 
 // OpenStore returns a handle to a ledgerId in Store
 func (provider *storeProvider) OpenStore(ledgerID string) (*Store, error) {
-	dbHandle := provider.dbProvider.GetDBHandle(ledgerID)
-	return &Store{db: dbHandle, ledgerID: ledgerID}, nil
+	provider.storesLock.Lock()
+	defer provider.storesLock.Unlock()
+
+	if s, ok := provider.stores[ledgerID]; ok {
+		return s, nil
+	}
+	s := &Store{
+		db:        provider.dbProvider.GetDBHandle(ledgerID),
+		ledgerID:  ledgerID,
+		sizeGauge: provider.sizeGauge.With("channel", ledgerID),
+	}
+	size, err := s.computeSize()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "computing the size of transient storage [%s]", ledgerID)
+	}
+	s.addSize(size)
+	if provider.config.MaxAge > 0 || provider.config.MaxSize > 0 {
+		s.retention = newRetention(provider.config.MaxAge, provider.config.MaxSize)
+		go s.retention.run(s)
+	}
+	provider.stores[ledgerID] = s
+	return s, nil
 }
 
 // Close closes the TransientStoreProvider
 func (provider *storeProvider) Close() {
+	provider.storesLock.Lock()
+	for _, s := range provider.stores {
+		s.retention.shutdown()
+	}
+	provider.storesLock.Unlock()
+
 	if provider.dbProvider != nil {
 		provider.dbProvider.Close()
 	}
@@ -146,6 +225,12 @@ func (provider *storeProvider) Close() {
 
 // delete the transient storage for a given ledger.
 func (provider *storeProvider) deleteStore(ledgerID string) error {
+	provider.storesLock.Lock()
+	if s, ok := provider.stores[ledgerID]; ok {
+		s.retention.shutdown()
+		delete(provider.stores, ledgerID)
+	}
+	provider.storesLock.Unlock()
 	return provider.dbProvider.Drop(ledgerID)
 }
 
@@ -284,7 +369,7 @@ func Drop(providerPath, ledgerID string) error {
 	defer lock.Unlock()
 
 	// Set up a StoreProvider
-	provider, err := newStoreProvider(providerPath, lock)
+	provider, err := newStoreProvider(providerPath, lock, &Config{})
 	if err != nil {
 		return errors.WithMessagef(err, "constructing provider from path [%s]", providerPath)
 	}
@@ -311,6 +396,42 @@ func Drop(providerPath, ledgerID string) error {
 	return nil
 }
 
+// Exists returns true if the transient storage holds any data of an input channel/ledger or if the
+// storage of the channel/ledger is pending deletion. Unlike a StoreProvider, the pending deletions are
+// not processed. This function must be invoked while the peer is shut down.
+func Exists(providerPath, ledgerID string) (bool, error) {
+	lockPath := filepath.Join(filepath.Dir(providerPath), transientStorageLockName)
+	lock := leveldbhelper.NewFileLock(lockPath)
+	if err := lock.Lock(); err != nil {
+		return false, errors.New("as another peer node command is executing," +
+			" wait for that command to complete its execution or terminate it before retrying")
+	}
+	defer lock.Unlock()
+
+	dbProvider, err := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: providerPath})
+	if err != nil {
+		return false, errors.WithMessagef(err, "constructing provider from path [%s]", providerPath)
+	}
+	defer dbProvider.Close()
+
+	provider := &storeProvider{dbProvider: dbProvider}
+	dl, err := provider.getStorageMarkedForDeletion()
+	if err != nil {
+		return false, errors.WithMessagef(err, "checking transient storage [%s]", ledgerID)
+	}
+	for _, l := range dl.List {
+		if l == ledgerID {
+			return true, nil
+		}
+	}
+
+	empty, err := dbProvider.GetDBHandle(ledgerID).IsEmpty()
+	if err != nil {
+		return false, errors.WithMessagef(err, "checking transient storage [%s]", ledgerID)
+	}
+	return !empty, nil
+}
+
 // Persist stores the private write set of a transaction along with the collection config
 // in the transient store based on txid and the block height the private data was received at
 func (s *Store) Persist(txid string, blockHeight uint64,
@@ -337,20 +458,24 @@ func (s *Store) Persist(txid string, blockHeight uint64,
 	value := append([]byte{nilByte}, privateSimulationResultsWithConfigBytes...)
 	dbBatch.Put(compositeKeyPvtRWSet, value)
 
+	// The purge indexes record the time at which the private write set is persisted and its size
+	// so that the private write set can be purged based on its age and the size of the store
+	purgeIndexValue := encodePurgeIndexValue(time.Now(), uint64(len(value)))
+
 	// Create two index: (i) by txid, and (ii) by height
 
 	// Create compositeKey for purge index by height with appropriate prefix, blockHeight,
-	// txid, uuid and store the compositeKey (purge index) with a nil byte as value. Note that
+	// txid, uuid and store the compositeKey (purge index) with the purgeIndexValue. Note that
 	// the purge index is used to remove orphan entries in the transient store (which are not removed
 	// by PurgeTxids()) using BTL policy by PurgeBelowHeight(). Note that orphan entries are due to transaction
 	// that gets endorsed but not submitted by the client for commit)
 	compositeKeyPurgeIndexByHeight := createCompositeKeyForPurgeIndexByHeight(blockHeight, txid, uuid)
-	dbBatch.Put(compositeKeyPurgeIndexByHeight, emptyValue)
+	dbBatch.Put(compositeKeyPurgeIndexByHeight, purgeIndexValue)
 
 	// Create compositeKey for purge index by txid with appropriate prefix, txid, uuid,
-	// blockHeight and store the compositeKey (purge index) with a nil byte as value.
+	// blockHeight and store the compositeKey (purge index) with the purgeIndexValue.
 	// Though compositeKeyPvtRWSet itself can be used to purge private write set by txid,
-	// we create a separate composite key with a nil byte as value. The reason is that
+	// we create a separate composite key with a small value. The reason is that
 	// if we use compositeKeyPvtRWSet, we unnecessarily read (potentially large) private write
 	// set associated with the key from db. Note that this purge index is used to remove non-orphan
 	// entries in the transient store and is used by PurgeTxids()
@@ -358,9 +483,13 @@ func (s *Store) Persist(txid string, blockHeight uint64,
 	// with purgeIndexByTxidPrefix. For code readability and to be expressive, we use a
 	// createCompositeKeyForPurgeIndexByTxid() instead.
 	compositeKeyPurgeIndexByTxid := createCompositeKeyForPurgeIndexByTxid(txid, uuid, blockHeight)
-	dbBatch.Put(compositeKeyPurgeIndexByTxid, emptyValue)
+	dbBatch.Put(compositeKeyPurgeIndexByTxid, purgeIndexValue)
 
-	return s.db.WriteBatch(dbBatch, true)
+	if err := s.db.WriteBatch(dbBatch, true); err != nil {
+		return err
+	}
+	s.addSize(int64(len(value)))
+	return nil
 }
 
 // GetTxPvtRWSetByTxid returns an iterator due to the fact that the txid may have multiple private
@@ -385,7 +514,11 @@ func (s *Store) GetTxPvtRWSetByTxid(txid string, filter ledger.PvtNsCollFilter)
 func (s *Store) PurgeByTxids(txids []string) error {
 	logger.Debug("Purging private data from transient store for committed txids")
 
+	s.purgeLock.Lock()
+	defer s.purgeLock.Unlock()
+
 	dbBatch := s.db.NewUpdateBatch()
+	purgedSize := int64(0)
 
 	for _, txid := range txids {
 		// Construct startKey and endKey to do an range query
@@ -419,12 +552,19 @@ func (s *Store) PurgeByTxids(txids []string) error {
 
 			// Remove purge index -- purgeIndexByTxid
 			dbBatch.Delete(compositeKeyPurgeIndexByTxid)
+
+			_, size, _ := decodePurgeIndexValue(iter.Value())
+			purgedSize += int64(size)
 		}
 		iter.Release()
 	}
 	// If peer fails before/while writing the batch to golevelDB, these entries will be
 	// removed as per BTL policy later by PurgeBelowHeight()
-	return s.db.WriteBatch(dbBatch, true)
+	if err := s.db.WriteBatch(dbBatch, true); err != nil {
+		return err
+	}
+	s.addSize(-purgedSize)
+	return nil
 }
 
 // PurgeBelowHeight removes private write sets at block height lesser than
@@ -436,6 +576,9 @@ func (s *Store) PurgeByTxids(txids []string) error {
 func (s *Store) PurgeBelowHeight(maxBlockNumToRetain uint64) error {
 	logger.Debugf("Purging orphaned private data from transient store received prior to block [%d]", maxBlockNumToRetain)
 
+	s.purgeLock.Lock()
+	defer s.purgeLock.Unlock()
+
 	// Do a range query with 0 as startKey and maxBlockNumToRetain-1 as endKey
 	startKey := createPurgeIndexByHeightRangeStartKey(0)
 	endKey := createPurgeIndexByHeightRangeEndKey(maxBlockNumToRetain - 1)
@@ -445,6 +588,7 @@ func (s *Store) PurgeBelowHeight(maxBlockNumToRetain uint64) error {
 	}
 
 	dbBatch := s.db.NewUpdateBatch()
+	purgedSize := int64(0)
 
 	// Get all txid and uuid from above result and remove it from transient store (both
 	// write set and the corresponding index.
@@ -468,10 +612,17 @@ func (s *Store) PurgeBelowHeight(maxBlockNumToRetain uint64) error {
 
 		// Remove purge index -- purgeIndexByHeight
 		dbBatch.Delete(compositeKeyPurgeIndexByHeight)
+
+		_, size, _ := decodePurgeIndexValue(iter.Value())
+		purgedSize += int64(size)
 	}
 	iter.Release()
 
-	return s.db.WriteBatch(dbBatch, true)
+	if err := s.db.WriteBatch(dbBatch, true); err != nil {
+		return err
+	}
+	s.addSize(-purgedSize)
+	return nil
 }
 
 // GetMinTransientBlkHt returns the lowest block height remaining in transient store
@@ -9,7 +9,9 @@ package transientstore
 import (
 	"bytes"
 	"errors"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/ledger/util"
@@ -74,6 +76,27 @@ func createCompositeKeyForPurgeIndexByHeight(blockHeight uint64, txid string, uu
 	return compositeKey
 }
 
+// encodePurgeIndexValue encodes the time at which a private write set is persisted and the size of the persisted
+// private write set. The encoded bytes are stored as the value of both the purge indexes of the private write set
+func encodePurgeIndexValue(persistTime time.Time, size uint64) []byte {
+	value := proto.EncodeVarint(uint64(persistTime.UnixNano()))
+	return append(value, proto.EncodeVarint(size)...)
+}
+
+// decodePurgeIndexValue decodes the value of a purge index. The returned bool is false for the purge indexes
+// persisted by the previous versions, the value of which is empty
+func decodePurgeIndexValue(value []byte) (persistTime time.Time, size uint64, ok bool) {
+	unixNano, n := proto.DecodeVarint(value)
+	if n == 0 {
+		return time.Time{}, 0, false
+	}
+	size, m := proto.DecodeVarint(value[n:])
+	if m == 0 {
+		return time.Time{}, 0, false
+	}
+	return time.Unix(0, int64(unixNano)), size, true
+}
+
 // splitCompositeKeyOfPvtRWSet splits the compositeKey (<prwsetPrefix>~txid~uuid~blockHeight)
 // into uuid and blockHeight.
 func splitCompositeKeyOfPvtRWSet(compositeKey []byte) (uuid string, blockHeight uint64, err error) {
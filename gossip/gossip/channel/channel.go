@@ -95,6 +95,9 @@ type GossipChannel interface {
 	// LeaveChannel makes the peer leave the channel
 	LeaveChannel()
 
+	// HasLeftChannel returns whether the peer has left the channel
+	HasLeftChannel() bool
+
 	// Stop stops the channel's activity
 	Stop()
 }
@@ -361,6 +364,11 @@ func (gc *gossipChannel) hasLeftChannel() bool {
 	return atomic.LoadInt32(&gc.leftChannel) == 1
 }
 
+// HasLeftChannel returns whether the peer has left the channel
+func (gc *gossipChannel) HasLeftChannel() bool {
+	return gc.hasLeftChannel()
+}
+
 // GetPeers returns a list of peers with metadata as published by them
 func (gc *gossipChannel) GetPeers() []discovery.NetworkMember {
 	var members []discovery.NetworkMember
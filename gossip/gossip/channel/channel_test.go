@@ -489,7 +489,9 @@ func TestLeaveChannel(t *testing.T) {
 	go gc.HandleMessage(hello)
 	DigestSentWg.Wait()
 	// Make the peer leave the channel
+	require.False(t, gc.HasLeftChannel())
 	gc.LeaveChannel()
+	require.True(t, gc.HasLeftChannel())
 	// Send another hello. Shouldn't respond
 	go gc.HandleMessage(hello)
 	// Ensure it doesn't know now any other peer
@@ -102,14 +102,18 @@ func (cs *channelState) joinChannel(joinMsg api.JoinChannelMessage, channelID co
 	}
 	cs.Lock()
 	defer cs.Unlock()
-	if gc, exists := cs.channels[string(channelID)]; !exists {
-		pkiID := cs.g.comm.GetPKIid()
-		ga := &gossipAdapterImpl{Node: cs.g, Discovery: cs.g.disc}
-		gc := channel.NewGossipChannel(pkiID, cs.g.selfOrg, cs.g.mcs, channelID, ga, joinMsg, metrics, nil)
-		cs.channels[string(channelID)] = gc
-	} else {
+	gc, exists := cs.channels[string(channelID)]
+	if exists && !gc.HasLeftChannel() {
 		gc.ConfigureChannel(joinMsg)
+		return
+	}
+	if exists {
+		// the peer rejoins a channel it has left, which requires a fresh channel as leaving is irreversible
+		gc.Stop()
 	}
+	pkiID := cs.g.comm.GetPKIid()
+	ga := &gossipAdapterImpl{Node: cs.g, Discovery: cs.g.disc}
+	cs.channels[string(channelID)] = channel.NewGossipChannel(pkiID, cs.g.selfOrg, cs.g.mcs, channelID, ga, joinMsg, metrics, nil)
 }
 
 type gossipAdapterImpl struct {
@@ -386,6 +386,15 @@ func TestLeaveChannel(t *testing.T) {
 	waitUntilOrFail(t, countMembership(p0, 1), "waiting for p0 to update membership view")
 	waitUntilOrFail(t, countMembership(p1, 1), "waiting for p1 to update membership view")
 	waitUntilOrFail(t, countMembership(p2, 0), "waiting for p2 to update membership view")
+
+	// Now p2 rejoins the channel
+	p2.JoinChan(&joinChanMsg{}, common.ChannelID("A"))
+	p2.UpdateLedgerHeight(1, common.ChannelID("A"))
+
+	// Ensure channel membership is restored
+	waitUntilOrFail(t, countMembership(p0, 2), "waiting for p0 to see p2 again")
+	waitUntilOrFail(t, countMembership(p1, 2), "waiting for p1 to see p2 again")
+	waitUntilOrFail(t, countMembership(p2, 2), "waiting for p2 to form membership again")
 }
 
 func TestPull(t *testing.T) {
@@ -415,6 +415,34 @@ func (g *GossipService) InitializeChannel(channelID string, ordererSource *order
 	}
 }
 
+// StopChannel stops the leader election, the state provider, the private data handlers and the delivery service of
+// the given channel and makes gossip leave the channel, so that the peer neither receives nor commits the blocks of
+// the channel. The channel can be initialized again via InitializeChannel
+func (g *GossipService) StopChannel(channelID string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	logger.Info("Stopping chain", channelID)
+	if le, exists := g.leaderElection[channelID]; exists {
+		logger.Infof("Stopping leader election for %s", channelID)
+		le.Stop()
+		delete(g.leaderElection, channelID)
+	}
+	if chain, exists := g.chains[channelID]; exists {
+		chain.Stop()
+		delete(g.chains, channelID)
+	}
+	if handler, exists := g.privateHandlers[channelID]; exists {
+		handler.close()
+		delete(g.privateHandlers, channelID)
+	}
+	if g.deliveryService[channelID] != nil {
+		g.deliveryService[channelID].Stop()
+	}
+	delete(g.deliveryService, channelID)
+	g.LeaveChan(common.ChannelID(channelID))
+}
+
 func (g *GossipService) createSelfSignedData() protoutil.SignedData {
 	msg := make([]byte, 32)
 	sig, err := g.mcs.Sign(msg)
@@ -327,6 +327,61 @@ func TestWithStaticDeliverClientNotLeader(t *testing.T) {
 	stopPeers(gossips)
 }
 
+func TestStopChannel(t *testing.T) {
+	serviceConfig := &ServiceConfig{
+		UseLeaderElection:                false,
+		OrgLeader:                        true,
+		ElectionStartupGracePeriod:       election.DefStartupGracePeriod,
+		ElectionMembershipSampleInterval: election.DefMembershipSampleInterval,
+		ElectionLeaderAliveThreshold:     election.DefLeaderAliveThreshold,
+		ElectionLeaderElectionDuration:   election.DefLeaderElectionDuration,
+	}
+	n := 2
+	gossips := startPeers(serviceConfig, n, 0, 1)
+	defer stopPeers(gossips)
+
+	channelName := "chanA"
+	addPeersToChannel(channelName, gossips, []int{0, 1})
+	waitForFullMembershipOrFailNow(t, channelName, gossips, n, TIMEOUT, time.Second*2)
+
+	store := newTransientStore(t)
+	defer store.tearDown()
+
+	deliverServiceFactory := &mockDeliverServiceFactory{
+		service: &mockDeliverService{
+			running: make(map[string]bool),
+		},
+	}
+	initializeChannel := func(g *gossipGRPC) {
+		g.InitializeChannel(channelName, orderers.NewConnectionSource(flogging.MustGetLogger("peer.orderers"), nil), store.Store, Support{
+			Committer: &mockLedgerInfo{1},
+		})
+	}
+	for i := 0; i < n; i++ {
+		gossips[i].deliveryFactory = deliverServiceFactory
+		initializeChannel(gossips[i])
+	}
+
+	gossips[0].StopChannel(channelName)
+	require.NotContains(t, gossips[0].chains, channelName)
+	require.NotContains(t, gossips[0].privateHandlers, channelName)
+	require.NotContains(t, gossips[0].deliveryService, channelName)
+	// the other peer does not see the peer that has stopped the channel
+	require.Eventually(t, func() bool {
+		return len(gossips[1].PeersOfChannel(gossipcommon.ChannelID(channelName))) == 0
+	}, TIMEOUT, time.Second)
+
+	// stopping a channel that is not initialized is a no-op
+	gossips[0].StopChannel("chanB")
+
+	// the channel is initialized again and the peer rejoins the channel
+	initializeChannel(gossips[0])
+	addPeersToChannel(channelName, gossips, []int{0})
+	require.Contains(t, gossips[0].chains, channelName)
+	require.NotNil(t, gossips[0].deliveryService[channelName])
+	waitForFullMembershipOrFailNow(t, channelName, gossips, n, TIMEOUT, time.Second*2)
+}
+
 func TestWithStaticDeliverClientBothStaticAndLeaderElection(t *testing.T) {
 	serviceConfig := &ServiceConfig{
 		UseLeaderElection:                true,
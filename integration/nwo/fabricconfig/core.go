@@ -111,6 +111,8 @@ type GossipElection struct {
 type GossipPvtData struct {
 	PullRetryThreshold                         time.Duration                   `yaml:"pullRetryThreshold,omitempty"`
 	TransientstoreMaxBlockRetention            int                             `yaml:"transientstoreMaxBlockRetention,omitempty"`
+	TransientstoreMaxAge                       time.Duration                   `yaml:"transientstoreMaxAge,omitempty"`
+	TransientstoreMaxSize                      int64                           `yaml:"transientstoreMaxSize,omitempty"`
 	PushAckTimeout                             time.Duration                   `yaml:"pushAckTimeout,omitempty"`
 	BtlPullMargin                              int                             `yaml:"btlPullMargin,omitempty"`
 	ReconcileBatchSize                         int                             `yaml:"reconcileBatchSize,omitempty"`
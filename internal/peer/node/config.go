@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	coreconfig "github.com/hyperledger/fabric/core/config"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/spf13/viper"
@@ -51,21 +52,55 @@ func ledgerConfig() *ledger.Config {
 	conf := &ledger.Config{
 		RootFSPath: ledgersDataRootDir,
 		StateDBConfig: &ledger.StateDBConfig{
-			StateDatabase: viper.GetString("ledger.state.stateDatabase"),
-			CouchDB:       &ledger.CouchDBConfig{},
+			StateDatabase:            viper.GetString("ledger.state.stateDatabase"),
+			CouchDB:                  &ledger.CouchDBConfig{},
+			PerNamespacePartitioning: viper.GetBool("ledger.state.perNamespacePartitioning"),
+			TotalQueryLimit:          viper.GetInt("ledger.state.totalQueryLimit"),
+			WriteTimeout:             viper.GetDuration("ledger.state.writeTimeout"),
+			AutoCompactInterval:      viper.GetDuration("ledger.state.autoCompactInterval"),
+			ReadCacheSize:            viper.GetInt("ledger.state.readCacheSize"),
+			CommitHashWorkers:        viper.GetInt("ledger.state.commitHashWorkers"),
+			PartialSavepointInterval: viper.GetInt("ledger.state.partialSavepointInterval"),
+			LevelDB:                  levelDBConfig("ledger.state.levelDBConfig"),
+			BlockToLive:              stateBlockToLive("ledger.state.blockToLive"),
 		},
 		PrivateDataConfig: &ledger.PrivateDataConfig{
 			MaxBatchSize:                        collElgProcMaxDbBatchSize,
 			BatchesInterval:                     collElgProcDbBatchesInterval,
 			PurgeInterval:                       purgeInterval,
 			DeprioritizedDataReconcilerInterval: deprioritizedDataReconcilerInterval,
+			CompactionInterval:                  viper.GetDuration("ledger.pvtdataStore.compactionInterval"),
 		},
 		HistoryDBConfig: &ledger.HistoryDBConfig{
-			Enabled: viper.GetBool("ledger.history.enableHistoryDatabase"),
+			Enabled:             viper.GetBool("ledger.history.enableHistoryDatabase"),
+			SampleEveryN:        viper.GetUint64("ledger.history.sampleEveryN"),
+			CommitPipelineDepth: viper.GetInt("ledger.history.commitPipelineDepth"),
+			StoreValues:         viper.GetBool("ledger.history.storeValues"),
+		},
+		BlockStorageConfig: &ledger.BlockStorageConfig{
+			SyncMode:            viper.GetString("ledger.blockchain.syncMode"),
+			SyncEveryN:          viper.GetInt("ledger.blockchain.syncEveryN"),
+			RetainBlocks:        viper.GetUint64("ledger.blockchain.retainBlocks"),
+			MaxBlockfileSizeMBs: viper.GetInt("ledger.blockchain.maxBlockfileSize"),
+			Compression:         viper.GetString("ledger.blockchain.compression"),
+			IndexChaincodeName:  viper.GetBool("ledger.blockchain.indexChaincodeName"),
+			IndexEndorserMSPIDs: viper.GetBool("ledger.blockchain.indexEndorserMSPIDs"),
+			IndexLevelDB:        levelDBConfig("ledger.blockchain.indexLevelDBConfig"),
+			Archiver:            blockfileArchiver(viper.GetString("ledger.blockchain.archiveDir")),
 		},
 		SnapshotsConfig: &ledger.SnapshotsConfig{
-			RootDir: snapshotsRootDir,
+			RootDir:                  snapshotsRootDir,
+			CompactStateDB:           viper.GetBool("ledger.snapshots.compactStateDB"),
+			MigrateSnapshots:         viper.GetBool("ledger.snapshots.migrateSnapshots"),
+			Incremental:              viper.GetBool("ledger.snapshots.incremental"),
+			MaxConcurrentGenerations: viper.GetInt("ledger.snapshots.maxConcurrentGenerations"),
+			MaxWriteRateMBs:          viper.GetInt("ledger.snapshots.maxWriteRateMBs"),
+			AutoGenerateEveryNBlocks: viper.GetUint64("ledger.snapshots.autoGenerateEveryNBlocks"),
+			AutoGenerateInterval:     viper.GetDuration("ledger.snapshots.autoGenerateInterval"),
+			RetainSnapshots:          viper.GetInt("ledger.snapshots.retainSnapshots"),
 		},
+		LevelDBOpenRetries:       viper.GetInt("ledger.levelDBOpenRetries"),
+		LevelDBOpenRetryInterval: viper.GetDuration("ledger.levelDBOpenRetryInterval"),
 	}
 
 	if conf.StateDBConfig.StateDatabase == ledger.CouchDB {
@@ -85,3 +120,50 @@ func ledgerConfig() *ledger.Config {
 	}
 	return conf
 }
+
+// blockfileArchiver returns an archiver that moves the old block files to the given directory. A nil value, which
+// disables the archiving of the block files, is returned if the directory is not set
+func blockfileArchiver(dir string) ledger.BlockfileArchiver {
+	if dir == "" {
+		return nil
+	}
+	return blkstorage.NewDirArchiver(dir)
+}
+
+// levelDBConfig reads the goleveldb tuning options under the given key. A nil value, which leaves the goleveldb
+// defaults in place, is returned if the key is not set
+func levelDBConfig(key string) *ledger.LevelDBConfig {
+	if !viper.IsSet(key) {
+		return nil
+	}
+	return &ledger.LevelDBConfig{
+		BloomFilterBitsPerKey: viper.GetInt(key + ".bloomFilterBitsPerKey"),
+		BlockCacheSizeMBs:     viper.GetInt(key + ".blockCacheSize"),
+		WriteBufferSizeMBs:    viper.GetInt(key + ".writeBufferSize"),
+		Compression:           viper.GetString(key + ".compression"),
+	}
+}
+
+// stateBlockToLive reads the block-to-live of the public state keys under the given key, which holds a list of
+// entries with a namespace and the number of blocks. A list is used instead of a map, as viper lowercases the keys
+// of a map whereas the namespaces are case sensitive. A nil value is returned if no entry is configured
+func stateBlockToLive(key string) map[string]uint64 {
+	var entries []struct {
+		Namespace string
+		Blocks    uint64
+	}
+	if err := viper.UnmarshalKey(key, &entries); err != nil {
+		logger.Panicf("Invalid configuration of %s: %s", key, err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	blockToLive := make(map[string]uint64, len(entries))
+	for _, e := range entries {
+		if e.Namespace == "" {
+			logger.Panicf("Invalid configuration of %s: the namespace is missing in an entry", key)
+		}
+		blockToLive[e.Namespace] = e.Blocks
+	}
+	return blockToLive
+}
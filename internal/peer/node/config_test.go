@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
@@ -43,6 +44,7 @@ func TestLedgerConfig(t *testing.T) {
 				HistoryDBConfig: &ledger.HistoryDBConfig{
 					Enabled: false,
 				},
+				BlockStorageConfig: &ledger.BlockStorageConfig{},
 				SnapshotsConfig: &ledger.SnapshotsConfig{
 					RootDir: "/peerfs/snapshots",
 				},
@@ -89,6 +91,7 @@ func TestLedgerConfig(t *testing.T) {
 				HistoryDBConfig: &ledger.HistoryDBConfig{
 					Enabled: false,
 				},
+				BlockStorageConfig: &ledger.BlockStorageConfig{},
 				SnapshotsConfig: &ledger.SnapshotsConfig{
 					RootDir: "/peerfs/snapshots",
 				},
@@ -113,8 +116,37 @@ func TestLedgerConfig(t *testing.T) {
 				"ledger.pvtdataStore.collElgProcDbBatchesInterval":        10000,
 				"ledger.pvtdataStore.purgeInterval":                       1000,
 				"ledger.pvtdataStore.deprioritizedDataReconcilerInterval": "180m",
+				"ledger.pvtdataStore.compactionInterval":                  "12h",
 				"ledger.history.enableHistoryDatabase":                    true,
+				"ledger.history.commitPipelineDepth":                      4,
+				"ledger.history.storeValues":                              true,
+				"ledger.state.levelDBConfig.bloomFilterBitsPerKey":        10,
+				"ledger.state.levelDBConfig.blockCacheSize":               64,
+				"ledger.state.levelDBConfig.writeBufferSize":              16,
+				"ledger.state.levelDBConfig.compression":                  "none",
+				"ledger.blockchain.indexLevelDBConfig.blockCacheSize":     32,
+				"ledger.blockchain.syncMode":                              "PerN",
+				"ledger.blockchain.syncEveryN":                            10,
+				"ledger.blockchain.retainBlocks":                          1000,
+				"ledger.blockchain.maxBlockfileSize":                      128,
+				"ledger.blockchain.compression":                           "snappy",
+				"ledger.blockchain.indexChaincodeName":                    true,
+				"ledger.blockchain.indexEndorserMSPIDs":                   true,
+				"ledger.blockchain.archiveDir":                            "/archive/blocks",
 				"ledger.snapshots.rootDir":                                "/peerfs/customLocationForsnapshots",
+				"ledger.snapshots.compactStateDB":                         true,
+				"ledger.snapshots.incremental":                            true,
+				"ledger.snapshots.maxConcurrentGenerations":               2,
+				"ledger.snapshots.maxWriteRateMBs":                        100,
+				"ledger.snapshots.autoGenerateEveryNBlocks":               10000,
+				"ledger.snapshots.autoGenerateInterval":                   "24h",
+				"ledger.snapshots.retainSnapshots":                        3,
+				"ledger.levelDBOpenRetries":                               5,
+				"ledger.levelDBOpenRetryInterval":                         "2s",
+				"ledger.state.blockToLive": []map[string]interface{}{
+					{"namespace": "sensorCC", "blocks": 100},
+					{"namespace": "mycc", "blocks": 10},
+				},
 			},
 			expected: &ledger.Config{
 				RootFSPath: "/peerfs/ledgersData",
@@ -133,19 +165,54 @@ func TestLedgerConfig(t *testing.T) {
 						RedoLogPath:           "/peerfs/ledgersData/couchdbRedoLogs",
 						UserCacheSizeMBs:      64,
 					},
+					LevelDB: &ledger.LevelDBConfig{
+						BloomFilterBitsPerKey: 10,
+						BlockCacheSizeMBs:     64,
+						WriteBufferSizeMBs:    16,
+						Compression:           "none",
+					},
+					BlockToLive: map[string]uint64{
+						"sensorCC": 100,
+						"mycc":     10,
+					},
 				},
 				PrivateDataConfig: &ledger.PrivateDataConfig{
 					MaxBatchSize:                        50000,
 					BatchesInterval:                     10000,
 					PurgeInterval:                       1000,
 					DeprioritizedDataReconcilerInterval: 180 * time.Minute,
+					CompactionInterval:                  12 * time.Hour,
 				},
 				HistoryDBConfig: &ledger.HistoryDBConfig{
-					Enabled: true,
+					Enabled:             true,
+					CommitPipelineDepth: 4,
+					StoreValues:         true,
+				},
+				BlockStorageConfig: &ledger.BlockStorageConfig{
+					SyncMode:            "PerN",
+					SyncEveryN:          10,
+					RetainBlocks:        1000,
+					MaxBlockfileSizeMBs: 128,
+					Compression:         "snappy",
+					IndexChaincodeName:  true,
+					IndexEndorserMSPIDs: true,
+					IndexLevelDB: &ledger.LevelDBConfig{
+						BlockCacheSizeMBs: 32,
+					},
+					Archiver: blkstorage.NewDirArchiver("/archive/blocks"),
 				},
 				SnapshotsConfig: &ledger.SnapshotsConfig{
-					RootDir: "/peerfs/customLocationForsnapshots",
+					RootDir:                  "/peerfs/customLocationForsnapshots",
+					CompactStateDB:           true,
+					Incremental:              true,
+					MaxConcurrentGenerations: 2,
+					MaxWriteRateMBs:          100,
+					AutoGenerateEveryNBlocks: 10000,
+					AutoGenerateInterval:     24 * time.Hour,
+					RetainSnapshots:          3,
 				},
+				LevelDBOpenRetries:       5,
+				LevelDBOpenRetryInterval: 2 * time.Second,
 			},
 		},
 	}
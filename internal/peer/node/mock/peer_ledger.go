@@ -11,6 +11,51 @@ import (
 )
 
 type PeerLedger struct {
+	AppendBlockRawStub        func(*common.Block) error
+	appendBlockRawMutex       sync.RWMutex
+	appendBlockRawArgsForCall []struct {
+		arg1 *common.Block
+	}
+	appendBlockRawReturns struct {
+		result1 error
+	}
+	appendBlockRawReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ArchiveBlocksStub        func(uint64) error
+	archiveBlocksMutex       sync.RWMutex
+	archiveBlocksArgsForCall []struct {
+		arg1 uint64
+	}
+	archiveBlocksReturns struct {
+		result1 error
+	}
+	archiveBlocksReturnsOnCall map[int]struct {
+		result1 error
+	}
+	BackupStub        func(string) error
+	backupMutex       sync.RWMutex
+	backupArgsForCall []struct {
+		arg1 string
+	}
+	backupReturns struct {
+		result1 error
+	}
+	backupReturnsOnCall map[int]struct {
+		result1 error
+	}
+	BlockStoreCheckpointInfoStub        func() (*ledger.BlockStoreCheckpoint, error)
+	blockStoreCheckpointInfoMutex       sync.RWMutex
+	blockStoreCheckpointInfoArgsForCall []struct {
+	}
+	blockStoreCheckpointInfoReturns struct {
+		result1 *ledger.BlockStoreCheckpoint
+		result2 error
+	}
+	blockStoreCheckpointInfoReturnsOnCall map[int]struct {
+		result1 *ledger.BlockStoreCheckpoint
+		result2 error
+	}
 	CancelSnapshotRequestStub        func(uint64) error
 	cancelSnapshotRequestMutex       sync.RWMutex
 	cancelSnapshotRequestArgsForCall []struct {
@@ -22,10 +67,56 @@ type PeerLedger struct {
 	cancelSnapshotRequestReturnsOnCall map[int]struct {
 		result1 error
 	}
+	CatchUpHistoryDBStub        func() error
+	catchUpHistoryDBMutex       sync.RWMutex
+	catchUpHistoryDBArgsForCall []struct {
+	}
+	catchUpHistoryDBReturns struct {
+		result1 error
+	}
+	catchUpHistoryDBReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ChannelIDStub        func() (string, error)
+	channelIDMutex       sync.RWMutex
+	channelIDArgsForCall []struct {
+	}
+	channelIDReturns struct {
+		result1 string
+		result2 error
+	}
+	channelIDReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	CloseStub        func()
 	closeMutex       sync.RWMutex
 	closeArgsForCall []struct {
 	}
+	CollectionConfigAtStub        func(string, uint64) (*peer.CollectionConfigPackage, error)
+	collectionConfigAtMutex       sync.RWMutex
+	collectionConfigAtArgsForCall []struct {
+		arg1 string
+		arg2 uint64
+	}
+	collectionConfigAtReturns struct {
+		result1 *peer.CollectionConfigPackage
+		result2 error
+	}
+	collectionConfigAtReturnsOnCall map[int]struct {
+		result1 *peer.CollectionConfigPackage
+		result2 error
+	}
+	CommitBackpressureStub        func() float64
+	commitBackpressureMutex       sync.RWMutex
+	commitBackpressureArgsForCall []struct {
+	}
+	commitBackpressureReturns struct {
+		result1 float64
+	}
+	commitBackpressureReturnsOnCall map[int]struct {
+		result1 float64
+	}
 	CommitLegacyStub        func(*ledger.BlockAndPvtData, *ledger.CommitOptions) error
 	commitLegacyMutex       sync.RWMutex
 	commitLegacyArgsForCall []struct {
@@ -65,6 +156,31 @@ type PeerLedger struct {
 		result1 []*ledger.PvtdataHashMismatch
 		result2 error
 	}
+	CompactPvtdataStoreStub        func() (int64, error)
+	compactPvtdataStoreMutex       sync.RWMutex
+	compactPvtdataStoreArgsForCall []struct {
+	}
+	compactPvtdataStoreReturns struct {
+		result1 int64
+		result2 error
+	}
+	compactPvtdataStoreReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	ComputeStateHashStub        func(uint64) ([]byte, error)
+	computeStateHashMutex       sync.RWMutex
+	computeStateHashArgsForCall []struct {
+		arg1 uint64
+	}
+	computeStateHashReturns struct {
+		result1 []byte
+		result2 error
+	}
+	computeStateHashReturnsOnCall map[int]struct {
+		result1 []byte
+		result2 error
+	}
 	DoesPvtDataInfoExistStub        func(uint64) (bool, error)
 	doesPvtDataInfoExistMutex       sync.RWMutex
 	doesPvtDataInfoExistArgsForCall []struct {
@@ -78,6 +194,16 @@ type PeerLedger struct {
 		result1 bool
 		result2 error
 	}
+	FreezeStub        func() error
+	freezeMutex       sync.RWMutex
+	freezeArgsForCall []struct {
+	}
+	freezeReturns struct {
+		result1 error
+	}
+	freezeReturnsOnCall map[int]struct {
+		result1 error
+	}
 	GetBlockByHashStub        func([]byte) (*common.Block, error)
 	getBlockByHashMutex       sync.RWMutex
 	getBlockByHashArgsForCall []struct {
@@ -129,6 +255,18 @@ type PeerLedger struct {
 		result1 *common.BlockchainInfo
 		result2 error
 	}
+	GetBlockchainInfoExtendedStub        func() (*ledger.ExtendedBlockchainInfo, error)
+	getBlockchainInfoExtendedMutex       sync.RWMutex
+	getBlockchainInfoExtendedArgsForCall []struct {
+	}
+	getBlockchainInfoExtendedReturns struct {
+		result1 *ledger.ExtendedBlockchainInfo
+		result2 error
+	}
+	getBlockchainInfoExtendedReturnsOnCall map[int]struct {
+		result1 *ledger.ExtendedBlockchainInfo
+		result2 error
+	}
 	GetBlocksIteratorStub        func(uint64) (ledgera.ResultsIterator, error)
 	getBlocksIteratorMutex       sync.RWMutex
 	getBlocksIteratorArgsForCall []struct {
@@ -154,6 +292,18 @@ type PeerLedger struct {
 		result1 ledger.ConfigHistoryRetriever
 		result2 error
 	}
+	GetLastBlockStub        func() (*common.Block, error)
+	getLastBlockMutex       sync.RWMutex
+	getLastBlockArgsForCall []struct {
+	}
+	getLastBlockReturns struct {
+		result1 *common.Block
+		result2 error
+	}
+	getLastBlockReturnsOnCall map[int]struct {
+		result1 *common.Block
+		result2 error
+	}
 	GetMissingPvtDataTrackerStub        func() (ledger.MissingPvtDataTracker, error)
 	getMissingPvtDataTrackerMutex       sync.RWMutex
 	getMissingPvtDataTrackerArgsForCall []struct {
@@ -166,6 +316,18 @@ type PeerLedger struct {
 		result1 ledger.MissingPvtDataTracker
 		result2 error
 	}
+	GetMostRecentConfigBlockStub        func() (*common.Block, error)
+	getMostRecentConfigBlockMutex       sync.RWMutex
+	getMostRecentConfigBlockArgsForCall []struct {
+	}
+	getMostRecentConfigBlockReturns struct {
+		result1 *common.Block
+		result2 error
+	}
+	getMostRecentConfigBlockReturnsOnCall map[int]struct {
+		result1 *common.Block
+		result2 error
+	}
 	GetPvtDataAndBlockByNumStub        func(uint64, ledger.PvtNsCollFilter) (*ledger.BlockAndPvtData, error)
 	getPvtDataAndBlockByNumMutex       sync.RWMutex
 	getPvtDataAndBlockByNumArgsForCall []struct {
@@ -194,6 +356,31 @@ type PeerLedger struct {
 		result1 []*ledger.TxPvtData
 		result2 error
 	}
+	GetPvtdataReconciliationStatusStub        func() (*ledger.PvtdataReconciliationStatus, error)
+	getPvtdataReconciliationStatusMutex       sync.RWMutex
+	getPvtdataReconciliationStatusArgsForCall []struct {
+	}
+	getPvtdataReconciliationStatusReturns struct {
+		result1 *ledger.PvtdataReconciliationStatus
+		result2 error
+	}
+	getPvtdataReconciliationStatusReturnsOnCall map[int]struct {
+		result1 *ledger.PvtdataReconciliationStatus
+		result2 error
+	}
+	GetPvtdataScheduledForPurgeStub        func(uint64) ([]*ledger.ScheduledPvtdataPurge, error)
+	getPvtdataScheduledForPurgeMutex       sync.RWMutex
+	getPvtdataScheduledForPurgeArgsForCall []struct {
+		arg1 uint64
+	}
+	getPvtdataScheduledForPurgeReturns struct {
+		result1 []*ledger.ScheduledPvtdataPurge
+		result2 error
+	}
+	getPvtdataScheduledForPurgeReturnsOnCall map[int]struct {
+		result1 []*ledger.ScheduledPvtdataPurge
+		result2 error
+	}
 	GetTransactionByIDStub        func(string) (*peer.ProcessedTransaction, error)
 	getTransactionByIDMutex       sync.RWMutex
 	getTransactionByIDArgsForCall []struct {
@@ -207,6 +394,19 @@ type PeerLedger struct {
 		result1 *peer.ProcessedTransaction
 		result2 error
 	}
+	GetTransactionsByIDsStub        func([]string) ([]*peer.ProcessedTransaction, error)
+	getTransactionsByIDsMutex       sync.RWMutex
+	getTransactionsByIDsArgsForCall []struct {
+		arg1 []string
+	}
+	getTransactionsByIDsReturns struct {
+		result1 []*peer.ProcessedTransaction
+		result2 error
+	}
+	getTransactionsByIDsReturnsOnCall map[int]struct {
+		result1 []*peer.ProcessedTransaction
+		result2 error
+	}
 	GetTxValidationCodeByTxIDStub        func(string) (peer.TxValidationCode, uint64, error)
 	getTxValidationCodeByTxIDMutex       sync.RWMutex
 	getTxValidationCodeByTxIDArgsForCall []struct {
@@ -222,6 +422,43 @@ type PeerLedger struct {
 		result2 uint64
 		result3 error
 	}
+	HistoryDBSavepointStub        func() (*ledger.HistoryDBSavepoint, error)
+	historyDBSavepointMutex       sync.RWMutex
+	historyDBSavepointArgsForCall []struct {
+	}
+	historyDBSavepointReturns struct {
+		result1 *ledger.HistoryDBSavepoint
+		result2 error
+	}
+	historyDBSavepointReturnsOnCall map[int]struct {
+		result1 *ledger.HistoryDBSavepoint
+		result2 error
+	}
+	HistoryRebuildStatusStub        func() (*ledger.HistoryRebuildStatus, error)
+	historyRebuildStatusMutex       sync.RWMutex
+	historyRebuildStatusArgsForCall []struct {
+	}
+	historyRebuildStatusReturns struct {
+		result1 *ledger.HistoryRebuildStatus
+		result2 error
+	}
+	historyRebuildStatusReturnsOnCall map[int]struct {
+		result1 *ledger.HistoryRebuildStatus
+		result2 error
+	}
+	MissingPvtDataInfoStub        func(uint64) (ledger.MissingPvtDataInfo, error)
+	missingPvtDataInfoMutex       sync.RWMutex
+	missingPvtDataInfoArgsForCall []struct {
+		arg1 uint64
+	}
+	missingPvtDataInfoReturns struct {
+		result1 ledger.MissingPvtDataInfo
+		result2 error
+	}
+	missingPvtDataInfoReturnsOnCall map[int]struct {
+		result1 ledger.MissingPvtDataInfo
+		result2 error
+	}
 	NewHistoryQueryExecutorStub        func() (ledger.HistoryQueryExecutor, error)
 	newHistoryQueryExecutorMutex       sync.RWMutex
 	newHistoryQueryExecutorArgsForCall []struct {
@@ -259,6 +496,20 @@ type PeerLedger struct {
 		result1 ledger.TxSimulator
 		result2 error
 	}
+	NewTxSimulatorAtHeightStub        func(string, uint64) (ledger.TxSimulator, error)
+	newTxSimulatorAtHeightMutex       sync.RWMutex
+	newTxSimulatorAtHeightArgsForCall []struct {
+		arg1 string
+		arg2 uint64
+	}
+	newTxSimulatorAtHeightReturns struct {
+		result1 ledger.TxSimulator
+		result2 error
+	}
+	newTxSimulatorAtHeightReturnsOnCall map[int]struct {
+		result1 ledger.TxSimulator
+		result2 error
+	}
 	PendingSnapshotRequestsStub        func() ([]uint64, error)
 	pendingSnapshotRequestsMutex       sync.RWMutex
 	pendingSnapshotRequestsArgsForCall []struct {
@@ -271,6 +522,63 @@ type PeerLedger struct {
 		result1 []uint64
 		result2 error
 	}
+	PreviewCommitStub        func(*ledger.BlockAndPvtData) (*ledger.StateDelta, error)
+	previewCommitMutex       sync.RWMutex
+	previewCommitArgsForCall []struct {
+		arg1 *ledger.BlockAndPvtData
+	}
+	previewCommitReturns struct {
+		result1 *ledger.StateDelta
+		result2 error
+	}
+	previewCommitReturnsOnCall map[int]struct {
+		result1 *ledger.StateDelta
+		result2 error
+	}
+	PruneBlocksStub        func(uint64) error
+	pruneBlocksMutex       sync.RWMutex
+	pruneBlocksArgsForCall []struct {
+		arg1 uint64
+	}
+	pruneBlocksReturns struct {
+		result1 error
+	}
+	pruneBlocksReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RegisterCommitListenerStub        func(func(committedBlock *common.Block)) func()
+	registerCommitListenerMutex       sync.RWMutex
+	registerCommitListenerArgsForCall []struct {
+		arg1 func(committedBlock *common.Block)
+	}
+	registerCommitListenerReturns struct {
+		result1 func()
+	}
+	registerCommitListenerReturnsOnCall map[int]struct {
+		result1 func()
+	}
+	RegisterPvtdataPurgeListenerStub        func(func(event *ledger.PvtdataPurgeEvent)) func()
+	registerPvtdataPurgeListenerMutex       sync.RWMutex
+	registerPvtdataPurgeListenerArgsForCall []struct {
+		arg1 func(event *ledger.PvtdataPurgeEvent)
+	}
+	registerPvtdataPurgeListenerReturns struct {
+		result1 func()
+	}
+	registerPvtdataPurgeListenerReturnsOnCall map[int]struct {
+		result1 func()
+	}
+	RollbackHistoryDBStub        func(uint64) error
+	rollbackHistoryDBMutex       sync.RWMutex
+	rollbackHistoryDBArgsForCall []struct {
+		arg1 uint64
+	}
+	rollbackHistoryDBReturns struct {
+		result1 error
+	}
+	rollbackHistoryDBReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SubmitSnapshotRequestStub        func(uint64) error
 	submitSnapshotRequestMutex       sync.RWMutex
 	submitSnapshotRequestArgsForCall []struct {
@@ -295,127 +603,646 @@ type PeerLedger struct {
 		result1 bool
 		result2 error
 	}
+	TxIDsByChaincodeNameStub        func(string) (ledgera.ResultsIterator, error)
+	txIDsByChaincodeNameMutex       sync.RWMutex
+	txIDsByChaincodeNameArgsForCall []struct {
+		arg1 string
+	}
+	txIDsByChaincodeNameReturns struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	txIDsByChaincodeNameReturnsOnCall map[int]struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	TxIDsByEndorserMSPIDStub        func(string) (ledgera.ResultsIterator, error)
+	txIDsByEndorserMSPIDMutex       sync.RWMutex
+	txIDsByEndorserMSPIDArgsForCall []struct {
+		arg1 string
+	}
+	txIDsByEndorserMSPIDReturns struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	txIDsByEndorserMSPIDReturnsOnCall map[int]struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	TxIDsInRangeStub        func(uint64, uint64) (ledgera.ResultsIterator, error)
+	txIDsInRangeMutex       sync.RWMutex
+	txIDsInRangeArgsForCall []struct {
+		arg1 uint64
+		arg2 uint64
+	}
+	txIDsInRangeReturns struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	txIDsInRangeReturnsOnCall map[int]struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}
+	UnfreezeStub        func() error
+	unfreezeMutex       sync.RWMutex
+	unfreezeArgsForCall []struct {
+	}
+	unfreezeReturns struct {
+		result1 error
+	}
+	unfreezeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UpdatePvtDataConfigStub        func(*ledger.PrivateDataConfig) error
+	updatePvtDataConfigMutex       sync.RWMutex
+	updatePvtDataConfigArgsForCall []struct {
+		arg1 *ledger.PrivateDataConfig
+	}
+	updatePvtDataConfigReturns struct {
+		result1 error
+	}
+	updatePvtDataConfigReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *PeerLedger) CancelSnapshotRequest(arg1 uint64) error {
-	fake.cancelSnapshotRequestMutex.Lock()
-	ret, specificReturn := fake.cancelSnapshotRequestReturnsOnCall[len(fake.cancelSnapshotRequestArgsForCall)]
-	fake.cancelSnapshotRequestArgsForCall = append(fake.cancelSnapshotRequestArgsForCall, struct {
-		arg1 uint64
+func (fake *PeerLedger) AppendBlockRaw(arg1 *common.Block) error {
+	fake.appendBlockRawMutex.Lock()
+	ret, specificReturn := fake.appendBlockRawReturnsOnCall[len(fake.appendBlockRawArgsForCall)]
+	fake.appendBlockRawArgsForCall = append(fake.appendBlockRawArgsForCall, struct {
+		arg1 *common.Block
 	}{arg1})
-	fake.recordInvocation("CancelSnapshotRequest", []interface{}{arg1})
-	fake.cancelSnapshotRequestMutex.Unlock()
-	if fake.CancelSnapshotRequestStub != nil {
-		return fake.CancelSnapshotRequestStub(arg1)
+	fake.recordInvocation("AppendBlockRaw", []interface{}{arg1})
+	fake.appendBlockRawMutex.Unlock()
+	if fake.AppendBlockRawStub != nil {
+		return fake.AppendBlockRawStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.cancelSnapshotRequestReturns
+	fakeReturns := fake.appendBlockRawReturns
 	return fakeReturns.result1
 }
 
-func (fake *PeerLedger) CancelSnapshotRequestCallCount() int {
-	fake.cancelSnapshotRequestMutex.RLock()
-	defer fake.cancelSnapshotRequestMutex.RUnlock()
-	return len(fake.cancelSnapshotRequestArgsForCall)
+func (fake *PeerLedger) AppendBlockRawCallCount() int {
+	fake.appendBlockRawMutex.RLock()
+	defer fake.appendBlockRawMutex.RUnlock()
+	return len(fake.appendBlockRawArgsForCall)
 }
 
-func (fake *PeerLedger) CancelSnapshotRequestCalls(stub func(uint64) error) {
-	fake.cancelSnapshotRequestMutex.Lock()
-	defer fake.cancelSnapshotRequestMutex.Unlock()
-	fake.CancelSnapshotRequestStub = stub
+func (fake *PeerLedger) AppendBlockRawCalls(stub func(*common.Block) error) {
+	fake.appendBlockRawMutex.Lock()
+	defer fake.appendBlockRawMutex.Unlock()
+	fake.AppendBlockRawStub = stub
 }
 
-func (fake *PeerLedger) CancelSnapshotRequestArgsForCall(i int) uint64 {
-	fake.cancelSnapshotRequestMutex.RLock()
-	defer fake.cancelSnapshotRequestMutex.RUnlock()
-	argsForCall := fake.cancelSnapshotRequestArgsForCall[i]
+func (fake *PeerLedger) AppendBlockRawArgsForCall(i int) *common.Block {
+	fake.appendBlockRawMutex.RLock()
+	defer fake.appendBlockRawMutex.RUnlock()
+	argsForCall := fake.appendBlockRawArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *PeerLedger) CancelSnapshotRequestReturns(result1 error) {
-	fake.cancelSnapshotRequestMutex.Lock()
-	defer fake.cancelSnapshotRequestMutex.Unlock()
-	fake.CancelSnapshotRequestStub = nil
-	fake.cancelSnapshotRequestReturns = struct {
+func (fake *PeerLedger) AppendBlockRawReturns(result1 error) {
+	fake.appendBlockRawMutex.Lock()
+	defer fake.appendBlockRawMutex.Unlock()
+	fake.AppendBlockRawStub = nil
+	fake.appendBlockRawReturns = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *PeerLedger) CancelSnapshotRequestReturnsOnCall(i int, result1 error) {
-	fake.cancelSnapshotRequestMutex.Lock()
-	defer fake.cancelSnapshotRequestMutex.Unlock()
-	fake.CancelSnapshotRequestStub = nil
-	if fake.cancelSnapshotRequestReturnsOnCall == nil {
-		fake.cancelSnapshotRequestReturnsOnCall = make(map[int]struct {
+func (fake *PeerLedger) AppendBlockRawReturnsOnCall(i int, result1 error) {
+	fake.appendBlockRawMutex.Lock()
+	defer fake.appendBlockRawMutex.Unlock()
+	fake.AppendBlockRawStub = nil
+	if fake.appendBlockRawReturnsOnCall == nil {
+		fake.appendBlockRawReturnsOnCall = make(map[int]struct {
 			result1 error
 		})
 	}
-	fake.cancelSnapshotRequestReturnsOnCall[i] = struct {
+	fake.appendBlockRawReturnsOnCall[i] = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *PeerLedger) Close() {
-	fake.closeMutex.Lock()
-	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
-	}{})
-	fake.recordInvocation("Close", []interface{}{})
-	fake.closeMutex.Unlock()
-	if fake.CloseStub != nil {
-		fake.CloseStub()
-	}
-}
-
-func (fake *PeerLedger) CloseCallCount() int {
-	fake.closeMutex.RLock()
-	defer fake.closeMutex.RUnlock()
-	return len(fake.closeArgsForCall)
-}
-
-func (fake *PeerLedger) CloseCalls(stub func()) {
-	fake.closeMutex.Lock()
-	defer fake.closeMutex.Unlock()
-	fake.CloseStub = stub
-}
-
-func (fake *PeerLedger) CommitLegacy(arg1 *ledger.BlockAndPvtData, arg2 *ledger.CommitOptions) error {
-	fake.commitLegacyMutex.Lock()
-	ret, specificReturn := fake.commitLegacyReturnsOnCall[len(fake.commitLegacyArgsForCall)]
-	fake.commitLegacyArgsForCall = append(fake.commitLegacyArgsForCall, struct {
-		arg1 *ledger.BlockAndPvtData
-		arg2 *ledger.CommitOptions
-	}{arg1, arg2})
-	fake.recordInvocation("CommitLegacy", []interface{}{arg1, arg2})
-	fake.commitLegacyMutex.Unlock()
-	if fake.CommitLegacyStub != nil {
-		return fake.CommitLegacyStub(arg1, arg2)
+func (fake *PeerLedger) ArchiveBlocks(arg1 uint64) error {
+	fake.archiveBlocksMutex.Lock()
+	ret, specificReturn := fake.archiveBlocksReturnsOnCall[len(fake.archiveBlocksArgsForCall)]
+	fake.archiveBlocksArgsForCall = append(fake.archiveBlocksArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	fake.recordInvocation("ArchiveBlocks", []interface{}{arg1})
+	fake.archiveBlocksMutex.Unlock()
+	if fake.ArchiveBlocksStub != nil {
+		return fake.ArchiveBlocksStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.commitLegacyReturns
+	fakeReturns := fake.archiveBlocksReturns
 	return fakeReturns.result1
 }
 
-func (fake *PeerLedger) CommitLegacyCallCount() int {
-	fake.commitLegacyMutex.RLock()
-	defer fake.commitLegacyMutex.RUnlock()
-	return len(fake.commitLegacyArgsForCall)
+func (fake *PeerLedger) ArchiveBlocksCallCount() int {
+	fake.archiveBlocksMutex.RLock()
+	defer fake.archiveBlocksMutex.RUnlock()
+	return len(fake.archiveBlocksArgsForCall)
 }
 
-func (fake *PeerLedger) CommitLegacyCalls(stub func(*ledger.BlockAndPvtData, *ledger.CommitOptions) error) {
-	fake.commitLegacyMutex.Lock()
-	defer fake.commitLegacyMutex.Unlock()
-	fake.CommitLegacyStub = stub
+func (fake *PeerLedger) ArchiveBlocksCalls(stub func(uint64) error) {
+	fake.archiveBlocksMutex.Lock()
+	defer fake.archiveBlocksMutex.Unlock()
+	fake.ArchiveBlocksStub = stub
 }
 
-func (fake *PeerLedger) CommitLegacyArgsForCall(i int) (*ledger.BlockAndPvtData, *ledger.CommitOptions) {
-	fake.commitLegacyMutex.RLock()
-	defer fake.commitLegacyMutex.RUnlock()
+func (fake *PeerLedger) ArchiveBlocksArgsForCall(i int) uint64 {
+	fake.archiveBlocksMutex.RLock()
+	defer fake.archiveBlocksMutex.RUnlock()
+	argsForCall := fake.archiveBlocksArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) ArchiveBlocksReturns(result1 error) {
+	fake.archiveBlocksMutex.Lock()
+	defer fake.archiveBlocksMutex.Unlock()
+	fake.ArchiveBlocksStub = nil
+	fake.archiveBlocksReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) ArchiveBlocksReturnsOnCall(i int, result1 error) {
+	fake.archiveBlocksMutex.Lock()
+	defer fake.archiveBlocksMutex.Unlock()
+	fake.ArchiveBlocksStub = nil
+	if fake.archiveBlocksReturnsOnCall == nil {
+		fake.archiveBlocksReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.archiveBlocksReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) Backup(arg1 string) error {
+	fake.backupMutex.Lock()
+	ret, specificReturn := fake.backupReturnsOnCall[len(fake.backupArgsForCall)]
+	fake.backupArgsForCall = append(fake.backupArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Backup", []interface{}{arg1})
+	fake.backupMutex.Unlock()
+	if fake.BackupStub != nil {
+		return fake.BackupStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.backupReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) BackupCallCount() int {
+	fake.backupMutex.RLock()
+	defer fake.backupMutex.RUnlock()
+	return len(fake.backupArgsForCall)
+}
+
+func (fake *PeerLedger) BackupCalls(stub func(string) error) {
+	fake.backupMutex.Lock()
+	defer fake.backupMutex.Unlock()
+	fake.BackupStub = stub
+}
+
+func (fake *PeerLedger) BackupArgsForCall(i int) string {
+	fake.backupMutex.RLock()
+	defer fake.backupMutex.RUnlock()
+	argsForCall := fake.backupArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) BackupReturns(result1 error) {
+	fake.backupMutex.Lock()
+	defer fake.backupMutex.Unlock()
+	fake.BackupStub = nil
+	fake.backupReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) BackupReturnsOnCall(i int, result1 error) {
+	fake.backupMutex.Lock()
+	defer fake.backupMutex.Unlock()
+	fake.BackupStub = nil
+	if fake.backupReturnsOnCall == nil {
+		fake.backupReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.backupReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) BlockStoreCheckpointInfo() (*ledger.BlockStoreCheckpoint, error) {
+	fake.blockStoreCheckpointInfoMutex.Lock()
+	ret, specificReturn := fake.blockStoreCheckpointInfoReturnsOnCall[len(fake.blockStoreCheckpointInfoArgsForCall)]
+	fake.blockStoreCheckpointInfoArgsForCall = append(fake.blockStoreCheckpointInfoArgsForCall, struct {
+	}{})
+	fake.recordInvocation("BlockStoreCheckpointInfo", []interface{}{})
+	fake.blockStoreCheckpointInfoMutex.Unlock()
+	if fake.BlockStoreCheckpointInfoStub != nil {
+		return fake.BlockStoreCheckpointInfoStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.blockStoreCheckpointInfoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) BlockStoreCheckpointInfoCallCount() int {
+	fake.blockStoreCheckpointInfoMutex.RLock()
+	defer fake.blockStoreCheckpointInfoMutex.RUnlock()
+	return len(fake.blockStoreCheckpointInfoArgsForCall)
+}
+
+func (fake *PeerLedger) BlockStoreCheckpointInfoCalls(stub func() (*ledger.BlockStoreCheckpoint, error)) {
+	fake.blockStoreCheckpointInfoMutex.Lock()
+	defer fake.blockStoreCheckpointInfoMutex.Unlock()
+	fake.BlockStoreCheckpointInfoStub = stub
+}
+
+func (fake *PeerLedger) BlockStoreCheckpointInfoReturns(result1 *ledger.BlockStoreCheckpoint, result2 error) {
+	fake.blockStoreCheckpointInfoMutex.Lock()
+	defer fake.blockStoreCheckpointInfoMutex.Unlock()
+	fake.BlockStoreCheckpointInfoStub = nil
+	fake.blockStoreCheckpointInfoReturns = struct {
+		result1 *ledger.BlockStoreCheckpoint
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) BlockStoreCheckpointInfoReturnsOnCall(i int, result1 *ledger.BlockStoreCheckpoint, result2 error) {
+	fake.blockStoreCheckpointInfoMutex.Lock()
+	defer fake.blockStoreCheckpointInfoMutex.Unlock()
+	fake.BlockStoreCheckpointInfoStub = nil
+	if fake.blockStoreCheckpointInfoReturnsOnCall == nil {
+		fake.blockStoreCheckpointInfoReturnsOnCall = make(map[int]struct {
+			result1 *ledger.BlockStoreCheckpoint
+			result2 error
+		})
+	}
+	fake.blockStoreCheckpointInfoReturnsOnCall[i] = struct {
+		result1 *ledger.BlockStoreCheckpoint
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) CancelSnapshotRequest(arg1 uint64) error {
+	fake.cancelSnapshotRequestMutex.Lock()
+	ret, specificReturn := fake.cancelSnapshotRequestReturnsOnCall[len(fake.cancelSnapshotRequestArgsForCall)]
+	fake.cancelSnapshotRequestArgsForCall = append(fake.cancelSnapshotRequestArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	fake.recordInvocation("CancelSnapshotRequest", []interface{}{arg1})
+	fake.cancelSnapshotRequestMutex.Unlock()
+	if fake.CancelSnapshotRequestStub != nil {
+		return fake.CancelSnapshotRequestStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.cancelSnapshotRequestReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) CancelSnapshotRequestCallCount() int {
+	fake.cancelSnapshotRequestMutex.RLock()
+	defer fake.cancelSnapshotRequestMutex.RUnlock()
+	return len(fake.cancelSnapshotRequestArgsForCall)
+}
+
+func (fake *PeerLedger) CancelSnapshotRequestCalls(stub func(uint64) error) {
+	fake.cancelSnapshotRequestMutex.Lock()
+	defer fake.cancelSnapshotRequestMutex.Unlock()
+	fake.CancelSnapshotRequestStub = stub
+}
+
+func (fake *PeerLedger) CancelSnapshotRequestArgsForCall(i int) uint64 {
+	fake.cancelSnapshotRequestMutex.RLock()
+	defer fake.cancelSnapshotRequestMutex.RUnlock()
+	argsForCall := fake.cancelSnapshotRequestArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) CancelSnapshotRequestReturns(result1 error) {
+	fake.cancelSnapshotRequestMutex.Lock()
+	defer fake.cancelSnapshotRequestMutex.Unlock()
+	fake.CancelSnapshotRequestStub = nil
+	fake.cancelSnapshotRequestReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) CancelSnapshotRequestReturnsOnCall(i int, result1 error) {
+	fake.cancelSnapshotRequestMutex.Lock()
+	defer fake.cancelSnapshotRequestMutex.Unlock()
+	fake.CancelSnapshotRequestStub = nil
+	if fake.cancelSnapshotRequestReturnsOnCall == nil {
+		fake.cancelSnapshotRequestReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.cancelSnapshotRequestReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) CatchUpHistoryDB() error {
+	fake.catchUpHistoryDBMutex.Lock()
+	ret, specificReturn := fake.catchUpHistoryDBReturnsOnCall[len(fake.catchUpHistoryDBArgsForCall)]
+	fake.catchUpHistoryDBArgsForCall = append(fake.catchUpHistoryDBArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CatchUpHistoryDB", []interface{}{})
+	fake.catchUpHistoryDBMutex.Unlock()
+	if fake.CatchUpHistoryDBStub != nil {
+		return fake.CatchUpHistoryDBStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.catchUpHistoryDBReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) CatchUpHistoryDBCallCount() int {
+	fake.catchUpHistoryDBMutex.RLock()
+	defer fake.catchUpHistoryDBMutex.RUnlock()
+	return len(fake.catchUpHistoryDBArgsForCall)
+}
+
+func (fake *PeerLedger) CatchUpHistoryDBCalls(stub func() error) {
+	fake.catchUpHistoryDBMutex.Lock()
+	defer fake.catchUpHistoryDBMutex.Unlock()
+	fake.CatchUpHistoryDBStub = stub
+}
+
+func (fake *PeerLedger) CatchUpHistoryDBReturns(result1 error) {
+	fake.catchUpHistoryDBMutex.Lock()
+	defer fake.catchUpHistoryDBMutex.Unlock()
+	fake.CatchUpHistoryDBStub = nil
+	fake.catchUpHistoryDBReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) CatchUpHistoryDBReturnsOnCall(i int, result1 error) {
+	fake.catchUpHistoryDBMutex.Lock()
+	defer fake.catchUpHistoryDBMutex.Unlock()
+	fake.CatchUpHistoryDBStub = nil
+	if fake.catchUpHistoryDBReturnsOnCall == nil {
+		fake.catchUpHistoryDBReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.catchUpHistoryDBReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) ChannelID() (string, error) {
+	fake.channelIDMutex.Lock()
+	ret, specificReturn := fake.channelIDReturnsOnCall[len(fake.channelIDArgsForCall)]
+	fake.channelIDArgsForCall = append(fake.channelIDArgsForCall, struct {
+	}{})
+	fake.recordInvocation("ChannelID", []interface{}{})
+	fake.channelIDMutex.Unlock()
+	if fake.ChannelIDStub != nil {
+		return fake.ChannelIDStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.channelIDReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) ChannelIDCallCount() int {
+	fake.channelIDMutex.RLock()
+	defer fake.channelIDMutex.RUnlock()
+	return len(fake.channelIDArgsForCall)
+}
+
+func (fake *PeerLedger) ChannelIDCalls(stub func() (string, error)) {
+	fake.channelIDMutex.Lock()
+	defer fake.channelIDMutex.Unlock()
+	fake.ChannelIDStub = stub
+}
+
+func (fake *PeerLedger) ChannelIDReturns(result1 string, result2 error) {
+	fake.channelIDMutex.Lock()
+	defer fake.channelIDMutex.Unlock()
+	fake.ChannelIDStub = nil
+	fake.channelIDReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) ChannelIDReturnsOnCall(i int, result1 string, result2 error) {
+	fake.channelIDMutex.Lock()
+	defer fake.channelIDMutex.Unlock()
+	fake.ChannelIDStub = nil
+	if fake.channelIDReturnsOnCall == nil {
+		fake.channelIDReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.channelIDReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) Close() {
+	fake.closeMutex.Lock()
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if fake.CloseStub != nil {
+		fake.CloseStub()
+	}
+}
+
+func (fake *PeerLedger) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *PeerLedger) CloseCalls(stub func()) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
+func (fake *PeerLedger) CollectionConfigAt(arg1 string, arg2 uint64) (*peer.CollectionConfigPackage, error) {
+	fake.collectionConfigAtMutex.Lock()
+	ret, specificReturn := fake.collectionConfigAtReturnsOnCall[len(fake.collectionConfigAtArgsForCall)]
+	fake.collectionConfigAtArgsForCall = append(fake.collectionConfigAtArgsForCall, struct {
+		arg1 string
+		arg2 uint64
+	}{arg1, arg2})
+	fake.recordInvocation("CollectionConfigAt", []interface{}{arg1, arg2})
+	fake.collectionConfigAtMutex.Unlock()
+	if fake.CollectionConfigAtStub != nil {
+		return fake.CollectionConfigAtStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.collectionConfigAtReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) CollectionConfigAtCallCount() int {
+	fake.collectionConfigAtMutex.RLock()
+	defer fake.collectionConfigAtMutex.RUnlock()
+	return len(fake.collectionConfigAtArgsForCall)
+}
+
+func (fake *PeerLedger) CollectionConfigAtCalls(stub func(string, uint64) (*peer.CollectionConfigPackage, error)) {
+	fake.collectionConfigAtMutex.Lock()
+	defer fake.collectionConfigAtMutex.Unlock()
+	fake.CollectionConfigAtStub = stub
+}
+
+func (fake *PeerLedger) CollectionConfigAtArgsForCall(i int) (string, uint64) {
+	fake.collectionConfigAtMutex.RLock()
+	defer fake.collectionConfigAtMutex.RUnlock()
+	argsForCall := fake.collectionConfigAtArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *PeerLedger) CollectionConfigAtReturns(result1 *peer.CollectionConfigPackage, result2 error) {
+	fake.collectionConfigAtMutex.Lock()
+	defer fake.collectionConfigAtMutex.Unlock()
+	fake.CollectionConfigAtStub = nil
+	fake.collectionConfigAtReturns = struct {
+		result1 *peer.CollectionConfigPackage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) CollectionConfigAtReturnsOnCall(i int, result1 *peer.CollectionConfigPackage, result2 error) {
+	fake.collectionConfigAtMutex.Lock()
+	defer fake.collectionConfigAtMutex.Unlock()
+	fake.CollectionConfigAtStub = nil
+	if fake.collectionConfigAtReturnsOnCall == nil {
+		fake.collectionConfigAtReturnsOnCall = make(map[int]struct {
+			result1 *peer.CollectionConfigPackage
+			result2 error
+		})
+	}
+	fake.collectionConfigAtReturnsOnCall[i] = struct {
+		result1 *peer.CollectionConfigPackage
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) CommitBackpressure() float64 {
+	fake.commitBackpressureMutex.Lock()
+	ret, specificReturn := fake.commitBackpressureReturnsOnCall[len(fake.commitBackpressureArgsForCall)]
+	fake.commitBackpressureArgsForCall = append(fake.commitBackpressureArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CommitBackpressure", []interface{}{})
+	fake.commitBackpressureMutex.Unlock()
+	if fake.CommitBackpressureStub != nil {
+		return fake.CommitBackpressureStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.commitBackpressureReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) CommitBackpressureCallCount() int {
+	fake.commitBackpressureMutex.RLock()
+	defer fake.commitBackpressureMutex.RUnlock()
+	return len(fake.commitBackpressureArgsForCall)
+}
+
+func (fake *PeerLedger) CommitBackpressureCalls(stub func() float64) {
+	fake.commitBackpressureMutex.Lock()
+	defer fake.commitBackpressureMutex.Unlock()
+	fake.CommitBackpressureStub = stub
+}
+
+func (fake *PeerLedger) CommitBackpressureReturns(result1 float64) {
+	fake.commitBackpressureMutex.Lock()
+	defer fake.commitBackpressureMutex.Unlock()
+	fake.CommitBackpressureStub = nil
+	fake.commitBackpressureReturns = struct {
+		result1 float64
+	}{result1}
+}
+
+func (fake *PeerLedger) CommitBackpressureReturnsOnCall(i int, result1 float64) {
+	fake.commitBackpressureMutex.Lock()
+	defer fake.commitBackpressureMutex.Unlock()
+	fake.CommitBackpressureStub = nil
+	if fake.commitBackpressureReturnsOnCall == nil {
+		fake.commitBackpressureReturnsOnCall = make(map[int]struct {
+			result1 float64
+		})
+	}
+	fake.commitBackpressureReturnsOnCall[i] = struct {
+		result1 float64
+	}{result1}
+}
+
+func (fake *PeerLedger) CommitLegacy(arg1 *ledger.BlockAndPvtData, arg2 *ledger.CommitOptions) error {
+	fake.commitLegacyMutex.Lock()
+	ret, specificReturn := fake.commitLegacyReturnsOnCall[len(fake.commitLegacyArgsForCall)]
+	fake.commitLegacyArgsForCall = append(fake.commitLegacyArgsForCall, struct {
+		arg1 *ledger.BlockAndPvtData
+		arg2 *ledger.CommitOptions
+	}{arg1, arg2})
+	fake.recordInvocation("CommitLegacy", []interface{}{arg1, arg2})
+	fake.commitLegacyMutex.Unlock()
+	if fake.CommitLegacyStub != nil {
+		return fake.CommitLegacyStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.commitLegacyReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) CommitLegacyCallCount() int {
+	fake.commitLegacyMutex.RLock()
+	defer fake.commitLegacyMutex.RUnlock()
+	return len(fake.commitLegacyArgsForCall)
+}
+
+func (fake *PeerLedger) CommitLegacyCalls(stub func(*ledger.BlockAndPvtData, *ledger.CommitOptions) error) {
+	fake.commitLegacyMutex.Lock()
+	defer fake.commitLegacyMutex.Unlock()
+	fake.CommitLegacyStub = stub
+}
+
+func (fake *PeerLedger) CommitLegacyArgsForCall(i int) (*ledger.BlockAndPvtData, *ledger.CommitOptions) {
+	fake.commitLegacyMutex.RLock()
+	defer fake.commitLegacyMutex.RUnlock()
 	argsForCall := fake.commitLegacyArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2
 }
@@ -569,8 +1396,126 @@ func (fake *PeerLedger) CommitPvtDataOfOldBlocksReturnsOnCall(i int, result1 []*
 			result2 error
 		})
 	}
-	fake.commitPvtDataOfOldBlocksReturnsOnCall[i] = struct {
-		result1 []*ledger.PvtdataHashMismatch
+	fake.commitPvtDataOfOldBlocksReturnsOnCall[i] = struct {
+		result1 []*ledger.PvtdataHashMismatch
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) CompactPvtdataStore() (int64, error) {
+	fake.compactPvtdataStoreMutex.Lock()
+	ret, specificReturn := fake.compactPvtdataStoreReturnsOnCall[len(fake.compactPvtdataStoreArgsForCall)]
+	fake.compactPvtdataStoreArgsForCall = append(fake.compactPvtdataStoreArgsForCall, struct {
+	}{})
+	fake.recordInvocation("CompactPvtdataStore", []interface{}{})
+	fake.compactPvtdataStoreMutex.Unlock()
+	if fake.CompactPvtdataStoreStub != nil {
+		return fake.CompactPvtdataStoreStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.compactPvtdataStoreReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) CompactPvtdataStoreCallCount() int {
+	fake.compactPvtdataStoreMutex.RLock()
+	defer fake.compactPvtdataStoreMutex.RUnlock()
+	return len(fake.compactPvtdataStoreArgsForCall)
+}
+
+func (fake *PeerLedger) CompactPvtdataStoreCalls(stub func() (int64, error)) {
+	fake.compactPvtdataStoreMutex.Lock()
+	defer fake.compactPvtdataStoreMutex.Unlock()
+	fake.CompactPvtdataStoreStub = stub
+}
+
+func (fake *PeerLedger) CompactPvtdataStoreReturns(result1 int64, result2 error) {
+	fake.compactPvtdataStoreMutex.Lock()
+	defer fake.compactPvtdataStoreMutex.Unlock()
+	fake.CompactPvtdataStoreStub = nil
+	fake.compactPvtdataStoreReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) CompactPvtdataStoreReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.compactPvtdataStoreMutex.Lock()
+	defer fake.compactPvtdataStoreMutex.Unlock()
+	fake.CompactPvtdataStoreStub = nil
+	if fake.compactPvtdataStoreReturnsOnCall == nil {
+		fake.compactPvtdataStoreReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.compactPvtdataStoreReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) ComputeStateHash(arg1 uint64) ([]byte, error) {
+	fake.computeStateHashMutex.Lock()
+	ret, specificReturn := fake.computeStateHashReturnsOnCall[len(fake.computeStateHashArgsForCall)]
+	fake.computeStateHashArgsForCall = append(fake.computeStateHashArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	fake.recordInvocation("ComputeStateHash", []interface{}{arg1})
+	fake.computeStateHashMutex.Unlock()
+	if fake.ComputeStateHashStub != nil {
+		return fake.ComputeStateHashStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.computeStateHashReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) ComputeStateHashCallCount() int {
+	fake.computeStateHashMutex.RLock()
+	defer fake.computeStateHashMutex.RUnlock()
+	return len(fake.computeStateHashArgsForCall)
+}
+
+func (fake *PeerLedger) ComputeStateHashCalls(stub func(uint64) ([]byte, error)) {
+	fake.computeStateHashMutex.Lock()
+	defer fake.computeStateHashMutex.Unlock()
+	fake.ComputeStateHashStub = stub
+}
+
+func (fake *PeerLedger) ComputeStateHashArgsForCall(i int) uint64 {
+	fake.computeStateHashMutex.RLock()
+	defer fake.computeStateHashMutex.RUnlock()
+	argsForCall := fake.computeStateHashArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) ComputeStateHashReturns(result1 []byte, result2 error) {
+	fake.computeStateHashMutex.Lock()
+	defer fake.computeStateHashMutex.Unlock()
+	fake.ComputeStateHashStub = nil
+	fake.computeStateHashReturns = struct {
+		result1 []byte
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) ComputeStateHashReturnsOnCall(i int, result1 []byte, result2 error) {
+	fake.computeStateHashMutex.Lock()
+	defer fake.computeStateHashMutex.Unlock()
+	fake.ComputeStateHashStub = nil
+	if fake.computeStateHashReturnsOnCall == nil {
+		fake.computeStateHashReturnsOnCall = make(map[int]struct {
+			result1 []byte
+			result2 error
+		})
+	}
+	fake.computeStateHashReturnsOnCall[i] = struct {
+		result1 []byte
 		result2 error
 	}{result1, result2}
 }
@@ -638,6 +1583,58 @@ func (fake *PeerLedger) DoesPvtDataInfoExistReturnsOnCall(i int, result1 bool, r
 	}{result1, result2}
 }
 
+func (fake *PeerLedger) Freeze() error {
+	fake.freezeMutex.Lock()
+	ret, specificReturn := fake.freezeReturnsOnCall[len(fake.freezeArgsForCall)]
+	fake.freezeArgsForCall = append(fake.freezeArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Freeze", []interface{}{})
+	fake.freezeMutex.Unlock()
+	if fake.FreezeStub != nil {
+		return fake.FreezeStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.freezeReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) FreezeCallCount() int {
+	fake.freezeMutex.RLock()
+	defer fake.freezeMutex.RUnlock()
+	return len(fake.freezeArgsForCall)
+}
+
+func (fake *PeerLedger) FreezeCalls(stub func() error) {
+	fake.freezeMutex.Lock()
+	defer fake.freezeMutex.Unlock()
+	fake.FreezeStub = stub
+}
+
+func (fake *PeerLedger) FreezeReturns(result1 error) {
+	fake.freezeMutex.Lock()
+	defer fake.freezeMutex.Unlock()
+	fake.FreezeStub = nil
+	fake.freezeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) FreezeReturnsOnCall(i int, result1 error) {
+	fake.freezeMutex.Lock()
+	defer fake.freezeMutex.Unlock()
+	fake.FreezeStub = nil
+	if fake.freezeReturnsOnCall == nil {
+		fake.freezeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.freezeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *PeerLedger) GetBlockByHash(arg1 []byte) (*common.Block, error) {
 	var arg1Copy []byte
 	if arg1 != nil {
@@ -887,6 +1884,61 @@ func (fake *PeerLedger) GetBlockchainInfoReturnsOnCall(i int, result1 *common.Bl
 	}{result1, result2}
 }
 
+func (fake *PeerLedger) GetBlockchainInfoExtended() (*ledger.ExtendedBlockchainInfo, error) {
+	fake.getBlockchainInfoExtendedMutex.Lock()
+	ret, specificReturn := fake.getBlockchainInfoExtendedReturnsOnCall[len(fake.getBlockchainInfoExtendedArgsForCall)]
+	fake.getBlockchainInfoExtendedArgsForCall = append(fake.getBlockchainInfoExtendedArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetBlockchainInfoExtended", []interface{}{})
+	fake.getBlockchainInfoExtendedMutex.Unlock()
+	if fake.GetBlockchainInfoExtendedStub != nil {
+		return fake.GetBlockchainInfoExtendedStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getBlockchainInfoExtendedReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) GetBlockchainInfoExtendedCallCount() int {
+	fake.getBlockchainInfoExtendedMutex.RLock()
+	defer fake.getBlockchainInfoExtendedMutex.RUnlock()
+	return len(fake.getBlockchainInfoExtendedArgsForCall)
+}
+
+func (fake *PeerLedger) GetBlockchainInfoExtendedCalls(stub func() (*ledger.ExtendedBlockchainInfo, error)) {
+	fake.getBlockchainInfoExtendedMutex.Lock()
+	defer fake.getBlockchainInfoExtendedMutex.Unlock()
+	fake.GetBlockchainInfoExtendedStub = stub
+}
+
+func (fake *PeerLedger) GetBlockchainInfoExtendedReturns(result1 *ledger.ExtendedBlockchainInfo, result2 error) {
+	fake.getBlockchainInfoExtendedMutex.Lock()
+	defer fake.getBlockchainInfoExtendedMutex.Unlock()
+	fake.GetBlockchainInfoExtendedStub = nil
+	fake.getBlockchainInfoExtendedReturns = struct {
+		result1 *ledger.ExtendedBlockchainInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) GetBlockchainInfoExtendedReturnsOnCall(i int, result1 *ledger.ExtendedBlockchainInfo, result2 error) {
+	fake.getBlockchainInfoExtendedMutex.Lock()
+	defer fake.getBlockchainInfoExtendedMutex.Unlock()
+	fake.GetBlockchainInfoExtendedStub = nil
+	if fake.getBlockchainInfoExtendedReturnsOnCall == nil {
+		fake.getBlockchainInfoExtendedReturnsOnCall = make(map[int]struct {
+			result1 *ledger.ExtendedBlockchainInfo
+			result2 error
+		})
+	}
+	fake.getBlockchainInfoExtendedReturnsOnCall[i] = struct {
+		result1 *ledger.ExtendedBlockchainInfo
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *PeerLedger) GetBlocksIterator(arg1 uint64) (ledgera.ResultsIterator, error) {
 	fake.getBlocksIteratorMutex.Lock()
 	ret, specificReturn := fake.getBlocksIteratorReturnsOnCall[len(fake.getBlocksIteratorArgsForCall)]
@@ -1005,6 +2057,61 @@ func (fake *PeerLedger) GetConfigHistoryRetrieverReturnsOnCall(i int, result1 le
 	}{result1, result2}
 }
 
+func (fake *PeerLedger) GetLastBlock() (*common.Block, error) {
+	fake.getLastBlockMutex.Lock()
+	ret, specificReturn := fake.getLastBlockReturnsOnCall[len(fake.getLastBlockArgsForCall)]
+	fake.getLastBlockArgsForCall = append(fake.getLastBlockArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetLastBlock", []interface{}{})
+	fake.getLastBlockMutex.Unlock()
+	if fake.GetLastBlockStub != nil {
+		return fake.GetLastBlockStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getLastBlockReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) GetLastBlockCallCount() int {
+	fake.getLastBlockMutex.RLock()
+	defer fake.getLastBlockMutex.RUnlock()
+	return len(fake.getLastBlockArgsForCall)
+}
+
+func (fake *PeerLedger) GetLastBlockCalls(stub func() (*common.Block, error)) {
+	fake.getLastBlockMutex.Lock()
+	defer fake.getLastBlockMutex.Unlock()
+	fake.GetLastBlockStub = stub
+}
+
+func (fake *PeerLedger) GetLastBlockReturns(result1 *common.Block, result2 error) {
+	fake.getLastBlockMutex.Lock()
+	defer fake.getLastBlockMutex.Unlock()
+	fake.GetLastBlockStub = nil
+	fake.getLastBlockReturns = struct {
+		result1 *common.Block
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) GetLastBlockReturnsOnCall(i int, result1 *common.Block, result2 error) {
+	fake.getLastBlockMutex.Lock()
+	defer fake.getLastBlockMutex.Unlock()
+	fake.GetLastBlockStub = nil
+	if fake.getLastBlockReturnsOnCall == nil {
+		fake.getLastBlockReturnsOnCall = make(map[int]struct {
+			result1 *common.Block
+			result2 error
+		})
+	}
+	fake.getLastBlockReturnsOnCall[i] = struct {
+		result1 *common.Block
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *PeerLedger) GetMissingPvtDataTracker() (ledger.MissingPvtDataTracker, error) {
 	fake.getMissingPvtDataTrackerMutex.Lock()
 	ret, specificReturn := fake.getMissingPvtDataTrackerReturnsOnCall[len(fake.getMissingPvtDataTrackerArgsForCall)]
@@ -1060,6 +2167,61 @@ func (fake *PeerLedger) GetMissingPvtDataTrackerReturnsOnCall(i int, result1 led
 	}{result1, result2}
 }
 
+func (fake *PeerLedger) GetMostRecentConfigBlock() (*common.Block, error) {
+	fake.getMostRecentConfigBlockMutex.Lock()
+	ret, specificReturn := fake.getMostRecentConfigBlockReturnsOnCall[len(fake.getMostRecentConfigBlockArgsForCall)]
+	fake.getMostRecentConfigBlockArgsForCall = append(fake.getMostRecentConfigBlockArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetMostRecentConfigBlock", []interface{}{})
+	fake.getMostRecentConfigBlockMutex.Unlock()
+	if fake.GetMostRecentConfigBlockStub != nil {
+		return fake.GetMostRecentConfigBlockStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getMostRecentConfigBlockReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) GetMostRecentConfigBlockCallCount() int {
+	fake.getMostRecentConfigBlockMutex.RLock()
+	defer fake.getMostRecentConfigBlockMutex.RUnlock()
+	return len(fake.getMostRecentConfigBlockArgsForCall)
+}
+
+func (fake *PeerLedger) GetMostRecentConfigBlockCalls(stub func() (*common.Block, error)) {
+	fake.getMostRecentConfigBlockMutex.Lock()
+	defer fake.getMostRecentConfigBlockMutex.Unlock()
+	fake.GetMostRecentConfigBlockStub = stub
+}
+
+func (fake *PeerLedger) GetMostRecentConfigBlockReturns(result1 *common.Block, result2 error) {
+	fake.getMostRecentConfigBlockMutex.Lock()
+	defer fake.getMostRecentConfigBlockMutex.Unlock()
+	fake.GetMostRecentConfigBlockStub = nil
+	fake.getMostRecentConfigBlockReturns = struct {
+		result1 *common.Block
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) GetMostRecentConfigBlockReturnsOnCall(i int, result1 *common.Block, result2 error) {
+	fake.getMostRecentConfigBlockMutex.Lock()
+	defer fake.getMostRecentConfigBlockMutex.Unlock()
+	fake.GetMostRecentConfigBlockStub = nil
+	if fake.getMostRecentConfigBlockReturnsOnCall == nil {
+		fake.getMostRecentConfigBlockReturnsOnCall = make(map[int]struct {
+			result1 *common.Block
+			result2 error
+		})
+	}
+	fake.getMostRecentConfigBlockReturnsOnCall[i] = struct {
+		result1 *common.Block
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *PeerLedger) GetPvtDataAndBlockByNum(arg1 uint64, arg2 ledger.PvtNsCollFilter) (*ledger.BlockAndPvtData, error) {
 	fake.getPvtDataAndBlockByNumMutex.Lock()
 	ret, specificReturn := fake.getPvtDataAndBlockByNumReturnsOnCall[len(fake.getPvtDataAndBlockByNumArgsForCall)]
@@ -1188,6 +2350,124 @@ func (fake *PeerLedger) GetPvtDataByNumReturnsOnCall(i int, result1 []*ledger.Tx
 	}{result1, result2}
 }
 
+func (fake *PeerLedger) GetPvtdataReconciliationStatus() (*ledger.PvtdataReconciliationStatus, error) {
+	fake.getPvtdataReconciliationStatusMutex.Lock()
+	ret, specificReturn := fake.getPvtdataReconciliationStatusReturnsOnCall[len(fake.getPvtdataReconciliationStatusArgsForCall)]
+	fake.getPvtdataReconciliationStatusArgsForCall = append(fake.getPvtdataReconciliationStatusArgsForCall, struct {
+	}{})
+	fake.recordInvocation("GetPvtdataReconciliationStatus", []interface{}{})
+	fake.getPvtdataReconciliationStatusMutex.Unlock()
+	if fake.GetPvtdataReconciliationStatusStub != nil {
+		return fake.GetPvtdataReconciliationStatusStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getPvtdataReconciliationStatusReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) GetPvtdataReconciliationStatusCallCount() int {
+	fake.getPvtdataReconciliationStatusMutex.RLock()
+	defer fake.getPvtdataReconciliationStatusMutex.RUnlock()
+	return len(fake.getPvtdataReconciliationStatusArgsForCall)
+}
+
+func (fake *PeerLedger) GetPvtdataReconciliationStatusCalls(stub func() (*ledger.PvtdataReconciliationStatus, error)) {
+	fake.getPvtdataReconciliationStatusMutex.Lock()
+	defer fake.getPvtdataReconciliationStatusMutex.Unlock()
+	fake.GetPvtdataReconciliationStatusStub = stub
+}
+
+func (fake *PeerLedger) GetPvtdataReconciliationStatusReturns(result1 *ledger.PvtdataReconciliationStatus, result2 error) {
+	fake.getPvtdataReconciliationStatusMutex.Lock()
+	defer fake.getPvtdataReconciliationStatusMutex.Unlock()
+	fake.GetPvtdataReconciliationStatusStub = nil
+	fake.getPvtdataReconciliationStatusReturns = struct {
+		result1 *ledger.PvtdataReconciliationStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) GetPvtdataReconciliationStatusReturnsOnCall(i int, result1 *ledger.PvtdataReconciliationStatus, result2 error) {
+	fake.getPvtdataReconciliationStatusMutex.Lock()
+	defer fake.getPvtdataReconciliationStatusMutex.Unlock()
+	fake.GetPvtdataReconciliationStatusStub = nil
+	if fake.getPvtdataReconciliationStatusReturnsOnCall == nil {
+		fake.getPvtdataReconciliationStatusReturnsOnCall = make(map[int]struct {
+			result1 *ledger.PvtdataReconciliationStatus
+			result2 error
+		})
+	}
+	fake.getPvtdataReconciliationStatusReturnsOnCall[i] = struct {
+		result1 *ledger.PvtdataReconciliationStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) GetPvtdataScheduledForPurge(arg1 uint64) ([]*ledger.ScheduledPvtdataPurge, error) {
+	fake.getPvtdataScheduledForPurgeMutex.Lock()
+	ret, specificReturn := fake.getPvtdataScheduledForPurgeReturnsOnCall[len(fake.getPvtdataScheduledForPurgeArgsForCall)]
+	fake.getPvtdataScheduledForPurgeArgsForCall = append(fake.getPvtdataScheduledForPurgeArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	fake.recordInvocation("GetPvtdataScheduledForPurge", []interface{}{arg1})
+	fake.getPvtdataScheduledForPurgeMutex.Unlock()
+	if fake.GetPvtdataScheduledForPurgeStub != nil {
+		return fake.GetPvtdataScheduledForPurgeStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getPvtdataScheduledForPurgeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) GetPvtdataScheduledForPurgeCallCount() int {
+	fake.getPvtdataScheduledForPurgeMutex.RLock()
+	defer fake.getPvtdataScheduledForPurgeMutex.RUnlock()
+	return len(fake.getPvtdataScheduledForPurgeArgsForCall)
+}
+
+func (fake *PeerLedger) GetPvtdataScheduledForPurgeCalls(stub func(uint64) ([]*ledger.ScheduledPvtdataPurge, error)) {
+	fake.getPvtdataScheduledForPurgeMutex.Lock()
+	defer fake.getPvtdataScheduledForPurgeMutex.Unlock()
+	fake.GetPvtdataScheduledForPurgeStub = stub
+}
+
+func (fake *PeerLedger) GetPvtdataScheduledForPurgeArgsForCall(i int) uint64 {
+	fake.getPvtdataScheduledForPurgeMutex.RLock()
+	defer fake.getPvtdataScheduledForPurgeMutex.RUnlock()
+	argsForCall := fake.getPvtdataScheduledForPurgeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) GetPvtdataScheduledForPurgeReturns(result1 []*ledger.ScheduledPvtdataPurge, result2 error) {
+	fake.getPvtdataScheduledForPurgeMutex.Lock()
+	defer fake.getPvtdataScheduledForPurgeMutex.Unlock()
+	fake.GetPvtdataScheduledForPurgeStub = nil
+	fake.getPvtdataScheduledForPurgeReturns = struct {
+		result1 []*ledger.ScheduledPvtdataPurge
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) GetPvtdataScheduledForPurgeReturnsOnCall(i int, result1 []*ledger.ScheduledPvtdataPurge, result2 error) {
+	fake.getPvtdataScheduledForPurgeMutex.Lock()
+	defer fake.getPvtdataScheduledForPurgeMutex.Unlock()
+	fake.GetPvtdataScheduledForPurgeStub = nil
+	if fake.getPvtdataScheduledForPurgeReturnsOnCall == nil {
+		fake.getPvtdataScheduledForPurgeReturnsOnCall = make(map[int]struct {
+			result1 []*ledger.ScheduledPvtdataPurge
+			result2 error
+		})
+	}
+	fake.getPvtdataScheduledForPurgeReturnsOnCall[i] = struct {
+		result1 []*ledger.ScheduledPvtdataPurge
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *PeerLedger) GetTransactionByID(arg1 string) (*peer.ProcessedTransaction, error) {
 	fake.getTransactionByIDMutex.Lock()
 	ret, specificReturn := fake.getTransactionByIDReturnsOnCall[len(fake.getTransactionByIDArgsForCall)]
@@ -1251,6 +2531,74 @@ func (fake *PeerLedger) GetTransactionByIDReturnsOnCall(i int, result1 *peer.Pro
 	}{result1, result2}
 }
 
+func (fake *PeerLedger) GetTransactionsByIDs(arg1 []string) ([]*peer.ProcessedTransaction, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.getTransactionsByIDsMutex.Lock()
+	ret, specificReturn := fake.getTransactionsByIDsReturnsOnCall[len(fake.getTransactionsByIDsArgsForCall)]
+	fake.getTransactionsByIDsArgsForCall = append(fake.getTransactionsByIDsArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	fake.recordInvocation("GetTransactionsByIDs", []interface{}{arg1Copy})
+	fake.getTransactionsByIDsMutex.Unlock()
+	if fake.GetTransactionsByIDsStub != nil {
+		return fake.GetTransactionsByIDsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.getTransactionsByIDsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) GetTransactionsByIDsCallCount() int {
+	fake.getTransactionsByIDsMutex.RLock()
+	defer fake.getTransactionsByIDsMutex.RUnlock()
+	return len(fake.getTransactionsByIDsArgsForCall)
+}
+
+func (fake *PeerLedger) GetTransactionsByIDsCalls(stub func([]string) ([]*peer.ProcessedTransaction, error)) {
+	fake.getTransactionsByIDsMutex.Lock()
+	defer fake.getTransactionsByIDsMutex.Unlock()
+	fake.GetTransactionsByIDsStub = stub
+}
+
+func (fake *PeerLedger) GetTransactionsByIDsArgsForCall(i int) []string {
+	fake.getTransactionsByIDsMutex.RLock()
+	defer fake.getTransactionsByIDsMutex.RUnlock()
+	argsForCall := fake.getTransactionsByIDsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) GetTransactionsByIDsReturns(result1 []*peer.ProcessedTransaction, result2 error) {
+	fake.getTransactionsByIDsMutex.Lock()
+	defer fake.getTransactionsByIDsMutex.Unlock()
+	fake.GetTransactionsByIDsStub = nil
+	fake.getTransactionsByIDsReturns = struct {
+		result1 []*peer.ProcessedTransaction
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) GetTransactionsByIDsReturnsOnCall(i int, result1 []*peer.ProcessedTransaction, result2 error) {
+	fake.getTransactionsByIDsMutex.Lock()
+	defer fake.getTransactionsByIDsMutex.Unlock()
+	fake.GetTransactionsByIDsStub = nil
+	if fake.getTransactionsByIDsReturnsOnCall == nil {
+		fake.getTransactionsByIDsReturnsOnCall = make(map[int]struct {
+			result1 []*peer.ProcessedTransaction
+			result2 error
+		})
+	}
+	fake.getTransactionsByIDsReturnsOnCall[i] = struct {
+		result1 []*peer.ProcessedTransaction
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *PeerLedger) GetTxValidationCodeByTxID(arg1 string) (peer.TxValidationCode, uint64, error) {
 	fake.getTxValidationCodeByTxIDMutex.Lock()
 	ret, specificReturn := fake.getTxValidationCodeByTxIDReturnsOnCall[len(fake.getTxValidationCodeByTxIDArgsForCall)]
@@ -1299,22 +2647,195 @@ func (fake *PeerLedger) GetTxValidationCodeByTxIDReturns(result1 peer.TxValidati
 	}{result1, result2, result3}
 }
 
-func (fake *PeerLedger) GetTxValidationCodeByTxIDReturnsOnCall(i int, result1 peer.TxValidationCode, result2 uint64, result3 error) {
-	fake.getTxValidationCodeByTxIDMutex.Lock()
-	defer fake.getTxValidationCodeByTxIDMutex.Unlock()
-	fake.GetTxValidationCodeByTxIDStub = nil
-	if fake.getTxValidationCodeByTxIDReturnsOnCall == nil {
-		fake.getTxValidationCodeByTxIDReturnsOnCall = make(map[int]struct {
-			result1 peer.TxValidationCode
-			result2 uint64
-			result3 error
+func (fake *PeerLedger) GetTxValidationCodeByTxIDReturnsOnCall(i int, result1 peer.TxValidationCode, result2 uint64, result3 error) {
+	fake.getTxValidationCodeByTxIDMutex.Lock()
+	defer fake.getTxValidationCodeByTxIDMutex.Unlock()
+	fake.GetTxValidationCodeByTxIDStub = nil
+	if fake.getTxValidationCodeByTxIDReturnsOnCall == nil {
+		fake.getTxValidationCodeByTxIDReturnsOnCall = make(map[int]struct {
+			result1 peer.TxValidationCode
+			result2 uint64
+			result3 error
+		})
+	}
+	fake.getTxValidationCodeByTxIDReturnsOnCall[i] = struct {
+		result1 peer.TxValidationCode
+		result2 uint64
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *PeerLedger) HistoryDBSavepoint() (*ledger.HistoryDBSavepoint, error) {
+	fake.historyDBSavepointMutex.Lock()
+	ret, specificReturn := fake.historyDBSavepointReturnsOnCall[len(fake.historyDBSavepointArgsForCall)]
+	fake.historyDBSavepointArgsForCall = append(fake.historyDBSavepointArgsForCall, struct {
+	}{})
+	fake.recordInvocation("HistoryDBSavepoint", []interface{}{})
+	fake.historyDBSavepointMutex.Unlock()
+	if fake.HistoryDBSavepointStub != nil {
+		return fake.HistoryDBSavepointStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.historyDBSavepointReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) HistoryDBSavepointCallCount() int {
+	fake.historyDBSavepointMutex.RLock()
+	defer fake.historyDBSavepointMutex.RUnlock()
+	return len(fake.historyDBSavepointArgsForCall)
+}
+
+func (fake *PeerLedger) HistoryDBSavepointCalls(stub func() (*ledger.HistoryDBSavepoint, error)) {
+	fake.historyDBSavepointMutex.Lock()
+	defer fake.historyDBSavepointMutex.Unlock()
+	fake.HistoryDBSavepointStub = stub
+}
+
+func (fake *PeerLedger) HistoryDBSavepointReturns(result1 *ledger.HistoryDBSavepoint, result2 error) {
+	fake.historyDBSavepointMutex.Lock()
+	defer fake.historyDBSavepointMutex.Unlock()
+	fake.HistoryDBSavepointStub = nil
+	fake.historyDBSavepointReturns = struct {
+		result1 *ledger.HistoryDBSavepoint
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) HistoryDBSavepointReturnsOnCall(i int, result1 *ledger.HistoryDBSavepoint, result2 error) {
+	fake.historyDBSavepointMutex.Lock()
+	defer fake.historyDBSavepointMutex.Unlock()
+	fake.HistoryDBSavepointStub = nil
+	if fake.historyDBSavepointReturnsOnCall == nil {
+		fake.historyDBSavepointReturnsOnCall = make(map[int]struct {
+			result1 *ledger.HistoryDBSavepoint
+			result2 error
+		})
+	}
+	fake.historyDBSavepointReturnsOnCall[i] = struct {
+		result1 *ledger.HistoryDBSavepoint
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) HistoryRebuildStatus() (*ledger.HistoryRebuildStatus, error) {
+	fake.historyRebuildStatusMutex.Lock()
+	ret, specificReturn := fake.historyRebuildStatusReturnsOnCall[len(fake.historyRebuildStatusArgsForCall)]
+	fake.historyRebuildStatusArgsForCall = append(fake.historyRebuildStatusArgsForCall, struct {
+	}{})
+	fake.recordInvocation("HistoryRebuildStatus", []interface{}{})
+	fake.historyRebuildStatusMutex.Unlock()
+	if fake.HistoryRebuildStatusStub != nil {
+		return fake.HistoryRebuildStatusStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.historyRebuildStatusReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) HistoryRebuildStatusCallCount() int {
+	fake.historyRebuildStatusMutex.RLock()
+	defer fake.historyRebuildStatusMutex.RUnlock()
+	return len(fake.historyRebuildStatusArgsForCall)
+}
+
+func (fake *PeerLedger) HistoryRebuildStatusCalls(stub func() (*ledger.HistoryRebuildStatus, error)) {
+	fake.historyRebuildStatusMutex.Lock()
+	defer fake.historyRebuildStatusMutex.Unlock()
+	fake.HistoryRebuildStatusStub = stub
+}
+
+func (fake *PeerLedger) HistoryRebuildStatusReturns(result1 *ledger.HistoryRebuildStatus, result2 error) {
+	fake.historyRebuildStatusMutex.Lock()
+	defer fake.historyRebuildStatusMutex.Unlock()
+	fake.HistoryRebuildStatusStub = nil
+	fake.historyRebuildStatusReturns = struct {
+		result1 *ledger.HistoryRebuildStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) HistoryRebuildStatusReturnsOnCall(i int, result1 *ledger.HistoryRebuildStatus, result2 error) {
+	fake.historyRebuildStatusMutex.Lock()
+	defer fake.historyRebuildStatusMutex.Unlock()
+	fake.HistoryRebuildStatusStub = nil
+	if fake.historyRebuildStatusReturnsOnCall == nil {
+		fake.historyRebuildStatusReturnsOnCall = make(map[int]struct {
+			result1 *ledger.HistoryRebuildStatus
+			result2 error
+		})
+	}
+	fake.historyRebuildStatusReturnsOnCall[i] = struct {
+		result1 *ledger.HistoryRebuildStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) MissingPvtDataInfo(arg1 uint64) (ledger.MissingPvtDataInfo, error) {
+	fake.missingPvtDataInfoMutex.Lock()
+	ret, specificReturn := fake.missingPvtDataInfoReturnsOnCall[len(fake.missingPvtDataInfoArgsForCall)]
+	fake.missingPvtDataInfoArgsForCall = append(fake.missingPvtDataInfoArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	fake.recordInvocation("MissingPvtDataInfo", []interface{}{arg1})
+	fake.missingPvtDataInfoMutex.Unlock()
+	if fake.MissingPvtDataInfoStub != nil {
+		return fake.MissingPvtDataInfoStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.missingPvtDataInfoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) MissingPvtDataInfoCallCount() int {
+	fake.missingPvtDataInfoMutex.RLock()
+	defer fake.missingPvtDataInfoMutex.RUnlock()
+	return len(fake.missingPvtDataInfoArgsForCall)
+}
+
+func (fake *PeerLedger) MissingPvtDataInfoCalls(stub func(uint64) (ledger.MissingPvtDataInfo, error)) {
+	fake.missingPvtDataInfoMutex.Lock()
+	defer fake.missingPvtDataInfoMutex.Unlock()
+	fake.MissingPvtDataInfoStub = stub
+}
+
+func (fake *PeerLedger) MissingPvtDataInfoArgsForCall(i int) uint64 {
+	fake.missingPvtDataInfoMutex.RLock()
+	defer fake.missingPvtDataInfoMutex.RUnlock()
+	argsForCall := fake.missingPvtDataInfoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) MissingPvtDataInfoReturns(result1 ledger.MissingPvtDataInfo, result2 error) {
+	fake.missingPvtDataInfoMutex.Lock()
+	defer fake.missingPvtDataInfoMutex.Unlock()
+	fake.MissingPvtDataInfoStub = nil
+	fake.missingPvtDataInfoReturns = struct {
+		result1 ledger.MissingPvtDataInfo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) MissingPvtDataInfoReturnsOnCall(i int, result1 ledger.MissingPvtDataInfo, result2 error) {
+	fake.missingPvtDataInfoMutex.Lock()
+	defer fake.missingPvtDataInfoMutex.Unlock()
+	fake.MissingPvtDataInfoStub = nil
+	if fake.missingPvtDataInfoReturnsOnCall == nil {
+		fake.missingPvtDataInfoReturnsOnCall = make(map[int]struct {
+			result1 ledger.MissingPvtDataInfo
+			result2 error
 		})
 	}
-	fake.getTxValidationCodeByTxIDReturnsOnCall[i] = struct {
-		result1 peer.TxValidationCode
-		result2 uint64
-		result3 error
-	}{result1, result2, result3}
+	fake.missingPvtDataInfoReturnsOnCall[i] = struct {
+		result1 ledger.MissingPvtDataInfo
+		result2 error
+	}{result1, result2}
 }
 
 func (fake *PeerLedger) NewHistoryQueryExecutor() (ledger.HistoryQueryExecutor, error) {
@@ -1411,138 +2932,505 @@ func (fake *PeerLedger) NewQueryExecutorReturns(result1 ledger.QueryExecutor, re
 	}{result1, result2}
 }
 
-func (fake *PeerLedger) NewQueryExecutorReturnsOnCall(i int, result1 ledger.QueryExecutor, result2 error) {
-	fake.newQueryExecutorMutex.Lock()
-	defer fake.newQueryExecutorMutex.Unlock()
-	fake.NewQueryExecutorStub = nil
-	if fake.newQueryExecutorReturnsOnCall == nil {
-		fake.newQueryExecutorReturnsOnCall = make(map[int]struct {
-			result1 ledger.QueryExecutor
-			result2 error
+func (fake *PeerLedger) NewQueryExecutorReturnsOnCall(i int, result1 ledger.QueryExecutor, result2 error) {
+	fake.newQueryExecutorMutex.Lock()
+	defer fake.newQueryExecutorMutex.Unlock()
+	fake.NewQueryExecutorStub = nil
+	if fake.newQueryExecutorReturnsOnCall == nil {
+		fake.newQueryExecutorReturnsOnCall = make(map[int]struct {
+			result1 ledger.QueryExecutor
+			result2 error
+		})
+	}
+	fake.newQueryExecutorReturnsOnCall[i] = struct {
+		result1 ledger.QueryExecutor
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) NewTxSimulator(arg1 string) (ledger.TxSimulator, error) {
+	fake.newTxSimulatorMutex.Lock()
+	ret, specificReturn := fake.newTxSimulatorReturnsOnCall[len(fake.newTxSimulatorArgsForCall)]
+	fake.newTxSimulatorArgsForCall = append(fake.newTxSimulatorArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("NewTxSimulator", []interface{}{arg1})
+	fake.newTxSimulatorMutex.Unlock()
+	if fake.NewTxSimulatorStub != nil {
+		return fake.NewTxSimulatorStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.newTxSimulatorReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) NewTxSimulatorCallCount() int {
+	fake.newTxSimulatorMutex.RLock()
+	defer fake.newTxSimulatorMutex.RUnlock()
+	return len(fake.newTxSimulatorArgsForCall)
+}
+
+func (fake *PeerLedger) NewTxSimulatorCalls(stub func(string) (ledger.TxSimulator, error)) {
+	fake.newTxSimulatorMutex.Lock()
+	defer fake.newTxSimulatorMutex.Unlock()
+	fake.NewTxSimulatorStub = stub
+}
+
+func (fake *PeerLedger) NewTxSimulatorArgsForCall(i int) string {
+	fake.newTxSimulatorMutex.RLock()
+	defer fake.newTxSimulatorMutex.RUnlock()
+	argsForCall := fake.newTxSimulatorArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) NewTxSimulatorReturns(result1 ledger.TxSimulator, result2 error) {
+	fake.newTxSimulatorMutex.Lock()
+	defer fake.newTxSimulatorMutex.Unlock()
+	fake.NewTxSimulatorStub = nil
+	fake.newTxSimulatorReturns = struct {
+		result1 ledger.TxSimulator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) NewTxSimulatorReturnsOnCall(i int, result1 ledger.TxSimulator, result2 error) {
+	fake.newTxSimulatorMutex.Lock()
+	defer fake.newTxSimulatorMutex.Unlock()
+	fake.NewTxSimulatorStub = nil
+	if fake.newTxSimulatorReturnsOnCall == nil {
+		fake.newTxSimulatorReturnsOnCall = make(map[int]struct {
+			result1 ledger.TxSimulator
+			result2 error
+		})
+	}
+	fake.newTxSimulatorReturnsOnCall[i] = struct {
+		result1 ledger.TxSimulator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) NewTxSimulatorAtHeight(arg1 string, arg2 uint64) (ledger.TxSimulator, error) {
+	fake.newTxSimulatorAtHeightMutex.Lock()
+	ret, specificReturn := fake.newTxSimulatorAtHeightReturnsOnCall[len(fake.newTxSimulatorAtHeightArgsForCall)]
+	fake.newTxSimulatorAtHeightArgsForCall = append(fake.newTxSimulatorAtHeightArgsForCall, struct {
+		arg1 string
+		arg2 uint64
+	}{arg1, arg2})
+	fake.recordInvocation("NewTxSimulatorAtHeight", []interface{}{arg1, arg2})
+	fake.newTxSimulatorAtHeightMutex.Unlock()
+	if fake.NewTxSimulatorAtHeightStub != nil {
+		return fake.NewTxSimulatorAtHeightStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.newTxSimulatorAtHeightReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) NewTxSimulatorAtHeightCallCount() int {
+	fake.newTxSimulatorAtHeightMutex.RLock()
+	defer fake.newTxSimulatorAtHeightMutex.RUnlock()
+	return len(fake.newTxSimulatorAtHeightArgsForCall)
+}
+
+func (fake *PeerLedger) NewTxSimulatorAtHeightCalls(stub func(string, uint64) (ledger.TxSimulator, error)) {
+	fake.newTxSimulatorAtHeightMutex.Lock()
+	defer fake.newTxSimulatorAtHeightMutex.Unlock()
+	fake.NewTxSimulatorAtHeightStub = stub
+}
+
+func (fake *PeerLedger) NewTxSimulatorAtHeightArgsForCall(i int) (string, uint64) {
+	fake.newTxSimulatorAtHeightMutex.RLock()
+	defer fake.newTxSimulatorAtHeightMutex.RUnlock()
+	argsForCall := fake.newTxSimulatorAtHeightArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *PeerLedger) NewTxSimulatorAtHeightReturns(result1 ledger.TxSimulator, result2 error) {
+	fake.newTxSimulatorAtHeightMutex.Lock()
+	defer fake.newTxSimulatorAtHeightMutex.Unlock()
+	fake.NewTxSimulatorAtHeightStub = nil
+	fake.newTxSimulatorAtHeightReturns = struct {
+		result1 ledger.TxSimulator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) NewTxSimulatorAtHeightReturnsOnCall(i int, result1 ledger.TxSimulator, result2 error) {
+	fake.newTxSimulatorAtHeightMutex.Lock()
+	defer fake.newTxSimulatorAtHeightMutex.Unlock()
+	fake.NewTxSimulatorAtHeightStub = nil
+	if fake.newTxSimulatorAtHeightReturnsOnCall == nil {
+		fake.newTxSimulatorAtHeightReturnsOnCall = make(map[int]struct {
+			result1 ledger.TxSimulator
+			result2 error
+		})
+	}
+	fake.newTxSimulatorAtHeightReturnsOnCall[i] = struct {
+		result1 ledger.TxSimulator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) PendingSnapshotRequests() ([]uint64, error) {
+	fake.pendingSnapshotRequestsMutex.Lock()
+	ret, specificReturn := fake.pendingSnapshotRequestsReturnsOnCall[len(fake.pendingSnapshotRequestsArgsForCall)]
+	fake.pendingSnapshotRequestsArgsForCall = append(fake.pendingSnapshotRequestsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("PendingSnapshotRequests", []interface{}{})
+	fake.pendingSnapshotRequestsMutex.Unlock()
+	if fake.PendingSnapshotRequestsStub != nil {
+		return fake.PendingSnapshotRequestsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.pendingSnapshotRequestsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) PendingSnapshotRequestsCallCount() int {
+	fake.pendingSnapshotRequestsMutex.RLock()
+	defer fake.pendingSnapshotRequestsMutex.RUnlock()
+	return len(fake.pendingSnapshotRequestsArgsForCall)
+}
+
+func (fake *PeerLedger) PendingSnapshotRequestsCalls(stub func() ([]uint64, error)) {
+	fake.pendingSnapshotRequestsMutex.Lock()
+	defer fake.pendingSnapshotRequestsMutex.Unlock()
+	fake.PendingSnapshotRequestsStub = stub
+}
+
+func (fake *PeerLedger) PendingSnapshotRequestsReturns(result1 []uint64, result2 error) {
+	fake.pendingSnapshotRequestsMutex.Lock()
+	defer fake.pendingSnapshotRequestsMutex.Unlock()
+	fake.PendingSnapshotRequestsStub = nil
+	fake.pendingSnapshotRequestsReturns = struct {
+		result1 []uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) PendingSnapshotRequestsReturnsOnCall(i int, result1 []uint64, result2 error) {
+	fake.pendingSnapshotRequestsMutex.Lock()
+	defer fake.pendingSnapshotRequestsMutex.Unlock()
+	fake.PendingSnapshotRequestsStub = nil
+	if fake.pendingSnapshotRequestsReturnsOnCall == nil {
+		fake.pendingSnapshotRequestsReturnsOnCall = make(map[int]struct {
+			result1 []uint64
+			result2 error
+		})
+	}
+	fake.pendingSnapshotRequestsReturnsOnCall[i] = struct {
+		result1 []uint64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) PreviewCommit(arg1 *ledger.BlockAndPvtData) (*ledger.StateDelta, error) {
+	fake.previewCommitMutex.Lock()
+	ret, specificReturn := fake.previewCommitReturnsOnCall[len(fake.previewCommitArgsForCall)]
+	fake.previewCommitArgsForCall = append(fake.previewCommitArgsForCall, struct {
+		arg1 *ledger.BlockAndPvtData
+	}{arg1})
+	fake.recordInvocation("PreviewCommit", []interface{}{arg1})
+	fake.previewCommitMutex.Unlock()
+	if fake.PreviewCommitStub != nil {
+		return fake.PreviewCommitStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.previewCommitReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) PreviewCommitCallCount() int {
+	fake.previewCommitMutex.RLock()
+	defer fake.previewCommitMutex.RUnlock()
+	return len(fake.previewCommitArgsForCall)
+}
+
+func (fake *PeerLedger) PreviewCommitCalls(stub func(*ledger.BlockAndPvtData) (*ledger.StateDelta, error)) {
+	fake.previewCommitMutex.Lock()
+	defer fake.previewCommitMutex.Unlock()
+	fake.PreviewCommitStub = stub
+}
+
+func (fake *PeerLedger) PreviewCommitArgsForCall(i int) *ledger.BlockAndPvtData {
+	fake.previewCommitMutex.RLock()
+	defer fake.previewCommitMutex.RUnlock()
+	argsForCall := fake.previewCommitArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) PreviewCommitReturns(result1 *ledger.StateDelta, result2 error) {
+	fake.previewCommitMutex.Lock()
+	defer fake.previewCommitMutex.Unlock()
+	fake.PreviewCommitStub = nil
+	fake.previewCommitReturns = struct {
+		result1 *ledger.StateDelta
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) PreviewCommitReturnsOnCall(i int, result1 *ledger.StateDelta, result2 error) {
+	fake.previewCommitMutex.Lock()
+	defer fake.previewCommitMutex.Unlock()
+	fake.PreviewCommitStub = nil
+	if fake.previewCommitReturnsOnCall == nil {
+		fake.previewCommitReturnsOnCall = make(map[int]struct {
+			result1 *ledger.StateDelta
+			result2 error
+		})
+	}
+	fake.previewCommitReturnsOnCall[i] = struct {
+		result1 *ledger.StateDelta
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) PruneBlocks(arg1 uint64) error {
+	fake.pruneBlocksMutex.Lock()
+	ret, specificReturn := fake.pruneBlocksReturnsOnCall[len(fake.pruneBlocksArgsForCall)]
+	fake.pruneBlocksArgsForCall = append(fake.pruneBlocksArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	fake.recordInvocation("PruneBlocks", []interface{}{arg1})
+	fake.pruneBlocksMutex.Unlock()
+	if fake.PruneBlocksStub != nil {
+		return fake.PruneBlocksStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.pruneBlocksReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) PruneBlocksCallCount() int {
+	fake.pruneBlocksMutex.RLock()
+	defer fake.pruneBlocksMutex.RUnlock()
+	return len(fake.pruneBlocksArgsForCall)
+}
+
+func (fake *PeerLedger) PruneBlocksCalls(stub func(uint64) error) {
+	fake.pruneBlocksMutex.Lock()
+	defer fake.pruneBlocksMutex.Unlock()
+	fake.PruneBlocksStub = stub
+}
+
+func (fake *PeerLedger) PruneBlocksArgsForCall(i int) uint64 {
+	fake.pruneBlocksMutex.RLock()
+	defer fake.pruneBlocksMutex.RUnlock()
+	argsForCall := fake.pruneBlocksArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) PruneBlocksReturns(result1 error) {
+	fake.pruneBlocksMutex.Lock()
+	defer fake.pruneBlocksMutex.Unlock()
+	fake.PruneBlocksStub = nil
+	fake.pruneBlocksReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) PruneBlocksReturnsOnCall(i int, result1 error) {
+	fake.pruneBlocksMutex.Lock()
+	defer fake.pruneBlocksMutex.Unlock()
+	fake.PruneBlocksStub = nil
+	if fake.pruneBlocksReturnsOnCall == nil {
+		fake.pruneBlocksReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pruneBlocksReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) RegisterCommitListener(arg1 func(committedBlock *common.Block)) func() {
+	fake.registerCommitListenerMutex.Lock()
+	ret, specificReturn := fake.registerCommitListenerReturnsOnCall[len(fake.registerCommitListenerArgsForCall)]
+	fake.registerCommitListenerArgsForCall = append(fake.registerCommitListenerArgsForCall, struct {
+		arg1 func(committedBlock *common.Block)
+	}{arg1})
+	fake.recordInvocation("RegisterCommitListener", []interface{}{arg1})
+	fake.registerCommitListenerMutex.Unlock()
+	if fake.RegisterCommitListenerStub != nil {
+		return fake.RegisterCommitListenerStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.registerCommitListenerReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) RegisterCommitListenerCallCount() int {
+	fake.registerCommitListenerMutex.RLock()
+	defer fake.registerCommitListenerMutex.RUnlock()
+	return len(fake.registerCommitListenerArgsForCall)
+}
+
+func (fake *PeerLedger) RegisterCommitListenerCalls(stub func(func(committedBlock *common.Block)) func()) {
+	fake.registerCommitListenerMutex.Lock()
+	defer fake.registerCommitListenerMutex.Unlock()
+	fake.RegisterCommitListenerStub = stub
+}
+
+func (fake *PeerLedger) RegisterCommitListenerArgsForCall(i int) func(committedBlock *common.Block) {
+	fake.registerCommitListenerMutex.RLock()
+	defer fake.registerCommitListenerMutex.RUnlock()
+	argsForCall := fake.registerCommitListenerArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) RegisterCommitListenerReturns(result1 func()) {
+	fake.registerCommitListenerMutex.Lock()
+	defer fake.registerCommitListenerMutex.Unlock()
+	fake.RegisterCommitListenerStub = nil
+	fake.registerCommitListenerReturns = struct {
+		result1 func()
+	}{result1}
+}
+
+func (fake *PeerLedger) RegisterCommitListenerReturnsOnCall(i int, result1 func()) {
+	fake.registerCommitListenerMutex.Lock()
+	defer fake.registerCommitListenerMutex.Unlock()
+	fake.RegisterCommitListenerStub = nil
+	if fake.registerCommitListenerReturnsOnCall == nil {
+		fake.registerCommitListenerReturnsOnCall = make(map[int]struct {
+			result1 func()
 		})
 	}
-	fake.newQueryExecutorReturnsOnCall[i] = struct {
-		result1 ledger.QueryExecutor
-		result2 error
-	}{result1, result2}
+	fake.registerCommitListenerReturnsOnCall[i] = struct {
+		result1 func()
+	}{result1}
 }
 
-func (fake *PeerLedger) NewTxSimulator(arg1 string) (ledger.TxSimulator, error) {
-	fake.newTxSimulatorMutex.Lock()
-	ret, specificReturn := fake.newTxSimulatorReturnsOnCall[len(fake.newTxSimulatorArgsForCall)]
-	fake.newTxSimulatorArgsForCall = append(fake.newTxSimulatorArgsForCall, struct {
-		arg1 string
+func (fake *PeerLedger) RegisterPvtdataPurgeListener(arg1 func(event *ledger.PvtdataPurgeEvent)) func() {
+	fake.registerPvtdataPurgeListenerMutex.Lock()
+	ret, specificReturn := fake.registerPvtdataPurgeListenerReturnsOnCall[len(fake.registerPvtdataPurgeListenerArgsForCall)]
+	fake.registerPvtdataPurgeListenerArgsForCall = append(fake.registerPvtdataPurgeListenerArgsForCall, struct {
+		arg1 func(event *ledger.PvtdataPurgeEvent)
 	}{arg1})
-	fake.recordInvocation("NewTxSimulator", []interface{}{arg1})
-	fake.newTxSimulatorMutex.Unlock()
-	if fake.NewTxSimulatorStub != nil {
-		return fake.NewTxSimulatorStub(arg1)
+	fake.recordInvocation("RegisterPvtdataPurgeListener", []interface{}{arg1})
+	fake.registerPvtdataPurgeListenerMutex.Unlock()
+	if fake.RegisterPvtdataPurgeListenerStub != nil {
+		return fake.RegisterPvtdataPurgeListenerStub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	fakeReturns := fake.newTxSimulatorReturns
-	return fakeReturns.result1, fakeReturns.result2
+	fakeReturns := fake.registerPvtdataPurgeListenerReturns
+	return fakeReturns.result1
 }
 
-func (fake *PeerLedger) NewTxSimulatorCallCount() int {
-	fake.newTxSimulatorMutex.RLock()
-	defer fake.newTxSimulatorMutex.RUnlock()
-	return len(fake.newTxSimulatorArgsForCall)
+func (fake *PeerLedger) RegisterPvtdataPurgeListenerCallCount() int {
+	fake.registerPvtdataPurgeListenerMutex.RLock()
+	defer fake.registerPvtdataPurgeListenerMutex.RUnlock()
+	return len(fake.registerPvtdataPurgeListenerArgsForCall)
 }
 
-func (fake *PeerLedger) NewTxSimulatorCalls(stub func(string) (ledger.TxSimulator, error)) {
-	fake.newTxSimulatorMutex.Lock()
-	defer fake.newTxSimulatorMutex.Unlock()
-	fake.NewTxSimulatorStub = stub
+func (fake *PeerLedger) RegisterPvtdataPurgeListenerCalls(stub func(func(event *ledger.PvtdataPurgeEvent)) func()) {
+	fake.registerPvtdataPurgeListenerMutex.Lock()
+	defer fake.registerPvtdataPurgeListenerMutex.Unlock()
+	fake.RegisterPvtdataPurgeListenerStub = stub
 }
 
-func (fake *PeerLedger) NewTxSimulatorArgsForCall(i int) string {
-	fake.newTxSimulatorMutex.RLock()
-	defer fake.newTxSimulatorMutex.RUnlock()
-	argsForCall := fake.newTxSimulatorArgsForCall[i]
+func (fake *PeerLedger) RegisterPvtdataPurgeListenerArgsForCall(i int) func(event *ledger.PvtdataPurgeEvent) {
+	fake.registerPvtdataPurgeListenerMutex.RLock()
+	defer fake.registerPvtdataPurgeListenerMutex.RUnlock()
+	argsForCall := fake.registerPvtdataPurgeListenerArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *PeerLedger) NewTxSimulatorReturns(result1 ledger.TxSimulator, result2 error) {
-	fake.newTxSimulatorMutex.Lock()
-	defer fake.newTxSimulatorMutex.Unlock()
-	fake.NewTxSimulatorStub = nil
-	fake.newTxSimulatorReturns = struct {
-		result1 ledger.TxSimulator
-		result2 error
-	}{result1, result2}
+func (fake *PeerLedger) RegisterPvtdataPurgeListenerReturns(result1 func()) {
+	fake.registerPvtdataPurgeListenerMutex.Lock()
+	defer fake.registerPvtdataPurgeListenerMutex.Unlock()
+	fake.RegisterPvtdataPurgeListenerStub = nil
+	fake.registerPvtdataPurgeListenerReturns = struct {
+		result1 func()
+	}{result1}
 }
 
-func (fake *PeerLedger) NewTxSimulatorReturnsOnCall(i int, result1 ledger.TxSimulator, result2 error) {
-	fake.newTxSimulatorMutex.Lock()
-	defer fake.newTxSimulatorMutex.Unlock()
-	fake.NewTxSimulatorStub = nil
-	if fake.newTxSimulatorReturnsOnCall == nil {
-		fake.newTxSimulatorReturnsOnCall = make(map[int]struct {
-			result1 ledger.TxSimulator
-			result2 error
+func (fake *PeerLedger) RegisterPvtdataPurgeListenerReturnsOnCall(i int, result1 func()) {
+	fake.registerPvtdataPurgeListenerMutex.Lock()
+	defer fake.registerPvtdataPurgeListenerMutex.Unlock()
+	fake.RegisterPvtdataPurgeListenerStub = nil
+	if fake.registerPvtdataPurgeListenerReturnsOnCall == nil {
+		fake.registerPvtdataPurgeListenerReturnsOnCall = make(map[int]struct {
+			result1 func()
 		})
 	}
-	fake.newTxSimulatorReturnsOnCall[i] = struct {
-		result1 ledger.TxSimulator
-		result2 error
-	}{result1, result2}
+	fake.registerPvtdataPurgeListenerReturnsOnCall[i] = struct {
+		result1 func()
+	}{result1}
 }
 
-func (fake *PeerLedger) PendingSnapshotRequests() ([]uint64, error) {
-	fake.pendingSnapshotRequestsMutex.Lock()
-	ret, specificReturn := fake.pendingSnapshotRequestsReturnsOnCall[len(fake.pendingSnapshotRequestsArgsForCall)]
-	fake.pendingSnapshotRequestsArgsForCall = append(fake.pendingSnapshotRequestsArgsForCall, struct {
-	}{})
-	fake.recordInvocation("PendingSnapshotRequests", []interface{}{})
-	fake.pendingSnapshotRequestsMutex.Unlock()
-	if fake.PendingSnapshotRequestsStub != nil {
-		return fake.PendingSnapshotRequestsStub()
+func (fake *PeerLedger) RollbackHistoryDB(arg1 uint64) error {
+	fake.rollbackHistoryDBMutex.Lock()
+	ret, specificReturn := fake.rollbackHistoryDBReturnsOnCall[len(fake.rollbackHistoryDBArgsForCall)]
+	fake.rollbackHistoryDBArgsForCall = append(fake.rollbackHistoryDBArgsForCall, struct {
+		arg1 uint64
+	}{arg1})
+	fake.recordInvocation("RollbackHistoryDB", []interface{}{arg1})
+	fake.rollbackHistoryDBMutex.Unlock()
+	if fake.RollbackHistoryDBStub != nil {
+		return fake.RollbackHistoryDBStub(arg1)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2
+		return ret.result1
 	}
-	fakeReturns := fake.pendingSnapshotRequestsReturns
-	return fakeReturns.result1, fakeReturns.result2
+	fakeReturns := fake.rollbackHistoryDBReturns
+	return fakeReturns.result1
 }
 
-func (fake *PeerLedger) PendingSnapshotRequestsCallCount() int {
-	fake.pendingSnapshotRequestsMutex.RLock()
-	defer fake.pendingSnapshotRequestsMutex.RUnlock()
-	return len(fake.pendingSnapshotRequestsArgsForCall)
+func (fake *PeerLedger) RollbackHistoryDBCallCount() int {
+	fake.rollbackHistoryDBMutex.RLock()
+	defer fake.rollbackHistoryDBMutex.RUnlock()
+	return len(fake.rollbackHistoryDBArgsForCall)
 }
 
-func (fake *PeerLedger) PendingSnapshotRequestsCalls(stub func() ([]uint64, error)) {
-	fake.pendingSnapshotRequestsMutex.Lock()
-	defer fake.pendingSnapshotRequestsMutex.Unlock()
-	fake.PendingSnapshotRequestsStub = stub
+func (fake *PeerLedger) RollbackHistoryDBCalls(stub func(uint64) error) {
+	fake.rollbackHistoryDBMutex.Lock()
+	defer fake.rollbackHistoryDBMutex.Unlock()
+	fake.RollbackHistoryDBStub = stub
 }
 
-func (fake *PeerLedger) PendingSnapshotRequestsReturns(result1 []uint64, result2 error) {
-	fake.pendingSnapshotRequestsMutex.Lock()
-	defer fake.pendingSnapshotRequestsMutex.Unlock()
-	fake.PendingSnapshotRequestsStub = nil
-	fake.pendingSnapshotRequestsReturns = struct {
-		result1 []uint64
-		result2 error
-	}{result1, result2}
+func (fake *PeerLedger) RollbackHistoryDBArgsForCall(i int) uint64 {
+	fake.rollbackHistoryDBMutex.RLock()
+	defer fake.rollbackHistoryDBMutex.RUnlock()
+	argsForCall := fake.rollbackHistoryDBArgsForCall[i]
+	return argsForCall.arg1
 }
 
-func (fake *PeerLedger) PendingSnapshotRequestsReturnsOnCall(i int, result1 []uint64, result2 error) {
-	fake.pendingSnapshotRequestsMutex.Lock()
-	defer fake.pendingSnapshotRequestsMutex.Unlock()
-	fake.PendingSnapshotRequestsStub = nil
-	if fake.pendingSnapshotRequestsReturnsOnCall == nil {
-		fake.pendingSnapshotRequestsReturnsOnCall = make(map[int]struct {
-			result1 []uint64
-			result2 error
+func (fake *PeerLedger) RollbackHistoryDBReturns(result1 error) {
+	fake.rollbackHistoryDBMutex.Lock()
+	defer fake.rollbackHistoryDBMutex.Unlock()
+	fake.RollbackHistoryDBStub = nil
+	fake.rollbackHistoryDBReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) RollbackHistoryDBReturnsOnCall(i int, result1 error) {
+	fake.rollbackHistoryDBMutex.Lock()
+	defer fake.rollbackHistoryDBMutex.Unlock()
+	fake.RollbackHistoryDBStub = nil
+	if fake.rollbackHistoryDBReturnsOnCall == nil {
+		fake.rollbackHistoryDBReturnsOnCall = make(map[int]struct {
+			result1 error
 		})
 	}
-	fake.pendingSnapshotRequestsReturnsOnCall[i] = struct {
-		result1 []uint64
-		result2 error
-	}{result1, result2}
+	fake.rollbackHistoryDBReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
 }
 
 func (fake *PeerLedger) SubmitSnapshotRequest(arg1 uint64) error {
@@ -1668,21 +3556,345 @@ func (fake *PeerLedger) TxIDExistsReturnsOnCall(i int, result1 bool, result2 err
 	}{result1, result2}
 }
 
+func (fake *PeerLedger) TxIDsByChaincodeName(arg1 string) (ledgera.ResultsIterator, error) {
+	fake.txIDsByChaincodeNameMutex.Lock()
+	ret, specificReturn := fake.txIDsByChaincodeNameReturnsOnCall[len(fake.txIDsByChaincodeNameArgsForCall)]
+	fake.txIDsByChaincodeNameArgsForCall = append(fake.txIDsByChaincodeNameArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("TxIDsByChaincodeName", []interface{}{arg1})
+	fake.txIDsByChaincodeNameMutex.Unlock()
+	if fake.TxIDsByChaincodeNameStub != nil {
+		return fake.TxIDsByChaincodeNameStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.txIDsByChaincodeNameReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) TxIDsByChaincodeNameCallCount() int {
+	fake.txIDsByChaincodeNameMutex.RLock()
+	defer fake.txIDsByChaincodeNameMutex.RUnlock()
+	return len(fake.txIDsByChaincodeNameArgsForCall)
+}
+
+func (fake *PeerLedger) TxIDsByChaincodeNameCalls(stub func(string) (ledgera.ResultsIterator, error)) {
+	fake.txIDsByChaincodeNameMutex.Lock()
+	defer fake.txIDsByChaincodeNameMutex.Unlock()
+	fake.TxIDsByChaincodeNameStub = stub
+}
+
+func (fake *PeerLedger) TxIDsByChaincodeNameArgsForCall(i int) string {
+	fake.txIDsByChaincodeNameMutex.RLock()
+	defer fake.txIDsByChaincodeNameMutex.RUnlock()
+	argsForCall := fake.txIDsByChaincodeNameArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) TxIDsByChaincodeNameReturns(result1 ledgera.ResultsIterator, result2 error) {
+	fake.txIDsByChaincodeNameMutex.Lock()
+	defer fake.txIDsByChaincodeNameMutex.Unlock()
+	fake.TxIDsByChaincodeNameStub = nil
+	fake.txIDsByChaincodeNameReturns = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) TxIDsByChaincodeNameReturnsOnCall(i int, result1 ledgera.ResultsIterator, result2 error) {
+	fake.txIDsByChaincodeNameMutex.Lock()
+	defer fake.txIDsByChaincodeNameMutex.Unlock()
+	fake.TxIDsByChaincodeNameStub = nil
+	if fake.txIDsByChaincodeNameReturnsOnCall == nil {
+		fake.txIDsByChaincodeNameReturnsOnCall = make(map[int]struct {
+			result1 ledgera.ResultsIterator
+			result2 error
+		})
+	}
+	fake.txIDsByChaincodeNameReturnsOnCall[i] = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) TxIDsByEndorserMSPID(arg1 string) (ledgera.ResultsIterator, error) {
+	fake.txIDsByEndorserMSPIDMutex.Lock()
+	ret, specificReturn := fake.txIDsByEndorserMSPIDReturnsOnCall[len(fake.txIDsByEndorserMSPIDArgsForCall)]
+	fake.txIDsByEndorserMSPIDArgsForCall = append(fake.txIDsByEndorserMSPIDArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("TxIDsByEndorserMSPID", []interface{}{arg1})
+	fake.txIDsByEndorserMSPIDMutex.Unlock()
+	if fake.TxIDsByEndorserMSPIDStub != nil {
+		return fake.TxIDsByEndorserMSPIDStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.txIDsByEndorserMSPIDReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) TxIDsByEndorserMSPIDCallCount() int {
+	fake.txIDsByEndorserMSPIDMutex.RLock()
+	defer fake.txIDsByEndorserMSPIDMutex.RUnlock()
+	return len(fake.txIDsByEndorserMSPIDArgsForCall)
+}
+
+func (fake *PeerLedger) TxIDsByEndorserMSPIDCalls(stub func(string) (ledgera.ResultsIterator, error)) {
+	fake.txIDsByEndorserMSPIDMutex.Lock()
+	defer fake.txIDsByEndorserMSPIDMutex.Unlock()
+	fake.TxIDsByEndorserMSPIDStub = stub
+}
+
+func (fake *PeerLedger) TxIDsByEndorserMSPIDArgsForCall(i int) string {
+	fake.txIDsByEndorserMSPIDMutex.RLock()
+	defer fake.txIDsByEndorserMSPIDMutex.RUnlock()
+	argsForCall := fake.txIDsByEndorserMSPIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) TxIDsByEndorserMSPIDReturns(result1 ledgera.ResultsIterator, result2 error) {
+	fake.txIDsByEndorserMSPIDMutex.Lock()
+	defer fake.txIDsByEndorserMSPIDMutex.Unlock()
+	fake.TxIDsByEndorserMSPIDStub = nil
+	fake.txIDsByEndorserMSPIDReturns = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) TxIDsByEndorserMSPIDReturnsOnCall(i int, result1 ledgera.ResultsIterator, result2 error) {
+	fake.txIDsByEndorserMSPIDMutex.Lock()
+	defer fake.txIDsByEndorserMSPIDMutex.Unlock()
+	fake.TxIDsByEndorserMSPIDStub = nil
+	if fake.txIDsByEndorserMSPIDReturnsOnCall == nil {
+		fake.txIDsByEndorserMSPIDReturnsOnCall = make(map[int]struct {
+			result1 ledgera.ResultsIterator
+			result2 error
+		})
+	}
+	fake.txIDsByEndorserMSPIDReturnsOnCall[i] = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) TxIDsInRange(arg1 uint64, arg2 uint64) (ledgera.ResultsIterator, error) {
+	fake.txIDsInRangeMutex.Lock()
+	ret, specificReturn := fake.txIDsInRangeReturnsOnCall[len(fake.txIDsInRangeArgsForCall)]
+	fake.txIDsInRangeArgsForCall = append(fake.txIDsInRangeArgsForCall, struct {
+		arg1 uint64
+		arg2 uint64
+	}{arg1, arg2})
+	fake.recordInvocation("TxIDsInRange", []interface{}{arg1, arg2})
+	fake.txIDsInRangeMutex.Unlock()
+	if fake.TxIDsInRangeStub != nil {
+		return fake.TxIDsInRangeStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.txIDsInRangeReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *PeerLedger) TxIDsInRangeCallCount() int {
+	fake.txIDsInRangeMutex.RLock()
+	defer fake.txIDsInRangeMutex.RUnlock()
+	return len(fake.txIDsInRangeArgsForCall)
+}
+
+func (fake *PeerLedger) TxIDsInRangeCalls(stub func(uint64, uint64) (ledgera.ResultsIterator, error)) {
+	fake.txIDsInRangeMutex.Lock()
+	defer fake.txIDsInRangeMutex.Unlock()
+	fake.TxIDsInRangeStub = stub
+}
+
+func (fake *PeerLedger) TxIDsInRangeArgsForCall(i int) (uint64, uint64) {
+	fake.txIDsInRangeMutex.RLock()
+	defer fake.txIDsInRangeMutex.RUnlock()
+	argsForCall := fake.txIDsInRangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *PeerLedger) TxIDsInRangeReturns(result1 ledgera.ResultsIterator, result2 error) {
+	fake.txIDsInRangeMutex.Lock()
+	defer fake.txIDsInRangeMutex.Unlock()
+	fake.TxIDsInRangeStub = nil
+	fake.txIDsInRangeReturns = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) TxIDsInRangeReturnsOnCall(i int, result1 ledgera.ResultsIterator, result2 error) {
+	fake.txIDsInRangeMutex.Lock()
+	defer fake.txIDsInRangeMutex.Unlock()
+	fake.TxIDsInRangeStub = nil
+	if fake.txIDsInRangeReturnsOnCall == nil {
+		fake.txIDsInRangeReturnsOnCall = make(map[int]struct {
+			result1 ledgera.ResultsIterator
+			result2 error
+		})
+	}
+	fake.txIDsInRangeReturnsOnCall[i] = struct {
+		result1 ledgera.ResultsIterator
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *PeerLedger) Unfreeze() error {
+	fake.unfreezeMutex.Lock()
+	ret, specificReturn := fake.unfreezeReturnsOnCall[len(fake.unfreezeArgsForCall)]
+	fake.unfreezeArgsForCall = append(fake.unfreezeArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Unfreeze", []interface{}{})
+	fake.unfreezeMutex.Unlock()
+	if fake.UnfreezeStub != nil {
+		return fake.UnfreezeStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.unfreezeReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) UnfreezeCallCount() int {
+	fake.unfreezeMutex.RLock()
+	defer fake.unfreezeMutex.RUnlock()
+	return len(fake.unfreezeArgsForCall)
+}
+
+func (fake *PeerLedger) UnfreezeCalls(stub func() error) {
+	fake.unfreezeMutex.Lock()
+	defer fake.unfreezeMutex.Unlock()
+	fake.UnfreezeStub = stub
+}
+
+func (fake *PeerLedger) UnfreezeReturns(result1 error) {
+	fake.unfreezeMutex.Lock()
+	defer fake.unfreezeMutex.Unlock()
+	fake.UnfreezeStub = nil
+	fake.unfreezeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) UnfreezeReturnsOnCall(i int, result1 error) {
+	fake.unfreezeMutex.Lock()
+	defer fake.unfreezeMutex.Unlock()
+	fake.UnfreezeStub = nil
+	if fake.unfreezeReturnsOnCall == nil {
+		fake.unfreezeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unfreezeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) UpdatePvtDataConfig(arg1 *ledger.PrivateDataConfig) error {
+	fake.updatePvtDataConfigMutex.Lock()
+	ret, specificReturn := fake.updatePvtDataConfigReturnsOnCall[len(fake.updatePvtDataConfigArgsForCall)]
+	fake.updatePvtDataConfigArgsForCall = append(fake.updatePvtDataConfigArgsForCall, struct {
+		arg1 *ledger.PrivateDataConfig
+	}{arg1})
+	fake.recordInvocation("UpdatePvtDataConfig", []interface{}{arg1})
+	fake.updatePvtDataConfigMutex.Unlock()
+	if fake.UpdatePvtDataConfigStub != nil {
+		return fake.UpdatePvtDataConfigStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.updatePvtDataConfigReturns
+	return fakeReturns.result1
+}
+
+func (fake *PeerLedger) UpdatePvtDataConfigCallCount() int {
+	fake.updatePvtDataConfigMutex.RLock()
+	defer fake.updatePvtDataConfigMutex.RUnlock()
+	return len(fake.updatePvtDataConfigArgsForCall)
+}
+
+func (fake *PeerLedger) UpdatePvtDataConfigCalls(stub func(*ledger.PrivateDataConfig) error) {
+	fake.updatePvtDataConfigMutex.Lock()
+	defer fake.updatePvtDataConfigMutex.Unlock()
+	fake.UpdatePvtDataConfigStub = stub
+}
+
+func (fake *PeerLedger) UpdatePvtDataConfigArgsForCall(i int) *ledger.PrivateDataConfig {
+	fake.updatePvtDataConfigMutex.RLock()
+	defer fake.updatePvtDataConfigMutex.RUnlock()
+	argsForCall := fake.updatePvtDataConfigArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *PeerLedger) UpdatePvtDataConfigReturns(result1 error) {
+	fake.updatePvtDataConfigMutex.Lock()
+	defer fake.updatePvtDataConfigMutex.Unlock()
+	fake.UpdatePvtDataConfigStub = nil
+	fake.updatePvtDataConfigReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *PeerLedger) UpdatePvtDataConfigReturnsOnCall(i int, result1 error) {
+	fake.updatePvtDataConfigMutex.Lock()
+	defer fake.updatePvtDataConfigMutex.Unlock()
+	fake.UpdatePvtDataConfigStub = nil
+	if fake.updatePvtDataConfigReturnsOnCall == nil {
+		fake.updatePvtDataConfigReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updatePvtDataConfigReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *PeerLedger) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.appendBlockRawMutex.RLock()
+	defer fake.appendBlockRawMutex.RUnlock()
+	fake.archiveBlocksMutex.RLock()
+	defer fake.archiveBlocksMutex.RUnlock()
+	fake.backupMutex.RLock()
+	defer fake.backupMutex.RUnlock()
+	fake.blockStoreCheckpointInfoMutex.RLock()
+	defer fake.blockStoreCheckpointInfoMutex.RUnlock()
 	fake.cancelSnapshotRequestMutex.RLock()
 	defer fake.cancelSnapshotRequestMutex.RUnlock()
+	fake.catchUpHistoryDBMutex.RLock()
+	defer fake.catchUpHistoryDBMutex.RUnlock()
+	fake.channelIDMutex.RLock()
+	defer fake.channelIDMutex.RUnlock()
 	fake.closeMutex.RLock()
 	defer fake.closeMutex.RUnlock()
+	fake.collectionConfigAtMutex.RLock()
+	defer fake.collectionConfigAtMutex.RUnlock()
+	fake.commitBackpressureMutex.RLock()
+	defer fake.commitBackpressureMutex.RUnlock()
 	fake.commitLegacyMutex.RLock()
 	defer fake.commitLegacyMutex.RUnlock()
 	fake.commitNotificationsChannelMutex.RLock()
 	defer fake.commitNotificationsChannelMutex.RUnlock()
 	fake.commitPvtDataOfOldBlocksMutex.RLock()
 	defer fake.commitPvtDataOfOldBlocksMutex.RUnlock()
+	fake.compactPvtdataStoreMutex.RLock()
+	defer fake.compactPvtdataStoreMutex.RUnlock()
+	fake.computeStateHashMutex.RLock()
+	defer fake.computeStateHashMutex.RUnlock()
 	fake.doesPvtDataInfoExistMutex.RLock()
 	defer fake.doesPvtDataInfoExistMutex.RUnlock()
+	fake.freezeMutex.RLock()
+	defer fake.freezeMutex.RUnlock()
 	fake.getBlockByHashMutex.RLock()
 	defer fake.getBlockByHashMutex.RUnlock()
 	fake.getBlockByNumberMutex.RLock()
@@ -1691,32 +3903,72 @@ func (fake *PeerLedger) Invocations() map[string][][]interface{} {
 	defer fake.getBlockByTxIDMutex.RUnlock()
 	fake.getBlockchainInfoMutex.RLock()
 	defer fake.getBlockchainInfoMutex.RUnlock()
+	fake.getBlockchainInfoExtendedMutex.RLock()
+	defer fake.getBlockchainInfoExtendedMutex.RUnlock()
 	fake.getBlocksIteratorMutex.RLock()
 	defer fake.getBlocksIteratorMutex.RUnlock()
 	fake.getConfigHistoryRetrieverMutex.RLock()
 	defer fake.getConfigHistoryRetrieverMutex.RUnlock()
+	fake.getLastBlockMutex.RLock()
+	defer fake.getLastBlockMutex.RUnlock()
 	fake.getMissingPvtDataTrackerMutex.RLock()
 	defer fake.getMissingPvtDataTrackerMutex.RUnlock()
+	fake.getMostRecentConfigBlockMutex.RLock()
+	defer fake.getMostRecentConfigBlockMutex.RUnlock()
 	fake.getPvtDataAndBlockByNumMutex.RLock()
 	defer fake.getPvtDataAndBlockByNumMutex.RUnlock()
 	fake.getPvtDataByNumMutex.RLock()
 	defer fake.getPvtDataByNumMutex.RUnlock()
+	fake.getPvtdataReconciliationStatusMutex.RLock()
+	defer fake.getPvtdataReconciliationStatusMutex.RUnlock()
+	fake.getPvtdataScheduledForPurgeMutex.RLock()
+	defer fake.getPvtdataScheduledForPurgeMutex.RUnlock()
 	fake.getTransactionByIDMutex.RLock()
 	defer fake.getTransactionByIDMutex.RUnlock()
+	fake.getTransactionsByIDsMutex.RLock()
+	defer fake.getTransactionsByIDsMutex.RUnlock()
 	fake.getTxValidationCodeByTxIDMutex.RLock()
 	defer fake.getTxValidationCodeByTxIDMutex.RUnlock()
+	fake.historyDBSavepointMutex.RLock()
+	defer fake.historyDBSavepointMutex.RUnlock()
+	fake.historyRebuildStatusMutex.RLock()
+	defer fake.historyRebuildStatusMutex.RUnlock()
+	fake.missingPvtDataInfoMutex.RLock()
+	defer fake.missingPvtDataInfoMutex.RUnlock()
 	fake.newHistoryQueryExecutorMutex.RLock()
 	defer fake.newHistoryQueryExecutorMutex.RUnlock()
 	fake.newQueryExecutorMutex.RLock()
 	defer fake.newQueryExecutorMutex.RUnlock()
 	fake.newTxSimulatorMutex.RLock()
 	defer fake.newTxSimulatorMutex.RUnlock()
+	fake.newTxSimulatorAtHeightMutex.RLock()
+	defer fake.newTxSimulatorAtHeightMutex.RUnlock()
 	fake.pendingSnapshotRequestsMutex.RLock()
 	defer fake.pendingSnapshotRequestsMutex.RUnlock()
+	fake.previewCommitMutex.RLock()
+	defer fake.previewCommitMutex.RUnlock()
+	fake.pruneBlocksMutex.RLock()
+	defer fake.pruneBlocksMutex.RUnlock()
+	fake.registerCommitListenerMutex.RLock()
+	defer fake.registerCommitListenerMutex.RUnlock()
+	fake.registerPvtdataPurgeListenerMutex.RLock()
+	defer fake.registerPvtdataPurgeListenerMutex.RUnlock()
+	fake.rollbackHistoryDBMutex.RLock()
+	defer fake.rollbackHistoryDBMutex.RUnlock()
 	fake.submitSnapshotRequestMutex.RLock()
 	defer fake.submitSnapshotRequestMutex.RUnlock()
 	fake.txIDExistsMutex.RLock()
 	defer fake.txIDExistsMutex.RUnlock()
+	fake.txIDsByChaincodeNameMutex.RLock()
+	defer fake.txIDsByChaincodeNameMutex.RUnlock()
+	fake.txIDsByEndorserMSPIDMutex.RLock()
+	defer fake.txIDsByEndorserMSPIDMutex.RUnlock()
+	fake.txIDsInRangeMutex.RLock()
+	defer fake.txIDsInRangeMutex.RUnlock()
+	fake.unfreezeMutex.RLock()
+	defer fake.unfreezeMutex.RUnlock()
+	fake.updatePvtDataConfigMutex.RLock()
+	defer fake.updatePvtDataConfigMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -8,10 +8,19 @@ package node
 
 import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/internal/peer/common"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
+var namespace string
+
 func rebuildDBsCmd() *cobra.Command {
+	nodeRebuildCmd.ResetFlags()
+	flags := nodeRebuildCmd.Flags()
+	flags.StringVarP(&channelID, "channelID", "c", common.UndefinedParamValue, "Channel of the namespace to rebuild.")
+	flags.StringVarP(&namespace, "namespace", "n", common.UndefinedParamValue, "Namespace (chaincode) to rebuild.")
+
 	return nodeRebuildCmd
 }
 
@@ -20,9 +29,21 @@ var nodeRebuildCmd = &cobra.Command{
 	Short: "Rebuilds databases.",
 	Long: "Drops the databases for all the channels and rebuilds them upon peer restart." +
 		" When the command is executed, the peer must be offline." +
-		" The command is not supported if the peer contains any channel that was bootstrapped from a snapshot.",
+		" The command is not supported if the peer contains any channel that was bootstrapped from a snapshot." +
+		" If the rebuild of any channel is still in progress, the databases are not dropped again and the rebuild resumes upon peer restart." +
+		" When a channel and a namespace are supplied, only the data of the namespace in the state database and" +
+		" the history database of the channel is dropped and rebuilt upon peer restart.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		config := ledgerConfig()
-		return kvledger.RebuildDBs(config)
+		if channelID == common.UndefinedParamValue && namespace == common.UndefinedParamValue {
+			return kvledger.RebuildDBs(config)
+		}
+		if channelID == common.UndefinedParamValue {
+			return errors.New("Must supply channel ID along with the namespace")
+		}
+		if namespace == common.UndefinedParamValue {
+			return errors.New("Must supply namespace along with the channel ID")
+		}
+		return kvledger.RebuildNamespace(config, channelID, namespace)
 	},
 }
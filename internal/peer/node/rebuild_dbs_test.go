@@ -26,3 +26,26 @@ func TestRebuildDBsCmd(t *testing.T) {
 	// this should return an error as no ledger has been set up
 	require.Contains(t, err.Error(), "error while checking if any ledger has been bootstrapped from snapshot")
 }
+
+func TestRebuildNamespaceCmd(t *testing.T) {
+	testPath := "/tmp/hyperledger/test"
+	os.RemoveAll(testPath)
+	viper.Set("peer.fileSystemPath", testPath)
+	defer os.RemoveAll(testPath)
+
+	cmd := rebuildDBsCmd()
+	cmd.SetArgs([]string{"-n", "mycc"})
+	err := cmd.Execute()
+	require.EqualError(t, err, "Must supply channel ID along with the namespace")
+
+	cmd = rebuildDBsCmd()
+	cmd.SetArgs([]string{"-c", "mychannel"})
+	err = cmd.Execute()
+	require.EqualError(t, err, "Must supply namespace along with the channel ID")
+
+	cmd = rebuildDBsCmd()
+	cmd.SetArgs([]string{"-c", "mychannel", "-n", "mycc"})
+	err = cmd.Execute()
+	// this should return an error as no ledger has been set up
+	require.Contains(t, err.Error(), "error while checking if any ledger has been bootstrapped from snapshot")
+}
@@ -37,6 +37,7 @@ import (
 	floggingmetrics "github.com/hyperledger/fabric/common/flogging/metrics"
 	"github.com/hyperledger/fabric/common/grpclogging"
 	"github.com/hyperledger/fabric/common/grpcmetrics"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/common/metadata"
 	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/policies"
@@ -273,8 +274,18 @@ func serve(args []string) error {
 		cs.SetClientCertificate(clientCert)
 	}
 
-	transientStoreProvider, err := transientstore.NewStoreProvider(
+	ledgerConf := ledgerConfig()
+	transientStoreProvider, err := transientstore.NewStoreProviderWithConfig(
 		filepath.Join(coreconfig.GetPath("peer.fileSystemPath"), "transientstore"),
+		&transientstore.Config{
+			MaxAge:          viper.GetDuration("peer.gossip.pvtData.transientstoreMaxAge"),
+			MaxSize:         uint64(viper.GetInt64("peer.gossip.pvtData.transientstoreMaxSize")),
+			MetricsProvider: metricsProvider,
+			OpenRetry: leveldbhelper.OpenRetry{
+				Retries:  ledgerConf.LevelDBOpenRetries,
+				Interval: ledgerConf.LevelDBOpenRetryInterval,
+			},
+		},
 	)
 	if err != nil {
 		return errors.WithMessage(err, "failed to open transient store")
@@ -435,9 +446,10 @@ func serve(args []string) error {
 			MetricsProvider:                 metricsProvider,
 			HealthCheckRegistry:             opsSystem,
 			StateListeners:                  []ledger.StateListener{lifecycleCache},
-			Config:                          ledgerConfig(),
+			Config:                          ledgerConf,
 			HashProvider:                    factory.GetDefault(),
 			EbMetadataProvider:              ebMetadataProvider,
+			LedgerIDValidator:               kvledger.ValidateLedgerID,
 		},
 	)
 
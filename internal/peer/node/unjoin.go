@@ -8,8 +8,10 @@ package node
 
 import (
 	"path/filepath"
+	"strings"
 
 	coreconfig "github.com/hyperledger/fabric/core/config"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger"
 	"github.com/hyperledger/fabric/core/transientstore"
 	"github.com/hyperledger/fabric/internal/peer/common"
@@ -23,7 +25,8 @@ func unjoinCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "unjoin",
 		Short: "Unjoin the peer from a channel.",
-		Long:  "Unjoin the peer from a channel.  When the command is executed, the peer must be offline.",
+		Long: "Unjoin the peer from a channel.  When the command is executed, the peer must be offline." +
+			"  After the removal, the command verifies that no data of the channel remains in the peer's stores.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if channelID == common.UndefinedParamValue {
 				return errors.New("Must supply channel ID")
@@ -58,5 +61,31 @@ func unjoinChannel(channelID string) error {
 		return err
 	}
 
+	return verifyChannelRemoved(config, transientStoragePath, channelID)
+}
+
+// verifyChannelRemoved checks that no data of the unjoined channel is left behind in the ledger stores and
+// in the transient storage, and returns an error that lists the stores that still hold data of the channel
+func verifyChannelRemoved(config *ledger.Config, transientStoragePath, channelID string) error {
+	report, err := kvledger.VerifyChannelRemoved(config, channelID)
+	if err != nil {
+		return err
+	}
+	exists, err := transientstore.Exists(transientStoragePath, channelID)
+	if err != nil {
+		return err
+	}
+	report.Add("transientStore", exists)
+
+	if !report.StateDBChecked {
+		logger.Warnf("The removal of the data of channel [%s] from the state database cannot be verified", channelID)
+	}
+	if !report.Complete() {
+		return errors.Errorf("channel [%s] has been unjoined but its data remains in [%s]",
+			channelID, strings.Join(report.RemainingData(), ", "))
+	}
+	for _, s := range report.Stores {
+		logger.Infof("Verified that the data of channel [%s] has been removed from %s", channelID, s.Store)
+	}
 	return nil
 }